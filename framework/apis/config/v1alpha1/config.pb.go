@@ -99,7 +99,9 @@ type Global struct {
 	IstioConfigSource *ConfigSource `protobuf:"bytes,14,opt,name=istioConfigSource,proto3" json:"istioConfigSource,omitempty"`
 	// deploy rev specified by user, used to define the deploy version of slime
 	DeployRev string `protobuf:"bytes,15,opt,name=deployRev,proto3" json:"deployRev,omitempty"`
-	// enable/disable convert serviceentry to istio res when configsource is specified
+	// enable/disable convert serviceentry to istio res when configsource is specified.
+	// The serviceentries are always kept in the service registry of the config controller,
+	// this also adds the k8s services to it and mirrors the registry to the istio res.
 	EnableConvertSeToIstioRes bool `protobuf:"varint,16,opt,name=enableConvertSeToIstioRes,proto3" json:"enableConvertSeToIstioRes,omitempty"`
 }
 
@@ -517,6 +519,73 @@ func (x *K8S_Source) GetHandlers() []string {
 	return nil
 }
 
+// Otlp_Source receives the metrics pushed by OTLP exporters and serves the queries from memory.
+type Otlp_Source struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// listen address of the OTLP grpc metrics service, like ":4317"
+	Address string `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	// the queries in the form of a PromQL subset, see framework/model/metric/otlp_query.go
+	Handlers map[string]*Prometheus_Source_Handler `protobuf:"bytes,2,rep,name=handlers,proto3" json:"handlers,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// how long the samples are kept in memory, like "10m", at least 1s. Defaults to 10m.
+	Retention string `protobuf:"bytes,3,opt,name=retention,proto3" json:"retention,omitempty"`
+}
+
+func (x *Otlp_Source) Reset() {
+	*x = Otlp_Source{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Otlp_Source) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Otlp_Source) ProtoMessage() {}
+
+func (x *Otlp_Source) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Otlp_Source.ProtoReflect.Descriptor instead.
+func (*Otlp_Source) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Otlp_Source) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *Otlp_Source) GetHandlers() map[string]*Prometheus_Source_Handler {
+	if x != nil {
+		return x.Handlers
+	}
+	return nil
+}
+
+func (x *Otlp_Source) GetRetention() string {
+	if x != nil {
+		return x.Retention
+	}
+	return ""
+}
+
 type Metric struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -524,12 +593,13 @@ type Metric struct {
 
 	Prometheus *Prometheus_Source `protobuf:"bytes,1,opt,name=prometheus,proto3" json:"prometheus,omitempty"`
 	K8S        *K8S_Source        `protobuf:"bytes,2,opt,name=k8s,proto3" json:"k8s,omitempty"`
+	Otlp       *Otlp_Source       `protobuf:"bytes,3,opt,name=otlp,proto3" json:"otlp,omitempty"`
 }
 
 func (x *Metric) Reset() {
 	*x = Metric{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_config_proto_msgTypes[5]
+		mi := &file_config_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -542,7 +612,7 @@ func (x *Metric) String() string {
 func (*Metric) ProtoMessage() {}
 
 func (x *Metric) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[5]
+	mi := &file_config_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -555,7 +625,7 @@ func (x *Metric) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Metric.ProtoReflect.Descriptor instead.
 func (*Metric) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{5}
+	return file_config_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *Metric) GetPrometheus() *Prometheus_Source {
@@ -572,6 +642,13 @@ func (x *Metric) GetK8S() *K8S_Source {
 	return nil
 }
 
+func (x *Metric) GetOtlp() *Otlp_Source {
+	if x != nil {
+		return x.Otlp
+	}
+	return nil
+}
+
 // +kubebuilder:pruning:PreserveUnknownFields
 type General struct {
 	state         protoimpl.MessageState
@@ -582,7 +659,7 @@ type General struct {
 func (x *General) Reset() {
 	*x = General{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_config_proto_msgTypes[6]
+		mi := &file_config_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -595,7 +672,7 @@ func (x *General) String() string {
 func (*General) ProtoMessage() {}
 
 func (x *General) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[6]
+	mi := &file_config_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -608,7 +685,7 @@ func (x *General) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use General.ProtoReflect.Descriptor instead.
 func (*General) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{6}
+	return file_config_proto_rawDescGZIP(), []int{7}
 }
 
 type Bundle struct {
@@ -622,7 +699,7 @@ type Bundle struct {
 func (x *Bundle) Reset() {
 	*x = Bundle{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_config_proto_msgTypes[7]
+		mi := &file_config_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -635,7 +712,7 @@ func (x *Bundle) String() string {
 func (*Bundle) ProtoMessage() {}
 
 func (x *Bundle) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[7]
+	mi := &file_config_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -648,7 +725,7 @@ func (x *Bundle) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Bundle.ProtoReflect.Descriptor instead.
 func (*Bundle) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{7}
+	return file_config_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *Bundle) GetModules() []*Bundle_Item {
@@ -677,7 +754,7 @@ type Config struct {
 func (x *Config) Reset() {
 	*x = Config{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_config_proto_msgTypes[8]
+		mi := &file_config_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -690,7 +767,7 @@ func (x *Config) String() string {
 func (*Config) ProtoMessage() {}
 
 func (x *Config) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[8]
+	mi := &file_config_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -703,7 +780,7 @@ func (x *Config) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Config.ProtoReflect.Descriptor instead.
 func (*Config) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{8}
+	return file_config_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *Config) GetGlobal() *Global {
@@ -773,13 +850,17 @@ type ConfigSource struct {
 	// Address of the server implementing the Istio Mesh Configuration
 	// protocol (MCP). Can be IP address or a fully qualified DNS name.
 	// Use fs:/// to specify a file-based backend with absolute path to the directory.
+	// Use xds://host:port?failover=host2:port to fail over to the other servers in turn
+	// if the current one keeps failing.
 	Address string `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	// TLS settings of the connection to the xds server, plain text is used if not set.
+	Tls *ConfigSource_TLS `protobuf:"bytes,2,opt,name=tls,proto3" json:"tls,omitempty"`
 }
 
 func (x *ConfigSource) Reset() {
 	*x = ConfigSource{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_config_proto_msgTypes[9]
+		mi := &file_config_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -792,7 +873,7 @@ func (x *ConfigSource) String() string {
 func (*ConfigSource) ProtoMessage() {}
 
 func (x *ConfigSource) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[9]
+	mi := &file_config_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -805,7 +886,7 @@ func (x *ConfigSource) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ConfigSource.ProtoReflect.Descriptor instead.
 func (*ConfigSource) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{9}
+	return file_config_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *ConfigSource) GetAddress() string {
@@ -815,6 +896,13 @@ func (x *ConfigSource) GetAddress() string {
 	return ""
 }
 
+func (x *ConfigSource) GetTls() *ConfigSource_TLS {
+	if x != nil {
+		return x.Tls
+	}
+	return nil
+}
+
 type ClientGoTokenBucket struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -829,7 +917,7 @@ type ClientGoTokenBucket struct {
 func (x *ClientGoTokenBucket) Reset() {
 	*x = ClientGoTokenBucket{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_config_proto_msgTypes[10]
+		mi := &file_config_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -842,7 +930,7 @@ func (x *ClientGoTokenBucket) String() string {
 func (*ClientGoTokenBucket) ProtoMessage() {}
 
 func (x *ClientGoTokenBucket) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[10]
+	mi := &file_config_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -855,7 +943,7 @@ func (x *ClientGoTokenBucket) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ClientGoTokenBucket.ProtoReflect.Descriptor instead.
 func (*ClientGoTokenBucket) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{10}
+	return file_config_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *ClientGoTokenBucket) GetQps() int32 {
@@ -884,7 +972,7 @@ type Prometheus_Source_Handler struct {
 func (x *Prometheus_Source_Handler) Reset() {
 	*x = Prometheus_Source_Handler{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_config_proto_msgTypes[12]
+		mi := &file_config_proto_msgTypes[13]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -897,7 +985,7 @@ func (x *Prometheus_Source_Handler) String() string {
 func (*Prometheus_Source_Handler) ProtoMessage() {}
 
 func (x *Prometheus_Source_Handler) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[12]
+	mi := &file_config_proto_msgTypes[13]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -941,7 +1029,7 @@ type Bundle_Item struct {
 func (x *Bundle_Item) Reset() {
 	*x = Bundle_Item{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_config_proto_msgTypes[14]
+		mi := &file_config_proto_msgTypes[16]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -954,7 +1042,7 @@ func (x *Bundle_Item) String() string {
 func (*Bundle_Item) ProtoMessage() {}
 
 func (x *Bundle_Item) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[14]
+	mi := &file_config_proto_msgTypes[16]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -967,7 +1055,7 @@ func (x *Bundle_Item) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Bundle_Item.ProtoReflect.Descriptor instead.
 func (*Bundle_Item) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{7, 0}
+	return file_config_proto_rawDescGZIP(), []int{8, 0}
 }
 
 func (x *Bundle_Item) GetName() string {
@@ -984,6 +1072,74 @@ func (x *Bundle_Item) GetKind() string {
 	return ""
 }
 
+// TLS describes the client side TLS settings of a config source.
+type ConfigSource_TLS struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Directory of the client certificate `cert-chain.pem`, the private key `key.pem` and the CA certificate
+	// `root-cert.pem` used to verify the server. The files are reloaded on each reconnect.
+	CertDir string `protobuf:"bytes,1,opt,name=certDir,proto3" json:"certDir,omitempty"`
+	// The expected SAN of the server certificate, defaults to the host of the address.
+	ServerName string `protobuf:"bytes,2,opt,name=serverName,proto3" json:"serverName,omitempty"`
+	// Skips the verification of the server certificate, for testing only.
+	InsecureSkipVerify bool `protobuf:"varint,3,opt,name=insecureSkipVerify,proto3" json:"insecureSkipVerify,omitempty"`
+}
+
+func (x *ConfigSource_TLS) Reset() {
+	*x = ConfigSource_TLS{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ConfigSource_TLS) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfigSource_TLS) ProtoMessage() {}
+
+func (x *ConfigSource_TLS) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfigSource_TLS.ProtoReflect.Descriptor instead.
+func (*ConfigSource_TLS) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{10, 0}
+}
+
+func (x *ConfigSource_TLS) GetCertDir() string {
+	if x != nil {
+		return x.CertDir
+	}
+	return ""
+}
+
+func (x *ConfigSource_TLS) GetServerName() string {
+	if x != nil {
+		return x.ServerName
+	}
+	return ""
+}
+
+func (x *ConfigSource_TLS) GetInsecureSkipVerify() bool {
+	if x != nil {
+		return x.InsecureSkipVerify
+	}
+	return false
+}
+
 var File_config_proto protoreflect.FileDescriptor
 
 var file_config_proto_rawDesc = []byte{
@@ -1092,53 +1248,84 @@ var file_config_proto_rawDesc = []byte{
 	0x0a, 0x05, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x10, 0x01, 0x22, 0x28, 0x0a, 0x0a, 0x4b, 0x38, 0x53,
 	0x5f, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x68, 0x61, 0x6e, 0x64, 0x6c,
 	0x65, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x68, 0x61, 0x6e, 0x64, 0x6c,
-	0x65, 0x72, 0x73, 0x22, 0x87, 0x01, 0x0a, 0x06, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x12, 0x48,
-	0x0a, 0x0a, 0x70, 0x72, 0x6f, 0x6d, 0x65, 0x74, 0x68, 0x65, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x28, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69,
-	0x67, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x50, 0x72, 0x6f, 0x6d, 0x65,
-	0x74, 0x68, 0x65, 0x75, 0x73, 0x5f, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x52, 0x0a, 0x70, 0x72,
-	0x6f, 0x6d, 0x65, 0x74, 0x68, 0x65, 0x75, 0x73, 0x12, 0x33, 0x0a, 0x03, 0x6b, 0x38, 0x73, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x63, 0x6f,
-	0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x4b, 0x38,
-	0x53, 0x5f, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x52, 0x03, 0x6b, 0x38, 0x73, 0x22, 0x09, 0x0a,
-	0x07, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x6c, 0x22, 0x76, 0x0a, 0x06, 0x42, 0x75, 0x6e, 0x64,
-	0x6c, 0x65, 0x12, 0x3c, 0x0a, 0x07, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x65, 0x73, 0x18, 0x01, 0x20,
-	0x03, 0x28, 0x0b, 0x32, 0x22, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66,
-	0x69, 0x67, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x42, 0x75, 0x6e, 0x64,
-	0x6c, 0x65, 0x2e, 0x49, 0x74, 0x65, 0x6d, 0x52, 0x07, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x65, 0x73,
-	0x1a, 0x2e, 0x0a, 0x04, 0x49, 0x74, 0x65, 0x6d, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04,
-	0x6b, 0x69, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64,
-	0x22, 0xbb, 0x02, 0x0a, 0x06, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x35, 0x0a, 0x06, 0x67,
-	0x6c, 0x6f, 0x62, 0x61, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x73, 0x6c,
+	0x65, 0x72, 0x73, 0x22, 0x82, 0x02, 0x0a, 0x0b, 0x4f, 0x74, 0x6c, 0x70, 0x5f, 0x53, 0x6f, 0x75,
+	0x72, 0x63, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x4c, 0x0a,
+	0x08, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65, 0x72, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x30, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76,
+	0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x4f, 0x74, 0x6c, 0x70, 0x5f, 0x53, 0x6f, 0x75,
+	0x72, 0x63, 0x65, 0x2e, 0x48, 0x61, 0x6e, 0x64, 0x6c, 0x65, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x52, 0x08, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65, 0x72, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x72,
+	0x65, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
+	0x72, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e, 0x1a, 0x6d, 0x0a, 0x0d, 0x48, 0x61, 0x6e,
+	0x64, 0x6c, 0x65, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65,
+	0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x46, 0x0a, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x30, 0x2e, 0x73, 0x6c,
 	0x69, 0x6d, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70,
-	0x68, 0x61, 0x31, 0x2e, 0x47, 0x6c, 0x6f, 0x62, 0x61, 0x6c, 0x52, 0x06, 0x67, 0x6c, 0x6f, 0x62,
-	0x61, 0x6c, 0x12, 0x35, 0x0a, 0x06, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x18, 0x06, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69,
-	0x67, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69,
-	0x63, 0x52, 0x06, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d,
-	0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a,
-	0x06, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x65,
-	0x6e, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x38, 0x0a, 0x07, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x6c,
-	0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x63,
-	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x47,
-	0x65, 0x6e, 0x65, 0x72, 0x61, 0x6c, 0x52, 0x07, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x6c, 0x12,
-	0x35, 0x0a, 0x06, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x68, 0x61, 0x31, 0x2e, 0x50, 0x72, 0x6f, 0x6d, 0x65, 0x74, 0x68, 0x65, 0x75, 0x73, 0x5f, 0x53,
+	0x6f, 0x75, 0x72, 0x63, 0x65, 0x2e, 0x48, 0x61, 0x6e, 0x64, 0x6c, 0x65, 0x72, 0x52, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xbf, 0x01, 0x0a, 0x06, 0x4d, 0x65, 0x74,
+	0x72, 0x69, 0x63, 0x12, 0x48, 0x0a, 0x0a, 0x70, 0x72, 0x6f, 0x6d, 0x65, 0x74, 0x68, 0x65, 0x75,
+	0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x28, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e,
+	0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e,
+	0x50, 0x72, 0x6f, 0x6d, 0x65, 0x74, 0x68, 0x65, 0x75, 0x73, 0x5f, 0x53, 0x6f, 0x75, 0x72, 0x63,
+	0x65, 0x52, 0x0a, 0x70, 0x72, 0x6f, 0x6d, 0x65, 0x74, 0x68, 0x65, 0x75, 0x73, 0x12, 0x33, 0x0a,
+	0x03, 0x6b, 0x38, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x73, 0x6c, 0x69,
+	0x6d, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68,
+	0x61, 0x31, 0x2e, 0x4b, 0x38, 0x53, 0x5f, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x52, 0x03, 0x6b,
+	0x38, 0x73, 0x12, 0x36, 0x0a, 0x04, 0x6f, 0x74, 0x6c, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x22, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e,
+	0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x4f, 0x74, 0x6c, 0x70, 0x5f, 0x53, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x52, 0x04, 0x6f, 0x74, 0x6c, 0x70, 0x22, 0x09, 0x0a, 0x07, 0x47, 0x65,
+	0x6e, 0x65, 0x72, 0x61, 0x6c, 0x22, 0x76, 0x0a, 0x06, 0x42, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x12,
+	0x3c, 0x0a, 0x07, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x22, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e,
+	0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x42, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x2e,
+	0x49, 0x74, 0x65, 0x6d, 0x52, 0x07, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x65, 0x73, 0x1a, 0x2e, 0x0a,
+	0x04, 0x49, 0x74, 0x65, 0x6d, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6b, 0x69, 0x6e,
+	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x22, 0xbb, 0x02,
+	0x0a, 0x06, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x35, 0x0a, 0x06, 0x67, 0x6c, 0x6f, 0x62,
+	0x61, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65,
+	0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31,
+	0x2e, 0x47, 0x6c, 0x6f, 0x62, 0x61, 0x6c, 0x52, 0x06, 0x67, 0x6c, 0x6f, 0x62, 0x61, 0x6c, 0x12,
+	0x35, 0x0a, 0x06, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32,
 	0x1d, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76,
-	0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x42, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x52, 0x06,
-	0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x0a,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6b, 0x69,
-	0x6e, 0x64, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x22, 0x28,
-	0x0a, 0x0c, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x18,
-	0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x22, 0x3d, 0x0a, 0x13, 0x43, 0x6c, 0x69, 0x65,
-	0x6e, 0x74, 0x47, 0x6f, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x12,
-	0x10, 0x0a, 0x03, 0x71, 0x70, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03, 0x71, 0x70,
-	0x73, 0x12, 0x14, 0x0a, 0x05, 0x62, 0x75, 0x72, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05,
-	0x52, 0x05, 0x62, 0x75, 0x72, 0x73, 0x74, 0x42, 0x2f, 0x5a, 0x2d, 0x73, 0x6c, 0x69, 0x6d, 0x65,
-	0x2e, 0x69, 0x6f, 0x2f, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2f, 0x66, 0x72, 0x61, 0x6d, 0x65, 0x77,
-	0x6f, 0x72, 0x6b, 0x2f, 0x61, 0x70, 0x69, 0x73, 0x2f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2f,
-	0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x52, 0x06,
+	0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x65, 0x6e,
+	0x61, 0x62, 0x6c, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x65, 0x6e, 0x61, 0x62,
+	0x6c, 0x65, 0x12, 0x38, 0x0a, 0x07, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x6c, 0x18, 0x08, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66,
+	0x69, 0x67, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x47, 0x65, 0x6e, 0x65,
+	0x72, 0x61, 0x6c, 0x52, 0x07, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x6c, 0x12, 0x35, 0x0a, 0x06,
+	0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x73,
+	0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31, 0x61, 0x6c,
+	0x70, 0x68, 0x61, 0x31, 0x2e, 0x42, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x52, 0x06, 0x62, 0x75, 0x6e,
+	0x64, 0x6c, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18,
+	0x0b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x22, 0xd4, 0x01, 0x0a, 0x0c,
+	0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x18, 0x0a, 0x07,
+	0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61,
+	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x39, 0x0a, 0x03, 0x74, 0x6c, 0x73, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x27, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66,
+	0x69, 0x67, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x43, 0x6f, 0x6e, 0x66,
+	0x69, 0x67, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x2e, 0x54, 0x4c, 0x53, 0x52, 0x03, 0x74, 0x6c,
+	0x73, 0x1a, 0x6f, 0x0a, 0x03, 0x54, 0x4c, 0x53, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x65, 0x72, 0x74,
+	0x44, 0x69, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x65, 0x72, 0x74, 0x44,
+	0x69, 0x72, 0x12, 0x1e, 0x0a, 0x0a, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x4e, 0x61,
+	0x6d, 0x65, 0x12, 0x2e, 0x0a, 0x12, 0x69, 0x6e, 0x73, 0x65, 0x63, 0x75, 0x72, 0x65, 0x53, 0x6b,
+	0x69, 0x70, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x12,
+	0x69, 0x6e, 0x73, 0x65, 0x63, 0x75, 0x72, 0x65, 0x53, 0x6b, 0x69, 0x70, 0x56, 0x65, 0x72, 0x69,
+	0x66, 0x79, 0x22, 0x3d, 0x0a, 0x13, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x47, 0x6f, 0x54, 0x6f,
+	0x6b, 0x65, 0x6e, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x71, 0x70, 0x73,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03, 0x71, 0x70, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x62,
+	0x75, 0x72, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x62, 0x75, 0x72, 0x73,
+	0x74, 0x42, 0x2f, 0x5a, 0x2d, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x69, 0x6f, 0x2f, 0x73, 0x6c,
+	0x69, 0x6d, 0x65, 0x2f, 0x66, 0x72, 0x61, 0x6d, 0x65, 0x77, 0x6f, 0x72, 0x6b, 0x2f, 0x61, 0x70,
+	0x69, 0x73, 0x2f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2f, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68,
+	0x61, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -1154,7 +1341,7 @@ func file_config_proto_rawDescGZIP() []byte {
 }
 
 var file_config_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_config_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_config_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
 var file_config_proto_goTypes = []interface{}{
 	(Prometheus_Source_Type)(0),       // 0: slime.config.v1alpha1.Prometheus_Source.Type
 	(*Global)(nil),                    // 1: slime.config.v1alpha1.Global
@@ -1162,39 +1349,46 @@ var file_config_proto_goTypes = []interface{}{
 	(*LogRotateConfig)(nil),           // 3: slime.config.v1alpha1.LogRotateConfig
 	(*Prometheus_Source)(nil),         // 4: slime.config.v1alpha1.Prometheus_Source
 	(*K8S_Source)(nil),                // 5: slime.config.v1alpha1.K8S_Source
-	(*Metric)(nil),                    // 6: slime.config.v1alpha1.Metric
-	(*General)(nil),                   // 7: slime.config.v1alpha1.General
-	(*Bundle)(nil),                    // 8: slime.config.v1alpha1.Bundle
-	(*Config)(nil),                    // 9: slime.config.v1alpha1.Config
-	(*ConfigSource)(nil),              // 10: slime.config.v1alpha1.ConfigSource
-	(*ClientGoTokenBucket)(nil),       // 11: slime.config.v1alpha1.ClientGoTokenBucket
-	nil,                               // 12: slime.config.v1alpha1.Global.MiscEntry
-	(*Prometheus_Source_Handler)(nil), // 13: slime.config.v1alpha1.Prometheus_Source.Handler
-	nil,                               // 14: slime.config.v1alpha1.Prometheus_Source.HandlersEntry
-	(*Bundle_Item)(nil),               // 15: slime.config.v1alpha1.Bundle.Item
+	(*Otlp_Source)(nil),               // 6: slime.config.v1alpha1.Otlp_Source
+	(*Metric)(nil),                    // 7: slime.config.v1alpha1.Metric
+	(*General)(nil),                   // 8: slime.config.v1alpha1.General
+	(*Bundle)(nil),                    // 9: slime.config.v1alpha1.Bundle
+	(*Config)(nil),                    // 10: slime.config.v1alpha1.Config
+	(*ConfigSource)(nil),              // 11: slime.config.v1alpha1.ConfigSource
+	(*ClientGoTokenBucket)(nil),       // 12: slime.config.v1alpha1.ClientGoTokenBucket
+	nil,                               // 13: slime.config.v1alpha1.Global.MiscEntry
+	(*Prometheus_Source_Handler)(nil), // 14: slime.config.v1alpha1.Prometheus_Source.Handler
+	nil,                               // 15: slime.config.v1alpha1.Prometheus_Source.HandlersEntry
+	nil,                               // 16: slime.config.v1alpha1.Otlp_Source.HandlersEntry
+	(*Bundle_Item)(nil),               // 17: slime.config.v1alpha1.Bundle.Item
+	(*ConfigSource_TLS)(nil),          // 18: slime.config.v1alpha1.ConfigSource.TLS
 }
 var file_config_proto_depIdxs = []int32{
 	2,  // 0: slime.config.v1alpha1.Global.log:type_name -> slime.config.v1alpha1.Log
-	12, // 1: slime.config.v1alpha1.Global.misc:type_name -> slime.config.v1alpha1.Global.MiscEntry
-	10, // 2: slime.config.v1alpha1.Global.configSources:type_name -> slime.config.v1alpha1.ConfigSource
-	11, // 3: slime.config.v1alpha1.Global.clientGoTokenBucket:type_name -> slime.config.v1alpha1.ClientGoTokenBucket
-	10, // 4: slime.config.v1alpha1.Global.istioConfigSource:type_name -> slime.config.v1alpha1.ConfigSource
+	13, // 1: slime.config.v1alpha1.Global.misc:type_name -> slime.config.v1alpha1.Global.MiscEntry
+	11, // 2: slime.config.v1alpha1.Global.configSources:type_name -> slime.config.v1alpha1.ConfigSource
+	12, // 3: slime.config.v1alpha1.Global.clientGoTokenBucket:type_name -> slime.config.v1alpha1.ClientGoTokenBucket
+	11, // 4: slime.config.v1alpha1.Global.istioConfigSource:type_name -> slime.config.v1alpha1.ConfigSource
 	3,  // 5: slime.config.v1alpha1.Log.logRotateConfig:type_name -> slime.config.v1alpha1.LogRotateConfig
-	14, // 6: slime.config.v1alpha1.Prometheus_Source.handlers:type_name -> slime.config.v1alpha1.Prometheus_Source.HandlersEntry
-	4,  // 7: slime.config.v1alpha1.Metric.prometheus:type_name -> slime.config.v1alpha1.Prometheus_Source
-	5,  // 8: slime.config.v1alpha1.Metric.k8s:type_name -> slime.config.v1alpha1.K8S_Source
-	15, // 9: slime.config.v1alpha1.Bundle.modules:type_name -> slime.config.v1alpha1.Bundle.Item
-	1,  // 10: slime.config.v1alpha1.Config.global:type_name -> slime.config.v1alpha1.Global
-	6,  // 11: slime.config.v1alpha1.Config.metric:type_name -> slime.config.v1alpha1.Metric
-	7,  // 12: slime.config.v1alpha1.Config.general:type_name -> slime.config.v1alpha1.General
-	8,  // 13: slime.config.v1alpha1.Config.bundle:type_name -> slime.config.v1alpha1.Bundle
-	0,  // 14: slime.config.v1alpha1.Prometheus_Source.Handler.type:type_name -> slime.config.v1alpha1.Prometheus_Source.Type
-	13, // 15: slime.config.v1alpha1.Prometheus_Source.HandlersEntry.value:type_name -> slime.config.v1alpha1.Prometheus_Source.Handler
-	16, // [16:16] is the sub-list for method output_type
-	16, // [16:16] is the sub-list for method input_type
-	16, // [16:16] is the sub-list for extension type_name
-	16, // [16:16] is the sub-list for extension extendee
-	0,  // [0:16] is the sub-list for field type_name
+	15, // 6: slime.config.v1alpha1.Prometheus_Source.handlers:type_name -> slime.config.v1alpha1.Prometheus_Source.HandlersEntry
+	16, // 7: slime.config.v1alpha1.Otlp_Source.handlers:type_name -> slime.config.v1alpha1.Otlp_Source.HandlersEntry
+	4,  // 8: slime.config.v1alpha1.Metric.prometheus:type_name -> slime.config.v1alpha1.Prometheus_Source
+	5,  // 9: slime.config.v1alpha1.Metric.k8s:type_name -> slime.config.v1alpha1.K8S_Source
+	6,  // 10: slime.config.v1alpha1.Metric.otlp:type_name -> slime.config.v1alpha1.Otlp_Source
+	17, // 11: slime.config.v1alpha1.Bundle.modules:type_name -> slime.config.v1alpha1.Bundle.Item
+	1,  // 12: slime.config.v1alpha1.Config.global:type_name -> slime.config.v1alpha1.Global
+	7,  // 13: slime.config.v1alpha1.Config.metric:type_name -> slime.config.v1alpha1.Metric
+	8,  // 14: slime.config.v1alpha1.Config.general:type_name -> slime.config.v1alpha1.General
+	9,  // 15: slime.config.v1alpha1.Config.bundle:type_name -> slime.config.v1alpha1.Bundle
+	18, // 16: slime.config.v1alpha1.ConfigSource.tls:type_name -> slime.config.v1alpha1.ConfigSource.TLS
+	0,  // 17: slime.config.v1alpha1.Prometheus_Source.Handler.type:type_name -> slime.config.v1alpha1.Prometheus_Source.Type
+	14, // 18: slime.config.v1alpha1.Prometheus_Source.HandlersEntry.value:type_name -> slime.config.v1alpha1.Prometheus_Source.Handler
+	14, // 19: slime.config.v1alpha1.Otlp_Source.HandlersEntry.value:type_name -> slime.config.v1alpha1.Prometheus_Source.Handler
+	20, // [20:20] is the sub-list for method output_type
+	20, // [20:20] is the sub-list for method input_type
+	20, // [20:20] is the sub-list for extension type_name
+	20, // [20:20] is the sub-list for extension extendee
+	0,  // [0:20] is the sub-list for field type_name
 }
 
 func init() { file_config_proto_init() }
@@ -1264,7 +1458,7 @@ func file_config_proto_init() {
 			}
 		}
 		file_config_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Metric); i {
+			switch v := v.(*Otlp_Source); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1276,7 +1470,7 @@ func file_config_proto_init() {
 			}
 		}
 		file_config_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*General); i {
+			switch v := v.(*Metric); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1288,7 +1482,7 @@ func file_config_proto_init() {
 			}
 		}
 		file_config_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Bundle); i {
+			switch v := v.(*General); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1300,7 +1494,7 @@ func file_config_proto_init() {
 			}
 		}
 		file_config_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Config); i {
+			switch v := v.(*Bundle); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1312,7 +1506,7 @@ func file_config_proto_init() {
 			}
 		}
 		file_config_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ConfigSource); i {
+			switch v := v.(*Config); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1324,6 +1518,18 @@ func file_config_proto_init() {
 			}
 		}
 		file_config_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ConfigSource); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*ClientGoTokenBucket); i {
 			case 0:
 				return &v.state
@@ -1335,7 +1541,7 @@ func file_config_proto_init() {
 				return nil
 			}
 		}
-		file_config_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+		file_config_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Prometheus_Source_Handler); i {
 			case 0:
 				return &v.state
@@ -1347,7 +1553,7 @@ func file_config_proto_init() {
 				return nil
 			}
 		}
-		file_config_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+		file_config_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Bundle_Item); i {
 			case 0:
 				return &v.state
@@ -1359,6 +1565,18 @@ func file_config_proto_init() {
 				return nil
 			}
 		}
+		file_config_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ConfigSource_TLS); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -1366,7 +1584,7 @@ func file_config_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_config_proto_rawDesc,
 			NumEnums:      1,
-			NumMessages:   15,
+			NumMessages:   18,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -17,6 +17,17 @@ func (this *SlimeBootStatus) UnmarshalJSON(b []byte) error {
 	return SlimeBootUnmarshaler.Unmarshal(bytes.NewReader(b), this)
 }
 
+// MarshalJSON is a custom marshaler for ModuleStatus
+func (this *ModuleStatus) MarshalJSON() ([]byte, error) {
+	str, err := SlimeBootMarshaler.MarshalToString(this)
+	return []byte(str), err
+}
+
+// UnmarshalJSON is a custom unmarshaler for ModuleStatus
+func (this *ModuleStatus) UnmarshalJSON(b []byte) error {
+	return SlimeBootUnmarshaler.Unmarshal(bytes.NewReader(b), this)
+}
+
 // MarshalJSON is a custom marshaler for SlimeBootSpec
 func (this *SlimeBootSpec) MarshalJSON() ([]byte, error) {
 	str, err := SlimeBootMarshaler.MarshalToString(this)
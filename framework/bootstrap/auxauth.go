@@ -0,0 +1,294 @@
+package bootstrap
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	authnv1 "k8s.io/api/authentication/v1"
+	authzv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// misc keys of the auxiliary http server
+	AuxMiscAddr         = "aux-addr"
+	AuxMiscTlsCert      = "aux-tls-cert"
+	AuxMiscTlsKey       = "aux-tls-key"
+	AuxMiscTlsClientCA  = "aux-tls-client-ca"
+	AuxMiscAuth         = "aux-auth"
+	auxAuthCacheTTL     = time.Minute
+	auxAuthCacheMaxSize = 1024
+
+	// VerbRead and VerbWrite are the verbs of the non-resource url used for
+	// the authorization of the read and write requests
+	VerbRead  = "get"
+	VerbWrite = "update"
+)
+
+// AuxServerOptions configures the auxiliary http server.
+type AuxServerOptions struct {
+	Addr string
+	// CertFile and KeyFile enable TLS if both are set
+	CertFile string
+	KeyFile  string
+	// ClientCAFile enables verifying the client certificates, the common name
+	// and organizations of which are used as the user and groups.
+	ClientCAFile string
+	// Auth enables the authentication and authorization of the requests to
+	// the non-public paths. The users are authenticated by the client
+	// certificates or the bearer tokens with TokenReview, and authorized
+	// with SubjectAccessReview of the non-resource url by the request path
+	// and verb `get` for reads or `update` for writes.
+	Auth bool
+}
+
+func NewAuxServerOptions(misc map[string]string) AuxServerOptions {
+	return AuxServerOptions{
+		Addr:         misc[AuxMiscAddr],
+		CertFile:     misc[AuxMiscTlsCert],
+		KeyFile:      misc[AuxMiscTlsKey],
+		ClientCAFile: misc[AuxMiscTlsClientCA],
+		Auth:         misc[AuxMiscAuth] == "on",
+	}
+}
+
+func (o AuxServerOptions) tlsEnabled() bool {
+	return o.CertFile != "" && o.KeyFile != ""
+}
+
+func (o AuxServerOptions) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if o.ClientCAFile == "" {
+		return cfg, nil
+	}
+	caData, err := os.ReadFile(o.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("read client ca %s failed: %v", o.ClientCAFile, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caData) {
+		return nil, fmt.Errorf("no valid certificate in client ca %s", o.ClientCAFile)
+	}
+	cfg.ClientCAs = pool
+	// the probes of kubelet do not present client certificates
+	cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	return cfg, nil
+}
+
+type pathAccess int
+
+const (
+	// accessDefault treats GET, HEAD and OPTIONS requests as reads, and others as writes
+	accessDefault pathAccess = iota
+	accessWrite
+	accessPublic
+)
+
+type accessHandler struct {
+	http.Handler
+	access pathAccess
+}
+
+// WriteHandler marks all the requests to the handler as writes, including the
+// GET ones, for handlers that mutate states on any request.
+func WriteHandler(h http.Handler) http.Handler {
+	return accessHandler{Handler: h, access: accessWrite}
+}
+
+// PublicHandler marks the handler to be served without authentication,
+// like the health probes.
+func PublicHandler(h http.Handler) http.Handler {
+	return accessHandler{Handler: h, access: accessPublic}
+}
+
+func requestVerb(h http.Handler, method string) (string, bool) {
+	access := accessDefault
+	if ah, ok := h.(accessHandler); ok {
+		access = ah.access
+	}
+	switch access {
+	case accessPublic:
+		return "", false
+	case accessWrite:
+		return VerbWrite, true
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return VerbRead, true
+	}
+	return VerbWrite, true
+}
+
+type auxUser struct {
+	name   string
+	uid    string
+	groups []string
+	extra  map[string]authzv1.ExtraValue
+}
+
+type auxAuthCacheEntry struct {
+	user    *auxUser
+	allowed bool
+	expire  time.Time
+}
+
+// auxAuthorizer authenticates and authorizes the requests of the auxiliary
+// http server, and caches the results of the reviews for a while.
+type auxAuthorizer struct {
+	client kubernetes.Interface
+
+	mut        sync.Mutex
+	authnCache map[string]auxAuthCacheEntry
+	authzCache map[string]auxAuthCacheEntry
+}
+
+func newAuxAuthorizer(client kubernetes.Interface) *auxAuthorizer {
+	return &auxAuthorizer{
+		client:     client,
+		authnCache: map[string]auxAuthCacheEntry{},
+		authzCache: map[string]auxAuthCacheEntry{},
+	}
+}
+
+func (a *auxAuthorizer) cached(cache map[string]auxAuthCacheEntry, key string) (auxAuthCacheEntry, bool) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	entry, ok := cache[key]
+	if !ok || time.Now().After(entry.expire) {
+		return entry, false
+	}
+	return entry, true
+}
+
+func (a *auxAuthorizer) cache(cache map[string]auxAuthCacheEntry, key string, entry auxAuthCacheEntry) {
+	now := time.Now()
+	entry.expire = now.Add(auxAuthCacheTTL)
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	if len(cache) >= auxAuthCacheMaxSize {
+		for k, e := range cache {
+			if now.After(e.expire) {
+				delete(cache, k)
+			}
+		}
+	}
+	if len(cache) < auxAuthCacheMaxSize {
+		cache[key] = entry
+	}
+}
+
+// authenticate returns the user of the request, or nil if not authenticated.
+func (a *auxAuthorizer) authenticate(r *http.Request) (*auxUser, error) {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		subject := r.TLS.VerifiedChains[0][0].Subject
+		return &auxUser{name: subject.CommonName, groups: subject.Organization}, nil
+	}
+
+	auth := strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(auth) < len("Bearer ") || !strings.EqualFold(auth[:len("Bearer ")], "Bearer ") {
+		return nil, nil
+	}
+	token := strings.TrimSpace(auth[len("Bearer "):])
+	if token == "" {
+		return nil, nil
+	}
+
+	sum := sha256.Sum256([]byte(token))
+	key := hex.EncodeToString(sum[:])
+	if entry, ok := a.cached(a.authnCache, key); ok {
+		return entry.user, nil
+	}
+
+	review, err := a.client.AuthenticationV1().TokenReviews().Create(r.Context(), &authnv1.TokenReview{
+		Spec: authnv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("token review failed: %v", err)
+	}
+	var user *auxUser
+	if review.Status.Authenticated {
+		info := review.Status.User
+		user = &auxUser{name: info.Username, uid: info.UID, groups: info.Groups}
+		if len(info.Extra) > 0 {
+			user.extra = make(map[string]authzv1.ExtraValue, len(info.Extra))
+			for k, v := range info.Extra {
+				user.extra[k] = authzv1.ExtraValue(v)
+			}
+		}
+	}
+	a.cache(a.authnCache, key, auxAuthCacheEntry{user: user})
+	return user, nil
+}
+
+func (a *auxAuthorizer) authorize(ctx context.Context, user *auxUser, path, verb string) (bool, error) {
+	key := strings.Join([]string{user.name, user.uid, strings.Join(user.groups, ","), path, verb}, "|")
+	if entry, ok := a.cached(a.authzCache, key); ok {
+		return entry.allowed, nil
+	}
+
+	review, err := a.client.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authzv1.SubjectAccessReview{
+		Spec: authzv1.SubjectAccessReviewSpec{
+			User:   user.name,
+			UID:    user.uid,
+			Groups: user.groups,
+			Extra:  user.extra,
+			NonResourceAttributes: &authzv1.NonResourceAttributes{
+				Path: path,
+				Verb: verb,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, fmt.Errorf("subject access review failed: %v", err)
+	}
+	a.cache(a.authzCache, key, auxAuthCacheEntry{allowed: review.Status.Allowed})
+	return review.Status.Allowed, nil
+}
+
+// wrap returns a handler that serves the requests by the PathHandler after
+// authenticating and authorizing them.
+func (a *auxAuthorizer) wrap(ph *PathHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, _ := ph.mux.Handler(r)
+		verb, needAuth := requestVerb(h, r.Method)
+		if !needAuth {
+			ph.mux.ServeHTTP(w, r)
+			return
+		}
+
+		user, err := a.authenticate(r)
+		if err != nil {
+			log.Errorf("authenticate aux request %s %s failed: %v", r.Method, r.URL.Path, err)
+			http.Error(w, "authentication failed", http.StatusInternalServerError)
+			return
+		}
+		if user == nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		allowed, err := a.authorize(r.Context(), user, r.URL.Path, verb)
+		if err != nil {
+			log.Errorf("authorize aux request %s %s of %s failed: %v", r.Method, r.URL.Path, user.name, err)
+			http.Error(w, "authorization failed", http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			log.Warnf("aux request %s %s of %s is forbidden", r.Method, r.URL.Path, user.name)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		ph.mux.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,72 @@
+package bootstrap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	authnv1 "k8s.io/api/authentication/v1"
+	authzv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestAuxAuthorizer(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	var reviews int
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		reviews++
+		review := action.(k8stesting.CreateAction).GetObject().(*authnv1.TokenReview)
+		switch review.Spec.Token {
+		case "reader-token":
+			review.Status = authnv1.TokenReviewStatus{Authenticated: true, User: authnv1.UserInfo{Username: "reader"}}
+		case "admin-token":
+			review.Status = authnv1.TokenReviewStatus{Authenticated: true, User: authnv1.UserInfo{Username: "admin"}}
+		}
+		return true, review, nil
+	})
+	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		sar := action.(k8stesting.CreateAction).GetObject().(*authzv1.SubjectAccessReview)
+		sar.Status.Allowed = sar.Spec.User == "admin" ||
+			(sar.Spec.User == "reader" && sar.Spec.NonResourceAttributes.Verb == VerbRead)
+		return true, sar, nil
+	})
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	ph := NewPathHandler(nil)
+	ph.Handle("/modules/livez", PublicHandler(ok))
+	ph.Handle("/log/slime", ok)
+	ph.Handle("/lazyload/debug/svfReset", WriteHandler(ok))
+	handler := newAuxAuthorizer(client).wrap(ph)
+
+	tests := []struct {
+		method, path, token string
+		code                int
+	}{
+		{http.MethodGet, "/modules/livez", "", http.StatusOK},
+		{http.MethodGet, "/log/slime", "", http.StatusUnauthorized},
+		{http.MethodGet, "/log/slime", "invalid-token", http.StatusUnauthorized},
+		{http.MethodGet, "/log/slime", "reader-token", http.StatusOK},
+		{http.MethodPut, "/log/slime", "reader-token", http.StatusForbidden},
+		{http.MethodGet, "/lazyload/debug/svfReset", "reader-token", http.StatusForbidden},
+		{http.MethodPut, "/log/slime", "admin-token", http.StatusOK},
+		{http.MethodGet, "/lazyload/debug/svfReset", "admin-token", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.code {
+			t.Errorf("%s %s with token %q: expect %d, got %d", tt.method, tt.path, tt.token, tt.code, rec.Code)
+		}
+	}
+
+	// the token reviews are cached
+	if reviews != 3 {
+		t.Errorf("expect 3 token reviews, got %d", reviews)
+	}
+}
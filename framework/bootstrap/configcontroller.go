@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/cenkalti/backoff"
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	log "github.com/sirupsen/logrus"
 	meshconfig "istio.io/api/mesh/v1alpha1"
@@ -14,23 +15,30 @@ import (
 	xdsc "istio.io/istio-mcp/pkg/mcp/xds/client"
 	mcpmodel "istio.io/istio-mcp/pkg/model"
 	"istio.io/libistio/pkg/config/schema/collections"
-	v1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/rest"
 
 	bootconfig "slime.io/slime/framework/apis/config/v1alpha1"
 	"slime.io/slime/framework/bootstrap/adsc"
 	"slime.io/slime/framework/bootstrap/resource"
-	"slime.io/slime/framework/bootstrap/serviceregistry/kube"
-	"slime.io/slime/framework/bootstrap/serviceregistry/model"
-	"slime.io/slime/framework/bootstrap/serviceregistry/serviceentry"
+	"slime.io/slime/framework/bootstrap/serviceregistry/registry"
 	"slime.io/slime/framework/bootstrap/viewstore"
 )
 
 const (
 	KubernetesConfigSourcePrefix = "k8s://"
 	McpOverXdsConfigSourcePrefix = "xds://"
+	FileConfigSourcePrefix       = "fs://"
 )
 
+func init() {
+	RegisterConfigSourceKind(&ConfigSourceKind{
+		Name:    "xds",
+		Prefix:  McpOverXdsConfigSourcePrefix,
+		Schemas: collections.Pilot,
+		Start:   startXdsMonitorController,
+	})
+}
+
 type InitReady interface {
 	InitReady() bool
 }
@@ -40,11 +48,15 @@ type ConfigController interface {
 	RegisterEventHandler(kind resource.GroupVersionKind, handler func(resource.Config, resource.Config, Event))
 	Run(stop <-chan struct{})
 	InitReady
+	// ServiceRegistry returns the registry of the istio services converted from
+	// the ServiceEntries, and the kubernetes Services if EnableConvertSeToIstioRes.
+	ServiceRegistry() *registry.Registry
 }
 
 type configController struct {
 	viewerStore        viewstore.ViewerStore
 	monitorControllers []*monitorController
+	serviceRegistry    *registry.Registry
 	stop               <-chan struct{}
 }
 
@@ -56,11 +68,20 @@ func RunIstioController(c ConfigController, config *bootconfig.Config) (ConfigCo
 	xdsSourceEnableIncPush := config.Global.Misc["xdsSourceEnableIncPush"] == "true"
 	stop := cc.stop
 	for _, mc := range cc.monitorControllers {
-		switch mc.kind {
-		case McpOverXds:
-			if err := startXdsMonitorController(mc, "", xdsSourceEnableIncPush, stop); err != nil {
-				return nil, err
-			}
+		if mc.sourceKind == nil {
+			continue
+		}
+		if mc.sourceKind.NeedRestConfig {
+			log.Warnf("%s config source [%s] is not supported by IstioConfigController",
+				mc.sourceKind.Name, mc.configSource.Address)
+			continue
+		}
+		opts := ConfigSourceOptions{
+			ConfigSource:           mc.configSource,
+			XdsSourceEnableIncPush: xdsSourceEnableIncPush,
+		}
+		if err := mc.sourceKind.Start(mc, opts, stop); err != nil {
+			return nil, err
 		}
 	}
 
@@ -80,21 +101,12 @@ func NewConfigController(configSources []*bootconfig.ConfigSource, stop <-chan s
 	mcs = append(mcs, imc)
 
 	for _, configSource := range configSources {
-		if strings.HasPrefix(configSource.Address, McpOverXdsConfigSourcePrefix) {
-			mc, err := initXdsMonitorController(configSource)
-			if err != nil {
-				return nil, err
-			}
-			mcs = append(mcs, mc)
-		} else if strings.HasPrefix(configSource.Address, KubernetesConfigSourcePrefix) {
-			mc, err := initK8sMonitorController(configSource)
-			if err != nil {
-				return nil, err
-			}
-			mcs = append(mcs, mc)
-		} else {
+		kind := lookupConfigSourceKind(configSource.Address)
+		if kind == nil {
 			log.Warnf("configsource address %s is not supported", configSource.Address)
+			continue
 		}
+		mcs = append(mcs, initMonitorController(kind, configSource))
 	}
 
 	if len(mcs) == 1 {
@@ -109,6 +121,7 @@ func NewConfigController(configSources []*bootconfig.ConfigSource, stop <-chan s
 	cc := &configController{
 		viewerStore:        vs,
 		monitorControllers: mcs,
+		serviceRegistry:    registry.NewRegistry(),
 		stop:               stop,
 	}
 	return cc, nil
@@ -122,240 +135,26 @@ func RunController(c ConfigController, config *bootconfig.Config, cfg *rest.Conf
 
 	var err error
 	imc := cc.monitorControllers[0]
-	vs := cc.viewerStore
 	stop := cc.stop
 	mcs := cc.monitorControllers
 	seLabelSelectorKeys := config.Global.Misc["seLabelSelectorKeys"]
 	configRevision := config.Global.ConfigRev
 	xdsSourceEnableIncPush := config.Global.Misc["xdsSourceEnableIncPush"] == "true"
 
-	// use cc to register handler for istio resources
-	if config.Global.EnableConvertSeToIstioRes {
-		seToIstioResHandler := func(old resource.Config, cfg resource.Config, e Event) {
-			switch e {
-			case EventAdd:
-				istioSvcs, istioEps := serviceentry.ConvertSvcsAndEps(cfg, seLabelSelectorKeys)
-				for _, istioSvc := range istioSvcs {
-					_, err = imc.Create(istioSvc.ConvertConfig())
-					if err != nil {
-						log.Errorf("[seToIstioResHandler] [EventAdd] for IstioService error: %+v", err)
-						return
-					}
-				}
-				for _, istioEp := range istioEps {
-					if _, err = imc.Create(istioEp.ConvertConfig()); err != nil {
-						log.Errorf("[seToIstioResHandler] [EventAdd] for IstioEndpoint error: %+v", err)
-						return
-					}
-				}
-			case EventUpdate:
-				oldIstioSvcs, oldIstioEps := serviceentry.ConvertSvcsAndEps(old, seLabelSelectorKeys)
-				for _, oldIstioSvc := range oldIstioSvcs {
-					c := oldIstioSvc.ConvertConfig()
-					err = imc.Delete(c.GroupVersionKind, c.Name, c.Namespace)
-					if err != nil {
-						log.Errorf("[seToIstioResHandler] [EventUpdate] delete old IstioService error: %+v", err)
-						return
-					}
-				}
-				for _, oldIstioEp := range oldIstioEps {
-					c := oldIstioEp.ConvertConfig()
-					err = imc.Delete(c.GroupVersionKind, c.Name, c.Namespace)
-					if err != nil {
-						log.Errorf("[seToIstioResHandler] [EventUpdate] delete old IstioEndpoint error: %+v", err)
-						return
-					}
-				}
-				newIstioSvcs, newIstioEps := serviceentry.ConvertSvcsAndEps(cfg, seLabelSelectorKeys)
-				for _, newIstioSvc := range newIstioSvcs {
-					_, err = imc.Create(newIstioSvc.ConvertConfig())
-					if err != nil {
-						log.Errorf("[seToIstioResHandler] [EventUpdate] create new IstioService error: %+v", err)
-						return
-					}
-				}
-				for _, newIstioEp := range newIstioEps {
-					_, err = imc.Create(newIstioEp.ConvertConfig())
-					if err != nil {
-						log.Errorf("[seToIstioResHandler] [EventUpdate] create new IstioEndpoint error: %+v", err)
-						return
-					}
-				}
-			case EventDelete:
-				istioSvcs, istioEps := serviceentry.ConvertSvcsAndEps(cfg, seLabelSelectorKeys)
-				for _, istioSvc := range istioSvcs {
-					c := istioSvc.ConvertConfig()
-					err = imc.Delete(c.GroupVersionKind, c.Name, c.Namespace)
-					if err != nil {
-						log.Errorf("[seToIstioResHandler] [EventDelete] for IstioService error: %+v", err)
-						return
-					}
-				}
-				for _, istioEp := range istioEps {
-					c := istioEp.ConvertConfig()
-					err = imc.Delete(c.GroupVersionKind, c.Name, c.Namespace)
-					if err != nil {
-						log.Errorf("[seToIstioResHandler] [EventDelete] for IstioEndpoint error: %+v", err)
-						return
-					}
-				}
-			}
-		}
-
-		svcToIstioResHandler := func(old resource.Config, cfg resource.Config, e Event) {
-			switch e {
-			case EventAdd:
-				service, eps, err := kube.ConvertSvcAndEps(cfg, vs)
-				if err != nil {
-					log.Errorf("[svcToIstioResHandler] [EventAdd] ConvertSvcAndEps error: %+v", err)
-					return
-				}
-				_, err = imc.Create(service.ConvertConfig())
-				if err != nil {
-					log.Errorf("[svcToIstioResHandler] [EventAdd] for service error: %+v", err)
-					return
-				}
-				for _, ep := range eps {
-					if _, err = imc.Create(ep.ConvertConfig()); err != nil {
-						log.Errorf("[svcToIstioResHandler] [EventAdd] for endpoint error: %+v", err)
-						return
-					}
-				}
-			case EventUpdate:
-				oldService, oldEps, err := kube.ConvertSvcAndEps(old, vs)
-				if err != nil {
-					log.Errorf("[svcToIstioResHandler] [EventUpdate] ConvertSvcAndEps error: %+v", err)
-					return
-				}
-				c := oldService.ConvertConfig()
-				err = imc.Delete(c.GroupVersionKind, c.Name, c.Namespace)
-				if err != nil {
-					log.Errorf("[svcToIstioResHandler] [EventUpdate] delete old service error: %+v", err)
-					return
-				}
-				for _, oldEp := range oldEps {
-					c = oldEp.ConvertConfig()
-					if err = imc.Delete(c.GroupVersionKind, c.Name, c.Namespace); err != nil {
-						log.Errorf("[svcToIstioResHandler] [EventUpdate] delete old endpoint error: %+v", err)
-						return
-					}
-				}
+	registerServiceRegistryHandlers(cc, imc, config.Global.EnableConvertSeToIstioRes, seLabelSelectorKeys)
 
-				newService, newEps, err := kube.ConvertSvcAndEps(cfg, vs)
-				if err != nil {
-					log.Errorf("[svcToIstioResHandler] [EventUpdate] ConvertSvcAndEps error: %+v", err)
-					return
-				}
-				_, err = imc.Create(newService.ConvertConfig())
-				if err != nil {
-					log.Errorf("[svcToIstioResHandler] [EventUpdate] create new service error: %+v", err)
-					return
-				}
-				for _, newEp := range newEps {
-					if _, err = imc.Create(newEp.ConvertConfig()); err != nil {
-						log.Errorf("[svcToIstioResHandler] [EventUpdate] create new endpoint error: %+v", err)
-						return
-					}
-				}
-			case EventDelete:
-				service, eps, err := kube.ConvertSvcAndEps(cfg, vs)
-				if err != nil {
-					log.Errorf("[svcToIstioResHandler] [EventUpdate] ConvertSvcAndEps error: %+v", err)
-					return
-				}
-				c := service.ConvertConfig()
-				err = imc.Delete(c.GroupVersionKind, c.Name, c.Namespace)
-				if err != nil {
-					log.Errorf("[svcToIstioResHandler] [EventDelete] for service error: %+v", err)
-					return
-				}
-				for _, ep := range eps {
-					c = ep.ConvertConfig()
-					if err = imc.Delete(c.GroupVersionKind, c.Name, c.Namespace); err != nil {
-						log.Errorf("[svcToIstioResHandler] [EventDelete] for endpoint error: %+v", err)
-						return
-					}
-				}
-			}
+	for _, mc := range mcs {
+		if mc.sourceKind == nil {
+			continue
 		}
-
-		epsToIstioResHandler := func(old resource.Config, cfg resource.Config, e Event) {
-			ep := cfg.Spec.(*v1.Endpoints)
-			hostname := kube.ServiceHostname(cfg.Name, cfg.Namespace, model.DefaultTrustDomain)
-
-			switch e {
-			case EventAdd:
-				// handle by svcHandler
-			case EventUpdate:
-				isCfg := imc.Get(resource.IstioService, string(hostname), cfg.Namespace)
-				if isCfg == nil {
-					return
-				}
-				is := isCfg.Spec.(*model.Service)
-
-				oldEp := old.Spec.(*v1.Endpoints)
-				oldIeps, err := kube.ConvertIstioEndpoints(oldEp, hostname, old.Name, old.Namespace, vs)
-				if err != nil {
-					log.Errorf("[epsToIstioResHandler] [EventUpdate] ConvertIstioEndpoints error: %+v", err)
-					return
-				}
-				for _, oldIep := range oldIeps {
-					oldIepName := model.BuildIstioEpName(oldIep.ServiceName, oldIep.ServicePortName, oldIep.Address, int(oldIep.EndpointPort)) //nolint: lll
-					if err = imc.Delete(resource.IstioEndpoint, oldIepName, oldIep.Namespace); err != nil {
-						log.Errorf("[epsToIstioResHandler] [EventUpdate] delete old IstioEndpoint error: %+v", err)
-						return
-					}
-					// delete related istioService.Endpoints
-					for i, ep := range is.Endpoints {
-						if ep.Address == oldIep.Address && ep.EndpointPort == oldIep.EndpointPort {
-							l := len(is.Endpoints)
-							if i != l-1 {
-								is.Endpoints[i], is.Endpoints[l-1] = is.Endpoints[l-1], is.Endpoints[i]
-							}
-							is.Endpoints = is.Endpoints[:l-1]
-							break
-						}
-					}
-				}
-
-				newIeps, err := kube.ConvertIstioEndpoints(ep, hostname, cfg.Name, cfg.Namespace, vs)
-				if err != nil {
-					log.Errorf("[epsToIstioResHandler] [EventUpdate] ConvertIstioEndpoints error: %+v", err)
-					return
-				}
-				for _, newIep := range newIeps {
-					if _, err = imc.Create(newIep.ConvertConfig()); err != nil {
-						log.Errorf("[epsToIstioResHandler] [EventUpdate] create new IstioEndpoint error: %+v", err)
-						return
-					}
-					// add related istioService.Endpoints
-					is.Endpoints = append(is.Endpoints, newIep)
-				}
-
-				if _, err := imc.Update(is.ConvertConfig()); err != nil {
-					log.Errorf("[epsToIstioResHandler] [EventUpdate] update IstioService error: %+v", err)
-					return
-				}
-			case EventDelete:
-				// handle by svcHandler
-			}
+		opts := ConfigSourceOptions{
+			ConfigSource:           mc.configSource,
+			RestConfig:             cfg,
+			ConfigRevision:         configRevision,
+			XdsSourceEnableIncPush: xdsSourceEnableIncPush,
 		}
-
-		cc.RegisterEventHandler(resource.ServiceEntry, seToIstioResHandler)
-		cc.RegisterEventHandler(resource.Service, svcToIstioResHandler)
-		cc.RegisterEventHandler(resource.Endpoints, epsToIstioResHandler)
-	}
-
-	for _, mc := range mcs {
-		switch mc.kind {
-		case Kubernetes:
-			if err = startK8sMonitorController(mc, cfg, stop); err != nil {
-				return nil, err
-			}
-		case McpOverXds:
-			if err = startXdsMonitorController(mc, configRevision, xdsSourceEnableIncPush, stop); err != nil {
-				return nil, err
-			}
+		if err = mc.sourceKind.Start(mc, opts, stop); err != nil {
+			return nil, err
 		}
 	}
 
@@ -374,6 +173,10 @@ func (c *configController) InitReady() bool {
 	return true
 }
 
+func (c *configController) ServiceRegistry() *registry.Registry {
+	return c.serviceRegistry
+}
+
 func (c *configController) Schemas() resource.Schemas {
 	return c.viewerStore.Schemas()
 }
@@ -409,29 +212,27 @@ func (c *configController) Run(stop <-chan struct{}) {
 func initIstioMonitorController() (*monitorController, error) {
 	ics := makeConfigStore(collections.Istio)
 	mc := newMonitorController(ics)
-	mc.kind = Istio
 	mc.SetReady()
 	return mc, nil
 }
 
-func initXdsMonitorController(configSource *bootconfig.ConfigSource) (*monitorController, error) {
-	xcs := makeConfigStore(collections.Pilot)
-	mc := newMonitorController(xcs)
-	mc.kind = McpOverXds
+func initMonitorController(kind *ConfigSourceKind, configSource *bootconfig.ConfigSource) *monitorController {
+	mc := newMonitorController(makeConfigStore(kind.Schemas))
+	mc.sourceKind = kind
 	mc.configSource = configSource
-	log.Infof("init xds config source [%s] successfully", configSource.Address)
-	return mc, nil
+	log.Infof("init %s config source [%s] successfully", kind.Name, configSource.Address)
+	return mc
 }
 
-func startXdsMonitorController(
-	mc *monitorController,
-	configRevision string,
-	xdsSourceEnableIncPush bool,
-	stop <-chan struct{},
-) error {
-	srcAddress, err := url.Parse(mc.configSource.Address)
+// startXdsMonitorController syncs the configs from the xds server of the address
+// like `xds://host:port?types=ServiceEntry&failover=host2:port`. The `types`
+// filters the types of configs to request, and the `failover` addresses are
+// tried in turn if the current server keeps failing.
+func startXdsMonitorController(mc ConfigSourceStore, opts ConfigSourceOptions, stop <-chan struct{}) error {
+	configSource := opts.ConfigSource
+	srcAddress, err := url.Parse(configSource.Address)
 	if err != nil {
-		return fmt.Errorf("invalid xds config source %s: %v", mc.configSource.Address, err)
+		return fmt.Errorf("invalid xds config source %s: %v", configSource.Address, err)
 	}
 	types := srcAddress.Query()["types"]
 
@@ -535,47 +336,60 @@ func startXdsMonitorController(
 			}, name, namespace)
 		},
 
-		Inc: xdsSourceEnableIncPush,
+		Inc: opts.XdsSourceEnableIncPush,
 	}
 
 	// mcpCli handles mcp data
 	mcpCli := mcpc.NewAdsc(&mcpc.Config{
 		// To reduce transported data if upstream server supports. Especially for custom servers.
-		Revision:           configRevision,
+		Revision:           opts.ConfigRevision,
 		InitReqTypes:       initReqTypes,
 		TypeConfigsHandler: configHandlerAdapter.TypeConfigsHandler,
 	})
 	configHandlerAdapter.A = mcpCli
 
-	// xdsMCP handles xds data
-	xdsMCP, err := xdsc.New(
-		&meshconfig.ProxyConfig{
-			DiscoveryAddress: srcAddress.Host,
+	tlsCfg := configSource.GetTls()
+	if tlsCfg != nil && tlsCfg.CertDir == "" {
+		return fmt.Errorf("xds config source %s: tls certDir is required", configSource.Address)
+	}
+
+	xdsClient := &failoverXdsClient{
+		addresses: append([]string{srcAddress.Host}, srcAddress.Query()["failover"]...),
+		newClient: func(address string, bo backoff.BackOff) (*xdsc.ADSC, error) {
+			xdsConfig := &xdsc.Config{
+				Meta: resource.NodeMetadata{
+					Generator:     "api",
+					IstioRevision: opts.ConfigRevision,
+				}.ToStruct(),
+				InitialDiscoveryRequests: initReqs,
+				DiscoveryHandler:         mcpCli,
+				BackoffPolicy:            bo,
+				StateNotifier: func(state xdsc.State) {
+					if state == xdsc.StateConnected {
+						configHandlerAdapter.Reset()
+					}
+				},
+			}
+			if tlsCfg != nil {
+				// the certs are loaded by the client on each (re)connect, so the rotated ones take effect on reconnect.
+				xdsConfig.CertDir = tlsCfg.CertDir
+				xdsConfig.XDSSAN = tlsCfg.ServerName
+				xdsConfig.InsecureSkipVerify = tlsCfg.InsecureSkipVerify
+			}
+			// xdsMCP handles xds data
+			return xdsc.New(&meshconfig.ProxyConfig{DiscoveryAddress: address}, xdsConfig)
 		},
-		&xdsc.Config{
-			Meta: resource.NodeMetadata{
-				Generator:     "api",
-				IstioRevision: configRevision,
-			}.ToStruct(),
-			InitialDiscoveryRequests: initReqs,
-			DiscoveryHandler:         mcpCli,
-			StateNotifier: func(state xdsc.State) {
-				if state == xdsc.StateConnected {
-					configHandlerAdapter.Reset()
-				}
-			},
-		})
-	if err != nil {
-		return fmt.Errorf("create xds client error: %v", err)
 	}
 
 	go func() {
-		log.Infof("MCP: connect xds source and wait sync in background")
-		err := xdsMCP.Run()
-		if err != nil {
+		if err := xdsClient.start(); err != nil {
 			log.Errorf("MCP: failed running %v", err)
 			return
 		}
+		go func() {
+			<-stop
+			xdsClient.close()
+		}()
 
 		ticker := time.NewTicker(1 * time.Second)
 		defer ticker.Stop()
@@ -585,12 +399,12 @@ func startXdsMonitorController(
 				log.Infof("get stop chan in XdsMonitorController")
 				return
 			case <-ticker.C:
-				if xdsMCP.HasSynced() {
-					log.Infof("sync xds config source [%s] successfully", mc.configSource.Address)
+				if xdsClient.hasSynced() {
+					log.Infof("sync xds config source [%s] successfully", configSource.Address)
 					mc.SetReady()
 					return
 				}
-				log.Debugf("waiting for syncing data of xds config source [%s]...", mc.configSource.Address)
+				log.Debugf("waiting for syncing data of xds config source [%s]...", configSource.Address)
 			}
 		}
 	}()
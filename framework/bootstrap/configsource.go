@@ -0,0 +1,83 @@
+package bootstrap
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"k8s.io/client-go/rest"
+
+	bootconfig "slime.io/slime/framework/apis/config/v1alpha1"
+	"slime.io/slime/framework/bootstrap/resource"
+)
+
+// ConfigSourceStore is the store a config source syncs its configs into, the
+// changes are dispatched to the event handlers registered to the config controller.
+type ConfigSourceStore interface {
+	ConfigStore
+	// SetReady marks the initial configs of the source as synced.
+	SetReady()
+}
+
+// ConfigSourceOptions carries the options to start a config source.
+type ConfigSourceOptions struct {
+	ConfigSource *bootconfig.ConfigSource
+	// RestConfig is the config of the local cluster, nil if the source is
+	// started by RunIstioController.
+	RestConfig             *rest.Config
+	ConfigRevision         string
+	XdsSourceEnableIncPush bool
+}
+
+// ConfigSourceKind is a kind of config source, which is selected by the prefix
+// of the config source address.
+type ConfigSourceKind struct {
+	// Name of the kind, used in logs
+	Name string
+	// Prefix of the config source addresses, like `k8s://`
+	Prefix string
+	// Schemas of the configs provided by the source
+	Schemas resource.Schemas
+	// NeedRestConfig indicates the source reads from the local cluster, which
+	// is not started by RunIstioController.
+	NeedRestConfig bool
+	// Start syncs the configs of the source into the store in background until
+	// stop, and marks the store ready once the initial configs are synced.
+	Start func(store ConfigSourceStore, opts ConfigSourceOptions, stop <-chan struct{}) error
+}
+
+var (
+	configSourceKindsMut sync.RWMutex
+	configSourceKinds    []*ConfigSourceKind
+)
+
+// RegisterConfigSourceKind registers a kind of config source. It is expected to
+// be called in init, and panics if the prefix is already registered.
+func RegisterConfigSourceKind(kind *ConfigSourceKind) {
+	if kind.Prefix == "" || kind.Start == nil {
+		panic(fmt.Sprintf("invalid config source kind %s", kind.Name))
+	}
+	configSourceKindsMut.Lock()
+	defer configSourceKindsMut.Unlock()
+	for _, k := range configSourceKinds {
+		if k.Prefix == kind.Prefix {
+			panic(fmt.Sprintf("config source prefix %s of kind %s is already registered by kind %s",
+				kind.Prefix, kind.Name, k.Name))
+		}
+	}
+	configSourceKinds = append(configSourceKinds, kind)
+}
+
+// lookupConfigSourceKind returns the kind of the config source address, the
+// longest matched prefix wins.
+func lookupConfigSourceKind(address string) *ConfigSourceKind {
+	configSourceKindsMut.RLock()
+	defer configSourceKindsMut.RUnlock()
+	var ret *ConfigSourceKind
+	for _, k := range configSourceKinds {
+		if strings.HasPrefix(address, k.Prefix) && (ret == nil || len(k.Prefix) > len(ret.Prefix)) {
+			ret = k
+		}
+	}
+	return ret
+}
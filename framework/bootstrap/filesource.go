@@ -0,0 +1,271 @@
+package bootstrap
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+	"istio.io/libistio/pkg/config"
+	"istio.io/libistio/pkg/config/schema/collections"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubeyaml "k8s.io/apimachinery/pkg/util/yaml"
+
+	"slime.io/slime/framework/bootstrap/resource"
+)
+
+const (
+	fileSourceDebounce = 500 * time.Millisecond
+	// fileSourceDefaultNamespace is the namespace of the configs without one
+	fileSourceDefaultNamespace = "default"
+)
+
+func init() {
+	RegisterConfigSourceKind(&ConfigSourceKind{
+		Name:    "file",
+		Prefix:  FileConfigSourcePrefix,
+		Schemas: collections.Pilot,
+		Start:   startFileMonitorController,
+	})
+}
+
+// startFileMonitorController syncs the istio configs from the yaml or json
+// files of the address like `fs:///etc/slime/configs`, which is a file or a
+// directory whose files are read non-recursively. The files are watched and
+// reloaded on changes, and the configs not in the files any more are deleted.
+// If any file is invalid, the whole reload is skipped to keep the previous configs.
+func startFileMonitorController(mc ConfigSourceStore, opts ConfigSourceOptions, stop <-chan struct{}) error {
+	path := strings.TrimPrefix(opts.ConfigSource.Address, FileConfigSourcePrefix)
+	if path == "" {
+		return fmt.Errorf("invalid file config source %s: empty path", opts.ConfigSource.Address)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("invalid file config source %s: %v", opts.ConfigSource.Address, err)
+	}
+
+	fs := &fileSource{path: path, isDir: info.IsDir(), store: mc}
+	if err := fs.sync(); err != nil {
+		return fmt.Errorf("load file config source %s failed: %v", opts.ConfigSource.Address, err)
+	}
+	mc.SetReady()
+	log.Infof("sync file config source [%s] successfully", opts.ConfigSource.Address)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create watcher of file config source %s failed: %v", opts.ConfigSource.Address, err)
+	}
+	watchPath := path
+	if !fs.isDir {
+		// watch the directory to get the events of the file being replaced
+		watchPath = filepath.Dir(path)
+	}
+	if err := watcher.Add(watchPath); err != nil {
+		_ = watcher.Close()
+		return fmt.Errorf("watch file config source %s failed: %v", opts.ConfigSource.Address, err)
+	}
+	go fs.watch(watcher, stop)
+	return nil
+}
+
+type fileSource struct {
+	path  string
+	isDir bool
+	store ConfigSourceStore
+}
+
+func (fs *fileSource) watch(watcher *fsnotify.Watcher, stop <-chan struct{}) {
+	defer watcher.Close()
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-stop:
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			log.Debugf("got file config source event %s", event)
+			debounce = time.After(fileSourceDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Errorf("file config source %s watcher error: %v", fs.path, err)
+		case <-debounce:
+			debounce = nil
+			if err := fs.sync(); err != nil {
+				log.Errorf("reload file config source %s failed: %v, keep the previous configs", fs.path, err)
+			}
+		}
+	}
+}
+
+// sync loads the configs from the files and applies the changes to the store.
+func (fs *fileSource) sync() error {
+	configs, err := fs.load()
+	if err != nil {
+		return err
+	}
+
+	for _, gvk := range fs.store.Schemas().GroupVersionKinds() {
+		existing, err := fs.store.List(gvk, "")
+		if err != nil {
+			return err
+		}
+		for _, cfg := range existing {
+			if _, ok := configs[cfg.Key()]; !ok {
+				if err := fs.store.Delete(gvk, cfg.Name, cfg.Namespace); err != nil {
+					log.Errorf("file config source delete %s failed: %v", cfg.Key(), err)
+				}
+			}
+		}
+	}
+
+	for _, cfg := range configs {
+		if fs.store.Get(cfg.GroupVersionKind, cfg.Name, cfg.Namespace) == nil {
+			_, err = fs.store.Create(cfg)
+		} else {
+			_, err = fs.store.Update(cfg)
+		}
+		if err != nil {
+			log.Errorf("file config source apply %s failed: %v", cfg.Key(), err)
+		}
+	}
+	return nil
+}
+
+func (fs *fileSource) load() (map[string]resource.Config, error) {
+	files := []string{fs.path}
+	if fs.isDir {
+		entries, err := os.ReadDir(fs.path)
+		if err != nil {
+			return nil, err
+		}
+		files = files[:0]
+		for _, e := range entries {
+			// skip the hidden ones like the `..data` of the mounted configmaps
+			if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+				continue
+			}
+			switch filepath.Ext(e.Name()) {
+			case ".yaml", ".yml", ".json":
+				files = append(files, filepath.Join(fs.path, e.Name()))
+			}
+		}
+		sort.Strings(files)
+	}
+
+	configs := map[string]resource.Config{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := parseFileConfigs(data, fs.store.Schemas())
+		if err != nil {
+			return nil, fmt.Errorf("parse %s failed: %v", file, err)
+		}
+		for _, cfg := range parsed {
+			key := cfg.Key()
+			if _, ok := configs[key]; ok {
+				return nil, fmt.Errorf("duplicated config %s in %s", key, file)
+			}
+			configs[key] = cfg
+		}
+	}
+	return configs, nil
+}
+
+type fileConfigObject struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              json.RawMessage `json:"spec"`
+}
+
+// parseFileConfigs parses the configs of the schemas from the yaml or json
+// documents, the objects of other kinds are skipped.
+func parseFileConfigs(data []byte, schemas resource.Schemas) ([]resource.Config, error) {
+	var ret []resource.Config
+	decoder := kubeyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		var obj fileConfigObject
+		if err := decoder.Decode(&obj); err != nil {
+			if errors.Is(err, io.EOF) {
+				return ret, nil
+			}
+			return nil, err
+		}
+		if obj.Kind == "" {
+			// empty document
+			continue
+		}
+
+		gv, err := schema.ParseGroupVersion(obj.APIVersion)
+		if err != nil {
+			return nil, err
+		}
+		gvk := resource.GroupVersionKind{Group: gv.Group, Version: gv.Version, Kind: obj.Kind}
+		s, ok := schemas.FindByGroupVersionAliasesKind(gvk)
+		if !ok {
+			log.Debugf("file config source skips unsupported kind %s of %s", gvk, obj.Name)
+			continue
+		}
+		spec, err := s.NewInstance()
+		if err != nil {
+			return nil, err
+		}
+		if len(obj.Spec) > 0 {
+			if err := config.ApplyJSON(spec, string(obj.Spec)); err != nil {
+				return nil, fmt.Errorf("invalid spec of %s %s: %v", gvk, obj.Name, err)
+			}
+		}
+
+		namespace := obj.Namespace
+		if namespace == "" {
+			namespace = fileSourceDefaultNamespace
+		}
+		if obj.Name == "" {
+			return nil, fmt.Errorf("%s without name", gvk)
+		}
+
+		// the content hash is used as the revision, so the unchanged configs are not updated
+		content, err := json.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(content)
+		annotations := make(map[string]string, len(obj.Annotations)+1)
+		for k, v := range obj.Annotations {
+			annotations[k] = v
+		}
+		annotations[ResourceVersion] = hex.EncodeToString(sum[:8])
+
+		cfg := resource.Config{
+			Meta: resource.Meta{
+				GroupVersionKind:  s.GroupVersionKind(),
+				Name:              obj.Name,
+				Namespace:         namespace,
+				Labels:            obj.Labels,
+				Annotations:       annotations,
+				CreationTimestamp: obj.CreationTimestamp.Time,
+			},
+			Spec: spec,
+		}
+		if _, err := s.ValidateConfig(cfg); err != nil {
+			return nil, fmt.Errorf("invalid %s %s/%s: %v", gvk, namespace, obj.Name, err)
+		}
+		ret = append(ret, cfg)
+	}
+}
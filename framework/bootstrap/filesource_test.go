@@ -0,0 +1,128 @@
+package bootstrap
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	networkingapi "istio.io/api/networking/v1alpha3"
+
+	bootconfig "slime.io/slime/framework/apis/config/v1alpha1"
+	"slime.io/slime/framework/bootstrap/resource"
+)
+
+const testServiceEntries = `
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: foo
+  namespace: test
+spec:
+  hosts:
+  - foo.example.com
+  ports:
+  - number: 80
+    name: http
+    protocol: HTTP
+  resolution: DNS
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: skipped
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: bar
+spec:
+  hosts:
+  - bar.example.com
+  ports:
+  - number: 80
+    name: http
+    protocol: HTTP
+  resolution: DNS
+`
+
+func TestFileConfigSource(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "se.yaml")
+	if err := os.WriteFile(file, []byte(testServiceEntries), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	configSource := &bootconfig.ConfigSource{Address: FileConfigSourcePrefix + dir}
+	kind := lookupConfigSourceKind(configSource.Address)
+	if kind == nil || kind.Name != "file" {
+		t.Fatalf("unexpected kind %v of %s", kind, configSource.Address)
+	}
+	mc := initMonitorController(kind, configSource)
+	events := make(chan Event, 10)
+	mc.RegisterEventHandler(resource.ServiceEntry, func(_, _ resource.Config, e Event) {
+		events <- e
+	})
+	stop := make(chan struct{})
+	defer close(stop)
+	go mc.Run(stop)
+
+	if err := kind.Start(mc, ConfigSourceOptions{ConfigSource: configSource}, stop); err != nil {
+		t.Fatal(err)
+	}
+	if !mc.InitReady() {
+		t.Fatalf("expect the source to be ready")
+	}
+	if cfg := mc.Get(resource.ServiceEntry, "bar", fileSourceDefaultNamespace); cfg == nil {
+		t.Fatalf("expect the config in the default namespace")
+	}
+	cfg := mc.Get(resource.ServiceEntry, "foo", "test")
+	if cfg == nil || cfg.Spec.(*networkingapi.ServiceEntry).Hosts[0] != "foo.example.com" {
+		t.Fatalf("unexpected config %v", cfg)
+	}
+
+	waitEvents := func(expect ...Event) {
+		t.Helper()
+		for _, e := range expect {
+			select {
+			case got := <-events:
+				if got != e {
+					t.Fatalf("expect event %v, got %v", e, got)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timeout waiting for event %v", e)
+			}
+		}
+		select {
+		case got := <-events:
+			t.Fatalf("unexpected event %v", got)
+		case <-time.After(2 * fileSourceDebounce):
+		}
+	}
+	waitEvents(EventAdd, EventAdd)
+
+	// the invalid content is skipped
+	if err := os.WriteFile(file, []byte("kind: [\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitEvents()
+	if mc.Get(resource.ServiceEntry, "foo", "test") == nil {
+		t.Fatalf("expect the previous configs to be kept")
+	}
+
+	// foo is updated and bar is unchanged
+	content := strings.Replace(testServiceEntries, "- foo.example.com\n", "- foo.example.com\n  - foo2.example.com\n", 1)
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitEvents(EventUpdate)
+	if hosts := mc.Get(resource.ServiceEntry, "foo", "test").Spec.(*networkingapi.ServiceEntry).Hosts; len(hosts) != 2 {
+		t.Fatalf("unexpected hosts %v", hosts)
+	}
+
+	if err := os.Remove(file); err != nil {
+		t.Fatal(err)
+	}
+	waitEvents(EventDelete, EventDelete)
+}
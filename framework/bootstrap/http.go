@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"k8s.io/kube-openapi/pkg/common"
@@ -105,7 +106,7 @@ func (m PrefixPathHandlerManager) Handle(path string, handler http.Handler) {
 func AuxiliaryHttpServerStart(
 	env Environment,
 	ph *PathHandler,
-	addr string,
+	opts AuxServerOptions,
 	readyChecker func() error,
 	pe http.Handler,
 ) {
@@ -116,8 +117,37 @@ func AuxiliaryHttpServerStart(
 	ConfigStoreRegister(env, ph)
 	ExporterRegister(ph, pe)
 
-	log.Infof("aux server is starting to listen %s", addr)
-	if err := http.ListenAndServe(addr, ph.mux); err != nil {
+	var handler http.Handler = ph.mux
+	if opts.Auth {
+		if env.K8SClient == nil {
+			log.Errorf("aux server auth needs the kube client")
+			return
+		}
+		if !opts.tlsEnabled() {
+			log.Warnf("aux server auth is enabled without tls, the bearer tokens are sent in plaintext")
+		}
+		handler = newAuxAuthorizer(env.K8SClient).wrap(ph)
+	}
+
+	server := &http.Server{
+		Addr:              opts.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	var err error
+	if opts.tlsEnabled() {
+		server.TLSConfig, err = opts.tlsConfig()
+		if err != nil {
+			log.Errorf("aux server tls config error, %+v", err)
+			return
+		}
+		log.Infof("aux server is starting to listen %s with tls", opts.Addr)
+		err = server.ListenAndServeTLS(opts.CertFile, opts.KeyFile)
+	} else {
+		log.Infof("aux server is starting to listen %s", opts.Addr)
+		err = server.ListenAndServe()
+	}
+	if err != nil {
 		log.Errorf("aux server starts error, %+v", err)
 	}
 }
@@ -126,7 +156,7 @@ func ExporterRegister(ph *PathHandler, pe http.Handler) {
 	if pe == nil {
 		return
 	}
-	ph.Handle("/metrics", pe)
+	ph.Handle("/metrics", PublicHandler(pe))
 }
 
 func ConfigStoreRegister(env Environment, ph *PathHandler) {
@@ -166,8 +196,8 @@ func configStoreHandler(controller ConfigController) http.Handler {
 }
 
 func HealthCheckRegister(ph *PathHandler, readyChecker func() error) {
-	ph.Handle("/modules/livez", livezHandler())
-	ph.Handle("/modules/readyz", readyzHandler(readyChecker))
+	ph.Handle("/modules/livez", PublicHandler(livezHandler()))
+	ph.Handle("/modules/readyz", PublicHandler(readyzHandler(readyChecker)))
 }
 
 func HealthCheckPathRegister() {
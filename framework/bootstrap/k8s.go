@@ -11,25 +11,25 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 
-	bootconfig "slime.io/slime/framework/apis/config/v1alpha1"
 	"slime.io/slime/framework/bootstrap/collections"
 	"slime.io/slime/framework/bootstrap/resource"
 )
 
-// TODO support multi clusters, configSource takes no effect now
-func initK8sMonitorController(configSource *bootconfig.ConfigSource) (*monitorController, error) {
-	kcs := makeConfigStore(collections.Kube)
-	mc := newMonitorController(kcs)
-	mc.kind = Kubernetes
-	mc.configSource = configSource
-	log.Infof("init k8s config source successfully")
-	return mc, nil
+func init() {
+	RegisterConfigSourceKind(&ConfigSourceKind{
+		Name:           "k8s",
+		Prefix:         KubernetesConfigSourcePrefix,
+		Schemas:        collections.Kube,
+		NeedRestConfig: true,
+		Start:          startK8sMonitorController,
+	})
 }
 
-func startK8sMonitorController(mc *monitorController, cfg *rest.Config, stopCh <-chan struct{}) error {
+// TODO support multi clusters, configSource takes no effect now
+func startK8sMonitorController(mc ConfigSourceStore, opts ConfigSourceOptions, stopCh <-chan struct{}) error {
+	cfg := opts.RestConfig
 	clientSet, err := kubernetes.NewForConfig(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to init k8s client sets for K8S config source: %+v", err)
@@ -122,7 +122,7 @@ func startK8sMonitorController(mc *monitorController, cfg *rest.Config, stopCh <
 	return nil
 }
 
-func addFuncFactory[T metav1.Object](mc *monitorController, gvk resource.GroupVersionKind) func(obj interface{}) {
+func addFuncFactory[T metav1.Object](mc ConfigSourceStore, gvk resource.GroupVersionKind) func(obj interface{}) {
 	return func(obj interface{}) {
 		re, ok := obj.(T)
 		if !ok {
@@ -146,7 +146,7 @@ func addFuncFactory[T metav1.Object](mc *monitorController, gvk resource.GroupVe
 	}
 }
 
-func updateFuncFactory[T metav1.Object](mc *monitorController, gvk resource.GroupVersionKind) func(oldObj, newObj interface{}) { //nolint: lll
+func updateFuncFactory[T metav1.Object](mc ConfigSourceStore, gvk resource.GroupVersionKind) func(oldObj, newObj interface{}) { //nolint: lll
 	return func(_, obj interface{}) {
 		re, ok := obj.(T)
 		if !ok {
@@ -170,7 +170,7 @@ func updateFuncFactory[T metav1.Object](mc *monitorController, gvk resource.Grou
 	}
 }
 
-func deleteFuncFactory[T metav1.Object](mc *monitorController, gvk resource.GroupVersionKind) func(obj interface{}) {
+func deleteFuncFactory[T metav1.Object](mc ConfigSourceStore, gvk resource.GroupVersionKind) func(obj interface{}) {
 	return func(obj interface{}) {
 		re, ok := obj.(T)
 		if !ok {
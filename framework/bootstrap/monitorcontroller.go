@@ -11,22 +11,15 @@ import (
 )
 
 type monitorController struct {
-	monitor      Monitor
-	configStore  ConfigStore
-	kind         monitorControllerKind
+	monitor     Monitor
+	configStore ConfigStore
+	// sourceKind is the kind of the config source, nil for the istio one
+	sourceKind   *ConfigSourceKind
 	configSource *bootconfig.ConfigSource
 	ready        bool
 	sync.RWMutex
 }
 
-type monitorControllerKind int
-
-const (
-	Istio monitorControllerKind = iota
-	Kubernetes
-	McpOverXds
-)
-
 func newMonitorController(cs ConfigStore) *monitorController {
 	out := &monitorController{
 		configStore: cs,
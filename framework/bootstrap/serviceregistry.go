@@ -0,0 +1,128 @@
+package bootstrap
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"slime.io/slime/framework/bootstrap/resource"
+	"slime.io/slime/framework/bootstrap/serviceregistry/kube"
+	"slime.io/slime/framework/bootstrap/serviceregistry/model"
+	"slime.io/slime/framework/bootstrap/serviceregistry/registry"
+	"slime.io/slime/framework/bootstrap/serviceregistry/serviceentry"
+)
+
+func serviceRegistrySource(kind resource.GroupVersionKind, cfg resource.Config) string {
+	return kind.Kind + "/" + cfg.Namespace + "/" + cfg.Name
+}
+
+// registerServiceRegistryHandlers keeps the service registry converted from the
+// ServiceEntries. With convertToIstioRes, the kubernetes Services are converted
+// as well, and the services are mirrored to the IstioService and IstioEndpoint
+// configs of the istio monitor controller.
+func registerServiceRegistryHandlers(
+	cc *configController,
+	imc *monitorController,
+	convertToIstioRes bool,
+	seLabelSelectorKeys string,
+) {
+	reg := cc.serviceRegistry
+	seHandler := func(_, cfg resource.Config, e Event) {
+		source := serviceRegistrySource(resource.ServiceEntry, cfg)
+		if e == EventDelete {
+			reg.DeleteSource(source)
+			return
+		}
+		svcs, _ := serviceentry.ConvertSvcsAndEps(cfg, seLabelSelectorKeys)
+		reg.ApplyServices(source, svcs)
+	}
+	cc.RegisterEventHandler(resource.ServiceEntry, seHandler)
+
+	if !convertToIstioRes {
+		return
+	}
+	reg.Subscribe(istioResMirror(reg, imc))
+
+	vs := cc.viewerStore
+	applyKubeService := func(cfg resource.Config) {
+		source := serviceRegistrySource(resource.Service, cfg)
+		svc, _, err := kube.ConvertSvcAndEps(cfg, vs)
+		if err != nil {
+			log.Errorf("[svcToIstioResHandler] ConvertSvcAndEps of %s error: %+v", source, err)
+			return
+		}
+		reg.ApplyServices(source, []*model.Service{svc})
+	}
+	svcHandler := func(_, cfg resource.Config, e Event) {
+		if e == EventDelete {
+			reg.DeleteSource(serviceRegistrySource(resource.Service, cfg))
+			return
+		}
+		applyKubeService(cfg)
+	}
+	epsHandler := func(_, cfg resource.Config, e Event) {
+		// the adds and deletes are handled with the service
+		if e != EventUpdate {
+			return
+		}
+		if svcCfg := vs.Get(resource.Service, cfg.Name, cfg.Namespace); svcCfg != nil {
+			applyKubeService(*svcCfg)
+		}
+	}
+	cc.RegisterEventHandler(resource.Service, svcHandler)
+	cc.RegisterEventHandler(resource.Endpoints, epsHandler)
+}
+
+// istioResMirror returns a handler that applies the changes of the services to
+// the IstioService and IstioEndpoint configs. The endpoints of a ServiceEntry
+// are shared by the services of its hosts, so an endpoint config is kept until
+// no service has it.
+func istioResMirror(reg *registry.Registry, imc *monitorController) func(registry.ServiceEvent) {
+	hasEndpoint := func(ep *model.IstioEndpoint) bool {
+		name := model.BuildIstioEpName(ep.ServiceName, ep.ServicePortName, ep.Address, int(ep.EndpointPort))
+		for _, svc := range reg.ServicesByEndpointIP(ep.Address) {
+			for _, cur := range svc.Endpoints {
+				if cur.Namespace == ep.Namespace &&
+					model.BuildIstioEpName(cur.ServiceName, cur.ServicePortName, cur.Address, int(cur.EndpointPort)) == name {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	return func(e registry.ServiceEvent) {
+		for _, ep := range e.RemovedEndpoints {
+			if hasEndpoint(ep) {
+				continue
+			}
+			c := ep.ConvertConfig()
+			if err := imc.Delete(c.GroupVersionKind, c.Name, c.Namespace); err != nil {
+				log.Debugf("[istioResMirror] delete IstioEndpoint error: %+v", err)
+			}
+		}
+
+		c := e.Service.ConvertConfig()
+		if e.Kind == registry.EventDelete {
+			if err := imc.Delete(c.GroupVersionKind, c.Name, c.Namespace); err != nil {
+				log.Errorf("[istioResMirror] delete IstioService %s/%s error: %+v", c.Namespace, c.Name, err)
+			}
+		} else if err := applyConfig(imc, c); err != nil {
+			log.Errorf("[istioResMirror] [%s] IstioService %s/%s error: %+v", e.Kind, c.Namespace, c.Name, err)
+		}
+
+		for _, ep := range e.AddedEndpoints {
+			c := ep.ConvertConfig()
+			if err := applyConfig(imc, c); err != nil {
+				log.Errorf("[istioResMirror] apply IstioEndpoint %s/%s error: %+v", c.Namespace, c.Name, err)
+			}
+		}
+	}
+}
+
+func applyConfig(mc *monitorController, c resource.Config) (err error) {
+	if mc.Get(c.GroupVersionKind, c.Name, c.Namespace) == nil {
+		_, err = mc.Create(c)
+	} else {
+		_, err = mc.Update(c)
+	}
+	return
+}
@@ -0,0 +1,336 @@
+package registry
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+
+	"slime.io/slime/framework/bootstrap/serviceregistry/model"
+)
+
+type EventKind int
+
+const (
+	EventAdd EventKind = iota
+	EventUpdate
+	EventDelete
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventAdd:
+		return "add"
+	case EventUpdate:
+		return "update"
+	case EventDelete:
+		return "delete"
+	}
+	return "unknown"
+}
+
+// ServiceEvent describes a change of a service in the registry.
+type ServiceEvent struct {
+	Kind EventKind
+	// Service is the new service, or the deleted one for EventDelete
+	Service *model.Service
+	// Old is the previous service for EventUpdate
+	Old *model.Service
+	// AddedEndpoints and RemovedEndpoints are the diff of the endpoints, all
+	// the endpoints are added for EventAdd and removed for EventDelete.
+	AddedEndpoints   []*model.IstioEndpoint
+	RemovedEndpoints []*model.IstioEndpoint
+}
+
+// ServiceKey identifies a service in the registry.
+type ServiceKey struct {
+	Namespace string
+	Hostname  model.Name
+}
+
+func keyOf(svc *model.Service) ServiceKey {
+	return ServiceKey{Namespace: svc.Attributes.Namespace, Hostname: svc.Hostname}
+}
+
+type serviceEntry struct {
+	svc    *model.Service
+	source string
+}
+
+// Registry is an in-memory registry of the istio services converted from the
+// ServiceEntries or kubernetes Services, indexed by host, namespace, label
+// selector and endpoint ip. The services returned are shared and must not be
+// modified.
+type Registry struct {
+	mut      sync.RWMutex
+	services map[ServiceKey]*serviceEntry
+	// sources records the services of each source, like `ServiceEntry/ns/name`
+	sources     map[string][]ServiceKey
+	byHost      map[model.Name]map[ServiceKey]struct{}
+	byNamespace map[string]map[ServiceKey]struct{}
+	// bySelector indexes the services by each `key=value` of their label selectors
+	bySelector   map[string]map[ServiceKey]struct{}
+	byEndpointIP map[string]map[ServiceKey]struct{}
+
+	handlersMut sync.RWMutex
+	handlers    []func(ServiceEvent)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		services:     map[ServiceKey]*serviceEntry{},
+		sources:      map[string][]ServiceKey{},
+		byHost:       map[model.Name]map[ServiceKey]struct{}{},
+		byNamespace:  map[string]map[ServiceKey]struct{}{},
+		bySelector:   map[string]map[ServiceKey]struct{}{},
+		byEndpointIP: map[string]map[ServiceKey]struct{}{},
+	}
+}
+
+// Subscribe registers a handler called on each change of the services. The
+// handlers are called in order of the changes, out of the lock of the registry.
+func (r *Registry) Subscribe(handler func(ServiceEvent)) {
+	r.handlersMut.Lock()
+	r.handlers = append(r.handlers, handler)
+	r.handlersMut.Unlock()
+}
+
+// ApplyServices replaces the services of the source with svcs, the services
+// and endpoints are diffed with the previous ones, and only the changes are
+// applied and notified. If a service is provided by multiple sources, the last
+// applied one wins.
+func (r *Registry) ApplyServices(source string, svcs []*model.Service) {
+	r.mut.Lock()
+	newKeys := make([]ServiceKey, 0, len(svcs))
+	newSet := make(map[ServiceKey]struct{}, len(svcs))
+	var events []ServiceEvent
+	for _, svc := range svcs {
+		key := keyOf(svc)
+		if _, ok := newSet[key]; ok {
+			continue
+		}
+		newSet[key] = struct{}{}
+		newKeys = append(newKeys, key)
+
+		prev := r.services[key]
+		if prev == nil {
+			r.put(key, svc, source)
+			events = append(events, ServiceEvent{Kind: EventAdd, Service: svc, AddedEndpoints: svc.Endpoints})
+			continue
+		}
+		added, removed := diffEndpoints(prev.svc.Endpoints, svc.Endpoints)
+		if len(added) == 0 && len(removed) == 0 && serviceEqual(prev.svc, svc) {
+			prev.source = source
+			continue
+		}
+		r.remove(key)
+		r.put(key, svc, source)
+		events = append(events, ServiceEvent{
+			Kind:             EventUpdate,
+			Service:          svc,
+			Old:              prev.svc,
+			AddedEndpoints:   added,
+			RemovedEndpoints: removed,
+		})
+	}
+	for _, key := range r.sources[source] {
+		if _, ok := newSet[key]; ok {
+			continue
+		}
+		if e := r.services[key]; e != nil && e.source == source {
+			r.remove(key)
+			events = append(events, ServiceEvent{Kind: EventDelete, Service: e.svc, RemovedEndpoints: e.svc.Endpoints})
+		}
+	}
+	if len(newKeys) > 0 {
+		r.sources[source] = newKeys
+	} else {
+		delete(r.sources, source)
+	}
+	r.mut.Unlock()
+
+	r.notify(events)
+}
+
+// DeleteSource deletes the services of the source.
+func (r *Registry) DeleteSource(source string) {
+	r.ApplyServices(source, nil)
+}
+
+func (r *Registry) notify(events []ServiceEvent) {
+	if len(events) == 0 {
+		return
+	}
+	r.handlersMut.RLock()
+	handlers := r.handlers
+	r.handlersMut.RUnlock()
+	for _, e := range events {
+		for _, h := range handlers {
+			h(e)
+		}
+	}
+}
+
+func (r *Registry) put(key ServiceKey, svc *model.Service, source string) {
+	r.services[key] = &serviceEntry{svc: svc, source: source}
+	addIndex(r.byHost, key.Hostname, key)
+	addIndex(r.byNamespace, key.Namespace, key)
+	for k, v := range svc.Attributes.LabelSelectors {
+		addIndex(r.bySelector, k+"="+v, key)
+	}
+	for _, ep := range svc.Endpoints {
+		addIndex(r.byEndpointIP, ep.Address, key)
+	}
+}
+
+func (r *Registry) remove(key ServiceKey) {
+	e := r.services[key]
+	if e == nil {
+		return
+	}
+	delete(r.services, key)
+	removeIndex(r.byHost, key.Hostname, key)
+	removeIndex(r.byNamespace, key.Namespace, key)
+	for k, v := range e.svc.Attributes.LabelSelectors {
+		removeIndex(r.bySelector, k+"="+v, key)
+	}
+	for _, ep := range e.svc.Endpoints {
+		removeIndex(r.byEndpointIP, ep.Address, key)
+	}
+}
+
+func addIndex[K comparable](index map[K]map[ServiceKey]struct{}, k K, key ServiceKey) {
+	keys := index[k]
+	if keys == nil {
+		keys = map[ServiceKey]struct{}{}
+		index[k] = keys
+	}
+	keys[key] = struct{}{}
+}
+
+func removeIndex[K comparable](index map[K]map[ServiceKey]struct{}, k K, key ServiceKey) {
+	keys := index[k]
+	delete(keys, key)
+	if len(keys) == 0 {
+		delete(index, k)
+	}
+}
+
+// Service returns the service of the host in the namespace, nil if not found.
+func (r *Registry) Service(namespace string, hostname model.Name) *model.Service {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+	if e := r.services[ServiceKey{Namespace: namespace, Hostname: hostname}]; e != nil {
+		return e.svc
+	}
+	return nil
+}
+
+// Services returns all the services.
+func (r *Registry) Services() []*model.Service {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+	ret := make([]*model.Service, 0, len(r.services))
+	for _, e := range r.services {
+		ret = append(ret, e.svc)
+	}
+	sortServices(ret)
+	return ret
+}
+
+// ServicesByHost returns the services of the host in all namespaces.
+func (r *Registry) ServicesByHost(hostname model.Name) []*model.Service {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+	return r.collect(r.byHost[hostname])
+}
+
+// ServicesByNamespace returns the services in the namespace.
+func (r *Registry) ServicesByNamespace(namespace string) []*model.Service {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+	return r.collect(r.byNamespace[namespace])
+}
+
+// ServicesByEndpointIP returns the services having an endpoint of the ip.
+func (r *Registry) ServicesByEndpointIP(ip string) []*model.Service {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+	return r.collect(r.byEndpointIP[ip])
+}
+
+// ServicesSelecting returns the services whose label selectors select the
+// workload labels. The services without selectors are not included.
+func (r *Registry) ServicesSelecting(labels map[string]string) []*model.Service {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+	hits := map[ServiceKey]int{}
+	for k, v := range labels {
+		for key := range r.bySelector[k+"="+v] {
+			hits[key]++
+		}
+	}
+	ret := make([]*model.Service, 0, len(hits))
+	for key, n := range hits {
+		if e := r.services[key]; e != nil && n == len(e.svc.Attributes.LabelSelectors) {
+			ret = append(ret, e.svc)
+		}
+	}
+	sortServices(ret)
+	return ret
+}
+
+func (r *Registry) collect(keys map[ServiceKey]struct{}) []*model.Service {
+	ret := make([]*model.Service, 0, len(keys))
+	for key := range keys {
+		if e := r.services[key]; e != nil {
+			ret = append(ret, e.svc)
+		}
+	}
+	sortServices(ret)
+	return ret
+}
+
+func sortServices(svcs []*model.Service) {
+	sort.Slice(svcs, func(i, j int) bool {
+		if svcs[i].Attributes.Namespace != svcs[j].Attributes.Namespace {
+			return svcs[i].Attributes.Namespace < svcs[j].Attributes.Namespace
+		}
+		return svcs[i].Hostname < svcs[j].Hostname
+	})
+}
+
+func endpointKey(ep *model.IstioEndpoint) string {
+	return model.BuildIstioEpName(ep.ServiceName, ep.ServicePortName, ep.Address, int(ep.EndpointPort))
+}
+
+// diffEndpoints returns the endpoints added or changed in cur, and the ones
+// removed or changed in prev.
+func diffEndpoints(prev, cur []*model.IstioEndpoint) (added, removed []*model.IstioEndpoint) {
+	prevMap := make(map[string]*model.IstioEndpoint, len(prev))
+	for _, ep := range prev {
+		prevMap[endpointKey(ep)] = ep
+	}
+	curMap := make(map[string]*model.IstioEndpoint, len(cur))
+	for _, ep := range cur {
+		key := endpointKey(ep)
+		curMap[key] = ep
+		if p, ok := prevMap[key]; !ok || !reflect.DeepEqual(p, ep) {
+			added = append(added, ep)
+		}
+	}
+	for _, ep := range prev {
+		key := endpointKey(ep)
+		if c, ok := curMap[key]; !ok || !reflect.DeepEqual(c, ep) {
+			removed = append(removed, ep)
+		}
+	}
+	return added, removed
+}
+
+// serviceEqual compares the services except the endpoints.
+func serviceEqual(a, b *model.Service) bool {
+	return a.Hostname == b.Hostname &&
+		reflect.DeepEqual(a.Attributes, b.Attributes) &&
+		reflect.DeepEqual(a.Ports, b.Ports) &&
+		reflect.DeepEqual(a.Addresses, b.Addresses)
+}
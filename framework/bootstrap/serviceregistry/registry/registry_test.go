@@ -0,0 +1,101 @@
+package registry
+
+import (
+	"testing"
+
+	"slime.io/slime/framework/bootstrap/serviceregistry/model"
+)
+
+func newTestService(ns, host string, selector map[string]string, ips ...string) *model.Service {
+	svc := &model.Service{
+		Hostname: model.Name(host),
+		Ports:    model.PortList{{Name: "http", Port: 80, Protocol: model.HTTP}},
+		Attributes: model.ServiceAttributes{
+			Name:           host,
+			Namespace:      ns,
+			LabelSelectors: selector,
+		},
+	}
+	for _, ip := range ips {
+		svc.Endpoints = append(svc.Endpoints, &model.IstioEndpoint{
+			Address:         ip,
+			ServiceName:     host,
+			Namespace:       ns,
+			ServicePortName: "http",
+			EndpointPort:    80,
+		})
+	}
+	return svc
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	var events []ServiceEvent
+	r.Subscribe(func(e ServiceEvent) {
+		events = append(events, e)
+	})
+
+	r.ApplyServices("ServiceEntry/a/foo", []*model.Service{
+		newTestService("a", "foo.com", map[string]string{"app": "foo"}, "1.1.1.1", "1.1.1.2"),
+		newTestService("a", "foo2.com", nil, "1.1.1.1"),
+	})
+	r.ApplyServices("ServiceEntry/b/foo", []*model.Service{
+		newTestService("b", "foo.com", map[string]string{"app": "foo", "version": "v1"}),
+	})
+	if len(events) != 3 || events[0].Kind != EventAdd || len(events[0].AddedEndpoints) != 2 {
+		t.Fatalf("unexpected events %+v", events)
+	}
+
+	if svc := r.Service("a", "foo.com"); svc == nil || len(svc.Endpoints) != 2 {
+		t.Fatalf("unexpected service %v", svc)
+	}
+	if svcs := r.ServicesByHost("foo.com"); len(svcs) != 2 || svcs[0].Attributes.Namespace != "a" {
+		t.Fatalf("unexpected services by host %v", svcs)
+	}
+	if svcs := r.ServicesByNamespace("a"); len(svcs) != 2 {
+		t.Fatalf("unexpected services by namespace %v", svcs)
+	}
+	if svcs := r.ServicesByEndpointIP("1.1.1.1"); len(svcs) != 2 {
+		t.Fatalf("unexpected services by endpoint ip %v", svcs)
+	}
+	if svcs := r.ServicesSelecting(map[string]string{"app": "foo", "pod": "x"}); len(svcs) != 1 ||
+		svcs[0].Attributes.Namespace != "a" {
+		t.Fatalf("unexpected services selecting %v", svcs)
+	}
+	if svcs := r.ServicesSelecting(map[string]string{"app": "foo", "version": "v1"}); len(svcs) != 2 {
+		t.Fatalf("unexpected services selecting %v", svcs)
+	}
+
+	// unchanged services are not notified, and the endpoints are diffed
+	events = nil
+	r.ApplyServices("ServiceEntry/a/foo", []*model.Service{
+		newTestService("a", "foo.com", map[string]string{"app": "foo"}, "1.1.1.2", "1.1.1.3"),
+		newTestService("a", "foo2.com", nil, "1.1.1.1"),
+	})
+	if len(events) != 1 || events[0].Kind != EventUpdate {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	if e := events[0]; len(e.AddedEndpoints) != 1 || e.AddedEndpoints[0].Address != "1.1.1.3" ||
+		len(e.RemovedEndpoints) != 1 || e.RemovedEndpoints[0].Address != "1.1.1.1" {
+		t.Fatalf("unexpected endpoints diff %+v", e)
+	}
+	if svcs := r.ServicesByEndpointIP("1.1.1.1"); len(svcs) != 1 || svcs[0].Hostname != "foo2.com" {
+		t.Fatalf("unexpected services by endpoint ip %v", svcs)
+	}
+
+	// the services not in the source any more are deleted
+	events = nil
+	r.ApplyServices("ServiceEntry/a/foo", []*model.Service{
+		newTestService("a", "foo.com", map[string]string{"app": "foo"}, "1.1.1.2", "1.1.1.3"),
+	})
+	if len(events) != 1 || events[0].Kind != EventDelete || events[0].Service.Hostname != "foo2.com" {
+		t.Fatalf("unexpected events %+v", events)
+	}
+
+	events = nil
+	r.DeleteSource("ServiceEntry/a/foo")
+	r.DeleteSource("ServiceEntry/b/foo")
+	if len(events) != 2 || len(r.Services()) != 0 || len(r.byEndpointIP) != 0 || len(r.bySelector) != 0 {
+		t.Fatalf("expect all services deleted, events %+v", events)
+	}
+}
@@ -0,0 +1,185 @@
+package bootstrap
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	log "github.com/sirupsen/logrus"
+	xdsc "istio.io/istio-mcp/pkg/mcp/xds/client"
+)
+
+// xdsFailoverThreshold is the count of consecutive connection failures to fail
+// over to the next address of a xds config source.
+const xdsFailoverThreshold = 3
+
+// neverBackOff parks the reconnecting of a stopped client, the timer never fires.
+const neverBackOff = time.Duration(math.MaxInt64)
+
+// failoverBackOff wraps the reconnect backoff of a xds client, and calls
+// onFailover once the client fails for xdsFailoverThreshold times in a row.
+// It also tracks whether the client has connected, as Close panics with a
+// client which never dials, e.g. the certs fail to load, and leaves the
+// client locked.
+type failoverBackOff struct {
+	backoff.BackOff
+	onFailover func()
+
+	mut      sync.Mutex
+	failures int
+	client   *xdsc.ADSC
+	// connected is set once the client connects, after which it is safe to close the client
+	connected bool
+	stopped   bool
+}
+
+func (b *failoverBackOff) NextBackOff() time.Duration {
+	b.mut.Lock()
+	if b.stopped {
+		b.mut.Unlock()
+		return neverBackOff
+	}
+	b.failures++
+	failover := b.failures == xdsFailoverThreshold
+	b.mut.Unlock()
+	if failover && b.onFailover != nil {
+		// called in the reconnecting goroutine of the client, which is closed by onFailover
+		go b.onFailover()
+	}
+	return b.BackOff.NextBackOff()
+}
+
+// Reset is called by the client once connected.
+func (b *failoverBackOff) Reset() {
+	b.mut.Lock()
+	b.failures = 0
+	b.connected = true
+	stopped, client := b.stopped, b.client
+	b.mut.Unlock()
+	if stopped && client != nil {
+		// stopped before connecting
+		client.Close()
+	}
+	b.BackOff.Reset()
+}
+
+func (b *failoverBackOff) setClient(client *xdsc.ADSC) {
+	b.mut.Lock()
+	b.client = client
+	b.mut.Unlock()
+}
+
+// stop closes the client if it has connected, otherwise parks its reconnecting
+// and closes it if it connects after all.
+func (b *failoverBackOff) stop() {
+	b.mut.Lock()
+	b.stopped = true
+	connected, client := b.connected, b.client
+	b.mut.Unlock()
+	if connected && client != nil {
+		client.Close()
+	}
+}
+
+// failoverXdsClient keeps a xds client connecting to one of the addresses, and
+// switches to the next address in turn if the current one keeps failing.
+// The configs are fully pushed by the new server once connected, so the
+// stale ones of the previous server are removed.
+type failoverXdsClient struct {
+	addresses []string
+	newClient func(address string, bo backoff.BackOff) (*xdsc.ADSC, error)
+
+	mut    sync.Mutex
+	idx    int
+	gen    int
+	client *xdsc.ADSC
+	bo     *failoverBackOff
+	closed bool
+}
+
+func (c *failoverXdsClient) start() error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.connectLocked(c.newBackOffLocked())
+}
+
+func (c *failoverXdsClient) newBackOffLocked() *failoverBackOff {
+	bo := backoff.NewExponentialBackOff()
+	bo.MaxElapsedTime = 0
+	fb := &failoverBackOff{BackOff: bo}
+	if len(c.addresses) > 1 {
+		gen := c.gen
+		fb.onFailover = func() { c.failover(gen) }
+	}
+	return fb
+}
+
+func (c *failoverXdsClient) connectLocked(fb *failoverBackOff) error {
+	address := c.addresses[c.idx]
+	client, err := c.newClient(address, fb)
+	if err != nil {
+		return err
+	}
+	fb.setClient(client)
+	c.client, c.bo = client, fb
+	log.Infof("MCP: connect xds source %s and wait sync in background", address)
+	return client.Run()
+}
+
+// connectOrRetryLocked connects with fb, and retries after the backoff of fb
+// if failed. The failures count towards the failover like the ones of the
+// client reconnecting.
+func (c *failoverXdsClient) connectOrRetryLocked(fb *failoverBackOff) {
+	err := c.connectLocked(fb)
+	if err == nil {
+		return
+	}
+	log.Errorf("MCP: failed running xds client of %s: %v", c.addresses[c.idx], err)
+	gen := c.gen
+	time.AfterFunc(fb.NextBackOff(), func() {
+		c.mut.Lock()
+		defer c.mut.Unlock()
+		if c.closed || c.gen != gen {
+			return
+		}
+		c.connectOrRetryLocked(fb)
+	})
+}
+
+func (c *failoverXdsClient) failover(gen int) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if c.closed || c.gen != gen {
+		return
+	}
+	prev := c.addresses[c.idx]
+	c.stopClientLocked()
+	c.gen++
+	c.idx = (c.idx + 1) % len(c.addresses)
+	log.Warnf("MCP: xds source %s keeps failing, fail over to %s", prev, c.addresses[c.idx])
+	c.connectOrRetryLocked(c.newBackOffLocked())
+}
+
+func (c *failoverXdsClient) hasSynced() bool {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.client != nil && c.client.HasSynced()
+}
+
+func (c *failoverXdsClient) close() {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	c.stopClientLocked()
+}
+
+func (c *failoverXdsClient) stopClientLocked() {
+	if c.bo != nil {
+		c.bo.stop()
+	}
+	c.client, c.bo = nil, nil
+}
@@ -0,0 +1,108 @@
+package bootstrap
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	xdsc "istio.io/istio-mcp/pkg/mcp/xds/client"
+)
+
+func TestFailoverBackOff(t *testing.T) {
+	failovers := make(chan struct{}, xdsFailoverThreshold)
+	b := &failoverBackOff{
+		BackOff:    &backoff.ZeroBackOff{},
+		onFailover: func() { failovers <- struct{}{} },
+	}
+
+	for i := 0; i < xdsFailoverThreshold-1; i++ {
+		b.NextBackOff()
+	}
+	b.Reset()
+	for i := 0; i < xdsFailoverThreshold-1; i++ {
+		b.NextBackOff()
+	}
+	if len(failovers) != 0 {
+		t.Fatalf("unexpected failover before the failures reach the threshold")
+	}
+
+	b.NextBackOff()
+	<-failovers
+	// failover only once
+	b.NextBackOff()
+	if len(failovers) != 0 {
+		t.Fatalf("unexpected repeated failover")
+	}
+}
+
+func TestStopNeverConnectedXdsClient(t *testing.T) {
+	fb := &failoverBackOff{BackOff: &backoff.ZeroBackOff{}}
+	// the certs fail to load, so the client never dials
+	client, err := xdsc.New(&meshconfig.ProxyConfig{DiscoveryAddress: "127.0.0.1:1"},
+		&xdsc.Config{CertDir: t.TempDir(), BackoffPolicy: fb})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fb.setClient(client)
+	_ = client.Run()
+
+	fb.stop()
+	if d := fb.NextBackOff(); d != neverBackOff {
+		t.Errorf("expect the reconnecting parked, got backoff %v", d)
+	}
+	// the client is not left locked
+	done := make(chan struct{})
+	go func() {
+		client.Closed()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the client is left locked")
+	}
+}
+
+func TestFailoverRetryOnConnectError(t *testing.T) {
+	var (
+		mut    sync.Mutex
+		dialed []string
+	)
+	c := &failoverXdsClient{
+		addresses: []string{"a", "b"},
+		newClient: func(address string, _ backoff.BackOff) (*xdsc.ADSC, error) {
+			mut.Lock()
+			defer mut.Unlock()
+			dialed = append(dialed, address)
+			return nil, errors.New("failed")
+		},
+	}
+	defer c.close()
+
+	c.failover(0)
+	// b is retried until the failures reach the threshold, then fails over to a again
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		mut.Lock()
+		got := append([]string(nil), dialed...)
+		mut.Unlock()
+		if len(got) > xdsFailoverThreshold {
+			for i := 0; i < xdsFailoverThreshold; i++ {
+				if got[i] != "b" {
+					t.Fatalf("expect b retried %d times first, got %v", xdsFailoverThreshold, got)
+				}
+			}
+			if got[xdsFailoverThreshold] != "a" {
+				t.Fatalf("expect failing over to a, got %v", got)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("connecting not retried, got %v", got)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,57 @@
+// The slimeboot command runs the native SlimeBoot controller, which replaces
+// the helm based operator in `boot/`.
+package main
+
+import (
+	"flag"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"slime.io/slime/framework/model/event"
+	"slime.io/slime/framework/model/slimeboot"
+)
+
+func main() {
+	var metricsAddr, probeAddr, namespace string
+	var leaderElection bool
+	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
+	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	flag.StringVar(&namespace, "namespace", os.Getenv("WATCH_NAMESPACE"), "The namespace of the SlimeBoots to watch, all if empty.")
+	flag.BoolVar(&leaderElection, "leader-elect", false, "Enable leader election for the controller.")
+	flag.Parse()
+
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	scheme.AddKnownTypeWithName(slimeboot.GroupVersionKind, &unstructured.Unstructured{})
+
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+		Scheme:                 scheme,
+		MetricsBindAddress:     metricsAddr,
+		HealthProbeBindAddress: probeAddr,
+		LeaderElection:         leaderElection,
+		LeaderElectionID:       "slimeboot.config.netease.com",
+		Namespace:              namespace,
+	})
+	if err != nil {
+		log.Fatalf("create manager error: %v", err)
+	}
+
+	broadcaster := event.NewBroadcaster(mgr.GetEventRecorderFor(slimeboot.ControllerName), scheme, 0)
+	if err := (&slimeboot.Reconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        scheme,
+		EventRecorder: broadcaster.ForModule(slimeboot.ControllerName),
+	}).SetupWithManager(mgr); err != nil {
+		log.Fatalf("setup slimeboot controller error: %v", err)
+	}
+
+	log.Infof("starting slimeboot controller")
+	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+		log.Fatalf("run manager error: %v", err)
+	}
+}
@@ -0,0 +1,252 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/tools/reference"
+)
+
+const (
+	DefaultBufferSize = 1000
+
+	// the same events of an object in the window are merged into one with count
+	dedupWindow = 5 * time.Minute
+	// each object can emit a burst of kubernetes events, and one more every interval
+	rateLimitBurst    = 10
+	rateLimitInterval = 30 * time.Second
+	// the tracked events and objects are pruned when exceeding the size
+	maxTracked = 4096
+)
+
+// Recorder records the decisions of a module on the objects, like creating a
+// resource for a CR. The events are emitted as kubernetes Events on the
+// objects and kept in the recent events of the Broadcaster.
+type Recorder interface {
+	// Event records an event on the object, eventType is corev1.EventTypeNormal
+	// or corev1.EventTypeWarning, and reason is a short CamelCase word.
+	Event(object runtime.Object, eventType, reason, message string)
+	// Eventf is like Event, but with the message formatted by Sprintf.
+	Eventf(object runtime.Object, eventType, reason, messageFmt string, args ...interface{})
+}
+
+// Event is a recorded event.
+type Event struct {
+	Module         string    `json:"module"`
+	Kind           string    `json:"kind"`
+	Namespace      string    `json:"namespace,omitempty"`
+	Name           string    `json:"name"`
+	Type           string    `json:"type"`
+	Reason         string    `json:"reason"`
+	Message        string    `json:"message"`
+	Count          int       `json:"count"`
+	FirstTimestamp time.Time `json:"firstTimestamp"`
+	LastTimestamp  time.Time `json:"lastTimestamp"`
+}
+
+type eventKey struct {
+	module, kind, namespace, name, eventType, reason, message string
+}
+
+type objectKey struct {
+	kind, namespace, name string
+}
+
+type objectLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Broadcaster emits the events of the modules as kubernetes Events with
+// deduplication and rate-limiting, and keeps the recent ones in a ring buffer.
+type Broadcaster struct {
+	// recorder emits the kubernetes events, nil to keep the events in memory only
+	recorder record.EventRecorder
+	scheme   *runtime.Scheme
+
+	mut      sync.Mutex
+	ring     []*Event
+	next     int
+	recent   map[eventKey]*Event
+	limiters map[objectKey]*objectLimiter
+	now      func() time.Time
+}
+
+func NewBroadcaster(recorder record.EventRecorder, scheme *runtime.Scheme, bufferSize int) *Broadcaster {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+	return &Broadcaster{
+		recorder: recorder,
+		scheme:   scheme,
+		ring:     make([]*Event, 0, bufferSize),
+		recent:   map[eventKey]*Event{},
+		limiters: map[objectKey]*objectLimiter{},
+		now:      time.Now,
+	}
+}
+
+// ForModule returns the recorder of the module.
+func (b *Broadcaster) ForModule(module string) Recorder {
+	return &moduleRecorder{broadcaster: b, module: module}
+}
+
+type moduleRecorder struct {
+	broadcaster *Broadcaster
+	module      string
+}
+
+func (r *moduleRecorder) Event(object runtime.Object, eventType, reason, message string) {
+	r.broadcaster.record(r.module, object, eventType, reason, message)
+}
+
+func (r *moduleRecorder) Eventf(object runtime.Object, eventType, reason, messageFmt string, args ...interface{}) {
+	r.broadcaster.record(r.module, object, eventType, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (b *Broadcaster) record(module string, object runtime.Object, eventType, reason, message string) {
+	ref, err := reference.GetReference(b.scheme, object)
+	if err != nil {
+		log.Warnf("record event %s of module %s failed: %v", reason, module, err)
+		return
+	}
+	log.Debugf("module %s records event %s on %s %s/%s: %s", module, reason, ref.Kind, ref.Namespace, ref.Name, message)
+
+	if b.add(module, ref, eventType, reason, message) && b.recorder != nil {
+		b.recorder.Event(ref, eventType, reason, message)
+	}
+}
+
+// add adds the event to the buffer, and returns whether to emit it.
+func (b *Broadcaster) add(module string, ref *corev1.ObjectReference, eventType, reason, message string) bool {
+	now := b.now()
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	key := eventKey{
+		module: module, kind: ref.Kind, namespace: ref.Namespace, name: ref.Name,
+		eventType: eventType, reason: reason, message: message,
+	}
+	if e := b.recent[key]; e != nil && now.Sub(e.LastTimestamp) < dedupWindow {
+		e.Count++
+		e.LastTimestamp = now
+		return false
+	}
+
+	e := &Event{
+		Module:         module,
+		Kind:           ref.Kind,
+		Namespace:      ref.Namespace,
+		Name:           ref.Name,
+		Type:           eventType,
+		Reason:         reason,
+		Message:        message,
+		Count:          1,
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+	}
+	if len(b.ring) < cap(b.ring) {
+		b.ring = append(b.ring, e)
+	} else {
+		b.ring[b.next] = e
+	}
+	b.next = (b.next + 1) % cap(b.ring)
+	b.recent[key] = e
+
+	objKey := objectKey{kind: ref.Kind, namespace: ref.Namespace, name: ref.Name}
+	l := b.limiters[objKey]
+	if l == nil {
+		l = &objectLimiter{limiter: rate.NewLimiter(rate.Every(rateLimitInterval), rateLimitBurst)}
+		b.limiters[objKey] = l
+	}
+	l.lastSeen = now
+	b.prune(now)
+	return l.limiter.AllowN(now, 1)
+}
+
+func (b *Broadcaster) prune(now time.Time) {
+	if len(b.recent) > maxTracked {
+		for k, e := range b.recent {
+			if now.Sub(e.LastTimestamp) >= dedupWindow {
+				delete(b.recent, k)
+			}
+		}
+	}
+	if len(b.limiters) > maxTracked {
+		// the limiter is full again after idle for burst * interval
+		for k, l := range b.limiters {
+			if now.Sub(l.lastSeen) >= rateLimitBurst*rateLimitInterval {
+				delete(b.limiters, k)
+			}
+		}
+	}
+}
+
+// Filter selects the events, the empty fields match all.
+type Filter struct {
+	Module    string
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+func (f Filter) match(e *Event) bool {
+	return (f.Module == "" || f.Module == e.Module) &&
+		(f.Kind == "" || strings.EqualFold(f.Kind, e.Kind)) &&
+		(f.Namespace == "" || f.Namespace == e.Namespace) &&
+		(f.Name == "" || f.Name == e.Name)
+}
+
+// Events returns at most limit recent events matching the filter, the newest
+// first. There is no limit if limit <= 0.
+func (b *Broadcaster) Events(f Filter, limit int) []Event {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	ret := make([]Event, 0)
+	for i := 1; i <= len(b.ring); i++ {
+		e := b.ring[(b.next-i+cap(b.ring))%cap(b.ring)]
+		if !f.match(e) {
+			continue
+		}
+		ret = append(ret, *e)
+		if limit > 0 && len(ret) >= limit {
+			break
+		}
+	}
+	return ret
+}
+
+// ServeHTTP serves the recent events filtered by the query parameters
+// `module`, `kind`, `namespace`, `name` and `limit`.
+func (b *Broadcaster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var limit int
+	if s := q.Get("limit"); s != "" {
+		var err error
+		if limit, err = strconv.Atoi(s); err != nil {
+			http.Error(w, fmt.Sprintf("invalid limit %s", s), http.StatusBadRequest)
+			return
+		}
+	}
+	events := b.Events(Filter{
+		Module:    q.Get("module"),
+		Kind:      q.Get("kind"),
+		Namespace: q.Get("namespace"),
+		Name:      q.Get("name"),
+	}, limit)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(events); err != nil {
+		log.Errorf("write events failed: %v", err)
+	}
+}
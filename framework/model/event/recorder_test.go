@@ -0,0 +1,102 @@
+package event
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+)
+
+func newTestBroadcaster(t *testing.T, bufferSize int) (*Broadcaster, *record.FakeRecorder, *time.Time) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	recorder := record.NewFakeRecorder(100)
+	b := NewBroadcaster(recorder, scheme, bufferSize)
+	now := time.Unix(0, 0)
+	b.now = func() time.Time { return now }
+	return b, recorder, &now
+}
+
+func newTestService(name string) *corev1.Service {
+	return &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: name}}
+}
+
+func TestBroadcasterDedupAndRateLimit(t *testing.T) {
+	b, recorder, now := newTestBroadcaster(t, 0)
+	r := b.ForModule("lazyload")
+	svc := newTestService("foo")
+
+	r.Event(svc, corev1.EventTypeNormal, "Created", "created")
+	r.Event(svc, corev1.EventTypeNormal, "Created", "created")
+	if n := len(recorder.Events); n != 1 {
+		t.Fatalf("expect the same event emitted once, got %d", n)
+	}
+	events := b.Events(Filter{}, 0)
+	if len(events) != 1 || events[0].Count != 2 || events[0].Kind != "Service" {
+		t.Fatalf("unexpected events %+v", events)
+	}
+
+	// the same event is emitted again after the dedup window
+	*now = now.Add(dedupWindow)
+	r.Event(svc, corev1.EventTypeNormal, "Created", "created")
+	if n := len(recorder.Events); n != 2 {
+		t.Fatalf("expect the event emitted after the dedup window, got %d", n)
+	}
+
+	// the burst of the object is used up, the events are kept but not emitted
+	for i := 0; i < rateLimitBurst; i++ {
+		r.Eventf(svc, corev1.EventTypeWarning, "Failed", "failed %d", i)
+	}
+	if n := len(recorder.Events); n != rateLimitBurst+1 {
+		t.Fatalf("expect %d events emitted, got %d", rateLimitBurst+1, n)
+	}
+	if events := b.Events(Filter{}, 0); len(events) != 2+rateLimitBurst {
+		t.Fatalf("expect all the events kept, got %d", len(events))
+	}
+	// the other objects are not limited
+	r.Event(newTestService("bar"), corev1.EventTypeNormal, "Created", "created")
+	if n := len(recorder.Events); n != rateLimitBurst+2 {
+		t.Fatalf("expect the event of the other object emitted, got %d", n)
+	}
+}
+
+func TestBroadcasterRingBuffer(t *testing.T) {
+	b, _, _ := newTestBroadcaster(t, 3)
+	for i, name := range []string{"a", "b", "c", "d"} {
+		b.ForModule("limiter").Eventf(newTestService(name), corev1.EventTypeNormal, "Created", "created %d", i)
+	}
+	b.ForModule("plugin").Event(newTestService("e"), corev1.EventTypeNormal, "Created", "created")
+
+	events := b.Events(Filter{}, 0)
+	if len(events) != 3 || events[0].Name != "e" || events[2].Name != "c" {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	if events := b.Events(Filter{Module: "limiter"}, 1); len(events) != 1 || events[0].Name != "d" {
+		t.Fatalf("unexpected events %+v", events)
+	}
+
+	req := httptest.NewRequest("GET", "/events?module=limiter&kind=service&name=c", nil)
+	w := httptest.NewRecorder()
+	b.ServeHTTP(w, req)
+	var got []Event
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Name != "c" || got[0].Namespace != "test" {
+		t.Fatalf("unexpected events %+v", got)
+	}
+
+	w = httptest.NewRecorder()
+	b.ServeHTTP(w, httptest.NewRequest("GET", "/events?limit=x", nil))
+	if w.Code != 400 {
+		t.Fatalf("expect bad request for invalid limit, got %d", w.Code)
+	}
+}
@@ -13,6 +13,7 @@ type AccessLogConvertor struct {
 	cacheResult     map[string]map[string]string // meta -> value
 	cacheResultCopy map[string]map[string]string
 	handler         func(logEntry []*data_accesslog.HTTPAccessLogEntry) (map[string]map[string]string, error)
+	tcpHandler      func(logEntry []*data_accesslog.TCPAccessLogEntry) (map[string]map[string]string, error)
 	convertorLock   sync.RWMutex
 }
 
@@ -23,6 +24,7 @@ func NewAccessLogConvertor(config AccessLogConvertorConfig) *AccessLogConvertor
 	return &AccessLogConvertor{
 		name:            config.Name,
 		handler:         config.Handler,
+		tcpHandler:      config.TcpHandler,
 		cacheResult:     result,
 		cacheResultCopy: resultCopy,
 	}
@@ -40,11 +42,31 @@ func (alc *AccessLogConvertor) CacheResultCopy() map[string]map[string]string {
 }
 
 func (alc *AccessLogConvertor) Convert(logEntry []*data_accesslog.HTTPAccessLogEntry) error {
-	l := log.WithField("reporter", "AccessLogConvertor").WithField("function", "Convert")
 	tmpResult, err := alc.handler(logEntry)
 	if err != nil {
 		return err
 	}
+	return alc.merge(tmpResult)
+}
+
+// SupportTcp returns whether the convertor handles tcp access logs
+func (alc *AccessLogConvertor) SupportTcp() bool {
+	return alc.tcpHandler != nil
+}
+
+func (alc *AccessLogConvertor) ConvertTcp(logEntry []*data_accesslog.TCPAccessLogEntry) error {
+	if alc.tcpHandler == nil {
+		return nil
+	}
+	tmpResult, err := alc.tcpHandler(logEntry)
+	if err != nil {
+		return err
+	}
+	return alc.merge(tmpResult)
+}
+
+func (alc *AccessLogConvertor) merge(tmpResult map[string]map[string]string) error {
+	l := log.WithField("reporter", "AccessLogConvertor").WithField("function", "merge")
 
 	alc.convertorLock.Lock()
 	defer alc.convertorLock.Unlock()
@@ -82,7 +104,7 @@ func (alc *AccessLogConvertor) Convert(logEntry []*data_accesslog.HTTPAccessLogE
 		alc.cacheResultCopy = newCacheResultCopy
 	}
 
-	return err
+	return nil
 }
 
 func valueMerge(cacheValue, tmpValue map[string]string) bool {
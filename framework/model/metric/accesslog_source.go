@@ -46,6 +46,21 @@ func (s *AccessLogSource) StreamAccessLogs(logServer service_accesslog.AccessLog
 				}
 			}
 		}
+
+		tcpLogEntries := message.GetTcpLogs()
+		if tcpLogEntries != nil {
+			log.Debugf("got tcp accesslog %s", tcpLogEntries.String())
+			for _, convertor := range s.convertors {
+				if !convertor.SupportTcp() {
+					continue
+				}
+				if err = convertor.ConvertTcp(tcpLogEntries.LogEntry); err != nil {
+					log.Errorf("convertor [%s] converted tcp logs error: %+v", convertor.Name(), err)
+				} else {
+					log.Debugf("convertor %s converts tcp logs successfully", convertor.Name())
+				}
+			}
+		}
 	}
 }
 
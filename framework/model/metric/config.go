@@ -1,6 +1,8 @@
 package metric
 
 import (
+	"time"
+
 	data_accesslog "github.com/envoyproxy/go-control-plane/envoy/data/accesslog/v3"
 	prometheus "github.com/prometheus/client_golang/api/prometheus/v1"
 	prometheusModel "github.com/prometheus/common/model"
@@ -12,11 +14,14 @@ type ProducerConfig struct {
 	EnablePrometheusSource bool
 	PrometheusSourceConfig PrometheusSourceConfig
 	AccessLogSourceConfig  AccessLogSourceConfig
+	EnableOtlpSource       bool
+	OtlpSourceConfig       OtlpSourceConfig
 	EnableMockSource       bool
 	EnableWatcherProducer  bool
 	WatcherProducerConfig  WatcherProducerConfig
 	EnableTickerProducer   bool
 	TickerProducerConfig   TickerProducerConfig
+	QueryConfig            QueryConfig
 	StopChan               <-chan struct{}
 }
 
@@ -37,6 +42,20 @@ type TickerProducerConfig struct {
 type PrometheusSourceConfig struct {
 	Api       prometheus.API
 	Convertor func(queryValue prometheusModel.Value) map[string]string
+	// Timeout is the timeout of each request to prometheus, no timeout if 0
+	Timeout time.Duration
+	// BatchSize is the max number of queries merged into one request, the
+	// queries are not merged if less than 2
+	BatchSize int
+}
+
+type OtlpSourceConfig struct {
+	ServeAddress string
+	// Retention is how long the samples are kept, default 10m
+	Retention time.Duration
+	Convertor func(queryValue prometheusModel.Value) map[string]string
+	// StopChan stops the server and the gc of the samples, the StopChan of the ProducerConfig if nil
+	StopChan <-chan struct{}
 }
 
 type AccessLogSourceConfig struct {
@@ -47,4 +66,6 @@ type AccessLogSourceConfig struct {
 type AccessLogConvertorConfig struct {
 	Name    string // handler name
 	Handler func(logEntry []*data_accesslog.HTTPAccessLogEntry) (map[string]map[string]string, error)
+	// TcpHandler converts the tcp access logs, the tcp logs are ignored by the convertor if it's nil
+	TcpHandler func(logEntry []*data_accesslog.TCPAccessLogEntry) (map[string]map[string]string, error)
 }
@@ -1,5 +1,14 @@
 package metric
 
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"slime.io/slime/framework/monitoring"
+)
+
 type QueryMap map[string][]Handler
 
 type Handler struct {
@@ -20,3 +29,15 @@ type Source interface {
 	Reset(info string) error
 	Fullfill(map[string]map[string]string) error
 }
+
+// QueryMetric queries the metric of the source in a span, which is a child of
+// the span in ctx if any.
+func QueryMetric(ctx context.Context, source Source, queryMap QueryMap) (Metric, error) {
+	_, span := monitoring.StartSpan(ctx, "framework", "QueryMetric",
+		attribute.String("source", fmt.Sprintf("%T", source)),
+		attribute.Int("queries", len(queryMap)),
+	)
+	metric, err := source.QueryMetric(queryMap)
+	monitoring.EndSpan(span, err)
+	return metric, err
+}
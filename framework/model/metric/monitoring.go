@@ -0,0 +1,46 @@
+package metric
+
+import "slime.io/slime/framework/monitoring"
+
+var (
+	sourceLabel   = monitoring.MustCreateLabel("source")
+	resultLabel   = monitoring.MustCreateLabel("result")
+	producerLabel = monitoring.MustCreateLabel("producer")
+
+	metricQueries = monitoring.NewSum(
+		"framework",
+		"metric_queries",
+		"total number of the queries to the metric sources",
+	)
+
+	metricQueryDuration = monitoring.NewHistogram(
+		"framework",
+		"metric_query_duration",
+		"Time spent on querying the metric sources in seconds",
+		monitoring.WithHistogramBounds([]float64{.01, .05, .1, .5, 1, 3, 5, 10, 30}...),
+	)
+
+	metricCacheHits = monitoring.NewSum(
+		"framework",
+		"metric_cache_hits",
+		"total number of the handler queries served by the cache or the identical queries in flight",
+	)
+
+	prometheusRequests = monitoring.NewSum(
+		"framework",
+		"metric_prometheus_requests",
+		"total number of the requests to prometheus, the batched queries are sent in one request",
+	)
+
+	metricQueueDepth = monitoring.NewGauge(
+		"framework",
+		"metric_queue_depth",
+		"the number of the metric results produced but not consumed",
+	)
+
+	metricResultsMerged = monitoring.NewSum(
+		"framework",
+		"metric_results_merged",
+		"total number of the stale metric results replaced by the newer ones before consumed",
+	)
+)
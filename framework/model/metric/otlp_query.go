@@ -0,0 +1,575 @@
+package metric
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	prometheusModel "github.com/prometheus/common/model"
+)
+
+// otlpLookback is how far an instant selector looks back for the latest sample, same as prometheus.
+const otlpLookback = 5 * time.Minute
+
+// The query language of the OTLP source is a subset of PromQL, which covers the queries used by lazyload and
+// limiter:
+//   - instant selectors: `name{l1="v1",l2!="v2",l3=~"re",l4!~"re"}`
+//   - `rate(selector[range])` and `increase(selector[range])`, without extrapolation
+//   - aggregations: `sum`, `min`, `max`, `avg` and `count`, with `by (labels)` before or after the arguments
+//   - `histogram_quantile(φ, expr)` over the `_bucket` series
+//
+// The result is always an instant vector, so the result values are the same as the ones of the prometheus
+// source with the same convertor.
+type otlpExpr interface {
+	eval(store *otlpStore, now time.Time) (prometheusModel.Vector, error)
+}
+
+type labelMatcher struct {
+	name  prometheusModel.LabelName
+	op    string
+	value string
+	re    *regexp.Regexp
+}
+
+func (m *labelMatcher) matches(v string) bool {
+	switch m.op {
+	case "=":
+		return v == m.value
+	case "!=":
+		return v != m.value
+	case "=~":
+		return m.re.MatchString(v)
+	case "!~":
+		return !m.re.MatchString(v)
+	}
+	return false
+}
+
+func matchLabels(metric prometheusModel.Metric, matchers []*labelMatcher) bool {
+	for _, m := range matchers {
+		if !m.matches(string(metric[m.name])) {
+			return false
+		}
+	}
+	return true
+}
+
+type selectorExpr struct {
+	name     string
+	matchers []*labelMatcher
+	rng      time.Duration // only for range selectors
+}
+
+func (e *selectorExpr) eval(store *otlpStore, now time.Time) (prometheusModel.Vector, error) {
+	if e.rng > 0 {
+		return nil, fmt.Errorf("range selector %s must be used in rate or increase", e.name)
+	}
+	var ret prometheusModel.Vector
+	for _, sr := range store.selectSeries(e.name, e.matchers, now.Add(-otlpLookback), now) {
+		last := sr.samples[len(sr.samples)-1]
+		ret = append(ret, newSample(sr.metric, last.value, now))
+	}
+	return ret, nil
+}
+
+type numberExpr struct {
+	value float64
+}
+
+func (e *numberExpr) eval(*otlpStore, time.Time) (prometheusModel.Vector, error) {
+	return nil, fmt.Errorf("number %v is not a vector", e.value)
+}
+
+type rangeFuncExpr struct {
+	fn  string
+	sel *selectorExpr
+}
+
+func (e *rangeFuncExpr) eval(store *otlpStore, now time.Time) (prometheusModel.Vector, error) {
+	var ret prometheusModel.Vector
+	for _, sr := range store.selectSeries(e.sel.name, e.sel.matchers, now.Add(-e.sel.rng), now) {
+		if len(sr.samples) < 2 {
+			continue
+		}
+		var increase float64
+		for i := 1; i < len(sr.samples); i++ {
+			cur, prev := sr.samples[i].value, sr.samples[i-1].value
+			if cur < prev {
+				// counter reset
+				increase += cur
+			} else {
+				increase += cur - prev
+			}
+		}
+		value := increase
+		if e.fn == "rate" {
+			value = increase / e.sel.rng.Seconds()
+		}
+		ret = append(ret, newSample(dropName(sr.metric), value, now))
+	}
+	return ret, nil
+}
+
+type aggregateExpr struct {
+	op       string
+	grouping []prometheusModel.LabelName
+	expr     otlpExpr
+}
+
+func (e *aggregateExpr) eval(store *otlpStore, now time.Time) (prometheusModel.Vector, error) {
+	vec, err := e.expr.eval(store, now)
+	if err != nil {
+		return nil, err
+	}
+	type group struct {
+		metric prometheusModel.Metric
+		value  float64
+		count  int
+	}
+	groups := map[string]*group{}
+	var keys []string
+	for _, s := range vec {
+		metric := prometheusModel.Metric{}
+		for _, l := range e.grouping {
+			if v, ok := s.Metric[l]; ok {
+				metric[l] = v
+			}
+		}
+		key := metric.String()
+		v := float64(s.Value)
+		g := groups[key]
+		if g == nil {
+			groups[key] = &group{metric: metric, value: v, count: 1}
+			keys = append(keys, key)
+			continue
+		}
+		g.count++
+		switch e.op {
+		case "sum", "avg":
+			g.value += v
+		case "min":
+			g.value = math.Min(g.value, v)
+		case "max":
+			g.value = math.Max(g.value, v)
+		}
+	}
+
+	sort.Strings(keys)
+	ret := make(prometheusModel.Vector, 0, len(keys))
+	for _, key := range keys {
+		g := groups[key]
+		value := g.value
+		switch e.op {
+		case "avg":
+			value /= float64(g.count)
+		case "count":
+			value = float64(g.count)
+		}
+		ret = append(ret, newSample(g.metric, value, now))
+	}
+	return ret, nil
+}
+
+type histogramQuantileExpr struct {
+	quantile float64
+	expr     otlpExpr
+}
+
+func (e *histogramQuantileExpr) eval(store *otlpStore, now time.Time) (prometheusModel.Vector, error) {
+	vec, err := e.expr.eval(store, now)
+	if err != nil {
+		return nil, err
+	}
+	type bucket struct {
+		upper float64
+		count float64
+	}
+	type histogram struct {
+		metric  prometheusModel.Metric
+		buckets []bucket
+	}
+	histograms := map[string]*histogram{}
+	var keys []string
+	for _, s := range vec {
+		le, ok := s.Metric[prometheusModel.BucketLabel]
+		if !ok {
+			continue
+		}
+		upper, err := strconv.ParseFloat(string(le), 64)
+		if err != nil {
+			continue
+		}
+		metric := dropName(s.Metric)
+		delete(metric, prometheusModel.BucketLabel)
+		key := metric.String()
+		h := histograms[key]
+		if h == nil {
+			h = &histogram{metric: metric}
+			histograms[key] = h
+			keys = append(keys, key)
+		}
+		h.buckets = append(h.buckets, bucket{upper: upper, count: float64(s.Value)})
+	}
+
+	sort.Strings(keys)
+	ret := make(prometheusModel.Vector, 0, len(keys))
+	for _, key := range keys {
+		h := histograms[key]
+		sort.Slice(h.buckets, func(i, j int) bool { return h.buckets[i].upper < h.buckets[j].upper })
+		uppers, counts := make([]float64, len(h.buckets)), make([]float64, len(h.buckets))
+		for i, b := range h.buckets {
+			uppers[i], counts[i] = b.upper, b.count
+		}
+		ret = append(ret, newSample(h.metric, bucketQuantile(e.quantile, uppers, counts), now))
+	}
+	return ret, nil
+}
+
+// bucketQuantile estimates the quantile from the cumulative buckets sorted by the upper bounds, by the linear
+// interpolation in the bucket as prometheus does.
+func bucketQuantile(q float64, uppers, counts []float64) float64 {
+	switch {
+	case math.IsNaN(q):
+		return math.NaN()
+	case q < 0:
+		return math.Inf(-1)
+	case q > 1:
+		return math.Inf(1)
+	}
+	n := len(uppers)
+	if n < 2 || !math.IsInf(uppers[n-1], 1) {
+		return math.NaN()
+	}
+	// the cumulative counts are made monotonic in case of the inconsistent samples of the buckets.
+	for i := 1; i < n; i++ {
+		if counts[i] < counts[i-1] {
+			counts[i] = counts[i-1]
+		}
+	}
+	total := counts[n-1]
+	if total == 0 {
+		return math.NaN()
+	}
+	rank := q * total
+	b := sort.SearchFloat64s(counts[:n-1], rank)
+	switch {
+	case b == n-1:
+		return uppers[n-2]
+	case b == 0 && uppers[0] <= 0:
+		return uppers[0]
+	}
+	var (
+		start float64
+		end   = uppers[b]
+		count = counts[b]
+	)
+	if b > 0 {
+		start = uppers[b-1]
+		count -= counts[b-1]
+		rank -= counts[b-1]
+	}
+	return start + (end-start)*(rank/count)
+}
+
+func newSample(metric prometheusModel.Metric, value float64, now time.Time) *prometheusModel.Sample {
+	return &prometheusModel.Sample{
+		Metric:    metric,
+		Value:     prometheusModel.SampleValue(value),
+		Timestamp: prometheusModel.TimeFromUnixNano(now.UnixNano()),
+	}
+}
+
+func dropName(metric prometheusModel.Metric) prometheusModel.Metric {
+	ret := make(prometheusModel.Metric, len(metric))
+	for k, v := range metric {
+		if k != prometheusModel.MetricNameLabel {
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+// parseOtlpQuery parses the query into the expression tree.
+func parseOtlpQuery(query string) (otlpExpr, error) {
+	p := &otlpQueryParser{query: query}
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, fmt.Errorf("parse query %q failed: %v", query, err)
+	}
+	if p.skipSpaces(); p.pos < len(p.query) {
+		return nil, fmt.Errorf("parse query %q failed: unexpected %q at %d", query, p.query[p.pos:], p.pos)
+	}
+	return expr, nil
+}
+
+type otlpQueryParser struct {
+	query string
+	pos   int
+}
+
+func (p *otlpQueryParser) skipSpaces() {
+	for p.pos < len(p.query) && unicode.IsSpace(rune(p.query[p.pos])) {
+		p.pos++
+	}
+}
+
+// consume skips the token if the query continues with it.
+func (p *otlpQueryParser) consume(token string) bool {
+	p.skipSpaces()
+	if strings.HasPrefix(p.query[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+	return false
+}
+
+func (p *otlpQueryParser) expect(token string) error {
+	if !p.consume(token) {
+		return p.errorf("expect %q", token)
+	}
+	return nil
+}
+
+func (p *otlpQueryParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s at %d", fmt.Sprintf(format, args...), p.pos)
+}
+
+func isIdentChar(c byte, first bool) bool {
+	return c == '_' || c == ':' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (!first && c >= '0' && c <= '9')
+}
+
+func (p *otlpQueryParser) ident() string {
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.query) && isIdentChar(p.query[p.pos], p.pos == start) {
+		p.pos++
+	}
+	return p.query[start:p.pos]
+}
+
+func (p *otlpQueryParser) parseExpr() (otlpExpr, error) {
+	p.skipSpaces()
+	if p.pos < len(p.query) && p.query[p.pos] == '{' {
+		return p.parseSelector("")
+	}
+	if p.pos < len(p.query) && (p.query[p.pos] == '.' || (p.query[p.pos] >= '0' && p.query[p.pos] <= '9')) {
+		return p.parseNumber()
+	}
+
+	name := p.ident()
+	if name == "" {
+		return nil, p.errorf("expect expression")
+	}
+	switch name {
+	case "sum", "min", "max", "avg", "count":
+		return p.parseAggregation(name)
+	case "rate", "increase":
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		sel, err := p.parseSelector(p.ident())
+		if err != nil {
+			return nil, err
+		}
+		if sel.rng == 0 {
+			return nil, p.errorf("%s requires a range selector", name)
+		}
+		if err = p.expect(")"); err != nil {
+			return nil, err
+		}
+		return &rangeFuncExpr{fn: name, sel: sel}, nil
+	case "histogram_quantile":
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		q, err := p.parseNumber()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect(","); err != nil {
+			return nil, err
+		}
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect(")"); err != nil {
+			return nil, err
+		}
+		return &histogramQuantileExpr{quantile: q.value, expr: expr}, nil
+	}
+	if p.consume("(") {
+		return nil, fmt.Errorf("function %s is not supported", name)
+	}
+	return p.parseSelector(name)
+}
+
+func (p *otlpQueryParser) parseNumber() (*numberExpr, error) {
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.query) && strings.IndexByte("0123456789.eE+-", p.query[p.pos]) >= 0 {
+		p.pos++
+	}
+	v, err := strconv.ParseFloat(p.query[start:p.pos], 64)
+	if err != nil {
+		p.pos = start
+		return nil, p.errorf("invalid number")
+	}
+	return &numberExpr{value: v}, nil
+}
+
+func (p *otlpQueryParser) parseAggregation(op string) (otlpExpr, error) {
+	agg := &aggregateExpr{op: op}
+	if err := p.parseGrouping(agg); err != nil {
+		return nil, err
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err = p.expect(")"); err != nil {
+		return nil, err
+	}
+	agg.expr = expr
+	if agg.grouping == nil {
+		if err = p.parseGrouping(agg); err != nil {
+			return nil, err
+		}
+	}
+	return agg, nil
+}
+
+func (p *otlpQueryParser) parseGrouping(agg *aggregateExpr) error {
+	start := p.pos
+	switch p.ident() {
+	case "by":
+	case "without":
+		return p.errorf("without is not supported")
+	default:
+		p.pos = start
+		return nil
+	}
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	agg.grouping = []prometheusModel.LabelName{}
+	for !p.consume(")") {
+		if len(agg.grouping) > 0 {
+			if err := p.expect(","); err != nil {
+				return err
+			}
+		}
+		label := p.ident()
+		if label == "" {
+			return p.errorf("expect label name")
+		}
+		agg.grouping = append(agg.grouping, prometheusModel.LabelName(label))
+	}
+	return nil
+}
+
+func (p *otlpQueryParser) parseSelector(name string) (*selectorExpr, error) {
+	sel := &selectorExpr{name: name}
+	if p.consume("{") {
+		for !p.consume("}") {
+			if len(sel.matchers) > 0 {
+				if err := p.expect(","); err != nil {
+					return nil, err
+				}
+				// trailing comma
+				if p.consume("}") {
+					break
+				}
+			}
+			m, err := p.parseMatcher()
+			if err != nil {
+				return nil, err
+			}
+			if m.name == prometheusModel.MetricNameLabel && m.op == "=" && sel.name == "" {
+				sel.name = m.value
+				continue
+			}
+			sel.matchers = append(sel.matchers, m)
+		}
+	}
+	if sel.name == "" {
+		return nil, p.errorf("selector requires a metric name")
+	}
+	if p.consume("[") {
+		end := strings.IndexByte(p.query[p.pos:], ']')
+		if end < 0 {
+			return nil, p.errorf("expect \"]\"")
+		}
+		d, err := prometheusModel.ParseDuration(strings.TrimSpace(p.query[p.pos : p.pos+end]))
+		if err != nil || d <= 0 {
+			return nil, p.errorf("invalid range %q", p.query[p.pos:p.pos+end])
+		}
+		p.pos += end + 1
+		sel.rng = time.Duration(d)
+	}
+	return sel, nil
+}
+
+func (p *otlpQueryParser) parseMatcher() (*labelMatcher, error) {
+	label := p.ident()
+	if label == "" {
+		return nil, p.errorf("expect label name")
+	}
+	m := &labelMatcher{name: prometheusModel.LabelName(label)}
+	for _, op := range []string{"=~", "!~", "!=", "="} {
+		if p.consume(op) {
+			m.op = op
+			break
+		}
+	}
+	if m.op == "" {
+		return nil, p.errorf("expect label matcher operator")
+	}
+
+	p.skipSpaces()
+	if p.pos >= len(p.query) || (p.query[p.pos] != '"' && p.query[p.pos] != '\'' && p.query[p.pos] != '`') {
+		return nil, p.errorf("expect quoted label value")
+	}
+	quote := p.query[p.pos]
+	end := p.pos + 1
+	for end < len(p.query) && p.query[end] != quote {
+		if p.query[end] == '\\' && quote != '`' {
+			end++
+		}
+		end++
+	}
+	if end >= len(p.query) {
+		return nil, p.errorf("unterminated label value")
+	}
+	raw := p.query[p.pos : end+1]
+	p.pos = end + 1
+	if quote == '`' {
+		m.value = raw[1 : len(raw)-1]
+	} else {
+		if quote == '\'' {
+			raw = `"` + strings.ReplaceAll(raw[1:len(raw)-1], `"`, `\"`) + `"`
+		}
+		v, err := strconv.Unquote(raw)
+		if err != nil {
+			return nil, p.errorf("invalid label value %s", raw)
+		}
+		m.value = v
+	}
+
+	if m.op == "=~" || m.op == "!~" {
+		re, err := regexp.Compile("^(?:" + m.value + ")$")
+		if err != nil {
+			return nil, p.errorf("invalid regexp %q: %v", m.value, err)
+		}
+		m.re = re
+	}
+	return m, nil
+}
@@ -0,0 +1,156 @@
+package metric
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	prometheusModel "github.com/prometheus/common/model"
+	log "github.com/sirupsen/logrus"
+	collectormetricsv1 "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/grpc"
+
+	bootconfig "slime.io/slime/framework/apis/config/v1alpha1"
+)
+
+const (
+	defaultOtlpRetention = 10 * time.Minute
+	minOtlpRetention     = time.Second
+)
+
+// OtlpSource receives the metrics by the OTLP grpc metrics service, keeps the recent samples in memory and
+// answers the queries of the handlers, so that the metrics can be consumed without a prometheus server.
+// See otlp_query.go for the supported queries.
+type OtlpSource struct {
+	collectormetricsv1.UnimplementedMetricsServiceServer
+	sync.Once
+
+	serveAddress string
+	store        *otlpStore
+	convertor    func(queryValue prometheusModel.Value) map[string]string
+	stop         <-chan struct{}
+}
+
+func NewOtlpSource(config OtlpSourceConfig) *OtlpSource {
+	retention := config.Retention
+	if retention <= 0 {
+		retention = defaultOtlpRetention
+	} else if retention < minOtlpRetention {
+		retention = minOtlpRetention
+	}
+	s := &OtlpSource{
+		serveAddress: config.ServeAddress,
+		store:        newOtlpStore(retention),
+		convertor:    defaultConvertor,
+		stop:         config.StopChan,
+	}
+	if config.Convertor != nil {
+		s.convertor = config.Convertor
+	}
+	return s
+}
+
+// NewOtlpSourceConfig builds the OtlpSourceConfig from the framework metric config.
+func NewOtlpSourceConfig(cfg *bootconfig.Otlp_Source) (OtlpSourceConfig, error) {
+	config := OtlpSourceConfig{ServeAddress: cfg.GetAddress()}
+	if cfg.GetRetention() != "" {
+		retention, err := time.ParseDuration(cfg.GetRetention())
+		if err != nil {
+			return config, fmt.Errorf("invalid otlp retention %q: %v", cfg.GetRetention(), err)
+		}
+		if retention < minOtlpRetention {
+			return config, fmt.Errorf("invalid otlp retention %q: should be at least %s", cfg.GetRetention(),
+				minOtlpRetention)
+		}
+		config.Retention = retention
+	}
+	return config, nil
+}
+
+// Export accepts the metrics from the OTLP exporters
+func (s *OtlpSource) Export(
+	_ context.Context,
+	req *collectormetricsv1.ExportMetricsServiceRequest,
+) (*collectormetricsv1.ExportMetricsServiceResponse, error) {
+	s.store.appendResourceMetrics(req.GetResourceMetrics(), time.Now())
+	return &collectormetricsv1.ExportMetricsServiceResponse{}, nil
+}
+
+// Start grpc server
+func (s *OtlpSource) Start() error {
+	var err error
+	s.Do(func() {
+		log := log.WithField("reporter", "OtlpSource").WithField("function", "Start")
+		var lis net.Listener
+		lis, err = net.Listen("tcp", s.serveAddress)
+		if err != nil {
+			return
+		}
+
+		server := grpc.NewServer()
+		collectormetricsv1.RegisterMetricsServiceServer(server, s)
+
+		go func() {
+			log.Infof("otlp metrics grpc server starts on %s", s.serveAddress)
+			if errL := server.Serve(lis); errL != nil {
+				log.Errorf("otlp metrics grpc server error: %+v", errL)
+			}
+		}()
+
+		go func() {
+			ticker := time.NewTicker(s.store.retention / 10)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-s.stop:
+					server.Stop()
+					log.Infof("otlp metrics grpc server stopped")
+					return
+				case now := <-ticker.C:
+					s.store.gc(now)
+				}
+			}
+		}()
+	})
+	return err
+}
+
+func (s *OtlpSource) QueryMetric(queryMap QueryMap) (Metric, error) {
+	log := log.WithField("reporter", "OtlpSource").WithField("function", "QueryMetric")
+
+	metric := make(map[string][]Result)
+	now := time.Now()
+	for meta, handlers := range queryMap {
+		for _, handler := range handlers {
+			expr, err := parseOtlpQuery(handler.Query)
+			if err != nil {
+				log.Debugf("failed to parse query, name: %s, error: %+v", handler.Name, err)
+				return nil, err
+			}
+			vector, err := expr.eval(s.store, now)
+			if err != nil {
+				log.Debugf("failed to eval query, name: %s, query: %s, error: %+v", handler.Name, handler.Query, err)
+				return nil, fmt.Errorf("failed to eval query %q, error: %+v", handler.Query, err)
+			}
+			if vector == nil {
+				vector = prometheusModel.Vector{}
+			}
+			metric[meta] = append(metric[meta], Result{
+				Name:  handler.Name,
+				Value: s.convertor(vector),
+			})
+		}
+	}
+	log.Debugf("successfully get metric from otlp source")
+	return metric, nil
+}
+
+func (s *OtlpSource) Reset(_ string) error {
+	return nil
+}
+
+func (s *OtlpSource) Fullfill(_ map[string]map[string]string) error {
+	return nil
+}
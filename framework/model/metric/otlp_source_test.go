@@ -0,0 +1,240 @@
+package metric
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	prometheusModel "github.com/prometheus/common/model"
+	collectormetricsv1 "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	metricsv1 "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+
+	bootconfig "slime.io/slime/framework/apis/config/v1alpha1"
+)
+
+func otlpAttrs(kvs ...string) []*commonv1.KeyValue {
+	var ret []*commonv1.KeyValue
+	for i := 0; i+1 < len(kvs); i += 2 {
+		ret = append(ret, &commonv1.KeyValue{
+			Key:   kvs[i],
+			Value: &commonv1.AnyValue{Value: &commonv1.AnyValue_StringValue{StringValue: kvs[i+1]}},
+		})
+	}
+	return ret
+}
+
+func exportOtlp(t *testing.T, s *OtlpSource, metrics ...*metricsv1.Metric) {
+	t.Helper()
+	_, err := s.Export(context.Background(), &collectormetricsv1.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricsv1.ResourceMetrics{{
+			Resource:     &resourcev1.Resource{Attributes: otlpAttrs("namespace", "default")},
+			ScopeMetrics: []*metricsv1.ScopeMetrics{{Metrics: metrics}},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func counter(name string, ts time.Time, value int64, kvs ...string) *metricsv1.Metric {
+	return &metricsv1.Metric{
+		Name: name,
+		Data: &metricsv1.Metric_Sum{Sum: &metricsv1.Sum{
+			AggregationTemporality: metricsv1.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+			IsMonotonic:            true,
+			DataPoints: []*metricsv1.NumberDataPoint{{
+				Attributes:   otlpAttrs(kvs...),
+				TimeUnixNano: uint64(ts.UnixNano()),
+				Value:        &metricsv1.NumberDataPoint_AsInt{AsInt: value},
+			}},
+		}},
+	}
+}
+
+func TestOtlpSourceQueryMetric(t *testing.T) {
+	s := NewOtlpSource(OtlpSourceConfig{})
+	now := time.Now()
+	t0, t1, t2 := now.Add(-90*time.Second), now.Add(-60*time.Second), now.Add(-30*time.Second)
+	sum := 1000.0
+
+	exportOtlp(t, s,
+		counter("istio.requests.total", t0, 10, "destination_service", "a", "source_app", "foo"),
+		counter("istio.requests.total", t0, 5, "destination_service", "b", "source_app", "foo"),
+	)
+	exportOtlp(t, s,
+		counter("istio.requests.total", t1, 20, "destination_service", "a", "source_app", "foo"),
+		counter("istio.requests.total", t1, 7, "destination_service", "b", "source_app", "foo"),
+		counter("istio.requests.total", t1, 3, "destination_service", "c", "source_app", "bar"),
+	)
+	// the counter of b is reset
+	exportOtlp(t, s,
+		counter("istio.requests.total", t2, 2, "destination_service", "b", "source_app", "foo"),
+		&metricsv1.Metric{
+			Name: "container.cpu.usage.seconds.total",
+			Data: &metricsv1.Metric_Gauge{Gauge: &metricsv1.Gauge{DataPoints: []*metricsv1.NumberDataPoint{
+				{Attributes: otlpAttrs("pod", "foo-1"), Value: &metricsv1.NumberDataPoint_AsDouble{AsDouble: 1.5}},
+				{Attributes: otlpAttrs("pod", "foo-2"), Value: &metricsv1.NumberDataPoint_AsDouble{AsDouble: 2.5}},
+				{Attributes: otlpAttrs("pod", "bar-1"), Value: &metricsv1.NumberDataPoint_AsDouble{AsDouble: 9}},
+			}}},
+		},
+		&metricsv1.Metric{
+			Name: "request.duration",
+			Data: &metricsv1.Metric_Histogram{Histogram: &metricsv1.Histogram{
+				AggregationTemporality: metricsv1.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA,
+				DataPoints: []*metricsv1.HistogramDataPoint{{
+					Attributes:     otlpAttrs("pod", "foo-1"),
+					TimeUnixNano:   uint64(t2.UnixNano()),
+					Count:          100,
+					Sum:            &sum,
+					ExplicitBounds: []float64{10, 20, 50},
+					BucketCounts:   []uint64{50, 40, 10, 0},
+				}},
+			}},
+		},
+	)
+
+	tests := []struct {
+		query string
+		want  map[string]string
+	}{
+		{
+			query: `sum(istio_requests_total{source_app="foo"})by(destination_service)`,
+			want:  map[string]string{`{destination_service="a"}`: "20", `{destination_service="b"}`: "2"},
+		},
+		{
+			query: `sum by (source_app) (increase(istio_requests_total{namespace="default"}[2m]))`,
+			want:  map[string]string{`{source_app="foo"}`: "14"},
+		},
+		{
+			query: `rate(istio_requests_total{destination_service="a"}[2m])`,
+			want:  map[string]string{`{destination_service="a", namespace="default", source_app="foo"}`: "0.08333333333333333"},
+		},
+		{
+			query: `max(container_cpu_usage_seconds_total{namespace="default",pod=~"foo-.*"})`,
+			want:  map[string]string{`{}`: "2.5"},
+		},
+		{
+			query: `count(container_cpu_usage_seconds_total{pod!~"foo-.*"})`,
+			want:  map[string]string{`{}`: "1"},
+		},
+		{
+			query: `histogram_quantile(0.9, sum(request_duration_bucket{pod="foo-1"})by(le))`,
+			want:  map[string]string{`{}`: "20"},
+		},
+		{
+			query: `histogram_quantile(0.5, request_duration_bucket)`,
+			want:  map[string]string{`{namespace="default", pod="foo-1"}`: "10"},
+		},
+		{
+			query: `request_duration_count`,
+			want:  map[string]string{`request_duration_count{namespace="default", pod="foo-1"}`: "100"},
+		},
+		{
+			query: `sum(not_exist)`,
+			want:  map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			m, err := s.QueryMetric(QueryMap{"meta": {{Name: "h", Query: tt.query}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := m["meta"][0].Value
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseOtlpQueryError(t *testing.T) {
+	for _, query := range []string{
+		`rate(foo)`,
+		`foo{a="b"`,
+		`foo{a~"b"}`,
+		`topk(3, foo)`,
+		`sum without (a) (foo)`,
+		`foo[5x]`,
+		`{a="b"}`,
+		`foo bar`,
+	} {
+		if _, err := parseOtlpQuery(query); err == nil {
+			t.Errorf("expect error for %s", query)
+		}
+	}
+}
+
+func TestOtlpStoreGC(t *testing.T) {
+	store := newOtlpStore(time.Minute)
+	now := time.Now()
+	old := prometheusModel.Metric{prometheusModel.MetricNameLabel: "foo", "pod": "old"}
+	recent := prometheusModel.Metric{prometheusModel.MetricNameLabel: "foo", "pod": "recent"}
+	store.append(old, now.Add(-2*time.Minute), 1, false)
+	store.append(recent, now.Add(-2*time.Minute), 1, true)
+	store.append(recent, now.Add(-30*time.Second), 2, true)
+
+	store.gc(now)
+	series := store.selectSeries("foo", nil, now.Add(-time.Hour), now)
+	if len(series) != 1 || series[0].metric["pod"] != "recent" {
+		t.Fatalf("unexpected series after gc: %v", series)
+	}
+	// the delta samples are accumulated
+	if samples := series[0].samples; len(samples) != 1 || samples[0].value != 3 {
+		t.Fatalf("unexpected samples after gc: %v", samples)
+	}
+}
+
+func TestNewOtlpSourceConfig(t *testing.T) {
+	for retention, valid := range map[string]bool{"": true, "1m": true, "1s": true, "0s": false, "5ns": false, "x": false} {
+		config, err := NewOtlpSourceConfig(&bootconfig.Otlp_Source{Retention: retention})
+		if (err == nil) != valid {
+			t.Errorf("retention %q expect valid %v, got err %v", retention, valid, err)
+		}
+		if err == nil {
+			// must not panic for the gc ticker
+			NewOtlpSource(config)
+		}
+	}
+	if s := NewOtlpSource(OtlpSourceConfig{Retention: 5}); s.store.retention != minOtlpRetention {
+		t.Errorf("expect retention clamped to %s, got %s", minOtlpRetention, s.store.retention)
+	}
+}
+
+func TestOtlpSourceStop(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := lis.Addr().String()
+	_ = lis.Close()
+
+	stop := make(chan struct{})
+	s := NewOtlpSource(OtlpSourceConfig{ServeAddress: addr, StopChan: stop})
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	close(stop)
+
+	// the grpc server is stopped along with the gc loop
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			return
+		}
+		_ = conn.Close()
+		if time.Now().After(deadline) {
+			t.Fatalf("otlp source still serving after stopped")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
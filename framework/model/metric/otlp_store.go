@@ -0,0 +1,298 @@
+package metric
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	prometheusModel "github.com/prometheus/common/model"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	metricsv1 "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+type otlpSample struct {
+	ts    time.Time
+	value float64
+}
+
+type otlpSeries struct {
+	metric prometheusModel.Metric
+	// samples are ordered by time
+	samples []otlpSample
+}
+
+// otlpStore keeps the recent samples of the series converted from OTLP metrics, in the same naming as the
+// prometheus exporter of OpenTelemetry:
+//   - the names and label names are sanitized to `[a-zA-Z0-9_:]`, e.g. `http.server.duration` to
+//     `http_server_duration`. The resource attributes are merged into the labels, overridden by the data point ones.
+//   - gauges and sums are stored by the name, delta sums are accumulated into cumulative ones.
+//   - histograms are stored as `<name>_bucket{le="..."}`, `<name>_sum` and `<name>_count`, and summaries as
+//     `<name>{quantile="..."}`, `<name>_sum` and `<name>_count`.
+type otlpStore struct {
+	retention time.Duration
+
+	mut sync.RWMutex
+	// series keyed by the name and then the label set string
+	series map[string]map[string]*otlpSeries
+}
+
+func newOtlpStore(retention time.Duration) *otlpStore {
+	return &otlpStore{
+		retention: retention,
+		series:    map[string]map[string]*otlpSeries{},
+	}
+}
+
+func (s *otlpStore) append(metric prometheusModel.Metric, ts time.Time, value float64, delta bool) {
+	name := string(metric[prometheusModel.MetricNameLabel])
+	key := metric.String()
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	byName := s.series[name]
+	if byName == nil {
+		byName = map[string]*otlpSeries{}
+		s.series[name] = byName
+	}
+	sr := byName[key]
+	if sr == nil {
+		sr = &otlpSeries{metric: metric}
+		byName[key] = sr
+	}
+	if n := len(sr.samples); n > 0 {
+		last := sr.samples[n-1]
+		if delta {
+			value += last.value
+		}
+		if !ts.After(last.ts) {
+			// out of order or duplicated samples, only the latest value at the time is kept
+			if ts.Equal(last.ts) {
+				sr.samples[n-1].value = value
+			}
+			return
+		}
+	}
+	sr.samples = append(sr.samples, otlpSample{ts: ts, value: value})
+}
+
+// gc drops the samples older than the retention, and the series without samples.
+func (s *otlpStore) gc(now time.Time) {
+	deadline := now.Add(-s.retention)
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	for name, byName := range s.series {
+		for key, sr := range byName {
+			idx := sort.Search(len(sr.samples), func(i int) bool { return sr.samples[i].ts.After(deadline) })
+			if idx == len(sr.samples) {
+				delete(byName, key)
+				continue
+			}
+			if idx > 0 {
+				sr.samples = append([]otlpSample(nil), sr.samples[idx:]...)
+			}
+		}
+		if len(byName) == 0 {
+			delete(s.series, name)
+		}
+	}
+}
+
+// selectSeries returns the copies of the series of the name matching the matchers, with the samples in (from, to].
+func (s *otlpStore) selectSeries(name string, matchers []*labelMatcher, from, to time.Time) []*otlpSeries {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	var ret []*otlpSeries
+	for _, sr := range s.series[name] {
+		if !matchLabels(sr.metric, matchers) {
+			continue
+		}
+		begin := sort.Search(len(sr.samples), func(i int) bool { return sr.samples[i].ts.After(from) })
+		end := sort.Search(len(sr.samples), func(i int) bool { return sr.samples[i].ts.After(to) })
+		if begin >= end {
+			continue
+		}
+		ret = append(ret, &otlpSeries{
+			metric:  sr.metric,
+			samples: append([]otlpSample(nil), sr.samples[begin:end]...),
+		})
+	}
+	return ret
+}
+
+// appendResourceMetrics converts and stores the OTLP metrics.
+func (s *otlpStore) appendResourceMetrics(rms []*metricsv1.ResourceMetrics, now time.Time) {
+	for _, rm := range rms {
+		resourceLabels := attributesToLabels(rm.GetResource().GetAttributes(), nil)
+		for _, sm := range rm.GetScopeMetrics() {
+			for _, m := range sm.GetMetrics() {
+				s.appendMetric(m, resourceLabels, now)
+			}
+		}
+	}
+}
+
+func (s *otlpStore) appendMetric(m *metricsv1.Metric, resourceLabels prometheusModel.LabelSet, now time.Time) {
+	name := sanitizeName(m.GetName())
+	if name == "" {
+		return
+	}
+	seriesMetric := func(attrs []*commonv1.KeyValue, suffix string, extra ...string) prometheusModel.Metric {
+		labels := attributesToLabels(attrs, resourceLabels)
+		for i := 0; i+1 < len(extra); i += 2 {
+			labels[prometheusModel.LabelName(extra[i])] = prometheusModel.LabelValue(extra[i+1])
+		}
+		labels[prometheusModel.MetricNameLabel] = prometheusModel.LabelValue(name + suffix)
+		return prometheusModel.Metric(labels)
+	}
+	ts := func(unixNano uint64) time.Time {
+		if unixNano == 0 {
+			return now
+		}
+		return time.Unix(0, int64(unixNano))
+	}
+
+	switch data := m.GetData().(type) {
+	case *metricsv1.Metric_Gauge:
+		for _, dp := range data.Gauge.GetDataPoints() {
+			if noRecordedValue(dp.GetFlags()) {
+				continue
+			}
+			s.append(seriesMetric(dp.GetAttributes(), ""), ts(dp.GetTimeUnixNano()), numberValue(dp), false)
+		}
+	case *metricsv1.Metric_Sum:
+		delta := data.Sum.GetAggregationTemporality() == metricsv1.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+		for _, dp := range data.Sum.GetDataPoints() {
+			if noRecordedValue(dp.GetFlags()) {
+				continue
+			}
+			s.append(seriesMetric(dp.GetAttributes(), ""), ts(dp.GetTimeUnixNano()), numberValue(dp), delta)
+		}
+	case *metricsv1.Metric_Histogram:
+		delta := data.Histogram.GetAggregationTemporality() ==
+			metricsv1.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+		for _, dp := range data.Histogram.GetDataPoints() {
+			if noRecordedValue(dp.GetFlags()) {
+				continue
+			}
+			t := ts(dp.GetTimeUnixNano())
+			var cumulative uint64
+			for i, bound := range dp.GetExplicitBounds() {
+				if i < len(dp.GetBucketCounts()) {
+					cumulative += dp.GetBucketCounts()[i]
+				}
+				s.append(seriesMetric(dp.GetAttributes(), "_bucket", "le", formatFloat(bound)), t,
+					float64(cumulative), delta)
+			}
+			s.append(seriesMetric(dp.GetAttributes(), "_bucket", "le", "+Inf"), t, float64(dp.GetCount()), delta)
+			s.append(seriesMetric(dp.GetAttributes(), "_count"), t, float64(dp.GetCount()), delta)
+			if dp.Sum != nil {
+				s.append(seriesMetric(dp.GetAttributes(), "_sum"), t, dp.GetSum(), delta)
+			}
+		}
+	case *metricsv1.Metric_ExponentialHistogram:
+		delta := data.ExponentialHistogram.GetAggregationTemporality() ==
+			metricsv1.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+		for _, dp := range data.ExponentialHistogram.GetDataPoints() {
+			if noRecordedValue(dp.GetFlags()) {
+				continue
+			}
+			t := ts(dp.GetTimeUnixNano())
+			s.append(seriesMetric(dp.GetAttributes(), "_count"), t, float64(dp.GetCount()), delta)
+			if dp.Sum != nil {
+				s.append(seriesMetric(dp.GetAttributes(), "_sum"), t, dp.GetSum(), delta)
+			}
+		}
+	case *metricsv1.Metric_Summary:
+		for _, dp := range data.Summary.GetDataPoints() {
+			if noRecordedValue(dp.GetFlags()) {
+				continue
+			}
+			t := ts(dp.GetTimeUnixNano())
+			for _, q := range dp.GetQuantileValues() {
+				s.append(seriesMetric(dp.GetAttributes(), "", "quantile", formatFloat(q.GetQuantile())), t,
+					q.GetValue(), false)
+			}
+			s.append(seriesMetric(dp.GetAttributes(), "_count"), t, float64(dp.GetCount()), false)
+			s.append(seriesMetric(dp.GetAttributes(), "_sum"), t, dp.GetSum(), false)
+		}
+	}
+}
+
+func noRecordedValue(flags uint32) bool {
+	return flags&uint32(metricsv1.DataPointFlags_DATA_POINT_FLAGS_NO_RECORDED_VALUE_MASK) != 0
+}
+
+func numberValue(dp *metricsv1.NumberDataPoint) float64 {
+	switch v := dp.GetValue().(type) {
+	case *metricsv1.NumberDataPoint_AsDouble:
+		return v.AsDouble
+	case *metricsv1.NumberDataPoint_AsInt:
+		return float64(v.AsInt)
+	}
+	return 0
+}
+
+// attributesToLabels converts the attributes to labels on top of the base ones.
+func attributesToLabels(attrs []*commonv1.KeyValue, base prometheusModel.LabelSet) prometheusModel.LabelSet {
+	labels := make(prometheusModel.LabelSet, len(base)+len(attrs))
+	for k, v := range base {
+		labels[k] = v
+	}
+	for _, kv := range attrs {
+		name := sanitizeLabelName(kv.GetKey())
+		if name == "" {
+			continue
+		}
+		labels[prometheusModel.LabelName(name)] = prometheusModel.LabelValue(anyValueString(kv.GetValue()))
+	}
+	return labels
+}
+
+func anyValueString(v *commonv1.AnyValue) string {
+	switch val := v.GetValue().(type) {
+	case *commonv1.AnyValue_StringValue:
+		return val.StringValue
+	case *commonv1.AnyValue_BoolValue:
+		return strconv.FormatBool(val.BoolValue)
+	case *commonv1.AnyValue_IntValue:
+		return strconv.FormatInt(val.IntValue, 10)
+	case *commonv1.AnyValue_DoubleValue:
+		return formatFloat(val.DoubleValue)
+	}
+	return ""
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func sanitizeName(name string) string {
+	return sanitize(name, true)
+}
+
+func sanitizeLabelName(name string) string {
+	return sanitize(name, false)
+}
+
+func sanitize(name string, allowColon bool) string {
+	if name == "" {
+		return ""
+	}
+	var b strings.Builder
+	for i, r := range name {
+		valid := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') ||
+			(r >= '0' && r <= '9' && i > 0) || (allowColon && r == ':')
+		if valid {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
@@ -38,11 +38,43 @@ func NewSource(config *ProducerConfig) Source {
 	var source Source
 	switch {
 	case config.EnablePrometheusSource:
-		source = NewPrometheusSource(config.PrometheusSourceConfig)
+		psc := config.PrometheusSourceConfig
+		if psc.Timeout == 0 {
+			psc.Timeout = config.QueryConfig.Timeout
+		}
+		if psc.BatchSize == 0 {
+			psc.BatchSize = config.QueryConfig.BatchSize
+		}
+		source = NewPrometheusSource(psc)
+	case config.EnableOtlpSource:
+		osc := config.OtlpSourceConfig
+		if osc.StopChan == nil {
+			osc.StopChan = config.StopChan
+		}
+		source = NewOtlpSource(osc)
 	case config.EnableMockSource:
 		source = NewMockSource()
 	default:
 		source = NewAccessLogSource(config.AccessLogSourceConfig)
 	}
-	return source
+	return NewQuerySource(source, config.QueryConfig)
+}
+
+// mergeMetric merges the metric into the pending one not consumed yet, the
+// stale results of the same meta are replaced by the newer ones.
+func mergeMetric(producer string, pending, metric Metric) Metric {
+	if pending == nil {
+		return metric
+	}
+	merged := 0
+	for meta, results := range metric {
+		if _, ok := pending[meta]; ok {
+			merged++
+		}
+		pending[meta] = results
+	}
+	if merged > 0 {
+		metricResultsMerged.With(producerLabel.Value(producer)).Add(float64(merged))
+	}
+	return pending
 }
@@ -3,7 +3,9 @@ package metric
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	prometheus "github.com/prometheus/client_golang/api/prometheus/v1"
@@ -11,15 +13,29 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// batchLabel is added to the series of each query in a batch, to tell which
+// query the series belongs to.
+const batchLabel = "__slime_batch"
+
 type PrometheusSource struct {
 	api       prometheus.API
 	convertor func(queryValue prometheusModel.Value) map[string]string
+	timeout   time.Duration
+	batchSize int
+
+	// unbatchable records the queries not resulting in instant vectors, which
+	// can not be merged with the others
+	mut         sync.Mutex
+	unbatchable map[string]struct{}
 }
 
 func NewPrometheusSource(config PrometheusSourceConfig) *PrometheusSource {
 	ps := &PrometheusSource{
-		api:       config.Api,
-		convertor: defaultConvertor,
+		api:         config.Api,
+		convertor:   defaultConvertor,
+		timeout:     config.Timeout,
+		batchSize:   config.BatchSize,
+		unbatchable: map[string]struct{}{},
 	}
 	if config.Convertor != nil {
 		ps.convertor = config.Convertor
@@ -31,38 +47,144 @@ func (ps *PrometheusSource) Start() error {
 	return nil
 }
 
+// QueryMetric queries each distinct PromQL of the handlers once, and merges
+// up to batchSize instant vector queries into one request if configured.
 func (ps *PrometheusSource) QueryMetric(queryMap QueryMap) (Metric, error) {
 	log := log.WithField("reporter", "PrometheusSource").WithField("function", "QueryMetric")
 
-	metric := make(map[string][]Result)
+	var queries []string
+	values := make(map[string]prometheusModel.Value)
+	for _, handlers := range queryMap {
+		for _, handler := range handlers {
+			if _, ok := values[handler.Query]; !ok {
+				values[handler.Query] = nil
+				queries = append(queries, handler.Query)
+			}
+		}
+	}
 
-	for meta, handlers := range queryMap {
-		if len(handlers) == 0 {
+	var batch []string
+	for _, query := range queries {
+		if ps.batchSize < 2 || !ps.batchable(query) {
+			if err := ps.query(query, values); err != nil {
+				log.Debugf("failed get metric from prometheus, query: %s, error: %+v", query, err)
+				return nil, err
+			}
 			continue
 		}
+		if batch = append(batch, query); len(batch) >= ps.batchSize {
+			if err := ps.queryBatch(batch, values); err != nil {
+				log.Debugf("failed get metric from prometheus, queries: %v, error: %+v", batch, err)
+				return nil, err
+			}
+			batch = nil
+		}
+	}
+	if err := ps.queryBatch(batch, values); err != nil {
+		log.Debugf("failed get metric from prometheus, queries: %v, error: %+v", batch, err)
+		return nil, err
+	}
 
+	metric := make(map[string][]Result)
+	for meta, handlers := range queryMap {
 		for _, handler := range handlers {
-			queryValue, w, e := ps.api.Query(context.Background(), handler.Query, time.Now())
-			if e != nil {
-				log.Debugf("failed get metric from prometheus, name: %s, query: %s, error: %+v",
-					handler.Name, handler.Query, e)
-				return nil, fmt.Errorf("failed to get metric from prometheus, error: %+v", e)
-			} else if w != nil {
-				log.Debugf("failed get metric from prometheus, name: %s, query: %s, warning: %s",
-					handler.Name, handler.Query, strings.Join(w, ";"))
-				return nil, fmt.Errorf("failed to get metric from prometheus, warning: %s", strings.Join(w, ";"))
-			}
-			result := Result{
+			metric[meta] = append(metric[meta], Result{
 				Name:  handler.Name,
-				Value: ps.convertor(queryValue),
-			}
-			metric[meta] = append(metric[meta], result)
+				Value: ps.convertor(values[handler.Query]),
+			})
 		}
 	}
 	log.Debugf("successfully get metric from prometheus")
 	return metric, nil
 }
 
+func (ps *PrometheusSource) query(query string, values map[string]prometheusModel.Value) error {
+	ctx := context.Background()
+	if ps.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ps.timeout)
+		defer cancel()
+	}
+	prometheusRequests.Increment()
+	queryValue, w, e := ps.api.Query(ctx, query, time.Now())
+	if e != nil {
+		return fmt.Errorf("failed to get metric from prometheus, error: %+v", e)
+	} else if w != nil {
+		return fmt.Errorf("failed to get metric from prometheus, warning: %s", strings.Join(w, ";"))
+	}
+	values[query] = queryValue
+	return nil
+}
+
+// queryBatch queries the batch in one request like
+// `label_replace(q0, "__slime_batch", "0", "__slime_batch", "") or ...`,
+// and splits the result by the batch label. If the batch fails, the queries
+// are sent one by one, and those not resulting in instant vectors are
+// recorded as unbatchable.
+func (ps *PrometheusSource) queryBatch(batch []string, values map[string]prometheusModel.Value) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	if len(batch) > 1 {
+		exprs := make([]string, 0, len(batch))
+		for i, query := range batch {
+			exprs = append(exprs, fmt.Sprintf(`label_replace((%s), "%s", "%d", "%s", "")`,
+				query, batchLabel, i, batchLabel))
+		}
+		query, merged := strings.Join(exprs, " or "), make(map[string]prometheusModel.Value, 1)
+		if err := ps.query(query, merged); err == nil {
+			if vector, ok := merged[query].(prometheusModel.Vector); ok {
+				splitBatch(batch, vector, values)
+				return nil
+			}
+		}
+	}
+
+	for _, query := range batch {
+		if err := ps.query(query, values); err != nil {
+			return err
+		}
+		if _, ok := values[query].(prometheusModel.Vector); !ok {
+			ps.mut.Lock()
+			ps.unbatchable[query] = struct{}{}
+			ps.mut.Unlock()
+		}
+	}
+	return nil
+}
+
+func (ps *PrometheusSource) batchable(query string) bool {
+	ps.mut.Lock()
+	defer ps.mut.Unlock()
+	_, ok := ps.unbatchable[query]
+	return !ok
+}
+
+func splitBatch(batch []string, vector prometheusModel.Vector, values map[string]prometheusModel.Value) {
+	vectors := make([]prometheusModel.Vector, len(batch))
+	for _, sample := range vector {
+		i, err := strconv.Atoi(string(sample.Metric[batchLabel]))
+		if err != nil || i < 0 || i >= len(batch) {
+			continue
+		}
+		m := make(prometheusModel.Metric, len(sample.Metric)-1)
+		for k, v := range sample.Metric {
+			if k != batchLabel {
+				m[k] = v
+			}
+		}
+		s := *sample
+		s.Metric = m
+		vectors[i] = append(vectors[i], &s)
+	}
+	for i, query := range batch {
+		if vectors[i] == nil {
+			vectors[i] = prometheusModel.Vector{}
+		}
+		values[query] = vectors[i]
+	}
+}
+
 func (ps *PrometheusSource) Reset(_ string) error {
 	return nil
 }
@@ -0,0 +1,309 @@
+package metric
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+// The misc keys of the global config to configure how the metric is queried
+// from the source.
+const (
+	// QueryMiscCacheTTL is how long the results of the queries are cached, like
+	// "5s". The results are not cached by default.
+	QueryMiscCacheTTL = "metric-query-cache-ttl"
+	// QueryMiscTimeout is the timeout of a query, including the time waiting for
+	// the rate limit, 30s by default
+	QueryMiscTimeout = "metric-query-timeout"
+	// QueryMiscQPS limits the queries to the source per second, no limit by default
+	QueryMiscQPS = "metric-query-qps"
+	// QueryMiscBurst is the burst of the queries, the ceil of the qps by default
+	QueryMiscBurst = "metric-query-burst"
+	// QueryMiscBatchSize is the max number of PromQL merged into one prometheus
+	// request, the queries are not merged by default
+	QueryMiscBatchSize = "metric-query-batch-size"
+)
+
+const defaultQueryTimeout = 30 * time.Second
+
+var errQueryTimeout = errors.New("query metric timeout")
+
+// QueryConfig configures the QuerySource in front of the metric source.
+type QueryConfig struct {
+	CacheTTL  time.Duration
+	Timeout   time.Duration
+	QPS       float64
+	Burst     int
+	BatchSize int
+}
+
+// NewQueryConfig parses the query config from the misc of the global config.
+func NewQueryConfig(misc map[string]string) (QueryConfig, error) {
+	cfg := QueryConfig{Timeout: defaultQueryTimeout}
+	var err error
+	if s := misc[QueryMiscCacheTTL]; s != "" {
+		if cfg.CacheTTL, err = time.ParseDuration(s); err != nil || cfg.CacheTTL < 0 {
+			return cfg, fmt.Errorf("invalid %s %q", QueryMiscCacheTTL, s)
+		}
+	}
+	if s := misc[QueryMiscTimeout]; s != "" {
+		if cfg.Timeout, err = time.ParseDuration(s); err != nil || cfg.Timeout < 0 {
+			return cfg, fmt.Errorf("invalid %s %q", QueryMiscTimeout, s)
+		}
+	}
+	if s := misc[QueryMiscQPS]; s != "" {
+		if cfg.QPS, err = strconv.ParseFloat(s, 64); err != nil || cfg.QPS < 0 {
+			return cfg, fmt.Errorf("invalid %s %q", QueryMiscQPS, s)
+		}
+	}
+	cfg.Burst = int(math.Ceil(cfg.QPS))
+	if s := misc[QueryMiscBurst]; s != "" {
+		if cfg.Burst, err = strconv.Atoi(s); err != nil || cfg.Burst < 1 {
+			return cfg, fmt.Errorf("invalid %s %q", QueryMiscBurst, s)
+		}
+	}
+	if s := misc[QueryMiscBatchSize]; s != "" {
+		if cfg.BatchSize, err = strconv.Atoi(s); err != nil || cfg.BatchSize < 0 {
+			return cfg, fmt.Errorf("invalid %s %q", QueryMiscBatchSize, s)
+		}
+	}
+	return cfg, nil
+}
+
+type queryKey struct {
+	meta, name, query string
+}
+
+// queryCall is a query of a handler in flight or finished.
+type queryCall struct {
+	done    chan struct{}
+	results []Result
+	err     error
+	expire  time.Time
+}
+
+// QuerySource queries the metric of the underlying source for the producers.
+// The identical queries of the handlers in flight are coalesced into one, and
+// the results are cached for the ttl. The queries to the source are limited
+// by the rate limiter and the timeout.
+type QuerySource struct {
+	Source
+
+	name    string
+	cfg     QueryConfig
+	limiter *rate.Limiter
+
+	mut   sync.Mutex
+	calls map[queryKey]*queryCall
+	now   func() time.Time
+}
+
+func NewQuerySource(source Source, cfg QueryConfig) *QuerySource {
+	limiter := rate.NewLimiter(rate.Inf, 0)
+	if cfg.QPS > 0 {
+		burst := cfg.Burst
+		if burst < 1 {
+			burst = 1
+		}
+		limiter = rate.NewLimiter(rate.Limit(cfg.QPS), burst)
+	}
+	return &QuerySource{
+		Source:  source,
+		name:    strings.TrimPrefix(fmt.Sprintf("%T", source), "*metric."),
+		cfg:     cfg,
+		limiter: limiter,
+		calls:   map[queryKey]*queryCall{},
+		now:     time.Now,
+	}
+}
+
+func (s *QuerySource) QueryMetric(queryMap QueryMap) (Metric, error) {
+	ctx := context.Background()
+	if s.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+	}
+
+	now := s.now()
+	calls := make(map[queryKey]*queryCall)
+	owned := make(map[queryKey]*queryCall)
+	missed := make(QueryMap)
+
+	s.mut.Lock()
+	for meta, handlers := range queryMap {
+		for _, handler := range handlers {
+			key := queryKey{meta: meta, name: handler.Name, query: handler.Query}
+			if _, ok := calls[key]; ok {
+				continue
+			}
+			call := s.calls[key]
+			if call != nil && !isDone(call) {
+				// in flight, wait for it
+				calls[key] = call
+				metricCacheHits.With(sourceLabel.Value(s.name)).Increment()
+				continue
+			}
+			if call != nil && call.err == nil && now.Before(call.expire) {
+				calls[key] = call
+				metricCacheHits.With(sourceLabel.Value(s.name)).Increment()
+				continue
+			}
+			call = &queryCall{done: make(chan struct{})}
+			s.calls[key] = call
+			calls[key] = call
+			owned[key] = call
+			missed[meta] = append(missed[meta], handler)
+		}
+	}
+	s.prune(now)
+	s.mut.Unlock()
+
+	if len(missed) > 0 {
+		// the query goes on after the caller times out, so that the coalesced
+		// callers and the cache still get the results
+		go s.query(missed, owned)
+	}
+
+	metric := make(Metric)
+	for meta, handlers := range queryMap {
+		for _, handler := range handlers {
+			call := calls[queryKey{meta: meta, name: handler.Name, query: handler.Query}]
+			select {
+			case <-call.done:
+			case <-ctx.Done():
+				metricQueries.With(sourceLabel.Value(s.name), resultLabel.Value("timeout")).Increment()
+				return nil, errQueryTimeout
+			}
+			if call.err != nil {
+				return nil, call.err
+			}
+			// the values are copied as the consumers may modify them
+			for _, result := range call.results {
+				var value map[string]string
+				if result.Value != nil {
+					value = make(map[string]string, len(result.Value))
+					for k, v := range result.Value {
+						value[k] = v
+					}
+				}
+				metric[meta] = append(metric[meta], Result{Name: result.Name, Value: value})
+			}
+		}
+	}
+	return metric, nil
+}
+
+func (s *QuerySource) query(queryMap QueryMap, calls map[queryKey]*queryCall) {
+	ctx := context.Background()
+	if s.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+	}
+
+	var (
+		metric Metric
+		err    error
+	)
+	if err = s.limiter.Wait(ctx); err != nil {
+		metricQueries.With(sourceLabel.Value(s.name), resultLabel.Value("throttled")).Increment()
+		err = fmt.Errorf("query metric throttled: %w", err)
+	} else {
+		start := time.Now()
+		metric, err = s.Source.QueryMetric(queryMap)
+		metricQueryDuration.With(sourceLabel.Value(s.name)).Record(time.Since(start).Seconds())
+		if err != nil {
+			metricQueries.With(sourceLabel.Value(s.name), resultLabel.Value("failed")).Increment()
+		} else {
+			metricQueries.With(sourceLabel.Value(s.name), resultLabel.Value("success")).Increment()
+		}
+	}
+
+	expire := s.now().Add(s.cfg.CacheTTL)
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	for meta, handlers := range queryMap {
+		results := matchResults(handlers, metric[meta])
+		for i, handler := range handlers {
+			call := calls[queryKey{meta: meta, name: handler.Name, query: handler.Query}]
+			call.results, call.err, call.expire = results[i], err, expire
+			close(call.done)
+		}
+	}
+	if err != nil {
+		log.WithField("reporter", "QuerySource").Debugf("query metric of %s failed: %v", s.name, err)
+	}
+}
+
+// matchResults matches the results to the handlers by name. If more than one
+// handler has the same name, the results are matched in order.
+func matchResults(handlers []Handler, results []Result) [][]Result {
+	count := make(map[string]int, len(handlers))
+	for _, handler := range handlers {
+		count[handler.Name]++
+	}
+	byName := make(map[string][]Result, len(handlers))
+	for _, result := range results {
+		byName[result.Name] = append(byName[result.Name], result)
+	}
+
+	ret := make([][]Result, len(handlers))
+	for i, handler := range handlers {
+		rs := byName[handler.Name]
+		if count[handler.Name] == 1 || len(rs) == 0 {
+			ret[i] = rs
+			continue
+		}
+		ret[i], byName[handler.Name] = rs[:1], rs[1:]
+	}
+	return ret
+}
+
+// Reset drops the cached results of the meta info, and resets the source.
+func (s *QuerySource) Reset(info string) error {
+	s.mut.Lock()
+	for key, call := range s.calls {
+		if isDone(call) && resetMatch(info, key.meta) {
+			delete(s.calls, key)
+		}
+	}
+	s.mut.Unlock()
+	return s.Source.Reset(info)
+}
+
+// resetMatch returns whether the meta is reset by the info `ns/name`, which
+// resets all the metas in ns if name is empty.
+func resetMatch(info, meta string) bool {
+	if info == meta {
+		return true
+	}
+	ns, name, ok := strings.Cut(info, "/")
+	return ok && name == "" && strings.HasPrefix(meta, ns+"/")
+}
+
+// prune drops the expired results, it must be called with the lock held.
+func (s *QuerySource) prune(now time.Time) {
+	for key, call := range s.calls {
+		if isDone(call) && (call.err != nil || !now.Before(call.expire)) {
+			delete(s.calls, key)
+		}
+	}
+}
+
+func isDone(call *queryCall) bool {
+	select {
+	case <-call.done:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,204 @@
+package metric
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	prometheus "github.com/prometheus/client_golang/api/prometheus/v1"
+	prometheusModel "github.com/prometheus/common/model"
+)
+
+type countingSource struct {
+	MockSource
+	mut     sync.Mutex
+	queries []QueryMap
+	block   chan struct{}
+}
+
+func (s *countingSource) QueryMetric(queryMap QueryMap) (Metric, error) {
+	s.mut.Lock()
+	s.queries = append(s.queries, queryMap)
+	s.mut.Unlock()
+	if s.block != nil {
+		<-s.block
+	}
+	metric := make(Metric)
+	for meta, handlers := range queryMap {
+		for _, handler := range handlers {
+			metric[meta] = append(metric[meta], Result{
+				Name:  handler.Name,
+				Value: map[string]string{handler.Query: meta},
+			})
+		}
+	}
+	return metric, nil
+}
+
+func (s *countingSource) count() int {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return len(s.queries)
+}
+
+func TestNewQueryConfig(t *testing.T) {
+	cfg, err := NewQueryConfig(nil)
+	if err != nil || cfg.Timeout != defaultQueryTimeout || cfg.CacheTTL != 0 || cfg.QPS != 0 {
+		t.Fatalf("unexpected default config %+v, err %v", cfg, err)
+	}
+	cfg, err = NewQueryConfig(map[string]string{
+		QueryMiscCacheTTL:  "5s",
+		QueryMiscQPS:       "2.5",
+		QueryMiscBatchSize: "20",
+	})
+	if err != nil || cfg.CacheTTL != 5*time.Second || cfg.Burst != 3 || cfg.BatchSize != 20 {
+		t.Fatalf("unexpected config %+v, err %v", cfg, err)
+	}
+	if _, err := NewQueryConfig(map[string]string{QueryMiscBurst: "0"}); err == nil {
+		t.Fatalf("expect invalid burst")
+	}
+}
+
+func TestQuerySourceCache(t *testing.T) {
+	source := &countingSource{}
+	s := NewQuerySource(source, QueryConfig{CacheTTL: time.Minute})
+	now := time.Unix(0, 0)
+	s.now = func() time.Time { return now }
+
+	queryMap := QueryMap{
+		"ns/a": {{Name: "qps", Query: "q1"}, {Name: "total", Query: "q2"}},
+		"ns/b": {{Name: "qps", Query: "q1"}},
+	}
+	metric, err := s.QueryMetric(queryMap)
+	if err != nil || len(metric["ns/a"]) != 2 || metric["ns/a"][1].Name != "total" || len(metric["ns/b"]) != 1 {
+		t.Fatalf("unexpected metric %+v, err %v", metric, err)
+	}
+	// the consumers modifying the results do not affect the cache
+	metric["ns/a"][0].Value["q1"] = "modified"
+
+	metric, err = s.QueryMetric(QueryMap{
+		"ns/a": {{Name: "qps", Query: "q1"}},
+		"ns/c": {{Name: "qps", Query: "q1"}},
+	})
+	if err != nil || metric["ns/a"][0].Value["q1"] != "ns/a" || metric["ns/c"][0].Value["q1"] != "ns/c" {
+		t.Fatalf("unexpected metric %+v, err %v", metric, err)
+	}
+	if n := source.count(); n != 2 || len(source.queries[1]) != 1 {
+		t.Fatalf("expect only the missed handlers queried, got %v", source.queries)
+	}
+
+	// the reset and expired results are queried again
+	_ = s.Reset("ns/")
+	_, _ = s.QueryMetric(QueryMap{"ns/a": {{Name: "qps", Query: "q1"}}})
+	now = now.Add(time.Minute)
+	_, _ = s.QueryMetric(QueryMap{"ns/a": {{Name: "qps", Query: "q1"}}})
+	if n := source.count(); n != 4 {
+		t.Fatalf("expect the results queried again, got %d", n)
+	}
+}
+
+func TestQuerySourceCoalesceAndTimeout(t *testing.T) {
+	source := &countingSource{block: make(chan struct{})}
+	s := NewQuerySource(source, QueryConfig{Timeout: 50 * time.Millisecond})
+	queryMap := QueryMap{"ns/a": {{Name: "qps", Query: "q1"}}}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = s.QueryMetric(queryMap)
+		}(i)
+	}
+	wg.Wait()
+	if !errors.Is(errs[0], errQueryTimeout) || !errors.Is(errs[1], errQueryTimeout) {
+		t.Fatalf("expect timeout, got %v", errs)
+	}
+	if n := source.count(); n != 1 {
+		t.Fatalf("expect identical queries coalesced, got %d", n)
+	}
+
+	close(source.block)
+	if _, err := s.QueryMetric(queryMap); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type fakePrometheusAPI struct {
+	prometheus.API
+	queries []string
+}
+
+func (api *fakePrometheusAPI) Query(
+	_ context.Context,
+	query string,
+	_ time.Time,
+	_ ...prometheus.Option,
+) (prometheusModel.Value, prometheus.Warnings, error) {
+	api.queries = append(api.queries, query)
+	if strings.HasPrefix(query, "label_replace") {
+		if strings.Contains(query, "scalar") {
+			return nil, nil, errors.New("expected type instant vector")
+		}
+		return prometheusModel.Vector{
+			{Metric: prometheusModel.Metric{"pod": "a", batchLabel: "0"}, Value: 1},
+			{Metric: prometheusModel.Metric{"pod": "a", batchLabel: "1"}, Value: 2},
+		}, nil, nil
+	}
+	if strings.Contains(query, "scalar") {
+		return &prometheusModel.Scalar{Value: 3}, nil, nil
+	}
+	return prometheusModel.Vector{{Metric: prometheusModel.Metric{"pod": "a"}, Value: 1}}, nil, nil
+}
+
+func TestPrometheusSourceBatch(t *testing.T) {
+	api := &fakePrometheusAPI{}
+	ps := NewPrometheusSource(PrometheusSourceConfig{Api: api, BatchSize: 10})
+
+	metric, err := ps.QueryMetric(QueryMap{
+		"ns/a": {{Name: "a", Query: "sum(a)"}, {Name: "b", Query: "sum(b)"}},
+		"ns/b": {{Name: "a", Query: "sum(a)"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(api.queries) != 1 {
+		t.Fatalf("expect the queries batched into one request, got %v", api.queries)
+	}
+	if v := metric["ns/b"][0].Value[`{pod="a"}`]; v != "1" {
+		t.Fatalf("unexpected metric %+v", metric)
+	}
+	if v := metric["ns/a"][1].Value[`{pod="a"}`]; v != "2" {
+		t.Fatalf("unexpected metric %+v", metric)
+	}
+
+	// the batch with a scalar query falls back to the single queries, and the
+	// scalar query is not batched any more
+	api.queries = nil
+	queryMap := QueryMap{"ns/a": {{Name: "a", Query: "sum(a)"}, {Name: "s", Query: "scalar(s)"}}}
+	if _, err := ps.QueryMetric(queryMap); err != nil {
+		t.Fatal(err)
+	}
+	if len(api.queries) != 3 {
+		t.Fatalf("expect the batch queried one by one, got %v", api.queries)
+	}
+	api.queries = nil
+	if _, err := ps.QueryMetric(queryMap); err != nil {
+		t.Fatal(err)
+	}
+	if len(api.queries) != 2 {
+		t.Fatalf("expect the scalar query not batched, got %v", api.queries)
+	}
+}
+
+func TestMergeMetric(t *testing.T) {
+	pending := mergeMetric("test", nil, Metric{"ns/a": {{Name: "old"}}})
+	pending = mergeMetric("test", pending, Metric{"ns/a": {{Name: "new"}}, "ns/b": {{Name: "new"}}})
+	if len(pending) != 2 || pending["ns/a"][0].Name != "new" {
+		t.Fatalf("unexpected merged metric %+v", pending)
+	}
+}
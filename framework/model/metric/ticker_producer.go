@@ -1,9 +1,12 @@
 package metric
 
 import (
+	"context"
+
 	log "github.com/sirupsen/logrus"
 
 	"slime.io/slime/framework/model/trigger"
+	"slime.io/slime/framework/monitoring"
 )
 
 type TickerProducer struct {
@@ -13,6 +16,7 @@ type TickerProducer struct {
 	source                  Source
 	MetricChan              chan Metric
 	StopChan                chan struct{}
+	queueDepth              *monitoring.MetricGauge
 }
 
 func NewTickerProducer(config TickerProducerConfig, source Source) *TickerProducer {
@@ -23,6 +27,7 @@ func NewTickerProducer(config TickerProducerConfig, source Source) *TickerProduc
 		source:                  source,
 		MetricChan:              config.MetricChan,
 		StopChan:                make(chan struct{}),
+		queueDepth:              metricQueueDepth.With(producerLabel.Value(config.Name)),
 	}
 
 	return tp
@@ -30,8 +35,17 @@ func NewTickerProducer(config TickerProducerConfig, source Source) *TickerProduc
 
 func (p *TickerProducer) HandleTickerEvent() {
 	l := log.WithField("reporter", "TickerProducer").WithField("function", "HandleTriggerEvent")
+	// the metric not consumed yet, it's merged with the newer one instead of
+	// blocking the producer when the consumer is slow
+	var (
+		pending Metric
+		out     chan Metric
+	)
 	for {
 		select {
+		case out <- pending:
+			pending, out = nil, nil
+			p.queueDepth.Record(0)
 		case <-p.StopChan:
 			l.Infof("ticker producer exited")
 			return
@@ -49,7 +63,7 @@ func (p *TickerProducer) HandleTickerEvent() {
 			}
 
 			// get metric
-			metric, err := p.source.QueryMetric(queryMap)
+			metric, err := QueryMetric(context.Background(), p.source, queryMap)
 			if err != nil {
 				l.Errorf("%v", err)
 				continue
@@ -59,7 +73,8 @@ func (p *TickerProducer) HandleTickerEvent() {
 				continue
 			}
 			// produce metric event
-			p.MetricChan <- metric
+			pending, out = mergeMetric(p.name, pending, metric), p.MetricChan
+			p.queueDepth.Record(float64(len(pending)))
 		}
 	}
 }
@@ -1,9 +1,12 @@
 package metric
 
 import (
+	"context"
+
 	log "github.com/sirupsen/logrus"
 
 	"slime.io/slime/framework/model/trigger"
+	"slime.io/slime/framework/monitoring"
 )
 
 type WatcherProducer struct {
@@ -13,6 +16,7 @@ type WatcherProducer struct {
 	source                  Source
 	MetricChan              chan Metric
 	StopChan                chan struct{}
+	queueDepth              *monitoring.MetricGauge
 }
 
 func NewWatcherProducer(config WatcherProducerConfig, source Source) *WatcherProducer {
@@ -23,6 +27,7 @@ func NewWatcherProducer(config WatcherProducerConfig, source Source) *WatcherPro
 		source:                  source,
 		MetricChan:              config.MetricChan,
 		StopChan:                make(chan struct{}),
+		queueDepth:              metricQueueDepth.With(producerLabel.Value(config.Name)),
 	}
 
 	return wp
@@ -30,8 +35,17 @@ func NewWatcherProducer(config WatcherProducerConfig, source Source) *WatcherPro
 
 func (p *WatcherProducer) HandleWatcherEvent() {
 	l := log.WithField("reporter", "WatcherProducer").WithField("function", "HandleWatcherEvent")
+	// the metric not consumed yet, it's merged with the newer one instead of
+	// blocking the producer when the consumer is slow
+	var (
+		pending Metric
+		out     chan Metric
+	)
 	for {
 		select {
+		case out <- pending:
+			pending, out = nil, nil
+			p.queueDepth.Record(0)
 		case <-p.StopChan:
 			l.Infof("watcher producer exited")
 			return
@@ -50,14 +64,15 @@ func (p *WatcherProducer) HandleWatcherEvent() {
 			}
 
 			// get metric
-			metric, err := p.source.QueryMetric(queryMap)
+			metric, err := QueryMetric(context.Background(), p.source, queryMap)
 			if err != nil {
 				l.Errorf("%v", err)
 				continue
 			}
 
 			// produce metric event
-			p.MetricChan <- metric
+			pending, out = mergeMetric(p.name, pending, metric), p.MetricChan
+			p.queueDepth.Record(float64(len(pending)))
 		}
 	}
 }
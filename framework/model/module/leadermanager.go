@@ -0,0 +1,180 @@
+package module
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"slime.io/slime/framework/monitoring"
+)
+
+// leaderGate tracks the current leader term. It is opened by `lead` as an
+// OnStartedLeading callback with the term context, and is closed implicitly
+// when the term context is done.
+type leaderGate struct {
+	mut  sync.Mutex
+	term context.Context
+	// started is closed and replaced every time a new term starts
+	started chan struct{}
+}
+
+func newLeaderGate() *leaderGate {
+	return &leaderGate{started: make(chan struct{})}
+}
+
+func (g *leaderGate) lead(ctx context.Context) {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+	g.term = ctx
+	close(g.started)
+	g.started = make(chan struct{})
+}
+
+// wait blocks until being the leader and returns the context of the term,
+// or returns false if the ctx is done before.
+func (g *leaderGate) wait(ctx context.Context) (context.Context, bool) {
+	for {
+		g.mut.Lock()
+		term, started := g.term, g.started
+		g.mut.Unlock()
+		if term != nil && term.Err() == nil {
+			return term, true
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-started:
+		}
+	}
+}
+
+// leaderReconciler only reconciles as the leader, requests of a follower are
+// held until being the leader. The reconcile context is cancelled as soon as
+// the leader term ends. Each reconcile is traced in a span.
+type leaderReconciler struct {
+	reconcile.Reconciler
+	gate *leaderGate
+	// controller is the name of the controller
+	controller string
+}
+
+func (r *leaderReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	term, ok := r.gate.wait(ctx)
+	if !ok {
+		return reconcile.Result{}, ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-term.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	attrs := append(monitoring.ResourceAttributes(req.Namespace, req.Name), attribute.String("controller", r.controller))
+	// not to reassign ctx, which is read by the goroutine above
+	spanCtx, span := monitoring.StartSpan(ctx, "framework", "Reconcile", attrs...)
+	result, err := r.Reconciler.Reconcile(spanCtx, req)
+	monitoring.EndSpan(span, err)
+	return result, err
+}
+
+// leaderTermRunnable starts the runnable in every leader term with the term
+// context, so the runnable must support being started again after it returns.
+type leaderTermRunnable struct {
+	manager.Runnable
+	gate *leaderGate
+}
+
+func (r *leaderTermRunnable) Start(ctx context.Context) error {
+	for {
+		term, ok := r.gate.wait(ctx)
+		if !ok {
+			return nil
+		}
+		if err := r.Runnable.Start(term); err != nil {
+			return err
+		}
+		// the runnable may finish before the end of the term
+		<-term.Done()
+	}
+}
+
+func (r *leaderTermRunnable) NeedLeaderElection() bool {
+	return false
+}
+
+// leaderAwareManager keeps the manager running regardless of the election
+// status, and only makes the runnables that need leader election work in the
+// leader terms:
+//   - the controllers keep watching and queueing as followers, and reconcile
+//     after becoming the leader
+//   - other runnables are started in each leader term with the term context
+//
+// This way, the caches are kept warm and the process does not need to exit
+// when switching from leader to candidate.
+type leaderAwareManager struct {
+	manager.Manager
+	gate *leaderGate
+}
+
+func newLeaderAwareManager(mgr manager.Manager) *leaderAwareManager {
+	return &leaderAwareManager{
+		Manager: mgr,
+		gate:    newLeaderGate(),
+	}
+}
+
+func (m *leaderAwareManager) Add(r manager.Runnable) error {
+	if _, ok := r.(interface{ GetCache() cache.Cache }); ok {
+		return m.Manager.Add(r)
+	}
+	if ler, ok := r.(manager.LeaderElectionRunnable); ok && !ler.NeedLeaderElection() {
+		return m.Manager.Add(r)
+	}
+	if m.gateReconciler(r) {
+		return m.Manager.Add(r)
+	}
+
+	// set the dependencies that will not be injected through the wrapper
+	if err := m.Manager.SetFields(r); err != nil { //nolint: staticcheck
+		return err
+	}
+	return m.Manager.Add(&leaderTermRunnable{Runnable: r, gate: m.gate})
+}
+
+var reconcilerType = reflect.TypeOf((*reconcile.Reconciler)(nil)).Elem()
+
+// gateReconciler uses reflect to wrap the reconciler of the controller, which
+// is the `Do` field of the controller-runtime controller.
+func (m *leaderAwareManager) gateReconciler(r manager.Runnable) bool {
+	v := reflect.ValueOf(r)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return false
+	}
+	f := v.Elem().FieldByName("Do")
+	if !f.IsValid() || !f.CanSet() || f.Type() != reconcilerType || f.IsNil() {
+		return false
+	}
+	if _, ok := f.Interface().(*leaderReconciler); ok {
+		return true
+	}
+	lr := &leaderReconciler{
+		Reconciler: f.Interface().(reconcile.Reconciler),
+		gate:       m.gate,
+	}
+	if name := v.Elem().FieldByName("Name"); name.IsValid() && name.Kind() == reflect.String {
+		lr.controller = name.String()
+	}
+	f.Set(reflect.ValueOf(lr))
+	return true
+}
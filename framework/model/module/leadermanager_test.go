@@ -0,0 +1,70 @@
+package module
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type fakeController struct {
+	Do reconcile.Reconciler
+}
+
+func (c *fakeController) Start(context.Context) error {
+	return nil
+}
+
+func TestLeaderReconciler(t *testing.T) {
+	m := newLeaderAwareManager(nil)
+	reconciled := make(chan context.Context, 1)
+	c := &fakeController{Do: reconcile.Func(func(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+		reconciled <- ctx
+		<-ctx.Done()
+		return reconcile.Result{}, nil
+	})}
+	if !m.gateReconciler(c) {
+		t.Fatalf("expect the controller to be gated")
+	}
+
+	reconcileAsync := func() {
+		go func() {
+			_, _ = c.Do.Reconcile(context.Background(), reconcile.Request{})
+		}()
+	}
+
+	// held as a follower
+	reconcileAsync()
+	select {
+	case <-reconciled:
+		t.Fatalf("unexpected reconcile as a follower")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// reconcile in the leader term, and cancelled when the term ends
+	for i := 0; i < 2; i++ {
+		term, cancel := context.WithCancel(context.Background())
+		m.gate.lead(term)
+		var ctx context.Context
+		select {
+		case ctx = <-reconciled:
+		case <-time.After(time.Second):
+			t.Fatalf("term %d: expect reconcile as the leader", i)
+		}
+		cancel()
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+			t.Fatalf("term %d: expect the reconcile context to be cancelled", i)
+		}
+		reconcileAsync()
+	}
+
+	// the ctx of a held request is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.Do.Reconcile(ctx, reconcile.Request{}); err == nil {
+		t.Fatalf("expect error for the cancelled request")
+	}
+}
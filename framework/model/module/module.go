@@ -7,10 +7,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"reflect"
 	"strings"
 	"sync"
-	"unsafe"
 
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/encoding/protojson"
@@ -20,14 +18,12 @@ import (
 	"k8s.io/client-go/kubernetes"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	ctrl "sigs.k8s.io/controller-runtime"
-	ctrlleaderelection "sigs.k8s.io/controller-runtime/pkg/leaderelection"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
-	"sigs.k8s.io/controller-runtime/pkg/recorder"
 
 	bootconfig "slime.io/slime/framework/apis/config/v1alpha1"
 	"slime.io/slime/framework/bootstrap"
+	"slime.io/slime/framework/model/event"
 	"slime.io/slime/framework/model/pkg/leaderelection"
 	"slime.io/slime/framework/monitoring"
 	"slime.io/slime/framework/util"
@@ -40,16 +36,20 @@ type InitCallbacks struct {
 type moduleConfig struct {
 	module Module
 	config *bootconfig.Config
+	// name is the suffix of the config file, empty for the main module
+	name        string
+	generalJson []byte
 }
 
 // ModuleOptions carries the framework context for setting a module.
 // For fields marked with REQUIRED, the framework will ensure their existence,
 // and the module can be used directly.
 // NOTE: the Manager and the LeaderElectionCbs share the election status.
-// When switching from leader to candidate, the Manager will exit.
-// The framework will close the LeaderElection and ends the process after
-// the Manager exits. Therefore, when implementing a module, the scenario of
-// becoming the leader again may not be considered.
+// The Manager keeps running as a follower, its controllers keep watching
+// resources but only reconcile as the leader. When switching from leader
+// to candidate, the process will not exit, and it may become the leader
+// again later. Therefore, when implementing a module, the leader-only tasks
+// must be able to stop and restart with the leader terms.
 // nolint: revive
 type ModuleOptions struct {
 	// Env is the common environment context used by the module.
@@ -72,11 +72,20 @@ type ModuleOptions struct {
 	// creation and update of resources in the cluster may involve race
 	// conditions and cause system exceptions. The startup of these services
 	// must be controlled through an election mechanism.
-	// Currently, only the following state transfers are supported:
+	// The following state transfers are supported:
 	//   1. START -> candidate -> leader -> EXIT
 	//   2. START -> candidate -> EXIT
+	//   3. START -> candidate -> leader -> candidate -> leader ... -> EXIT
+	// In the case 3, the OnStartedLeading callbacks will be executed again
+	// with a new `Context` for each leader term, and the resident tasks
+	// created by the callbacks must exit when the `Context` is closed.
 	// REQUIRED
 	LeaderElectionCbs leaderelection.LeaderCallbacks
+
+	// EventRecorder records the decisions of the module on the objects, like
+	// creating a resource for a CR, as kubernetes Events on the objects. The
+	// recent events are served at `/events` of the auxiliary http server.
+	EventRecorder event.Recorder
 }
 
 type Module interface {
@@ -89,6 +98,22 @@ type Module interface {
 	Clone() Module
 }
 
+// ConfigChangeHandler can be implemented by a module to support reloading
+// its configuration without restart. The framework watches the module config
+// files rendered by SlimeBoot, and calls OnConfigChange after the new config
+// is validated. Changes of the fields that are not reloadable are rejected.
+type ConfigChangeHandler interface {
+	// ReloadableFields returns the paths of the reloadable fields of the
+	// module config, in json names joined by dots, like `general.refresh` or
+	// `global.log`. A path covers all the sub fields.
+	ReloadableFields() []string
+	// OnConfigChange is called with the new config and the new module self
+	// config parsed from `general`, which has the same type as `Config()`.
+	// The callbacks are called serially, and the module can reject the new
+	// config by returning an error.
+	OnConfigChange(cfg *bootconfig.Config, modCfg proto.Message) error
+}
+
 // LegcyModule represents a legacy module with InitManager method.
 type LegcyModule interface {
 	InitManager(mgr manager.Manager, env bootstrap.Environment, cbs InitCallbacks) error
@@ -194,8 +219,8 @@ func LoadModuleFromConfig(
 	// get mod.Config() value from config.general
 	if len(pmCfg.GeneralJson) > 0 {
 		if err := unmarshaler.Unmarshal(pmCfg.GeneralJson, modSelfCfg); err != nil {
-			log.Errorf("unmarshal for mod %s modGeneralJson (%v) met err %v", modCfg.Name, pmCfg.GeneralJson, err)
-			fatal()
+			return nil, fmt.Errorf("unmarshal for mod %s modGeneralJson (%s) met err %v",
+				modCfg.Name, pmCfg.GeneralJson, err)
 		}
 	}
 
@@ -206,7 +231,51 @@ func fatal() {
 	os.Exit(1) //nolint: revive
 }
 
+// Options overrides where Run loads the module configs and the cluster from,
+// so that the modules can be run in process, like by the conformance harness
+// in `framework/test/conformance`.
+type Options struct {
+	// ModuleConfig loads the parsed config of the module by name, the empty
+	// name for the main module. Defaults to the config files rendered by
+	// SlimeBoot, and the configs are reloaded on changes only by default.
+	ModuleConfig func(name string) (*bootstrap.ParsedModuleConfig, error)
+	// RestConfig is the config of the cluster. Defaults to the masterUrl of
+	// the global config, or the kubeconfig.
+	RestConfig *restclient.Config
+	// DisableAuxServer disables the auxiliary http server and the prometheus
+	// exporter, which can be created only once in a process.
+	DisableAuxServer bool
+}
+
 func Main(bundle string, modules []Module) {
+	if err := Run(ctrl.SetupSignalHandler(), bundle, modules, Options{}); err != nil {
+		log.Errorf("run bundle %s failed: %v", bundle, err)
+		fatal()
+	}
+}
+
+// Run sets up the modules like Main and runs them until the ctx is done.
+func Run(ctx context.Context, bundle string, modules []Module, opts Options) error {
+	loadConfig := opts.ModuleConfig
+	if loadConfig == nil {
+		loadConfig = bootstrap.GetModuleConfig
+	}
+	loadModule := func(
+		name string,
+		modGetter func(modCfg *bootconfig.Config) Module,
+		bundleConfig *bootconfig.Config,
+	) (Module, *bootstrap.ParsedModuleConfig, error) {
+		pmCfg, err := loadConfig(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if pmCfg == nil {
+			return nil, nil, fmt.Errorf("module config of %q not found", name)
+		}
+		mod, err := LoadModuleFromConfig(pmCfg, modGetter, bundleConfig)
+		return mod, pmCfg, err
+	}
+
 	// prepare module definition map
 	moduleDefinitions := make(map[string]Module)
 	for _, mod := range modules {
@@ -227,18 +296,18 @@ func Main(bundle string, modules []Module) {
 		return m
 	}
 	// get main module config
-	mainMod, mainModParsedCfg, err := LoadModule("", modGetter, nil)
+	mainMod, mainModParsedCfg, err := loadModule("", modGetter, nil)
 	if err != nil {
-		panic(err)
+		return err
 	}
 	mainModConfig, mainModRawJson, mainModGeneralJson := mainModParsedCfg.Config,
 		mainModParsedCfg.RawJson, mainModParsedCfg.GeneralJson
 	if mainModConfig == nil {
-		panic(fmt.Errorf("module config nil for %s", bundle))
+		return fmt.Errorf("module config nil for %s", bundle)
 	}
 	err = util.InitLog(mainModConfig.Global.Log)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	log.Infof("load module config of %s: %s, generalCfg: %s", bundle, string(mainModRawJson), string(mainModGeneralJson))
@@ -247,13 +316,13 @@ func Main(bundle string, modules []Module) {
 	isBundle := mainModConfig.Bundle != nil
 	if !isBundle {
 		if mainMod == nil {
-			log.Errorf("mod nil for %s", mainModConfig.Name)
-			fatal()
+			return fmt.Errorf("mod nil for %s", mainModConfig.Name)
 		}
 
 		mc := &moduleConfig{
-			module: mainMod,
-			config: mainModConfig,
+			module:      mainMod,
+			config:      mainModConfig,
+			generalJson: mainModGeneralJson,
 		}
 
 		if mainModConfig.Enable {
@@ -261,21 +330,22 @@ func Main(bundle string, modules []Module) {
 		}
 	} else {
 		for _, modCfg := range mainModConfig.Bundle.Modules {
-			mod, modParsedCfg, err := LoadModule(modCfg.Name, modGetter, mainModConfig)
+			mod, modParsedCfg, err := loadModule(modCfg.Name, modGetter, mainModConfig)
 			if err != nil {
-				panic(err)
+				return err
 			}
 			if mod == nil {
-				log.Errorf("mod nil for %s", modCfg.Name)
-				fatal()
+				return fmt.Errorf("mod nil for %s", modCfg.Name)
 			}
 
 			log.Infof("load raw module config of bundle item %s: %s, general: %s",
 				modCfg.Name, string(modParsedCfg.RawJson), string(modParsedCfg.GeneralJson))
 
 			mc := &moduleConfig{
-				module: mod,
-				config: modParsedCfg.Config,
+				module:      mod,
+				config:      modParsedCfg.Config,
+				name:        modCfg.Name,
+				generalJson: modParsedCfg.GeneralJson,
 			}
 
 			if mc.config.Enable {
@@ -293,16 +363,16 @@ func Main(bundle string, modules []Module) {
 	for _, mc := range mcs {
 		modKinds = append(modKinds, mc.module.Kind())
 		if err := mc.module.InitScheme(scheme); err != nil {
-			log.Errorf("mod %s InitScheme met err %v", mc.module.Kind(), err)
-			fatal()
+			return fmt.Errorf("mod %s InitScheme met err %v", mc.module.Kind(), err)
 		}
 	}
 
-	var conf *restclient.Config
-	if mainModConfig.Global != nil && mainModConfig.Global.GetMasterUrl() != "" {
+	conf := opts.RestConfig
+	if conf != nil {
+		conf = restclient.CopyConfig(conf)
+	} else if mainModConfig.Global != nil && mainModConfig.Global.GetMasterUrl() != "" {
 		if conf, err = clientcmd.BuildConfigFromFlags(mainModConfig.Global.GetMasterUrl(), ""); err != nil {
-			log.Errorf("unable to build rest client by %s", mainModConfig.Global.GetMasterUrl())
-			fatal()
+			return fmt.Errorf("unable to build rest client by %s", mainModConfig.Global.GetMasterUrl())
 		}
 	} else {
 		conf = ctrl.GetConfigOrDie()
@@ -324,11 +394,9 @@ func Main(bundle string, modules []Module) {
 		// create a resource lock in the same namespace as the workload instance
 		rl, err := leaderelection.NewKubeResourceLock(conf, os.Getenv("WATCH_NAMESPACE"), bundle)
 		if err != nil {
-			log.Errorf("create kube reource lock failed: %v", err)
-			fatal()
+			return fmt.Errorf("create kube reource lock failed: %v", err)
 		}
 		le = leaderelection.NewKubeLeaderElector(rl)
-		mgrOpts = mgrOptionsWithLeaderElection(mgrOpts, rl)
 	} else {
 		le = leaderelection.NewAlwaysLeader()
 	}
@@ -338,19 +406,20 @@ func Main(bundle string, modules []Module) {
 	mgrOpts.Port = 9443
 	mgr, err := ctrl.NewManager(conf, mgrOpts)
 	if err != nil {
-		log.Errorf("unable to create manager %s, %+v", bundle, err)
-		fatal()
+		return fmt.Errorf("unable to create manager %s, %+v", bundle, err)
 	}
+	// the manager itself does not take part in the leader election, so that
+	// it can keep running when the leadership is lost. The modules use the
+	// leaderMgr to run the controllers only in the leader terms.
+	leaderMgr := newLeaderAwareManager(mgr)
 	clientSet, err := kubernetes.NewForConfig(mgr.GetConfig())
 	if err != nil {
-		log.Errorf("create a new clientSet failed, %+v", err)
-		fatal()
+		return fmt.Errorf("create a new clientSet failed, %+v", err)
 	}
 
 	dynamicClient, err := dynamic.NewForConfig(mgr.GetConfig())
 	if err != nil {
-		log.Errorf("create a new dynamic client failed, %+v", err)
-		fatal()
+		return fmt.Errorf("create a new dynamic client failed, %+v", err)
 	}
 
 	var startups []func(ctx context.Context)
@@ -380,9 +449,19 @@ func Main(bundle string, modules []Module) {
 	readyMgr := &moduleReadyManager{moduleReadyCheckers: map[string][]readyChecker{}}
 
 	var once sync.Once
-	ctx, cancel := context.WithCancel(ctrl.SetupSignalHandler())
+	ctx, cancel := context.WithCancel(ctx)
 	defer once.Do(cancel)
 
+	shutdownTracing, err := monitoring.InitTracing(ctx, bundle, mainModConfig.Global.Misc)
+	if err != nil {
+		return fmt.Errorf("init tracing failed: %v", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Warnf("shutdown tracing met err %v", err)
+		}
+	}()
+
 	// init ConfigController
 	var configController, istioConfigController bootstrap.ConfigController
 
@@ -412,10 +491,20 @@ func Main(bundle string, modules []Module) {
 		Stop:                  ctx.Done(),
 	}
 
+	events := event.NewBroadcaster(mgr.GetEventRecorderFor("slime"), mgr.GetScheme(), event.DefaultBufferSize)
+	ph.Handle("/events", events)
+	var reloader *configReloader
+	if opts.ModuleConfig == nil {
+		reloader = newConfigReloader(isBundle, events)
+	}
+
 	// setup modules
 	monitoring.SubModulesCount.Record(float64(len(mcs)))
 	for _, mc := range mcs {
 		modCfg := mc.config
+		if reloader != nil {
+			reloader.addModule(mc.name, mc.module, modCfg, mc.generalJson)
+		}
 		moduleEnv := bootstrap.Environment{
 			Config:                modCfg,
 			ConfigController:      configController,
@@ -435,58 +524,65 @@ func Main(bundle string, modules []Module) {
 		}
 
 		if lm, ok := mc.module.(LegcyModule); ok {
-			if err := lm.InitManager(mgr, moduleEnv, cbs); err != nil {
-				log.Errorf("mod %s InitManager met err %v", modCfg.Name, err)
-				fatal()
+			if err := lm.InitManager(leaderMgr, moduleEnv, cbs); err != nil {
+				return fmt.Errorf("mod %s InitManager met err %v", modCfg.Name, err)
 			}
 		} else {
 			if err := mc.module.Setup(ModuleOptions{
 				Env:               moduleEnv,
 				InitCbs:           cbs,
-				Manager:           mgr,
+				Manager:           leaderMgr,
 				LeaderElectionCbs: le,
+				EventRecorder:     events.ForModule(modCfg.Name),
 			}); err != nil {
-				log.Errorf("mod %s Setup met err %v", modCfg.Name, err)
-				fatal()
+				return fmt.Errorf("mod %s Setup met err %v", modCfg.Name, err)
 			}
 		}
 	}
 
+	// open the controllers after the OnStartedLeading callbacks of modules
+	le.AddOnStartedLeading(leaderMgr.gate.lead)
+
 	// run ConfigController
 	if configController != nil {
 		_, err = bootstrap.RunController(configController, mainModConfig, mgr.GetConfig())
 		if err != nil {
-			log.Errorf("run config controller failed: %s", err)
-			return
+			return fmt.Errorf("run config controller failed: %s", err)
 		}
 	}
 
 	if istioConfigController != nil {
 		_, err = bootstrap.RunIstioController(istioConfigController, mainModConfig)
 		if err != nil {
-			log.Errorf("run config controller failed: %s", err)
-			return
+			return fmt.Errorf("run config controller failed: %s", err)
 		}
 	}
 
-	// Create the Prometheus exporter.
-	pe, err := monitoring.NewExporter()
-	if err != nil {
-		log.Errorf("Failed to create the Prometheus stats exporter: %v", err)
-		fatal()
-	}
+	if !opts.DisableAuxServer {
+		// Create the Prometheus exporter.
+		pe, err := monitoring.NewExporter()
+		if err != nil {
+			return fmt.Errorf("failed to create the Prometheus stats exporter: %v", err)
+		}
 
-	go func() {
-		auxAddr := mainModConfig.Global.Misc["aux-addr"]
-		bootstrap.AuxiliaryHttpServerStart(env, ph, auxAddr, readyMgr.check, pe)
-	}()
+		go func() {
+			auxOpts := bootstrap.NewAuxServerOptions(mainModConfig.Global.Misc)
+			bootstrap.AuxiliaryHttpServerStart(env, ph, auxOpts, readyMgr.check, pe)
+		}()
+	}
 
 	// Run the runnable function registered by the submodule
 	for _, startup := range startups {
 		startup(ctx)
 	}
 
+	if reloader != nil {
+		go reloader.run(ctx)
+	}
+
 	var wg sync.WaitGroup
+	// the one stopping first is the cause of the others stopping, buffered for both
+	errCh := make(chan error, 2)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -494,6 +590,7 @@ func Main(bundle string, modules []Module) {
 		log.Infof("starting bundle %s with modules %v", bundle, modKinds)
 		if err := le.Run(ctx); err != nil {
 			log.Errorf("problem running, %+v", err)
+			errCh <- fmt.Errorf("run leader election failed: %w", err)
 		}
 	}()
 
@@ -504,9 +601,12 @@ func Main(bundle string, modules []Module) {
 		log.Infof("starting manager with modules %v", modKinds)
 		if err := mgr.Start(ctx); err != nil {
 			log.Errorf("problem running, %+v", err)
+			errCh <- fmt.Errorf("start manager failed: %w", err)
 		}
 	}()
 	wg.Wait()
+	close(errCh)
+	return <-errCh
 }
 
 // Merge The content of dst will not be changed, return a new instance with merged result
@@ -515,23 +615,3 @@ func merge(dst, src proto.Message) proto.Message {
 	proto.Merge(ret, src)
 	return ret
 }
-
-// mgrOptionsWithLeaderElection uses reflect to set the manager's resourcelock
-// instead of creating by it yourself. This way we keep the election state of
-// ctrl manager and slime leader selector in sync.
-func mgrOptionsWithLeaderElection(opts ctrl.Options, rl resourcelock.Interface) ctrl.Options {
-	opts.LeaderElection = true
-	f := func(_ *restclient.Config, _ recorder.Provider, _ ctrlleaderelection.Options) (resourcelock.Interface, error) {
-		return rl, nil
-	}
-	v := reflect.ValueOf(&opts).Elem()
-	for i := 0; i < v.NumField(); i++ {
-		if v.Type().Field(i).Name != "newResourceLock" {
-			continue
-		}
-		vf := v.Field(i)
-		vf = reflect.NewAt(vf.Type(), unsafe.Pointer(vf.UnsafeAddr())).Elem()
-		vf.Set(reflect.ValueOf(f))
-	}
-	return opts
-}
@@ -0,0 +1,312 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	corev1 "k8s.io/api/core/v1"
+
+	bootconfig "slime.io/slime/framework/apis/config/v1alpha1"
+	"slime.io/slime/framework/bootstrap"
+	"slime.io/slime/framework/model/event"
+	"slime.io/slime/framework/monitoring"
+)
+
+const (
+	configReloadDebounce = time.Second
+
+	configReloadApplied  = "applied"
+	configReloadRejected = "rejected"
+)
+
+var (
+	configReloadModuleLabel = monitoring.MustCreateLabel("module")
+	configReloadResultLabel = monitoring.MustCreateLabel("result")
+)
+
+type reloadableModule struct {
+	// name is the suffix of the config file, empty for the main module
+	name        string
+	module      Module
+	config      *bootconfig.Config
+	generalJson []byte
+	// rejected is the last rejected config, to avoid reporting it repeatedly
+	rejected string
+}
+
+// configReloader watches the module config files and reloads the modules
+// implementing ConfigChangeHandler.
+type configReloader struct {
+	isBundle bool
+	modules  []*reloadableModule
+	load     func(name string) (*bootstrap.ParsedModuleConfig, error)
+	events   *event.Broadcaster
+	// eventRef is the object to record events on, nil to disable events
+	eventRef *corev1.ObjectReference
+}
+
+func newConfigReloader(isBundle bool, events *event.Broadcaster) *configReloader {
+	r := &configReloader{
+		isBundle: isBundle,
+		load:     bootstrap.GetModuleConfig,
+		events:   events,
+	}
+	if podName := os.Getenv("POD_NAME"); podName != "" && events != nil {
+		r.eventRef = &corev1.ObjectReference{
+			Kind:       "Pod",
+			APIVersion: "v1",
+			Namespace:  os.Getenv("WATCH_NAMESPACE"),
+			Name:       podName,
+		}
+	}
+	return r
+}
+
+// addModule adds the module to be reloaded, the ones not implementing ConfigChangeHandler are skipped as they
+// just keep running with the config loaded at starting.
+func (r *configReloader) addModule(name string, mod Module, cfg *bootconfig.Config, generalJson []byte) {
+	if _, ok := mod.(ConfigChangeHandler); !ok {
+		log.Debugf("module %s does not support config reload", cfg.GetName())
+		return
+	}
+	r.modules = append(r.modules, &reloadableModule{
+		name:        name,
+		module:      mod,
+		config:      cfg,
+		generalJson: generalJson,
+	})
+}
+
+// run watches the directory of the config files until the ctx is done.
+// The config files are mounted from a ConfigMap, whose updates are done by
+// replacing symlinks in the directory.
+func (r *configReloader) run(ctx context.Context) {
+	if len(r.modules) == 0 {
+		return
+	}
+	dir := filepath.Dir(bootstrap.DefaultModuleConfigPath)
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Errorf("create config watcher failed: %v, config reload is disabled", err)
+		return
+	}
+	defer watcher.Close()
+	if err := watcher.Add(dir); err != nil {
+		log.Warnf("watch config dir %s failed: %v, config reload is disabled", dir, err)
+		return
+	}
+	log.Infof("watching config dir %s for reloading", dir)
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			log.Debugf("got config dir event %s", event)
+			debounce = time.After(configReloadDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Errorf("config watcher error: %v", err)
+		case <-debounce:
+			debounce = nil
+			r.reload()
+		}
+	}
+}
+
+func (r *configReloader) reload() {
+	var bundleConfig *bootconfig.Config
+	if r.isBundle {
+		pmCfg, err := r.load("")
+		if err == nil && pmCfg == nil {
+			err = fmt.Errorf("config file not found")
+		}
+		if err != nil {
+			log.Errorf("load bundle config failed: %v, skip reloading", err)
+			return
+		}
+		bundleConfig = pmCfg.Config
+	}
+
+	for _, m := range r.modules {
+		r.reloadModule(m, bundleConfig)
+	}
+}
+
+func (r *configReloader) reloadModule(m *reloadableModule, bundleConfig *bootconfig.Config) {
+	pmCfg, err := r.load(m.name)
+	if err == nil && pmCfg == nil {
+		err = fmt.Errorf("config file not found")
+	}
+	if err != nil {
+		log.Errorf("load config of module %s failed: %v, skip reloading", m.config.GetName(), err)
+		return
+	}
+	cfg, generalJson := pmCfg.Config, pmCfg.GeneralJson
+	if bundleConfig != nil && bundleConfig.Global != nil {
+		cfg.Global = merge(bundleConfig.Global, cfg.Global).(*bootconfig.Global)
+	}
+
+	oldMap, err := configToMap(m.config, m.generalJson)
+	if err != nil {
+		log.Errorf("convert config of module %s failed: %v", m.config.GetName(), err)
+		return
+	}
+	newMap, err := configToMap(cfg, generalJson)
+	if err != nil {
+		r.reject(m, "", fmt.Errorf("invalid config: %v", err))
+		return
+	}
+	var changed []string
+	diffConfigPaths("", oldMap, newMap, &changed)
+	if len(changed) == 0 {
+		return
+	}
+	newContent, _ := json.Marshal(newMap)
+	if string(newContent) == m.rejected {
+		return
+	}
+
+	if err := r.apply(m, cfg, generalJson, changed); err != nil {
+		r.reject(m, string(newContent), err)
+		return
+	}
+
+	m.config, m.generalJson, m.rejected = cfg, generalJson, ""
+	log.Infof("config of module %s reloaded, changed fields: %v", cfg.GetName(), changed)
+	monitoring.ConfigReloads.With(
+		configReloadModuleLabel.Value(cfg.GetName()),
+		configReloadResultLabel.Value(configReloadApplied),
+	).Increment()
+	r.event(cfg.GetName(), corev1.EventTypeNormal, "ConfigReloaded",
+		fmt.Sprintf("config of module %s reloaded, changed fields: %v", cfg.GetName(), changed))
+}
+
+func (r *configReloader) apply(m *reloadableModule, cfg *bootconfig.Config, generalJson []byte, changed []string) error {
+	handler := m.module.(ConfigChangeHandler)
+	if fields := unreloadableFields(changed, handler.ReloadableFields()); len(fields) > 0 {
+		return fmt.Errorf("fields %v can not be reloaded", fields)
+	}
+
+	var modCfg proto.Message
+	if selfCfg := m.module.Config(); selfCfg != nil {
+		modCfg = selfCfg.ProtoReflect().New().Interface()
+		if len(generalJson) > 0 {
+			unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+			if err := unmarshaler.Unmarshal(generalJson, modCfg); err != nil {
+				return fmt.Errorf("invalid general config: %v", err)
+			}
+		}
+	}
+
+	if err := handler.OnConfigChange(cfg, modCfg); err != nil {
+		return fmt.Errorf("module rejects the config: %v", err)
+	}
+	return nil
+}
+
+func (r *configReloader) reject(m *reloadableModule, content string, err error) {
+	m.rejected = content
+	log.Errorf("config of module %s is not reloaded: %v", m.config.GetName(), err)
+	monitoring.ConfigReloads.With(
+		configReloadModuleLabel.Value(m.config.GetName()),
+		configReloadResultLabel.Value(configReloadRejected),
+	).Increment()
+	r.event(m.config.GetName(), corev1.EventTypeWarning, "ConfigReloadRejected",
+		fmt.Sprintf("config of module %s is not reloaded: %v", m.config.GetName(), err))
+}
+
+func (r *configReloader) event(module, eventType, reason, message string) {
+	if r.eventRef == nil {
+		return
+	}
+	r.events.ForModule(module).Event(r.eventRef, eventType, reason, message)
+}
+
+// configToMap converts the config to a generic map, with the `general` field
+// replaced by the raw general json, which is not kept in the config.
+func configToMap(cfg *bootconfig.Config, generalJson []byte) (map[string]interface{}, error) {
+	b, err := protojson.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	ret := map[string]interface{}{}
+	if err := json.Unmarshal(b, &ret); err != nil {
+		return nil, err
+	}
+	delete(ret, "general")
+	if len(generalJson) > 0 {
+		var general interface{}
+		if err := json.Unmarshal(generalJson, &general); err != nil {
+			return nil, err
+		}
+		ret["general"] = general
+	}
+	return ret, nil
+}
+
+// diffConfigPaths appends the paths of the changed leaf fields, or the changed
+// non-object fields, to paths in order.
+func diffConfigPaths(prefix string, oldVal, newVal interface{}, paths *[]string) {
+	oldMap, oldIsMap := oldVal.(map[string]interface{})
+	newMap, newIsMap := newVal.(map[string]interface{})
+	if (oldIsMap || oldVal == nil) && (newIsMap || newVal == nil) && (oldIsMap || newIsMap) {
+		keys := make(map[string]struct{}, len(oldMap)+len(newMap))
+		for k := range oldMap {
+			keys[k] = struct{}{}
+		}
+		for k := range newMap {
+			keys[k] = struct{}{}
+		}
+		sortedKeys := make([]string, 0, len(keys))
+		for k := range keys {
+			sortedKeys = append(sortedKeys, k)
+		}
+		sort.Strings(sortedKeys)
+		for _, k := range sortedKeys {
+			path := k
+			if prefix != "" {
+				path = prefix + "." + k
+			}
+			diffConfigPaths(path, oldMap[k], newMap[k], paths)
+		}
+		return
+	}
+	if !reflect.DeepEqual(oldVal, newVal) {
+		*paths = append(*paths, prefix)
+	}
+}
+
+func unreloadableFields(changed, reloadable []string) []string {
+	var ret []string
+	for _, path := range changed {
+		covered := false
+		for _, field := range reloadable {
+			if path == field || strings.HasPrefix(path, field+".") {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			ret = append(ret, path)
+		}
+	}
+	return ret
+}
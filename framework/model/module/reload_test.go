@@ -0,0 +1,158 @@
+package module
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	bootconfig "slime.io/slime/framework/apis/config/v1alpha1"
+	"slime.io/slime/framework/bootstrap"
+)
+
+type fakeReloadableModule struct {
+	config  structpb.Struct
+	changes []proto.Message
+	err     error
+}
+
+func (m *fakeReloadableModule) Setup(ModuleOptions) error        { return nil }
+func (m *fakeReloadableModule) Kind() string                     { return "fake" }
+func (m *fakeReloadableModule) Config() proto.Message            { return &m.config }
+func (m *fakeReloadableModule) InitScheme(*runtime.Scheme) error { return nil }
+func (m *fakeReloadableModule) Clone() Module                    { return m }
+func (m *fakeReloadableModule) ReloadableFields() []string {
+	return []string{"general.refresh", "global.log"}
+}
+func (m *fakeReloadableModule) OnConfigChange(_ *bootconfig.Config, modCfg proto.Message) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.changes = append(m.changes, modCfg)
+	return nil
+}
+
+func TestConfigReloader(t *testing.T) {
+	data := `
+name: fake
+enable: true
+global:
+  log:
+    logLevel: info
+general:
+  refresh: 10s
+  backend: a
+`
+	loadFake := func(string) (*bootstrap.ParsedModuleConfig, error) {
+		return bootstrap.LoadModuleConfigFromData([]byte(data), false)
+	}
+	pmCfg, _ := loadFake("")
+	mod := &fakeReloadableModule{}
+	r := newConfigReloader(false, nil)
+	r.load = loadFake
+	r.addModule("", mod, pmCfg.Config, pmCfg.GeneralJson)
+	// modules not supporting reload are not watched at all
+	r.addModule("legacy", struct{ Module }{mod}, pmCfg.Config, pmCfg.GeneralJson)
+	if len(r.modules) != 1 {
+		t.Fatalf("expect only the reloadable module added, got %d", len(r.modules))
+	}
+
+	// unchanged
+	r.reload()
+	if len(mod.changes) != 0 {
+		t.Fatalf("unexpected reload of the unchanged config")
+	}
+
+	// reloadable fields changed
+	data = `
+name: fake
+enable: true
+global:
+  log:
+    logLevel: debug
+general:
+  refresh: 20s
+  backend: a
+`
+	r.reload()
+	if len(mod.changes) != 1 {
+		t.Fatalf("expect 1 reload, got %d", len(mod.changes))
+	}
+	refresh := mod.changes[0].(*structpb.Struct).GetFields()["refresh"].GetStringValue()
+	if refresh != "20s" {
+		t.Fatalf("expect the new module config, got %v", mod.changes[0])
+	}
+
+	// unreloadable field changed
+	data = `
+name: fake
+enable: true
+global:
+  log:
+    logLevel: debug
+general:
+  refresh: 30s
+  backend: b
+`
+	r.reload()
+	if len(mod.changes) != 1 {
+		t.Fatalf("unexpected reload of the unreloadable field")
+	}
+	if r.modules[0].rejected == "" {
+		t.Fatalf("expect the config to be rejected")
+	}
+
+	// rejected by the module
+	data = `
+name: fake
+enable: true
+global:
+  log:
+    logLevel: debug
+general:
+  refresh: 30s
+  backend: a
+`
+	mod.err = errors.New("invalid refresh")
+	r.reload()
+	if len(mod.changes) != 1 || r.modules[0].config.GetGlobal().GetLog().GetLogLevel() != "debug" {
+		t.Fatalf("unexpected reload of the config rejected by the module")
+	}
+	mod.err = nil
+	r.reload()
+	if len(mod.changes) != 1 {
+		t.Fatalf("unexpected reload of the same rejected config")
+	}
+}
+
+func TestDiffConfigPaths(t *testing.T) {
+	oldVal := map[string]interface{}{
+		"name": "a",
+		"global": map[string]interface{}{
+			"misc": map[string]interface{}{"k1": "v1", "k2": "v2"},
+		},
+		"general": map[string]interface{}{"list": []interface{}{"a"}},
+	}
+	newVal := map[string]interface{}{
+		"name": "a",
+		"global": map[string]interface{}{
+			"misc": map[string]interface{}{"k1": "v1", "k3": "v3"},
+			"log":  map[string]interface{}{"logLevel": "debug"},
+		},
+		"general": map[string]interface{}{"list": []interface{}{"a", "b"}},
+	}
+	var paths []string
+	diffConfigPaths("", oldVal, newVal, &paths)
+	expect := []string{"general.list", "global.log.logLevel", "global.misc.k2", "global.misc.k3"}
+	if !reflect.DeepEqual(paths, expect) {
+		t.Fatalf("expect %v, got %v", expect, paths)
+	}
+
+	if fields := unreloadableFields(paths, []string{"global.misc", "general"}); !reflect.DeepEqual(fields,
+		[]string{"global.log.logLevel"}) {
+		t.Fatalf("unexpected unreloadable fields %v", fields)
+	}
+}
@@ -18,6 +18,8 @@ type LeaderCallbacks interface {
 	// reasonably to achieve the election effect. In particular, resident tasks
 	// that need to be run by a single instance must exit when the `Context`
 	// is closed.
+	// The callback will be executed again with a new `Context` each time it
+	// becomes the leader again, so the tasks must be able to restart.
 	AddOnStartedLeading(func(context.Context))
 	// AddOnStoppedLeading add a callback that needs to be executed after
 	// stopping as a leader. The callback function needs to be non-blocking,
@@ -40,6 +42,8 @@ type LeaderElector interface {
 	//      OnStartedLeading callbacks can be notified asynchronously, and the
 	//      OnStoppedLeading callbacks can be executed synchronously in order;
 	//   4. When serving as a leader, if the `Context` is closed, follow `3`.
+	// After `3`, it becomes a candidate and goes back to `1`, unless the
+	// `Context` is closed.
 	Run(context.Context) error
 }
 
@@ -0,0 +1,312 @@
+package slimeboot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	config "slime.io/slime/framework/apis/config/v1alpha1"
+	"slime.io/slime/framework/model/event"
+)
+
+const ControllerName = "slimeboot"
+
+// Reconciler reconciles the SlimeBoots. It renders the resources of the
+// modules, restores the resources modified by hand, and reports the module
+// status in the status of the SlimeBoot.
+type Reconciler struct {
+	client.Client
+	Scheme        *runtime.Scheme
+	EventRecorder event.Recorder
+}
+
+//nolint: lll
+// +kubebuilder:rbac:groups=config.netease.com,resources=slimeboots,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=config.netease.com,resources=slimeboots/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=configmaps;services;serviceaccounts,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterrolebindings,verbs=get;list;watch;create;update;delete
+
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.WithContext(ctx).WithField("slimeboot", req.NamespacedName)
+
+	u := NewUnstructured()
+	if err := r.Get(ctx, req.NamespacedName, u); err != nil {
+		if errors.IsNotFound(err) {
+			// the owner references only cover the resources in the namespace of the slimeboot
+			log.Infof("slimeboot is deleted, delete the rendered resources")
+			return ctrl.Result{}, r.deleteRendered(ctx, req.NamespacedName)
+		}
+		return ctrl.Result{}, err
+	}
+	if u.GetDeletionTimestamp() != nil {
+		return ctrl.Result{}, nil
+	}
+
+	sb, err := FromUnstructured(u)
+	if err != nil {
+		r.recordEvent(u, corev1.EventTypeWarning, "InvalidSpec", err.Error())
+		return ctrl.Result{}, nil
+	}
+	modules, err := Render(sb)
+	if err != nil {
+		r.recordEvent(u, corev1.EventTypeWarning, "RenderFailed", err.Error())
+		return ctrl.Result{}, nil
+	}
+
+	status := &config.SlimeBootStatus{ObservedGeneration: u.GetGeneration()}
+	var applyErr error
+	for _, m := range modules {
+		ms := &config.ModuleStatus{Name: m.Name, Kind: m.Kind, ConfigVersion: m.ConfigVersion}
+		status.Modules = append(status.Modules, ms)
+
+		for _, obj := range m.Objects {
+			if obj.GetNamespace() == u.GetNamespace() {
+				if err := controllerutil.SetOwnerReference(u, obj, r.Scheme); err != nil {
+					return ctrl.Result{}, err
+				}
+			}
+			drifted, err := r.apply(ctx, obj)
+			if err != nil {
+				log.Errorf("apply %s of module %s failed: %v", objectName(obj), m.Name, err)
+				r.recordEvent(u, corev1.EventTypeWarning, "ApplyFailed",
+					fmt.Sprintf("apply %s of module %s failed: %v", objectName(obj), m.Name, err))
+				ms.Message = fmt.Sprintf("apply %s failed: %v", objectName(obj), err)
+				applyErr = err
+				continue
+			}
+			if drifted {
+				log.Warnf("%s of module %s was modified by hand, restored", objectName(obj), m.Name)
+				r.recordEvent(u, corev1.EventTypeWarning, "DriftRestored",
+					fmt.Sprintf("%s of module %s was modified by hand, restored", objectName(obj), m.Name))
+				ms.Drifted = append(ms.Drifted, objectName(obj))
+			}
+		}
+		deploymentStatus(m.Deployment, ms)
+	}
+
+	if err := r.updateStatus(ctx, u, status); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, applyErr
+}
+
+// apply creates the object or updates it to the rendered one, and returns
+// whether the object was modified by hand, which means the object differs
+// from the rendered one while the hash of the rendering is unchanged.
+func (r *Reconciler) apply(ctx context.Context, desired client.Object) (bool, error) {
+	existing, ok := desired.DeepCopyObject().(client.Object)
+	if !ok {
+		return false, fmt.Errorf("unexpected object %T", desired)
+	}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(desired), existing); err != nil {
+		if !errors.IsNotFound(err) {
+			return false, err
+		}
+		return false, r.Create(ctx, desired)
+	}
+
+	hashChanged := existing.GetAnnotations()[AnnotationRenderedHash] != desired.GetAnnotations()[AnnotationRenderedHash]
+	if !hashChanged && derived(desired, existing) {
+		copyStatus(existing, desired)
+		return false, nil
+	}
+
+	merge(desired, existing)
+	if err := r.Update(ctx, existing); err != nil {
+		return false, err
+	}
+	copyStatus(existing, desired)
+	return !hashChanged, nil
+}
+
+// derived returns whether all the fields rendered are kept in the existing
+// object, the fields set by the apiserver or the others are ignored.
+func derived(desired, existing client.Object) bool {
+	if !equality.Semantic.DeepDerivative(desired.GetLabels(), existing.GetLabels()) {
+		return false
+	}
+	switch d := desired.(type) {
+	case *corev1.ConfigMap:
+		return equality.Semantic.DeepEqual(d.Data, existing.(*corev1.ConfigMap).Data)
+	case *appsv1.Deployment:
+		return equality.Semantic.DeepDerivative(d.Spec, existing.(*appsv1.Deployment).Spec)
+	case *corev1.Service:
+		e := existing.(*corev1.Service)
+		ports := make([]corev1.ServicePort, len(d.Spec.Ports))
+		for i, p := range d.Spec.Ports {
+			// the node ports are allocated by the apiserver
+			if i < len(e.Spec.Ports) {
+				p.NodePort = e.Spec.Ports[i].NodePort
+			}
+			ports[i] = p
+		}
+		return d.Spec.Type == e.Spec.Type &&
+			equality.Semantic.DeepDerivative(ports, e.Spec.Ports) &&
+			equality.Semantic.DeepEqual(d.Spec.Selector, e.Spec.Selector)
+	case *rbacv1.ClusterRoleBinding:
+		e := existing.(*rbacv1.ClusterRoleBinding)
+		return equality.Semantic.DeepEqual(d.Subjects, e.Subjects) && d.RoleRef == e.RoleRef
+	}
+	return true
+}
+
+// merge sets the rendered fields to the existing object.
+func merge(desired, existing client.Object) {
+	labels := existing.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for k, v := range desired.GetLabels() {
+		labels[k] = v
+	}
+	existing.SetLabels(labels)
+	annotations := existing.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[AnnotationRenderedHash] = desired.GetAnnotations()[AnnotationRenderedHash]
+	existing.SetAnnotations(annotations)
+	if refs := desired.GetOwnerReferences(); len(refs) > 0 {
+		existing.SetOwnerReferences(refs)
+	}
+
+	switch d := desired.(type) {
+	case *corev1.ConfigMap:
+		existing.(*corev1.ConfigMap).Data = d.Data
+	case *appsv1.Deployment:
+		existing.(*appsv1.Deployment).Spec = d.Spec
+	case *corev1.Service:
+		e := existing.(*corev1.Service)
+		ports := d.Spec.Ports
+		for i := range ports {
+			if i < len(e.Spec.Ports) {
+				ports[i].NodePort = e.Spec.Ports[i].NodePort
+			}
+		}
+		e.Spec.Type, e.Spec.Ports, e.Spec.Selector = d.Spec.Type, ports, d.Spec.Selector
+	case *rbacv1.ClusterRoleBinding:
+		e := existing.(*rbacv1.ClusterRoleBinding)
+		e.Subjects = d.Subjects
+	}
+}
+
+func copyStatus(from, to client.Object) {
+	if f, ok := from.(*appsv1.Deployment); ok {
+		to.(*appsv1.Deployment).Status = f.Status
+		to.(*appsv1.Deployment).Generation = f.Generation
+	}
+}
+
+// deploymentStatus sets the readiness of the module by the deployment status.
+func deploymentStatus(deploy *appsv1.Deployment, ms *config.ModuleStatus) {
+	var desired int32 = 1
+	if deploy.Spec.Replicas != nil {
+		desired = *deploy.Spec.Replicas
+	}
+	ms.Replicas = desired
+	ms.ReadyReplicas = deploy.Status.ReadyReplicas
+	if ms.Message != "" {
+		return
+	}
+	ms.Ready = deploy.Status.ObservedGeneration >= deploy.Generation &&
+		deploy.Status.UpdatedReplicas >= desired &&
+		deploy.Status.ReadyReplicas >= desired
+	if !ms.Ready {
+		ms.Message = fmt.Sprintf("%d/%d replicas ready", deploy.Status.ReadyReplicas, desired)
+	}
+}
+
+func (r *Reconciler) updateStatus(ctx context.Context, u *unstructured.Unstructured, status *config.SlimeBootStatus) error {
+	b, err := config.SlimeBootMarshaler.MarshalToString(status)
+	if err != nil {
+		return err
+	}
+	content := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(b), &content); err != nil {
+		return err
+	}
+	u.Object["status"] = content
+	return r.Status().Update(ctx, u)
+}
+
+// deleteRendered deletes the resources rendered for the slimeboot in all namespaces, found by the labels.
+func (r *Reconciler) deleteRendered(ctx context.Context, nn types.NamespacedName) error {
+	selector := client.MatchingLabels{
+		LabelSlimeBootName:      nn.Name,
+		LabelSlimeBootNamespace: nn.Namespace,
+	}
+	var errs []error
+	for _, list := range []client.ObjectList{
+		&appsv1.DeploymentList{},
+		&corev1.ConfigMapList{},
+		&corev1.ServiceList{},
+		&corev1.ServiceAccountList{},
+		&rbacv1.ClusterRoleBindingList{},
+	} {
+		if err := r.List(ctx, list, selector); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := meta.EachListItem(list, func(o runtime.Object) error {
+			obj := o.(client.Object)
+			if err := r.Delete(ctx, obj); err != nil && !errors.IsNotFound(err) {
+				return fmt.Errorf("delete %s/%s: %w", obj.GetNamespace(), obj.GetName(), err)
+			}
+			return nil
+		}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (r *Reconciler) recordEvent(obj runtime.Object, eventType, reason, message string) {
+	if r.EventRecorder != nil {
+		r.EventRecorder.Event(obj, eventType, reason, message)
+	}
+}
+
+func objectName(obj client.Object) string {
+	return fmt.Sprintf("%s/%s", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName())
+}
+
+// SetupWithManager watches the SlimeBoots and the rendered resources, so that
+// the resources modified or deleted by hand are restored at once.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	enqueue := handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+		labels := obj.GetLabels()
+		name, ns := labels[LabelSlimeBootName], labels[LabelSlimeBootNamespace]
+		if name == "" || ns == "" {
+			return nil
+		}
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: ns, Name: name}}}
+	})
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(ControllerName).
+		For(NewUnstructured()).
+		Watches(&source.Kind{Type: &appsv1.Deployment{}}, enqueue).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, enqueue).
+		Watches(&source.Kind{Type: &corev1.Service{}}, enqueue).
+		Watches(&source.Kind{Type: &corev1.ServiceAccount{}}, enqueue).
+		Watches(&source.Kind{Type: &rbacv1.ClusterRoleBinding{}}, enqueue).
+		Complete(r)
+}
@@ -0,0 +1,140 @@
+package slimeboot
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func moduleStatus(t *testing.T, c client.Client) map[string]interface{} {
+	t.Helper()
+	u := NewUnstructured()
+	if err := c.Get(context.Background(), client.ObjectKey{Namespace: "mesh-operator", Name: "boot"}, u); err != nil {
+		t.Fatal(err)
+	}
+	modules, _, _ := unstructured.NestedSlice(u.Object, "status", "modules")
+	if len(modules) != 1 {
+		t.Fatalf("unexpected status %v", u.Object["status"])
+	}
+	return modules[0].(map[string]interface{})
+}
+
+func TestReconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	scheme.AddKnownTypeWithName(GroupVersionKind, &unstructured.Unstructured{})
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(newTestSlimeBoot(t, testSpec)).Build()
+	r := &Reconciler{Client: c, Scheme: scheme}
+
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: client.ObjectKey{Namespace: "mesh-operator", Name: "boot"}}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	deploy := &appsv1.Deployment{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: "mesh-operator", Name: "lazyload"}, deploy); err != nil {
+		t.Fatal(err)
+	}
+	if len(deploy.OwnerReferences) != 1 || deploy.OwnerReferences[0].UID != "uid" {
+		t.Fatalf("unexpected owner references %v", deploy.OwnerReferences)
+	}
+	status := moduleStatus(t, c)
+	if status["name"] != "lazyload" || status["ready"] == true || status["configVersion"] == "" {
+		t.Fatalf("unexpected module status %v", status)
+	}
+
+	// the deployment becomes ready
+	deploy.Status = appsv1.DeploymentStatus{
+		ObservedGeneration: deploy.Generation, Replicas: 1, UpdatedReplicas: 1, ReadyReplicas: 1,
+	}
+	if err := c.Status().Update(ctx, deploy); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if status := moduleStatus(t, c); status["ready"] != true || status["drifted"] != nil {
+		t.Fatalf("unexpected module status %v", status)
+	}
+
+	// the resources modified by hand are restored
+	cm := &corev1.ConfigMap{}
+	_ = c.Get(ctx, client.ObjectKey{Namespace: "mesh-operator", Name: "lazyload"}, cm)
+	expect := cm.Data["cfg"]
+	cm.Data["cfg"] = "{}"
+	if err := c.Update(ctx, cm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	_ = c.Get(ctx, client.ObjectKey{Namespace: "mesh-operator", Name: "lazyload"}, cm)
+	if cm.Data["cfg"] != expect {
+		t.Fatalf("expect the configmap restored, got %s", cm.Data["cfg"])
+	}
+	status = moduleStatus(t, c)
+	if drifted, _ := status["drifted"].([]interface{}); len(drifted) != 1 || drifted[0] != "ConfigMap/lazyload" {
+		t.Fatalf("unexpected module status %v", status)
+	}
+
+	// the rendered resources are deleted with the slimeboot
+	_ = c.Delete(ctx, newTestSlimeBoot(t, testSpec))
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	assertRenderedDeleted(t, c)
+}
+
+func TestReconcileDeleteInOtherNamespace(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	scheme.AddKnownTypeWithName(GroupVersionKind, &unstructured.Unstructured{})
+	sb := newTestSlimeBoot(t, testSpec)
+	_ = unstructured.SetNestedField(sb.Object, "slime", "spec", "namespace")
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sb).Build()
+	r := &Reconciler{Client: c, Scheme: scheme}
+
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: client.ObjectKey{Namespace: "mesh-operator", Name: "boot"}}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	deploy := &appsv1.Deployment{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: "slime", Name: "lazyload"}, deploy); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = c.Delete(ctx, sb)
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	assertRenderedDeleted(t, c)
+}
+
+func assertRenderedDeleted(t *testing.T, c client.Client) {
+	t.Helper()
+	for _, list := range []client.ObjectList{
+		&appsv1.DeploymentList{},
+		&corev1.ConfigMapList{},
+		&corev1.ServiceList{},
+		&corev1.ServiceAccountList{},
+		&rbacv1.ClusterRoleBindingList{},
+	} {
+		if err := c.List(context.Background(), list); err != nil {
+			t.Fatal(err)
+		}
+		if n := meta.LenList(list); n != 0 {
+			t.Errorf("expect %T deleted, got %d left", list, n)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package slimeboot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ModuleSelector selects the modules in the spec by their raw configs.
+type ModuleSelector func(module map[string]interface{}) bool
+
+// ModuleName selects the modules by name.
+func ModuleName(name string) ModuleSelector {
+	return func(module map[string]interface{}) bool {
+		n, _ := module["name"].(string)
+		return n == name
+	}
+}
+
+// ModuleKind selects the modules by kind.
+func ModuleKind(kind string) ModuleSelector {
+	return func(module map[string]interface{}) bool {
+		k, _ := module["kind"].(string)
+		return k == kind
+	}
+}
+
+type patchOp struct {
+	selector ModuleSelector
+	path     []string
+	value    interface{}
+	remove   bool
+}
+
+// Patch is a structured patch of the SlimeBoot spec. It sets or removes the
+// fields of the selected module configs, including the module specific ones
+// like `general`, instead of patching the raw json of the spec.
+type Patch struct {
+	ops []patchOp
+	err error
+}
+
+func NewPatch() *Patch {
+	return &Patch{}
+}
+
+// Set sets the field at path of the selected modules to value, the missing
+// parent fields are created. The value must be marshalable to json.
+func (p *Patch) Set(selector ModuleSelector, value interface{}, path ...string) *Patch {
+	if len(path) == 0 {
+		p.setErr(fmt.Errorf("set module field with empty path"))
+		return p
+	}
+	v, err := normalize(value)
+	if err != nil {
+		p.setErr(fmt.Errorf("set module field %v: %v", path, err))
+		return p
+	}
+	p.ops = append(p.ops, patchOp{selector: selector, path: path, value: v})
+	return p
+}
+
+// Remove removes the field at path of the selected modules.
+func (p *Patch) Remove(selector ModuleSelector, path ...string) *Patch {
+	p.ops = append(p.ops, patchOp{selector: selector, path: path, remove: true})
+	return p
+}
+
+func (p *Patch) setErr(err error) {
+	if p.err == nil {
+		p.err = err
+	}
+}
+
+// Apply applies the patch to the raw spec. It's an error if no module is
+// selected by a Set.
+func (p *Patch) Apply(spec map[string]interface{}) error {
+	if p.err != nil {
+		return p.err
+	}
+	modules, _ := spec["module"].([]interface{})
+	for _, op := range p.ops {
+		matched := false
+		for _, item := range modules {
+			module, ok := item.(map[string]interface{})
+			if !ok || !op.selector(module) {
+				continue
+			}
+			matched = true
+			if op.remove {
+				unstructured.RemoveNestedField(module, op.path...)
+				continue
+			}
+			if err := unstructured.SetNestedField(module, op.value, op.path...); err != nil {
+				return fmt.Errorf("set module field %v error: %v", op.path, err)
+			}
+		}
+		if !matched && !op.remove {
+			return fmt.Errorf("no module matched to set field %v", op.path)
+		}
+	}
+	return nil
+}
+
+// ModuleField returns the field at path of the first selected module in the
+// raw spec.
+func ModuleField(spec map[string]interface{}, selector ModuleSelector, path ...string) (interface{}, bool) {
+	modules, _ := spec["module"].([]interface{})
+	for _, item := range modules {
+		module, ok := item.(map[string]interface{})
+		if !ok || !selector(module) {
+			continue
+		}
+		v, found, err := unstructured.NestedFieldCopy(module, path...)
+		return v, found && err == nil
+	}
+	return nil, false
+}
+
+// PatchSlimeBoot applies the patch to the SlimeBoot in the cluster. The patch
+// is sent as a merge patch guarded by the resource version, and retried with
+// the latest SlimeBoot on conflicts.
+func PatchSlimeBoot(ctx context.Context, c client.Client, key types.NamespacedName, p *Patch) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		u := NewUnstructured()
+		if err := c.Get(ctx, key, u); err != nil {
+			return err
+		}
+		orig := u.DeepCopy()
+		spec, _, err := unstructured.NestedMap(u.Object, "spec")
+		if err != nil {
+			return err
+		}
+		if spec == nil {
+			spec = map[string]interface{}{}
+		}
+		if err := p.Apply(spec); err != nil {
+			return err
+		}
+		if err := unstructured.SetNestedMap(u.Object, spec, "spec"); err != nil {
+			return err
+		}
+		return c.Patch(ctx, u, client.MergeFromWithOptions(orig, client.MergeFromWithOptimisticLock{}))
+	})
+}
+
+// normalize converts the value to the json types of the unstructured objects.
+func normalize(value interface{}) (interface{}, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var ret interface{}
+	if err := json.Unmarshal(b, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
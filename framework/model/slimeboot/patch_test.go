@@ -0,0 +1,39 @@
+package slimeboot
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPatch(t *testing.T) {
+	spec := map[string]interface{}{
+		"module": []interface{}{
+			map[string]interface{}{"name": "lazyload", "kind": "lazyload", "general": map[string]interface{}{"a": "b"}},
+			map[string]interface{}{"name": "limiter", "kind": "limiter", "general": map[string]interface{}{"c": "d"}},
+		},
+	}
+	err := NewPatch().
+		Set(ModuleKind("lazyload"), []string{"9080"}, "general", "wormholePort").
+		Set(ModuleName("lazyload"), "lazyload", "general", "render").
+		Remove(ModuleName("limiter"), "general", "c").
+		Apply(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, found := ModuleField(spec, ModuleKind("lazyload"), "general")
+	expect := map[string]interface{}{"a": "b", "wormholePort": []interface{}{"9080"}, "render": "lazyload"}
+	if !found || !reflect.DeepEqual(v, expect) {
+		t.Fatalf("unexpected general %v", v)
+	}
+	if v, _ := ModuleField(spec, ModuleName("limiter"), "general"); !reflect.DeepEqual(v, map[string]interface{}{}) {
+		t.Fatalf("unexpected general %v", v)
+	}
+
+	if err := NewPatch().Set(ModuleKind("plugin"), "x", "general").Apply(spec); err == nil {
+		t.Fatalf("expect error if no module matched")
+	}
+	if err := NewPatch().Set(ModuleKind("lazyload"), func() {}, "general").Apply(spec); err == nil {
+		t.Fatalf("expect error of the unmarshalable value")
+	}
+}
@@ -0,0 +1,360 @@
+package slimeboot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	config "slime.io/slime/framework/apis/config/v1alpha1"
+)
+
+const (
+	modeBundleItem = "BundleItem"
+
+	configVolume    = "config-volume"
+	configMountPath = "/etc/slime/config"
+	clusterRoleName = "slime"
+)
+
+// Module is the rendered resources of a module.
+type Module struct {
+	Name string
+	Kind string
+	// ConfigVersion is the hash of the module config in the ConfigMap
+	ConfigVersion string
+	Deployment    *appsv1.Deployment
+	// Objects are all the resources of the module, including the Deployment
+	Objects []client.Object
+}
+
+// Render renders the resources of the enabled modules like the helm chart
+// `boot/helm-charts/slimeboot`: a ConfigMap with the module configs, the
+// Deployment, the Service, and the RBAC resources of each module.
+func Render(sb *SlimeBoot) ([]*Module, error) {
+	spec := sb.Spec
+	raws := sb.RawModules()
+
+	var modules []*Module
+	for i, mod := range spec.Module {
+		if !mod.GetEnable() || mod.GetMode() == modeBundleItem {
+			continue
+		}
+		if mod.GetName() == "" {
+			return nil, fmt.Errorf("module %d of slimeboot %s/%s without name", i, sb.Namespace, sb.Name)
+		}
+
+		cm, version, err := renderConfigMap(sb, i, raws)
+		if err != nil {
+			return nil, err
+		}
+		m := &Module{
+			Name:          mod.GetName(),
+			Kind:          mod.GetKind(),
+			ConfigVersion: version,
+		}
+		deploy, err := renderDeployment(spec, mod)
+		if err != nil {
+			return nil, fmt.Errorf("render deployment of module %s error: %v", mod.GetName(), err)
+		}
+		m.Deployment = deploy
+		m.Objects = append(m.Objects, cm, deploy, renderClusterRoleBinding(spec, mod))
+		if spec.ServiceAccount.GetCreate() {
+			m.Objects = append(m.Objects, renderServiceAccount(spec, mod))
+		}
+		m.Objects = append(m.Objects, renderService(spec, mod.GetName(), nil))
+		if (mod.GetName() == "lazyload" || mod.GetKind() == "lazyload") &&
+			mod.GetGlobal().GetMisc()["enableLeaderElection"] == "on" {
+			m.Objects = append(m.Objects, renderService(spec, mod.GetName(), map[string]string{"slime.io/leader": "true"}))
+		}
+
+		for _, obj := range m.Objects {
+			if err := setMeta(sb, obj); err != nil {
+				return nil, err
+			}
+		}
+		modules = append(modules, m)
+	}
+	return modules, nil
+}
+
+func objectMeta(spec *config.SlimeBootSpec, name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: spec.Namespace,
+		Labels:    map[string]string{"app": name},
+	}
+}
+
+// setMeta sets the labels of the SlimeBoot and the hash of the resource.
+func setMeta(sb *SlimeBoot, obj client.Object) error {
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[LabelSlimeBootName] = sb.Name
+	labels[LabelSlimeBootNamespace] = sb.Namespace
+	obj.SetLabels(labels)
+
+	content, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[AnnotationRenderedHash] = hash(content)
+	obj.SetAnnotations(annotations)
+	return nil
+}
+
+func hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:8])
+}
+
+// renderConfigMap renders the ConfigMap of the module configs, and returns the
+// hash of the configs as the config version.
+func renderConfigMap(sb *SlimeBoot, idx int, raws []map[string]interface{}) (*corev1.ConfigMap, string, error) {
+	mod := sb.Spec.Module[idx]
+	data := map[string]string{}
+	cfg, err := json.Marshal(raws[idx])
+	if err != nil {
+		return nil, "", fmt.Errorf("marshal config of module %s error: %v", mod.GetName(), err)
+	}
+	data["cfg"] = string(cfg)
+	if mod.GetBundle() != nil {
+		for i, item := range sb.Spec.Module {
+			if item.GetBundle() != nil {
+				continue
+			}
+			cfg, err := json.Marshal(raws[i])
+			if err != nil {
+				return nil, "", fmt.Errorf("marshal config of module %s error: %v", item.GetName(), err)
+			}
+			data["cfg_"+item.GetName()] = string(cfg)
+		}
+	}
+	content, err := json.Marshal(data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	meta := objectMeta(sb.Spec, mod.GetName())
+	delete(meta.Labels, "app")
+	return &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: meta,
+		Data:       data,
+	}, hash(content), nil
+}
+
+func renderDeployment(spec *config.SlimeBootSpec, mod *config.Config) (*appsv1.Deployment, error) {
+	name := mod.GetName()
+	image := spec.Image.GetRepository()
+	if spec.Image.GetTag() != "" {
+		image += ":" + spec.Image.GetTag()
+	}
+	resources, err := resourceRequirements(spec.Resources)
+	if err != nil {
+		return nil, err
+	}
+
+	scheme := corev1.URISchemeHTTP
+	if mod.GetGlobal().GetMisc()["aux-tls-cert"] != "" {
+		scheme = corev1.URISchemeHTTPS
+	}
+	probe := func(path string, failureThreshold int32) *corev1.Probe {
+		return &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{
+				Path:   path,
+				Port:   intstr.FromString("aux-port"),
+				Scheme: scheme,
+			}},
+			InitialDelaySeconds: 3,
+			PeriodSeconds:       5,
+			FailureThreshold:    failureThreshold,
+			// the defaults of the apiserver, so that the drift is not reported
+			TimeoutSeconds:   1,
+			SuccessThreshold: 1,
+		}
+	}
+
+	env := []corev1.EnvVar{
+		{Name: "WATCH_NAMESPACE", ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"},
+		}},
+		{Name: "POD_NAME", ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
+		}},
+		{Name: "OPERATOR_NAME", Value: "slime"},
+		{Name: "GODEBUG", Value: "gctrace=1"},
+	}
+	for _, e := range spec.Env {
+		env = append(env, *e)
+	}
+
+	container := corev1.Container{
+		Name:            "slime",
+		SecurityContext: spec.ContainerSecurityContext,
+		Image:           image,
+		Command:         []string{"/manager"},
+		Args:            spec.Args,
+		ImagePullPolicy: corev1.PullPolicy(spec.Image.GetPullPolicy()),
+		Env:             env,
+		Ports: []corev1.ContainerPort{
+			{Name: "http", ContainerPort: 80, Protocol: corev1.ProtocolTCP},
+			{Name: "aux-port", ContainerPort: spec.Service.AuxiliaryPort, Protocol: corev1.ProtocolTCP},
+			{Name: "log-source-port", ContainerPort: spec.Service.LogSourcePort, Protocol: corev1.ProtocolTCP},
+			{Name: "mcp-over-xds", ContainerPort: spec.Service.McpOverXdsPort, Protocol: corev1.ProtocolTCP},
+		},
+		Resources:      resources,
+		ReadinessProbe: probe("/modules/readyz", 1),
+		LivenessProbe:  probe("/modules/livez", 2),
+		VolumeMounts:   []corev1.VolumeMount{{MountPath: configMountPath, Name: configVolume}},
+	}
+	for _, m := range spec.VolumeMounts {
+		container.VolumeMounts = append(container.VolumeMounts, *m)
+	}
+
+	defaultMode := int32(420)
+	podSpec := corev1.PodSpec{
+		ServiceAccountName: name,
+		SecurityContext:    spec.PodSecurityContext,
+		Containers:         []corev1.Container{container},
+		Volumes: []corev1.Volume{{
+			Name: configVolume,
+			VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: name},
+				DefaultMode:          &defaultMode,
+			}},
+		}},
+		NodeSelector: spec.NodeSelector,
+		Affinity:     spec.Affinity,
+	}
+	for _, s := range spec.ImagePullSecrets {
+		podSpec.ImagePullSecrets = append(podSpec.ImagePullSecrets, *s)
+	}
+	for _, v := range spec.Volumes {
+		podSpec.Volumes = append(podSpec.Volumes, *v)
+	}
+	for _, t := range spec.Tolerations {
+		podSpec.Tolerations = append(podSpec.Tolerations, *t)
+	}
+
+	replicas := spec.ReplicaCount
+	return &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: objectMeta(spec, name),
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": name}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": name}},
+				Spec:       podSpec,
+			},
+		},
+	}, nil
+}
+
+func resourceRequirements(r *config.ResourceRequirements) (corev1.ResourceRequirements, error) {
+	var ret corev1.ResourceRequirements
+	parse := func(m map[string]string) (corev1.ResourceList, error) {
+		if len(m) == 0 {
+			return nil, nil
+		}
+		list := make(corev1.ResourceList, len(m))
+		for k, v := range m {
+			q, err := resource.ParseQuantity(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quantity %q of %s: %v", v, k, err)
+			}
+			list[corev1.ResourceName(k)] = q
+		}
+		return list, nil
+	}
+	var err error
+	if ret.Limits, err = parse(r.GetLimits()); err != nil {
+		return ret, err
+	}
+	if ret.Requests, err = parse(r.GetRequests()); err != nil {
+		return ret, err
+	}
+	for _, c := range r.GetClaims() {
+		ret.Claims = append(ret.Claims, *c)
+	}
+	return ret, nil
+}
+
+func renderServiceAccount(spec *config.SlimeBootSpec, mod *config.Config) *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+		ObjectMeta: objectMeta(spec, mod.GetName()),
+	}
+}
+
+func renderClusterRoleBinding(spec *config.SlimeBootSpec, mod *config.Config) *rbacv1.ClusterRoleBinding {
+	meta := objectMeta(spec, mod.GetName())
+	meta.Namespace = ""
+	delete(meta.Labels, "app")
+	return &rbacv1.ClusterRoleBinding{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRoleBinding"},
+		ObjectMeta: meta,
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      mod.GetName(),
+			Namespace: spec.Namespace,
+		}},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     clusterRoleName,
+		},
+	}
+}
+
+// renderService renders the service of the module, or the service of the
+// leader if extraSelector is set.
+func renderService(spec *config.SlimeBootSpec, name string, extraSelector map[string]string) *corev1.Service {
+	meta := objectMeta(spec, name)
+	selector := map[string]string{"app": name}
+	if len(extraSelector) > 0 {
+		meta.Name += "-leader"
+		for k, v := range extraSelector {
+			selector[k] = v
+		}
+	}
+	svc := spec.Service
+	return &corev1.Service{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: meta,
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceType(svc.Type),
+			Ports: []corev1.ServicePort{
+				{Name: "http", Port: svc.Port, TargetPort: intstr.FromString("http"), Protocol: corev1.ProtocolTCP},
+				{
+					Name: "aux-port", Port: svc.AuxiliaryPort,
+					TargetPort: intstr.FromString("aux-port"), Protocol: corev1.ProtocolTCP,
+				},
+				{
+					Name: "log-source-port", Port: svc.LogSourcePort,
+					TargetPort: intstr.FromString("log-source-port"), Protocol: corev1.ProtocolTCP,
+				},
+				{
+					Name: "mcp-over-xds", Port: svc.McpOverXdsPort,
+					TargetPort: intstr.FromInt(16010), Protocol: corev1.ProtocolTCP,
+				},
+			},
+			Selector: selector,
+		},
+	}
+}
@@ -0,0 +1,85 @@
+package slimeboot
+
+import (
+	"encoding/json"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newTestSlimeBoot(t *testing.T, spec string) *unstructured.Unstructured {
+	t.Helper()
+	raw := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(spec), &raw); err != nil {
+		t.Fatal(err)
+	}
+	u := NewUnstructured()
+	u.SetNamespace("mesh-operator")
+	u.SetName("boot")
+	u.SetUID("uid")
+	u.SetGeneration(2)
+	u.Object["spec"] = raw
+	return u
+}
+
+const testSpec = `{
+	"image": {"repository": "slime", "tag": "v1"},
+	"module": [
+		{"name": "lazyload", "kind": "lazyload", "enable": true,
+			"global": {"misc": {"enableLeaderElection": "on"}},
+			"general": {"wormholePort": ["9080"], "autoPort": true}},
+		{"name": "limiter", "kind": "limiter", "enable": false}
+	]
+}`
+
+func TestRender(t *testing.T) {
+	sb, err := FromUnstructured(newTestSlimeBoot(t, testSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	modules, err := Render(sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(modules) != 1 || modules[0].Name != "lazyload" || modules[0].ConfigVersion == "" {
+		t.Fatalf("expect only the enabled module rendered, got %+v", modules)
+	}
+
+	m := modules[0]
+	// configmap, deployment, crb, service account, service and leader service
+	if len(m.Objects) != 6 {
+		t.Fatalf("unexpected objects %d", len(m.Objects))
+	}
+	cm := m.Objects[0].(*corev1.ConfigMap)
+	var cfg map[string]interface{}
+	if err := json.Unmarshal([]byte(cm.Data["cfg"]), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	// the module specific fields are kept
+	if general, _ := cfg["general"].(map[string]interface{}); general["autoPort"] != true {
+		t.Fatalf("unexpected config %s", cm.Data["cfg"])
+	}
+
+	deploy := m.Objects[1].(*appsv1.Deployment)
+	if deploy.Namespace != "mesh-operator" || *deploy.Spec.Replicas != 1 ||
+		deploy.Spec.Template.Spec.Containers[0].Image != "slime:v1" {
+		t.Fatalf("unexpected deployment %+v", deploy)
+	}
+	for _, obj := range m.Objects {
+		if obj.GetLabels()[LabelSlimeBootName] != "boot" || obj.GetAnnotations()[AnnotationRenderedHash] == "" {
+			t.Fatalf("unexpected meta of %s", objectName(obj))
+		}
+	}
+	if name := m.Objects[5].GetName(); name != "lazyload-leader" {
+		t.Fatalf("unexpected leader service %s", name)
+	}
+
+	// the rendering is stable
+	again, _ := Render(sb)
+	if again[0].Deployment.Annotations[AnnotationRenderedHash] != deploy.Annotations[AnnotationRenderedHash] ||
+		again[0].ConfigVersion != m.ConfigVersion {
+		t.Fatalf("expect the same hash")
+	}
+}
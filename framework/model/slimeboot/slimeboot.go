@@ -0,0 +1,137 @@
+// Package slimeboot renders the module resources of the SlimeBoots natively
+// instead of the helm based operator in `boot/`, reports the module status,
+// and offers a structured patch API of the SlimeBoot spec.
+package slimeboot
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	config "slime.io/slime/framework/apis/config/v1alpha1"
+)
+
+var GroupVersionKind = schema.GroupVersionKind{
+	Group:   "config.netease.com",
+	Version: "v1alpha1",
+	Kind:    "SlimeBoot",
+}
+
+const (
+	// LabelSlimeBootName and LabelSlimeBootNamespace are set on the rendered
+	// resources to find the SlimeBoot they belong to.
+	LabelSlimeBootName      = "slime.io/slimeboot-name"
+	LabelSlimeBootNamespace = "slime.io/slimeboot-namespace"
+	// AnnotationRenderedHash is the hash of the rendered resource, which tells
+	// whether the changes of the resource are made by hand or by the SlimeBoot.
+	AnnotationRenderedHash = "slime.io/rendered-hash"
+
+	defaultNamespace      = "mesh-operator"
+	defaultIstioNamespace = "istio-system"
+)
+
+// SlimeBoot is a SlimeBoot with the raw spec, as the module configs contain
+// the module specific fields not in the proto, like `general`.
+type SlimeBoot struct {
+	*config.SlimeBoot
+	// Raw is the raw spec
+	Raw map[string]interface{}
+}
+
+// NewUnstructured returns an empty unstructured SlimeBoot.
+func NewUnstructured() *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(GroupVersionKind)
+	return u
+}
+
+// FromUnstructured converts the unstructured SlimeBoot, and sets the default
+// values of the spec like the helm chart.
+func FromUnstructured(u *unstructured.Unstructured) (*SlimeBoot, error) {
+	sb := &config.SlimeBoot{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), sb); err != nil {
+		return nil, fmt.Errorf("convert slimeboot %s/%s to structured error: %v", u.GetNamespace(), u.GetName(), err)
+	}
+	if sb.Spec == nil {
+		sb.Spec = &config.SlimeBootSpec{}
+	}
+
+	raw, _, err := unstructured.NestedMap(u.Object, "spec")
+	if err != nil {
+		return nil, fmt.Errorf("get spec of slimeboot %s/%s error: %v", u.GetNamespace(), u.GetName(), err)
+	}
+	if raw == nil {
+		raw = map[string]interface{}{}
+	}
+	setDefaults(sb.Spec, raw)
+	return &SlimeBoot{SlimeBoot: sb, Raw: raw}, nil
+}
+
+// RawModules returns the raw configs of the modules, in the order of
+// Spec.Module.
+func (sb *SlimeBoot) RawModules() []map[string]interface{} {
+	items, _ := sb.Raw["module"].([]interface{})
+	ret := make([]map[string]interface{}, len(sb.Spec.Module))
+	for i := range ret {
+		if i < len(items) {
+			ret[i], _ = items[i].(map[string]interface{})
+		}
+		if ret[i] == nil {
+			ret[i] = map[string]interface{}{}
+		}
+	}
+	return ret
+}
+
+// setDefaults sets the default values of the helm chart, the raw spec tells
+// whether the zero values are set explicitly.
+func setDefaults(spec *config.SlimeBootSpec, raw map[string]interface{}) {
+	if spec.Namespace == "" {
+		spec.Namespace = defaultNamespace
+	}
+	if spec.IstioNamespace == "" {
+		spec.IstioNamespace = defaultIstioNamespace
+	}
+	if _, ok := raw["replicaCount"]; !ok {
+		spec.ReplicaCount = 1
+	}
+	if spec.Image == nil {
+		spec.Image = &config.Image{}
+	}
+	if spec.Image.PullPolicy == "" {
+		spec.Image.PullPolicy = "Always"
+	}
+	if spec.ServiceAccount == nil {
+		spec.ServiceAccount = &config.ServiceAccount{}
+	}
+	if _, ok, _ := unstructured.NestedFieldNoCopy(raw, "serviceAccount", "create"); !ok {
+		spec.ServiceAccount.Create = true
+	}
+	if spec.Resources == nil {
+		spec.Resources = &config.ResourceRequirements{
+			Limits:   map[string]string{"cpu": "1", "memory": "1Gi"},
+			Requests: map[string]string{"cpu": "200m", "memory": "200Mi"},
+		}
+	}
+	if spec.Service == nil {
+		spec.Service = &config.Service{}
+	}
+	svc := spec.Service
+	if svc.Type == "" {
+		svc.Type = "ClusterIP"
+	}
+	if svc.Port == 0 {
+		svc.Port = 80
+	}
+	if svc.AuxiliaryPort == 0 {
+		svc.AuxiliaryPort = 8081
+	}
+	if svc.LogSourcePort == 0 {
+		svc.LogSourcePort = 8082
+	}
+	if svc.McpOverXdsPort == 0 {
+		svc.McpOverXdsPort = 16010
+	}
+}
@@ -36,6 +36,12 @@ var SubModulesCount = NewGauge(
 	"total number of submodules",
 )
 
+var ConfigReloads = NewSum(
+	"framework",
+	"config_reloads",
+	"total number of module config reloads",
+)
+
 func NewExporter() (http.Handler, error) {
 	exporter, err := prometheus.New()
 	if err != nil {
@@ -99,6 +105,13 @@ func (sum *MetricSum) Increment() {
 	sum.counter.Add(sum.ctx, 1, sum.opts...)
 }
 
+func (sum *MetricSum) Add(value float64) {
+	if sum.counter == nil {
+		sum.counter = sum.initializer()
+	}
+	sum.counter.Add(sum.ctx, value, sum.opts...)
+}
+
 func (sum *MetricSum) Decrement() {
 	if sum.counter == nil {
 		sum.counter = sum.initializer()
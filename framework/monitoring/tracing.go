@@ -0,0 +1,152 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"slime.io/slime/framework/util"
+)
+
+// The misc keys of the global config to configure tracing. Tracing is enabled
+// only when the otlp endpoint is set.
+const (
+	// TracingMiscOtlpEndpoint is the `host:port` of the otlp grpc collector
+	TracingMiscOtlpEndpoint = "tracing-otlp-endpoint"
+	// TracingMiscOtlpInsecure disables the TLS to the collector with "true"
+	TracingMiscOtlpInsecure = "tracing-otlp-insecure"
+	// TracingMiscSampleRatio is the ratio of the sampled root spans, 1 by default
+	TracingMiscSampleRatio = "tracing-sample-ratio"
+)
+
+const (
+	logTraceIDKey = "trace_id"
+	logSpanIDKey  = "span_id"
+)
+
+// InitTracing sets the global tracer provider exporting the spans to the otlp
+// collector configured in misc, and adds the trace context to the logs written
+// with `WithContext`. The returned func flushes and stops the exporting. If the
+// endpoint is not configured, the spans are not recorded and nothing is done.
+func InitTracing(ctx context.Context, service string, misc map[string]string) (func(context.Context) error, error) {
+	endpoint := misc[TracingMiscOtlpEndpoint]
+	if endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	ratio := 1.0
+	if s := misc[TracingMiscSampleRatio]; s != "" {
+		var err error
+		if ratio, err = strconv.ParseFloat(s, 64); err != nil || ratio < 0 || ratio > 1 {
+			return nil, fmt.Errorf("invalid %s %q", TracingMiscSampleRatio, s)
+		}
+	}
+
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(endpoint)}
+	if misc[TracingMiscOtlpInsecure] == "true" {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create otlp trace exporter failed: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(service),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+	log.AddHook(traceLogHook{})
+	log.Infof("tracing enabled, exporting to %s with sample ratio %v", endpoint, ratio)
+
+	return provider.Shutdown, nil
+}
+
+// StartSpan starts a span of the module as a child of the span in ctx, the
+// returned span must be ended with EndSpan.
+func StartSpan(
+	ctx context.Context,
+	module, name string,
+	attrs ...attribute.KeyValue,
+) (context.Context, trace.Span) {
+	return otel.Tracer(util.MetricPrefix+"/"+module).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// ResourceAttributes returns the span attributes of a namespaced resource.
+func ResourceAttributes(namespace, name string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("namespace", namespace),
+		attribute.String("name", name),
+	}
+}
+
+// EndSpan records the err, if not nil, as the status of the span and ends it.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// RecordSpan records a finished span of the module from start to end, for the
+// operations measured by their time rather than run in a context, like the
+// pollings of the registries.
+func RecordSpan(
+	ctx context.Context,
+	module, name string,
+	start, end time.Time,
+	err error,
+	attrs ...attribute.KeyValue,
+) {
+	_, span := otel.Tracer(util.MetricPrefix+"/"+module).Start(ctx, name,
+		trace.WithTimestamp(start), trace.WithAttributes(attrs...))
+	if err != nil {
+		span.RecordError(err, trace.WithTimestamp(end))
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End(trace.WithTimestamp(end))
+}
+
+// traceLogHook adds the ids of the span in the context of the entry to the logs.
+type traceLogHook struct{}
+
+func (traceLogHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (traceLogHook) Fire(entry *log.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	sc := trace.SpanContextFromContext(entry.Context)
+	if !sc.IsValid() {
+		return nil
+	}
+	entry.Data[logTraceIDKey] = sc.TraceID().String()
+	entry.Data[logSpanIDKey] = sc.SpanID().String()
+	return nil
+}
@@ -0,0 +1,70 @@
+package monitoring
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestInitTracingMisc(t *testing.T) {
+	shutdown, err := InitTracing(context.Background(), "test", nil)
+	if err != nil || shutdown(context.Background()) != nil {
+		t.Fatalf("expect tracing disabled without endpoint, err %v", err)
+	}
+	if _, err := InitTracing(context.Background(), "test", map[string]string{
+		TracingMiscOtlpEndpoint: "127.0.0.1:4317",
+		TracingMiscSampleRatio:  "2",
+	}); err == nil {
+		t.Fatalf("expect invalid sample ratio")
+	}
+}
+
+func TestSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+
+	ctx, parent := StartSpan(context.Background(), "test", "Reconcile", ResourceAttributes("ns", "foo")...)
+	_, child := StartSpan(ctx, "test", "RefreshSidecar")
+	EndSpan(child, errors.New("conflict"))
+
+	var buf bytes.Buffer
+	logger := log.New()
+	logger.SetOutput(&buf)
+	logger.AddHook(traceLogHook{})
+	logger.WithContext(ctx).Info("refreshed")
+	EndSpan(parent, nil)
+
+	start := time.Unix(100, 0)
+	RecordSpan(context.Background(), "test", "RegistryPoll", start, start.Add(time.Second), nil)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("expect 3 spans, got %d", len(spans))
+	}
+	c, p, r := spans[0], spans[1], spans[2]
+	if c.Parent.SpanID() != p.SpanContext.SpanID() || c.Status.Code != codes.Error || len(c.Events) != 1 {
+		t.Fatalf("unexpected child span %+v", c)
+	}
+	if p.Status.Code == codes.Error || len(p.Attributes) != 2 {
+		t.Fatalf("unexpected parent span %+v", p)
+	}
+	if !r.StartTime.Equal(start) || r.EndTime.Sub(r.StartTime) != time.Second {
+		t.Fatalf("unexpected recorded span %+v", r)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "trace_id="+p.SpanContext.TraceID().String()) ||
+		!strings.Contains(out, "span_id="+p.SpanContext.SpanID().String()) {
+		t.Fatalf("expect trace context in log %q", out)
+	}
+}
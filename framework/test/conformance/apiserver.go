@@ -0,0 +1,851 @@
+package conformance
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// maxWatchHistory is the number of the recent events kept to resume the
+	// watches from a resource version
+	maxWatchHistory = 10000
+	watchBufferSize = 1024
+)
+
+// clusterScopedKinds are the kinds served as cluster scoped, all the others
+// are namespaced.
+var clusterScopedKinds = map[string]bool{
+	"Namespace":                      true,
+	"Node":                           true,
+	"PersistentVolume":               true,
+	"ComponentStatus":                true,
+	"ClusterRole":                    true,
+	"ClusterRoleBinding":             true,
+	"CustomResourceDefinition":       true,
+	"APIService":                     true,
+	"StorageClass":                   true,
+	"CSIDriver":                      true,
+	"CSINode":                        true,
+	"VolumeAttachment":               true,
+	"PriorityClass":                  true,
+	"RuntimeClass":                   true,
+	"IngressClass":                   true,
+	"CertificateSigningRequest":      true,
+	"MutatingWebhookConfiguration":   true,
+	"ValidatingWebhookConfiguration": true,
+	"TokenReview":                    true,
+	"SubjectAccessReview":            true,
+	"SelfSubjectAccessReview":        true,
+	"SelfSubjectRulesReview":         true,
+}
+
+type resourceInfo struct {
+	gvk        schema.GroupVersionKind
+	gvr        schema.GroupVersionResource
+	namespaced bool
+}
+
+type watchEvent struct {
+	rv     uint64
+	gvr    schema.GroupVersionResource
+	typ    string
+	object map[string]interface{}
+}
+
+type watcher struct {
+	gvr       schema.GroupVersionResource
+	namespace string
+	label     labels.Selector
+	field     fields.Selector
+	ch        chan *watchEvent
+}
+
+// APIServer is an in-process fake of the kubernetes api server for the types
+// of a scheme. It serves the discovery, and the get, list, watch, create,
+// update, patch and delete of the objects in memory, which is enough for the
+// clients, informers and controllers of the modules.
+//
+// Unlike a real api server, there is no admission, validation, defaulting or
+// version conversion. All the resources have the status subresource, the
+// dependents are deleted in background by the owner references, and the
+// strategic merge patches of the types without patch strategies are applied
+// as json merge patches. Server side apply is not supported.
+type APIServer struct {
+	scheme *runtime.Scheme
+	server *httptest.Server
+	stop   chan struct{}
+
+	// resources by the group version and the plural name
+	resources map[schema.GroupVersion]map[string]*resourceInfo
+
+	mut      sync.Mutex
+	rv       uint64
+	objects  map[schema.GroupVersionResource]map[string]map[string]interface{}
+	history  []*watchEvent
+	watchers map[*watcher]struct{}
+}
+
+// NewAPIServer returns a started APIServer serving the types of the scheme.
+func NewAPIServer(scheme *runtime.Scheme) *APIServer {
+	s := &APIServer{
+		scheme:    scheme,
+		stop:      make(chan struct{}),
+		resources: map[schema.GroupVersion]map[string]*resourceInfo{},
+		objects:   map[schema.GroupVersionResource]map[string]map[string]interface{}{},
+		watchers:  map[*watcher]struct{}{},
+	}
+	for gvk := range scheme.AllKnownTypes() {
+		if gvk.Version == runtime.APIVersionInternal || strings.HasSuffix(gvk.Kind, "List") ||
+			!scheme.Recognizes(gvk.GroupVersion().WithKind(gvk.Kind+"List")) {
+			continue
+		}
+		plural := strings.ToLower(gvk.Kind)
+		switch {
+		case plural == "endpoints":
+		case strings.HasSuffix(plural, "s"), strings.HasSuffix(plural, "x"), strings.HasSuffix(plural, "ch"):
+			plural += "es"
+		case strings.HasSuffix(plural, "y") && !strings.HasSuffix(plural, "ey"):
+			plural = plural[:len(plural)-1] + "ies"
+		default:
+			plural += "s"
+		}
+		gv := gvk.GroupVersion()
+		if s.resources[gv] == nil {
+			s.resources[gv] = map[string]*resourceInfo{}
+		}
+		s.resources[gv][plural] = &resourceInfo{
+			gvk:        gvk,
+			gvr:        gv.WithResource(plural),
+			namespaced: !clusterScopedKinds[gvk.Kind],
+		}
+	}
+	s.server = httptest.NewServer(s)
+	return s
+}
+
+// RestConfig returns the config of the clients to the server.
+func (s *APIServer) RestConfig() *rest.Config {
+	return &rest.Config{Host: s.server.URL, QPS: 1000, Burst: 1000}
+}
+
+// Stop ends the watches and stops the server.
+func (s *APIServer) Stop() {
+	close(s.stop)
+	s.server.CloseClientConnections()
+	s.server.Close()
+}
+
+func (s *APIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	var gv schema.GroupVersion
+	switch {
+	case r.URL.Path == "/version":
+		writeJSON(w, http.StatusOK, version.Info{Major: "1", Minor: "26", GitVersion: "v1.26.0"})
+		return
+	case parts[0] == "api" && len(parts) == 1:
+		writeJSON(w, http.StatusOK, &metav1.APIVersions{
+			TypeMeta: metav1.TypeMeta{Kind: "APIVersions"},
+			Versions: []string{"v1"},
+		})
+		return
+	case parts[0] == "apis" && len(parts) == 1:
+		s.serveGroups(w)
+		return
+	case parts[0] == "api" && len(parts) >= 2:
+		gv, parts = schema.GroupVersion{Version: parts[1]}, parts[2:]
+	case parts[0] == "apis" && len(parts) >= 3:
+		gv, parts = schema.GroupVersion{Group: parts[1], Version: parts[2]}, parts[3:]
+	default:
+		writeStatus(w, http.StatusNotFound, metav1.StatusReasonNotFound, "the server could not find the requested resource")
+		return
+	}
+	if len(parts) == 0 {
+		s.serveResources(w, gv)
+		return
+	}
+
+	var namespace, resource, name, subresource string
+	if parts[0] == "namespaces" && len(parts) >= 3 && !(len(parts) == 3 && parts[2] == "status") {
+		namespace, parts = parts[1], parts[2:]
+	}
+	resource, parts = parts[0], parts[1:]
+	if len(parts) > 0 {
+		name, parts = parts[0], parts[1:]
+	}
+	if len(parts) > 0 {
+		subresource = parts[0]
+	}
+	info := s.resources[gv][resource]
+	if info == nil || (subresource != "" && subresource != "status") {
+		writeStatus(w, http.StatusNotFound, metav1.StatusReasonNotFound,
+			fmt.Sprintf("the server could not find the requested resource %s", r.URL.Path))
+		return
+	}
+	if !info.namespaced {
+		namespace = ""
+	}
+
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodGet && name == "" && (query.Get("watch") == "true" || query.Get("watch") == "1"):
+		s.watch(w, r, info, namespace)
+	case r.Method == http.MethodGet && name == "":
+		s.list(w, r, info, namespace)
+	case r.Method == http.MethodGet:
+		s.get(w, info, namespace, name)
+	case r.Method == http.MethodPost && name == "":
+		s.create(w, r, info, namespace)
+	case r.Method == http.MethodPut && name != "":
+		s.update(w, r, info, namespace, name, subresource == "status")
+	case r.Method == http.MethodPatch && name != "":
+		s.patch(w, r, info, namespace, name, subresource == "status")
+	case r.Method == http.MethodDelete && name == "":
+		s.deleteCollection(w, r, info, namespace)
+	case r.Method == http.MethodDelete:
+		s.delete(w, info, namespace, name)
+	default:
+		writeStatus(w, http.StatusMethodNotAllowed, metav1.StatusReasonMethodNotAllowed,
+			fmt.Sprintf("method %s is not supported", r.Method))
+	}
+}
+
+func (s *APIServer) serveGroups(w http.ResponseWriter) {
+	versions := map[string][]string{}
+	for gv := range s.resources {
+		if gv.Group != "" {
+			versions[gv.Group] = append(versions[gv.Group], gv.Version)
+		}
+	}
+	list := &metav1.APIGroupList{TypeMeta: metav1.TypeMeta{Kind: "APIGroupList", APIVersion: "v1"}}
+	for group, vs := range versions {
+		sort.Slice(vs, func(i, j int) bool { return version.CompareKubeAwareVersionStrings(vs[i], vs[j]) > 0 })
+		g := metav1.APIGroup{Name: group}
+		for _, v := range vs {
+			g.Versions = append(g.Versions, metav1.GroupVersionForDiscovery{GroupVersion: group + "/" + v, Version: v})
+		}
+		g.PreferredVersion = g.Versions[0]
+		list.Groups = append(list.Groups, g)
+	}
+	sort.Slice(list.Groups, func(i, j int) bool { return list.Groups[i].Name < list.Groups[j].Name })
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *APIServer) serveResources(w http.ResponseWriter, gv schema.GroupVersion) {
+	resources, ok := s.resources[gv]
+	if !ok {
+		writeStatus(w, http.StatusNotFound, metav1.StatusReasonNotFound, fmt.Sprintf("group version %s not found", gv))
+		return
+	}
+	list := &metav1.APIResourceList{
+		TypeMeta:     metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"},
+		GroupVersion: gv.String(),
+	}
+	for plural, info := range resources {
+		list.APIResources = append(list.APIResources, metav1.APIResource{
+			Name:         plural,
+			SingularName: strings.ToLower(info.gvk.Kind),
+			Namespaced:   info.namespaced,
+			Kind:         info.gvk.Kind,
+			Verbs: metav1.Verbs{
+				"create", "delete", "deletecollection", "get", "list", "patch", "update", "watch",
+			},
+		}, metav1.APIResource{
+			Name:       plural + "/status",
+			Namespaced: info.namespaced,
+			Kind:       info.gvk.Kind,
+			Verbs:      metav1.Verbs{"get", "patch", "update"},
+		})
+	}
+	sort.Slice(list.APIResources, func(i, j int) bool { return list.APIResources[i].Name < list.APIResources[j].Name })
+	writeJSON(w, http.StatusOK, list)
+}
+
+func objectKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+func (s *APIServer) get(w http.ResponseWriter, info *resourceInfo, namespace, name string) {
+	s.mut.Lock()
+	obj := s.objects[info.gvr][objectKey(namespace, name)]
+	if obj != nil {
+		obj = copyObject(obj)
+	}
+	s.mut.Unlock()
+	if obj == nil {
+		writeNotFound(w, info, name)
+		return
+	}
+	writeJSON(w, http.StatusOK, obj)
+}
+
+func parseSelectors(r *http.Request) (labels.Selector, fields.Selector, error) {
+	label, err := labels.Parse(r.URL.Query().Get("labelSelector"))
+	if err != nil {
+		return nil, nil, err
+	}
+	field, err := fields.ParseSelector(r.URL.Query().Get("fieldSelector"))
+	if err != nil {
+		return nil, nil, err
+	}
+	return label, field, nil
+}
+
+// matches returns whether the object matches the selectors, only the name and
+// the namespace are supported by the field selectors.
+func matches(obj map[string]interface{}, namespace string, label labels.Selector, field fields.Selector) bool {
+	meta := objectMeta(obj)
+	ns, _ := meta["namespace"].(string)
+	if namespace != "" && ns != namespace {
+		return false
+	}
+	name, _ := meta["name"].(string)
+	if !field.Matches(fields.Set{"metadata.name": name, "metadata.namespace": ns}) {
+		return false
+	}
+	return label.Matches(labels.Set(stringMap(meta["labels"])))
+}
+
+func (s *APIServer) list(w http.ResponseWriter, r *http.Request, info *resourceInfo, namespace string) {
+	label, field, err := parseSelectors(r)
+	if err != nil {
+		writeStatus(w, http.StatusBadRequest, metav1.StatusReasonBadRequest, err.Error())
+		return
+	}
+
+	s.mut.Lock()
+	items := make([]interface{}, 0, len(s.objects[info.gvr]))
+	for _, obj := range s.objects[info.gvr] {
+		if matches(obj, namespace, label, field) {
+			items = append(items, copyObject(obj))
+		}
+	}
+	rv := s.rv
+	s.mut.Unlock()
+
+	sort.Slice(items, func(i, j int) bool {
+		return itemKey(items[i].(map[string]interface{})) < itemKey(items[j].(map[string]interface{}))
+	})
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"apiVersion": info.gvk.GroupVersion().String(),
+		"kind":       info.gvk.Kind + "List",
+		"metadata":   map[string]interface{}{"resourceVersion": strconv.FormatUint(rv, 10)},
+		"items":      items,
+	})
+}
+
+func itemKey(obj map[string]interface{}) string {
+	meta := objectMeta(obj)
+	ns, _ := meta["namespace"].(string)
+	name, _ := meta["name"].(string)
+	return objectKey(ns, name)
+}
+
+func (s *APIServer) watch(w http.ResponseWriter, r *http.Request, info *resourceInfo, namespace string) {
+	label, field, err := parseSelectors(r)
+	if err != nil {
+		writeStatus(w, http.StatusBadRequest, metav1.StatusReasonBadRequest, err.Error())
+		return
+	}
+	var since uint64
+	if v := r.URL.Query().Get("resourceVersion"); v != "" {
+		if since, err = strconv.ParseUint(v, 10, 64); err != nil {
+			writeStatus(w, http.StatusBadRequest, metav1.StatusReasonBadRequest, "invalid resource version "+v)
+			return
+		}
+	}
+	var timeout <-chan time.Time
+	if v := r.URL.Query().Get("timeoutSeconds"); v != "" {
+		if sec, err := strconv.Atoi(v); err == nil && sec > 0 {
+			timeout = time.After(time.Duration(sec) * time.Second)
+		}
+	}
+
+	wt := &watcher{
+		gvr:       info.gvr,
+		namespace: namespace,
+		label:     label,
+		field:     field,
+		ch:        make(chan *watchEvent, watchBufferSize),
+	}
+	var initial []*watchEvent
+	s.mut.Lock()
+	switch {
+	case since == 0:
+		// starts with the current objects
+		for _, obj := range s.objects[info.gvr] {
+			initial = append(initial, &watchEvent{typ: "ADDED", gvr: info.gvr, object: copyObject(obj)})
+		}
+		sort.Slice(initial, func(i, j int) bool { return itemKey(initial[i].object) < itemKey(initial[j].object) })
+	case len(s.history) > 0 && s.history[0].rv > since+1 && since < s.rv:
+		s.mut.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"type": "ERROR", "object": &metav1.Status{
+			TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+			Status:   metav1.StatusFailure,
+			Code:     http.StatusGone,
+			Reason:   metav1.StatusReasonExpired,
+			Message:  fmt.Sprintf("too old resource version: %d", since),
+		}})
+		return
+	default:
+		for _, e := range s.history {
+			if e.rv > since && e.gvr == info.gvr {
+				initial = append(initial, e)
+			}
+		}
+	}
+	s.watchers[wt] = struct{}{}
+	s.mut.Unlock()
+	defer func() {
+		s.mut.Lock()
+		delete(s.watchers, wt)
+		s.mut.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Transfer-Encoding", "chunked")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	send := func(e *watchEvent) bool {
+		if !matches(e.object, wt.namespace, wt.label, wt.field) {
+			return true
+		}
+		if err := encoder.Encode(map[string]interface{}{"type": e.typ, "object": e.object}); err != nil {
+			return false
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return true
+	}
+	for _, e := range initial {
+		if !send(e) {
+			return
+		}
+	}
+	if flusher != nil {
+		flusher.Flush()
+	}
+	for {
+		select {
+		case e, ok := <-wt.ch:
+			if !ok || !send(e) {
+				return
+			}
+		case <-timeout:
+			return
+		case <-r.Context().Done():
+			return
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// notify records the event and sends it to the watchers, it must be called
+// with the lock held.
+func (s *APIServer) notify(gvr schema.GroupVersionResource, typ string, obj map[string]interface{}) {
+	e := &watchEvent{rv: s.rv, gvr: gvr, typ: typ, object: copyObject(obj)}
+	s.history = append(s.history, e)
+	if len(s.history) > maxWatchHistory {
+		s.history = s.history[len(s.history)-maxWatchHistory:]
+	}
+	for wt := range s.watchers {
+		if wt.gvr != gvr {
+			continue
+		}
+		select {
+		case wt.ch <- e:
+		default:
+			// the slow watcher is closed, and the client will list again
+			close(wt.ch)
+			delete(s.watchers, wt)
+		}
+	}
+}
+
+func readObject(r *http.Request) (map[string]interface{}, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	return decodeObject(body)
+}
+
+func decodeObject(data []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var obj map[string]interface{}
+	if err := decoder.Decode(&obj); err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return nil, fmt.Errorf("empty object")
+	}
+	return obj, nil
+}
+
+func (s *APIServer) create(w http.ResponseWriter, r *http.Request, info *resourceInfo, namespace string) {
+	obj, err := readObject(r)
+	if err != nil {
+		writeStatus(w, http.StatusBadRequest, metav1.StatusReasonBadRequest, err.Error())
+		return
+	}
+	meta := objectMeta(obj)
+	name, _ := meta["name"].(string)
+	if generateName, _ := meta["generateName"].(string); name == "" && generateName != "" {
+		name = generateName + rand.String(5)
+	}
+	if name == "" {
+		writeStatus(w, http.StatusUnprocessableEntity, metav1.StatusReasonInvalid, "metadata.name is required")
+		return
+	}
+	if ns, _ := meta["namespace"].(string); info.namespaced && namespace == "" {
+		namespace = ns
+	}
+	if info.namespaced && namespace == "" {
+		writeStatus(w, http.StatusUnprocessableEntity, metav1.StatusReasonInvalid, "metadata.namespace is required")
+		return
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if s.objects[info.gvr] == nil {
+		s.objects[info.gvr] = map[string]map[string]interface{}{}
+	}
+	key := objectKey(namespace, name)
+	if _, ok := s.objects[info.gvr][key]; ok {
+		writeStatus(w, http.StatusConflict, metav1.StatusReasonAlreadyExists,
+			fmt.Sprintf("%s %q already exists", info.gvr.Resource, name))
+		return
+	}
+	s.rv++
+	obj["apiVersion"], obj["kind"] = info.gvk.GroupVersion().String(), info.gvk.Kind
+	meta["name"] = name
+	if info.namespaced {
+		meta["namespace"] = namespace
+	} else {
+		delete(meta, "namespace")
+	}
+	meta["uid"] = string(uuid.NewUUID())
+	meta["resourceVersion"] = strconv.FormatUint(s.rv, 10)
+	meta["generation"] = json.Number("1")
+	meta["creationTimestamp"] = time.Now().UTC().Format(time.RFC3339)
+	delete(meta, "deletionTimestamp")
+	s.objects[info.gvr][key] = obj
+	s.notify(info.gvr, "ADDED", obj)
+	writeJSON(w, http.StatusCreated, obj)
+}
+
+func (s *APIServer) update(w http.ResponseWriter, r *http.Request, info *resourceInfo, namespace, name string, status bool) {
+	obj, err := readObject(r)
+	if err != nil {
+		writeStatus(w, http.StatusBadRequest, metav1.StatusReasonBadRequest, err.Error())
+		return
+	}
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.write(w, info, namespace, name, status, func(map[string]interface{}) (map[string]interface{}, error) {
+		return obj, nil
+	})
+}
+
+func (s *APIServer) patch(w http.ResponseWriter, r *http.Request, info *resourceInfo, namespace, name string, status bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeStatus(w, http.StatusBadRequest, metav1.StatusReasonBadRequest, err.Error())
+		return
+	}
+	patchType := types.PatchType(strings.Split(r.Header.Get("Content-Type"), ";")[0])
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.write(w, info, namespace, name, status, func(existing map[string]interface{}) (map[string]interface{}, error) {
+		orig, err := json.Marshal(existing)
+		if err != nil {
+			return nil, err
+		}
+		var patched []byte
+		switch patchType {
+		case types.JSONPatchType:
+			p, err := jsonpatch.DecodePatch(body)
+			if err != nil {
+				return nil, err
+			}
+			patched, err = p.Apply(orig)
+			if err != nil {
+				return nil, err
+			}
+		case types.MergePatchType:
+			if patched, err = jsonpatch.MergePatch(orig, body); err != nil {
+				return nil, err
+			}
+		case types.StrategicMergePatchType:
+			if typed, err := s.scheme.New(info.gvk); err == nil {
+				if patched, err = strategicpatch.StrategicMergePatch(orig, body, typed); err == nil {
+					break
+				}
+			}
+			// the types without patch strategies
+			if patched, err = jsonpatch.MergePatch(orig, body); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, errUnsupportedPatch
+		}
+		return decodeObject(patched)
+	})
+}
+
+var errUnsupportedPatch = fmt.Errorf("unsupported patch type")
+
+// write updates the object by the mutate func, it must be called with the lock
+// held. Only the status is updated for the status subresource, and the status
+// is kept otherwise.
+func (s *APIServer) write(
+	w http.ResponseWriter,
+	info *resourceInfo,
+	namespace, name string,
+	status bool,
+	mutate func(existing map[string]interface{}) (map[string]interface{}, error),
+) {
+	key := objectKey(namespace, name)
+	existing := s.objects[info.gvr][key]
+	if existing == nil {
+		writeNotFound(w, info, name)
+		return
+	}
+	obj, err := mutate(copyObject(existing))
+	if err != nil {
+		if err == errUnsupportedPatch {
+			writeStatus(w, http.StatusUnsupportedMediaType, metav1.StatusReasonUnsupportedMediaType, err.Error())
+			return
+		}
+		writeStatus(w, http.StatusUnprocessableEntity, metav1.StatusReasonInvalid, err.Error())
+		return
+	}
+	meta, existingMeta := objectMeta(obj), objectMeta(existing)
+	if rv, _ := meta["resourceVersion"].(string); rv != "" && rv != existingMeta["resourceVersion"] {
+		writeStatus(w, http.StatusConflict, metav1.StatusReasonConflict, fmt.Sprintf(
+			"Operation cannot be fulfilled on %s %q: the object has been modified; "+
+				"please apply your changes to the latest version and try again", info.gvr.Resource, name))
+		return
+	}
+
+	if status {
+		ret := copyObject(existing)
+		ret["status"] = obj["status"]
+		obj = ret
+	} else {
+		obj["status"] = existing["status"]
+		if obj["status"] == nil {
+			delete(obj, "status")
+		}
+		// the immutable fields
+		for _, f := range []string{"name", "namespace", "uid", "creationTimestamp", "deletionTimestamp"} {
+			if v, ok := existingMeta[f]; ok {
+				meta[f] = v
+			} else {
+				delete(meta, f)
+			}
+		}
+		generation, _ := strconv.ParseInt(fmt.Sprint(existingMeta["generation"]), 10, 64)
+		if !equalIgnoringMeta(existing, obj) {
+			generation++
+		}
+		meta["generation"] = json.Number(strconv.FormatInt(generation, 10))
+	}
+	obj["apiVersion"], obj["kind"] = info.gvk.GroupVersion().String(), info.gvk.Kind
+
+	s.rv++
+	objectMeta(obj)["resourceVersion"] = strconv.FormatUint(s.rv, 10)
+	if objectMeta(obj)["deletionTimestamp"] != nil && len(sliceOf(objectMeta(obj)["finalizers"])) == 0 {
+		// the last finalizer is removed
+		delete(s.objects[info.gvr], key)
+		s.notify(info.gvr, "DELETED", obj)
+		s.deleteDependents(objectMeta(obj)["uid"])
+	} else {
+		s.objects[info.gvr][key] = obj
+		s.notify(info.gvr, "MODIFIED", obj)
+	}
+	writeJSON(w, http.StatusOK, obj)
+}
+
+func (s *APIServer) delete(w http.ResponseWriter, info *resourceInfo, namespace, name string) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	obj := s.deleteObject(info.gvr, objectKey(namespace, name))
+	if obj == nil {
+		writeNotFound(w, info, name)
+		return
+	}
+	writeJSON(w, http.StatusOK, obj)
+}
+
+func (s *APIServer) deleteCollection(w http.ResponseWriter, r *http.Request, info *resourceInfo, namespace string) {
+	label, field, err := parseSelectors(r)
+	if err != nil {
+		writeStatus(w, http.StatusBadRequest, metav1.StatusReasonBadRequest, err.Error())
+		return
+	}
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	var keys []string
+	for key, obj := range s.objects[info.gvr] {
+		if matches(obj, namespace, label, field) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s.deleteObject(info.gvr, key)
+	}
+	writeJSON(w, http.StatusOK, &metav1.Status{
+		TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+		Status:   metav1.StatusSuccess,
+	})
+}
+
+// deleteObject deletes the object, or marks it deleting if it has finalizers.
+// It must be called with the lock held.
+func (s *APIServer) deleteObject(gvr schema.GroupVersionResource, key string) map[string]interface{} {
+	obj := s.objects[gvr][key]
+	if obj == nil {
+		return nil
+	}
+	meta := objectMeta(obj)
+	s.rv++
+	if len(sliceOf(meta["finalizers"])) > 0 {
+		if meta["deletionTimestamp"] == nil {
+			meta["deletionTimestamp"] = time.Now().UTC().Format(time.RFC3339)
+		}
+		meta["resourceVersion"] = strconv.FormatUint(s.rv, 10)
+		s.notify(gvr, "MODIFIED", obj)
+		return copyObject(obj)
+	}
+	delete(s.objects[gvr], key)
+	meta["resourceVersion"] = strconv.FormatUint(s.rv, 10)
+	s.notify(gvr, "DELETED", obj)
+	s.deleteDependents(meta["uid"])
+	return obj
+}
+
+// deleteDependents deletes the objects owned by the uid in background like
+// the garbage collector.
+func (s *APIServer) deleteDependents(uid interface{}) {
+	if uid == nil {
+		return
+	}
+	for gvr, objs := range s.objects {
+		var keys []string
+		for key, obj := range objs {
+			for _, ref := range sliceOf(objectMeta(obj)["ownerReferences"]) {
+				if m, ok := ref.(map[string]interface{}); ok && m["uid"] == uid {
+					keys = append(keys, key)
+					break
+				}
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			s.deleteObject(gvr, key)
+		}
+	}
+}
+
+func objectMeta(obj map[string]interface{}) map[string]interface{} {
+	meta, ok := obj["metadata"].(map[string]interface{})
+	if !ok {
+		meta = map[string]interface{}{}
+		obj["metadata"] = meta
+	}
+	return meta
+}
+
+func sliceOf(v interface{}) []interface{} {
+	ret, _ := v.([]interface{})
+	return ret
+}
+
+func stringMap(v interface{}) map[string]string {
+	m, _ := v.(map[string]interface{})
+	ret := make(map[string]string, len(m))
+	for k, v := range m {
+		ret[k], _ = v.(string)
+	}
+	return ret
+}
+
+func copyObject(obj map[string]interface{}) map[string]interface{} {
+	return runtime.DeepCopyJSONValue(obj).(map[string]interface{})
+}
+
+// equalIgnoringMeta compares the objects except the metadata and the status,
+// whose changes do not bump the generation.
+func equalIgnoringMeta(a, b map[string]interface{}) bool {
+	strip := func(obj map[string]interface{}) ([]byte, error) {
+		obj = copyObject(obj)
+		delete(obj, "metadata")
+		delete(obj, "status")
+		return json.Marshal(obj)
+	}
+	ja, err := strip(a)
+	if err != nil {
+		return false
+	}
+	jb, err := strip(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(ja, jb)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeStatus(w http.ResponseWriter, code int, reason metav1.StatusReason, message string) {
+	writeJSON(w, code, &metav1.Status{
+		TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+		Status:   metav1.StatusFailure,
+		Code:     int32(code),
+		Reason:   reason,
+		Message:  message,
+	})
+}
+
+func writeNotFound(w http.ResponseWriter, info *resourceInfo, name string) {
+	writeJSON(w, http.StatusNotFound, &metav1.Status{
+		TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+		Status:   metav1.StatusFailure,
+		Code:     http.StatusNotFound,
+		Reason:   metav1.StatusReasonNotFound,
+		Details:  &metav1.StatusDetails{Name: name, Group: info.gvr.Group, Kind: info.gvr.Resource},
+		Message:  fmt.Sprintf("%s %q not found", info.gvr.GroupResource(), name),
+	})
+}
@@ -0,0 +1,186 @@
+package conformance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+func newTestClientset(t *testing.T) kubernetes.Interface {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	s := NewAPIServer(scheme)
+	t.Cleanup(s.Stop)
+	return kubernetes.NewForConfigOrDie(s.RestConfig())
+}
+
+func TestAPIServerCRUD(t *testing.T) {
+	ctx := context.Background()
+	cms := newTestClientset(t).CoreV1().ConfigMaps("default")
+
+	cm, err := cms.Create(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "cm", Labels: map[string]string{"app": "a"}},
+		Data:       map[string]string{"k": "v"},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cm.UID == "" || cm.ResourceVersion == "" || cm.Generation != 1 {
+		t.Errorf("unexpected meta of created object: %+v", cm.ObjectMeta)
+	}
+	if _, err := cms.Create(ctx, cm, metav1.CreateOptions{}); !apierrors.IsAlreadyExists(err) {
+		t.Errorf("create existing object, want AlreadyExists, got %v", err)
+	}
+
+	stale := cm.DeepCopy()
+	cm.Data["k"] = "v2"
+	if cm, err = cms.Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if cm.Generation != 2 {
+		t.Errorf("generation after update, want 2, got %d", cm.Generation)
+	}
+	if _, err := cms.Update(ctx, stale, metav1.UpdateOptions{}); !apierrors.IsConflict(err) {
+		t.Errorf("update stale object, want Conflict, got %v", err)
+	}
+
+	cm, err = cms.Patch(ctx, "cm", types.MergePatchType, []byte(`{"data":{"k2":"v"}}`), metav1.PatchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cm.Data["k"] != "v2" || cm.Data["k2"] != "v" {
+		t.Errorf("unexpected data after patch: %v", cm.Data)
+	}
+
+	list, err := cms.List(ctx, metav1.ListOptions{LabelSelector: "app=a"})
+	if err != nil || len(list.Items) != 1 {
+		t.Errorf("list by labels, want 1 item, got %v, %v", list, err)
+	}
+	list, err = cms.List(ctx, metav1.ListOptions{LabelSelector: "app=b"})
+	if err != nil || len(list.Items) != 0 {
+		t.Errorf("list by labels, want no item, got %v, %v", list, err)
+	}
+
+	if err := cms.Delete(ctx, "cm", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cms.Get(ctx, "cm", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("get deleted object, want NotFound, got %v", err)
+	}
+}
+
+func TestAPIServerStatus(t *testing.T) {
+	ctx := context.Background()
+	deploys := newTestClientset(t).AppsV1().Deployments("default")
+
+	deploy, err := deploys.Create(ctx, &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "d"}}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deploy.Status.ReadyReplicas = 1
+	deploy.Spec.Paused = true
+	if deploy, err = deploys.UpdateStatus(ctx, deploy, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if deploy.Status.ReadyReplicas != 1 || deploy.Spec.Paused || deploy.Generation != 1 {
+		t.Errorf("status update should only change the status: %+v", deploy)
+	}
+
+	deploy.Status.ReadyReplicas = 0
+	deploy.Spec.Paused = true
+	if deploy, err = deploys.Update(ctx, deploy, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if deploy.Status.ReadyReplicas != 1 || !deploy.Spec.Paused || deploy.Generation != 2 {
+		t.Errorf("update should not change the status: %+v", deploy)
+	}
+}
+
+func TestAPIServerDeletion(t *testing.T) {
+	ctx := context.Background()
+	cms := newTestClientset(t).CoreV1().ConfigMaps("default")
+
+	owner, err := cms.Create(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "owner", Finalizers: []string{"test"}},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = cms.Create(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name:            "dependent",
+		OwnerReferences: []metav1.OwnerReference{{APIVersion: "v1", Kind: "ConfigMap", Name: owner.Name, UID: owner.UID}},
+	}}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cms.Delete(ctx, "owner", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if owner, err = cms.Get(ctx, "owner", metav1.GetOptions{}); err != nil || owner.DeletionTimestamp == nil {
+		t.Fatalf("object with finalizers should be marked deleted, got %v, %v", owner, err)
+	}
+
+	owner.Finalizers = nil
+	if _, err := cms.Update(ctx, owner, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"owner", "dependent"} {
+		if _, err := cms.Get(ctx, name, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+			t.Errorf("get %s, want NotFound, got %v", name, err)
+		}
+	}
+}
+
+func TestAPIServerWatch(t *testing.T) {
+	ctx := context.Background()
+	cms := newTestClientset(t).CoreV1().ConfigMaps("default")
+
+	if _, err := cms.Create(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "a"}}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	list, err := cms.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := cms.Watch(ctx, metav1.ListOptions{ResourceVersion: list.ResourceVersion})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	if _, err := cms.Create(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "b"}}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cms.Delete(ctx, "a", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		typ  watch.EventType
+		name string
+	}{{watch.Added, "b"}, {watch.Deleted, "a"}}
+	for _, ev := range want {
+		select {
+		case got := <-w.ResultChan():
+			cm, ok := got.Object.(*corev1.ConfigMap)
+			if !ok || got.Type != ev.typ || cm.Name != ev.name {
+				t.Errorf("want event %s %s, got %s %v", ev.typ, ev.name, got.Type, got.Object)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("event %s %s is not received", ev.typ, ev.name)
+		}
+	}
+}
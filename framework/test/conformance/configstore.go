@@ -0,0 +1,62 @@
+package conformance
+
+import (
+	"os"
+	"path/filepath"
+
+	"slime.io/slime/framework/bootstrap"
+)
+
+// ConfigStore is a fake istio config store, served to the modules as a file
+// config source. The istio configs are applied as yaml documents, which are
+// synced to the ConfigController of the modules in background.
+type ConfigStore struct {
+	dir string
+}
+
+func newConfigStore(dir string) (*ConfigStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &ConfigStore{dir: dir}, nil
+}
+
+// Address returns the config source address of the store.
+func (s *ConfigStore) Address() string {
+	return bootstrap.FileConfigSourcePrefix + s.dir
+}
+
+// Apply sets the istio configs in the yaml documents under the name, the
+// configs previously applied under the name are replaced.
+func (s *ConfigStore) Apply(name, yaml string) error {
+	// write and rename, so that the source never reads a partial file
+	tmp := filepath.Join(s.dir, "."+name+".tmp")
+	if err := os.WriteFile(tmp, []byte(yaml), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path(name))
+}
+
+// ApplyFile applies the istio configs in the file, under the name of the file.
+func (s *ConfigStore) ApplyFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return s.Apply(filepath.Base(path), string(data))
+}
+
+// Delete deletes the configs applied under the name.
+func (s *ConfigStore) Delete(name string) error {
+	if err := os.Remove(s.path(name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *ConfigStore) path(name string) string {
+	if ext := filepath.Ext(name); ext != ".yaml" && ext != ".yml" && ext != ".json" {
+		name += ".yaml"
+	}
+	return filepath.Join(s.dir, name)
+}
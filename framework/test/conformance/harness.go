@@ -0,0 +1,386 @@
+// Package conformance is an in-process harness of the hermetic integration
+// tests of the modules. It boots the modules with the wiring of `module.Main`
+// against a fake api server and a fake istio config store, drives the changes
+// of the CRs, and asserts the generated resources with golden yaml files.
+//
+// A typical test looks like:
+//
+//	h := conformance.New(t, conformance.Options{
+//		Modules: []module.Module{&pluginmod.Module{}},
+//		Config:  `{"name": "plugin", "kind": "plugin", "enable": true}`,
+//	})
+//	h.ApplyFile("testdata/envoyplugin.yaml")
+//	h.ExpectGolden(envoyFilterGVK, "default", "testdata/envoyfilter.golden.yaml")
+//
+// Run the tests with `REFRESH_GOLDEN=true` to update the golden files.
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	bootconfig "slime.io/slime/framework/apis/config/v1alpha1"
+	"slime.io/slime/framework/bootstrap"
+	"slime.io/slime/framework/model/module"
+)
+
+const (
+	defaultBundle  = "conformance"
+	defaultTimeout = 10 * time.Second
+	pollInterval   = 100 * time.Millisecond
+	stopTimeout    = 10 * time.Second
+
+	// RefreshGoldenEnv is the env to update the golden files instead of
+	// asserting them.
+	RefreshGoldenEnv = "REFRESH_GOLDEN"
+)
+
+// Options are the options to boot the modules.
+type Options struct {
+	// Modules are the definitions of the modules, like the ones passed to
+	// `module.Main`.
+	Modules []module.Module
+	// Config is the module config as rendered by SlimeBoot, in yaml or json.
+	// For a bundle, the configs of the bundle items are in BundleItems by name.
+	Config      string
+	BundleItems map[string]string
+	// Bundle is the name of the bundle, `conformance` by default.
+	Bundle string
+	// ConfigSource adds a fake istio config store to `global.configSources`,
+	// whose configs are managed by Harness.ConfigSource.
+	ConfigSource bool
+	// Timeout of the eventual assertions, 10s by default.
+	Timeout time.Duration
+}
+
+// Harness runs the modules in process until the end of the test.
+type Harness struct {
+	t       testing.TB
+	timeout time.Duration
+	done    chan struct{}
+	runErr  error
+
+	// Scheme contains the types of all the modules
+	Scheme *runtime.Scheme
+	// APIServer is the fake api server the modules run against
+	APIServer *APIServer
+	// RestConfig is the config of the clients to the APIServer
+	RestConfig *rest.Config
+	// Client is a client to the APIServer
+	Client client.Client
+	// ConfigSource is the fake istio config store if Options.ConfigSource
+	ConfigSource *ConfigStore
+}
+
+// New boots the modules, which are stopped at the end of the test.
+func New(t testing.TB, opts Options) *Harness {
+	t.Helper()
+	if opts.Bundle == "" {
+		opts.Bundle = defaultBundle
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+
+	scheme := runtime.NewScheme()
+	for _, mod := range opts.Modules {
+		if err := mod.InitScheme(scheme); err != nil {
+			t.Fatalf("init scheme of module %s error: %v", mod.Kind(), err)
+		}
+	}
+
+	h := &Harness{
+		t:       t,
+		timeout: opts.Timeout,
+		done:    make(chan struct{}),
+		Scheme:  scheme,
+	}
+	if opts.ConfigSource {
+		store, err := newConfigStore(filepath.Join(t.TempDir(), "configs"))
+		if err != nil {
+			t.Fatalf("create config store error: %v", err)
+		}
+		h.ConfigSource = store
+	}
+	configs, err := h.moduleConfigs(opts)
+	if err != nil {
+		t.Fatalf("load module configs error: %v", err)
+	}
+
+	h.APIServer = NewAPIServer(scheme)
+	h.RestConfig = h.APIServer.RestConfig()
+	h.Client, err = client.New(h.RestConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		h.APIServer.Stop()
+		t.Fatalf("create client error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		defer close(h.done)
+		h.runErr = module.Run(ctx, opts.Bundle, opts.Modules, module.Options{
+			ModuleConfig: func(name string) (*bootstrap.ParsedModuleConfig, error) {
+				cfg, ok := configs[name]
+				if !ok {
+					return nil, fmt.Errorf("config of bundle item %s not found", name)
+				}
+				return cfg, nil
+			},
+			RestConfig:       h.RestConfig,
+			DisableAuxServer: true,
+		})
+	}()
+	t.Cleanup(func() {
+		cancel()
+		select {
+		case <-h.done:
+		case <-time.After(stopTimeout):
+			t.Errorf("modules are not stopped in %s", stopTimeout)
+		}
+		h.APIServer.Stop()
+		if h.runErr != nil {
+			t.Errorf("run modules error: %v", h.runErr)
+		}
+	})
+	return h
+}
+
+// moduleConfigs parses the module configs like the config files, and points
+// the modules to the fakes.
+func (h *Harness) moduleConfigs(opts Options) (map[string]*bootstrap.ParsedModuleConfig, error) {
+	main, err := bootstrap.LoadModuleConfigFromData([]byte(opts.Config), true)
+	if err != nil {
+		return nil, err
+	}
+	global := main.Config.Global
+	if global == nil {
+		global = &bootconfig.Global{}
+		main.Config.Global = global
+	}
+	if global.Misc == nil {
+		global.Misc = map[string]string{}
+	}
+	// the metrics of the manager are disabled, as the port may be in use
+	global.Misc["metrics-addr"] = "0"
+	if h.ConfigSource != nil {
+		global.ConfigSources = append(global.ConfigSources, &bootconfig.ConfigSource{
+			Address: h.ConfigSource.Address(),
+		})
+	}
+
+	configs := map[string]*bootstrap.ParsedModuleConfig{"": main}
+	for name, cfg := range opts.BundleItems {
+		parsed, err := bootstrap.LoadModuleConfigFromData([]byte(cfg), false)
+		if err != nil {
+			return nil, fmt.Errorf("parse config of bundle item %s error: %v", name, err)
+		}
+		configs[name] = parsed
+	}
+	return configs, nil
+}
+
+// Eventually polls the condition until it returns nil, and fails the test if
+// it still fails after the timeout.
+func (h *Harness) Eventually(cond func() error) {
+	h.t.Helper()
+	deadline := time.Now().Add(h.timeout)
+	for {
+		err := cond()
+		if err == nil {
+			return
+		}
+		select {
+		case <-h.done:
+			h.t.Fatalf("modules stopped with error %v, last condition error: %v", h.runErr, err)
+		default:
+		}
+		if time.Now().After(deadline) {
+			h.t.Fatalf("condition is not met in %s: %v", h.timeout, err)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// Apply creates the objects or updates the existing ones.
+func (h *Harness) Apply(objs ...client.Object) {
+	h.t.Helper()
+	ctx := context.Background()
+	for _, obj := range objs {
+		existing := &unstructured.Unstructured{}
+		gvk, err := h.gvk(obj)
+		if err != nil {
+			h.t.Fatal(err)
+		}
+		existing.SetGroupVersionKind(gvk)
+		err = h.Client.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+		switch {
+		case apierrors.IsNotFound(err):
+			err = h.Client.Create(ctx, obj)
+		case err == nil:
+			obj.SetResourceVersion(existing.GetResourceVersion())
+			err = h.Client.Update(ctx, obj)
+		}
+		if err != nil {
+			h.t.Fatalf("apply %s %s error: %v", gvk.Kind, client.ObjectKeyFromObject(obj), err)
+		}
+	}
+}
+
+// ApplyFile applies the objects in the yaml or json documents of the file.
+func (h *Harness) ApplyFile(path string) {
+	h.t.Helper()
+	objs, err := LoadObjects(path)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	h.Apply(objs...)
+}
+
+// Delete deletes the objects, the missing ones are ignored.
+func (h *Harness) Delete(objs ...client.Object) {
+	h.t.Helper()
+	for _, obj := range objs {
+		if err := h.Client.Delete(context.Background(), obj); err != nil && !apierrors.IsNotFound(err) {
+			h.t.Fatalf("delete %s error: %v", client.ObjectKeyFromObject(obj), err)
+		}
+	}
+}
+
+// DeleteFile deletes the objects in the yaml or json documents of the file.
+func (h *Harness) DeleteFile(path string) {
+	h.t.Helper()
+	objs, err := LoadObjects(path)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	h.Delete(objs...)
+}
+
+func (h *Harness) gvk(obj client.Object) (schema.GroupVersionKind, error) {
+	if gvk := obj.GetObjectKind().GroupVersionKind(); !gvk.Empty() {
+		return gvk, nil
+	}
+	gvks, _, err := h.Scheme.ObjectKinds(obj)
+	if err != nil {
+		return schema.GroupVersionKind{}, err
+	}
+	return gvks[0], nil
+}
+
+// ExpectGolden waits until the objects of the kind in the namespace, all the
+// namespaces if empty, equal to the ones in the golden file. The golden file
+// is updated instead if the env REFRESH_GOLDEN is true.
+func (h *Harness) ExpectGolden(gvk schema.GroupVersionKind, namespace, golden string) {
+	h.t.Helper()
+	if os.Getenv(RefreshGoldenEnv) == "true" {
+		// wait for the modules to settle
+		time.Sleep(h.timeout / 5)
+		got, err := h.dump(gvk, namespace)
+		if err != nil {
+			h.t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+			h.t.Fatal(err)
+		}
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			h.t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	h.Eventually(func() error {
+		got, err := h.dump(gvk, namespace)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(bytes.TrimSpace(got), bytes.TrimSpace(want)) {
+			return fmt.Errorf("%s in %q differ from %s\n--- want:\n%s\n--- got:\n%s", gvk.Kind, namespace, golden, want, got)
+		}
+		return nil
+	})
+}
+
+// dump returns the normalized yaml of the objects, the fields set by the api
+// server are removed so that the outputs are stable.
+func (h *Harness) dump(gvk schema.GroupVersionKind, namespace string) ([]byte, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := h.Client.List(context.Background(), list, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		a, b := list.Items[i], list.Items[j]
+		return a.GetNamespace()+"/"+a.GetName() < b.GetNamespace()+"/"+b.GetName()
+	})
+
+	docs := make([]string, 0, len(list.Items))
+	for i := range list.Items {
+		obj := list.Items[i].Object
+		for _, f := range []string{"uid", "resourceVersion", "creationTimestamp", "generation", "managedFields", "selfLink"} {
+			unstructured.RemoveNestedField(obj, "metadata", f)
+		}
+		refs, _, _ := unstructured.NestedSlice(obj, "metadata", "ownerReferences")
+		for _, ref := range refs {
+			if m, ok := ref.(map[string]interface{}); ok {
+				delete(m, "uid")
+			}
+		}
+		if len(refs) > 0 {
+			_ = unstructured.SetNestedSlice(obj, refs, "metadata", "ownerReferences")
+		}
+		out, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, string(out))
+	}
+	return []byte(strings.Join(docs, "---\n")), nil
+}
+
+// LoadObjects loads the objects in the yaml or json documents of the file as
+// unstructured objects.
+func LoadObjects(path string) ([]client.Object, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var objs []client.Object
+	decoder := utilyaml.NewYAMLOrJSONDecoder(f, 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objs, nil
+			}
+			return nil, fmt.Errorf("decode objects of %s error: %v", path, err)
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+		objs = append(objs, obj)
+	}
+}
@@ -13,6 +13,7 @@ package v1alpha1
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	v1alpha3 "istio.io/api/networking/v1alpha3"
 	reflect "reflect"
 	sync "sync"
 )
@@ -24,6 +25,60 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type OverflowPolicy int32
+
+const (
+	// replace the learned hosts of a namespace with the namespace wildcard `ns/*`, from the namespace
+	// with the most learned hosts, until the learned hosts are within the max
+	OverflowPolicy_NAMESPACE_WILDCARD OverflowPolicy = 0
+	// keep the most recently called learned hosts, the evicted ones go to the global-sidecar again
+	// and are learned back when called
+	OverflowPolicy_EVICT_LEAST_RECENTLY_CALLED OverflowPolicy = 1
+	// disable fencing, the sidecar contains all the hosts `*/*`
+	OverflowPolicy_DISABLE_FENCE OverflowPolicy = 2
+)
+
+// Enum value maps for OverflowPolicy.
+var (
+	OverflowPolicy_name = map[int32]string{
+		0: "NAMESPACE_WILDCARD",
+		1: "EVICT_LEAST_RECENTLY_CALLED",
+		2: "DISABLE_FENCE",
+	}
+	OverflowPolicy_value = map[string]int32{
+		"NAMESPACE_WILDCARD":          0,
+		"EVICT_LEAST_RECENTLY_CALLED": 1,
+		"DISABLE_FENCE":               2,
+	}
+)
+
+func (x OverflowPolicy) Enum() *OverflowPolicy {
+	p := new(OverflowPolicy)
+	*p = x
+	return p
+}
+
+func (x OverflowPolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (OverflowPolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_service_fence_proto_enumTypes[0].Descriptor()
+}
+
+func (OverflowPolicy) Type() protoreflect.EnumType {
+	return &file_service_fence_proto_enumTypes[0]
+}
+
+func (x OverflowPolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use OverflowPolicy.Descriptor instead.
+func (OverflowPolicy) EnumDescriptor() ([]byte, []int) {
+	return file_service_fence_proto_rawDescGZIP(), []int{0}
+}
+
 type Destinations_Status int32
 
 const (
@@ -67,11 +122,11 @@ func (x Destinations_Status) String() string {
 }
 
 func (Destinations_Status) Descriptor() protoreflect.EnumDescriptor {
-	return file_service_fence_proto_enumTypes[0].Descriptor()
+	return file_service_fence_proto_enumTypes[1].Descriptor()
 }
 
 func (Destinations_Status) Type() protoreflect.EnumType {
-	return &file_service_fence_proto_enumTypes[0]
+	return &file_service_fence_proto_enumTypes[1]
 }
 
 func (x Destinations_Status) Number() protoreflect.EnumNumber {
@@ -167,6 +222,14 @@ func (x *Timestamp) GetNanos() int32 {
 //	     group: foo
 //	     zone: hz
 //	   fromService: false
+//	 sidecarTemplate: # merged with the generated sidecar
+//	   outboundTrafficPolicy:
+//	     mode: REGISTRY_ONLY
+//	   egress:
+//	     - hosts: # static hosts, merged with the learned hosts
+//	         - istio-system/*
+//	 maxLearnedHosts: 100 # at most 100 learned hosts in the sidecar
+//	 overflowPolicy: EVICT_LEAST_RECENTLY_CALLED
 type ServiceFenceSpec struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -180,6 +243,14 @@ type ServiceFenceSpec struct {
 	// services match one selector of the label selector are all static dependency, will not expire
 	LabelSelector    []*Selector       `protobuf:"bytes,4,rep,name=labelSelector,proto3" json:"labelSelector,omitempty"`
 	WorkloadSelector *WorkloadSelector `protobuf:"bytes,5,opt,name=workloadSelector,proto3" json:"workloadSelector,omitempty"`
+	// template of the generated sidecar, the fields other than egress are kept, and the hosts
+	// of the egress listener without port are merged with the learned hosts
+	SidecarTemplate *v1alpha3.Sidecar `protobuf:"bytes,6,opt,name=sidecarTemplate,proto3" json:"sidecarTemplate,omitempty"`
+	// max number of the learned hosts in the generated sidecar, the static ones are not counted.
+	// No limit if 0
+	MaxLearnedHosts uint32 `protobuf:"varint,7,opt,name=maxLearnedHosts,proto3" json:"maxLearnedHosts,omitempty"`
+	// what to do if the learned hosts exceed maxLearnedHosts
+	OverflowPolicy OverflowPolicy `protobuf:"varint,8,opt,name=overflowPolicy,proto3,enum=slime.microservice.lazyload.v1alpha1.OverflowPolicy" json:"overflowPolicy,omitempty"`
 }
 
 func (x *ServiceFenceSpec) Reset() {
@@ -249,6 +320,27 @@ func (x *ServiceFenceSpec) GetWorkloadSelector() *WorkloadSelector {
 	return nil
 }
 
+func (x *ServiceFenceSpec) GetSidecarTemplate() *v1alpha3.Sidecar {
+	if x != nil {
+		return x.SidecarTemplate
+	}
+	return nil
+}
+
+func (x *ServiceFenceSpec) GetMaxLearnedHosts() uint32 {
+	if x != nil {
+		return x.MaxLearnedHosts
+	}
+	return 0
+}
+
+func (x *ServiceFenceSpec) GetOverflowPolicy() OverflowPolicy {
+	if x != nil {
+		return x.OverflowPolicy
+	}
+	return OverflowPolicy_NAMESPACE_WILDCARD
+}
+
 type Selector struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -434,7 +526,8 @@ type Destinations struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// Deprecated
+	// the last time the learned hosts are called, it's updated in the refresh after the hosts are
+	// learned or called again, and used to evict the least recently called hosts
 	RecentlyCalled *Timestamp          `protobuf:"bytes,1,opt,name=RecentlyCalled,proto3" json:"RecentlyCalled,omitempty"`
 	Hosts          []string            `protobuf:"bytes,2,rep,name=hosts,proto3" json:"hosts,omitempty"`
 	Status         Destinations_Status `protobuf:"varint,3,opt,name=status,proto3,enum=slime.microservice.lazyload.v1alpha1.Destinations_Status" json:"status,omitempty"`
@@ -493,6 +586,91 @@ func (x *Destinations) GetStatus() Destinations_Status {
 	return Destinations_ACTIVE
 }
 
+// HostBudgetStatus reports the learned hosts against the maxLearnedHosts
+type HostBudgetStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// number of the learned hosts
+	LearnedHosts uint32 `protobuf:"varint,1,opt,name=learnedHosts,proto3" json:"learnedHosts,omitempty"`
+	// whether the learned hosts exceed the max
+	Overflow bool `protobuf:"varint,2,opt,name=overflow,proto3" json:"overflow,omitempty"`
+	// the learned hosts evicted from the sidecar by EVICT_LEAST_RECENTLY_CALLED
+	EvictedHosts []string `protobuf:"bytes,3,rep,name=evictedHosts,proto3" json:"evictedHosts,omitempty"`
+	// the namespaces replaced with the wildcard in the sidecar by NAMESPACE_WILDCARD
+	WildcardNamespaces []string `protobuf:"bytes,4,rep,name=wildcardNamespaces,proto3" json:"wildcardNamespaces,omitempty"`
+	// whether the fencing is disabled by DISABLE_FENCE
+	FenceDisabled bool `protobuf:"varint,5,opt,name=fenceDisabled,proto3" json:"fenceDisabled,omitempty"`
+}
+
+func (x *HostBudgetStatus) Reset() {
+	*x = HostBudgetStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_service_fence_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HostBudgetStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HostBudgetStatus) ProtoMessage() {}
+
+func (x *HostBudgetStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_service_fence_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HostBudgetStatus.ProtoReflect.Descriptor instead.
+func (*HostBudgetStatus) Descriptor() ([]byte, []int) {
+	return file_service_fence_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *HostBudgetStatus) GetLearnedHosts() uint32 {
+	if x != nil {
+		return x.LearnedHosts
+	}
+	return 0
+}
+
+func (x *HostBudgetStatus) GetOverflow() bool {
+	if x != nil {
+		return x.Overflow
+	}
+	return false
+}
+
+func (x *HostBudgetStatus) GetEvictedHosts() []string {
+	if x != nil {
+		return x.EvictedHosts
+	}
+	return nil
+}
+
+func (x *HostBudgetStatus) GetWildcardNamespaces() []string {
+	if x != nil {
+		return x.WildcardNamespaces
+	}
+	return nil
+}
+
+func (x *HostBudgetStatus) GetFenceDisabled() bool {
+	if x != nil {
+		return x.FenceDisabled
+	}
+	return false
+}
+
 type ServiceFenceStatus struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -502,12 +680,14 @@ type ServiceFenceStatus struct {
 	MetricStatus map[string]string        `protobuf:"bytes,3,rep,name=metricStatus,proto3" json:"metricStatus,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 	// Deprecated
 	Visitor map[string]bool `protobuf:"bytes,2,rep,name=visitor,proto3" json:"visitor,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	// set if maxLearnedHosts is set
+	HostBudget *HostBudgetStatus `protobuf:"bytes,4,opt,name=hostBudget,proto3" json:"hostBudget,omitempty"`
 }
 
 func (x *ServiceFenceStatus) Reset() {
 	*x = ServiceFenceStatus{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_service_fence_proto_msgTypes[6]
+		mi := &file_service_fence_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -520,7 +700,7 @@ func (x *ServiceFenceStatus) String() string {
 func (*ServiceFenceStatus) ProtoMessage() {}
 
 func (x *ServiceFenceStatus) ProtoReflect() protoreflect.Message {
-	mi := &file_service_fence_proto_msgTypes[6]
+	mi := &file_service_fence_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -533,7 +713,7 @@ func (x *ServiceFenceStatus) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ServiceFenceStatus.ProtoReflect.Descriptor instead.
 func (*ServiceFenceStatus) Descriptor() ([]byte, []int) {
-	return file_service_fence_proto_rawDescGZIP(), []int{6}
+	return file_service_fence_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *ServiceFenceStatus) GetDomains() map[string]*Destinations {
@@ -557,6 +737,13 @@ func (x *ServiceFenceStatus) GetVisitor() map[string]bool {
 	return nil
 }
 
+func (x *ServiceFenceStatus) GetHostBudget() *HostBudgetStatus {
+	if x != nil {
+		return x.HostBudget
+	}
+	return nil
+}
+
 type RecyclingStrategy_Stable struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -566,7 +753,7 @@ type RecyclingStrategy_Stable struct {
 func (x *RecyclingStrategy_Stable) Reset() {
 	*x = RecyclingStrategy_Stable{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_service_fence_proto_msgTypes[10]
+		mi := &file_service_fence_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -579,7 +766,7 @@ func (x *RecyclingStrategy_Stable) String() string {
 func (*RecyclingStrategy_Stable) ProtoMessage() {}
 
 func (x *RecyclingStrategy_Stable) ProtoReflect() protoreflect.Message {
-	mi := &file_service_fence_proto_msgTypes[10]
+	mi := &file_service_fence_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -606,7 +793,7 @@ type RecyclingStrategy_Deadline struct {
 func (x *RecyclingStrategy_Deadline) Reset() {
 	*x = RecyclingStrategy_Deadline{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_service_fence_proto_msgTypes[11]
+		mi := &file_service_fence_proto_msgTypes[12]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -619,7 +806,7 @@ func (x *RecyclingStrategy_Deadline) String() string {
 func (*RecyclingStrategy_Deadline) ProtoMessage() {}
 
 func (x *RecyclingStrategy_Deadline) ProtoReflect() protoreflect.Message {
-	mi := &file_service_fence_proto_msgTypes[11]
+	mi := &file_service_fence_proto_msgTypes[12]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -653,7 +840,7 @@ type RecyclingStrategy_Auto struct {
 func (x *RecyclingStrategy_Auto) Reset() {
 	*x = RecyclingStrategy_Auto{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_service_fence_proto_msgTypes[12]
+		mi := &file_service_fence_proto_msgTypes[13]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -666,7 +853,7 @@ func (x *RecyclingStrategy_Auto) String() string {
 func (*RecyclingStrategy_Auto) ProtoMessage() {}
 
 func (x *RecyclingStrategy_Auto) ProtoReflect() protoreflect.Message {
-	mi := &file_service_fence_proto_msgTypes[12]
+	mi := &file_service_fence_proto_msgTypes[13]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -695,135 +882,169 @@ var file_service_fence_proto_rawDesc = []byte{
 	0x0a, 0x13, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x5f, 0x66, 0x65, 0x6e, 0x63, 0x65, 0x2e,
 	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x24, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x6d, 0x69, 0x63,
 	0x72, 0x6f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6c, 0x61, 0x7a, 0x79, 0x6c, 0x6f,
-	0x61, 0x64, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x22, 0x3b, 0x0a, 0x09, 0x54,
-	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x65, 0x63, 0x6f,
-	0x6e, 0x64, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x73, 0x65, 0x63, 0x6f, 0x6e,
-	0x64, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x61, 0x6e, 0x6f, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x05, 0x52, 0x05, 0x6e, 0x61, 0x6e, 0x6f, 0x73, 0x22, 0xda, 0x03, 0x0a, 0x10, 0x53, 0x65, 0x72,
-	0x76, 0x69, 0x63, 0x65, 0x46, 0x65, 0x6e, 0x63, 0x65, 0x53, 0x70, 0x65, 0x63, 0x12, 0x54, 0x0a,
-	0x04, 0x68, 0x6f, 0x73, 0x74, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x40, 0x2e, 0x73, 0x6c,
-	0x69, 0x6d, 0x65, 0x2e, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65,
-	0x2e, 0x6c, 0x61, 0x7a, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68,
-	0x61, 0x31, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x46, 0x65, 0x6e, 0x63, 0x65, 0x53,
-	0x70, 0x65, 0x63, 0x2e, 0x48, 0x6f, 0x73, 0x74, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x04, 0x68,
-	0x6f, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x08, 0x52, 0x06, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x2c, 0x0a, 0x11, 0x6e,
-	0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72,
-	0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x11, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63,
-	0x65, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x54, 0x0a, 0x0d, 0x6c, 0x61, 0x62,
-	0x65, 0x6c, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b,
-	0x32, 0x2e, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x65,
-	0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6c, 0x61, 0x7a, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x2e, 0x76,
-	0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72,
-	0x52, 0x0d, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x12,
-	0x62, 0x0a, 0x10, 0x77, 0x6f, 0x72, 0x6b, 0x6c, 0x6f, 0x61, 0x64, 0x53, 0x65, 0x6c, 0x65, 0x63,
-	0x74, 0x6f, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x36, 0x2e, 0x73, 0x6c, 0x69, 0x6d,
+	0x61, 0x64, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x1a, 0x21, 0x6e, 0x65, 0x74,
+	0x77, 0x6f, 0x72, 0x6b, 0x69, 0x6e, 0x67, 0x2f, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x33,
+	0x2f, 0x73, 0x69, 0x64, 0x65, 0x63, 0x61, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x3b,
+	0x0a, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x18, 0x0a, 0x07, 0x73,
+	0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x73, 0x65,
+	0x63, 0x6f, 0x6e, 0x64, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x61, 0x6e, 0x6f, 0x73, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x6e, 0x61, 0x6e, 0x6f, 0x73, 0x22, 0xb0, 0x05, 0x0a, 0x10,
+	0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x46, 0x65, 0x6e, 0x63, 0x65, 0x53, 0x70, 0x65, 0x63,
+	0x12, 0x54, 0x0a, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x40,
+	0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x65, 0x72, 0x76,
+	0x69, 0x63, 0x65, 0x2e, 0x6c, 0x61, 0x7a, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x2e, 0x76, 0x31, 0x61,
+	0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x46, 0x65, 0x6e,
+	0x63, 0x65, 0x53, 0x70, 0x65, 0x63, 0x2e, 0x48, 0x6f, 0x73, 0x74, 0x45, 0x6e, 0x74, 0x72, 0x79,
+	0x52, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x2c,
+	0x0a, 0x11, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x53, 0x65, 0x6c, 0x65, 0x63,
+	0x74, 0x6f, 0x72, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x11, 0x6e, 0x61, 0x6d, 0x65, 0x73,
+	0x70, 0x61, 0x63, 0x65, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x54, 0x0a, 0x0d,
+	0x6c, 0x61, 0x62, 0x65, 0x6c, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x18, 0x04, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x2e, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x6d, 0x69, 0x63, 0x72,
+	0x6f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6c, 0x61, 0x7a, 0x79, 0x6c, 0x6f, 0x61,
+	0x64, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x53, 0x65, 0x6c, 0x65, 0x63,
+	0x74, 0x6f, 0x72, 0x52, 0x0d, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74,
+	0x6f, 0x72, 0x12, 0x62, 0x0a, 0x10, 0x77, 0x6f, 0x72, 0x6b, 0x6c, 0x6f, 0x61, 0x64, 0x53, 0x65,
+	0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x36, 0x2e, 0x73,
+	0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63,
+	0x65, 0x2e, 0x6c, 0x61, 0x7a, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70,
+	0x68, 0x61, 0x31, 0x2e, 0x57, 0x6f, 0x72, 0x6b, 0x6c, 0x6f, 0x61, 0x64, 0x53, 0x65, 0x6c, 0x65,
+	0x63, 0x74, 0x6f, 0x72, 0x52, 0x10, 0x77, 0x6f, 0x72, 0x6b, 0x6c, 0x6f, 0x61, 0x64, 0x53, 0x65,
+	0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x4c, 0x0a, 0x0f, 0x73, 0x69, 0x64, 0x65, 0x63, 0x61,
+	0x72, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x22, 0x2e, 0x69, 0x73, 0x74, 0x69, 0x6f, 0x2e, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x69,
+	0x6e, 0x67, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x33, 0x2e, 0x53, 0x69, 0x64, 0x65,
+	0x63, 0x61, 0x72, 0x52, 0x0f, 0x73, 0x69, 0x64, 0x65, 0x63, 0x61, 0x72, 0x54, 0x65, 0x6d, 0x70,
+	0x6c, 0x61, 0x74, 0x65, 0x12, 0x28, 0x0a, 0x0f, 0x6d, 0x61, 0x78, 0x4c, 0x65, 0x61, 0x72, 0x6e,
+	0x65, 0x64, 0x48, 0x6f, 0x73, 0x74, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0f, 0x6d,
+	0x61, 0x78, 0x4c, 0x65, 0x61, 0x72, 0x6e, 0x65, 0x64, 0x48, 0x6f, 0x73, 0x74, 0x73, 0x12, 0x5c,
+	0x0a, 0x0e, 0x6f, 0x76, 0x65, 0x72, 0x66, 0x6c, 0x6f, 0x77, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79,
+	0x18, 0x08, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x34, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x6d,
+	0x69, 0x63, 0x72, 0x6f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6c, 0x61, 0x7a, 0x79,
+	0x6c, 0x6f, 0x61, 0x64, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x4f, 0x76,
+	0x65, 0x72, 0x66, 0x6c, 0x6f, 0x77, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x52, 0x0e, 0x6f, 0x76,
+	0x65, 0x72, 0x66, 0x6c, 0x6f, 0x77, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x1a, 0x70, 0x0a, 0x09,
+	0x48, 0x6f, 0x73, 0x74, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x4d, 0x0a, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x37, 0x2e, 0x73, 0x6c, 0x69,
+	0x6d, 0x65, 0x2e, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e,
+	0x6c, 0x61, 0x7a, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61,
+	0x31, 0x2e, 0x52, 0x65, 0x63, 0x79, 0x63, 0x6c, 0x69, 0x6e, 0x67, 0x53, 0x74, 0x72, 0x61, 0x74,
+	0x65, 0x67, 0x79, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xa1,
+	0x01, 0x0a, 0x08, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x58, 0x0a, 0x08, 0x73,
+	0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x3c, 0x2e,
+	0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x65, 0x72, 0x76, 0x69,
+	0x63, 0x65, 0x2e, 0x6c, 0x61, 0x7a, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x2e, 0x76, 0x31, 0x61, 0x6c,
+	0x70, 0x68, 0x61, 0x31, 0x2e, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x2e, 0x53, 0x65,
+	0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x08, 0x73, 0x65, 0x6c,
+	0x65, 0x63, 0x74, 0x6f, 0x72, 0x1a, 0x3b, 0x0a, 0x0d, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f,
+	0x72, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02,
+	0x38, 0x01, 0x22, 0xcb, 0x01, 0x0a, 0x10, 0x57, 0x6f, 0x72, 0x6b, 0x6c, 0x6f, 0x61, 0x64, 0x53,
+	0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x20, 0x0a, 0x0b, 0x66, 0x72, 0x6f, 0x6d, 0x53,
+	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x66, 0x72,
+	0x6f, 0x6d, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x5a, 0x0a, 0x06, 0x6c, 0x61, 0x62,
+	0x65, 0x6c, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x42, 0x2e, 0x73, 0x6c, 0x69, 0x6d,
 	0x65, 0x2e, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6c,
 	0x61, 0x7a, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31,
 	0x2e, 0x57, 0x6f, 0x72, 0x6b, 0x6c, 0x6f, 0x61, 0x64, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f,
-	0x72, 0x52, 0x10, 0x77, 0x6f, 0x72, 0x6b, 0x6c, 0x6f, 0x61, 0x64, 0x53, 0x65, 0x6c, 0x65, 0x63,
-	0x74, 0x6f, 0x72, 0x1a, 0x70, 0x0a, 0x09, 0x48, 0x6f, 0x73, 0x74, 0x45, 0x6e, 0x74, 0x72, 0x79,
-	0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b,
-	0x65, 0x79, 0x12, 0x4d, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x0b, 0x32, 0x37, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73,
-	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6c, 0x61, 0x7a, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x2e,
-	0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x52, 0x65, 0x63, 0x79, 0x63, 0x6c, 0x69,
-	0x6e, 0x67, 0x53, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75,
-	0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xa1, 0x01, 0x0a, 0x08, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74,
-	0x6f, 0x72, 0x12, 0x58, 0x0a, 0x08, 0x73, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x18, 0x01,
-	0x20, 0x03, 0x28, 0x0b, 0x32, 0x3c, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x6d, 0x69, 0x63,
-	0x72, 0x6f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6c, 0x61, 0x7a, 0x79, 0x6c, 0x6f,
-	0x61, 0x64, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x53, 0x65, 0x6c, 0x65,
-	0x63, 0x74, 0x6f, 0x72, 0x2e, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x45, 0x6e, 0x74,
-	0x72, 0x79, 0x52, 0x08, 0x73, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x1a, 0x3b, 0x0a, 0x0d,
-	0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a,
-	0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
-	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
-	0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xcb, 0x01, 0x0a, 0x10, 0x57, 0x6f,
-	0x72, 0x6b, 0x6c, 0x6f, 0x61, 0x64, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x20,
-	0x0a, 0x0b, 0x66, 0x72, 0x6f, 0x6d, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x08, 0x52, 0x0b, 0x66, 0x72, 0x6f, 0x6d, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65,
-	0x12, 0x5a, 0x0a, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b,
-	0x32, 0x42, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x65,
+	0x72, 0x2e, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x6c,
+	0x61, 0x62, 0x65, 0x6c, 0x73, 0x1a, 0x39, 0x0a, 0x0b, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01,
+	0x22, 0xa8, 0x04, 0x0a, 0x11, 0x52, 0x65, 0x63, 0x79, 0x63, 0x6c, 0x69, 0x6e, 0x67, 0x53, 0x74,
+	0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x12, 0x56, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x62, 0x6c, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x3e, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x6d,
+	0x69, 0x63, 0x72, 0x6f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6c, 0x61, 0x7a, 0x79,
+	0x6c, 0x6f, 0x61, 0x64, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x52, 0x65,
+	0x63, 0x79, 0x63, 0x6c, 0x69, 0x6e, 0x67, 0x53, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x2e,
+	0x53, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x52, 0x06, 0x73, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x5c,
+	0x0a, 0x08, 0x64, 0x65, 0x61, 0x64, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x40, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x65,
 	0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6c, 0x61, 0x7a, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x2e, 0x76,
-	0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x57, 0x6f, 0x72, 0x6b, 0x6c, 0x6f, 0x61, 0x64,
-	0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x2e, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x45,
-	0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x1a, 0x39, 0x0a, 0x0b,
-	0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b,
-	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a,
-	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61,
-	0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xa8, 0x04, 0x0a, 0x11, 0x52, 0x65, 0x63, 0x79,
-	0x63, 0x6c, 0x69, 0x6e, 0x67, 0x53, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x12, 0x56, 0x0a,
-	0x06, 0x73, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x3e, 0x2e,
+	0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x52, 0x65, 0x63, 0x79, 0x63, 0x6c, 0x69, 0x6e,
+	0x67, 0x53, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x2e, 0x44, 0x65, 0x61, 0x64, 0x6c, 0x69,
+	0x6e, 0x65, 0x52, 0x08, 0x64, 0x65, 0x61, 0x64, 0x6c, 0x69, 0x6e, 0x65, 0x12, 0x50, 0x0a, 0x04,
+	0x61, 0x75, 0x74, 0x6f, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x3c, 0x2e, 0x73, 0x6c, 0x69,
+	0x6d, 0x65, 0x2e, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e,
+	0x6c, 0x61, 0x7a, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61,
+	0x31, 0x2e, 0x52, 0x65, 0x63, 0x79, 0x63, 0x6c, 0x69, 0x6e, 0x67, 0x53, 0x74, 0x72, 0x61, 0x74,
+	0x65, 0x67, 0x79, 0x2e, 0x41, 0x75, 0x74, 0x6f, 0x52, 0x04, 0x61, 0x75, 0x74, 0x6f, 0x12, 0x57,
+	0x0a, 0x0e, 0x52, 0x65, 0x63, 0x65, 0x6e, 0x74, 0x6c, 0x79, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x64,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x2f, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x6d,
+	0x69, 0x63, 0x72, 0x6f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6c, 0x61, 0x7a, 0x79,
+	0x6c, 0x6f, 0x61, 0x64, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x54, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0e, 0x52, 0x65, 0x63, 0x65, 0x6e, 0x74, 0x6c,
+	0x79, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x64, 0x1a, 0x08, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x62, 0x6c,
+	0x65, 0x1a, 0x53, 0x0a, 0x08, 0x44, 0x65, 0x61, 0x64, 0x6c, 0x69, 0x6e, 0x65, 0x12, 0x47, 0x0a,
+	0x06, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x2f, 0x2e,
 	0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x65, 0x72, 0x76, 0x69,
 	0x63, 0x65, 0x2e, 0x6c, 0x61, 0x7a, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x2e, 0x76, 0x31, 0x61, 0x6c,
-	0x70, 0x68, 0x61, 0x31, 0x2e, 0x52, 0x65, 0x63, 0x79, 0x63, 0x6c, 0x69, 0x6e, 0x67, 0x53, 0x74,
-	0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x2e, 0x53, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x52, 0x06, 0x73,
-	0x74, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x5c, 0x0a, 0x08, 0x64, 0x65, 0x61, 0x64, 0x6c, 0x69, 0x6e,
-	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x40, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e,
-	0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6c, 0x61, 0x7a,
-	0x79, 0x6c, 0x6f, 0x61, 0x64, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x52,
-	0x65, 0x63, 0x79, 0x63, 0x6c, 0x69, 0x6e, 0x67, 0x53, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79,
-	0x2e, 0x44, 0x65, 0x61, 0x64, 0x6c, 0x69, 0x6e, 0x65, 0x52, 0x08, 0x64, 0x65, 0x61, 0x64, 0x6c,
-	0x69, 0x6e, 0x65, 0x12, 0x50, 0x0a, 0x04, 0x61, 0x75, 0x74, 0x6f, 0x18, 0x03, 0x20, 0x01, 0x28,
-	0x0b, 0x32, 0x3c, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73,
-	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6c, 0x61, 0x7a, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x2e,
-	0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x52, 0x65, 0x63, 0x79, 0x63, 0x6c, 0x69,
-	0x6e, 0x67, 0x53, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x2e, 0x41, 0x75, 0x74, 0x6f, 0x52,
-	0x04, 0x61, 0x75, 0x74, 0x6f, 0x12, 0x57, 0x0a, 0x0e, 0x52, 0x65, 0x63, 0x65, 0x6e, 0x74, 0x6c,
-	0x79, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x2f, 0x2e,
-	0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x65, 0x72, 0x76, 0x69,
-	0x63, 0x65, 0x2e, 0x6c, 0x61, 0x7a, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x2e, 0x76, 0x31, 0x61, 0x6c,
-	0x70, 0x68, 0x61, 0x31, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0e,
-	0x52, 0x65, 0x63, 0x65, 0x6e, 0x74, 0x6c, 0x79, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x64, 0x1a, 0x08,
-	0x0a, 0x06, 0x53, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x1a, 0x53, 0x0a, 0x08, 0x44, 0x65, 0x61, 0x64,
-	0x6c, 0x69, 0x6e, 0x65, 0x12, 0x47, 0x0a, 0x06, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x18, 0x01,
+	0x70, 0x68, 0x61, 0x31, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x06,
+	0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x1a, 0x53, 0x0a, 0x04, 0x41, 0x75, 0x74, 0x6f, 0x12, 0x4b,
+	0x0a, 0x08, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x2f, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6c, 0x61, 0x7a, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x2e, 0x76,
+	0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x52, 0x08, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x82, 0x02, 0x0a, 0x0c,
+	0x44, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x57, 0x0a, 0x0e,
+	0x52, 0x65, 0x63, 0x65, 0x6e, 0x74, 0x6c, 0x79, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x64, 0x18, 0x01,
 	0x20, 0x01, 0x28, 0x0b, 0x32, 0x2f, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x6d, 0x69, 0x63,
 	0x72, 0x6f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6c, 0x61, 0x7a, 0x79, 0x6c, 0x6f,
 	0x61, 0x64, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x54, 0x69, 0x6d, 0x65,
-	0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x06, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x1a, 0x53, 0x0a,
-	0x04, 0x41, 0x75, 0x74, 0x6f, 0x12, 0x4b, 0x0a, 0x08, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f,
-	0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x2f, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e,
-	0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6c, 0x61, 0x7a,
-	0x79, 0x6c, 0x6f, 0x61, 0x64, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x54,
-	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x08, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69,
-	0x6f, 0x6e, 0x22, 0x82, 0x02, 0x0a, 0x0c, 0x44, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69,
-	0x6f, 0x6e, 0x73, 0x12, 0x57, 0x0a, 0x0e, 0x52, 0x65, 0x63, 0x65, 0x6e, 0x74, 0x6c, 0x79, 0x43,
-	0x61, 0x6c, 0x6c, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x2f, 0x2e, 0x73, 0x6c,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0e, 0x52, 0x65, 0x63, 0x65, 0x6e, 0x74, 0x6c, 0x79, 0x43,
+	0x61, 0x6c, 0x6c, 0x65, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x68, 0x6f, 0x73, 0x74, 0x73, 0x18, 0x02,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x68, 0x6f, 0x73, 0x74, 0x73, 0x12, 0x51, 0x0a, 0x06, 0x73,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x39, 0x2e, 0x73, 0x6c,
 	0x69, 0x6d, 0x65, 0x2e, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65,
 	0x2e, 0x6c, 0x61, 0x7a, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68,
-	0x61, 0x31, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0e, 0x52, 0x65,
-	0x63, 0x65, 0x6e, 0x74, 0x6c, 0x79, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x64, 0x12, 0x14, 0x0a, 0x05,
-	0x68, 0x6f, 0x73, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x68, 0x6f, 0x73,
-	0x74, 0x73, 0x12, 0x51, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x03, 0x20, 0x01,
-	0x28, 0x0e, 0x32, 0x39, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x6d, 0x69, 0x63, 0x72, 0x6f,
-	0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6c, 0x61, 0x7a, 0x79, 0x6c, 0x6f, 0x61, 0x64,
-	0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x44, 0x65, 0x73, 0x74, 0x69, 0x6e,
-	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73,
-	0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x30, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12,
-	0x0a, 0x0a, 0x06, 0x41, 0x43, 0x54, 0x49, 0x56, 0x45, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x45,
-	0x58, 0x50, 0x49, 0x52, 0x45, 0x10, 0x01, 0x12, 0x0e, 0x0a, 0x0a, 0x45, 0x58, 0x50, 0x49, 0x52,
-	0x45, 0x57, 0x41, 0x49, 0x54, 0x10, 0x02, 0x22, 0xb3, 0x04, 0x0a, 0x12, 0x53, 0x65, 0x72, 0x76,
-	0x69, 0x63, 0x65, 0x46, 0x65, 0x6e, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x5f,
-	0x0a, 0x07, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
-	0x45, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x65, 0x72,
-	0x76, 0x69, 0x63, 0x65, 0x2e, 0x6c, 0x61, 0x7a, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x2e, 0x76, 0x31,
-	0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x46, 0x65,
-	0x6e, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x2e, 0x44, 0x6f, 0x6d, 0x61, 0x69, 0x6e,
-	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x73, 0x12,
-	0x6e, 0x0a, 0x0c, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18,
-	0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x4a, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x6d, 0x69,
-	0x63, 0x72, 0x6f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6c, 0x61, 0x7a, 0x79, 0x6c,
-	0x6f, 0x61, 0x64, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x53, 0x65, 0x72,
-	0x76, 0x69, 0x63, 0x65, 0x46, 0x65, 0x6e, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x2e,
-	0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x45, 0x6e, 0x74, 0x72,
-	0x79, 0x52, 0x0c, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12,
-	0x5f, 0x0a, 0x07, 0x76, 0x69, 0x73, 0x69, 0x74, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b,
-	0x32, 0x45, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x65,
-	0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6c, 0x61, 0x7a, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x2e, 0x76,
-	0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x46,
-	0x65, 0x6e, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x2e, 0x56, 0x69, 0x73, 0x69, 0x74,
-	0x6f, 0x72, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x76, 0x69, 0x73, 0x69, 0x74, 0x6f, 0x72,
+	0x61, 0x31, 0x2e, 0x44, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x30,
+	0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x0a, 0x0a, 0x06, 0x41, 0x43, 0x54, 0x49,
+	0x56, 0x45, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x45, 0x58, 0x50, 0x49, 0x52, 0x45, 0x10, 0x01,
+	0x12, 0x0e, 0x0a, 0x0a, 0x45, 0x58, 0x50, 0x49, 0x52, 0x45, 0x57, 0x41, 0x49, 0x54, 0x10, 0x02,
+	0x22, 0xcc, 0x01, 0x0a, 0x10, 0x48, 0x6f, 0x73, 0x74, 0x42, 0x75, 0x64, 0x67, 0x65, 0x74, 0x53,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x22, 0x0a, 0x0c, 0x6c, 0x65, 0x61, 0x72, 0x6e, 0x65, 0x64,
+	0x48, 0x6f, 0x73, 0x74, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0c, 0x6c, 0x65, 0x61,
+	0x72, 0x6e, 0x65, 0x64, 0x48, 0x6f, 0x73, 0x74, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x6f, 0x76, 0x65,
+	0x72, 0x66, 0x6c, 0x6f, 0x77, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x6f, 0x76, 0x65,
+	0x72, 0x66, 0x6c, 0x6f, 0x77, 0x12, 0x22, 0x0a, 0x0c, 0x65, 0x76, 0x69, 0x63, 0x74, 0x65, 0x64,
+	0x48, 0x6f, 0x73, 0x74, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0c, 0x65, 0x76, 0x69,
+	0x63, 0x74, 0x65, 0x64, 0x48, 0x6f, 0x73, 0x74, 0x73, 0x12, 0x2e, 0x0a, 0x12, 0x77, 0x69, 0x6c,
+	0x64, 0x63, 0x61, 0x72, 0x64, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x73, 0x18,
+	0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x12, 0x77, 0x69, 0x6c, 0x64, 0x63, 0x61, 0x72, 0x64, 0x4e,
+	0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x73, 0x12, 0x24, 0x0a, 0x0d, 0x66, 0x65, 0x6e,
+	0x63, 0x65, 0x44, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x0d, 0x66, 0x65, 0x6e, 0x63, 0x65, 0x44, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x22,
+	0x8b, 0x05, 0x0a, 0x12, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x46, 0x65, 0x6e, 0x63, 0x65,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x5f, 0x0a, 0x07, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e,
+	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x45, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e,
+	0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6c, 0x61, 0x7a,
+	0x79, 0x6c, 0x6f, 0x61, 0x64, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x53,
+	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x46, 0x65, 0x6e, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x2e, 0x44, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07,
+	0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x73, 0x12, 0x6e, 0x0a, 0x0c, 0x6d, 0x65, 0x74, 0x72, 0x69,
+	0x63, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x4a, 0x2e,
+	0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x65, 0x72, 0x76, 0x69,
+	0x63, 0x65, 0x2e, 0x6c, 0x61, 0x7a, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x2e, 0x76, 0x31, 0x61, 0x6c,
+	0x70, 0x68, 0x61, 0x31, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x46, 0x65, 0x6e, 0x63,
+	0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0c, 0x6d, 0x65, 0x74, 0x72, 0x69,
+	0x63, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x5f, 0x0a, 0x07, 0x76, 0x69, 0x73, 0x69, 0x74,
+	0x6f, 0x72, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x45, 0x2e, 0x73, 0x6c, 0x69, 0x6d, 0x65,
+	0x2e, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6c, 0x61,
+	0x7a, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e,
+	0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x46, 0x65, 0x6e, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x2e, 0x56, 0x69, 0x73, 0x69, 0x74, 0x6f, 0x72, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52,
+	0x07, 0x76, 0x69, 0x73, 0x69, 0x74, 0x6f, 0x72, 0x12, 0x56, 0x0a, 0x0a, 0x68, 0x6f, 0x73, 0x74,
+	0x42, 0x75, 0x64, 0x67, 0x65, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x36, 0x2e, 0x73,
+	0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63,
+	0x65, 0x2e, 0x6c, 0x61, 0x7a, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70,
+	0x68, 0x61, 0x31, 0x2e, 0x48, 0x6f, 0x73, 0x74, 0x42, 0x75, 0x64, 0x67, 0x65, 0x74, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x52, 0x0a, 0x68, 0x6f, 0x73, 0x74, 0x42, 0x75, 0x64, 0x67, 0x65, 0x74,
 	0x1a, 0x6e, 0x0a, 0x0c, 0x44, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79,
 	0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b,
 	0x65, 0x79, 0x12, 0x48, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
@@ -838,11 +1059,17 @@ var file_service_fence_proto_rawDesc = []byte{
 	0x01, 0x1a, 0x3a, 0x0a, 0x0c, 0x56, 0x69, 0x73, 0x69, 0x74, 0x6f, 0x72, 0x45, 0x6e, 0x74, 0x72,
 	0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
 	0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x08, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x42, 0x2e, 0x5a,
-	0x2c, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x69, 0x6f, 0x2f, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2f,
-	0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x65, 0x73, 0x2f, 0x6c, 0x61, 0x7a, 0x79, 0x6c, 0x6f, 0x61, 0x64,
-	0x2f, 0x61, 0x70, 0x69, 0x2f, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x62, 0x06, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x28, 0x08, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x2a, 0x5c, 0x0a,
+	0x0e, 0x4f, 0x76, 0x65, 0x72, 0x66, 0x6c, 0x6f, 0x77, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12,
+	0x16, 0x0a, 0x12, 0x4e, 0x41, 0x4d, 0x45, 0x53, 0x50, 0x41, 0x43, 0x45, 0x5f, 0x57, 0x49, 0x4c,
+	0x44, 0x43, 0x41, 0x52, 0x44, 0x10, 0x00, 0x12, 0x1f, 0x0a, 0x1b, 0x45, 0x56, 0x49, 0x43, 0x54,
+	0x5f, 0x4c, 0x45, 0x41, 0x53, 0x54, 0x5f, 0x52, 0x45, 0x43, 0x45, 0x4e, 0x54, 0x4c, 0x59, 0x5f,
+	0x43, 0x41, 0x4c, 0x4c, 0x45, 0x44, 0x10, 0x01, 0x12, 0x11, 0x0a, 0x0d, 0x44, 0x49, 0x53, 0x41,
+	0x42, 0x4c, 0x45, 0x5f, 0x46, 0x45, 0x4e, 0x43, 0x45, 0x10, 0x02, 0x42, 0x2e, 0x5a, 0x2c, 0x73,
+	0x6c, 0x69, 0x6d, 0x65, 0x2e, 0x69, 0x6f, 0x2f, 0x73, 0x6c, 0x69, 0x6d, 0x65, 0x2f, 0x6d, 0x6f,
+	0x64, 0x75, 0x6c, 0x65, 0x73, 0x2f, 0x6c, 0x61, 0x7a, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x2f, 0x61,
+	0x70, 0x69, 0x2f, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
 }
 
 var (
@@ -857,51 +1084,57 @@ func file_service_fence_proto_rawDescGZIP() []byte {
 	return file_service_fence_proto_rawDescData
 }
 
-var file_service_fence_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_service_fence_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_service_fence_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_service_fence_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
 var file_service_fence_proto_goTypes = []interface{}{
-	(Destinations_Status)(0),           // 0: slime.microservice.lazyload.v1alpha1.Destinations.Status
-	(*Timestamp)(nil),                  // 1: slime.microservice.lazyload.v1alpha1.Timestamp
-	(*ServiceFenceSpec)(nil),           // 2: slime.microservice.lazyload.v1alpha1.ServiceFenceSpec
-	(*Selector)(nil),                   // 3: slime.microservice.lazyload.v1alpha1.Selector
-	(*WorkloadSelector)(nil),           // 4: slime.microservice.lazyload.v1alpha1.WorkloadSelector
-	(*RecyclingStrategy)(nil),          // 5: slime.microservice.lazyload.v1alpha1.RecyclingStrategy
-	(*Destinations)(nil),               // 6: slime.microservice.lazyload.v1alpha1.Destinations
-	(*ServiceFenceStatus)(nil),         // 7: slime.microservice.lazyload.v1alpha1.ServiceFenceStatus
-	nil,                                // 8: slime.microservice.lazyload.v1alpha1.ServiceFenceSpec.HostEntry
-	nil,                                // 9: slime.microservice.lazyload.v1alpha1.Selector.SelectorEntry
-	nil,                                // 10: slime.microservice.lazyload.v1alpha1.WorkloadSelector.LabelsEntry
-	(*RecyclingStrategy_Stable)(nil),   // 11: slime.microservice.lazyload.v1alpha1.RecyclingStrategy.Stable
-	(*RecyclingStrategy_Deadline)(nil), // 12: slime.microservice.lazyload.v1alpha1.RecyclingStrategy.Deadline
-	(*RecyclingStrategy_Auto)(nil),     // 13: slime.microservice.lazyload.v1alpha1.RecyclingStrategy.Auto
-	nil,                                // 14: slime.microservice.lazyload.v1alpha1.ServiceFenceStatus.DomainsEntry
-	nil,                                // 15: slime.microservice.lazyload.v1alpha1.ServiceFenceStatus.MetricStatusEntry
-	nil,                                // 16: slime.microservice.lazyload.v1alpha1.ServiceFenceStatus.VisitorEntry
+	(OverflowPolicy)(0),                // 0: slime.microservice.lazyload.v1alpha1.OverflowPolicy
+	(Destinations_Status)(0),           // 1: slime.microservice.lazyload.v1alpha1.Destinations.Status
+	(*Timestamp)(nil),                  // 2: slime.microservice.lazyload.v1alpha1.Timestamp
+	(*ServiceFenceSpec)(nil),           // 3: slime.microservice.lazyload.v1alpha1.ServiceFenceSpec
+	(*Selector)(nil),                   // 4: slime.microservice.lazyload.v1alpha1.Selector
+	(*WorkloadSelector)(nil),           // 5: slime.microservice.lazyload.v1alpha1.WorkloadSelector
+	(*RecyclingStrategy)(nil),          // 6: slime.microservice.lazyload.v1alpha1.RecyclingStrategy
+	(*Destinations)(nil),               // 7: slime.microservice.lazyload.v1alpha1.Destinations
+	(*HostBudgetStatus)(nil),           // 8: slime.microservice.lazyload.v1alpha1.HostBudgetStatus
+	(*ServiceFenceStatus)(nil),         // 9: slime.microservice.lazyload.v1alpha1.ServiceFenceStatus
+	nil,                                // 10: slime.microservice.lazyload.v1alpha1.ServiceFenceSpec.HostEntry
+	nil,                                // 11: slime.microservice.lazyload.v1alpha1.Selector.SelectorEntry
+	nil,                                // 12: slime.microservice.lazyload.v1alpha1.WorkloadSelector.LabelsEntry
+	(*RecyclingStrategy_Stable)(nil),   // 13: slime.microservice.lazyload.v1alpha1.RecyclingStrategy.Stable
+	(*RecyclingStrategy_Deadline)(nil), // 14: slime.microservice.lazyload.v1alpha1.RecyclingStrategy.Deadline
+	(*RecyclingStrategy_Auto)(nil),     // 15: slime.microservice.lazyload.v1alpha1.RecyclingStrategy.Auto
+	nil,                                // 16: slime.microservice.lazyload.v1alpha1.ServiceFenceStatus.DomainsEntry
+	nil,                                // 17: slime.microservice.lazyload.v1alpha1.ServiceFenceStatus.MetricStatusEntry
+	nil,                                // 18: slime.microservice.lazyload.v1alpha1.ServiceFenceStatus.VisitorEntry
+	(*v1alpha3.Sidecar)(nil),           // 19: istio.networking.v1alpha3.Sidecar
 }
 var file_service_fence_proto_depIdxs = []int32{
-	8,  // 0: slime.microservice.lazyload.v1alpha1.ServiceFenceSpec.host:type_name -> slime.microservice.lazyload.v1alpha1.ServiceFenceSpec.HostEntry
-	3,  // 1: slime.microservice.lazyload.v1alpha1.ServiceFenceSpec.labelSelector:type_name -> slime.microservice.lazyload.v1alpha1.Selector
-	4,  // 2: slime.microservice.lazyload.v1alpha1.ServiceFenceSpec.workloadSelector:type_name -> slime.microservice.lazyload.v1alpha1.WorkloadSelector
-	9,  // 3: slime.microservice.lazyload.v1alpha1.Selector.selector:type_name -> slime.microservice.lazyload.v1alpha1.Selector.SelectorEntry
-	10, // 4: slime.microservice.lazyload.v1alpha1.WorkloadSelector.labels:type_name -> slime.microservice.lazyload.v1alpha1.WorkloadSelector.LabelsEntry
-	11, // 5: slime.microservice.lazyload.v1alpha1.RecyclingStrategy.stable:type_name -> slime.microservice.lazyload.v1alpha1.RecyclingStrategy.Stable
-	12, // 6: slime.microservice.lazyload.v1alpha1.RecyclingStrategy.deadline:type_name -> slime.microservice.lazyload.v1alpha1.RecyclingStrategy.Deadline
-	13, // 7: slime.microservice.lazyload.v1alpha1.RecyclingStrategy.auto:type_name -> slime.microservice.lazyload.v1alpha1.RecyclingStrategy.Auto
-	1,  // 8: slime.microservice.lazyload.v1alpha1.RecyclingStrategy.RecentlyCalled:type_name -> slime.microservice.lazyload.v1alpha1.Timestamp
-	1,  // 9: slime.microservice.lazyload.v1alpha1.Destinations.RecentlyCalled:type_name -> slime.microservice.lazyload.v1alpha1.Timestamp
-	0,  // 10: slime.microservice.lazyload.v1alpha1.Destinations.status:type_name -> slime.microservice.lazyload.v1alpha1.Destinations.Status
-	14, // 11: slime.microservice.lazyload.v1alpha1.ServiceFenceStatus.domains:type_name -> slime.microservice.lazyload.v1alpha1.ServiceFenceStatus.DomainsEntry
-	15, // 12: slime.microservice.lazyload.v1alpha1.ServiceFenceStatus.metricStatus:type_name -> slime.microservice.lazyload.v1alpha1.ServiceFenceStatus.MetricStatusEntry
-	16, // 13: slime.microservice.lazyload.v1alpha1.ServiceFenceStatus.visitor:type_name -> slime.microservice.lazyload.v1alpha1.ServiceFenceStatus.VisitorEntry
-	5,  // 14: slime.microservice.lazyload.v1alpha1.ServiceFenceSpec.HostEntry.value:type_name -> slime.microservice.lazyload.v1alpha1.RecyclingStrategy
-	1,  // 15: slime.microservice.lazyload.v1alpha1.RecyclingStrategy.Deadline.expire:type_name -> slime.microservice.lazyload.v1alpha1.Timestamp
-	1,  // 16: slime.microservice.lazyload.v1alpha1.RecyclingStrategy.Auto.duration:type_name -> slime.microservice.lazyload.v1alpha1.Timestamp
-	6,  // 17: slime.microservice.lazyload.v1alpha1.ServiceFenceStatus.DomainsEntry.value:type_name -> slime.microservice.lazyload.v1alpha1.Destinations
-	18, // [18:18] is the sub-list for method output_type
-	18, // [18:18] is the sub-list for method input_type
-	18, // [18:18] is the sub-list for extension type_name
-	18, // [18:18] is the sub-list for extension extendee
-	0,  // [0:18] is the sub-list for field type_name
+	10, // 0: slime.microservice.lazyload.v1alpha1.ServiceFenceSpec.host:type_name -> slime.microservice.lazyload.v1alpha1.ServiceFenceSpec.HostEntry
+	4,  // 1: slime.microservice.lazyload.v1alpha1.ServiceFenceSpec.labelSelector:type_name -> slime.microservice.lazyload.v1alpha1.Selector
+	5,  // 2: slime.microservice.lazyload.v1alpha1.ServiceFenceSpec.workloadSelector:type_name -> slime.microservice.lazyload.v1alpha1.WorkloadSelector
+	19, // 3: slime.microservice.lazyload.v1alpha1.ServiceFenceSpec.sidecarTemplate:type_name -> istio.networking.v1alpha3.Sidecar
+	0,  // 4: slime.microservice.lazyload.v1alpha1.ServiceFenceSpec.overflowPolicy:type_name -> slime.microservice.lazyload.v1alpha1.OverflowPolicy
+	11, // 5: slime.microservice.lazyload.v1alpha1.Selector.selector:type_name -> slime.microservice.lazyload.v1alpha1.Selector.SelectorEntry
+	12, // 6: slime.microservice.lazyload.v1alpha1.WorkloadSelector.labels:type_name -> slime.microservice.lazyload.v1alpha1.WorkloadSelector.LabelsEntry
+	13, // 7: slime.microservice.lazyload.v1alpha1.RecyclingStrategy.stable:type_name -> slime.microservice.lazyload.v1alpha1.RecyclingStrategy.Stable
+	14, // 8: slime.microservice.lazyload.v1alpha1.RecyclingStrategy.deadline:type_name -> slime.microservice.lazyload.v1alpha1.RecyclingStrategy.Deadline
+	15, // 9: slime.microservice.lazyload.v1alpha1.RecyclingStrategy.auto:type_name -> slime.microservice.lazyload.v1alpha1.RecyclingStrategy.Auto
+	2,  // 10: slime.microservice.lazyload.v1alpha1.RecyclingStrategy.RecentlyCalled:type_name -> slime.microservice.lazyload.v1alpha1.Timestamp
+	2,  // 11: slime.microservice.lazyload.v1alpha1.Destinations.RecentlyCalled:type_name -> slime.microservice.lazyload.v1alpha1.Timestamp
+	1,  // 12: slime.microservice.lazyload.v1alpha1.Destinations.status:type_name -> slime.microservice.lazyload.v1alpha1.Destinations.Status
+	16, // 13: slime.microservice.lazyload.v1alpha1.ServiceFenceStatus.domains:type_name -> slime.microservice.lazyload.v1alpha1.ServiceFenceStatus.DomainsEntry
+	17, // 14: slime.microservice.lazyload.v1alpha1.ServiceFenceStatus.metricStatus:type_name -> slime.microservice.lazyload.v1alpha1.ServiceFenceStatus.MetricStatusEntry
+	18, // 15: slime.microservice.lazyload.v1alpha1.ServiceFenceStatus.visitor:type_name -> slime.microservice.lazyload.v1alpha1.ServiceFenceStatus.VisitorEntry
+	8,  // 16: slime.microservice.lazyload.v1alpha1.ServiceFenceStatus.hostBudget:type_name -> slime.microservice.lazyload.v1alpha1.HostBudgetStatus
+	6,  // 17: slime.microservice.lazyload.v1alpha1.ServiceFenceSpec.HostEntry.value:type_name -> slime.microservice.lazyload.v1alpha1.RecyclingStrategy
+	2,  // 18: slime.microservice.lazyload.v1alpha1.RecyclingStrategy.Deadline.expire:type_name -> slime.microservice.lazyload.v1alpha1.Timestamp
+	2,  // 19: slime.microservice.lazyload.v1alpha1.RecyclingStrategy.Auto.duration:type_name -> slime.microservice.lazyload.v1alpha1.Timestamp
+	7,  // 20: slime.microservice.lazyload.v1alpha1.ServiceFenceStatus.DomainsEntry.value:type_name -> slime.microservice.lazyload.v1alpha1.Destinations
+	21, // [21:21] is the sub-list for method output_type
+	21, // [21:21] is the sub-list for method input_type
+	21, // [21:21] is the sub-list for extension type_name
+	21, // [21:21] is the sub-list for extension extendee
+	0,  // [0:21] is the sub-list for field type_name
 }
 
 func init() { file_service_fence_proto_init() }
@@ -983,6 +1216,18 @@ func file_service_fence_proto_init() {
 			}
 		}
 		file_service_fence_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HostBudgetStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_service_fence_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*ServiceFenceStatus); i {
 			case 0:
 				return &v.state
@@ -994,7 +1239,7 @@ func file_service_fence_proto_init() {
 				return nil
 			}
 		}
-		file_service_fence_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+		file_service_fence_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*RecyclingStrategy_Stable); i {
 			case 0:
 				return &v.state
@@ -1006,7 +1251,7 @@ func file_service_fence_proto_init() {
 				return nil
 			}
 		}
-		file_service_fence_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+		file_service_fence_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*RecyclingStrategy_Deadline); i {
 			case 0:
 				return &v.state
@@ -1018,7 +1263,7 @@ func file_service_fence_proto_init() {
 				return nil
 			}
 		}
-		file_service_fence_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+		file_service_fence_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*RecyclingStrategy_Auto); i {
 			case 0:
 				return &v.state
@@ -1036,8 +1281,8 @@ func file_service_fence_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_service_fence_proto_rawDesc,
-			NumEnums:      1,
-			NumMessages:   16,
+			NumEnums:      2,
+			NumMessages:   17,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -194,6 +194,27 @@ func (in *Destinations) DeepCopyInterface() interface{} {
 	return in.DeepCopy()
 }
 
+// DeepCopyInto supports using HostBudgetStatus within kubernetes types, where deepcopy-gen is used.
+func (in *HostBudgetStatus) DeepCopyInto(out *HostBudgetStatus) {
+	p := proto.Clone(in).(*HostBudgetStatus)
+	*out = *p
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostBudgetStatus. Required by controller-gen.
+func (in *HostBudgetStatus) DeepCopy() *HostBudgetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HostBudgetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInterface is an autogenerated deepcopy function, copying the receiver, creating a new HostBudgetStatus. Required by controller-gen.
+func (in *HostBudgetStatus) DeepCopyInterface() interface{} {
+	return in.DeepCopy()
+}
+
 // DeepCopyInto supports using ServiceFenceStatus within kubernetes types, where deepcopy-gen is used.
 func (in *ServiceFenceStatus) DeepCopyInto(out *ServiceFenceStatus) {
 	p := proto.Clone(in).(*ServiceFenceStatus)
@@ -105,6 +105,17 @@ func (this *Destinations) UnmarshalJSON(b []byte) error {
 	return ServiceFenceUnmarshaler.Unmarshal(bytes.NewReader(b), this)
 }
 
+// MarshalJSON is a custom marshaler for HostBudgetStatus
+func (this *HostBudgetStatus) MarshalJSON() ([]byte, error) {
+	str, err := ServiceFenceMarshaler.MarshalToString(this)
+	return []byte(str), err
+}
+
+// UnmarshalJSON is a custom unmarshaler for HostBudgetStatus
+func (this *HostBudgetStatus) UnmarshalJSON(b []byte) error {
+	return ServiceFenceUnmarshaler.Unmarshal(bytes.NewReader(b), this)
+}
+
 // MarshalJSON is a custom marshaler for ServiceFenceStatus
 func (this *ServiceFenceStatus) MarshalJSON() ([]byte, error) {
 	str, err := ServiceFenceMarshaler.MarshalToString(this)
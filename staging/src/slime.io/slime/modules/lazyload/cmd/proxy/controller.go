@@ -81,7 +81,7 @@ func (c *controller) updatePorts(key string) error {
 		return fmt.Errorf("Fetching object with key %s from store failed with: %v", key, err)
 	}
 
-	var wormholePorts map[int]struct{}
+	var wormholePorts, tcpWormholePorts map[int]struct{}
 	if !exists {
 		log.Infof("Configmap %s does not exist anymore", key)
 	} else {
@@ -90,9 +90,10 @@ func (c *controller) updatePorts(key string) error {
 		if err != nil {
 			return fmt.Errorf("Convert to configmap failed with: %v", err)
 		}
-		wormholePorts = extractWormholePorts(cm.Data[configDataName])
+		wormholePorts, tcpWormholePorts = extractWormholePorts(cm.Data[configDataName])
 	}
 	startListenAndServe(wormholePorts)
+	startTcpListenAndServe(tcpWormholePorts, wormholePorts)
 	return nil
 }
 
@@ -111,19 +112,25 @@ func convertToConfigMap(obj interface{}) (*corev1.ConfigMap, error) {
 	return cm, nil
 }
 
-func extractWormholePorts(rawCfg string) map[int]struct{} {
+// extractWormholePorts returns the http and tcp wormhole ports
+func extractWormholePorts(rawCfg string) (map[int]struct{}, map[int]struct{}) {
 	log.Debugf("ExtractWormholePorts with rawCfg: %q", rawCfg)
 	ctr := struct {
-		WormholePorts []int
+		WormholePorts    []int
+		TcpWormholePorts []int
 	}{}
 	err := yaml.Unmarshal([]byte(rawCfg), &ctr)
 	if err != nil {
 		log.Warnf("Unmarshal %s falied: %v", rawCfg, err)
-		return nil
+		return nil, nil
 	}
 	ret := map[int]struct{}{}
 	for _, port := range ctr.WormholePorts {
 		ret[port] = struct{}{}
 	}
-	return ret
+	tcpRet := map[int]struct{}{}
+	for _, port := range ctr.TcpWormholePorts {
+		tcpRet[port] = struct{}{}
+	}
+	return ret, tcpRet
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"net"
 	"net/http"
 	"os"
@@ -21,6 +22,7 @@ import (
 	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
 
+	"slime.io/slime/framework/monitoring"
 	"slime.io/slime/modules/lazyload/pkg/proxy"
 )
 
@@ -31,11 +33,13 @@ const (
 	EnvDisableSvcController        = "DISABLE_SVC_CONTROLLER"
 	EnvWormHolePortPriorToHostPort = "WORMHOLE_PORT_PRIOR_TO_HOST_PORT"
 	EnvCleanupWormholePort         = "CLEAN_UP_WORMHOLE_PORT"
+	EnvRecentFallbacks             = "RECENT_FALLBACKS"
 )
 
 var (
 	serverMutex sync.RWMutex
 	servers     = map[int]*http.Server{}
+	tcpServers  = map[int]*proxy.TcpProxy{}
 
 	probePort = os.Getenv(EnvProbePort)
 	logLevel  = os.Getenv(EnvLogLevel)
@@ -47,6 +51,8 @@ var (
 	configLabelSelector = "lazyload.slime.io/config=global-sidecar"
 
 	Cache *proxy.Cache
+
+	recorder *proxy.FallbackRecorder
 )
 
 func init() {
@@ -72,9 +78,15 @@ func main() {
 	})
 
 	if probePort != "" {
-		// start health check server
+		recentFallbacks, _ := strconv.Atoi(os.Getenv(EnvRecentFallbacks))
+		recorder = proxy.NewFallbackRecorder(recentFallbacks)
+		handler, err := newProbeHandler()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// start health check server, which also serves the metrics and the recent fallbacks
 		go func() {
-			handler := &proxy.HealthzProxy{}
 			log.Println("Starting health check on", ":"+probePort)
 			if err := http.ListenAndServe(":"+probePort, handler); err != nil {
 				log.Fatal("ListenAndServe:", err)
@@ -99,6 +111,18 @@ func main() {
 	stopListenAndServe()
 }
 
+func newProbeHandler() (http.Handler, error) {
+	exporter, err := monitoring.NewExporter()
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exporter)
+	mux.Handle("/debug/fallbacks", recorder)
+	mux.Handle("/", &proxy.HealthzProxy{})
+	return mux, nil
+}
+
 func newConfigMapController() (*controller, error) {
 	config, err := rest.InClusterConfig()
 	if err != nil {
@@ -149,6 +173,10 @@ func stopListenAndServe() {
 			wg.Done()
 		}(srv)
 	}
+	for port, srv := range tcpServers {
+		log.Infof("Stopping tcp proxy on: %d", port)
+		_ = srv.Close()
+	}
 	wg.Wait()
 	log.Infof("Shutdown all proxy server")
 }
@@ -176,6 +204,7 @@ func startListenAndServe(wormholePorts map[int]struct{}) {
 					WormholePortPriorToHostPort: wormHolePortPriorToHostPort,
 					WormholePort:                whPort,
 					SvcCache:                    Cache,
+					Recorder:                    recorder,
 				},
 			}
 			servers[whPort] = srv
@@ -196,8 +225,56 @@ func startListenAndServe(wormholePorts map[int]struct{}) {
 	}
 }
 
-func startServer(srv *http.Server) {
-	log.Infof("Starting proxy on: %s", srv.Addr)
+// startTcpListenAndServe starts the tcp proxies on the tcp wormhole ports, the ones conflict with the
+// probe port or the http wormhole ports are skipped.
+func startTcpListenAndServe(tcpWormholePorts, wormholePorts map[int]struct{}) {
+	serverMutex.Lock()
+	defer serverMutex.Unlock()
+	log.Infof("Starting listen and serve with tcpWormholePorts: %v", tcpWormholePorts)
+	for whPort := range tcpWormholePorts {
+		if strconv.Itoa(whPort) == probePort {
+			log.Warnf("ProbePort is conflict with tcpWormholePort %v, skip", whPort)
+			continue
+		}
+		if _, ok := wormholePorts[whPort]; ok || whPort == 80 {
+			log.Warnf("WormholePort is conflict with tcpWormholePort %v, skip", whPort)
+			continue
+		}
+		if _, exist := tcpServers[whPort]; !exist {
+			srv := &proxy.TcpProxy{
+				WormholePort: whPort,
+				SvcCache:     Cache,
+				Recorder:     recorder,
+			}
+			tcpServers[whPort] = srv
+			go startTcpServer(srv, whPort)
+		}
+	}
+	if cleanupWormholePort {
+		for whPort, srv := range tcpServers {
+			if _, exist := tcpWormholePorts[whPort]; !exist {
+				log.Infof("remove tcp wormhole port %d", whPort)
+				delete(tcpServers, whPort)
+				_ = srv.Close()
+			}
+		}
+	}
+}
+
+func startTcpServer(srv *proxy.TcpProxy, port int) {
+	addr := "0.0.0.0" + ":" + strconv.Itoa(port)
+	log.Infof("Starting tcp proxy on: %s", addr)
+	l, err := listen(addr)
+	if err != nil {
+		log.Warn("Tcp proxy Listen error:", err)
+		return
+	}
+	if err := srv.Serve(l); err != nil && !errors.Is(err, net.ErrClosed) {
+		log.Warn("Tcp proxy Serve error:", err)
+	}
+}
+
+func listen(addr string) (net.Listener, error) {
 	lc := net.ListenConfig{
 		Control: func(network, address string, c syscall.RawConn) error {
 			return c.Control(func(fd uintptr) {
@@ -206,7 +283,12 @@ func startServer(srv *http.Server) {
 			})
 		},
 	}
-	l, err := lc.Listen(context.Background(), "tcp", srv.Addr)
+	return lc.Listen(context.Background(), "tcp", addr)
+}
+
+func startServer(srv *http.Server) {
+	log.Infof("Starting proxy on: %s", srv.Addr)
+	l, err := listen(srv.Addr)
 	if err != nil {
 		log.Warn("Proxy Listen error:", err)
 	} else {
@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	lazyloadv1alpha1 "slime.io/slime/modules/lazyload/api/v1alpha1"
+)
+
+// isLearnedDomain returns whether the domain is learned from the metric. The learned domains are marked with
+// RecentlyCalled, while the static ones of spec.host and spec.labelSelector are not.
+func isLearnedDomain(dest *lazyloadv1alpha1.Destinations) bool {
+	return dest.RecentlyCalled != nil
+}
+
+// isSidecarDomain returns whether the domain goes into the generated sidecar
+func isSidecarDomain(dest *lazyloadv1alpha1.Destinations) bool {
+	return dest.Status == lazyloadv1alpha1.Destinations_ACTIVE || dest.Status == lazyloadv1alpha1.Destinations_EXPIREWAIT
+}
+
+// recentlyCalled returns the RecentlyCalled of a learned domain, now if the domain is called or newly
+// learned, or the previous one
+func recentlyCalled(prev *lazyloadv1alpha1.Destinations, called bool, now time.Time) *lazyloadv1alpha1.Timestamp {
+	if !called && prev != nil && prev.RecentlyCalled != nil {
+		return prev.RecentlyCalled
+	}
+	return &lazyloadv1alpha1.Timestamp{Seconds: now.Unix(), Nanos: int32(now.Nanosecond())}
+}
+
+// callTracker records the metric items called since they were last refreshed into the servicefences. The
+// access log convertor only merges the new items into the shared metric cache, so the later calls of the known
+// items are tracked here to keep the RecentlyCalled of the learned domains up to date.
+type callTracker struct {
+	mut sync.Mutex
+	// mapping of the servicefence's namespaced name to the called metric items
+	calls map[string]map[string]bool
+}
+
+// record records the items of the access log handler result
+func (t *callTracker) record(result map[string]map[string]string) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	if t.calls == nil {
+		t.calls = map[string]map[string]bool{}
+	}
+	for meta, items := range result {
+		called := t.calls[meta]
+		if called == nil {
+			called = make(map[string]bool, len(items))
+			t.calls[meta] = called
+		}
+		for k := range items {
+			called[k] = true
+		}
+	}
+}
+
+// take returns and forgets the items called since the last take of the servicefence
+func (t *callTracker) take(meta string) map[string]bool {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	called := t.calls[meta]
+	delete(t.calls, meta)
+	return called
+}
+
+// hostNamespace returns the namespace of the host like `svc.ns.svc.cluster.local`, or empty if not
+// a service host
+func hostNamespace(h string) string {
+	parts := strings.Split(h, ".")
+	if len(parts) != 5 || !strings.HasSuffix(h, ".svc.cluster.local") {
+		return ""
+	}
+	return parts[1]
+}
+
+// genHostBudget checks the learned domains going into the sidecar against spec.maxLearnedHosts, and decides
+// which ones are evicted or replaced with the namespace wildcard according to spec.overflowPolicy.
+// Returns nil if maxLearnedHosts is not set.
+func genHostBudget(
+	spec *lazyloadv1alpha1.ServiceFenceSpec,
+	domains map[string]*lazyloadv1alpha1.Destinations,
+) *lazyloadv1alpha1.HostBudgetStatus {
+	max := int(spec.GetMaxLearnedHosts())
+	if max == 0 {
+		return nil
+	}
+
+	var learned []string
+	for k, dest := range domains {
+		if isLearnedDomain(dest) && isSidecarDomain(dest) {
+			learned = append(learned, k)
+		}
+	}
+	sort.Strings(learned)
+
+	budget := &lazyloadv1alpha1.HostBudgetStatus{
+		LearnedHosts: uint32(len(learned)),
+		Overflow:     len(learned) > max,
+	}
+	if !budget.Overflow {
+		return budget
+	}
+
+	switch spec.GetOverflowPolicy() {
+	case lazyloadv1alpha1.OverflowPolicy_DISABLE_FENCE:
+		budget.FenceDisabled = true
+	case lazyloadv1alpha1.OverflowPolicy_EVICT_LEAST_RECENTLY_CALLED:
+		sort.SliceStable(learned, func(i, j int) bool {
+			ti, tj := domains[learned[i]].RecentlyCalled, domains[learned[j]].RecentlyCalled
+			if ti.Seconds != tj.Seconds {
+				return ti.Seconds > tj.Seconds
+			}
+			return ti.Nanos > tj.Nanos
+		})
+		budget.EvictedHosts = append(budget.EvictedHosts, learned[max:]...)
+		sort.Strings(budget.EvictedHosts)
+	default:
+		budget.WildcardNamespaces = wildcardNamespaces(learned, max)
+	}
+	return budget
+}
+
+// wildcardNamespaces returns the namespaces to be replaced with the wildcard, from the namespace with the most
+// learned hosts, until the learned hosts are within the max. A namespace with only one learned host is never
+// replaced as it does not help.
+func wildcardNamespaces(learned []string, max int) []string {
+	nsHosts := map[string]int{}
+	for _, h := range learned {
+		if ns := hostNamespace(h); ns != "" {
+			nsHosts[ns]++
+		}
+	}
+	nss := make([]string, 0, len(nsHosts))
+	for ns := range nsHosts {
+		nss = append(nss, ns)
+	}
+	sort.Slice(nss, func(i, j int) bool {
+		if nsHosts[nss[i]] != nsHosts[nss[j]] {
+			return nsHosts[nss[i]] > nsHosts[nss[j]]
+		}
+		return nss[i] < nss[j]
+	})
+
+	var ret []string
+	count := len(learned)
+	for _, ns := range nss {
+		if count <= max || nsHosts[ns] <= 1 {
+			break
+		}
+		ret = append(ret, ns)
+		count -= nsHosts[ns] - 1
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// budgetSidecarHosts returns the egress hosts of the domains with the host budget applied
+func budgetSidecarHosts(
+	domains map[string]*lazyloadv1alpha1.Destinations,
+	budget *lazyloadv1alpha1.HostBudgetStatus,
+	isDefaultAddNs func(string) bool,
+) []string {
+	if budget.GetFenceDisabled() {
+		return []string{"*/*"}
+	}
+
+	evicted := make(map[string]struct{}, len(budget.GetEvictedHosts()))
+	for _, h := range budget.GetEvictedHosts() {
+		evicted[h] = struct{}{}
+	}
+	wildcard := make(map[string]struct{}, len(budget.GetWildcardNamespaces()))
+	var hosts []string
+	for _, ns := range budget.GetWildcardNamespaces() {
+		wildcard[ns] = struct{}{}
+		hosts = append(hosts, ns+"/*")
+	}
+
+	for k, v := range domains {
+		if !isSidecarDomain(v) {
+			continue
+		}
+		if isLearnedDomain(v) {
+			if _, ok := evicted[k]; ok {
+				continue
+			}
+			if _, ok := wildcard[hostNamespace(k)]; ok {
+				continue
+			}
+		}
+		if strings.HasSuffix(k, "/*") {
+			if !isDefaultAddNs(k) {
+				hosts = append(hosts, k)
+			}
+		}
+
+		for _, h := range v.Hosts {
+			hosts = append(hosts, "*/"+h)
+		}
+	}
+	return hosts
+}
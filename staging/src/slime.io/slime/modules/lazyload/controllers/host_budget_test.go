@@ -0,0 +1,221 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	networkingv1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	bootconfig "slime.io/slime/framework/apis/config/v1alpha1"
+	"slime.io/slime/framework/bootstrap"
+	"slime.io/slime/modules/lazyload/api/config"
+	lazyloadv1alpha1 "slime.io/slime/modules/lazyload/api/v1alpha1"
+)
+
+func testBudgetDomains() map[string]*lazyloadv1alpha1.Destinations {
+	learned := func(h string, sec int64) *lazyloadv1alpha1.Destinations {
+		return &lazyloadv1alpha1.Destinations{
+			Hosts:          []string{h},
+			Status:         lazyloadv1alpha1.Destinations_ACTIVE,
+			RecentlyCalled: &lazyloadv1alpha1.Timestamp{Seconds: sec},
+		}
+	}
+	return map[string]*lazyloadv1alpha1.Destinations{
+		// static
+		"static.default.svc.cluster.local": {
+			Hosts:  []string{"static.default.svc.cluster.local"},
+			Status: lazyloadv1alpha1.Destinations_ACTIVE,
+		},
+		"a.foo.svc.cluster.local": learned("a.foo.svc.cluster.local", 1),
+		"b.foo.svc.cluster.local": learned("b.foo.svc.cluster.local", 5),
+		"c.foo.svc.cluster.local": learned("c.foo.svc.cluster.local", 3),
+		"d.bar.svc.cluster.local": learned("d.bar.svc.cluster.local", 4),
+		"e.bar.svc.cluster.local": learned("e.bar.svc.cluster.local", 2),
+		"www.example.com":         learned("www.example.com", 6),
+	}
+}
+
+func TestHostBudget(t *testing.T) {
+	domains := testBudgetDomains()
+	isDefaultAddNs := func(string) bool { return false }
+
+	if budget := genHostBudget(&lazyloadv1alpha1.ServiceFenceSpec{}, domains); budget != nil {
+		t.Fatalf("expect no budget without max, got %v", budget)
+	}
+	budget := genHostBudget(&lazyloadv1alpha1.ServiceFenceSpec{MaxLearnedHosts: 6}, domains)
+	if budget.LearnedHosts != 6 || budget.Overflow {
+		t.Fatalf("expect 6 learned hosts without overflow, got %v", budget)
+	}
+
+	budget = genHostBudget(&lazyloadv1alpha1.ServiceFenceSpec{
+		MaxLearnedHosts: 3,
+		OverflowPolicy:  lazyloadv1alpha1.OverflowPolicy_EVICT_LEAST_RECENTLY_CALLED,
+	}, domains)
+	expectEvicted := []string{"a.foo.svc.cluster.local", "c.foo.svc.cluster.local", "e.bar.svc.cluster.local"}
+	if !budget.Overflow || !reflect.DeepEqual(expectEvicted, budget.EvictedHosts) {
+		t.Errorf("expect evicted %v, got %v", expectEvicted, budget)
+	}
+	hosts := budgetSidecarHosts(domains, budget, isDefaultAddNs)
+	sort.Strings(hosts)
+	expectHosts := []string{
+		"*/b.foo.svc.cluster.local", "*/d.bar.svc.cluster.local",
+		"*/static.default.svc.cluster.local", "*/www.example.com",
+	}
+	if !reflect.DeepEqual(expectHosts, hosts) {
+		t.Errorf("expect hosts %v, got %v", expectHosts, hosts)
+	}
+
+	// foo: 3 -> 1, then bar: 2 -> 1
+	budget = genHostBudget(&lazyloadv1alpha1.ServiceFenceSpec{MaxLearnedHosts: 3}, domains)
+	if expect := []string{"bar", "foo"}; !reflect.DeepEqual(expect, budget.WildcardNamespaces) {
+		t.Errorf("expect wildcard namespaces %v, got %v", expect, budget)
+	}
+	budget = genHostBudget(&lazyloadv1alpha1.ServiceFenceSpec{MaxLearnedHosts: 4}, domains)
+	if expect := []string{"foo"}; !reflect.DeepEqual(expect, budget.WildcardNamespaces) {
+		t.Errorf("expect wildcard namespaces %v, got %v", expect, budget)
+	}
+	hosts = budgetSidecarHosts(domains, budget, isDefaultAddNs)
+	sort.Strings(hosts)
+	expectHosts = []string{
+		"*/d.bar.svc.cluster.local", "*/e.bar.svc.cluster.local",
+		"*/static.default.svc.cluster.local", "*/www.example.com", "foo/*",
+	}
+	if !reflect.DeepEqual(expectHosts, hosts) {
+		t.Errorf("expect hosts %v, got %v", expectHosts, hosts)
+	}
+
+	budget = genHostBudget(&lazyloadv1alpha1.ServiceFenceSpec{
+		MaxLearnedHosts: 3,
+		OverflowPolicy:  lazyloadv1alpha1.OverflowPolicy_DISABLE_FENCE,
+	}, domains)
+	if hosts = budgetSidecarHosts(domains, budget, isDefaultAddNs); !reflect.DeepEqual([]string{"*/*"}, hosts) {
+		t.Errorf("expect fence disabled, got %v", hosts)
+	}
+}
+
+func TestMetricHost(t *testing.T) {
+	cases := map[string]string{
+		`{destination_service="grafana.istio-system.svc.cluster.local"}`: "grafana.istio-system.svc.cluster.local",
+		`{request_host="www.example.com:8080"}`:                          "www.example.com",
+		`{source_app="foo"}`:                                             "",
+	}
+	for metricName, expect := range cases {
+		if got := metricHost(metricName); got != expect {
+			t.Errorf("metric %s expect host %q, got %q", metricName, expect, got)
+		}
+	}
+}
+
+func TestCallTracker(t *testing.T) {
+	var tracker callTracker
+	tracker.record(map[string]map[string]string{
+		"default/foo": {"bar.default.svc.cluster.local": "1"},
+	})
+	tracker.record(map[string]map[string]string{
+		"default/foo": {"baz.default.svc.cluster.local": "1"},
+		"default/qux": {"bar.default.svc.cluster.local": "2"},
+	})
+
+	expect := map[string]bool{"bar.default.svc.cluster.local": true, "baz.default.svc.cluster.local": true}
+	if got := tracker.take("default/foo"); !reflect.DeepEqual(got, expect) {
+		t.Errorf("expect calls %v, got %v", expect, got)
+	}
+	if got := tracker.take("default/foo"); len(got) != 0 {
+		t.Errorf("expect no calls after take, got %v", got)
+	}
+	if got := tracker.take("default/qux"); !got["bar.default.svc.cluster.local"] {
+		t.Errorf("expect the calls of default/qux kept, got %v", got)
+	}
+}
+
+func TestRefreshEvictedHostCalledAgain(t *testing.T) {
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{
+		clientgoscheme.AddToScheme, lazyloadv1alpha1.AddToScheme, networkingv1alpha3.AddToScheme,
+	} {
+		if err := add(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const (
+		a, b             = "a.foo.svc.cluster.local", "b.foo.svc.cluster.local"
+		aMetric, bMetric = `{destination_service="a.foo.svc.cluster.local"}`, `{destination_service="b.foo.svc.cluster.local"}`
+	)
+	learned := func(h string, sec int64) *lazyloadv1alpha1.Destinations {
+		return &lazyloadv1alpha1.Destinations{
+			Hosts:          []string{h},
+			Status:         lazyloadv1alpha1.Destinations_ACTIVE,
+			RecentlyCalled: &lazyloadv1alpha1.Timestamp{Seconds: sec},
+		}
+	}
+	metricStatus := map[string]string{aMetric: "1", bMetric: "1"}
+	sf := &lazyloadv1alpha1.ServiceFence{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"},
+		Spec: lazyloadv1alpha1.ServiceFenceSpec{
+			Enable:          true,
+			MaxLearnedHosts: 1,
+			OverflowPolicy:  lazyloadv1alpha1.OverflowPolicy_EVICT_LEAST_RECENTLY_CALLED,
+		},
+		Status: lazyloadv1alpha1.ServiceFenceStatus{
+			MetricStatus: metricStatus,
+			// a is evicted as the least recently called
+			Domains:    map[string]*lazyloadv1alpha1.Destinations{a: learned(a, 1), b: learned(b, 2)},
+			HostBudget: &lazyloadv1alpha1.HostBudgetStatus{LearnedHosts: 2, Overflow: true, EvictedHosts: []string{a}},
+		},
+	}
+
+	r := NewReconciler(
+		ReconcilerWithCfg(&config.Fence{}),
+		ReconcilerWithEnv(bootstrap.Environment{Config: &bootconfig.Config{Global: &bootconfig.Global{
+			IstioNamespace: "istio-system",
+			SlimeNamespace: "istio-system",
+		}}}),
+	)
+	r.Scheme = scheme
+	r.Client = fake.NewClientBuilder().WithScheme(scheme).WithObjects(sf).Build()
+
+	ctx := context.Background()
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "foo"}}
+	refreshed := func() (*lazyloadv1alpha1.ServiceFence, []string) {
+		t.Helper()
+		if _, err := r.Refresh(ctx, req, map[string]string{aMetric: "1", bMetric: "1"}); err != nil {
+			t.Fatal(err)
+		}
+		got := &lazyloadv1alpha1.ServiceFence{}
+		if err := r.Client.Get(ctx, req.NamespacedName, got); err != nil {
+			t.Fatal(err)
+		}
+		sidecar := &networkingv1alpha3.Sidecar{}
+		if err := r.Client.Get(ctx, req.NamespacedName, sidecar); err != nil {
+			return got, nil
+		}
+		return got, sidecar.Spec.Egress[0].Hosts
+	}
+
+	// nothing is called, skip refreshing
+	if got, hosts := refreshed(); !reflect.DeepEqual(got.Status.HostBudget.EvictedHosts, []string{a}) || hosts != nil {
+		t.Fatalf("expect unchanged servicefence, got %v sidecar hosts %v", got.Status.HostBudget, hosts)
+	}
+
+	// the evicted host is called again through the global-sidecar, the metric status is unchanged
+	r.calls.record(map[string]map[string]string{"default/foo": {aMetric: "1"}})
+	got, hosts := refreshed()
+	if !reflect.DeepEqual(got.Status.HostBudget.EvictedHosts, []string{b}) {
+		t.Errorf("expect %s evicted, got %v", b, got.Status.HostBudget)
+	}
+	if got.Status.Domains[a].RecentlyCalled.Seconds <= 2 {
+		t.Errorf("expect RecentlyCalled of %s updated, got %v", a, got.Status.Domains[a].RecentlyCalled)
+	}
+	if expect := []string{"*/" + a, "istio-system/*"}; !reflect.DeepEqual(hosts, expect) {
+		t.Errorf("expect sidecar hosts %v with %s learned back, got %v", expect, a, hosts)
+	}
+}
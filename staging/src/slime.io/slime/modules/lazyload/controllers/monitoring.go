@@ -54,6 +54,18 @@ var (
 		"total number of update extra resource failed",
 	)
 
+	ShardMembers = monitoring.NewGauge(
+		model.ModuleName,
+		"shard_members",
+		"number of the replicas sharing the dependency learning",
+	)
+
+	ShardCheckpoints = monitoring.NewSum(
+		model.ModuleName,
+		"shard_checkpoints",
+		"total number of checkpoints of the dependencies learned for the servicefences owned by other replicas",
+	)
+
 	ServicefenceLoads = monitoring.NewHistogram(
 		model.ModuleName,
 		"servicefenc_loads",
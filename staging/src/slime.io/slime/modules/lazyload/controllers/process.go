@@ -48,13 +48,13 @@ func (r *ServicefenceReconciler) WatchMetric(ctx context.Context) {
 				log.Warningf("watcher mertic channel closed, break process loop")
 				return
 			}
-			r.ConsumeMetric(metric)
+			r.ConsumeMetric(ctx, metric)
 		case metric, ok := <-r.tickerMetricChan:
 			if !ok {
 				log.Warningf("ticker metric channel closed, break process loop")
 				return
 			}
-			r.ConsumeMetric(metric)
+			r.ConsumeMetric(ctx, metric)
 		}
 	}
 }
@@ -75,7 +75,7 @@ func (r *ServicefenceReconciler) WatchMetric(ctx context.Context) {
 //	}
 //
 // ```
-func (r *ServicefenceReconciler) ConsumeMetric(metric metric.Metric) {
+func (r *ServicefenceReconciler) ConsumeMetric(ctx context.Context, metric metric.Metric) {
 	for meta, results := range metric {
 		log.Debugf("got metric for %s", meta)
 		namespace, name := strings.Split(meta, "/")[0], strings.Split(meta, "/")[1]
@@ -85,20 +85,24 @@ func (r *ServicefenceReconciler) ConsumeMetric(metric metric.Metric) {
 			continue
 		}
 		value := results[0].Value
-		if _, err := r.Refresh(reconcile.Request{NamespacedName: nn}, value); err != nil {
+		if _, err := r.Refresh(ctx, reconcile.Request{NamespacedName: nn}, value); err != nil {
 			log.Errorf("refresh error:%v", err)
 		}
 	}
 }
 
-func (r *ServicefenceReconciler) Refresh(req reconcile.Request, value map[string]string) (reconcile.Result, error) {
-	log := log.WithField("reporter", "ServicefenceReconciler").WithField("function", "Refresh")
+func (r *ServicefenceReconciler) Refresh(
+	ctx context.Context,
+	req reconcile.Request,
+	value map[string]string,
+) (reconcile.Result, error) {
+	log := log.WithContext(ctx).WithField("reporter", "ServicefenceReconciler").WithField("function", "Refresh")
 
 	r.reconcileLock.Lock()
 	defer r.reconcileLock.Unlock()
 
 	sf := &lazyloadv1alpha1.ServiceFence{}
-	err := r.Client.Get(context.TODO(), req.NamespacedName, sf)
+	err := r.Client.Get(ctx, req.NamespacedName, sf)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			sf = nil
@@ -110,6 +114,7 @@ func (r *ServicefenceReconciler) Refresh(req reconcile.Request, value map[string
 
 	if sf == nil {
 		log.Info("ServiceFence Not Found, skip")
+		r.calls.take(req.NamespacedName.String())
 		return reconcile.Result{}, nil
 	} else if rev := model.IstioRevFromLabel(sf.Labels); !r.env.RevInScope(rev) {
 		log.Infof("existing sf %v istioRev %s but our %s, skip ...",
@@ -117,18 +122,32 @@ func (r *ServicefenceReconciler) Refresh(req reconcile.Request, value map[string
 		return reconcile.Result{}, nil
 	}
 
+	if r.sharded() {
+		if r.shardResets.honour(sf) {
+			// learned before the reset
+			value = nil
+		}
+		// keep the dependencies checkpointed by other replicas
+		value = mergeMetricStatus(sf.Status.MetricStatus, value)
+	}
 	log.Debugf("refresh with servicefence %s metricstatus old: %v, new: %v",
 		req.NamespacedName, sf.Status.MetricStatus, value)
-	// skip refresh when metric result has not changed
-	if mapStrStrEqual(sf.Status.MetricStatus, value) {
+	// the later calls of the known items do not change the metric status, take them from the tracker
+	tracked := r.calls.take(req.NamespacedName.String())
+	// skip refresh when metric result has not changed and nothing is called since last refresh
+	if mapStrStrEqual(sf.Status.MetricStatus, value) && len(tracked) == 0 {
 		return reconcile.Result{}, nil
 	}
+	called := changedMetricStatus(sf.Status.MetricStatus, value)
+	for k := range tracked {
+		called[k] = true
+	}
 	// use updateVisitedHostStatus to update svf.spec and svf.status
 	sf.Status.MetricStatus = value
-	r.updateServicefenceDomain(sf)
+	r.updateServicefenceDomain(sf, called)
 
 	if sf.Spec.Enable {
-		if err := r.refreshSidecar(sf); err != nil {
+		if err := r.refreshSidecar(ctx, sf); err != nil {
 			// XXX return err?
 			log.Errorf("refresh sidecar %v met err: %v", req.NamespacedName, err)
 		}
@@ -137,6 +156,17 @@ func (r *ServicefenceReconciler) Refresh(req reconcile.Request, value map[string
 	return reconcile.Result{}, nil
 }
 
+// changedMetricStatus returns the items of the new metric status which are new or changed
+func changedMetricStatus(old, cur map[string]string) map[string]bool {
+	ret := map[string]bool{}
+	for k, v := range cur {
+		if ov, ok := old[k]; !ok || ov != v {
+			ret[k] = true
+		}
+	}
+	return ret
+}
+
 func mapStrStrEqual(m1, m2 map[string]string) bool {
 	if len(m1) != len(m2) {
 		return false
@@ -317,6 +347,8 @@ func (r *ServicefenceReconciler) refreshFenceStatusOfService(
 					return reconcile.Result{}, err
 				}
 				ServiceFenceCreations.Increment()
+				r.recordEvent(sf, corev1.EventTypeNormal, "ServiceFenceCreated",
+					"created by controller for fenced service %s", nn)
 				log.Infof("create fence succeed %s:%s in refreshFenceStatusOfService", sf.Namespace, sf.Name)
 			} else {
 				log.Infof("service %s is not fenced, skip create servicefence", nn)
@@ -426,6 +458,8 @@ func (r *ServicefenceReconciler) handlePodUpdate(ctx context.Context, _, obj int
 			return
 		}
 		ServiceFenceCreations.Increment()
+		r.recordEvent(sf, corev1.EventTypeNormal, "ServiceFenceCreated",
+			"created by controller for workload selector '%s=%s'", r.workloadFenceLabelKey, v)
 		r.appendIpToFence(namespacedName, pod.Status.PodIP)
 		log.Infof("create fence %s for workload selector by '%s=%s' ", namespacedName, r.workloadFenceLabelKey, v)
 	}
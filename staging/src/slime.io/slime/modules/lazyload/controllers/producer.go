@@ -5,6 +5,7 @@ import (
 	stderrors "errors"
 	"fmt"
 	"net"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -30,6 +31,7 @@ const (
 	AccessLogConvertorName     = "lazyload-accesslog-convertor"
 	MetricSourceTypePrometheus = "prometheus"
 	MetricSourceTypeAccesslog  = "accesslog"
+	MetricSourceTypeOtlp       = "otlp"
 	SvfResource                = "servicefences"
 )
 
@@ -41,7 +43,7 @@ func (r *ServicefenceReconciler) handleWatcherEvent(event trigger.WatcherEvent)
 	}
 	invalidEvent := false
 	for _, gvk := range gvks {
-		if event.GVK == gvk && r.getInterestMeta()[event.NN.String()] {
+		if event.GVK == gvk && r.interested(event.NN.String()) {
 			invalidEvent = true
 		}
 	}
@@ -54,8 +56,8 @@ func (r *ServicefenceReconciler) handleWatcherEvent(event trigger.WatcherEvent)
 
 	// check metric source type
 	switch r.cfg.MetricSourceType {
-	case MetricSourceTypePrometheus:
-		for pName, pHandler := range r.env.Config.Metric.Prometheus.Handlers {
+	case MetricSourceTypePrometheus, MetricSourceTypeOtlp:
+		for pName, pHandler := range r.metricHandlers() {
 			hs = append(hs, generateHandler(event.NN.Name, event.NN.Namespace, pName, pHandler))
 		}
 	case MetricSourceTypeAccesslog:
@@ -79,18 +81,27 @@ func (r *ServicefenceReconciler) handleTickerEvent(_ trigger.TickerEvent) metric
 	// check metric source type
 	qm := make(map[string][]metric.Handler)
 
+	metas := r.getInterestMeta()
+	if r.sharded() {
+		// the servicefences are reconciled by the leader, each replica queries the ones it owns
+		metas = map[string]bool{}
+		for _, meta := range r.ownedFences(context.TODO()) {
+			metas[meta] = true
+		}
+	}
+
 	switch r.cfg.MetricSourceType {
-	case MetricSourceTypePrometheus:
-		for meta := range r.getInterestMeta() {
+	case MetricSourceTypePrometheus, MetricSourceTypeOtlp:
+		for meta := range metas {
 			namespace, name := strings.Split(meta, "/")[0], strings.Split(meta, "/")[1]
 			var hs []metric.Handler
-			for pName, pHandler := range r.env.Config.Metric.Prometheus.Handlers {
+			for pName, pHandler := range r.metricHandlers() {
 				hs = append(hs, generateHandler(name, namespace, pName, pHandler))
 			}
 			qm[meta] = hs
 		}
 	case MetricSourceTypeAccesslog:
-		for meta := range r.getInterestMeta() {
+		for meta := range metas {
 			qm[meta] = []metric.Handler{
 				{
 					Name:  AccessLogConvertorName,
@@ -103,6 +114,22 @@ func (r *ServicefenceReconciler) handleTickerEvent(_ trigger.TickerEvent) metric
 	return qm
 }
 
+// interested returns whether the metric of the servicefence should be produced by this replica
+func (r *ServicefenceReconciler) interested(meta string) bool {
+	if r.sharded() {
+		return r.shardRing.Owns(meta)
+	}
+	return r.getInterestMeta()[meta]
+}
+
+// metricHandlers returns the query handlers of the metric source
+func (r *ServicefenceReconciler) metricHandlers() map[string]*v1alpha1.Prometheus_Source_Handler {
+	if r.cfg.MetricSourceType == MetricSourceTypeOtlp {
+		return r.env.Config.Metric.GetOtlp().GetHandlers()
+	}
+	return r.env.Config.Metric.GetPrometheus().GetHandlers()
+}
+
 func generateHandler(name, namespace, pName string, pHandler *v1alpha1.Prometheus_Source_Handler) metric.Handler {
 	query := strings.ReplaceAll(pHandler.Query, "$namespace", namespace)
 	query = strings.ReplaceAll(query, "$source_app", name)
@@ -111,8 +138,9 @@ func generateHandler(name, namespace, pName string, pHandler *v1alpha1.Prometheu
 
 func NewProducerConfig(env bootstrap.Environment, cfg config.Fence) (*metric.ProducerConfig, error) {
 	// init metric source
-	var enablePrometheusSource bool
+	var enablePrometheusSource, enableOtlpSource bool
 	var prometheusSourceConfig metric.PrometheusSourceConfig
+	var otlpSourceConfig metric.OtlpSourceConfig
 	var accessLogSourceConfig metric.AccessLogSourceConfig
 	var err error
 
@@ -123,6 +151,12 @@ func NewProducerConfig(env bootstrap.Environment, cfg config.Fence) (*metric.Pro
 		if err != nil {
 			return nil, err
 		}
+	case MetricSourceTypeOtlp:
+		enableOtlpSource = true
+		otlpSourceConfig, err = newOtlpSourceConfig(env)
+		if err != nil {
+			return nil, err
+		}
 	case MetricSourceTypeAccesslog:
 		enablePrometheusSource = false
 		// init log source port
@@ -142,11 +176,18 @@ func NewProducerConfig(env bootstrap.Environment, cfg config.Fence) (*metric.Pro
 		return nil, stderrors.New("wrong metricSourceType")
 	}
 
+	queryConfig, err := metric.NewQueryConfig(env.Config.GetGlobal().GetMisc())
+	if err != nil {
+		return nil, err
+	}
+
 	// init whole producer config
 	pc := &metric.ProducerConfig{
 		EnablePrometheusSource: enablePrometheusSource,
 		PrometheusSourceConfig: prometheusSourceConfig,
 		AccessLogSourceConfig:  accessLogSourceConfig,
+		EnableOtlpSource:       enableOtlpSource,
+		OtlpSourceConfig:       otlpSourceConfig,
 		EnableWatcherProducer:  true,
 		WatcherProducerConfig: metric.WatcherProducerConfig{
 			Name:       "lazyload-watcher",
@@ -174,7 +215,8 @@ func NewProducerConfig(env bootstrap.Environment, cfg config.Fence) (*metric.Pro
 				EventChan: make(chan trigger.TickerEvent),
 			},
 		},
-		StopChan: env.Stop,
+		QueryConfig: queryConfig,
+		StopChan:    env.Stop,
 	}
 
 	return pc, nil
@@ -182,7 +224,21 @@ func NewProducerConfig(env bootstrap.Environment, cfg config.Fence) (*metric.Pro
 
 // nolint: lll
 func (r *ServicefenceReconciler) LogHandler(logEntry []*data_accesslog.HTTPAccessLogEntry) (map[string]map[string]string, error) {
-	return accessLogHandler(logEntry, r.ipToSvcCache, r.svcToIpsCache, r.ipTofence, r.fenceToIp, r.cfg.EnableShortDomain)
+	result, err := accessLogHandler(logEntry, r.ipToSvcCache, r.svcToIpsCache, r.ipTofence, r.fenceToIp,
+		r.cfg.EnableShortDomain)
+	if err == nil {
+		r.calls.record(result)
+	}
+	return result, err
+}
+
+// nolint: lll
+func (r *ServicefenceReconciler) TcpLogHandler(logEntry []*data_accesslog.TCPAccessLogEntry) (map[string]map[string]string, error) {
+	result, err := tcpAccessLogHandler(logEntry, r.ipToSvcCache, r.clusterIpToSvcCache, r.ipTofence)
+	if err == nil {
+		r.calls.record(result)
+	}
+	return result, err
 }
 
 func newPrometheusSourceConfig(env bootstrap.Environment) (metric.PrometheusSourceConfig, error) {
@@ -203,6 +259,14 @@ func newPrometheusSourceConfig(env bootstrap.Environment) (metric.PrometheusSour
 	}, nil
 }
 
+func newOtlpSourceConfig(env bootstrap.Environment) (metric.OtlpSourceConfig, error) {
+	otlp := env.Config.Metric.GetOtlp()
+	if otlp == nil {
+		return metric.OtlpSourceConfig{}, stderrors.New("failure create otlp source, empty otlp config")
+	}
+	return metric.NewOtlpSourceConfig(otlp)
+}
+
 func NewCache(env bootstrap.Environment) (map[string]map[string]string, error) {
 	result := make(map[string]map[string]string)
 
@@ -244,15 +308,46 @@ func NewCache(env bootstrap.Environment) (map[string]map[string]string, error) {
 
 func accessLogHandler(logEntry []*data_accesslog.HTTPAccessLogEntry, ipToSvcCache *IpToSvcCache,
 	svcToIpsCache *SvcToIpsCache, ipTofenceCache *IpTofence, _ *FenceToIp, enableShortDomain bool,
+) (map[string]map[string]string, error) {
+	commons := make([]*data_accesslog.AccessLogCommon, 0, len(logEntry))
+	for _, entry := range logEntry {
+		commons = append(commons, entry.GetCommonProperties())
+	}
+	return convertAccessLogs(commons, ipToSvcCache, ipTofenceCache,
+		func(i int, sourceSvcs []string, fenceNN *types.NamespacedName) []string {
+			return spliceDestinationSvc(logEntry[i], sourceSvcs, svcToIpsCache, fenceNN, enableShortDomain)
+		})
+}
+
+func tcpAccessLogHandler(logEntry []*data_accesslog.TCPAccessLogEntry, ipToSvcCache *IpToSvcCache,
+	clusterIpToSvcCache *IpToSvcCache, ipTofenceCache *IpTofence,
+) (map[string]map[string]string, error) {
+	commons := make([]*data_accesslog.AccessLogCommon, 0, len(logEntry))
+	for _, entry := range logEntry {
+		commons = append(commons, entry.GetCommonProperties())
+	}
+	return convertAccessLogs(commons, ipToSvcCache, ipTofenceCache,
+		func(i int, _ []string, _ *types.NamespacedName) []string {
+			return spliceTcpDestinationSvc(logEntry[i], clusterIpToSvcCache, ipToSvcCache)
+		})
+}
+
+// convertAccessLogs maps the source services and workload fence of each access log to the destination services
+// returned by destinationSvcsOf, and counts the calls.
+func convertAccessLogs(
+	commons []*data_accesslog.AccessLogCommon,
+	ipToSvcCache *IpToSvcCache,
+	ipTofenceCache *IpTofence,
+	destinationSvcsOf func(i int, sourceSvcs []string, fenceNN *types.NamespacedName) []string,
 ) (map[string]map[string]string, error) {
 	log = log.WithField("reporter", "accesslog convertor").WithField("function", "accessLogHandler")
 	// map sourceSvc to destinationSvc
 	result := make(map[string]map[string]string)
 	tmpResult := make(map[string]map[string]int)
 
-	for _, entry := range logEntry {
+	for i, common := range commons {
 		// fetch source ip
-		sourceIp, err := fetchSourceIp(entry)
+		sourceIp, err := fetchSourceIp(common)
 		if err != nil {
 			return nil, err
 		}
@@ -280,7 +375,7 @@ func accessLogHandler(logEntry []*data_accesslog.HTTPAccessLogEntry, ipToSvcCach
 
 		// fetch all destination services like:
 		// []string{`{destination_service="foo.default.svc.cluster.local"`}
-		destinationSvcs := spliceDestinationSvc(entry, sourceSvcs, svcToIpsCache, fenceNN, enableShortDomain)
+		destinationSvcs := destinationSvcsOf(i, sourceSvcs, fenceNN)
 		if len(destinationSvcs) == 0 {
 			continue
 		}
@@ -317,13 +412,13 @@ func accessLogHandler(logEntry []*data_accesslog.HTTPAccessLogEntry, ipToSvcCach
 	return result, nil
 }
 
-func fetchSourceIp(entry *data_accesslog.HTTPAccessLogEntry) (string, error) {
+func fetchSourceIp(common *data_accesslog.AccessLogCommon) (string, error) {
 	log := log.WithField("reporter", "accesslog convertor").WithField("function", "fetchSourceIp")
-	if entry.CommonProperties.DownstreamDirectRemoteAddress == nil {
+	if common.GetDownstreamDirectRemoteAddress() == nil {
 		log.Debugf("DownstreamDirectRemoteAddress is nil, skip")
 		return "", nil
 	}
-	addr := entry.CommonProperties.DownstreamDirectRemoteAddress.Address
+	addr := common.DownstreamDirectRemoteAddress.Address
 	downstreamSock, ok := addr.(*envoy_config_core.Address_SocketAddress)
 	if !ok {
 		return "", stderrors.New("wrong type of DownstreamDirectRemoteAddress")
@@ -442,6 +537,64 @@ func spliceDestinationSvc(
 	return result
 }
 
+// spliceTcpDestinationSvc splices destination service from the tcp entry with below rules
+//   - use the host of the upstream cluster if it's an outbound cluster like `outbound|port|subset|host`
+//   - otherwise, resolve the original destination address to the services by the cluster ip first, and then by
+//     the pod ip. The services are expanded to k8s fqdn.
+func spliceTcpDestinationSvc(
+	entry *data_accesslog.TCPAccessLogEntry,
+	clusterIpToSvcCache *IpToSvcCache,
+	ipToSvcCache *IpToSvcCache,
+) []string {
+	log := log.WithField("reporter", "accesslog convertor").WithField("function", "spliceTcpDestinationSvc")
+	common := entry.GetCommonProperties()
+
+	parts := strings.Split(common.GetUpstreamCluster(), "|")
+	if len(parts) == 4 && parts[0] == "outbound" && parts[3] != "" && net.ParseIP(parts[3]) == nil {
+		dest := fmt.Sprintf("{destination_service=\"%s\"}", parts[3])
+		log.Debugf("DestinationSvc is: %s", dest)
+		return []string{dest}
+	}
+
+	destIp := common.GetDownstreamLocalAddress().GetSocketAddress().GetAddress()
+	if destIp == "" {
+		log.Debugf("original destination of tcp accesslog is empty, skip")
+		return nil
+	}
+	svcs := lookupIpSvcs(destIp, clusterIpToSvcCache)
+	if len(svcs) == 0 {
+		svcs = lookupIpSvcs(destIp, ipToSvcCache)
+	}
+	if len(svcs) == 0 {
+		log.Debugf("svc not found base on original destination %s, skip", destIp)
+		return nil
+	}
+
+	result := make([]string, 0, len(svcs))
+	for _, svc := range svcs {
+		nsName := strings.SplitN(svc, "/", 2)
+		if len(nsName) != 2 {
+			continue
+		}
+		result = append(result,
+			fmt.Sprintf("{destination_service=\"%s.%s.svc.cluster.local\"}", nsName[1], nsName[0]))
+	}
+	log.Debugf("DestinationSvc is: %+v", result)
+	return result
+}
+
+func lookupIpSvcs(ip string, cache *IpToSvcCache) []string {
+	cache.RLock()
+	defer cache.RUnlock()
+
+	svcs := make([]string, 0, len(cache.Data[ip]))
+	for svc := range cache.Data[ip] {
+		svcs = append(svcs, svc)
+	}
+	sort.Strings(svcs)
+	return svcs
+}
+
 func completeDestSvcName(destParts []string, dest, suffix string, svcToIpsCache *SvcToIpsCache) (destSvc string) {
 	svcToIpsCache.RLock()
 	defer svcToIpsCache.RUnlock()
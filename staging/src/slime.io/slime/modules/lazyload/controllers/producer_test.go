@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	envoy_config_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	data_accesslog "github.com/envoyproxy/go-control-plane/envoy/data/accesslog/v3"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func socketAddress(ip string, port uint32) *envoy_config_core.Address {
+	return &envoy_config_core.Address{Address: &envoy_config_core.Address_SocketAddress{
+		SocketAddress: &envoy_config_core.SocketAddress{
+			Address:       ip,
+			PortSpecifier: &envoy_config_core.SocketAddress_PortValue{PortValue: port},
+		},
+	}}
+}
+
+func TestTcpAccessLogHandler(t *testing.T) {
+	ipToSvc := &IpToSvcCache{Data: map[string]map[string]struct{}{
+		"10.0.0.1": {"default/client": {}},
+		"10.0.0.9": {"db/mysql": {}},
+	}}
+	clusterIpToSvc := &IpToSvcCache{Data: map[string]map[string]struct{}{
+		"172.16.0.10": {"cache/redis": {}},
+	}}
+	ipToFence := &IpTofence{Data: map[string]types.NamespacedName{
+		"10.0.0.2": {Namespace: "default", Name: "worker"},
+	}}
+	entry := func(src, upstreamCluster, origDst string) *data_accesslog.TCPAccessLogEntry {
+		return &data_accesslog.TCPAccessLogEntry{CommonProperties: &data_accesslog.AccessLogCommon{
+			DownstreamDirectRemoteAddress: socketAddress(src, 40000),
+			DownstreamLocalAddress:        socketAddress(origDst, 6379),
+			UpstreamCluster:               upstreamCluster,
+		}}
+	}
+
+	result, err := tcpAccessLogHandler([]*data_accesslog.TCPAccessLogEntry{
+		// the host of the outbound cluster
+		entry("10.0.0.1", "outbound|20880||dubbo-provider.default.svc.cluster.local", "172.16.0.20"),
+		// the original destination resolved by the cluster ip
+		entry("10.0.0.1", "PassthroughCluster", "172.16.0.10"),
+		entry("10.0.0.1", "PassthroughCluster", "172.16.0.10"),
+		// the original destination resolved by the pod ip, recorded to the workload fence
+		entry("10.0.0.2", "InboundPassthroughClusterIpv4", "10.0.0.9"),
+		// unknown source or destination
+		entry("10.0.0.3", "PassthroughCluster", "172.16.0.10"),
+		entry("10.0.0.1", "PassthroughCluster", "172.16.0.99"),
+		{CommonProperties: &data_accesslog.AccessLogCommon{}},
+	}, ipToSvc, clusterIpToSvc, ipToFence)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := map[string]map[string]string{
+		"default/client": {
+			`{destination_service="dubbo-provider.default.svc.cluster.local"}`: "1",
+			`{destination_service="redis.cache.svc.cluster.local"}`:            "2",
+		},
+		"default/worker": {
+			`{destination_service="mysql.db.svc.cluster.local"}`: "1",
+		},
+	}
+	if !reflect.DeepEqual(expect, result) {
+		t.Fatalf("expect %v, got %v", expect, result)
+	}
+}
@@ -62,6 +62,7 @@ func (r *ServicefenceReconciler) handleSvcAdd(_ context.Context, obj interface{}
 	r.addLabelSvcCache(svc)
 	r.addNsSvcCache(svc)
 	r.addPortProtocolCache(svc)
+	r.addClusterIpSvcCache(svc)
 }
 
 func (r *ServicefenceReconciler) handleSvcUpdate(_ context.Context, old, obj interface{}) {
@@ -87,6 +88,9 @@ func (r *ServicefenceReconciler) handleSvcUpdate(_ context.Context, old, obj int
 
 	r.deletePortProtocolCache(oldSvc)
 	r.addPortProtocolCache(svc)
+
+	r.deleteClusterIpSvcCache(oldSvc)
+	r.addClusterIpSvcCache(svc)
 }
 
 func (r *ServicefenceReconciler) handleSvcDelete(_ context.Context, obj interface{}) {
@@ -100,6 +104,8 @@ func (r *ServicefenceReconciler) handleSvcDelete(_ context.Context, obj interfac
 	r.deleteNsSvcCache(svc)
 
 	r.deletePortProtocolCache(svc)
+
+	r.deleteClusterIpSvcCache(svc)
 }
 
 func (r *ServicefenceReconciler) addLabelSvcCache(svc *corev1.Service) {
@@ -131,6 +137,46 @@ func (r *ServicefenceReconciler) deleteLabelSvcCache(svc *corev1.Service) {
 	}
 }
 
+func serviceClusterIPs(svc *corev1.Service) []string {
+	ips := svc.Spec.ClusterIPs
+	if len(ips) == 0 && svc.Spec.ClusterIP != "" {
+		ips = []string{svc.Spec.ClusterIP}
+	}
+	ret := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		if ip != "" && ip != corev1.ClusterIPNone {
+			ret = append(ret, ip)
+		}
+	}
+	return ret
+}
+
+func (r *ServicefenceReconciler) addClusterIpSvcCache(svc *corev1.Service) {
+	nn := fmt.Sprintf("%s/%s", svc.GetNamespace(), svc.GetName())
+
+	r.clusterIpToSvcCache.Lock()
+	defer r.clusterIpToSvcCache.Unlock()
+	for _, ip := range serviceClusterIPs(svc) {
+		if r.clusterIpToSvcCache.Data[ip] == nil {
+			r.clusterIpToSvcCache.Data[ip] = make(map[string]struct{})
+		}
+		r.clusterIpToSvcCache.Data[ip][nn] = struct{}{}
+	}
+}
+
+func (r *ServicefenceReconciler) deleteClusterIpSvcCache(svc *corev1.Service) {
+	nn := fmt.Sprintf("%s/%s", svc.GetNamespace(), svc.GetName())
+
+	r.clusterIpToSvcCache.Lock()
+	defer r.clusterIpToSvcCache.Unlock()
+	for _, ip := range serviceClusterIPs(svc) {
+		delete(r.clusterIpToSvcCache.Data[ip], nn)
+		if len(r.clusterIpToSvcCache.Data[ip]) == 0 {
+			delete(r.clusterIpToSvcCache.Data, ip)
+		}
+	}
+}
+
 func (r *ServicefenceReconciler) addNsSvcCache(svc *corev1.Service) {
 	ns := svc.GetNamespace()
 	nn := fmt.Sprintf("%s/%s", ns, svc.GetName())
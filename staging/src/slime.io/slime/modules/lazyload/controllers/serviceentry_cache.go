@@ -1,29 +1,25 @@
 package controllers
 
 import (
-	"slime.io/slime/framework/bootstrap"
-	"slime.io/slime/framework/bootstrap/resource"
 	"slime.io/slime/framework/bootstrap/serviceregistry/model"
-	"slime.io/slime/framework/bootstrap/serviceregistry/serviceentry"
+	"slime.io/slime/framework/bootstrap/serviceregistry/registry"
 )
 
 func (r *ServicefenceReconciler) RegisterSeHandler() {
 	// TODO: add delete event
 	// only add, not delete
-	sePortToCache := func(old resource.Config, cfg resource.Config, e bootstrap.Event) {
-		switch e {
-		case bootstrap.EventAdd:
-			istioSvcs, _ := serviceentry.ConvertSvcsAndEps(cfg, "")
-			r.cachePort(istioSvcs)
-		case bootstrap.EventUpdate:
-			istioSvcs, _ := serviceentry.ConvertSvcsAndEps(cfg, "")
-			r.cachePort(istioSvcs)
+	sePortToCache := func(e registry.ServiceEvent) {
+		switch e.Kind {
+		case registry.EventAdd, registry.EventUpdate:
+			if e.Service.Attributes.ServiceRegistry == model.External {
+				r.cachePort([]*model.Service{e.Service})
+			}
 		default:
 		}
 	}
 	log.Infof("lazyload: register serviceEntry handler")
 
-	r.env.ConfigController.RegisterEventHandler(resource.ServiceEntry, sePortToCache)
+	r.env.ConfigController.ServiceRegistry().Subscribe(sePortToCache)
 }
 
 func (r *ServicefenceReconciler) cachePort(istioSvcs []*model.Service) {
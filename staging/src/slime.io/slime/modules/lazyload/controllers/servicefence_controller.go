@@ -18,6 +18,7 @@ package controllers
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"sort"
@@ -31,6 +32,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/informers"
@@ -42,7 +44,9 @@ import (
 	"slime.io/slime/framework/bootstrap"
 	"slime.io/slime/framework/controllers"
 	"slime.io/slime/framework/model"
+	"slime.io/slime/framework/model/event"
 	"slime.io/slime/framework/model/metric"
+	"slime.io/slime/framework/monitoring"
 	"slime.io/slime/modules/lazyload/api/config"
 	lazyloadv1alpha1 "slime.io/slime/modules/lazyload/api/v1alpha1"
 	modmodel "slime.io/slime/modules/lazyload/model"
@@ -79,8 +83,23 @@ type ServicefenceReconciler struct {
 	// mapping of the pod's ip to the namespaced name of the service it implements, and it's reverse
 	ipToSvcCache  *IpToSvcCache
 	svcToIpsCache *SvcToIpsCache
+	// mapping of the service's cluster ip to the namespaced name of the service, used to resolve the original
+	// destination of the tcp access logs
+	clusterIpToSvcCache *IpToSvcCache
 
 	factory informers.SharedInformerFactory
+
+	// eventRecorder records the events on the servicefences, nil to skip
+	eventRecorder event.Recorder
+
+	// calls tracks the calls of the known metric items between the refreshes
+	calls callTracker
+
+	// shardRing shards the servicefences across the replicas, nil if the learning is not sharded
+	shardRing     *ShardRing
+	shardOpts     ShardOptions
+	shardSelector labels.Selector
+	shardResets   *shardResets
 }
 
 type ReconcilerOpts func(*ServicefenceReconciler)
@@ -102,6 +121,24 @@ func ReconcilerWithCfg(cfg *config.Fence) ReconcilerOpts {
 	}
 }
 
+func ReconcilerWithEventRecorder(recorder event.Recorder) ReconcilerOpts {
+	return func(sr *ServicefenceReconciler) {
+		sr.eventRecorder = recorder
+	}
+}
+
+// ReconcilerWithShard shards the dependency learning of the servicefences across the replicas
+func ReconcilerWithShard(opts ShardOptions) ReconcilerOpts {
+	return func(sr *ServicefenceReconciler) {
+		if opts.Interval <= 0 {
+			opts.Interval = defaultShardCheckpointInterval
+		}
+		sr.shardOpts = opts
+		sr.shardRing = NewShardRing(opts.PodName)
+		sr.shardResets = newShardResets()
+	}
+}
+
 func ReconcilerWithProducerConfig(pc *metric.ProducerConfig) ReconcilerOpts {
 	return func(sr *ServicefenceReconciler) {
 		sr.watcherMetricChan = pc.WatcherProducerConfig.MetricChan
@@ -113,6 +150,7 @@ func ReconcilerWithProducerConfig(pc *metric.ProducerConfig) ReconcilerOpts {
 		// reconciler defines log handler
 		for i := range pc.AccessLogSourceConfig.AccessLogConvertorConfigs {
 			pc.AccessLogSourceConfig.AccessLogConvertorConfigs[i].Handler = sr.LogHandler
+			pc.AccessLogSourceConfig.AccessLogConvertorConfigs[i].TcpHandler = sr.TcpLogHandler
 		}
 	}
 }
@@ -129,6 +167,8 @@ func NewReconciler(opts ...ReconcilerOpts) *ServicefenceReconciler {
 		fenceToIp:         &FenceToIp{Data: map[types.NamespacedName]map[string]struct{}{}},
 		ipToSvcCache:      &IpToSvcCache{Data: map[string]map[string]struct{}{}},
 		svcToIpsCache:     &SvcToIpsCache{Data: map[string][]string{}},
+
+		clusterIpToSvcCache: &IpToSvcCache{Data: map[string]map[string]struct{}{}},
 	}
 	for _, opt := range opts {
 		opt(r)
@@ -136,6 +176,12 @@ func NewReconciler(opts ...ReconcilerOpts) *ServicefenceReconciler {
 	return r
 }
 
+func (r *ServicefenceReconciler) recordEvent(obj runtime.Object, eventType, reason, messageFmt string, args ...interface{}) {
+	if r.eventRecorder != nil {
+		r.eventRecorder.Eventf(obj, eventType, reason, messageFmt, args...)
+	}
+}
+
 // Clear do anything since releading is not supported by framework
 func (r *ServicefenceReconciler) Clear() {
 	// r.reconcileLock.Lock()
@@ -153,7 +199,7 @@ func (r *ServicefenceReconciler) Clear() {
 // +kubebuilder:rbac:groups=microservice.slime.io,resources=servicefences/status,verbs=get;update;patch
 
 func (r *ServicefenceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	log := modmodel.ModuleLog.WithField(model.LogFieldKeyResource, req.NamespacedName)
+	log := modmodel.ModuleLog.WithContext(ctx).WithField(model.LogFieldKeyResource, req.NamespacedName)
 
 	log.Debugf("reconcile svf %s", req)
 	// Fetch the ServiceFence instance
@@ -183,10 +229,10 @@ func (r *ServicefenceReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	log.Infof("serviceFence %+v is added or update", req.NamespacedName)
 
 	// 资源更新
-	r.updateServicefenceDomain(instance)
+	r.updateServicefenceDomain(instance, nil)
 
 	if instance.Spec.Enable {
-		err = r.refreshSidecar(instance)
+		err = r.refreshSidecar(ctx, instance)
 		r.interestMeta[req.NamespacedName.String()] = true
 		r.updateInterestMetaCopy()
 	}
@@ -208,8 +254,12 @@ func (r *ServicefenceReconciler) getInterestMeta() map[string]bool {
 	return r.interestMetaCopy
 }
 
-func (r *ServicefenceReconciler) refreshSidecar(instance *lazyloadv1alpha1.ServiceFence) error {
-	log := log.WithField("reporter", "ServicefenceReconciler").WithField("function", "refreshSidecar")
+func (r *ServicefenceReconciler) refreshSidecar(ctx context.Context, instance *lazyloadv1alpha1.ServiceFence) (err error) {
+	ctx, span := monitoring.StartSpan(ctx, modmodel.ModuleName, "RefreshSidecar",
+		monitoring.ResourceAttributes(instance.Namespace, instance.Name)...)
+	defer func() { monitoring.EndSpan(span, err) }()
+
+	log := log.WithContext(ctx).WithField("reporter", "ServicefenceReconciler").WithField("function", "refreshSidecar")
 	sidecar, err := r.newSidecar(instance, r.env)
 	if err != nil {
 		log.Errorf("servicefence generate sidecar failed, %+v", err)
@@ -229,7 +279,7 @@ func (r *ServicefenceReconciler) refreshSidecar(instance *lazyloadv1alpha1.Servi
 	// Check if this Pod already exists
 	found := &networkingv1alpha3.Sidecar{}
 	nsName := types.NamespacedName{Name: sidecar.Name, Namespace: sidecar.Namespace}
-	err = r.Client.Get(context.TODO(), nsName, found)
+	err = r.Client.Get(ctx, nsName, found)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			found = nil
@@ -238,45 +288,85 @@ func (r *ServicefenceReconciler) refreshSidecar(instance *lazyloadv1alpha1.Servi
 		}
 	}
 
+	if instance.Spec.SidecarTemplate != nil {
+		desired, err := json.Marshal(&sidecar.Spec)
+		if err != nil {
+			return err
+		}
+		// keep the fields of the template modified by hand
+		var last string
+		if found != nil {
+			last = found.Annotations[AnnotationLastAppliedSidecar]
+		}
+		if last != "" {
+			merged, err := threeWayMergeSidecar([]byte(last), &sidecar.Spec, &found.Spec)
+			if err != nil {
+				log.Warnf("three-way merge sidecar %s failed, override it, %+v", nsName, err)
+			} else {
+				proto.Reset(&sidecar.Spec)
+				proto.Merge(&sidecar.Spec, merged)
+			}
+		}
+		sidecar.Annotations = map[string]string{AnnotationLastAppliedSidecar: string(desired)}
+	}
+
 	if found == nil {
 		log.Infof("Creating a new Sidecar in %s:%s", sidecar.Namespace, sidecar.Name)
-		err = r.Client.Create(context.TODO(), sidecar)
+		err = r.Client.Create(ctx, sidecar)
 		if err != nil {
 			SidecarFailedCreations.Increment()
+			r.recordEvent(instance, corev1.EventTypeWarning, "SidecarCreateFailed",
+				"create sidecar %s failed: %v", nsName, err)
 			return err
 		}
 		SidecarCreations.Increment()
+		r.recordEvent(instance, corev1.EventTypeNormal, "SidecarCreated", "created sidecar %s", nsName)
 	} else if foundRev := model.IstioRevFromLabel(found.Labels); !r.env.RevInScope(foundRev) {
 		log.Infof("existed sidecar %v istioRev %s but our rev %s, skip update ...",
 			nsName, foundRev, r.env.IstioRev())
-	} else if !proto.Equal(&found.Spec, &sidecar.Spec) || !reflect.DeepEqual(found.Labels, sidecar.Labels) {
+	} else if !proto.Equal(&found.Spec, &sidecar.Spec) || !reflect.DeepEqual(found.Labels, sidecar.Labels) ||
+		found.Annotations[AnnotationLastAppliedSidecar] != sidecar.Annotations[AnnotationLastAppliedSidecar] {
 		log.Infof("Update a Sidecar in %s:%s", sidecar.Namespace, sidecar.Name)
 		sidecar.ResourceVersion = found.ResourceVersion
-		err = r.Client.Update(context.TODO(), sidecar)
+		err = r.Client.Update(ctx, sidecar)
 		if err != nil {
+			r.recordEvent(instance, corev1.EventTypeWarning, "SidecarUpdateFailed",
+				"update sidecar %s failed: %v", nsName, err)
 			return err
 		}
 		SidecarRefreshes.Increment()
+		r.recordEvent(instance, corev1.EventTypeNormal, "SidecarRefreshed", "refreshed sidecar %s", nsName)
 	}
 	return nil
 }
 
-func (r *ServicefenceReconciler) updateServicefenceDomain(sf *lazyloadv1alpha1.ServiceFence) {
-	domains := r.genDomains(sf, r.doAliasRules)
+// updateServicefenceDomain updates the domains and the host budget in the status, `called` is the items of
+// metricStatus which are new or changed since last update.
+func (r *ServicefenceReconciler) updateServicefenceDomain(sf *lazyloadv1alpha1.ServiceFence, called map[string]bool) {
+	domains := r.genDomains(sf, r.doAliasRules, called)
 
 	for k, dest := range sf.Status.Domains {
 		if _, ok := domains[k]; !ok {
 			if dest.Status == lazyloadv1alpha1.Destinations_ACTIVE {
 				// active -> pending
 				domains[k] = &lazyloadv1alpha1.Destinations{
-					Hosts:  dest.Hosts,
-					Status: lazyloadv1alpha1.Destinations_EXPIREWAIT,
+					Hosts:          dest.Hosts,
+					Status:         lazyloadv1alpha1.Destinations_EXPIREWAIT,
+					RecentlyCalled: dest.RecentlyCalled,
 				}
 			}
 		}
 	}
 	sf.Status.Domains = domains
 
+	budget := genHostBudget(&sf.Spec, domains)
+	if budget.GetOverflow() && !sf.Status.HostBudget.GetOverflow() {
+		r.recordEvent(sf, corev1.EventTypeWarning, "HostBudgetOverflow",
+			"%d learned hosts exceed the max %d, apply %s", budget.GetLearnedHosts(),
+			sf.Spec.GetMaxLearnedHosts(), sf.Spec.GetOverflowPolicy())
+	}
+	sf.Status.HostBudget = budget
+
 	_ = r.Client.Status().Update(context.TODO(), sf)
 	ServiceFenceRefresh.Increment()
 }
@@ -284,12 +374,13 @@ func (r *ServicefenceReconciler) updateServicefenceDomain(sf *lazyloadv1alpha1.S
 func (r *ServicefenceReconciler) genDomains(
 	sf *lazyloadv1alpha1.ServiceFence,
 	rules []*domainAliasRule,
+	called map[string]bool,
 ) map[string]*lazyloadv1alpha1.Destinations {
 	domains := make(map[string]*lazyloadv1alpha1.Destinations)
 
 	addDomainsWithHost(domains, sf, r.nsSvcCache, rules)
 	addDomainsWithLabelSelector(domains, sf, r.labelSvcCache, rules)
-	addDomainsWithMetricStatus(domains, sf, rules)
+	addDomainsWithMetricStatus(domains, sf, rules, called)
 
 	return domains
 }
@@ -463,39 +554,51 @@ func addDomainsWithLabelSelector(domains map[string]*lazyloadv1alpha1.Destinatio
 	}
 }
 
-// update domains with Status.MetricStatus
+// update domains with Status.MetricStatus, the learned domains are marked with RecentlyCalled
 func addDomainsWithMetricStatus(
 	domains map[string]*lazyloadv1alpha1.Destinations,
 	sf *lazyloadv1alpha1.ServiceFence,
 	rules []*domainAliasRule,
+	called map[string]bool,
 ) {
+	now := time.Now()
 	for metricName := range sf.Status.MetricStatus {
-		metricName = strings.Trim(metricName, "{}")
-		if !strings.HasPrefix(metricName, "destination_service") && !strings.HasPrefix(metricName, "request_host") {
-			continue
-		}
-		// destination_service format like: "grafana.istio-system.svc.cluster.local"
-
-		var fullHost string
-		// trim ""
-		ss := strings.Split(metricName, "\"")
-		if len(ss) != 3 {
-			continue
-		}
-		// remove port
-		fullHost = strings.SplitN(ss[1], ":", 2)[0]
-
-		if !isValidHost(fullHost) {
+		fullHost := metricHost(metricName)
+		if fullHost == "" || !isValidHost(fullHost) {
 			continue
 		}
 
 		fullHosts := domainAddAlias(fullHost, rules)
 		for _, fh := range fullHosts {
+			if dest := domains[fh]; dest != nil {
+				// learned with another port
+				if isLearnedDomain(dest) && called[metricName] {
+					dest.RecentlyCalled = recentlyCalled(nil, true, now)
+				}
+				continue
+			}
 			addToDomains(domains, fh)
+			domains[fh].RecentlyCalled = recentlyCalled(sf.Status.Domains[fh], called[metricName], now)
 		}
 	}
 }
 
+// metricHost returns the host of the metric like `{destination_service="grafana.istio-system.svc.cluster.local"}`,
+// or empty if the metric is not about a host
+func metricHost(metricName string) string {
+	metricName = strings.Trim(metricName, "{}")
+	if !strings.HasPrefix(metricName, "destination_service") && !strings.HasPrefix(metricName, "request_host") {
+		return ""
+	}
+	// trim ""
+	ss := strings.Split(metricName, "\"")
+	if len(ss) != 3 {
+		return ""
+	}
+	// remove port
+	return strings.SplitN(ss[1], ":", 2)[0]
+}
+
 func (r *ServicefenceReconciler) newSidecar(
 	sf *lazyloadv1alpha1.ServiceFence,
 	env bootstrap.Environment,
@@ -519,19 +622,7 @@ func (r *ServicefenceReconciler) newSidecar(
 		}
 	}
 
-	for k, v := range sf.Status.Domains {
-		if v.Status == lazyloadv1alpha1.Destinations_ACTIVE || v.Status == lazyloadv1alpha1.Destinations_EXPIREWAIT {
-			if strings.HasSuffix(k, "/*") {
-				if !r.isDefaultAddNs(k) {
-					hosts = append(hosts, k)
-				}
-			}
-
-			for _, h := range v.Hosts {
-				hosts = append(hosts, "*/"+h)
-			}
-		}
-	}
+	hosts = append(hosts, budgetSidecarHosts(sf.Status.Domains, sf.Status.HostBudget, r.isDefaultAddNs)...)
 
 	// check whether using namespace global-sidecar
 	// if so, init config of sidecar will adds */global-sidecar.${svf.ns}.svc.cluster.local
@@ -604,6 +695,10 @@ func (r *ServicefenceReconciler) newSidecar(
 		sidecar.WorkloadSelector.Labels[env.Config.Global.Service] = sf.Name
 	}
 
+	if sf.Spec.SidecarTemplate != nil {
+		sidecar = mergeSidecarTemplate(sf.Spec.SidecarTemplate, sidecar)
+	}
+
 	ret := &networkingv1alpha3.Sidecar{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      sf.Name,
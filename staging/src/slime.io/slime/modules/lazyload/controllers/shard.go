@@ -0,0 +1,442 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"slime.io/slime/framework/model"
+	"slime.io/slime/framework/model/metric"
+	lazyloadv1alpha1 "slime.io/slime/modules/lazyload/api/v1alpha1"
+)
+
+const (
+	// MiscEnableShardedLearning enables learning the dependencies on all the replicas, the servicefences are
+	// sharded across the ready replicas by consistent hashing. Only works with the accesslog metric source.
+	MiscEnableShardedLearning = "enableShardedLearning"
+	// MiscShardCheckpointInterval is the interval of refreshing the replicas and checkpointing the
+	// dependencies learned for the servicefences owned by other replicas, 10s by default
+	MiscShardCheckpointInterval = "shardCheckpointInterval"
+
+	// AnnotationMetricStatusResetAt is the time the learned dependencies of the servicefence are reset at, every
+	// replica clears the ones it learned for the servicefence once it sees a newer one
+	AnnotationMetricStatusResetAt = "slime.io/metricStatusResetAt"
+
+	defaultShardCheckpointInterval = 10 * time.Second
+	shardVirtualNodes              = 64
+)
+
+// ShardOptions configures the sharded dependency learning.
+type ShardOptions struct {
+	// PodName and PodNamespace locate the pod of this replica, the replicas are the ready pods in the
+	// same namespace with the same `app` label.
+	PodName      string
+	PodNamespace string
+	// Interval of refreshing the replicas and checkpointing the learned dependencies
+	Interval time.Duration
+}
+
+type shardNode struct {
+	hash   uint32
+	member string
+}
+
+// ShardRing assigns the servicefences to the replicas by consistent hashing, so that only the servicefences
+// of the leaving or joining replicas are moved when the replicas change.
+type ShardRing struct {
+	self string
+
+	mut     sync.RWMutex
+	members []string
+	nodes   []shardNode
+}
+
+func NewShardRing(self string) *ShardRing {
+	return &ShardRing{self: self}
+}
+
+func shardHash(s string) uint32 {
+	sum := sha256.Sum256([]byte(s))
+	return binary.BigEndian.Uint32(sum[:4])
+}
+
+// SetMembers replaces the members of the ring, returns true if the members changed.
+func (r *ShardRing) SetMembers(members []string) bool {
+	set := make(map[string]struct{}, len(members))
+	sorted := make([]string, 0, len(members))
+	for _, m := range members {
+		if _, ok := set[m]; ok || m == "" {
+			continue
+		}
+		set[m] = struct{}{}
+		sorted = append(sorted, m)
+	}
+	sort.Strings(sorted)
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if stringsEqual(r.members, sorted) {
+		return false
+	}
+
+	nodes := make([]shardNode, 0, len(sorted)*shardVirtualNodes)
+	for _, m := range sorted {
+		for i := 0; i < shardVirtualNodes; i++ {
+			nodes = append(nodes, shardNode{hash: shardHash(m + "#" + strconv.Itoa(i)), member: m})
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].hash != nodes[j].hash {
+			return nodes[i].hash < nodes[j].hash
+		}
+		return nodes[i].member < nodes[j].member
+	})
+	r.members, r.nodes = sorted, nodes
+	return true
+}
+
+// Members returns the sorted members of the ring
+func (r *ShardRing) Members() []string {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+	return r.members
+}
+
+// Owner returns the member owning the key, or empty if there is no member.
+func (r *ShardRing) Owner(key string) string {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+	if len(r.nodes) == 0 {
+		return ""
+	}
+	h := shardHash(key)
+	i := sort.Search(len(r.nodes), func(i int) bool { return r.nodes[i].hash >= h })
+	if i == len(r.nodes) {
+		i = 0
+	}
+	return r.nodes[i].member
+}
+
+// Owns returns whether the key is owned by this replica. Nothing is owned before this replica
+// becomes a member, like when it's not ready yet.
+func (r *ShardRing) Owns(key string) bool {
+	return r.Owner(key) == r.self
+}
+
+func stringsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// shardResets tracks the resets of the servicefences seen by this replica.
+type shardResets struct {
+	mut sync.Mutex
+	// started is the time this replica starts learning, the resets before it are already honoured
+	// as the learned dependencies are loaded from the status
+	started time.Time
+	source  metric.Source
+	// mapping of the servicefence's namespaced name to the reset marker seen
+	seen map[string]string
+}
+
+func newShardResets() *shardResets {
+	return &shardResets{started: time.Now(), seen: map[string]string{}}
+}
+
+func (s *shardResets) setSource(source metric.Source) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.source = source
+}
+
+// mark records the reset marker set by this replica
+func (s *shardResets) mark(nn, marker string) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.seen[nn] = marker
+}
+
+// honour clears the dependencies learned by this replica for the servicefence if it's reset since last seen,
+// returns true if it's cleared.
+func (s *shardResets) honour(sf *lazyloadv1alpha1.ServiceFence) bool {
+	marker := sf.Annotations[AnnotationMetricStatusResetAt]
+	if marker == "" {
+		return false
+	}
+	nn := types.NamespacedName{Namespace: sf.Namespace, Name: sf.Name}.String()
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if s.seen[nn] == marker {
+		return false
+	}
+	s.seen[nn] = marker
+	if at, err := time.Parse(time.RFC3339Nano, marker); err == nil && at.Before(s.started) {
+		return false
+	}
+	if s.source != nil {
+		if err := s.source.Reset(nn); err != nil {
+			log.Errorf("reset learned dependencies of %s failed: %v", nn, err)
+		}
+	}
+	log.Infof("learned dependencies of %s are reset at %s", nn, marker)
+	return true
+}
+
+// sharded returns whether the dependency learning is sharded across the replicas
+func (r *ServicefenceReconciler) sharded() bool {
+	return r.shardRing != nil
+}
+
+// StartShard refreshes the replicas and checkpoints the dependencies learned for the servicefences owned by
+// other replicas periodically, until the ctx is done. A final checkpoint is made before returning so the
+// dependencies are not lost when this replica leaves.
+func (r *ServicefenceReconciler) StartShard(ctx context.Context, source metric.Source) {
+	log := log.WithField("reporter", "ServicefenceReconciler").WithField("function", "StartShard")
+	r.shardResets.setSource(source)
+	go func() {
+		first := make(chan struct{}, 1)
+		first <- struct{}{}
+		ticker := time.NewTicker(r.shardOpts.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				log.Infof("ctx is done, checkpoint before return")
+				finalCtx, cancel := context.WithTimeout(context.Background(), r.shardOpts.Interval)
+				r.checkpoint(finalCtx, source, true)
+				cancel()
+				return
+			case <-first:
+			case <-ticker.C:
+			}
+			if err := r.refreshShardMembers(ctx); err != nil {
+				log.Errorf("refresh shard members failed: %v", err)
+			}
+			r.checkpoint(ctx, source, false)
+		}
+	}()
+}
+
+func (r *ServicefenceReconciler) refreshShardMembers(ctx context.Context) error {
+	if r.shardSelector == nil {
+		self, err := r.env.K8SClient.CoreV1().Pods(r.shardOpts.PodNamespace).
+			Get(ctx, r.shardOpts.PodName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		r.shardSelector = labels.SelectorFromSet(labels.Set{"app": self.Labels["app"]})
+	}
+	pods, err := r.env.K8SClient.CoreV1().Pods(r.shardOpts.PodNamespace).List(ctx,
+		metav1.ListOptions{LabelSelector: r.shardSelector.String()})
+	if err != nil {
+		return err
+	}
+
+	var members []string
+	for i := range pods.Items {
+		if pod := &pods.Items[i]; pod.DeletionTimestamp == nil && isPodReady(pod) {
+			members = append(members, pod.Name)
+		}
+	}
+	if r.shardRing.SetMembers(members) {
+		log.Infof("shard members changed to %v", r.shardRing.Members())
+		ShardMembers.Record(float64(len(members)))
+	}
+	return nil
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// ownedFences returns the enabled servicefences owned by this replica
+func (r *ServicefenceReconciler) ownedFences(ctx context.Context) []string {
+	sfs := &lazyloadv1alpha1.ServiceFenceList{}
+	if err := r.Client.List(ctx, sfs); err != nil {
+		log.Errorf("list servicefences failed: %v", err)
+		return nil
+	}
+	var ret []string
+	for i := range sfs.Items {
+		sf := &sfs.Items[i]
+		nn := types.NamespacedName{Namespace: sf.Namespace, Name: sf.Name}.String()
+		if sf.Spec.Enable && r.env.RevInScope(model.IstioRevFromLabel(sf.Labels)) && r.shardRing.Owns(nn) {
+			ret = append(ret, nn)
+		}
+	}
+	return ret
+}
+
+// checkpoint patches the dependencies learned by this replica but missing in the status of the servicefences
+// owned by other replicas, or all the servicefences if `all` is true. The patch only adds the missing ones,
+// so it does not conflict with the writes of the owner or other replicas.
+func (r *ServicefenceReconciler) checkpoint(ctx context.Context, source metric.Source, all bool) {
+	log := log.WithField("reporter", "ServicefenceReconciler").WithField("function", "checkpoint")
+
+	sfs := &lazyloadv1alpha1.ServiceFenceList{}
+	if err := r.Client.List(ctx, sfs); err != nil {
+		log.Errorf("list servicefences failed: %v", err)
+		return
+	}
+	fences := make(map[string]*lazyloadv1alpha1.ServiceFence, len(sfs.Items))
+	qm := metric.QueryMap{}
+	for i := range sfs.Items {
+		sf := &sfs.Items[i]
+		nn := types.NamespacedName{Namespace: sf.Namespace, Name: sf.Name}.String()
+		if !sf.Spec.Enable || !r.env.RevInScope(model.IstioRevFromLabel(sf.Labels)) {
+			continue
+		}
+		// the owned ones are honoured in refreshing as well, but they may not be refreshed for a while
+		if r.shardResets.honour(sf) || (!all && r.shardRing.Owns(nn)) {
+			continue
+		}
+		fences[nn] = sf
+		qm[nn] = []metric.Handler{{Name: AccessLogConvertorName}}
+	}
+	if len(qm) == 0 {
+		return
+	}
+
+	learned, err := source.QueryMetric(qm)
+	if err != nil {
+		log.Errorf("query learned metric failed: %v", err)
+		return
+	}
+	for nn, results := range learned {
+		if len(results) != 1 {
+			continue
+		}
+		sf := fences[nn]
+		missing := missingMetricStatus(sf.Status.MetricStatus, results[0].Value)
+		if len(missing) == 0 {
+			continue
+		}
+		// the resource version fails the patch if the servicefence is reset after listing
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{"resourceVersion": sf.ResourceVersion},
+			"status":   map[string]interface{}{"metricStatus": missing},
+		})
+		if err != nil {
+			log.Errorf("marshal checkpoint of %s failed: %v", nn, err)
+			continue
+		}
+		if err := r.Client.Status().Patch(ctx, sf, client.RawPatch(types.MergePatchType, patch)); err != nil {
+			log.Errorf("checkpoint %d dependencies of %s failed: %v", len(missing), nn, err)
+			continue
+		}
+		ShardCheckpoints.Increment()
+		log.Debugf("checkpoint dependencies of %s: %v", nn, missing)
+	}
+}
+
+// missingMetricStatus returns the learned items not in the status
+func missingMetricStatus(status, learned map[string]string) map[string]string {
+	var missing map[string]string
+	for k, v := range learned {
+		if _, ok := status[k]; ok {
+			continue
+		}
+		if missing == nil {
+			missing = map[string]string{}
+		}
+		missing[k] = v
+	}
+	return missing
+}
+
+// mergeMetricStatus returns the learned items with the ones checkpointed to the status by other replicas
+func mergeMetricStatus(status, learned map[string]string) map[string]string {
+	ret := make(map[string]string, len(status)+len(learned))
+	for k, v := range status {
+		ret[k] = v
+	}
+	for k, v := range learned {
+		ret[k] = v
+	}
+	return ret
+}
+
+// ResetMetricStatus clears the learned dependencies in the status of the servicefence `ns/name`, or all
+// the servicefences in the namespace if the name is empty. It's used when the learning is sharded, as the
+// dependencies checkpointed by other replicas are kept otherwise. The servicefences are marked with the
+// reset time, so that the other replicas clear the dependencies they learned as well.
+func (r *ServicefenceReconciler) ResetMetricStatus(info string) error {
+	if !r.sharded() {
+		return nil
+	}
+	nn := types.NamespacedName{}
+	if parts := strings.SplitN(info, "/", 2); len(parts) == 2 {
+		nn.Namespace, nn.Name = parts[0], parts[1]
+	}
+
+	ctx := context.TODO()
+	var sfs []*lazyloadv1alpha1.ServiceFence
+	if nn.Name != "" {
+		sf := &lazyloadv1alpha1.ServiceFence{}
+		if err := r.Client.Get(ctx, nn, sf); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		sfs = append(sfs, sf)
+	} else {
+		list := &lazyloadv1alpha1.ServiceFenceList{}
+		if err := r.Client.List(ctx, list, client.InNamespace(nn.Namespace)); err != nil {
+			return err
+		}
+		for i := range list.Items {
+			sfs = append(sfs, &list.Items[i])
+		}
+	}
+
+	r.reconcileLock.Lock()
+	defer r.reconcileLock.Unlock()
+	marker := time.Now().Format(time.RFC3339Nano)
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{AnnotationMetricStatusResetAt: marker},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	for _, sf := range sfs {
+		nn := types.NamespacedName{Namespace: sf.Namespace, Name: sf.Name}.String()
+		// this replica is reset by the caller
+		r.shardResets.mark(nn, marker)
+		if err := r.Client.Patch(ctx, sf, client.RawPatch(types.MergePatchType, patch)); err != nil {
+			return err
+		}
+		if len(sf.Status.MetricStatus) == 0 {
+			continue
+		}
+		sf.Status.MetricStatus = nil
+		if err := r.Client.Status().Update(ctx, sf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"slime.io/slime/framework/model/metric"
+	lazyloadv1alpha1 "slime.io/slime/modules/lazyload/api/v1alpha1"
+)
+
+func TestShardRing(t *testing.T) {
+	ring := NewShardRing("slime-0")
+	if ring.Owns("default/foo") {
+		t.Fatalf("owns key without members")
+	}
+	if !ring.SetMembers([]string{"slime-2", "slime-0", "slime-1", "slime-0"}) {
+		t.Fatalf("members not changed")
+	}
+	if ring.SetMembers([]string{"slime-1", "slime-2", "slime-0"}) {
+		t.Fatalf("members changed with the same members")
+	}
+
+	keys := make([]string, 0, 3000)
+	for i := 0; i < cap(keys); i++ {
+		keys = append(keys, fmt.Sprintf("ns-%d/svc-%d", i%10, i))
+	}
+	owners := map[string]string{}
+	counts := map[string]int{}
+	for _, key := range keys {
+		owner := ring.Owner(key)
+		owners[key] = owner
+		counts[owner]++
+		if ring.Owns(key) != (owner == "slime-0") {
+			t.Fatalf("owns %s mismatch owner %s", key, owner)
+		}
+	}
+	for _, member := range ring.Members() {
+		// roughly balanced
+		if counts[member] < len(keys)/6 {
+			t.Errorf("member %s owns %d of %d keys", member, counts[member], len(keys))
+		}
+	}
+
+	// only the keys of the leaving member are moved
+	ring.SetMembers([]string{"slime-0", "slime-1"})
+	for _, key := range keys {
+		if owner := ring.Owner(key); owners[key] != "slime-2" && owner != owners[key] {
+			t.Fatalf("key %s moved from %s to %s", key, owners[key], owner)
+		}
+	}
+}
+
+func TestMetricStatusMerge(t *testing.T) {
+	status := map[string]string{`{destination_service="a"}`: "1", `{destination_service="b"}`: "2"}
+	learned := map[string]string{`{destination_service="b"}`: "5", `{destination_service="c"}`: "1"}
+
+	missing := missingMetricStatus(status, learned)
+	if expect := map[string]string{`{destination_service="c"}`: "1"}; !reflect.DeepEqual(expect, missing) {
+		t.Errorf("expect missing %v, got %v", expect, missing)
+	}
+	if missing = missingMetricStatus(status, map[string]string{`{destination_service="a"}`: "1"}); missing != nil {
+		t.Errorf("expect no missing, got %v", missing)
+	}
+
+	merged := mergeMetricStatus(status, learned)
+	expect := map[string]string{
+		`{destination_service="a"}`: "1",
+		`{destination_service="b"}`: "5",
+		`{destination_service="c"}`: "1",
+	}
+	if !reflect.DeepEqual(expect, merged) {
+		t.Errorf("expect merged %v, got %v", expect, merged)
+	}
+}
+
+type resetRecordingSource struct {
+	metric.Source
+	resets []string
+}
+
+func (s *resetRecordingSource) Reset(info string) error {
+	s.resets = append(s.resets, info)
+	return nil
+}
+
+func TestShardResetsHonour(t *testing.T) {
+	source := &resetRecordingSource{}
+	resets := newShardResets()
+	resets.setSource(source)
+	sf := &lazyloadv1alpha1.ServiceFence{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}
+
+	if resets.honour(sf) {
+		t.Fatalf("reset without marker")
+	}
+	// reset before this replica starts, the learned ones are loaded from the cleared status
+	sf.Annotations = map[string]string{
+		AnnotationMetricStatusResetAt: resets.started.Add(-time.Second).Format(time.RFC3339Nano),
+	}
+	if resets.honour(sf) {
+		t.Fatalf("reset with marker before started")
+	}
+
+	sf.Annotations[AnnotationMetricStatusResetAt] = resets.started.Add(time.Second).Format(time.RFC3339Nano)
+	if !resets.honour(sf) || !reflect.DeepEqual(source.resets, []string{"default/foo"}) {
+		t.Fatalf("expect reset once, got %v", source.resets)
+	}
+	if resets.honour(sf) {
+		t.Fatalf("reset again with the seen marker")
+	}
+
+	// marked by this replica
+	marker := resets.started.Add(2 * time.Second).Format(time.RFC3339Nano)
+	resets.mark("default/foo", marker)
+	sf.Annotations[AnnotationMetricStatusResetAt] = marker
+	if resets.honour(sf) || len(source.resets) != 1 {
+		t.Errorf("reset with the marker set by self, got %v", source.resets)
+	}
+}
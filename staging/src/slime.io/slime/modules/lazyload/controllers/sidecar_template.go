@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"encoding/json"
+	"sort"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"google.golang.org/protobuf/proto"
+	networkingapi "istio.io/api/networking/v1alpha3"
+	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
+)
+
+// AnnotationLastAppliedSidecar records the sidecar spec rendered with the sidecar template
+// last time, which is the base of the three-way merge with the live sidecar.
+const AnnotationLastAppliedSidecar = "slime.io/lastAppliedSidecar"
+
+// mergeSidecarTemplate merges the generated sidecar into the template. The workload selector
+// is always the generated one, and the learned hosts are merged into the hosts of the egress
+// listener without port, which is added if the template does not have one.
+func mergeSidecarTemplate(tmpl, generated *networkingapi.Sidecar) *networkingapi.Sidecar {
+	ret := proto.Clone(tmpl).(*networkingapi.Sidecar)
+	ret.WorkloadSelector = generated.WorkloadSelector
+
+	var learned *networkingapi.IstioEgressListener
+	if len(generated.Egress) > 0 {
+		learned = generated.Egress[0]
+	}
+	if learned == nil {
+		return ret
+	}
+	for _, egress := range ret.Egress {
+		if egress.Port == nil {
+			egress.Hosts = mergeHosts(egress.Hosts, learned.Hosts)
+			return ret
+		}
+	}
+	// istio requires the egress listener without port to be the last one
+	ret.Egress = append(ret.Egress, proto.Clone(learned).(*networkingapi.IstioEgressListener))
+	return ret
+}
+
+// mergeHosts returns the sorted union of the hosts, so that it follows the Equals semantics
+func mergeHosts(static, learned []string) []string {
+	set := make(map[string]struct{}, len(static)+len(learned))
+	hosts := make([]string, 0, len(static)+len(learned))
+	for _, list := range [][]string{static, learned} {
+		for _, h := range list {
+			if _, ok := set[h]; !ok {
+				set[h] = struct{}{}
+				hosts = append(hosts, h)
+			}
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// threeWayMergeSidecar applies the changes between the last applied and the desired spec to
+// the live one, so the fields modified by hand survive as long as the template does not
+// change them. The workload selector and the egress listeners are owned by the controller
+// and always set to the desired ones.
+func threeWayMergeSidecar(lastApplied []byte, desired, live *networkingapi.Sidecar) (*networkingapi.Sidecar, error) {
+	desiredJSON, err := json.Marshal(desired)
+	if err != nil {
+		return nil, err
+	}
+	liveJSON, err := json.Marshal(live)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := jsonmergepatch.CreateThreeWayJSONMergePatch(lastApplied, desiredJSON, liveJSON)
+	if err != nil {
+		return nil, err
+	}
+	merged, err := jsonpatch.MergePatch(liveJSON, patch)
+	if err != nil {
+		return nil, err
+	}
+	ret := &networkingapi.Sidecar{}
+	if err := json.Unmarshal(merged, ret); err != nil {
+		return nil, err
+	}
+	ret.WorkloadSelector = desired.WorkloadSelector
+	ret.Egress = desired.Egress
+	return ret, nil
+}
@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	networkingapi "istio.io/api/networking/v1alpha3"
+)
+
+func TestMergeSidecarTemplate(t *testing.T) {
+	generated := &networkingapi.Sidecar{
+		WorkloadSelector: &networkingapi.WorkloadSelector{Labels: map[string]string{"app": "foo"}},
+		Egress:           []*networkingapi.IstioEgressListener{{Hosts: []string{"*/b.default.svc.cluster.local", "istio-system/*"}}},
+	}
+	registryOnly := &networkingapi.OutboundTrafficPolicy{Mode: networkingapi.OutboundTrafficPolicy_REGISTRY_ONLY}
+	portEgress := &networkingapi.IstioEgressListener{
+		Port:  &networkingapi.Port{Number: 3306, Protocol: "TCP", Name: "mysql"},
+		Hosts: []string{"db/*"},
+	}
+
+	cases := []struct {
+		name string
+		tmpl *networkingapi.Sidecar
+		want *networkingapi.Sidecar
+	}{
+		{
+			name: "merge static hosts",
+			tmpl: &networkingapi.Sidecar{
+				WorkloadSelector:      &networkingapi.WorkloadSelector{Labels: map[string]string{"app": "bar"}},
+				OutboundTrafficPolicy: registryOnly,
+				Egress: []*networkingapi.IstioEgressListener{
+					portEgress,
+					{Hosts: []string{"istio-system/*", "*/a.default.svc.cluster.local"}},
+				},
+			},
+			want: &networkingapi.Sidecar{
+				WorkloadSelector:      generated.WorkloadSelector,
+				OutboundTrafficPolicy: registryOnly,
+				Egress: []*networkingapi.IstioEgressListener{
+					portEgress,
+					{Hosts: []string{"*/a.default.svc.cluster.local", "*/b.default.svc.cluster.local", "istio-system/*"}},
+				},
+			},
+		},
+		{
+			name: "append learned egress",
+			tmpl: &networkingapi.Sidecar{
+				OutboundTrafficPolicy: registryOnly,
+				Egress:                []*networkingapi.IstioEgressListener{portEgress},
+			},
+			want: &networkingapi.Sidecar{
+				WorkloadSelector:      generated.WorkloadSelector,
+				OutboundTrafficPolicy: registryOnly,
+				Egress:                []*networkingapi.IstioEgressListener{portEgress, generated.Egress[0]},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tmpl := proto.Clone(c.tmpl)
+			got := mergeSidecarTemplate(c.tmpl, generated)
+			if !proto.Equal(got, c.want) {
+				t.Errorf("want %v, got %v", c.want, got)
+			}
+			if !proto.Equal(tmpl, c.tmpl) {
+				t.Errorf("template is modified")
+			}
+		})
+	}
+}
+
+func TestThreeWayMergeSidecar(t *testing.T) {
+	selector := &networkingapi.WorkloadSelector{Labels: map[string]string{"app": "foo"}}
+	egress := func(hosts ...string) []*networkingapi.IstioEgressListener {
+		return []*networkingapi.IstioEgressListener{{Hosts: hosts}}
+	}
+	policy := func(mode networkingapi.OutboundTrafficPolicy_Mode) *networkingapi.OutboundTrafficPolicy {
+		return &networkingapi.OutboundTrafficPolicy{Mode: mode}
+	}
+
+	lastApplied := &networkingapi.Sidecar{
+		WorkloadSelector:      selector,
+		OutboundTrafficPolicy: policy(networkingapi.OutboundTrafficPolicy_REGISTRY_ONLY),
+		Egress:                egress("*/a"),
+	}
+	// modified by hand
+	live := &networkingapi.Sidecar{
+		WorkloadSelector:      selector,
+		OutboundTrafficPolicy: policy(networkingapi.OutboundTrafficPolicy_ALLOW_ANY),
+		Ingress:               []*networkingapi.IstioIngressListener{{Port: &networkingapi.Port{Number: 8080, Protocol: "HTTP", Name: "http"}}},
+		Egress:                egress("*/a", "*/manual"),
+	}
+	last, err := json.Marshal(lastApplied)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the learned hosts changed, the fields modified by hand are kept except the egress
+	desired := &networkingapi.Sidecar{
+		WorkloadSelector:      selector,
+		OutboundTrafficPolicy: policy(networkingapi.OutboundTrafficPolicy_REGISTRY_ONLY),
+		Egress:                egress("*/a", "*/b"),
+	}
+	got, err := threeWayMergeSidecar(last, desired, live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &networkingapi.Sidecar{
+		WorkloadSelector:      selector,
+		OutboundTrafficPolicy: policy(networkingapi.OutboundTrafficPolicy_ALLOW_ANY),
+		Ingress:               live.Ingress,
+		Egress:                egress("*/a", "*/b"),
+	}
+	if !proto.Equal(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	// the template field changed, which overrides the one modified by hand
+	desired.OutboundTrafficPolicy = nil
+	if got, err = threeWayMergeSidecar(last, desired, live); err != nil {
+		t.Fatal(err)
+	}
+	want.OutboundTrafficPolicy = nil
+	if !proto.Equal(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
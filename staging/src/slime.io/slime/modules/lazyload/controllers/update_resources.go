@@ -9,7 +9,6 @@ import (
 	"strings"
 	"sync"
 
-	"github.com/buger/jsonparser"
 	"helm.sh/helm/v3/pkg/chart"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -21,6 +20,7 @@ import (
 	config "slime.io/slime/framework/apis/config/v1alpha1"
 	"slime.io/slime/framework/bootstrap"
 	"slime.io/slime/framework/model"
+	"slime.io/slime/framework/model/slimeboot"
 	"slime.io/slime/modules/lazyload/charts"
 	"slime.io/slime/modules/lazyload/pkg/helm"
 	"slime.io/slime/modules/lazyload/pkg/kube"
@@ -172,7 +172,7 @@ func generateValuesFormSlimeboot(
 	env *bootstrap.Environment,
 ) (*config.SlimeBoot, map[string]interface{}, error) {
 	// Deserialize to config.SlimeBoot
-	specJson, slimeBoot, err := getSlimeboot(env)
+	rawSpec, slimeBoot, err := getSlimeboot(env)
 	if err != nil {
 		return nil, nil, fmt.Errorf("get slimeboot error: %v", err)
 	}
@@ -194,26 +194,6 @@ func generateValuesFormSlimeboot(
 		return nil, nil, fmt.Errorf("marshal slimeboot spec error: %v", err)
 	}
 
-	// Insert general and general.wormholeport into general
-	if len(wormholePort) > 0 {
-		var pos string
-		wp, err := json.Marshal(wormholePort)
-		if err != nil {
-			return nil, nil, fmt.Errorf("marshal wormholePort err %s", err)
-		}
-
-		for idx := range slimeBoot.Spec.Module {
-			if slimeBoot.Spec.Module[idx].Kind == "lazyload" {
-				pos = fmt.Sprintf("[%d]", idx)
-				break
-			}
-		}
-		spec, err = patchSlimeboot(spec, wp, specJson, pos)
-		if err != nil {
-			return nil, nil, fmt.Errorf("patch slimeboot err %s", err)
-		}
-	}
-
 	// Deserialize values to map[string]interface{}
 	values := make(map[string]interface{})
 	err = json.Unmarshal(spec, &values)
@@ -221,40 +201,35 @@ func generateValuesFormSlimeboot(
 		log.Errorf("unmarshal result to values err %s", err)
 		return nil, nil, err
 	}
+
+	// Insert general and general.wormholeport into general
+	if len(wormholePort) > 0 {
+		if err := patchSlimeboot(values, rawSpec, wormholePort); err != nil {
+			return nil, nil, fmt.Errorf("patch slimeboot err %s", err)
+		}
+	}
 	log.Debugf("get slimeboot values %+v", values)
 
 	return slimeBoot, values, nil
 }
 
-func patchSlimeboot(spec, wp []byte, specRaw, pos string) ([]byte, error) {
-	general, _, _, err := jsonparser.Get([]byte(specRaw), "module", pos, "general")
-	if err != nil {
-		return nil, fmt.Errorf("get slimeboot module%s.general err %s", pos, err)
+func patchSlimeboot(values, rawSpec map[string]interface{}, wormholePort []string) error {
+	lazyload := slimeboot.ModuleKind("lazyload")
+	general, found := slimeboot.ModuleField(rawSpec, lazyload, "general")
+	if !found {
+		return fmt.Errorf("slimeboot module general of lazyload not found")
 	}
-	log.Debugf("get raw slimeboot module%s.general: %s", pos, general)
+	log.Debugf("get raw slimeboot module general: %v", general)
 
-	// set general and general.wormholeport into general
-	spec, err = jsonparser.Set(spec, general, "module", pos, "general")
-	if err != nil {
-		return nil, fmt.Errorf("set slimeboot general get err %s", err)
-	}
-	log.Debugf("set slimeboot spec.module%s.general : %s succeed", pos, general)
-
-	spec, err = jsonparser.Set(spec, wp, "module", pos, "general", "wormholePort")
-	if err != nil {
-		return nil, fmt.Errorf("set slimeboot general wormholePort get err %s", err)
-	}
-	log.Debugf("set slimeboot spec.module%s.general.wormholePort: %s succeed", pos, wp)
-
-	// set general.render into general
-	spec, err = jsonparser.Set(spec, []byte(`"lazyload"`), "module", pos, "general", "render")
-	if err != nil {
-		return nil, fmt.Errorf("set slimeboot general render get err %s", err)
-	}
-	return spec, nil
+	// set general, general.wormholePort and general.render into general
+	return slimeboot.NewPatch().
+		Set(lazyload, general, "general").
+		Set(lazyload, wormholePort, "general", "wormholePort").
+		Set(lazyload, "lazyload", "general", "render").
+		Apply(values)
 }
 
-func getSlimeboot(env *bootstrap.Environment) (string, *config.SlimeBoot, error) {
+func getSlimeboot(env *bootstrap.Environment) (map[string]interface{}, *config.SlimeBoot, error) {
 	slimeBootNs := os.Getenv("WATCH_NAMESPACE")
 	deployName := strings.Split(os.Getenv("POD_NAME"), "-")[0]
 
@@ -264,22 +239,21 @@ func getSlimeboot(env *bootstrap.Environment) (string, *config.SlimeBoot, error)
 		utd, err = getSlimebootByLabelSelector(slimeBootNs, deployName, env)
 		if err != nil {
 			log.Infof("get slimeboot by labelselector failed with %q", err)
-			return "", nil, fmt.Errorf("try to get slimeboot in namespace %s failed", slimeBootNs)
+			return nil, nil, fmt.Errorf("try to get slimeboot in namespace %s failed", slimeBootNs)
 		}
 	}
 	// Unstructured -> SlimeBoot
-	res := utd.UnstructuredContent()
 	var slimeBoot config.SlimeBoot
-	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(res, &slimeBoot); err != nil {
-		return "", nil, fmt.Errorf("convert slimeboot %s/%s to structured error: %v", slimeBootNs, utd.GetName(), err)
+	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(utd.UnstructuredContent(), &slimeBoot); err != nil {
+		return nil, nil, fmt.Errorf("convert slimeboot %s/%s to structured error: %v", slimeBootNs, utd.GetName(), err)
 	}
-	raw, err := json.Marshal(res["spec"])
+	raw, _, err := unstructured.NestedMap(utd.Object, "spec")
 	if err != nil {
-		return "", nil, fmt.Errorf("marshal slimeboot %s/%s error: %v", slimeBootNs, utd.GetName(), err)
+		return nil, nil, fmt.Errorf("get slimeboot %s/%s spec error: %v", slimeBootNs, utd.GetName(), err)
 	}
 
-	log.Debugf("get raw slimeboot spec: %s", string(raw))
-	return string(raw), &slimeBoot, nil
+	log.Debugf("get raw slimeboot spec: %v", raw)
+	return raw, &slimeBoot, nil
 }
 
 func getSlimebootByOwnerRef(
@@ -66,11 +66,24 @@ func (m *Module) Setup(opts module.ModuleOptions) error {
 	if err != nil {
 		return fmt.Errorf("unable to create ProducerConfig, %+v", err)
 	}
-	sfReconciler := controllers.NewReconciler(
+
+	podNs := os.Getenv("WATCH_NAMESPACE")
+	podName := os.Getenv("POD_NAME")
+
+	reconcilerOpts := []controllers.ReconcilerOpts{
 		controllers.ReconcilerWithCfg(&m.config),
 		controllers.ReconcilerWithEnv(env),
 		controllers.ReconcilerWithProducerConfig(pc),
-	)
+		controllers.ReconcilerWithEventRecorder(opts.EventRecorder),
+	}
+	sharded, err := shardedLearning(env.Config.GetGlobal().GetMisc(), m.config.MetricSourceType, podName, podNs)
+	if err != nil {
+		return err
+	}
+	if sharded != nil {
+		reconcilerOpts = append(reconcilerOpts, controllers.ReconcilerWithShard(*sharded))
+	}
+	sfReconciler := controllers.NewReconciler(reconcilerOpts...)
 	sfReconciler.Client = mgr.GetClient()
 	sfReconciler.Scheme = mgr.GetScheme()
 
@@ -78,9 +91,6 @@ func (m *Module) Setup(opts module.ModuleOptions) error {
 		sfReconciler.RegisterSeHandler()
 	}
 
-	podNs := os.Getenv("WATCH_NAMESPACE")
-	podName := os.Getenv("POD_NAME")
-
 	opts.InitCbs.AddStartup(func(ctx context.Context) {
 		sfReconciler.StartCache(ctx)
 		if env.Config.Global != nil && env.Config.Global.Misc["enableLeaderElection"] == "on" {
@@ -103,6 +113,7 @@ func (m *Module) Setup(opts module.ModuleOptions) error {
 	handler := &server.Handler{
 		HttpPathHandler: env.HttpPathHandler,
 		Source:          source,
+		ResetStatus:     sfReconciler.ResetMetricStatus,
 	}
 	svfResetRegister(handler)
 
@@ -121,8 +132,9 @@ func (m *Module) Setup(opts module.ModuleOptions) error {
 		})
 		// use FenceLabelKeyAlias ad switch to turn on/off workload fence
 		if m.config.FenceLabelKeyAlias != "" {
-			podController := sfReconciler.NewPodController(env.K8SClient, m.config.FenceLabelKeyAlias)
 			le.AddOnStartedLeading(func(ctx context.Context) {
+				// the informer can not be restarted, create a new one for each leader term
+				podController := sfReconciler.NewPodController(env.K8SClient, m.config.FenceLabelKeyAlias)
 				go podController.Run(ctx.Done())
 			})
 		}
@@ -144,21 +156,37 @@ func (m *Module) Setup(opts module.ModuleOptions) error {
 		return fmt.Errorf("unable to create controller,%+v", err)
 	}
 
-	le.AddOnStartedLeading(func(ctx context.Context) {
-		log.Infof("retrieve metric from svf status.metric")
-		cache, err := controllers.NewCache(env)
-		if err != nil {
-			log.Warnf("GetCacheFromServicefence occured err in StartedLeading: %s", err)
-			return
-		}
-		_ = source.Fullfill(cache)
-		log.Debugf("GetCacheFromServicefence is %+v", cache)
-	})
+	if sharded != nil {
+		// all the replicas learn the dependencies of the servicefences they own, and checkpoint
+		// the ones of others to the status
+		log.Infof("dependency learning is sharded across the replicas")
+		opts.InitCbs.AddStartup(func(ctx context.Context) {
+			startPc := *pc
+			startPc.StopChan = ctx.Done()
+			metric.NewProducer(&startPc, source)
+			go sfReconciler.WatchMetric(ctx)
+			sfReconciler.StartShard(ctx, source)
+		})
+	} else {
+		le.AddOnStartedLeading(func(ctx context.Context) {
+			log.Infof("retrieve metric from svf status.metric")
+			cache, err := controllers.NewCache(env)
+			if err != nil {
+				log.Warnf("GetCacheFromServicefence occured err in StartedLeading: %s", err)
+				return
+			}
+			_ = source.Fullfill(cache)
+			log.Debugf("GetCacheFromServicefence is %+v", cache)
+		})
 
-	le.AddOnStartedLeading(func(ctx context.Context) {
-		log.Infof("producers starts")
-		metric.NewProducer(pc, source)
-	})
+		le.AddOnStartedLeading(func(ctx context.Context) {
+			log.Infof("producers starts")
+			// producers are stopped at the end of the leader term
+			termPc := *pc
+			termPc.StopChan = ctx.Done()
+			metric.NewProducer(&termPc, source)
+		})
+	}
 
 	if m.config.AutoPort {
 		le.AddOnStartedLeading(func(ctx context.Context) {
@@ -166,7 +194,10 @@ func (m *Module) Setup(opts module.ModuleOptions) error {
 		})
 	}
 
-	if env.Config.Metric != nil ||
+	// watching metric is started on all the replicas if the learning is sharded
+	if sharded != nil {
+		log.Debugf("metric is watched by all the replicas")
+	} else if env.Config.Metric != nil ||
 		m.config.MetricSourceType == controllers.MetricSourceTypeAccesslog {
 		le.AddOnStartedLeading(func(ctx context.Context) {
 			go sfReconciler.WatchMetric(ctx)
@@ -212,8 +243,32 @@ func (m *Module) Setup(opts module.ModuleOptions) error {
 	return nil
 }
 
+// shardedLearning returns the options of the sharded dependency learning, or nil if it's not enabled.
+// The replica must know its pod, or it owns no servicefence and the dependencies learned for them are lost.
+func shardedLearning(misc map[string]string, metricSourceType, podName, podNs string) (*controllers.ShardOptions, error) {
+	if misc[controllers.MiscEnableShardedLearning] != "on" {
+		return nil, nil
+	}
+	if metricSourceType != controllers.MetricSourceTypeAccesslog {
+		log.Warnf("%s only works with the accesslog metric source, ignore it", controllers.MiscEnableShardedLearning)
+		return nil, nil
+	}
+	if podName == "" || podNs == "" {
+		return nil, fmt.Errorf("%s requires the env POD_NAME and WATCH_NAMESPACE", controllers.MiscEnableShardedLearning)
+	}
+	opts := &controllers.ShardOptions{PodName: podName, PodNamespace: podNs}
+	if s := misc[controllers.MiscShardCheckpointInterval]; s != "" {
+		interval, err := time.ParseDuration(s)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid %s %q", controllers.MiscShardCheckpointInterval, s)
+		}
+		opts.Interval = interval
+	}
+	return opts, nil
+}
+
 func svfResetRegister(handler *server.Handler) {
-	handler.HandleFunc("/debug/svfReset", handler.SvfResetSetting)
+	handler.HandleWriteFunc("/debug/svfReset", handler.SvfResetSetting)
 }
 
 func deleteLeaderLabelUntilSucceed(client *kubernetes.Clientset, podNs, podName string) {
@@ -0,0 +1,92 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultRecentFallbacks = 256
+
+// FallbackRecord is a request or connection forwarded by the proxy
+type FallbackRecord struct {
+	Time time.Time `json:"time"`
+	// Protocol is http, tcp or tls
+	Protocol        string `json:"protocol"`
+	SourceNamespace string `json:"sourceNamespace,omitempty"`
+	Host            string `json:"host"`
+	// Dest is the address the request is forwarded to
+	Dest     string `json:"dest"`
+	OrigDest bool   `json:"origDest,omitempty"`
+	Code     int    `json:"code,omitempty"`
+	Error    string `json:"error,omitempty"`
+	// Duration in milliseconds, of forwarding the http request, or connecting to the upstream for tcp and tls
+	Duration float64 `json:"duration"`
+}
+
+// FallbackRecorder keeps the recent fallbacks in a ring buffer and serves them as json, the latest first.
+type FallbackRecorder struct {
+	mut     sync.Mutex
+	records []FallbackRecord
+	next    int
+	full    bool
+}
+
+// NewFallbackRecorder returns a recorder keeping the recent `size` fallbacks, 256 if size is not positive.
+func NewFallbackRecorder(size int) *FallbackRecorder {
+	if size <= 0 {
+		size = defaultRecentFallbacks
+	}
+	return &FallbackRecorder{records: make([]FallbackRecord, size)}
+}
+
+func (r *FallbackRecorder) Record(record FallbackRecord) {
+	if r == nil {
+		return
+	}
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.records[r.next] = record
+	r.next = (r.next + 1) % len(r.records)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Recent returns at most `limit` recent fallbacks, the latest first. All are returned if limit is not positive.
+func (r *FallbackRecorder) Recent(limit int) []FallbackRecord {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	n := r.next
+	if r.full {
+		n = len(r.records)
+	}
+	if limit > 0 && limit < n {
+		n = limit
+	}
+	ret := make([]FallbackRecord, 0, n)
+	for i := 1; i <= n; i++ {
+		ret = append(ret, r.records[(r.next-i+len(r.records))%len(r.records)])
+	}
+	return ret
+}
+
+// ServeHTTP serves the recent fallbacks, `?limit=n` limits the number of them
+func (r *FallbackRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var limit int
+	if v := req.URL.Query().Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "invalid limit "+v, http.StatusBadRequest)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(r.Recent(limit)); err != nil {
+		log.Warnf("write recent fallbacks error: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestFallbackRecorder(t *testing.T) {
+	r := NewFallbackRecorder(3)
+	if got := r.Recent(0); len(got) != 0 {
+		t.Fatalf("expect no fallbacks, got %v", got)
+	}
+
+	for _, host := range []string{"a", "b", "c", "d"} {
+		r.Record(FallbackRecord{Host: host})
+	}
+	hosts := func(records []FallbackRecord) (ret []string) {
+		for _, record := range records {
+			ret = append(ret, record.Host)
+		}
+		return
+	}
+	if got := hosts(r.Recent(0)); len(got) != 3 || got[0] != "d" || got[2] != "b" {
+		t.Errorf("expect [d c b], got %v", got)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/fallbacks?limit=2", nil))
+	var records []FallbackRecord
+	if err := json.Unmarshal(w.Body.Bytes(), &records); err != nil {
+		t.Fatalf("unmarshal fallbacks error: %v", err)
+	}
+	if got := hosts(records); len(got) != 2 || got[0] != "d" || got[1] != "c" {
+		t.Errorf("expect [d c], got %v", got)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/fallbacks?limit=x", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expect bad request with invalid limit, got %d", w.Code)
+	}
+}
+
+func TestServiceHostLabel(t *testing.T) {
+	cache := &Cache{Data: map[types.NamespacedName]struct{}{{Namespace: "default", Name: "foo"}: {}}}
+	cases := map[string]string{
+		"foo.default":                        "foo.default.svc.cluster.local",
+		"foo.default:8080":                   "foo.default.svc.cluster.local",
+		"foo.default.svc":                    "foo.default.svc.cluster.local",
+		"foo.default.svc.cluster.local:9080": "foo.default.svc.cluster.local",
+		"bar.default":                        "unknown",
+		"foo.default.example.com":            "unknown",
+		"10.0.0.1":                           "unknown",
+		"":                                   "unknown",
+	}
+	for host, expect := range cases {
+		if got := serviceHostLabel(host, cache); got != expect {
+			t.Errorf("host %q expect label %q, got %q", host, expect, got)
+		}
+	}
+	if got := serviceHostLabel("foo.default", nil); got != "unknown" {
+		t.Errorf("expect unknown without the cache, got %q", got)
+	}
+}
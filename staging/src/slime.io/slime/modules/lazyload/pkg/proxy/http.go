@@ -31,18 +31,26 @@ type Proxy struct {
 	WormholePort                int
 	SvcCache                    *Cache
 	WormholePortPriorToHostPort bool
+	// Recorder records the recent fallbacks if not nil
+	Recorder *FallbackRecorder
 }
 
 func (p *Proxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	var (
 		reqCtx           = req.Context()
 		reqHost          = req.Host
+		srcNs            = req.Header.Get(HeaderSourceNs)
 		origDest, destIp string
 		destPort         = p.WormholePort
+		start            = time.Now()
 	)
 	log.Debugf("proxy received request, reqHost: %s", reqHost)
 
-	if srcNs := req.Header.Get(HeaderSourceNs); srcNs != "" {
+	done := func(code int, err error) {
+		p.record(start, srcNs, reqHost, origDest, fmt.Sprintf("%s:%d", destIp, destPort), code, err)
+	}
+
+	if srcNs != "" {
 		req.Header.Del(HeaderSourceNs)
 
 		// we do not sure if reqHost is k8s short name or no ns service
@@ -93,6 +101,7 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			if err != nil {
 				errMsg := fmt.Sprintf("invalid header %s value: %s", HeaderOrigDest, origDest)
 				http.Error(w, errMsg, http.StatusBadRequest)
+				done(http.StatusBadRequest, err)
 				return
 			}
 			destPort = port
@@ -107,6 +116,7 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			v, err := strconv.Atoi(reqHost[idx+1:])
 			if err != nil {
 				http.Error(w, fmt.Sprintf("invalid host %s value: %s", reqHost, reqHost), http.StatusBadRequest)
+				done(http.StatusBadRequest, err)
 				return
 			}
 			reqPort = v
@@ -153,12 +163,16 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		select {
 		case <-reqCtx.Done():
+			// canceled by the downstream
+			done(0, err)
 		default:
 			log.Infof("do req get err %v", err)
 			http.Error(w, "", http.StatusInternalServerError)
+			done(http.StatusInternalServerError, err)
 		}
 		return
 	}
+	defer resp.Body.Close()
 
 	for k, vv := range resp.Header {
 		for _, v := range vv {
@@ -167,4 +181,38 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	}
 	w.WriteHeader(resp.StatusCode)
 	_, _ = io.Copy(w, resp.Body)
+	done(resp.StatusCode, nil)
+}
+
+// record reports the metrics of a fallback and records it to the recorder
+func (p *Proxy) record(start time.Time, srcNs, host, origDest, dest string, code int, err error) {
+	duration := float64(time.Since(start)) / float64(time.Millisecond)
+	hostLabel := serviceHostLabel(host, p.SvcCache)
+	nsLabel := srcNs
+	if nsLabel == "" {
+		nsLabel = unknownLabelValue
+	}
+
+	FallbackRequests.With(sourceNsLabel.Value(nsLabel), destHostLabel.Value(hostLabel),
+		codeLabel.Value(strconv.Itoa(code)), origDestLabel.Value(strconv.FormatBool(origDest != ""))).Increment()
+	FallbackDuration.With(destHostLabel.Value(hostLabel)).Record(duration)
+	// code is 0 if canceled by the downstream
+	if err != nil && code == http.StatusInternalServerError {
+		FallbackUpstreamErrors.With(destHostLabel.Value(hostLabel)).Increment()
+	}
+
+	record := FallbackRecord{
+		Time:            start,
+		Protocol:        "http",
+		SourceNamespace: srcNs,
+		Host:            host,
+		Dest:            dest,
+		OrigDest:        origDest != "",
+		Code:            code,
+		Duration:        duration,
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	p.Recorder.Record(record)
 }
@@ -0,0 +1,77 @@
+package proxy
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"slime.io/slime/framework/monitoring"
+	"slime.io/slime/modules/lazyload/model"
+)
+
+const unknownLabelValue = "unknown"
+
+var (
+	sourceNsLabel = monitoring.MustCreateLabel("source_namespace")
+	destHostLabel = monitoring.MustCreateLabel("destination_host")
+	codeLabel     = monitoring.MustCreateLabel("code")
+	origDestLabel = monitoring.MustCreateLabel("orig_dest")
+	protocolLabel = monitoring.MustCreateLabel("protocol")
+	resultLabel   = monitoring.MustCreateLabel("result")
+
+	FallbackRequests = monitoring.NewSum(
+		model.ModuleName,
+		"proxy_fallback_requests",
+		"total number of the requests forwarded by the global-sidecar proxy",
+	)
+
+	FallbackUpstreamErrors = monitoring.NewSum(
+		model.ModuleName,
+		"proxy_upstream_errors",
+		"total number of the requests failed to be forwarded to the upstream",
+	)
+
+	FallbackDuration = monitoring.NewHistogram(
+		model.ModuleName,
+		"proxy_fallback_duration",
+		"Time spent on forwarding the requests in milliseconds",
+		monitoring.WithHistogramBounds([]float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}...),
+	)
+
+	FallbackConnections = monitoring.NewSum(
+		model.ModuleName,
+		"proxy_fallback_connections",
+		"total number of the tcp or tls connections forwarded by the global-sidecar proxy",
+	)
+
+	FallbackConnectDuration = monitoring.NewHistogram(
+		model.ModuleName,
+		"proxy_fallback_connect_duration",
+		"Time spent on connecting to the upstream of the tcp or tls connections in milliseconds",
+		monitoring.WithHistogramBounds([]float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}...),
+	)
+)
+
+// serviceHostLabel returns the FQDN of the service host like `svc.ns`, `svc.ns.svc` or `svc.ns.svc.cluster.local`,
+// with or without the port, if the service exists in the cache. Otherwise returns "unknown", as the host comes
+// from the client and would make the cardinality of the label unbounded.
+func serviceHostLabel(host string, cache *Cache) string {
+	if cache == nil {
+		return unknownLabelValue
+	}
+	if idx := strings.LastIndex(host, ":"); idx >= 0 {
+		host = host[:idx]
+	}
+	parts := strings.Split(host, ".")
+	switch {
+	case len(parts) == 2:
+	case len(parts) == 3 && parts[2] == "svc":
+	case len(parts) == 5 && strings.HasSuffix(host, ".svc.cluster.local"):
+	default:
+		return unknownLabelValue
+	}
+	if !cache.Exist(types.NamespacedName{Namespace: parts[1], Name: parts[0]}) {
+		return unknownLabelValue
+	}
+	return parts[0] + "." + parts[1] + ".svc.cluster.local"
+}
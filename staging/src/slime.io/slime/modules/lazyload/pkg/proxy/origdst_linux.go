@@ -0,0 +1,64 @@
+package proxy
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// IP6T_SO_ORIGINAL_DST of linux/netfilter_ipv6/ip6_tables.h
+const ip6tSoOriginalDst = 80
+
+// originalDst returns the destination before redirected by iptables, SO_ORIGINAL_DST of the conn
+func originalDst(conn net.Conn) (*net.TCPAddr, error) {
+	tc, ok := conn.(*net.TCPConn)
+	if !ok {
+		return nil, errors.New("not a tcp conn")
+	}
+	rc, err := tc.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		addr    *net.TCPAddr
+		sockErr error
+	)
+	isIPv4 := tc.LocalAddr().(*net.TCPAddr).IP.To4() != nil
+	err = rc.Control(func(fd uintptr) {
+		if isIPv4 {
+			// struct sockaddr_in, got as ipv6_mreq which has the same size
+			mreq, err := unix.GetsockoptIPv6Mreq(int(fd), syscall.SOL_IP, unix.SO_ORIGINAL_DST)
+			if err != nil {
+				sockErr = err
+				return
+			}
+			b := mreq.Multiaddr
+			addr = &net.TCPAddr{
+				IP:   net.IPv4(b[4], b[5], b[6], b[7]),
+				Port: int(binary.BigEndian.Uint16(b[2:4])),
+			}
+			return
+		}
+		// struct sockaddr_in6, got as ipv6_mtuinfo which has the same layout at the head
+		info, err := unix.GetsockoptIPv6MTUInfo(int(fd), syscall.SOL_IPV6, ip6tSoOriginalDst)
+		if err != nil {
+			sockErr = err
+			return
+		}
+		// the port is in network byte order
+		port := (*[2]byte)(unsafe.Pointer(&info.Addr.Port))
+		addr = &net.TCPAddr{
+			IP:   net.IP(info.Addr.Addr[:]),
+			Port: int(binary.BigEndian.Uint16(port[:])),
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return addr, sockErr
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package proxy
+
+import (
+	"errors"
+	"net"
+)
+
+// originalDst is only supported on linux
+func originalDst(_ net.Conn) (*net.TCPAddr, error) {
+	return nil, errors.New("SO_ORIGINAL_DST is not supported")
+}
@@ -0,0 +1,127 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+var (
+	proxyProtoV1Prefix = []byte("PROXY ")
+	proxyProtoV2Sig    = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+	errNotProxyProto = errors.New("not proxy protocol")
+)
+
+const (
+	// the max length of a v1 header, including the CRLF
+	proxyProtoV1MaxLen = 107
+	proxyProtoV2HdrLen = 16
+)
+
+// readProxyProto reads the proxy protocol header, v1 or v2, sent by envoy, and returns the original source and
+// destination. Returns errNotProxyProto without consuming anything if there is no header, or nil addresses if
+// the header is `UNKNOWN` or `LOCAL`.
+func readProxyProto(r *bufio.Reader) (src, dst *net.TCPAddr, err error) {
+	// the signature of v2 is longer, peek as many as possible
+	sig, _ := r.Peek(len(proxyProtoV2Sig))
+	switch {
+	case bytes.HasPrefix(sig, proxyProtoV2Sig):
+		return readProxyProtoV2(r)
+	case bytes.HasPrefix(sig, proxyProtoV1Prefix):
+		return readProxyProtoV1(r)
+	default:
+		return nil, nil, errNotProxyProto
+	}
+}
+
+// readProxyProtoV1 reads the header like `PROXY TCP4 10.0.0.1 10.0.0.2 34567 3306\r\n`
+func readProxyProtoV1(r *bufio.Reader) (src, dst *net.TCPAddr, err error) {
+	var line []byte
+	for len(line) < proxyProtoV1MaxLen {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, nil, err
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, nil, fmt.Errorf("invalid proxy protocol v1 header %q", line)
+	}
+
+	fields := strings.Fields(string(line[:len(line)-2]))
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, nil, fmt.Errorf("invalid proxy protocol v1 header %q", line)
+	}
+	if src, err = parseTCPAddr(fields[2], fields[4]); err != nil {
+		return nil, nil, err
+	}
+	if dst, err = parseTCPAddr(fields[3], fields[5]); err != nil {
+		return nil, nil, err
+	}
+	return src, dst, nil
+}
+
+func parseTCPAddr(ip, port string) (*net.TCPAddr, error) {
+	addr := &net.TCPAddr{IP: net.ParseIP(ip)}
+	if addr.IP == nil {
+		return nil, fmt.Errorf("invalid proxy protocol address %s", ip)
+	}
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy protocol port %s", port)
+	}
+	addr.Port = int(p)
+	return addr, nil
+}
+
+// readProxyProtoV2 reads the binary header, the TLVs are skipped
+func readProxyProtoV2(r *bufio.Reader) (src, dst *net.TCPAddr, err error) {
+	hdr := make([]byte, proxyProtoV2HdrLen)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return nil, nil, err
+	}
+	verCmd, fam := hdr[12], hdr[13]
+	if verCmd>>4 != 2 {
+		return nil, nil, fmt.Errorf("invalid proxy protocol v2 version %d", verCmd>>4)
+	}
+	payload := make([]byte, binary.BigEndian.Uint16(hdr[14:16]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, nil, err
+	}
+
+	// LOCAL command, like health checks
+	if verCmd&0xf == 0 {
+		return nil, nil, nil
+	}
+	switch fam >> 4 {
+	case 1: // AF_INET
+		if len(payload) < 12 {
+			return nil, nil, errors.New("short proxy protocol v2 ipv4 addresses")
+		}
+		src = &net.TCPAddr{IP: net.IP(payload[0:4]), Port: int(binary.BigEndian.Uint16(payload[8:10]))}
+		dst = &net.TCPAddr{IP: net.IP(payload[4:8]), Port: int(binary.BigEndian.Uint16(payload[10:12]))}
+	case 2: // AF_INET6
+		if len(payload) < 36 {
+			return nil, nil, errors.New("short proxy protocol v2 ipv6 addresses")
+		}
+		src = &net.TCPAddr{IP: net.IP(payload[0:16]), Port: int(binary.BigEndian.Uint16(payload[32:34]))}
+		dst = &net.TCPAddr{IP: net.IP(payload[16:32]), Port: int(binary.BigEndian.Uint16(payload[34:36]))}
+	default:
+		// AF_UNSPEC or AF_UNIX
+		return nil, nil, nil
+	}
+	return src, dst, nil
+}
@@ -0,0 +1,63 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"time"
+)
+
+const (
+	tlsRecordTypeHandshake = 0x16
+	tlsRecordHeaderLen     = 5
+	tlsMaxRecordLen        = 16384
+
+	// tcpReaderSize is large enough to peek a whole tls record
+	tcpReaderSize = tlsRecordHeaderLen + tlsMaxRecordLen
+)
+
+var errHelloPeeked = errors.New("client hello peeked")
+
+// peekServerName returns the server name of the tls client hello at the beginning of r without consuming it,
+// or empty if it's not tls or the client hello has no server name.
+func peekServerName(r *bufio.Reader) string {
+	hdr, err := r.Peek(tlsRecordHeaderLen)
+	if err != nil || hdr[0] != tlsRecordTypeHandshake {
+		return ""
+	}
+	recLen := int(hdr[3])<<8 | int(hdr[4])
+	if recLen > tlsMaxRecordLen {
+		return ""
+	}
+	hello, err := r.Peek(tlsRecordHeaderLen + recLen)
+	if err != nil {
+		return ""
+	}
+
+	var serverName string
+	conn := &helloConn{r: bytes.NewReader(hello)}
+	_ = tls.Server(conn, &tls.Config{
+		GetConfigForClient: func(info *tls.ClientHelloInfo) (*tls.Config, error) {
+			serverName = info.ServerName
+			return nil, errHelloPeeked
+		},
+	}).Handshake()
+	return serverName
+}
+
+// helloConn feeds the peeked client hello to the tls server, writes are dropped
+type helloConn struct {
+	r io.Reader
+}
+
+func (c *helloConn) Read(p []byte) (int, error)         { return c.r.Read(p) }
+func (c *helloConn) Write(p []byte) (int, error)        { return 0, io.ErrClosedPipe }
+func (c *helloConn) Close() error                       { return nil }
+func (c *helloConn) LocalAddr() net.Addr                { return nil }
+func (c *helloConn) RemoteAddr() net.Addr               { return nil }
+func (c *helloConn) SetDeadline(_ time.Time) error      { return nil }
+func (c *helloConn) SetReadDeadline(_ time.Time) error  { return nil }
+func (c *helloConn) SetWriteDeadline(_ time.Time) error { return nil }
@@ -0,0 +1,226 @@
+package proxy
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultDetectTimeout = 100 * time.Millisecond
+	defaultDialTimeout   = 10 * time.Second
+)
+
+// TcpProxy forwards the tcp connections to the original destination, which is got from the proxy protocol header
+// sent by envoy, or SO_ORIGINAL_DST of the connection. The tls connections are routed by the server name of the
+// client hello if there is one, to the port of the original destination or the wormhole port.
+type TcpProxy struct {
+	WormholePort int
+	// SvcCache resolves the tls server names to the services for the metric labels, nil to label them unknown
+	SvcCache *Cache
+	// Recorder records the recent fallbacks if not nil
+	Recorder *FallbackRecorder
+	// DetectTimeout is the max time waiting for the proxy protocol header or the tls client hello, as the server
+	// first protocols like mysql send nothing before the server greets. 100ms by default
+	DetectTimeout time.Duration
+	// DialTimeout of connecting to the upstream, 10s by default
+	DialTimeout time.Duration
+
+	mut      sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+}
+
+// Serve accepts the connections on l until Close is called, and always returns a non-nil error.
+func (p *TcpProxy) Serve(l net.Listener) error {
+	p.mut.Lock()
+	if p.closed {
+		p.mut.Unlock()
+		_ = l.Close()
+		return net.ErrClosed
+	}
+	p.listener = l
+	p.mut.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				log.Warnf("tcp proxy accept error: %v", err)
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+		if !p.track(conn, true) {
+			_ = conn.Close()
+			continue
+		}
+		go func() {
+			defer p.track(conn, false)
+			p.handle(conn)
+		}()
+	}
+}
+
+// Close closes the listener and all the connections being forwarded.
+func (p *TcpProxy) Close() error {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.closed = true
+	var err error
+	if p.listener != nil {
+		err = p.listener.Close()
+	}
+	for conn := range p.conns {
+		_ = conn.Close()
+	}
+	p.conns = nil
+	return err
+}
+
+func (p *TcpProxy) track(conn net.Conn, add bool) bool {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	if !add {
+		delete(p.conns, conn)
+		return true
+	}
+	if p.closed {
+		return false
+	}
+	if p.conns == nil {
+		p.conns = map[net.Conn]struct{}{}
+	}
+	p.conns[conn] = struct{}{}
+	return true
+}
+
+func (p *TcpProxy) handle(conn net.Conn) {
+	defer conn.Close()
+	start := time.Now()
+
+	detectTimeout := p.DetectTimeout
+	if detectTimeout <= 0 {
+		detectTimeout = defaultDetectTimeout
+	}
+	br := bufio.NewReaderSize(conn, tcpReaderSize)
+	_ = conn.SetReadDeadline(start.Add(detectTimeout))
+	_, dst, err := readProxyProto(br)
+	if err != nil && !errors.Is(err, errNotProxyProto) {
+		p.record(start, "tcp", "", "", false, fmt.Errorf("read proxy protocol: %w", err))
+		return
+	}
+	viaProxyProto := dst != nil
+	serverName := peekServerName(br)
+	_ = conn.SetReadDeadline(time.Time{})
+
+	if dst == nil {
+		if dst, err = originalDst(conn); err != nil {
+			log.Debugf("get original destination of %s error: %v", conn.RemoteAddr(), err)
+			dst = nil
+		} else if local, ok := conn.LocalAddr().(*net.TCPAddr); ok && local.IP.Equal(dst.IP) && local.Port == dst.Port {
+			// not redirected
+			dst = nil
+		}
+	}
+
+	protocol, host, target := "tcp", "", ""
+	switch {
+	case serverName != "":
+		port := p.WormholePort
+		if dst != nil {
+			port = dst.Port
+		}
+		protocol, host, target = "tls", serverName, net.JoinHostPort(serverName, strconv.Itoa(port))
+	case dst != nil:
+		host, target = dst.IP.String(), dst.String()
+	default:
+		p.record(start, protocol, host, target, viaProxyProto, errors.New("no original destination"))
+		return
+	}
+	log.Debugf("tcp proxy forward %s connection from %s to %s", protocol, conn.RemoteAddr(), target)
+
+	dialTimeout := p.DialTimeout
+	if dialTimeout <= 0 {
+		dialTimeout = defaultDialTimeout
+	}
+	upstream, err := net.DialTimeout("tcp", target, dialTimeout)
+	p.record(start, protocol, host, target, viaProxyProto, err)
+	if err != nil {
+		log.Infof("tcp proxy dial %s error: %v", target, err)
+		return
+	}
+	defer upstream.Close()
+	if !p.track(upstream, true) {
+		return
+	}
+	defer p.track(upstream, false)
+
+	pipe(conn, br, upstream)
+}
+
+// pipe copies the data between the downstream and the upstream until both directions are done, the buffered
+// data of the downstream reader goes first.
+func pipe(downstream net.Conn, downstreamReader io.Reader, upstream net.Conn) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, _ = io.Copy(upstream, downstreamReader)
+		closeWrite(upstream)
+	}()
+	go func() {
+		defer wg.Done()
+		_, _ = io.Copy(downstream, upstream)
+		closeWrite(downstream)
+	}()
+	wg.Wait()
+}
+
+func closeWrite(conn net.Conn) {
+	if cw, ok := conn.(interface{ CloseWrite() error }); ok {
+		_ = cw.CloseWrite()
+		return
+	}
+	_ = conn.Close()
+}
+
+// record reports the metrics of a forwarded connection and records it to the recorder
+func (p *TcpProxy) record(start time.Time, protocol, host, target string, viaProxyProto bool, err error) {
+	duration := float64(time.Since(start)) / float64(time.Millisecond)
+	hostLabel := serviceHostLabel(host, p.SvcCache)
+	result := "success"
+	if err != nil {
+		result = "error"
+		// no target if failed before connecting
+		if target != "" {
+			FallbackUpstreamErrors.With(destHostLabel.Value(hostLabel)).Increment()
+		}
+	}
+	FallbackConnections.With(destHostLabel.Value(hostLabel), protocolLabel.Value(protocol),
+		resultLabel.Value(result)).Increment()
+	FallbackConnectDuration.With(destHostLabel.Value(hostLabel)).Record(duration)
+
+	record := FallbackRecord{
+		Time:     start,
+		Protocol: protocol,
+		Host:     host,
+		Dest:     target,
+		OrigDest: viaProxyProto,
+		Duration: duration,
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	p.Recorder.Record(record)
+}
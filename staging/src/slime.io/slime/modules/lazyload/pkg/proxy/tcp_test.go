@@ -0,0 +1,182 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadProxyProto(t *testing.T) {
+	v2 := append([]byte{}, proxyProtoV2Sig...)
+	v2 = append(v2, 0x21, 0x11) // v2 PROXY, TCP over IPv4
+	v2 = binary.BigEndian.AppendUint16(v2, 12+3)
+	v2 = append(v2, 10, 0, 0, 1, 10, 0, 0, 2)
+	v2 = binary.BigEndian.AppendUint16(v2, 34567)
+	v2 = binary.BigEndian.AppendUint16(v2, 3306)
+	v2 = append(v2, 0x04, 0x00, 0x00) // NOOP TLV
+	v2 = append(v2, "payload"...)
+
+	cases := []struct {
+		name     string
+		data     []byte
+		src, dst string
+		err      bool
+	}{
+		{name: "v1", data: []byte("PROXY TCP4 10.0.0.1 10.0.0.2 34567 3306\r\npayload"),
+			src: "10.0.0.1:34567", dst: "10.0.0.2:3306"},
+		{name: "v1 ipv6", data: []byte("PROXY TCP6 ::1 fd00::2 34567 6379\r\npayload"),
+			src: "[::1]:34567", dst: "[fd00::2]:6379"},
+		{name: "v1 unknown", data: []byte("PROXY UNKNOWN\r\npayload")},
+		{name: "v1 invalid", data: []byte("PROXY TCP4 10.0.0.1\r\npayload"), err: true},
+		{name: "v2", data: v2, src: "10.0.0.1:34567", dst: "10.0.0.2:3306"},
+	}
+	for _, c := range cases {
+		r := bufio.NewReader(bytes.NewReader(c.data))
+		src, dst, err := readProxyProto(r)
+		if c.err {
+			if err == nil {
+				t.Errorf("%s: expect error", c.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+			continue
+		}
+		if (c.src == "") != (src == nil) || (src != nil && src.String() != c.src) {
+			t.Errorf("%s: expect src %q, got %v", c.name, c.src, src)
+		}
+		if (c.dst == "") != (dst == nil) || (dst != nil && dst.String() != c.dst) {
+			t.Errorf("%s: expect dst %q, got %v", c.name, c.dst, dst)
+		}
+		if rest, _ := io.ReadAll(r); string(rest) != "payload" {
+			t.Errorf("%s: expect payload left, got %q", c.name, rest)
+		}
+	}
+
+	r := bufio.NewReader(strings.NewReader("GET / HTTP/1.1\r\n"))
+	if _, _, err := readProxyProto(r); err != errNotProxyProto {
+		t.Errorf("expect not proxy protocol, got %v", err)
+	}
+	if rest, _ := io.ReadAll(r); string(rest) != "GET / HTTP/1.1\r\n" {
+		t.Errorf("expect nothing consumed, got %q", rest)
+	}
+}
+
+// clientHello returns the client hello sent by a tls client with the server name
+func clientHello(t *testing.T, serverName string) []byte {
+	client, server := net.Pipe()
+	defer server.Close()
+	go func() {
+		_ = tls.Client(client, &tls.Config{ServerName: serverName, InsecureSkipVerify: true}).Handshake()
+		client.Close()
+	}()
+	hdr := make([]byte, tlsRecordHeaderLen)
+	if _, err := io.ReadFull(server, hdr); err != nil {
+		t.Fatalf("read client hello error: %v", err)
+	}
+	body := make([]byte, int(hdr[3])<<8|int(hdr[4]))
+	if _, err := io.ReadFull(server, body); err != nil {
+		t.Fatalf("read client hello error: %v", err)
+	}
+	return append(hdr, body...)
+}
+
+func TestPeekServerName(t *testing.T) {
+	hello := clientHello(t, "db.example.com")
+	r := bufio.NewReaderSize(bytes.NewReader(hello), tcpReaderSize)
+	if got := peekServerName(r); got != "db.example.com" {
+		t.Errorf("expect server name db.example.com, got %q", got)
+	}
+	if rest, _ := io.ReadAll(r); !bytes.Equal(rest, hello) {
+		t.Errorf("client hello consumed")
+	}
+
+	r = bufio.NewReaderSize(strings.NewReader("PING\r\n"), tcpReaderSize)
+	if got := peekServerName(r); got != "" {
+		t.Errorf("expect no server name, got %q", got)
+	}
+}
+
+func TestTcpProxy(t *testing.T) {
+	// upstream replies the first line it reads
+	upstream, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer upstream.Close()
+	go func() {
+		for {
+			conn, err := upstream.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				line, _ := bufio.NewReader(conn).ReadString('\n')
+				_, _ = conn.Write([]byte("echo " + line))
+			}()
+		}
+	}()
+	upstreamAddr := upstream.Addr().(*net.TCPAddr)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := NewFallbackRecorder(0)
+	p := &TcpProxy{Recorder: recorder, DetectTimeout: 50 * time.Millisecond}
+	go func() { _ = p.Serve(l) }()
+	defer p.Close()
+
+	roundTrip := func(data string) string {
+		conn, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+		if _, err := conn.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		reply, _ := io.ReadAll(conn)
+		return string(reply)
+	}
+
+	header := "PROXY TCP4 127.0.0.1 127.0.0.1 34567 " + strconv.Itoa(upstreamAddr.Port) + "\r\n"
+	if got := roundTrip(header + "hello\n"); got != "echo hello\n" {
+		t.Errorf("expect echo by the destination of proxy protocol, got %q", got)
+	}
+	records := recorder.Recent(1)
+	if len(records) != 1 || records[0].Protocol != "tcp" || records[0].Dest != upstreamAddr.String() ||
+		!records[0].OrigDest || records[0].Error != "" {
+		t.Errorf("unexpected record %+v", records)
+	}
+
+	// routed by the server name, to the port of the proxy protocol destination
+	hello := clientHello(t, "localhost")
+	if got := roundTrip(header + string(hello) + "\n"); !strings.HasPrefix(got, "echo ") {
+		t.Errorf("expect echo by the server name, got %q", got)
+	}
+	records = recorder.Recent(1)
+	if len(records) != 1 || records[0].Protocol != "tls" || records[0].Host != "localhost" ||
+		records[0].Dest != "localhost:"+strconv.Itoa(upstreamAddr.Port) {
+		t.Errorf("unexpected record %+v", records)
+	}
+
+	// neither proxy protocol nor redirected
+	if got := roundTrip("hello\n"); got != "" {
+		t.Errorf("expect closed without destination, got %q", got)
+	}
+	records = recorder.Recent(1)
+	if len(records) != 1 || records[0].Error == "" {
+		t.Errorf("unexpected record %+v", records)
+	}
+}
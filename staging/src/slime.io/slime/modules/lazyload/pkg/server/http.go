@@ -7,12 +7,15 @@ import (
 	log "github.com/sirupsen/logrus"
 	"k8s.io/kube-openapi/pkg/common"
 
+	"slime.io/slime/framework/bootstrap"
 	"slime.io/slime/framework/model/metric"
 )
 
 type Handler struct {
 	HttpPathHandler common.PathHandler
 	Source          metric.Source
+	// ResetStatus resets the learned dependencies kept in the status of the servicefences, optional
+	ResetStatus func(info string) error
 }
 
 func (s *Handler) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
@@ -21,6 +24,13 @@ func (s *Handler) HandleFunc(pattern string, handler func(http.ResponseWriter, *
 	}
 }
 
+// HandleWriteFunc registers the handler which mutates states on any request.
+func (s *Handler) HandleWriteFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	if s.HttpPathHandler != nil {
+		s.HttpPathHandler.Handle(pattern, bootstrap.WriteHandler(http.HandlerFunc(handler)))
+	}
+}
+
 // SvfResetSetting ns is needed, it will reset all svf in ns if svc is empty
 // otherwise, ns/name will reset
 func (s *Handler) SvfResetSetting(w http.ResponseWriter, r *http.Request) {
@@ -36,6 +46,12 @@ func (s *Handler) SvfResetSetting(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("svf reset err %s", err), http.StatusInternalServerError)
 		return
 	}
+	if s.ResetStatus != nil {
+		if err := s.ResetStatus(info); err != nil {
+			http.Error(w, fmt.Sprintf("svf reset status err %s", err), http.StatusInternalServerError)
+			return
+		}
+	}
 
 	if _, err := w.Write([]byte("succeed")); err != nil {
 		log.Errorf("reset svf %s err %s", info, err)
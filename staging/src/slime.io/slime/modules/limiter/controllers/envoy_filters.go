@@ -10,7 +10,6 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 
-	"slime.io/slime/framework/bootstrap/resource"
 	slime_serviceregistry "slime.io/slime/framework/bootstrap/serviceregistry/model"
 	"slime.io/slime/framework/controllers"
 	"slime.io/slime/framework/util"
@@ -50,19 +49,19 @@ func (r *SmartLimiterReconciler) GenerateEnvoyConfigs(spec limiterv1alpha2.Smart
 	setsSmartLimitDescriptor := make(map[string]*limiterv1alpha2.SmartLimitDescriptors)
 	globalDescriptors := make([]*model.Descriptor, 0)
 	params := &LimiterSpec{
-		rls:                               r.cfg.GetRls(),
+		rls:                               r.config().GetRls(),
 		gw:                                spec.Gateway,
 		labels:                            spec.WorkloadSelector,
 		loc:                               loc,
-		disableGlobalRateLimit:            r.cfg.GetDisableGlobalRateLimit(),
-		disableInsertLocalRateLimit:       r.cfg.GetDisableInsertLocalRateLimit(),
-		disableInsertGlobalRateLimit:      r.cfg.GetDisableInsertGlobalRateLimit(),
-		enableRatelimitPlaceholderInRoute: r.cfg.GetEnableRatelimitPlaceholderInRoute(),
+		disableGlobalRateLimit:            r.config().GetDisableGlobalRateLimit(),
+		disableInsertLocalRateLimit:       r.config().GetDisableInsertLocalRateLimit(),
+		disableInsertGlobalRateLimit:      r.config().GetDisableInsertGlobalRateLimit(),
+		enableRatelimitPlaceholderInRoute: r.config().GetEnableRatelimitPlaceholderInRoute(),
 		host:                              spec.Host,
 		target:                            spec.Target,
-		domain:                            r.cfg.GetDomain(),
-		rlsConfigmap:                      r.cfg.GetRlsConfigMap(),
-		proxyVersion:                      r.cfg.GetProxyVersion(),
+		domain:                            r.config().GetDomain(),
+		rlsConfigmap:                      r.config().GetRlsConfigMap(),
+		proxyVersion:                      r.config().GetProxyVersion(),
 	}
 
 	var sets []*networkingapi.Subset
@@ -294,15 +293,10 @@ func getIstioServiceLabels(svc *slime_serviceregistry.Service) map[string]string
 }
 
 func getIstioService(r *SmartLimiterReconciler, nn types.NamespacedName) (*slime_serviceregistry.Service, error) {
-	// get config from framework
-	config := r.env.ConfigController.Get(resource.IstioService, nn.Name, nn.Namespace)
-	if config == nil {
-		return nil, fmt.Errorf("get empty config base on %s/%s", nn.Namespace, nn.Name)
-	}
-
-	svc, ok := config.Spec.(*slime_serviceregistry.Service)
-	if !ok {
-		return nil, fmt.Errorf("convert config to istio service err")
+	// get service from the service registry of framework
+	svc := r.env.ConfigController.ServiceRegistry().Service(nn.Namespace, slime_serviceregistry.Name(nn.Name))
+	if svc == nil {
+		return nil, fmt.Errorf("get empty service base on %s/%s", nn.Namespace, nn.Name)
 	}
 	return svc, nil
 }
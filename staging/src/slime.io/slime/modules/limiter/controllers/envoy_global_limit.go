@@ -298,7 +298,7 @@ func getConfigMapNamespaceName(cm *config.RlsConfigMap) (types.NamespacedName, e
 
 // if configmap rate-limit-config not exist, return
 func refreshConfigMap(desc []*model.Descriptor, r *SmartLimiterReconciler, serviceLoc types.NamespacedName) {
-	loc, err := getConfigMapNamespaceName(r.cfg.RlsConfigMap)
+	loc, err := getConfigMapNamespaceName(r.config().GetRlsConfigMap())
 	if err != nil {
 		log.Errorf("getConfigMapNamespaceName err: %s", err.Error())
 		return
@@ -333,7 +333,7 @@ func refreshConfigMap(desc []*model.Descriptor, r *SmartLimiterReconciler, servi
 		return newCm[i].Value < newCm[j].Value
 	})
 
-	newCfg := constructNewConfig(newCm, getDomain(r.cfg.GetDomain()))
+	newCfg := constructNewConfig(newCm, getDomain(r.config().GetDomain()))
 	if !reflect.DeepEqual(found.Data[model.ConfigMapConfig], newCfg) {
 		log.Infof("update rate-limit-config %s:%s", loc.Namespace, loc.Name)
 		found.Data[model.ConfigMapConfig] = newCfg
@@ -14,6 +14,7 @@ import (
 	"google.golang.org/protobuf/proto"
 	networkingapi "istio.io/api/networking/v1alpha3"
 	networkingv1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -24,6 +25,7 @@ import (
 
 	slime_model "slime.io/slime/framework/model"
 	"slime.io/slime/framework/model/metric"
+	"slime.io/slime/framework/monitoring"
 	"slime.io/slime/framework/util"
 	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
 	"slime.io/slime/modules/limiter/model"
@@ -45,19 +47,19 @@ func (r *SmartLimiterReconciler) WatchMetric(ctx context.Context) {
 				return
 			}
 			log.Debugf("get metric from watcherMetricChan, %+v", metric)
-			r.ConsumeMetric(metric)
+			r.ConsumeMetric(ctx, metric)
 		case metric, ok := <-r.tickerMetricChan:
 			if !ok {
 				log.Warningf("ticker metric channel closed, break process loop")
 				return
 			}
 			log.Debugf("get metric from tickerMetricChan, %+v", metric)
-			r.ConsumeMetric(metric)
+			r.ConsumeMetric(ctx, metric)
 		}
 	}
 }
 
-func (r *SmartLimiterReconciler) ConsumeMetric(metricMap metric.Metric) {
+func (r *SmartLimiterReconciler) ConsumeMetric(ctx context.Context, metricMap metric.Metric) {
 	r.Lock()
 	defer r.Unlock()
 
@@ -99,13 +101,17 @@ func (r *SmartLimiterReconciler) ConsumeMetric(metricMap metric.Metric) {
 		}
 		log.Debugf("exact metric info, %v", info)
 		// use info to refresh SmartLimiter's status
-		if _, err := r.Refresh(reconcile.Request{NamespacedName: loc}, info); err != nil {
+		if _, err := r.Refresh(ctx, reconcile.Request{NamespacedName: loc}, info); err != nil {
 			log.Errorf("refresh error:%v", err)
 		}
 	}
 }
 
-func (r *SmartLimiterReconciler) Refresh(request reconcile.Request, args map[string]string) (reconcile.Result, error) {
+func (r *SmartLimiterReconciler) Refresh(
+	ctx context.Context,
+	request reconcile.Request,
+	args map[string]string,
+) (reconcile.Result, error) {
 	_, ok := r.metricInfo.Get(request.Namespace + "/" + request.Name)
 	if !ok {
 		r.metricInfo.Set(request.Namespace+"/"+request.Name, &slime_model.Endpoints{
@@ -123,7 +129,7 @@ func (r *SmartLimiterReconciler) Refresh(request reconcile.Request, args map[str
 	}
 
 	instance := &microservicev1alpha2.SmartLimiter{}
-	err := r.Client.Get(context.TODO(), request.NamespacedName, instance)
+	err := r.Client.Get(ctx, request.NamespacedName, instance)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request.
@@ -142,14 +148,17 @@ func (r *SmartLimiterReconciler) Refresh(request reconcile.Request, args map[str
 		return ctrl.Result{}, nil
 	}
 
-	if result, err := r.refresh(instance); err == nil {
+	if result, err := r.refresh(ctx, instance); err == nil {
 		return result, nil
 	}
 	return reconcile.Result{}, err
 }
 
 // refresh envoy filters and configmap
-func (r *SmartLimiterReconciler) refresh(instance *microservicev1alpha2.SmartLimiter) (reconcile.Result, error) {
+func (r *SmartLimiterReconciler) refresh(
+	ctx context.Context,
+	instance *microservicev1alpha2.SmartLimiter,
+) (reconcile.Result, error) {
 	var err error
 	loc := types.NamespacedName{
 		Namespace: instance.Namespace,
@@ -186,12 +195,12 @@ func (r *SmartLimiterReconciler) refresh(instance *microservicev1alpha2.SmartLim
 				},
 			}
 		}
-		_, err = refreshEnvoyFilter(instance, r, efcr, ef)
+		_, err = refreshEnvoyFilter(ctx, instance, r, efcr, ef)
 		if err != nil {
 			log.Errorf("generated/deleted EnvoyFilter %s failed:%+v", efcr.Name, err)
 		}
 	}
-	if !r.cfg.GetDisableGlobalRateLimit() {
+	if !r.config().GetDisableGlobalRateLimit() {
 		refreshConfigMap(gdesc, r, loc)
 	} else {
 		log.Debugf("global rate limiter is closed")
@@ -200,7 +209,7 @@ func (r *SmartLimiterReconciler) refresh(instance *microservicev1alpha2.SmartLim
 		RatelimitStatus: descriptor,
 		MetricStatus:    material,
 	}
-	if err = r.Client.Status().Update(context.TODO(), instance); err != nil {
+	if err = r.Client.Status().Update(ctx, instance); err != nil {
 		return reconcile.Result{}, err
 	}
 	return reconcile.Result{}, nil
@@ -214,11 +223,16 @@ func (r *SmartLimiterReconciler) getMaterial(loc types.NamespacedName) map[strin
 }
 
 func refreshEnvoyFilter(
+	ctx context.Context,
 	instance *microservicev1alpha2.SmartLimiter,
 	r *SmartLimiterReconciler,
 	obj *networkingv1alpha3.EnvoyFilter,
 	ef *networkingapi.EnvoyFilter,
-) (reconcile.Result, error) {
+) (_ reconcile.Result, err error) {
+	ctx, span := monitoring.StartSpan(ctx, model.ModuleName, "RefreshEnvoyFilter",
+		monitoring.ResourceAttributes(obj.Namespace, obj.Name)...)
+	defer func() { monitoring.EndSpan(span, err) }()
+
 	if err := controllerutil.SetControllerReference(instance, obj, r.Scheme); err != nil {
 		return reconcile.Result{}, err
 	}
@@ -228,7 +242,7 @@ func refreshEnvoyFilter(
 	slime_model.PatchObjectMeta(&obj.ObjectMeta, &instance.ObjectMeta)
 
 	found := &networkingv1alpha3.EnvoyFilter{}
-	if err := r.Client.Get(context.TODO(), loc, found); err != nil {
+	if err := r.Client.Get(ctx, loc, found); err != nil {
 		if errors.IsNotFound(err) {
 			found = nil
 			err = nil
@@ -242,11 +256,14 @@ func refreshEnvoyFilter(
 		// found is nil and obj's spec is not nil , create envoyFilter
 		if ef != nil {
 			obj.Spec = *ef
-			if err := r.Client.Create(context.TODO(), obj); err != nil {
+			if err := r.Client.Create(ctx, obj); err != nil {
 				EnvoyFilterCreationsFailed.Increment()
+				r.recordEvent(instance, corev1.EventTypeWarning, "EnvoyFilterCreateFailed",
+					"create envoyfilter %s failed: %v", loc, err)
 				return reconcile.Result{}, fmt.Errorf("creating a new EnvoyFilter err, %+v", err.Error())
 			}
 			EnvoyFilterCreations.Increment()
+			r.recordEvent(instance, corev1.EventTypeNormal, "EnvoyFilterCreated", "created envoyfilter %s", loc)
 			log.Infof("creating a new EnvoyFilter,%+v", loc)
 			return reconcile.Result{}, nil
 		}
@@ -259,12 +276,15 @@ func refreshEnvoyFilter(
 			if !proto.Equal(&found.Spec, ef) || !reflect.DeepEqual(found.Labels, obj.Labels) {
 				obj.Spec = *ef
 				obj.ResourceVersion = found.ResourceVersion
-				err := r.Client.Update(context.TODO(), obj)
+				err := r.Client.Update(ctx, obj)
 				if err != nil {
 					log.Errorf("update envoyfilter err: %+v", err.Error())
+					r.recordEvent(instance, corev1.EventTypeWarning, "EnvoyFilterUpdateFailed",
+						"update envoyfilter %s failed: %v", loc, err)
 					return reconcile.Result{}, err
 				}
 				EnvoyfilterRefreshes.Increment()
+				r.recordEvent(instance, corev1.EventTypeNormal, "EnvoyFilterRefreshed", "refreshed envoyfilter %s", loc)
 				log.Infof("Update a new EnvoyFilter succeed,%v", loc)
 				// Pod created successfully - don't requeue
 				return reconcile.Result{}, nil
@@ -272,11 +292,14 @@ func refreshEnvoyFilter(
 		} else {
 			log.Infof("obj sepec is nil, delete obj %s/%s", obj.Namespace, obj.Name)
 			// spec is nil , delete
-			err := r.Client.Delete(context.TODO(), obj)
+			err := r.Client.Delete(ctx, obj)
 			if errors.IsNotFound(err) {
 				err = nil
 			}
 			EnvoyfilterDeletions.Increment()
+			if err == nil {
+				r.recordEvent(instance, corev1.EventTypeNormal, "EnvoyFilterDeleted", "deleted envoyfilter %s", loc)
+			}
 			return reconcile.Result{}, err
 		}
 	}
@@ -78,7 +78,7 @@ func (r *SmartLimiterReconciler) handleEvent(loc types.NamespacedName) metric.Qu
 	}
 
 	// handle loc which is in interest map in inbound scenario
-	if !r.cfg.GetDisableAdaptive() {
+	if !r.config().GetDisableAdaptive() {
 		return r.handlePrometheusEvent(meta, loc)
 	}
 	// unify mesh and gateway in local event
@@ -118,12 +118,11 @@ func (r *SmartLimiterReconciler) handlePrometheusEvent(
 	limiterMeta SmartLimiterMeta,
 	loc types.NamespacedName,
 ) metric.QueryMap {
-	if r.env.Config == nil || r.env.Config.Metric == nil ||
-		r.env.Config.Metric.Prometheus == nil || r.env.Config.Metric.Prometheus.Handlers == nil {
+	handlers := metricHandlers(r.env)
+	if handlers == nil {
 		log.Infof("query handler is empty, skip query")
 		return nil
 	}
-	handlers := r.env.Config.Metric.Prometheus.Handlers
 
 	// TODO workloadSelector
 	if len(limiterMeta.workloadSelector) > 0 {
@@ -328,6 +327,14 @@ func replaceQueryString(
 	return handlers, isGroup
 }
 
+// metricHandlers returns the query handlers of the otlp source if configured, otherwise the prometheus ones.
+func metricHandlers(env bootstrap.Environment) map[string]*v1alpha1.Prometheus_Source_Handler {
+	if otlp := env.Config.GetMetric().GetOtlp(); otlp != nil {
+		return otlp.GetHandlers()
+	}
+	return env.Config.GetMetric().GetPrometheus().GetHandlers()
+}
+
 func newPrometheusSourceConfig(env bootstrap.Environment) (metric.PrometheusSourceConfig, error) {
 	if env.Config == nil || env.Config.Metric == nil || env.Config.Metric.Prometheus == nil {
 		return metric.PrometheusSourceConfig{}, stderrors.New("failure create prometheus client, empty prometheus config")
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	cmap "github.com/orcaman/concurrent-map/v2"
@@ -33,6 +34,7 @@ import (
 
 	"slime.io/slime/framework/bootstrap"
 	slime_model "slime.io/slime/framework/model"
+	"slime.io/slime/framework/model/event"
 	"slime.io/slime/framework/model/metric"
 	"slime.io/slime/framework/model/trigger"
 	"slime.io/slime/framework/util"
@@ -47,7 +49,8 @@ type SmartLimiterReconciler struct {
 	sync.RWMutex
 	Scheme *runtime.Scheme
 
-	cfg *config.Limiter
+	// cfg may be replaced by reloading the module config, use config() to read it
+	cfg atomic.Pointer[config.Limiter]
 	env bootstrap.Environment
 	// key is limiter's namespace and name
 	// value is the SmartLimiterMeta
@@ -61,6 +64,9 @@ type SmartLimiterReconciler struct {
 	watcherMetricChan <-chan metric.Metric
 	tickerMetricChan  <-chan metric.Metric
 	Source            metric.Source
+
+	// eventRecorder records the events on the smartlimiters, nil to skip
+	eventRecorder event.Recorder
 }
 
 //nolint: lll
@@ -68,6 +74,7 @@ type SmartLimiterReconciler struct {
 // +kubebuilder:rbac:groups=microservice.slime.io,resources=smartlimiters/status,verbs=get;update;patch
 
 func (r *SmartLimiterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.WithContext(ctx)
 	log.Infof("begin reconcile, get smartlimiter %+v", req)
 	ReconcilesTotal.Increment()
 
@@ -106,7 +113,7 @@ func (r *SmartLimiterReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	r.RegisterInterest(instance, req, sidecarOutbound, gateway)
 
 	log.Debugf("update start")
-	r.RefreshResource(instance)
+	r.RefreshResource(ctx, instance)
 
 	return ctrl.Result{}, nil
 }
@@ -129,12 +136,18 @@ func NewReconciler(opts ...ReconcilerOpts) *SmartLimiterReconciler {
 	return r
 }
 
+// Clear drops the metrics after stopping as a leader. The interest is kept,
+// since the reconciles are held instead of being dropped as a follower and
+// will keep it up to date after becoming the leader again.
 func (r *SmartLimiterReconciler) Clear() {
-	r.interest.Clear()
 	r.metricInfo.Clear()
 }
 
 func NewProducerConfig(env bootstrap.Environment, cfg *config.Limiter) (*metric.ProducerConfig, error) {
+	queryConfig, err := metric.NewQueryConfig(env.Config.GetGlobal().GetMisc())
+	if err != nil {
+		return nil, err
+	}
 	pc := &metric.ProducerConfig{
 		EnableWatcherProducer: false,
 		WatcherProducerConfig: metric.WatcherProducerConfig{
@@ -163,18 +176,29 @@ func NewProducerConfig(env bootstrap.Environment, cfg *config.Limiter) (*metric.
 				EventChan: make(chan trigger.TickerEvent),
 			},
 		},
-		StopChan: env.Stop,
+		QueryConfig: queryConfig,
+		StopChan:    env.Stop,
 	}
 
 	if !cfg.GetDisableAdaptive() {
 		log.Info("enable adaptive ratelimiter")
-		prometheusSourceConfig, err := newPrometheusSourceConfig(env)
-		if err != nil {
-			return nil, err
+		if otlp := env.Config.GetMetric().GetOtlp(); otlp != nil {
+			otlpSourceConfig, err := metric.NewOtlpSourceConfig(otlp)
+			if err != nil {
+				return nil, err
+			}
+			log.Infof("use otlp metric source on %s", otlpSourceConfig.ServeAddress)
+			pc.OtlpSourceConfig = otlpSourceConfig
+			pc.EnableOtlpSource = true
+		} else {
+			prometheusSourceConfig, err := newPrometheusSourceConfig(env)
+			if err != nil {
+				return nil, err
+			}
+			log.Infof("create new prometheus client success")
+			pc.PrometheusSourceConfig = prometheusSourceConfig
+			pc.EnablePrometheusSource = true
 		}
-		log.Infof("create new prometheus client success")
-		pc.PrometheusSourceConfig = prometheusSourceConfig
-		pc.EnablePrometheusSource = true
 		pc.EnableWatcherProducer = true
 	} else {
 		log.Info("disable adaptive ratelimiter and promql is closed")
@@ -223,7 +247,7 @@ func (r *SmartLimiterReconciler) RemoveInterested(req ctrl.Request) {
 	count := r.interest.Count()
 	CachedLimiter.Record(float64(count))
 	// if contain global smart limiter, should delete info in configmap
-	if !r.cfg.GetDisableGlobalRateLimit() {
+	if !r.config().GetDisableGlobalRateLimit() {
 		log.Infof("refresh global rate limiter configmap")
 		refreshConfigMap([]*model.Descriptor{}, r, req.NamespacedName)
 	} else {
@@ -259,7 +283,7 @@ func (r *SmartLimiterReconciler) Validate(instance *limiterv1alpha2.SmartLimiter
 			}
 
 			if gateway || sidecarOutbound {
-				if !r.cfg.GetDisableAdaptive() {
+				if !r.config().GetDisableAdaptive() {
 					return sidecarOutbound, gateway, fmt.Errorf("outbound/gw must disable adaptive limiter")
 				}
 				if condition != "true" {
@@ -274,8 +298,8 @@ func (r *SmartLimiterReconciler) Validate(instance *limiterv1alpha2.SmartLimiter
 // RefreshResource refresh smartlimiter and ef on time
 // even if reconcile fails, there are still ticker tasks to refresh
 // only logging errors, no errors return
-func (r *SmartLimiterReconciler) RefreshResource(instance *limiterv1alpha2.SmartLimiter) {
-	log := log.WithField("function", "RefreshResource")
+func (r *SmartLimiterReconciler) RefreshResource(ctx context.Context, instance *limiterv1alpha2.SmartLimiter) {
+	log := log.WithContext(ctx).WithField("function", "RefreshResource")
 
 	queryMap := r.handleEvent(types.NamespacedName{
 		Namespace: instance.Namespace,
@@ -286,14 +310,14 @@ func (r *SmartLimiterReconciler) RefreshResource(instance *limiterv1alpha2.Smart
 		return
 	}
 
-	metric, err := r.Source.QueryMetric(queryMap)
+	metricMap, err := metric.QueryMetric(ctx, r.Source, queryMap)
 	if err != nil {
 		log.Errorf("query metric of %s/%s failed: %s", instance.Namespace, instance.Name, err.Error())
-	} else if len(metric) == 0 {
+	} else if len(metricMap) == 0 {
 		log.Errorf("query metric of %s/%s get empty", instance.Namespace, instance.Name)
 	} else {
-		log.Debugf("get metric %+v", metric)
-		r.ConsumeMetric(metric)
+		log.Debugf("get metric %+v", metricMap)
+		r.ConsumeMetric(ctx, metricMap)
 	}
 }
 
@@ -311,16 +335,31 @@ func ReconcilerWithEnv(env bootstrap.Environment) ReconcilerOpts {
 
 func ReconcilerWithCfg(cfg *config.Limiter) ReconcilerOpts {
 	return func(sr *SmartLimiterReconciler) {
-		sr.cfg = cfg
+		sr.cfg.Store(cfg)
 	}
 }
 
+func (r *SmartLimiterReconciler) config() *config.Limiter {
+	return r.cfg.Load()
+}
+
+// SetConfig replaces the config, which takes effect in the following refreshes of the smartlimiters.
+func (r *SmartLimiterReconciler) SetConfig(cfg *config.Limiter) {
+	r.cfg.Store(cfg)
+}
+
 func ReconcilerWithSource(source metric.Source) ReconcilerOpts {
 	return func(sr *SmartLimiterReconciler) {
 		sr.Source = source
 	}
 }
 
+func ReconcilerWithEventRecorder(recorder event.Recorder) ReconcilerOpts {
+	return func(sr *SmartLimiterReconciler) {
+		sr.eventRecorder = recorder
+	}
+}
+
 func ReconcilerWithProducerConfig(pc *metric.ProducerConfig) ReconcilerOpts {
 	return func(sr *SmartLimiterReconciler) {
 		sr.watcherMetricChan = pc.WatcherProducerConfig.MetricChan
@@ -330,3 +369,9 @@ func ReconcilerWithProducerConfig(pc *metric.ProducerConfig) ReconcilerOpts {
 		pc.TickerProducerConfig.NeedUpdateMetricHandler = sr.handleTickerEvent
 	}
 }
+
+func (r *SmartLimiterReconciler) recordEvent(obj runtime.Object, eventType, reason, messageFmt string, args ...interface{}) {
+	if r.eventRecorder != nil {
+		r.eventRecorder.Eventf(obj, eventType, reason, messageFmt, args...)
+	}
+}
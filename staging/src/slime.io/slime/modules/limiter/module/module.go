@@ -11,7 +11,9 @@ import (
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
+	bootconfig "slime.io/slime/framework/apis/config/v1alpha1"
 	"slime.io/slime/framework/bootstrap"
+	"slime.io/slime/framework/model/event"
 	"slime.io/slime/framework/model/metric"
 	"slime.io/slime/framework/model/module"
 	"slime.io/slime/framework/model/pkg/leaderelection"
@@ -21,6 +23,8 @@ import (
 	"slime.io/slime/modules/limiter/model"
 )
 
+var _ module.ConfigChangeHandler = &Module{}
+
 type Module struct {
 	config config.Limiter
 	env    bootstrap.Environment
@@ -54,8 +58,30 @@ func (m *Module) Clone() module.Module {
 	return &ret
 }
 
+// ReloadableFields implements module.ConfigChangeHandler, the fields are only used to render the envoyfilters.
+func (m *Module) ReloadableFields() []string {
+	return []string{
+		"general.proxyVersion",
+		"general.enableRatelimitPlaceholderInRoute",
+	}
+}
+
+// OnConfigChange implements module.ConfigChangeHandler. The envoyfilters are rendered with the new config in the
+// following refreshes of the smartlimiters.
+func (m *Module) OnConfigChange(_ *bootconfig.Config, modCfg proto.Message) error {
+	cfg, ok := modCfg.(*config.Limiter)
+	if !ok {
+		return fmt.Errorf("unexpected limiter config type %T", modCfg)
+	}
+	if m.sr == nil {
+		return fmt.Errorf("limiter is not set up")
+	}
+	m.sr.SetConfig(cfg)
+	return nil
+}
+
 func (m *Module) Setup(opts module.ModuleOptions) error {
-	if err := m.init(opts.Env); err != nil {
+	if err := m.init(opts.Env, opts.EventRecorder); err != nil {
 		return err
 	}
 
@@ -69,7 +95,7 @@ func (m *Module) Setup(opts module.ModuleOptions) error {
 	return nil
 }
 
-func (m *Module) init(env bootstrap.Environment) error {
+func (m *Module) init(env bootstrap.Environment, recorder event.Recorder) error {
 	m.env = env
 	pc, err := controllers.NewProducerConfig(m.env, &m.config)
 	if err != nil {
@@ -82,6 +108,7 @@ func (m *Module) init(env bootstrap.Environment) error {
 		controllers.ReconcilerWithEnv(m.env),
 		controllers.ReconcilerWithProducerConfig(m.pc),
 		controllers.ReconcilerWithSource(source),
+		controllers.ReconcilerWithEventRecorder(recorder),
 	)
 	return nil
 }
@@ -99,7 +126,10 @@ func (m *Module) setupWithManager(mgr manager.Manager) error {
 func (m *Module) setupWithLeaderElection(le leaderelection.LeaderCallbacks) error {
 	le.AddOnStartedLeading(func(ctx context.Context) {
 		log.Infof("producers starts")
-		metric.NewProducer(m.pc, m.sr.Source)
+		// producers are stopped at the end of the leader term
+		termPc := *m.pc
+		termPc.StopChan = ctx.Done()
+		metric.NewProducer(&termPc, m.sr.Source)
 
 		go m.sr.WatchMetric(ctx)
 	})
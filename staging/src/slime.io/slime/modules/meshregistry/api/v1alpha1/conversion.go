@@ -2,10 +2,12 @@ package v1alpha1
 
 import (
 	"fmt"
+	"reflect"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"slime.io/slime/modules/meshregistry/pkg/bootstrap"
+	"slime.io/slime/modules/meshregistry/pkg/features"
 )
 
 func ConvertRegistrySourceToArgs(in *RegistrySource, out *bootstrap.RegistryArgs) error {
@@ -78,3 +80,232 @@ func convertZookeeperSource(in *Zookeeper, out *bootstrap.ZookeeperSourceArgs) {
 		postCovertZookeeperSourceHook(out)
 	}
 }
+
+// Credentials is the username and password resolved from a `CredentialsSecretRef`.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// CredentialsResolver resolves the credentials referred by a RegistrySource in the namespace.
+type CredentialsResolver func(namespace string, ref *CredentialsSecretRef) (*Credentials, error)
+
+// RegistryConnectionStatusKey returns the source name and the registry id under which the status of the connection
+// is reported by the source.
+func RegistryConnectionStatusKey(rs *RegistrySource) (string, string) {
+	if rs.Spec.Connection == nil {
+		return "", ""
+	}
+	if rs.Spec.Connection.Type == RegistryTypeZookeeper {
+		// zookeeper supports only one connection, which is reported without registry id
+		return string(RegistryTypeZookeeper), ""
+	}
+	return string(rs.Spec.Connection.Type), rs.Name
+}
+
+// MergeRegistryConnectionsToArgs adds the connections of the RegistrySources to the sources of `out` in order, the
+// ones without connection are ignored. The args of a source must be configured before any connection can be added to
+// it, and the source will be enabled. It returns the errors of the RegistrySources whose connections can not be
+// added, keyed by name.
+func MergeRegistryConnectionsToArgs(
+	in []*RegistrySource,
+	resolve CredentialsResolver,
+	out *bootstrap.RegistryArgs,
+) map[string]error {
+	m := &connectionMerger{
+		args:         out,
+		resolve:      resolve,
+		namingOwners: map[RegistryType]string{},
+		scoped:       map[RegistryType]*scopedSelectors{},
+	}
+	errs := map[string]error{}
+	for _, rs := range in {
+		if rs.Spec.Connection == nil {
+			continue
+		}
+		if err := m.merge(rs); err != nil {
+			errs[rs.Name] = err
+		}
+	}
+	m.complete()
+	return errs
+}
+
+// scopedSelectors tracks the endpoint selectors scoped to connections by the registry id.
+type scopedSelectors struct {
+	args *bootstrap.SourceArgs
+	// whether the source-scoped selectors are empty, which means all the instances, before the scoped ones are added
+	selectAll   bool
+	registryIDs []string
+}
+
+type connectionMerger struct {
+	args    *bootstrap.RegistryArgs
+	resolve CredentialsResolver
+
+	zookeeperOwner string
+	namingOwners   map[RegistryType]string
+	scoped         map[RegistryType]*scopedSelectors
+}
+
+func (m *connectionMerger) merge(rs *RegistrySource) error {
+	conn := rs.Spec.Connection
+	if len(conn.Addresses) == 0 {
+		return fmt.Errorf("no address of the %s registry", conn.Type)
+	}
+
+	var srcArgs *bootstrap.SourceArgs
+	switch conn.Type {
+	case RegistryTypeZookeeper:
+		if m.args.ZookeeperSource == nil {
+			return fmt.Errorf("zookeeper source is not configured")
+		}
+		if m.zookeeperOwner != "" {
+			return fmt.Errorf("only one zookeeper connection is supported, which is used by %s", m.zookeeperOwner)
+		}
+		srcArgs = &m.args.ZookeeperSource.SourceArgs
+	case RegistryTypeNacos:
+		if m.args.NacosSource == nil {
+			return fmt.Errorf("nacos source is not configured")
+		}
+		srcArgs = &m.args.NacosSource.SourceArgs
+	case RegistryTypeEureka:
+		if m.args.EurekaSource == nil {
+			return fmt.Errorf("eureka source is not configured")
+		}
+		srcArgs = &m.args.EurekaSource.SourceArgs
+	default:
+		return fmt.Errorf("unsupported registry type %q", conn.Type)
+	}
+
+	naming := convertServiceNaming(conn.Naming)
+	if owner, ok := m.namingOwners[conn.Type]; ok && naming != nil && !reflect.DeepEqual(srcArgs.ServiceNaming, naming) {
+		return fmt.Errorf("naming conflicts with %s, the naming of the %s source is shared by all its connections",
+			owner, conn.Type)
+	}
+
+	var cred Credentials
+	if conn.CredentialsSecretRef != nil {
+		if m.resolve == nil {
+			return fmt.Errorf("can not resolve the credentials secret %s", conn.CredentialsSecretRef.Name)
+		}
+		c, err := m.resolve(rs.Namespace, conn.CredentialsSecretRef)
+		if err != nil {
+			return fmt.Errorf("resolve credentials failed: %w", err)
+		}
+		cred = *c
+	}
+
+	switch conn.Type {
+	case RegistryTypeZookeeper:
+		args := m.args.ZookeeperSource
+		m.zookeeperOwner = rs.Name
+		args.Address = conn.Addresses
+		args.Username, args.Password = cred.Username, cred.Password
+		// no need to scope the selectors as there's only one connection
+		for i := range conn.Selectors {
+			args.EndpointSelectors = append(args.EndpointSelectors, &bootstrap.EndpointSelector{
+				LabelSelector: conn.Selectors[i].DeepCopy(),
+			})
+		}
+	case RegistryTypeNacos:
+		args := m.args.NacosSource
+		if len(args.Servers) == 0 && len(args.NacosServer.Address) > 0 {
+			// the embedded server is ignored once there're any servers
+			args.Servers = []bootstrap.NacosServer{args.NacosServer}
+		}
+		server := bootstrap.NacosServer{
+			RegistryID: rs.Name,
+			Address:    conn.Addresses,
+			Username:   cred.Username,
+			Password:   cred.Password,
+		}
+		if conn.Nacos != nil {
+			server.Namespace = conn.Nacos.Namespace
+			server.Group = conn.Nacos.Group
+			server.NamespaceGroups = conn.Nacos.NamespaceGroups
+			server.AllNamespaces = conn.Nacos.AllNamespaces
+		}
+		args.Servers = append(args.Servers, server)
+		m.addScopedSelectors(conn.Type, srcArgs, rs.Name, conn.Selectors)
+	case RegistryTypeEureka:
+		args := m.args.EurekaSource
+		if len(args.Servers) == 0 && len(args.EurekaServer.Address) > 0 {
+			args.Servers = []bootstrap.EurekaServer{args.EurekaServer}
+		}
+		args.Servers = append(args.Servers, bootstrap.EurekaServer{
+			RegistryID: rs.Name,
+			Address:    conn.Addresses,
+			Username:   cred.Username,
+			Password:   cred.Password,
+		})
+		m.addScopedSelectors(conn.Type, srcArgs, rs.Name, conn.Selectors)
+	}
+
+	srcArgs.Enabled = true
+	if naming != nil {
+		srcArgs.ServiceNaming = naming
+		m.namingOwners[conn.Type] = rs.Name
+	}
+	return nil
+}
+
+// addScopedSelectors adds the selectors of a connection of the source with multiple servers, they are scoped to the
+// connection by matching the registry id injected into the instance metadata.
+func (m *connectionMerger) addScopedSelectors(
+	typ RegistryType,
+	args *bootstrap.SourceArgs,
+	registryID string,
+	selectors []metav1.LabelSelector,
+) {
+	if len(selectors) == 0 {
+		return
+	}
+	scoped := m.scoped[typ]
+	if scoped == nil {
+		scoped = &scopedSelectors{args: args, selectAll: len(args.EndpointSelectors) == 0}
+		m.scoped[typ] = scoped
+	}
+	scoped.registryIDs = append(scoped.registryIDs, registryID)
+	for i := range selectors {
+		sel := selectors[i].DeepCopy()
+		if sel.MatchLabels == nil {
+			sel.MatchLabels = map[string]string{}
+		}
+		sel.MatchLabels[features.RegistryIDMetaKey] = registryID
+		args.EndpointSelectors = append(args.EndpointSelectors, &bootstrap.EndpointSelector{LabelSelector: sel})
+	}
+}
+
+// complete keeps selecting all the instances of the other servers if the source selected all before the scoped
+// selectors are added.
+func (m *connectionMerger) complete() {
+	for _, scoped := range m.scoped {
+		if !scoped.selectAll || scoped.args.EmptyEpSelectorsExcludeAll {
+			continue
+		}
+		scoped.args.EndpointSelectors = append(scoped.args.EndpointSelectors, &bootstrap.EndpointSelector{
+			LabelSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{
+					Key:      features.RegistryIDMetaKey,
+					Operator: metav1.LabelSelectorOpNotIn,
+					Values:   scoped.registryIDs,
+				}},
+			},
+		})
+	}
+}
+
+func convertServiceNaming(in *ServiceNaming) *bootstrap.ServiceNameConverter {
+	if in == nil {
+		return nil
+	}
+	out := &bootstrap.ServiceNameConverter{Sep: in.Sep}
+	for _, item := range in.Items {
+		out.Items = append(out.Items, bootstrap.ServiceNamingItem{
+			Kind:  bootstrap.ServiceNameItemKind(item.Kind),
+			Value: item.Value,
+		})
+	}
+	return out
+}
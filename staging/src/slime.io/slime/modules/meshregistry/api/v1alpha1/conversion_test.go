@@ -0,0 +1,114 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"slime.io/slime/modules/meshregistry/pkg/bootstrap"
+	"slime.io/slime/modules/meshregistry/pkg/features"
+)
+
+func TestMergeRegistryConnectionsToArgs(t *testing.T) {
+	rs := func(name string, conn *RegistryConnection) *RegistrySource {
+		return &RegistrySource{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "mesh"},
+			Spec:       RegistrySourceSpec{Connection: conn},
+		}
+	}
+	resolve := func(namespace string, ref *CredentialsSecretRef) (*Credentials, error) {
+		if ref.Name != "nacos-auth" {
+			return nil, fmt.Errorf("secret %s/%s not found", namespace, ref.Name)
+		}
+		return &Credentials{Username: "user", Password: "pass"}, nil
+	}
+	args := &bootstrap.RegistryArgs{
+		ZookeeperSource: &bootstrap.ZookeeperSourceArgs{Address: []string{"zk-static:2181"}},
+		NacosSource: &bootstrap.NacosSourceArgs{
+			NacosServer: bootstrap.NacosServer{Address: []string{"nacos-static:8848"}},
+		},
+	}
+	naming := &ServiceNaming{Sep: ".", Items: []ServiceNamingItem{{Kind: "$", Value: "service"}}}
+
+	errs := MergeRegistryConnectionsToArgs([]*RegistrySource{
+		rs("legacy", nil),
+		rs("nacos-a", &RegistryConnection{
+			Type:                 RegistryTypeNacos,
+			Addresses:            []string{"nacos-a:8848"},
+			CredentialsSecretRef: &CredentialsSecretRef{Name: "nacos-auth"},
+			Selectors:            []metav1.LabelSelector{{MatchLabels: map[string]string{"env": "prod"}}},
+			Naming:               naming,
+			Nacos:                &NacosConnection{Namespace: "public"},
+		}),
+		rs("nacos-b", &RegistryConnection{
+			Type:      RegistryTypeNacos,
+			Addresses: []string{"nacos-b:8848"},
+			Naming:    &ServiceNaming{Sep: "-"},
+		}),
+		rs("nacos-c", &RegistryConnection{
+			Type:                 RegistryTypeNacos,
+			Addresses:            []string{"nacos-c:8848"},
+			CredentialsSecretRef: &CredentialsSecretRef{Name: "missing"},
+		}),
+		rs("zk-a", &RegistryConnection{Type: RegistryTypeZookeeper, Addresses: []string{"zk-a:2181"}}),
+		rs("zk-b", &RegistryConnection{Type: RegistryTypeZookeeper, Addresses: []string{"zk-b:2181"}}),
+		rs("eureka", &RegistryConnection{Type: RegistryTypeEureka, Addresses: []string{"eureka:8761"}}),
+	}, resolve, args)
+
+	var failed []string
+	for name := range errs {
+		failed = append(failed, name)
+	}
+	assert.ElementsMatch(t, []string{"eureka", "nacos-b", "nacos-c", "zk-b"}, failed)
+	assert.Contains(t, errs["eureka"].Error(), "not configured")
+	assert.Contains(t, errs["nacos-b"].Error(), "naming conflicts with nacos-a")
+	assert.Contains(t, errs["nacos-c"].Error(), "resolve credentials failed")
+	assert.Contains(t, errs["zk-b"].Error(), "used by zk-a")
+
+	assert.True(t, args.ZookeeperSource.Enabled)
+	assert.Equal(t, []string{"zk-a:2181"}, args.ZookeeperSource.Address)
+
+	nacos := args.NacosSource
+	assert.True(t, nacos.Enabled)
+	assert.Equal(t, []bootstrap.NacosServer{
+		{Address: []string{"nacos-static:8848"}},
+		{
+			RegistryID: "nacos-a",
+			Address:    []string{"nacos-a:8848"},
+			Namespace:  "public",
+			Username:   "user",
+			Password:   "pass",
+		},
+	}, nacos.Servers)
+	assert.Equal(t, &bootstrap.ServiceNameConverter{
+		Sep:   ".",
+		Items: []bootstrap.ServiceNamingItem{{Kind: bootstrap.InstanceBasicInfoKind, Value: "service"}},
+	}, nacos.ServiceNaming)
+	// the selectors are scoped to the connection, and the other servers are still selected
+	assert.Equal(t, []*bootstrap.EndpointSelector{
+		{LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{
+			"env":                      "prod",
+			features.RegistryIDMetaKey: "nacos-a",
+		}}},
+		{LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+			Key:      features.RegistryIDMetaKey,
+			Operator: metav1.LabelSelectorOpNotIn,
+			Values:   []string{"nacos-a"},
+		}}}},
+	}, nacos.EndpointSelectors)
+}
+
+func TestRegistryConnectionStatusKey(t *testing.T) {
+	rs := &RegistrySource{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}
+	rs.Spec.Connection = &RegistryConnection{Type: RegistryTypeNacos}
+	src, id := RegistryConnectionStatusKey(rs)
+	assert.Equal(t, "nacos", src)
+	assert.Equal(t, "foo", id)
+
+	rs.Spec.Connection.Type = RegistryTypeZookeeper
+	src, id = RegistryConnectionStatusKey(rs)
+	assert.Equal(t, "zookeeper", src)
+	assert.Equal(t, "", id)
+}
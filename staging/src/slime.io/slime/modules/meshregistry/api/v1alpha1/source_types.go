@@ -48,22 +48,122 @@ type Zookeeper struct {
 	AbnormalInstanceIPs map[string][]string `json:"abnormalInstanceIPs,omitempty"`
 }
 
+// RegistryType is the type of the registry of a connection
+type RegistryType string
+
+const (
+	RegistryTypeZookeeper RegistryType = "zookeeper"
+	RegistryTypeNacos     RegistryType = "nacos"
+	RegistryTypeEureka    RegistryType = "eureka"
+)
+
+// CredentialsSecretRef refers to a Secret in the namespace of the RegistrySource which holds the credentials
+type CredentialsSecretRef struct {
+	// Name of the Secret
+	Name string `json:"name"`
+	// UsernameKey is the key of the username in the Secret, default `username`
+	// +optional
+	UsernameKey string `json:"usernameKey,omitempty"`
+	// PasswordKey is the key of the password in the Secret, default `password`
+	// +optional
+	PasswordKey string `json:"passwordKey,omitempty"`
+}
+
+// NacosConnection is the nacos specific settings of a connection
+type NacosConnection struct {
+	// Namespace and Group to fetch the services from, ignored if NamespaceGroups is set
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// +optional
+	Group string `json:"group,omitempty"`
+	// NamespaceGroups specifies multiple namespaces and groups, like `ns1:g1,g2`
+	// +optional
+	NamespaceGroups []string `json:"namespaceGroups,omitempty"`
+	// AllNamespaces fetches the services from all the namespaces
+	// +optional
+	AllNamespaces bool `json:"allNamespaces,omitempty"`
+}
+
+// ServiceNamingItem is a substring of the service name, see the `ServiceNaming` of the source args
+type ServiceNamingItem struct {
+	// Kind is one of `$`, `meta` and `static`
+	Kind  string `json:"kind,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+// ServiceNaming reassigns the service to which the instance belongs
+type ServiceNaming struct {
+	Sep   string              `json:"sep,omitempty"`
+	Items []ServiceNamingItem `json:"items,omitempty"`
+}
+
+// RegistryConnection defines a connection to a registry
+type RegistryConnection struct {
+	// +kubebuilder:validation:Enum=zookeeper;nacos;eureka
+	Type RegistryType `json:"type"`
+	// Addresses of the registry servers
+	// +kubebuilder:validation:MinItems=1
+	Addresses []string `json:"addresses"`
+	// CredentialsSecretRef refers to the Secret holding the username and password of the registry
+	// +optional
+	CredentialsSecretRef *CredentialsSecretRef `json:"credentialsSecretRef,omitempty"`
+	// Selectors select the instances of this connection to be processed, they are ORed together and with the
+	// source-scoped endpoint selectors. Empty means all the instances.
+	// +optional
+	Selectors []metav1.LabelSelector `json:"selectors,omitempty"`
+	// Naming is applied to the whole source of the type, as the naming is source-scoped.
+	// +optional
+	Naming *ServiceNaming `json:"naming,omitempty"`
+	// +optional
+	Nacos *NacosConnection `json:"nacos,omitempty"`
+}
+
 // RegistrySourceSpec defines the desired state of RegistrySource
 type RegistrySourceSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
 	Zookeeper *Zookeeper `json:"zookeeper,omitempty"`
+
+	// Connection defines a registry connection, which is added to the source of its type.
+	// Only takes effect when the connections are watched, see env `WATCHING_REGISTRYSOURCE_CONNECTIONS`.
+	// +optional
+	Connection *RegistryConnection `json:"connection,omitempty"`
 }
 
 // RegistrySourceStatus defines the observed state of RegistrySource
 type RegistrySourceStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
+
+	// ObservedGeneration is the generation of the spec the status is observed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Connected reports whether the registry is reachable
+	// +optional
+	Connected bool `json:"connected,omitempty"`
+	// LastSyncTime is the time of the last successful sync with the registry
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+	// Services and Instances are the counts fetched by the last successful sync
+	// +optional
+	Services int `json:"services,omitempty"`
+	// +optional
+	Instances int `json:"instances,omitempty"`
+	// Error is the error of the connection, or why the connection can not be applied
+	// +optional
+	Error string `json:"error,omitempty"`
+	// +optional
+	ErrorTime *metav1.Time `json:"errorTime,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.connection.type`
+//+kubebuilder:printcolumn:name="Connected",type=boolean,JSONPath=`.status.connected`
+//+kubebuilder:printcolumn:name="Services",type=integer,JSONPath=`.status.services`
+//+kubebuilder:printcolumn:name="Instances",type=integer,JSONPath=`.status.instances`
+//+kubebuilder:printcolumn:name="LastSync",type=date,JSONPath=`.status.lastSyncTime`
 
 // RegistrySource is the Schema for the RegistrySource API
 type RegistrySource struct {
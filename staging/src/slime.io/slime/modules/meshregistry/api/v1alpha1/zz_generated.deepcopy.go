@@ -22,9 +22,25 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialsSecretRef) DeepCopyInto(out *CredentialsSecretRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialsSecretRef.
+func (in *CredentialsSecretRef) DeepCopy() *CredentialsSecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialsSecretRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DubboInterface) DeepCopyInto(out *DubboInterface) {
 	*out = *in
@@ -40,13 +56,75 @@ func (in *DubboInterface) DeepCopy() *DubboInterface {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NacosConnection) DeepCopyInto(out *NacosConnection) {
+	*out = *in
+	if in.NamespaceGroups != nil {
+		in, out := &in.NamespaceGroups, &out.NamespaceGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NacosConnection.
+func (in *NacosConnection) DeepCopy() *NacosConnection {
+	if in == nil {
+		return nil
+	}
+	out := new(NacosConnection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegistryConnection) DeepCopyInto(out *RegistryConnection) {
+	*out = *in
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CredentialsSecretRef != nil {
+		in, out := &in.CredentialsSecretRef, &out.CredentialsSecretRef
+		*out = new(CredentialsSecretRef)
+		**out = **in
+	}
+	if in.Selectors != nil {
+		in, out := &in.Selectors, &out.Selectors
+		*out = make([]v1.LabelSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Naming != nil {
+		in, out := &in.Naming, &out.Naming
+		*out = new(ServiceNaming)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Nacos != nil {
+		in, out := &in.Nacos, &out.Nacos
+		*out = new(NacosConnection)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegistryConnection.
+func (in *RegistryConnection) DeepCopy() *RegistryConnection {
+	if in == nil {
+		return nil
+	}
+	out := new(RegistryConnection)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RegistrySource) DeepCopyInto(out *RegistrySource) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegistrySource.
@@ -107,6 +185,11 @@ func (in *RegistrySourceSpec) DeepCopyInto(out *RegistrySourceSpec) {
 		*out = new(Zookeeper)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Connection != nil {
+		in, out := &in.Connection, &out.Connection
+		*out = new(RegistryConnection)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegistrySourceSpec.
@@ -122,6 +205,14 @@ func (in *RegistrySourceSpec) DeepCopy() *RegistrySourceSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RegistrySourceStatus) DeepCopyInto(out *RegistrySourceStatus) {
 	*out = *in
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ErrorTime != nil {
+		in, out := &in.ErrorTime, &out.ErrorTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegistrySourceStatus.
@@ -134,6 +225,41 @@ func (in *RegistrySourceStatus) DeepCopy() *RegistrySourceStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceNaming) DeepCopyInto(out *ServiceNaming) {
+	*out = *in
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ServiceNamingItem, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceNaming.
+func (in *ServiceNaming) DeepCopy() *ServiceNaming {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceNaming)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceNamingItem) DeepCopyInto(out *ServiceNamingItem) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceNamingItem.
+func (in *ServiceNamingItem) DeepCopy() *ServiceNamingItem {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceNamingItem)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Zookeeper) DeepCopyInto(out *Zookeeper) {
 	*out = *in
@@ -6,5 +6,3 @@ import (
 )
 
 var log = model.ModuleLog.WithField(frameworkmodel.LogFieldKeyPkg, "controllers")
-
-var _ = log
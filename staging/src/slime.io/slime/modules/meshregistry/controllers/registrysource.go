@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+
+	meshregv1alpha1 "slime.io/slime/modules/meshregistry/api/v1alpha1"
+	"slime.io/slime/modules/meshregistry/pkg/source"
+)
+
+const (
+	defaultUsernameKey = "username"
+	defaultPasswordKey = "password"
+
+	registrySourceStatusSyncPeriod = 10 * time.Second
+)
+
+// NewSecretCredentialsResolver returns the resolver which reads the credentials from the Secrets in the store.
+func NewSecretCredentialsResolver(store cache.Store) meshregv1alpha1.CredentialsResolver {
+	return func(namespace string, ref *meshregv1alpha1.CredentialsSecretRef) (*meshregv1alpha1.Credentials, error) {
+		obj, exist, err := store.GetByKey(namespace + "/" + ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		if !exist {
+			return nil, fmt.Errorf("secret %s/%s not found", namespace, ref.Name)
+		}
+		secret, ok := obj.(*corev1.Secret)
+		if !ok {
+			return nil, fmt.Errorf("not secret")
+		}
+
+		usernameKey, passwordKey := ref.UsernameKey, ref.PasswordKey
+		if usernameKey == "" {
+			usernameKey = defaultUsernameKey
+		}
+		if passwordKey == "" {
+			passwordKey = defaultPasswordKey
+		}
+		username, ok := secret.Data[usernameKey]
+		if !ok {
+			return nil, fmt.Errorf("key %s not found in secret %s/%s", usernameKey, namespace, ref.Name)
+		}
+		password, ok := secret.Data[passwordKey]
+		if !ok {
+			return nil, fmt.Errorf("key %s not found in secret %s/%s", passwordKey, namespace, ref.Name)
+		}
+		return &meshregv1alpha1.Credentials{Username: string(username), Password: string(password)}, nil
+	}
+}
+
+// ReferencesSecret returns whether any of the RegistrySources in the store refers to the Secret.
+func ReferencesSecret(store cache.Store, namespace, name string) bool {
+	for _, rs := range ListRegistrySources(store) {
+		conn := rs.Spec.Connection
+		if rs.Namespace == namespace && conn != nil && conn.CredentialsSecretRef != nil &&
+			conn.CredentialsSecretRef.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ListRegistrySources converts the unstructured RegistrySources in the store, the invalid ones are skipped.
+func ListRegistrySources(store cache.Store) []*meshregv1alpha1.RegistrySource {
+	items := store.List()
+	ret := make([]*meshregv1alpha1.RegistrySource, 0, len(items))
+	for _, item := range items {
+		u, ok := item.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		rs := &meshregv1alpha1.RegistrySource{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), rs); err != nil {
+			log.Errorf("convert RegistrySource %s/%s failed: %v", u.GetNamespace(), u.GetName(), err)
+			continue
+		}
+		ret = append(ret, rs)
+	}
+	return ret
+}
+
+// RegistrySourceStatusController reports the observed status of the registry connections to the RegistrySources.
+type RegistrySourceStatusController struct {
+	client dynamic.Interface
+	store  cache.Store
+
+	mut sync.RWMutex
+	// mergeErrs is the errors of the connections which can not be added to the sources, keyed by namespaced name
+	mergeErrs map[string]error
+}
+
+func NewRegistrySourceStatusController(client dynamic.Interface, store cache.Store) *RegistrySourceStatusController {
+	return &RegistrySourceStatusController{
+		client: client,
+		store:  store,
+	}
+}
+
+// SetMergeErrors sets the errors of the RegistrySources in the namespace whose connections can not be added.
+func (c *RegistrySourceStatusController) SetMergeErrors(namespace string, errs map[string]error) {
+	nsErrs := make(map[string]error, len(errs))
+	for name, err := range errs {
+		nsErrs[namespace+"/"+name] = err
+	}
+	c.mut.Lock()
+	c.mergeErrs = nsErrs
+	c.mut.Unlock()
+}
+
+func (c *RegistrySourceStatusController) getMergeError(namespace, name string) error {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	return c.mergeErrs[namespace+"/"+name]
+}
+
+// Run syncs the status periodically until the ctx is done. It should only be run by the leader.
+func (c *RegistrySourceStatusController) Run(ctx context.Context) {
+	log.Infof("start to report the status of RegistrySources")
+	ticker := time.NewTicker(registrySourceStatusSyncPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.sync(ctx)
+		}
+	}
+}
+
+func (c *RegistrySourceStatusController) sync(ctx context.Context) {
+	for _, rs := range ListRegistrySources(c.store) {
+		if rs.Spec.Connection == nil {
+			continue
+		}
+		srcName, registryID := meshregv1alpha1.RegistryConnectionStatusKey(rs)
+		st, found := source.GetRegistryStatus(srcName, registryID)
+		status := BuildRegistrySourceStatus(rs, st, found, c.getMergeError(rs.Namespace, rs.Name))
+		if equality.Semantic.DeepEqual(rs.Status, status) {
+			continue
+		}
+		if err := c.updateStatus(ctx, rs, status); err != nil {
+			log.Errorf("update status of RegistrySource %s/%s failed: %v", rs.Namespace, rs.Name, err)
+		}
+	}
+}
+
+func (c *RegistrySourceStatusController) updateStatus(
+	ctx context.Context,
+	rs *meshregv1alpha1.RegistrySource,
+	status meshregv1alpha1.RegistrySourceStatus,
+) error {
+	rs = rs.DeepCopy()
+	rs.Status = status
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(rs)
+	if err != nil {
+		return err
+	}
+	u := &unstructured.Unstructured{Object: content}
+	u.SetGroupVersionKind(meshregv1alpha1.GroupVersion.WithKind("RegistrySource"))
+	_, err = c.client.Resource(meshregv1alpha1.RegistrySourcesResource).Namespace(rs.Namespace).
+		UpdateStatus(ctx, u, metav1.UpdateOptions{})
+	return err
+}
+
+// BuildRegistrySourceStatus builds the status of the RegistrySource from the observed status of its connection,
+// `found` reports whether the connection has been observed. The merge error, if any, takes precedence as the
+// connection is not in use.
+func BuildRegistrySourceStatus(
+	rs *meshregv1alpha1.RegistrySource,
+	st source.RegistryStatus,
+	found bool,
+	mergeErr error,
+) meshregv1alpha1.RegistrySourceStatus {
+	status := meshregv1alpha1.RegistrySourceStatus{ObservedGeneration: rs.Generation}
+	if mergeErr != nil {
+		status.Error = mergeErr.Error()
+		if rs.Status.Error == status.Error && rs.Status.ErrorTime != nil {
+			status.ErrorTime = rs.Status.ErrorTime
+		} else {
+			status.ErrorTime = statusTime(time.Now())
+		}
+		return status
+	}
+	if !found {
+		return status
+	}
+
+	status.Connected = st.Connected
+	status.Services, status.Instances = st.Services, st.Instances
+	status.LastSyncTime = statusTime(st.LastSyncTime)
+	status.Error = st.Error
+	status.ErrorTime = statusTime(st.ErrorTime)
+	return status
+}
+
+// statusTime truncates the time to the precision of the serialized `metav1.Time`, so that it can be compared with
+// the stored one.
+func statusTime(t time.Time) *metav1.Time {
+	if t.IsZero() {
+		return nil
+	}
+	mt := metav1.NewTime(t.Truncate(time.Second))
+	return &mt
+}
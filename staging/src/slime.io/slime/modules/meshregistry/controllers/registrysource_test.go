@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	meshregv1alpha1 "slime.io/slime/modules/meshregistry/api/v1alpha1"
+	"slime.io/slime/modules/meshregistry/pkg/source"
+)
+
+func TestSecretCredentialsResolver(t *testing.T) {
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	_ = store.Add(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "mesh", Name: "auth"},
+		Data: map[string][]byte{
+			"username": []byte("user"),
+			"password": []byte("pass"),
+			"token":    []byte("secret"),
+		},
+	})
+	resolve := NewSecretCredentialsResolver(store)
+
+	cred, err := resolve("mesh", &meshregv1alpha1.CredentialsSecretRef{Name: "auth"})
+	assert.NoError(t, err)
+	assert.Equal(t, &meshregv1alpha1.Credentials{Username: "user", Password: "pass"}, cred)
+
+	cred, err = resolve("mesh", &meshregv1alpha1.CredentialsSecretRef{Name: "auth", PasswordKey: "token"})
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", cred.Password)
+
+	_, err = resolve("mesh", &meshregv1alpha1.CredentialsSecretRef{Name: "auth", UsernameKey: "user"})
+	assert.Error(t, err)
+	_, err = resolve("other", &meshregv1alpha1.CredentialsSecretRef{Name: "auth"})
+	assert.Error(t, err)
+}
+
+func TestBuildRegistrySourceStatus(t *testing.T) {
+	now := time.Now()
+	rs := &meshregv1alpha1.RegistrySource{ObjectMeta: metav1.ObjectMeta{Name: "nacos", Generation: 2}}
+
+	status := BuildRegistrySourceStatus(rs, source.RegistryStatus{
+		Connected:    true,
+		LastSyncTime: now,
+		Services:     3,
+		Instances:    10,
+	}, true, nil)
+	assert.Equal(t, meshregv1alpha1.RegistrySourceStatus{
+		ObservedGeneration: 2,
+		Connected:          true,
+		LastSyncTime:       &metav1.Time{Time: now.Truncate(time.Second)},
+		Services:           3,
+		Instances:          10,
+	}, status)
+
+	// the merge error takes precedence and its time is kept if unchanged
+	errTime := metav1.NewTime(now.Add(-time.Hour).Truncate(time.Second))
+	rs.Status = meshregv1alpha1.RegistrySourceStatus{Error: "nacos source is not configured", ErrorTime: &errTime}
+	status = BuildRegistrySourceStatus(rs, source.RegistryStatus{Connected: true}, true,
+		errors.New("nacos source is not configured"))
+	assert.False(t, status.Connected)
+	assert.Equal(t, &errTime, status.ErrorTime)
+
+	status = BuildRegistrySourceStatus(rs, source.RegistryStatus{}, false, nil)
+	assert.Equal(t, meshregv1alpha1.RegistrySourceStatus{ObservedGeneration: 2}, status)
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"sync"
 	"time"
 
@@ -29,6 +30,7 @@ import (
 	"slime.io/slime/framework/bootstrap"
 	"slime.io/slime/framework/model/module"
 	meshregv1alpha1 "slime.io/slime/modules/meshregistry/api/v1alpha1"
+	"slime.io/slime/modules/meshregistry/controllers"
 	"slime.io/slime/modules/meshregistry/model"
 	meshregbootstrap "slime.io/slime/modules/meshregistry/pkg/bootstrap"
 	"slime.io/slime/modules/meshregistry/pkg/features"
@@ -57,6 +59,8 @@ type Module struct {
 	reloadDynamicConfigTask func(ctx context.Context)
 	dynConfigHandlers       []func(args *meshregbootstrap.RegistryArgs)
 	mut                     sync.RWMutex
+	// registrySourceStatus reports the status of the RegistrySource connections, nil if not watching them.
+	registrySourceStatus *controllers.RegistrySourceStatusController
 }
 
 func (m *Module) Kind() string {
@@ -156,8 +160,10 @@ func (m *Module) prepareDynamicConfigController(
 	//    - or return original static config
 	// 2. init patch configuration Configurator
 	// 	  - watching RegistrySource CR specified by env var WATCHING_REGISTRYSOURCE
+	// 	  - and/or the connections of all RegistrySource CRs if env var WATCHING_REGISTRYSOURCE_CONNECTIONS is true
 	dynCmName, dynRegistrySource := features.DynamicConfigMap, features.WatchingRegistrySource
-	if dynCmName == "" && dynRegistrySource == "" {
+	watchConnections := features.WatchingRegistrySourceConnections
+	if dynCmName == "" && dynRegistrySource == "" && !watchConnections {
 		return nil, nil
 	}
 
@@ -181,10 +187,11 @@ func (m *Module) prepareDynamicConfigController(
 		}
 	}
 
-	if dynRegistrySource != "" {
-		patchConfigurator, wait = m.prepareCrDynamicConfigController(dynRegistrySource, changeNotifyCh, opts)
+	if dynRegistrySource != "" || watchConnections {
+		patchConfigurator, wait = m.prepareCrDynamicConfigController(
+			dynRegistrySource, watchConnections, changeNotifyCh, opts)
 		if !wait() {
-			return nil, fmt.Errorf("failed to wait for configmap cache sync")
+			return nil, fmt.Errorf("failed to wait for registrysource cache sync")
 		}
 	}
 
@@ -330,12 +337,16 @@ func (m *Module) prepareCmDynamicConfigController(
 
 func (m *Module) prepareCrDynamicConfigController(
 	name string,
+	watchConnections bool,
 	changeNotifyCh chan struct{},
 	opts module.ModuleOptions,
 ) (func(*meshregbootstrap.RegistryArgs) (*meshregbootstrap.RegistryArgs, error), func() bool) {
 	client := opts.Env.DynamicClient
 
-	listOptions := metav1.ListOptions{FieldSelector: fields.Set{"metadata.name": name}.AsSelector().String()}
+	listOptions := metav1.ListOptions{}
+	if !watchConnections {
+		listOptions.FieldSelector = fields.Set{"metadata.name": name}.AsSelector().String()
+	}
 	lw := &cache.ListWatch{
 		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
 			return client.Resource(meshregv1alpha1.RegistrySourcesResource).Namespace(PodNamespace).List(context.Background(), listOptions) //nolint: lll
@@ -355,39 +366,109 @@ func (m *Module) prepareCrDynamicConfigController(
 	store, controller := cache.NewInformer(lw, &unstructured.Unstructured{}, 60*time.Second,
 		cache.ResourceEventHandlerFuncs{
 			AddFunc:    func(obj interface{}) { notify(nil, obj) },
-			UpdateFunc: func(oldObj, newObj interface{}) { notify(oldObj, newObj) },
+			UpdateFunc: func(oldObj, newObj interface{}) { notifyOnSpecChange(oldObj, newObj, notify) },
 			DeleteFunc: func(obj interface{}) { notify(obj, nil) },
 		})
 	go controller.Run(opts.Env.Stop)
+	synced := []cache.InformerSynced{controller.HasSynced}
+
+	var resolveCredentials meshregv1alpha1.CredentialsResolver
+	if watchConnections {
+		secretStore, secretSynced := prepareSecretController(store, notify, opts)
+		synced = append(synced, secretSynced)
+		resolveCredentials = controllers.NewSecretCredentialsResolver(secretStore)
+		m.registrySourceStatus = controllers.NewRegistrySourceStatusController(client, store)
+	}
 
 	patcher := func(src *meshregbootstrap.RegistryArgs) (*meshregbootstrap.RegistryArgs, error) {
-		got, exist, err := store.GetByKey(PodNamespace + "/" + name)
-		if err != nil {
-			return nil, err
-		}
-		if !exist {
-			return src, nil
-		}
-		u, ok := got.(*unstructured.Unstructured)
-		if !ok {
-			return nil, fmt.Errorf("not unstructured")
-		}
+		if name != "" {
+			got, exist, err := store.GetByKey(PodNamespace + "/" + name)
+			if err != nil {
+				return nil, err
+			}
+			if exist {
+				u, ok := got.(*unstructured.Unstructured)
+				if !ok {
+					return nil, fmt.Errorf("not unstructured")
+				}
 
-		var rs meshregv1alpha1.RegistrySource
-		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), &rs); err != nil {
-			return nil, err
+				var rs meshregv1alpha1.RegistrySource
+				if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), &rs); err != nil {
+					return nil, err
+				}
+
+				var patch meshregbootstrap.RegistryArgs
+				_ = meshregv1alpha1.ConvertRegistrySourceToArgs(&rs, &patch)
+				if src, err = patchRegistryArgs(src, &patch); err != nil {
+					return nil, err
+				}
+			}
 		}
 
-		var patch meshregbootstrap.RegistryArgs
-		_ = meshregv1alpha1.ConvertRegistrySourceToArgs(&rs, &patch)
-		return patchRegistryArgs(src, &patch)
+		if watchConnections {
+			rss := controllers.ListRegistrySources(store)
+			sort.Slice(rss, func(i, j int) bool { return rss[i].Name < rss[j].Name })
+			errs := meshregv1alpha1.MergeRegistryConnectionsToArgs(rss, resolveCredentials, src)
+			for rsName, err := range errs {
+				log.Errorf("add the connection of RegistrySource %s failed: %v", rsName, err)
+			}
+			m.registrySourceStatus.SetMergeErrors(PodNamespace, errs)
+		}
+		return src, nil
 	}
 
 	return patcher, func() bool {
-		return cache.WaitForCacheSync(opts.Env.Stop, controller.HasSynced)
+		return cache.WaitForCacheSync(opts.Env.Stop, synced...)
 	}
 }
 
+// notifyOnSpecChange ignores the updates of the status reported by ourselves.
+func notifyOnSpecChange(oldObj, newObj interface{}, notify func(_, _ interface{})) {
+	oldU, ok1 := oldObj.(*unstructured.Unstructured)
+	newU, ok2 := newObj.(*unstructured.Unstructured)
+	if ok1 && ok2 && oldU.GetGeneration() == newU.GetGeneration() && oldU.GetGeneration() != 0 {
+		return
+	}
+	notify(oldObj, newObj)
+}
+
+// prepareSecretController watches the Secrets referred by the RegistrySources in the store for the credentials.
+func prepareSecretController(
+	rsStore cache.Store,
+	notify func(_, _ interface{}),
+	opts module.ModuleOptions,
+) (cache.Store, cache.InformerSynced) {
+	client := opts.Env.K8SClient
+
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return client.CoreV1().Secrets(PodNamespace).List(context.Background(), options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return client.CoreV1().Secrets(PodNamespace).Watch(context.Background(), options)
+		},
+	}
+
+	notifyIfReferred := func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		secret, ok := obj.(*corev1.Secret)
+		if ok && controllers.ReferencesSecret(rsStore, secret.Namespace, secret.Name) {
+			notify(nil, obj)
+		}
+	}
+
+	store, controller := cache.NewInformer(lw, &corev1.Secret{}, 60*time.Second, cache.ResourceEventHandlerFuncs{
+		AddFunc:    notifyIfReferred,
+		UpdateFunc: func(_, newObj interface{}) { notifyIfReferred(newObj) },
+		DeleteFunc: notifyIfReferred,
+	})
+	go controller.Run(opts.Env.Stop)
+
+	return store, controller.HasSynced
+}
+
 func patchRegistryArgs(src, patch *meshregbootstrap.RegistryArgs) (*meshregbootstrap.RegistryArgs, error) {
 	if patch == nil {
 		return src, nil
@@ -427,7 +508,7 @@ func (m *Module) Setup(opts module.ModuleOptions) error {
 	}
 
 	if dynRegArgs != nil {
-		bs, err := json.MarshalIndent(regArgs, "", "  ")
+		bs, err := json.MarshalIndent(regArgs.Redacted(), "", "  ")
 		log.Infof("init registry args but override by dynamic reg args: %s, err %v", string(bs), err)
 		regArgs = dynRegArgs.Rectify()
 	}
@@ -436,9 +517,15 @@ func (m *Module) Setup(opts module.ModuleOptions) error {
 		return fmt.Errorf("invalid args for meshregsitry: %w", err)
 	}
 
-	bs, err := json.MarshalIndent(regArgs, "", "  ")
+	bs, err := json.MarshalIndent(regArgs.Redacted(), "", "  ")
 	log.Infof("inuse registry args: %s, err %v", string(bs), err)
 
+	if m.registrySourceStatus != nil && opts.LeaderElectionCbs != nil {
+		opts.LeaderElectionCbs.AddOnStartedLeading(func(ctx context.Context) {
+			go m.registrySourceStatus.Run(ctx)
+		})
+	}
+
 	cbs := opts.InitCbs
 	cbs.AddStartup(func(ctx context.Context) {
 		if m.reloadDynamicConfigTask != nil {
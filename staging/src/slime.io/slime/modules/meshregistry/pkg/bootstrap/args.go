@@ -24,10 +24,19 @@ const (
 	// zookeeper
 	defaultConsumerPath = "/consumers"
 	disableConsumerPath = "-"
+
+	DubboRegisterModeInterface = "interface"
+	DubboRegisterModeInstance  = "instance"
+	DubboRegisterModeAll       = "all"
 )
 
 var podNamespace = env.RegisterStringVar("POD_NAMESPACE", "istio-system", "").Get()
 
+// PodNamespace returns the namespace where meshregistry resides.
+func PodNamespace() string {
+	return podNamespace
+}
+
 type Args struct {
 	// Path to the mesh config file
 	// UNSUPPORTED
@@ -76,11 +85,16 @@ type RegistryArgs struct {
 
 	Mcp *McpArgs `json:"Mcp,omitempty"`
 	K8S *K8SArgs `json:"K8S,omitempty"`
+	// KubeSink exports the resources to kubernetes as CRs, as an alternative to serving them over MCP.
+	KubeSink *KubeSinkArgs `json:"KubeSink,omitempty"`
 
 	K8SSource       *K8SSourceArgs       `json:"K8SSource,omitempty"`
 	ZookeeperSource *ZookeeperSourceArgs `json:"ZookeeperSource,omitempty"`
 	EurekaSource    *EurekaSourceArgs    `json:"EurekaSource,omitempty"`
 	NacosSource     *NacosSourceArgs     `json:"NacosSource,omitempty"`
+	// DubboSidecar configures the dubbo `Sidecar` generation shared by the sources contributing dubbo call edges.
+	// If not set, it's derived from the zookeeper source args for backwards compatibility.
+	DubboSidecar *DubboSidecarArgs `json:"DubboSidecar,omitempty"`
 
 	HTTPServerAddr string `json:"HTTPServerAddr,omitempty"`
 	// istio revision
@@ -112,9 +126,72 @@ func (args *RegistryArgs) Rectify() *RegistryArgs {
 	args.ZookeeperSource.Rectify()
 	args.NacosSource.Rectify()
 	args.EurekaSource.Rectify()
+	if args.DubboSidecar == nil && args.ZookeeperSource != nil {
+		args.DubboSidecar = args.ZookeeperSource.DubboSidecarArgs()
+	}
 	return args
 }
 
+// redactedValue replaces the credentials in the redacted args
+const redactedValue = "******"
+
+// Redacted returns a copy of the args with the credentials of the registries masked, which is safe to be logged
+// or served. The args are not changed.
+func (args *RegistryArgs) Redacted() *RegistryArgs {
+	if args == nil {
+		return nil
+	}
+	ret := *args
+	if zk := args.ZookeeperSource; zk != nil {
+		zkCopy := *zk
+		redact(&zkCopy.Username, &zkCopy.Password)
+		ret.ZookeeperSource = &zkCopy
+	}
+	if eureka := args.EurekaSource; eureka != nil {
+		eurekaCopy := *eureka
+		redact(&eurekaCopy.Username, &eurekaCopy.Password)
+		eurekaCopy.Servers = append([]EurekaServer(nil), eureka.Servers...)
+		for i := range eurekaCopy.Servers {
+			redact(&eurekaCopy.Servers[i].Username, &eurekaCopy.Servers[i].Password)
+		}
+		ret.EurekaSource = &eurekaCopy
+	}
+	if nacos := args.NacosSource; nacos != nil {
+		nacosCopy := *nacos
+		redact(&nacosCopy.Username, &nacosCopy.Password)
+		nacosCopy.Servers = append([]NacosServer(nil), nacos.Servers...)
+		for i := range nacosCopy.Servers {
+			redact(&nacosCopy.Servers[i].Username, &nacosCopy.Servers[i].Password)
+		}
+		ret.NacosSource = &nacosCopy
+	}
+	return &ret
+}
+
+func redact(values ...*string) {
+	for _, v := range values {
+		if *v != "" {
+			*v = redactedValue
+		}
+	}
+}
+
+// DubboSidecarArgs configures the generation of the dubbo `Sidecar`s, which is fed by the call edges contributed
+// by all the sources with the dubbo sidecar enabled.
+type DubboSidecarArgs struct {
+	// the namespace of the generated `Sidecar`s
+	ResourceNs string `json:"ResourceNs,omitempty"`
+	// the removed dep service of an app will only be effective when so much time has passed (since last)
+	TrimDubboRemoveDepInterval util.Duration `json:"TrimDubboRemoveDepInterval,omitempty"`
+	// specify how to map `app` to label key:value pair
+	DubboWorkloadAppLabel string `json:"DubboWorkloadAppLabel,omitempty"`
+	// if true, will consider self-provided services as consumed services and add them to `Sidecar`
+	SelfConsume bool `json:"SelfConsume,omitempty"`
+	// the protocol of the egress listener, same as `SourceArgs.SvcProtocol` and `SourceArgs.GenericProtocol`
+	SvcProtocol     string `json:"SvcProtocol,omitempty"`
+	GenericProtocol bool   `json:"GenericProtocol,omitempty"`
+}
+
 type SourceArgs struct {
 	// enable the source
 	Enabled bool `json:"Enabled,omitempty"`
@@ -154,6 +231,9 @@ type SourceArgs struct {
 	// AlwaysUseSourceScopedEpSelectors if set to true, the source scoped EndpointSelectors should be processed
 	// even if the service matches ServicedEndpointSelector
 	AlwaysUseSourceScopedEpSelectors bool `json:"AlwaysUseSourceScopedEpSelectors,omitempty"`
+	// EndpointHealth configures how the registry health and weight of the instances are mapped to the endpoints.
+	// If not set, the legacy behavior of each source is kept.
+	EndpointHealth *EndpointHealthArgs `json:"EndpointHealth,omitempty"`
 
 	// MockService used to aggregate all the port of the services
 	// MockServiceName is the host of the mock service
@@ -182,6 +262,28 @@ type SourceArgs struct {
 	EnableEmptyProtection bool `json:"EnableEmptyProtection,omitempty"`
 }
 
+// EndpointHealthArgs maps the registry status of an instance to whether it's excluded, and the registry weight to
+// `WorkloadEntry.Weight`. The status of an instance is one of:
+//   - healthy
+//   - unhealthy: nacos `healthy=false`, eureka status other than UP and OUT_OF_SERVICE
+//   - disabled: nacos `enabled=false`, eureka OUT_OF_SERVICE, dubbo `disabled=true`, or weight 0
+type EndpointHealthArgs struct {
+	// keep the unhealthy instances as endpoints rather than excluding them
+	KeepUnhealthy bool `json:"KeepUnhealthy,omitempty"`
+	// keep the disabled instances as endpoints rather than excluding them
+	KeepDisabled bool `json:"KeepDisabled,omitempty"`
+	// if not empty, the status of the instance is set to the endpoint label with this key
+	StatusLabel string `json:"StatusLabel,omitempty"`
+	// set the registry weight to `WorkloadEntry.Weight`. The weights are normalized so that the default weight of
+	// each registry is 100: nacos `weight * 100`, dubbo `weight`, eureka metadata `weight`.
+	EnableWeight bool `json:"EnableWeight,omitempty"`
+	// if positive, the weight of a newly registered instance ramps up linearly within this duration since its
+	// registration time, which is the dubbo `timestamp` param(also in nacos metadata) or the eureka service up
+	// time. The dubbo `warmup` param overrides it. Only effective with `EnableWeight`.
+	// NOTICE: the weights are re-calculated at each polling or resync.
+	Warmup util.Duration `json:"Warmup,omitempty"`
+}
+
 // IPRanges defines a set of ip with ip list and cidr list
 type IPRanges struct {
 	IPs   []string `json:"IPs,omitempty"`
@@ -315,13 +417,25 @@ type ZookeeperSourceArgs struct {
 	SourceArgs
 
 	Address []string `json:"Address,omitempty"`
+	// username and password for the zk digest auth
+	Username string `json:"Username,omitempty"`
+	Password string `json:"Password,omitempty"`
 	// ignore label in ZookeeperSource instance
 	IgnoreLabel       []string      `json:"IgnoreLabel,omitempty"`
 	ConnectionTimeout util.Duration `json:"ConnectionTimeout,omitempty"`
 	// dubbo register node in Zookeeper
 	RegistryRootNode string `json:"RegistryRootNode,omitempty"`
-	// NOSUPPORTED
+	// dubbo application-level(dubbo 3) register node in Zookeeper, where the app instances are registered.
 	ApplicationRegisterRootNode string `json:"ApplicationRegisterRootNode,omitempty"`
+	// dubbo service-name mapping node in Zookeeper, which maps the interfaces to their provider apps.
+	MappingRootNode string `json:"MappingRootNode,omitempty"`
+	// dubbo metadata report node in Zookeeper, where the metadata of each app revision is reported.
+	MetadataRootNode string `json:"MetadataRootNode,omitempty"`
+	// RegisterMode specifies which dubbo registrations to discover, like the `register-mode` of dubbo:
+	//   - interface: the interface-level registrations, default
+	//   - instance: the application-level registrations
+	//   - all: both, mainly used during migration
+	RegisterMode string `json:"RegisterMode,omitempty"`
 	// zk mode for get zk info
 	Mode                string            `json:"Mode,omitempty"`
 	WatchingWorkerCount int               `json:"WatchingWorkerCount,omitempty"`
@@ -376,9 +490,36 @@ func (args *ZookeeperSourceArgs) Validate() error {
 	if len(args.Address) == 0 {
 		return errors.New("zookeeper server address must be set when zookeeper source is enabled")
 	}
+	switch args.RegisterMode {
+	case "", DubboRegisterModeInterface, DubboRegisterModeInstance, DubboRegisterModeAll:
+	default:
+		return fmt.Errorf("invalid register mode %s for zookeeper source", args.RegisterMode)
+	}
 	return nil
 }
 
+// DiscoverInterfaces returns whether to discover the dubbo interface-level registrations.
+func (args *ZookeeperSourceArgs) DiscoverInterfaces() bool {
+	return args.RegisterMode != DubboRegisterModeInstance
+}
+
+// DiscoverApplications returns whether to discover the dubbo application-level registrations.
+func (args *ZookeeperSourceArgs) DiscoverApplications() bool {
+	return args.RegisterMode == DubboRegisterModeInstance || args.RegisterMode == DubboRegisterModeAll
+}
+
+// DubboSidecarArgs derives the dubbo `Sidecar` generation args from the legacy zookeeper source args.
+func (args *ZookeeperSourceArgs) DubboSidecarArgs() *DubboSidecarArgs {
+	return &DubboSidecarArgs{
+		ResourceNs:                 args.ResourceNs,
+		TrimDubboRemoveDepInterval: args.TrimDubboRemoveDepInterval,
+		DubboWorkloadAppLabel:      args.DubboWorkloadAppLabel,
+		SelfConsume:                args.SelfConsume,
+		SvcProtocol:                args.SvcProtocol,
+		GenericProtocol:            args.GenericProtocol,
+	}
+}
+
 func (args *ZookeeperSourceArgs) Rectify() {
 	if args == nil {
 		return
@@ -422,6 +563,9 @@ type EurekaServer struct {
 	// If set, the registry id will be used as an entry in the endpoint metadata.
 	RegistryID string   `json:"RegistryID,omitempty"`
 	Address    []string `json:"Address,omitempty"`
+	// username and password for the basic auth of the eureka server
+	Username string `json:"Username,omitempty"`
+	Password string `json:"Password,omitempty"`
 }
 
 func (eurekaServer *EurekaServer) Validate() error {
@@ -488,6 +632,9 @@ type NacosSourceArgs struct {
 	// if not empty, will add this suffix to dom
 	DomSuffix string        `json:"DomSuffix,omitempty"`
 	Servers   []NacosServer `json:"Servers,omitempty"`
+	// whether to contribute the dubbo call edges to the dubbo `Sidecar` generation. If true, the subscribers of the
+	// services will be fetched, and the app of a provider/subscriber is specified by the metadata `application`.
+	EnableDubboSidecar bool `json:"EnableDubboSidecar,omitempty"`
 }
 
 type NacosServer struct {
@@ -591,6 +738,63 @@ type McpArgs struct {
 	EnableIncPush bool `json:"EnableIncPush,omitempty"`
 	// non-0 means enable clean zombie config brought by incremental push.
 	CleanZombieInterval util.Duration `json:"CleanZombieInterval,omitempty"`
+	// EnableDeltaXds enables serving the incremental(delta) xDS protocol besides the state-of-the-world one.
+	// Delta clients only receive changed and removed resources of their subscription on each push.
+	// Only works with `xds://` server url and requires EnableAnnoResVer.
+	EnableDeltaXds bool `json:"EnableDeltaXds,omitempty"`
+	// TLS enables TLS on the mcp server. Only works with `xds://` server url.
+	TLS *McpTLSArgs `json:"TLS,omitempty"`
+	// JWT enables the authentication of the clients by the JWT in the grpc metadata `authorization`.
+	// Only works with `xds://` server url.
+	JWT *McpJWTArgs `json:"JWT,omitempty"`
+}
+
+// McpTLSArgs configures the certs of the mcp server, either from files or from a kubernetes Secret.
+// The certs are hot reloaded, and the new ones take effect on new connections.
+type McpTLSArgs struct {
+	// CertFile and KeyFile are the paths of the server certificate chain and private key.
+	CertFile string `json:"CertFile,omitempty"`
+	KeyFile  string `json:"KeyFile,omitempty"`
+	// CAFile is the path of the CA certificates to verify the clients. If set, the clients are required to
+	// present a certificate signed by it, a.k.a. mTLS.
+	CAFile string `json:"CAFile,omitempty"`
+	// SecretName loads the certs from the keys `tls.crt`, `tls.key` and `ca.crt`(optional, enables mTLS) of the
+	// Secret instead of the files. The Secret is watched for changes.
+	SecretName string `json:"SecretName,omitempty"`
+	// SecretNamespace is the namespace of the Secret, defaults to ${POD_NAMESPACE}.
+	SecretNamespace string `json:"SecretNamespace,omitempty"`
+	// ReloadInterval is the interval to check the files for changes, defaults to 30s.
+	ReloadInterval util.Duration `json:"ReloadInterval,omitempty"`
+}
+
+// McpJWTArgs configures the JWT authentication of the mcp clients. The token must be signed by one of the keys in
+// the JWKS, not expired, and match the issuer and audiences if configured.
+type McpJWTArgs struct {
+	// JwksFile is the path of the JWKS file.
+	JwksFile string `json:"JwksFile,omitempty"`
+	// JwksUri is the url to fetch the JWKS from, used if JwksFile is not set.
+	JwksUri string `json:"JwksUri,omitempty"`
+	// Issuer is the expected `iss` claim, not checked if empty.
+	Issuer string `json:"Issuer,omitempty"`
+	// Audiences are the accepted `aud` claims, the token must contain one of them. Not checked if empty.
+	Audiences []string `json:"Audiences,omitempty"`
+	// ReloadInterval is the interval to reload the JWKS, defaults to 30s.
+	ReloadInterval util.Duration `json:"ReloadInterval,omitempty"`
+}
+
+type KubeSinkArgs struct {
+	// Enable exports the ServiceEntries and Sidecars converted from registry sources to kubernetes as CRs.
+	// The writes are rate limited by the global `ClientGoTokenBucket`.
+	Enable bool `json:"Enable,omitempty"`
+	// Owner is the value of the owner label `meshregistry.slime.io/owner` of the exported CRs. Only CRs with
+	// the same owner are updated or garbage-collected, so that multiple instances can export to one cluster.
+	Owner string `json:"Owner,omitempty"`
+	// BatchInterval is the interval to flush the changes to kubernetes in batches.
+	BatchInterval util.Duration `json:"BatchInterval,omitempty"`
+	// MaxBatchSize is the max number of CRs written in one batch, 0 means no limit.
+	MaxBatchSize int `json:"MaxBatchSize,omitempty"`
+	// GcInterval is the interval to garbage-collect stale CRs after all sources are ready, 0 means only once.
+	GcInterval util.Duration `json:"GcInterval,omitempty"`
 }
 
 type K8SArgs struct {
@@ -625,6 +829,12 @@ func NewRegistryArgs() *RegistryArgs {
 			ClusterID:                  features.ClusterName,
 			ClusterRegistriesNamespace: podNamespace,
 		},
+		KubeSink: &KubeSinkArgs{
+			Owner:         "meshregistry",
+			BatchInterval: util.Duration(time.Second),
+			MaxBatchSize:  100,
+			GcInterval:    util.Duration(10 * time.Minute),
+		},
 		RegistryStartDelay: util.Duration(5 * time.Second),
 
 		K8SSource: &K8SSourceArgs{
@@ -640,14 +850,18 @@ func NewRegistryArgs() *RegistryArgs {
 				InstancePortAsSvcPort: true,
 				ResourceNs:            "dubbo",
 			},
-			IgnoreLabel:                []string{"pid", "timestamp", "dubbo"},
-			Mode:                       "polling",
-			WatchingWorkerCount:        10,
-			ConnectionTimeout:          util.Duration(30 * time.Second),
-			RegistryRootNode:           "/dubbo",
-			TrimDubboRemoveDepInterval: util.Duration(24 * time.Hour),
-			EnableDubboSidecar:         true,
-			DubboWorkloadAppLabel:      "app",
+			IgnoreLabel:                 []string{"pid", "timestamp", "dubbo"},
+			Mode:                        "polling",
+			WatchingWorkerCount:         10,
+			ConnectionTimeout:           util.Duration(30 * time.Second),
+			RegistryRootNode:            "/dubbo",
+			ApplicationRegisterRootNode: "/services",
+			MappingRootNode:             "/dubbo/mapping",
+			MetadataRootNode:            "/dubbo/metadata",
+			RegisterMode:                DubboRegisterModeInterface,
+			TrimDubboRemoveDepInterval:  util.Duration(24 * time.Hour),
+			EnableDubboSidecar:          true,
+			DubboWorkloadAppLabel:       "app",
 		},
 		EurekaSource: &EurekaSourceArgs{
 			SourceArgs: SourceArgs{
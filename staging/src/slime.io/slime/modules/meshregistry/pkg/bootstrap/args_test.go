@@ -0,0 +1,45 @@
+package bootstrap
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRegistryArgsRedacted(t *testing.T) {
+	args := &RegistryArgs{
+		ZookeeperSource: &ZookeeperSourceArgs{Address: []string{"zk:2181"}, Username: "zk-user", Password: "zk-pass"},
+		EurekaSource: &EurekaSourceArgs{
+			EurekaServer: EurekaServer{Username: "eureka-user", Password: "eureka-pass"},
+			Servers:      []EurekaServer{{Address: []string{"eureka:8761"}, Password: "eureka-pass-1"}},
+		},
+		NacosSource: &NacosSourceArgs{
+			NacosServer: NacosServer{Username: "nacos-user", Password: "nacos-pass"},
+			Servers:     []NacosServer{{Address: []string{"nacos:8848"}, Username: "nacos-user-1"}},
+		},
+	}
+
+	bs, err := json.Marshal(args.Redacted())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{
+		"zk-user", "zk-pass", "eureka-user", "eureka-pass", "eureka-pass-1", "nacos-user", "nacos-pass", "nacos-user-1",
+	} {
+		if strings.Contains(string(bs), `"`+secret+`"`) {
+			t.Errorf("credential %s not redacted: %s", secret, bs)
+		}
+	}
+	if !strings.Contains(string(bs), "zk:2181") || !strings.Contains(string(bs), "nacos:8848") {
+		t.Errorf("non-credential fields should be kept: %s", bs)
+	}
+
+	// the original args are untouched
+	if args.ZookeeperSource.Password != "zk-pass" || args.EurekaSource.Servers[0].Password != "eureka-pass-1" ||
+		args.NacosSource.Servers[0].Username != "nacos-user-1" {
+		t.Errorf("the original args are changed")
+	}
+	if args := (*RegistryArgs)(nil).Redacted(); args != nil {
+		t.Errorf("expect nil for nil args, got %v", args)
+	}
+}
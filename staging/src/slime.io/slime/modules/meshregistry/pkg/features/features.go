@@ -50,6 +50,13 @@ var (
 		"specify which RegistrySource cr to watch, empty means no watching",
 	).Get()
 
+	WatchingRegistrySourceConnections = env.RegisterBoolVar(
+		"WATCHING_REGISTRYSOURCE_CONNECTIONS",
+		false,
+		"if true, the connections of all the RegistrySource crs in the namespace will be added to the sources, "+
+			"and their status will be reported to the crs",
+	).Get()
+
 	SkipValidateTagValue = env.RegisterBoolVar(
 		"SKIP_VALIDATE_LABEL_VALUE",
 		false,
@@ -0,0 +1,437 @@
+package kubesink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	mcpmodel "istio.io/istio-mcp/pkg/model"
+	"istio.io/libistio/pkg/config"
+	"istio.io/libistio/pkg/config/event"
+	"istio.io/libistio/pkg/config/schema/collections"
+	"istio.io/libistio/pkg/config/schema/resource"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/flowcontrol"
+
+	bootconfig "slime.io/slime/framework/apis/config/v1alpha1"
+	frameworkmodel "slime.io/slime/framework/model"
+	"slime.io/slime/modules/meshregistry/model"
+	"slime.io/slime/modules/meshregistry/pkg/bootstrap"
+	"slime.io/slime/modules/meshregistry/pkg/monitoring"
+)
+
+const (
+	// LabelManagedBy marks the CRs exported by meshregistry.
+	LabelManagedBy = "app.kubernetes.io/managed-by"
+	// LabelOwner is the owner of the exported CRs, see `KubeSinkArgs.Owner`.
+	LabelOwner = "meshregistry.slime.io/owner"
+	// LabelSource is the registry source the exported CR comes from.
+	LabelSource = "meshregistry.slime.io/source"
+
+	managedByValue = "meshregistry"
+
+	opCreate = "create"
+	opUpdate = "update"
+	opDelete = "delete"
+)
+
+var log = model.ModuleLog.WithField(frameworkmodel.LogFieldKeyPkg, "kubesink")
+
+// exportedSchemas are the kinds exported by the sink.
+var exportedSchemas = []resource.Schema{
+	collections.ServiceEntry,
+	collections.Sidecar,
+}
+
+type objectKey struct {
+	gvr       schema.GroupVersionResource
+	namespace string
+	name      string
+}
+
+func (k objectKey) String() string {
+	return fmt.Sprintf("%s %s/%s", k.gvr.Resource, k.namespace, k.name)
+}
+
+// Sink exports the resources from registry sources to kubernetes as CRs.
+//   - the changes are recorded by the handlers and flushed in batches, each CR write is rate limited.
+//   - the CRs are labeled with the owner, CRs not owned by the sink are never touched.
+//   - after all sources are ready, the owned CRs which are no longer present are garbage-collected.
+type Sink struct {
+	args     *bootstrap.KubeSinkArgs
+	revision string
+	client   dynamic.Interface
+	limiter  flowcontrol.RateLimiter
+
+	mut     sync.Mutex
+	desired map[objectKey]*unstructured.Unstructured
+	pending map[objectKey]struct{}
+
+	readyOnce sync.Once
+	readyCh   chan struct{}
+}
+
+// New creates the sink. The writes are rate limited by the token bucket if it's specified.
+func New(
+	args *bootstrap.RegistryArgs,
+	client dynamic.Interface,
+	tokenBucket *bootconfig.ClientGoTokenBucket,
+) (*Sink, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kube sink requires a dynamic client")
+	}
+	if args.KubeSink == nil || args.KubeSink.Owner == "" {
+		return nil, fmt.Errorf("kube sink requires an owner")
+	}
+	s := &Sink{
+		args:     args.KubeSink,
+		revision: args.Revision,
+		client:   client,
+		desired:  map[objectKey]*unstructured.Unstructured{},
+		pending:  map[objectKey]struct{}{},
+		readyCh:  make(chan struct{}),
+	}
+	if tokenBucket != nil && tokenBucket.Qps > 0 {
+		burst := int(tokenBucket.Burst)
+		if burst <= 0 {
+			burst = int(tokenBucket.Qps)
+		}
+		s.limiter = flowcontrol.NewTokenBucketRateLimiter(float32(tokenBucket.Qps), burst)
+	}
+	return s, nil
+}
+
+// HandlerFor returns the event handler for the events from the registry source.
+func (s *Sink) HandlerFor(source string) event.Handler {
+	return &sourceHandler{s: s, source: source}
+}
+
+// MarkSourcesReady notifies the sink that all the sources are ready, thus the owned CRs which are not present
+// can be garbage-collected.
+func (s *Sink) MarkSourcesReady() {
+	s.readyOnce.Do(func() {
+		close(s.readyCh)
+	})
+}
+
+func (s *Sink) Run(stop <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	interval := time.Duration(s.args.BatchInterval)
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var (
+		readyCh  = s.readyCh
+		gcTicker <-chan time.Time
+	)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.flush(ctx)
+		case <-readyCh:
+			readyCh = nil
+			// flush before gc to make sure the desired ones are there
+			s.flush(ctx)
+			s.gc(ctx)
+			if gcInterval := time.Duration(s.args.GcInterval); gcInterval > 0 {
+				t := time.NewTicker(gcInterval)
+				defer t.Stop()
+				gcTicker = t.C
+			}
+		case <-gcTicker:
+			s.gc(ctx)
+		}
+	}
+}
+
+func (s *Sink) handle(e event.Event, source string) {
+	if e.Resource == nil || e.Source == nil || !exported(e.Source) {
+		return
+	}
+	key := objectKey{
+		gvr:       e.Source.GroupVersionResource(),
+		namespace: e.Resource.Metadata.FullName.Namespace.String(),
+		name:      e.Resource.Metadata.FullName.Name.String(),
+	}
+
+	var obj *unstructured.Unstructured
+	switch e.Kind {
+	case event.Added, event.Updated:
+		var err error
+		if obj, err = s.toObject(e, source); err != nil {
+			log.Errorf("convert %s to kube object met err %v", key, err)
+			return
+		}
+	case event.Deleted:
+	default:
+		return
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if obj == nil {
+		delete(s.desired, key)
+	} else {
+		s.desired[key] = obj
+	}
+	s.pending[key] = struct{}{}
+}
+
+func (s *Sink) toObject(e event.Event, source string) (*unstructured.Unstructured, error) {
+	spec, err := config.ToMap(e.Resource.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := e.Resource.Metadata
+	objLabels := make(map[string]string, len(meta.Labels)+4)
+	for k, v := range meta.Labels {
+		objLabels[k] = v
+	}
+	objLabels[LabelManagedBy] = managedByValue
+	objLabels[LabelOwner] = s.args.Owner
+	if source != "" {
+		objLabels[LabelSource] = source
+	}
+	if s.revision != "" {
+		objLabels[frameworkmodel.IstioRevLabel] = s.revision
+	}
+
+	var annotations map[string]string
+	for k, v := range meta.Annotations {
+		// the mcp resource version makes no sense in kubernetes
+		if k == mcpmodel.AnnotationResourceVersion {
+			continue
+		}
+		if annotations == nil {
+			annotations = make(map[string]string, len(meta.Annotations))
+		}
+		annotations[k] = v
+	}
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetAPIVersion(e.Source.APIVersion())
+	obj.SetKind(e.Source.Kind())
+	obj.SetNamespace(meta.FullName.Namespace.String())
+	obj.SetName(meta.FullName.Name.String())
+	obj.SetLabels(objLabels)
+	obj.SetAnnotations(annotations)
+	return obj, nil
+}
+
+// flush writes a batch of the pending changes to kubernetes, the failed ones will be retried in the next batch.
+func (s *Sink) flush(ctx context.Context) {
+	s.mut.Lock()
+	keys := make([]objectKey, 0, len(s.pending))
+	for key := range s.pending {
+		if s.args.MaxBatchSize > 0 && len(keys) >= s.args.MaxBatchSize {
+			break
+		}
+		keys = append(keys, key)
+		delete(s.pending, key)
+	}
+	s.mut.Unlock()
+
+	if len(keys) == 0 {
+		return
+	}
+
+	var failed []objectKey
+	for idx, key := range keys {
+		if err := s.wait(ctx); err != nil {
+			failed = append(failed, keys[idx:]...)
+			break
+		}
+		if err := s.sync(ctx, key); err != nil {
+			log.Errorf("sync %s to kubernetes met err %v", key, err)
+			failed = append(failed, key)
+		}
+	}
+
+	if len(failed) > 0 {
+		s.mut.Lock()
+		for _, key := range failed {
+			s.pending[key] = struct{}{}
+		}
+		s.mut.Unlock()
+	}
+	log.Debugf("kube sink flushed %d changes, %d failed", len(keys), len(failed))
+}
+
+func (s *Sink) wait(ctx context.Context) error {
+	if s.limiter == nil {
+		return ctx.Err()
+	}
+	return s.limiter.Wait(ctx)
+}
+
+func (s *Sink) getDesired(key objectKey) *unstructured.Unstructured {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if obj := s.desired[key]; obj != nil {
+		return obj.DeepCopy()
+	}
+	return nil
+}
+
+// sync makes the CR of the key in kubernetes to be the desired one.
+func (s *Sink) sync(ctx context.Context, key objectKey) error {
+	client := s.client.Resource(key.gvr).Namespace(key.namespace)
+	desired := s.getDesired(key)
+
+	existing, err := client.Get(ctx, key.name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		existing = nil
+	} else if err != nil {
+		return err
+	}
+
+	switch {
+	case existing == nil && desired == nil:
+		return nil
+	case existing != nil && !s.owned(existing):
+		log.Warnf("%s exists but not owned by %s, skip", key, s.args.Owner)
+		return nil
+	case desired == nil:
+		err = client.Delete(ctx, key.name, metav1.DeleteOptions{})
+		if errors.IsNotFound(err) {
+			err = nil
+		}
+		monitoring.RecordKubeSinkWrite(opDelete, err == nil)
+		return err
+	case existing == nil:
+		_, err = client.Create(ctx, desired, metav1.CreateOptions{})
+		monitoring.RecordKubeSinkWrite(opCreate, err == nil)
+		return err
+	default:
+		if objectEqual(existing, desired) {
+			return nil
+		}
+		desired.SetResourceVersion(existing.GetResourceVersion())
+		_, err = client.Update(ctx, desired, metav1.UpdateOptions{})
+		monitoring.RecordKubeSinkWrite(opUpdate, err == nil)
+		return err
+	}
+}
+
+func (s *Sink) owned(obj *unstructured.Unstructured) bool {
+	return obj.GetLabels()[LabelOwner] == s.args.Owner
+}
+
+func (s *Sink) ownerSelector() string {
+	return labels.SelectorFromSet(labels.Set{
+		LabelManagedBy: managedByValue,
+		LabelOwner:     s.args.Owner,
+	}).String()
+}
+
+// gc deletes the owned CRs which are neither desired nor pending.
+func (s *Sink) gc(ctx context.Context) {
+	var stale []objectKey
+	for _, sch := range exportedSchemas {
+		gvr := sch.GroupVersionResource()
+		list, err := s.client.Resource(gvr).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+			LabelSelector: s.ownerSelector(),
+		})
+		if err != nil {
+			log.Errorf("list owned %s met err %v", gvr.Resource, err)
+			continue
+		}
+
+		s.mut.Lock()
+		for _, item := range list.Items {
+			key := objectKey{gvr: gvr, namespace: item.GetNamespace(), name: item.GetName()}
+			if _, ok := s.desired[key]; ok {
+				continue
+			}
+			if _, ok := s.pending[key]; ok {
+				continue
+			}
+			stale = append(stale, key)
+		}
+		s.mut.Unlock()
+	}
+
+	for _, key := range stale {
+		if err := s.wait(ctx); err != nil {
+			return
+		}
+		err := s.client.Resource(key.gvr).Namespace(key.namespace).Delete(ctx, key.name, metav1.DeleteOptions{})
+		if errors.IsNotFound(err) {
+			err = nil
+		}
+		monitoring.RecordKubeSinkWrite(opDelete, err == nil)
+		if err != nil {
+			log.Errorf("gc stale %s met err %v", key, err)
+		}
+	}
+	if len(stale) > 0 {
+		log.Infof("kube sink garbage-collected %d stale CRs", len(stale))
+	}
+}
+
+func exported(sch resource.Schema) bool {
+	for _, item := range exportedSchemas {
+		if item.GroupVersionKind() == sch.GroupVersionKind() {
+			return true
+		}
+	}
+	return false
+}
+
+// objectEqual compares the parts managed by the sink. The specs are compared in json to get rid of the
+// difference between numeric types.
+func objectEqual(existing, desired *unstructured.Unstructured) bool {
+	if !mapEqual(existing.GetLabels(), desired.GetLabels()) ||
+		!mapEqual(existing.GetAnnotations(), desired.GetAnnotations()) {
+		return false
+	}
+	existingSpec, err := json.Marshal(existing.Object["spec"])
+	if err != nil {
+		return false
+	}
+	desiredSpec, err := json.Marshal(desired.Object["spec"])
+	if err != nil {
+		return false
+	}
+	return string(existingSpec) == string(desiredSpec)
+}
+
+func mapEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
+
+type sourceHandler struct {
+	s      *Sink
+	source string
+}
+
+func (h *sourceHandler) Handle(e event.Event) {
+	h.s.handle(e, h.source)
+}
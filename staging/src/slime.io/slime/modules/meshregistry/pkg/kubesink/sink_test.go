@@ -0,0 +1,169 @@
+package kubesink
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	networkingapi "istio.io/api/networking/v1alpha3"
+	"istio.io/libistio/pkg/config/event"
+	"istio.io/libistio/pkg/config/resource"
+	"istio.io/libistio/pkg/config/schema/collections"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	frameworkmodel "slime.io/slime/framework/model"
+	"slime.io/slime/modules/meshregistry/pkg/bootstrap"
+)
+
+var seGvr = collections.ServiceEntry.GroupVersionResource()
+
+func newTestSink(t *testing.T, objs ...runtime.Object) *Sink {
+	listKinds := map[schema.GroupVersionResource]string{}
+	for _, sch := range exportedSchemas {
+		listKinds[sch.GroupVersionResource()] = sch.Kind() + "List"
+	}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objs...)
+
+	args := bootstrap.NewRegistryArgs()
+	args.Revision = "canary"
+	s, err := New(args, client, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func newServiceEntryEvent(kind event.Kind, ns, name string) event.Event {
+	return event.Event{
+		Kind:   kind,
+		Source: collections.ServiceEntry,
+		Resource: &resource.Instance{
+			Metadata: resource.Metadata{
+				FullName: resource.NewFullName(resource.Namespace(ns), resource.LocalName(name)),
+				Labels:   map[string]string{"app": name},
+			},
+			Message: &networkingapi.ServiceEntry{
+				Hosts: []string{name},
+				Ports: []*networkingapi.ServicePort{{Number: 80, Protocol: "HTTP", Name: "http"}},
+			},
+		},
+	}
+}
+
+func newServiceEntryObject(ns, name, owner string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"hosts": []interface{}{name}},
+	}}
+	obj.SetAPIVersion(collections.ServiceEntry.APIVersion())
+	obj.SetKind(collections.ServiceEntry.Kind())
+	obj.SetNamespace(ns)
+	obj.SetName(name)
+	if owner != "" {
+		obj.SetLabels(map[string]string{LabelManagedBy: managedByValue, LabelOwner: owner})
+	}
+	return obj
+}
+
+func listServiceEntries(t *testing.T, s *Sink) map[string]unstructured.Unstructured {
+	list, err := s.client.Resource(seGvr).Namespace(metav1.NamespaceAll).List(context.Background(),
+		metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ret := map[string]unstructured.Unstructured{}
+	for _, item := range list.Items {
+		ret[item.GetNamespace()+"/"+item.GetName()] = item
+	}
+	return ret
+}
+
+func sortedKeys(m map[string]unstructured.Unstructured) []string {
+	ret := make([]string, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func TestSinkFlush(t *testing.T) {
+	s := newTestSink(t, newServiceEntryObject("dubbo", "taken", ""))
+	ctx := context.Background()
+	h := s.HandlerFor("zookeeper")
+
+	h.Handle(newServiceEntryEvent(event.Added, "dubbo", "a"))
+	h.Handle(newServiceEntryEvent(event.Added, "dubbo", "b"))
+	h.Handle(newServiceEntryEvent(event.Added, "dubbo", "taken"))
+	s.flush(ctx)
+
+	got := listServiceEntries(t, s)
+	if want := []string{"dubbo/a", "dubbo/b", "dubbo/taken"}; !reflect.DeepEqual(sortedKeys(got), want) {
+		t.Fatalf("got %v, want %v", sortedKeys(got), want)
+	}
+	a := got["dubbo/a"]
+	wantLabels := map[string]string{
+		"app":                        "a",
+		LabelManagedBy:               managedByValue,
+		LabelOwner:                   "meshregistry",
+		LabelSource:                  "zookeeper",
+		frameworkmodel.IstioRevLabel: "canary",
+	}
+	if !reflect.DeepEqual(a.GetLabels(), wantLabels) {
+		t.Errorf("labels = %v, want %v", a.GetLabels(), wantLabels)
+	}
+	if hosts, _, _ := unstructured.NestedStringSlice(a.Object, "spec", "hosts"); !reflect.DeepEqual(hosts, []string{"a"}) {
+		t.Errorf("hosts = %v", hosts)
+	}
+	if taken := got["dubbo/taken"]; len(taken.GetLabels()) != 0 {
+		t.Errorf("not owned object should not be touched, got labels %v", taken.GetLabels())
+	}
+	if len(s.pending) != 0 {
+		t.Errorf("pending should be empty, got %v", s.pending)
+	}
+
+	h.Handle(newServiceEntryEvent(event.Deleted, "dubbo", "b"))
+	s.flush(ctx)
+	if got, want := sortedKeys(listServiceEntries(t, s)), []string{"dubbo/a", "dubbo/taken"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSinkBatch(t *testing.T) {
+	s := newTestSink(t)
+	s.args.MaxBatchSize = 2
+	h := s.HandlerFor("nacos")
+	for _, name := range []string{"a", "b", "c"} {
+		h.Handle(newServiceEntryEvent(event.Added, "nacos", name))
+	}
+
+	s.flush(context.Background())
+	if got := len(listServiceEntries(t, s)); got != 2 {
+		t.Errorf("first batch wrote %d, want 2", got)
+	}
+	s.flush(context.Background())
+	if got := len(listServiceEntries(t, s)); got != 3 {
+		t.Errorf("second batch wrote %d in total, want 3", got)
+	}
+}
+
+func TestSinkGc(t *testing.T) {
+	s := newTestSink(t,
+		newServiceEntryObject("dubbo", "stale", "meshregistry"),
+		newServiceEntryObject("dubbo", "others", "another-meshregistry"),
+		newServiceEntryObject("dubbo", "unmanaged", ""),
+	)
+	ctx := context.Background()
+	s.HandlerFor("zookeeper").Handle(newServiceEntryEvent(event.Added, "dubbo", "a"))
+	s.flush(ctx)
+	s.gc(ctx)
+
+	got := sortedKeys(listServiceEntries(t, s))
+	if want := []string{"dubbo/a", "dubbo/others", "dubbo/unmanaged"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,369 @@
+package mcpoverxds
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jwt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"slime.io/slime/modules/meshregistry/pkg/bootstrap"
+)
+
+const (
+	defaultCredentialReloadInterval = 30 * time.Second
+
+	secretCertKey = "tls.crt"
+	secretKeyKey  = "tls.key"
+	secretCAKey   = "ca.crt"
+
+	authorizationHeader = "authorization"
+	bearerTokenPrefix   = "bearer "
+)
+
+func credentialReloadInterval(d time.Duration) time.Duration {
+	if d <= 0 {
+		return defaultCredentialReloadInterval
+	}
+	return d
+}
+
+// tlsMaterial is the PEM encoded certs of the server, ca is optional.
+type tlsMaterial struct {
+	cert, key, ca []byte
+}
+
+func (m tlsMaterial) equal(o tlsMaterial) bool {
+	return bytes.Equal(m.cert, o.cert) && bytes.Equal(m.key, o.key) && bytes.Equal(m.ca, o.ca)
+}
+
+// serverTLS holds the certs of the mcp server, which are hot reloaded from the files or the Secret. The tls config
+// returned by `config` always uses the latest certs, so the existing connections are not affected by reloading.
+type serverTLS struct {
+	args       *bootstrap.McpTLSArgs
+	kubeClient kubernetes.Interface
+
+	mut       sync.RWMutex
+	material  tlsMaterial
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+// newServerTLS creates the serverTLS with the initial certs loaded, so that a misconfiguration fails fast.
+func newServerTLS(args *bootstrap.McpTLSArgs, kubeClient kubernetes.Interface) (*serverTLS, error) {
+	st := &serverTLS{args: args, kubeClient: kubeClient}
+
+	var (
+		m   tlsMaterial
+		err error
+	)
+	if args.SecretName != "" {
+		if kubeClient == nil {
+			return nil, errors.New("kube client is required to load the certs from secret")
+		}
+		var secret *corev1.Secret
+		secret, err = kubeClient.CoreV1().Secrets(st.secretNamespace()).
+			Get(context.TODO(), args.SecretName, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("get secret %s/%s failed: %v", st.secretNamespace(), args.SecretName, err)
+		}
+		m, err = tlsMaterialFromSecret(secret)
+	} else {
+		if args.CertFile == "" || args.KeyFile == "" {
+			return nil, errors.New("CertFile and KeyFile are required if SecretName is not set")
+		}
+		m, err = st.readFiles()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = st.update(m); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+func (st *serverTLS) secretNamespace() string {
+	if st.args.SecretNamespace != "" {
+		return st.args.SecretNamespace
+	}
+	return bootstrap.PodNamespace()
+}
+
+func (st *serverTLS) readFiles() (tlsMaterial, error) {
+	var (
+		m   tlsMaterial
+		err error
+	)
+	if m.cert, err = os.ReadFile(st.args.CertFile); err != nil {
+		return m, err
+	}
+	if m.key, err = os.ReadFile(st.args.KeyFile); err != nil {
+		return m, err
+	}
+	if st.args.CAFile != "" {
+		if m.ca, err = os.ReadFile(st.args.CAFile); err != nil {
+			return m, err
+		}
+	}
+	return m, nil
+}
+
+func tlsMaterialFromSecret(secret *corev1.Secret) (tlsMaterial, error) {
+	m := tlsMaterial{
+		cert: secret.Data[secretCertKey],
+		key:  secret.Data[secretKeyKey],
+		ca:   secret.Data[secretCAKey],
+	}
+	if len(m.cert) == 0 || len(m.key) == 0 {
+		return m, fmt.Errorf("key %s or %s not found in secret %s/%s",
+			secretCertKey, secretKeyKey, secret.Namespace, secret.Name)
+	}
+	return m, nil
+}
+
+// update parses and takes the certs into use if they are changed. The previous ones are kept if the new ones
+// are invalid.
+func (st *serverTLS) update(m tlsMaterial) error {
+	st.mut.RLock()
+	unchanged := st.cert != nil && st.material.equal(m)
+	st.mut.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	cert, err := tls.X509KeyPair(m.cert, m.key)
+	if err != nil {
+		return fmt.Errorf("invalid server certificate: %v", err)
+	}
+	var clientCAs *x509.CertPool
+	if len(m.ca) > 0 {
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(m.ca) {
+			return errors.New("invalid CA certificate: no certificate found")
+		}
+	}
+
+	st.mut.Lock()
+	st.material, st.cert, st.clientCAs = m, &cert, clientCAs
+	st.mut.Unlock()
+	log.Infof("mcp server certs loaded, mTLS %v", clientCAs != nil)
+	return nil
+}
+
+func (st *serverTLS) config() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			st.mut.RLock()
+			defer st.mut.RUnlock()
+			cfg := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*st.cert},
+				// grpc requires h2, which is only added to the base config by the grpc credentials.
+				NextProtos: []string{"h2"},
+			}
+			if st.clientCAs != nil {
+				cfg.ClientCAs = st.clientCAs
+				cfg.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+			return cfg, nil
+		},
+	}
+}
+
+// run reloads the certs until the ctx is done.
+func (st *serverTLS) run(ctx context.Context) {
+	if st.args.SecretName != "" {
+		st.watchSecret(ctx)
+		return
+	}
+
+	ticker := time.NewTicker(credentialReloadInterval(time.Duration(st.args.ReloadInterval)))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m, err := st.readFiles()
+			if err == nil {
+				err = st.update(m)
+			}
+			if err != nil {
+				log.Errorf("reload mcp server certs failed, keep using the previous ones: %v", err)
+			}
+		}
+	}
+}
+
+func (st *serverTLS) watchSecret(ctx context.Context) {
+	ns, name := st.secretNamespace(), st.args.SecretName
+	fieldSelector := fields.OneTermEqualSelector("metadata.name", name).String()
+	lw := &cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			opts.FieldSelector = fieldSelector
+			return st.kubeClient.CoreV1().Secrets(ns).List(ctx, opts)
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			opts.FieldSelector = fieldSelector
+			return st.kubeClient.CoreV1().Secrets(ns).Watch(ctx, opts)
+		},
+	}
+	onSecret := func(obj interface{}) {
+		secret, ok := obj.(*corev1.Secret)
+		if !ok {
+			return
+		}
+		m, err := tlsMaterialFromSecret(secret)
+		if err == nil {
+			err = st.update(m)
+		}
+		if err != nil {
+			log.Errorf("reload mcp server certs failed, keep using the previous ones: %v", err)
+		}
+	}
+	_, informer := cache.NewInformer(lw, &corev1.Secret{}, 0, cache.ResourceEventHandlerFuncs{
+		AddFunc:    onSecret,
+		UpdateFunc: func(_, newObj interface{}) { onSecret(newObj) },
+		DeleteFunc: func(interface{}) {
+			log.Warnf("secret %s/%s of mcp server certs is deleted, keep using the previous ones", ns, name)
+		},
+	})
+	informer.Run(ctx.Done())
+}
+
+// jwtAuthenticator authenticates the grpc streams by the bearer token in the metadata `authorization`.
+type jwtAuthenticator struct {
+	args *bootstrap.McpJWTArgs
+
+	mut  sync.RWMutex
+	keys jwk.Set
+}
+
+// newJWTAuthenticator creates the jwtAuthenticator with the initial JWKS loaded.
+func newJWTAuthenticator(args *bootstrap.McpJWTArgs) (*jwtAuthenticator, error) {
+	if args.JwksFile == "" && args.JwksUri == "" {
+		return nil, errors.New("either JwksFile or JwksUri is required")
+	}
+	a := &jwtAuthenticator{args: args}
+	keys, err := a.loadKeys(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+	a.keys = keys
+	return a, nil
+}
+
+func (a *jwtAuthenticator) loadKeys(ctx context.Context) (jwk.Set, error) {
+	if a.args.JwksFile != "" {
+		keys, err := jwk.ReadFile(a.args.JwksFile)
+		if err != nil {
+			return nil, fmt.Errorf("read jwks file %s failed: %v", a.args.JwksFile, err)
+		}
+		return keys, nil
+	}
+	keys, err := jwk.Fetch(ctx, a.args.JwksUri)
+	if err != nil {
+		return nil, fmt.Errorf("fetch jwks from %s failed: %v", a.args.JwksUri, err)
+	}
+	return keys, nil
+}
+
+// run reloads the JWKS until the ctx is done.
+func (a *jwtAuthenticator) run(ctx context.Context) {
+	ticker := time.NewTicker(credentialReloadInterval(time.Duration(a.args.ReloadInterval)))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			keys, err := a.loadKeys(ctx)
+			if err != nil {
+				log.Errorf("reload jwks failed, keep using the previous ones: %v", err)
+				continue
+			}
+			a.mut.Lock()
+			a.keys = keys
+			a.mut.Unlock()
+		}
+	}
+}
+
+func (a *jwtAuthenticator) authenticate(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(authorizationHeader)
+	if len(values) == 0 {
+		return status.Error(codes.Unauthenticated, "missing authorization token")
+	}
+	token := values[0]
+	if len(token) <= len(bearerTokenPrefix) || !strings.EqualFold(token[:len(bearerTokenPrefix)], bearerTokenPrefix) {
+		return status.Error(codes.Unauthenticated, "authorization is not a bearer token")
+	}
+	token = token[len(bearerTokenPrefix):]
+
+	a.mut.RLock()
+	keys := a.keys
+	a.mut.RUnlock()
+	opts := []jwt.ParseOption{
+		jwt.WithKeySet(keys),
+		jwt.UseDefaultKey(true),
+		jwt.InferAlgorithmFromKey(true),
+		jwt.WithValidate(true),
+	}
+	if a.args.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(a.args.Issuer))
+	}
+	tok, err := jwt.Parse([]byte(token), opts...)
+	if err != nil {
+		return status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+	}
+	if len(a.args.Audiences) > 0 && !containsAny(tok.Audience(), a.args.Audiences) {
+		return status.Error(codes.Unauthenticated, "invalid token: audience not accepted")
+	}
+	return nil
+}
+
+func (a *jwtAuthenticator) streamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	_ *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	if err := a.authenticate(ss.Context()); err != nil {
+		log.Warnf("reject mcp client: %v", err)
+		return err
+	}
+	return handler(srv, ss)
+}
+
+func containsAny(values, candidates []string) bool {
+	for _, v := range values {
+		for _, c := range candidates {
+			if v == c {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,286 @@
+package mcpoverxds
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jwt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"slime.io/slime/modules/meshregistry/pkg/bootstrap"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func newTestCert(t *testing.T, cn string, parent *testCert) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA, tmpl.BasicConstraintsValid = true, true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// handshake dials the tls server with the client cert(optional) and returns the serial number of the server cert.
+func handshake(t *testing.T, serverCfg *tls.Config, ca *testCert, client *testCert) (*big.Int, error) {
+	t.Helper()
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", serverCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	go func() {
+		conn, err := lis.Accept()
+		if err == nil {
+			_ = conn.(*tls.Conn).Handshake()
+			_, _ = conn.Read(make([]byte, 1))
+			_ = conn.Close()
+		}
+	}()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientCfg := &tls.Config{RootCAs: roots, ServerName: "mcp-server"}
+	if client != nil {
+		cert, _ := tls.X509KeyPair(client.certPEM, client.keyPEM)
+		clientCfg.Certificates = []tls.Certificate{cert}
+	}
+	conn, err := tls.Dial("tcp", lis.Addr().String(), clientCfg)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	// the server verifies the client cert after the client finishes the handshake in tls 1.3, which is only
+	// observed by the client on the next read.
+	_ = conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if _, err = conn.Read(make([]byte, 1)); err != nil && !isTimeout(err) {
+		return nil, err
+	}
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber, nil
+}
+
+func isTimeout(err error) bool {
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
+
+func TestServerTLSFromFiles(t *testing.T) {
+	ca := newTestCert(t, "ca", nil)
+	server := newTestCert(t, "mcp-server", ca)
+	client := newTestCert(t, "mcp-client", ca)
+
+	dir := t.TempDir()
+	args := &bootstrap.McpTLSArgs{
+		CertFile: filepath.Join(dir, "tls.crt"),
+		KeyFile:  filepath.Join(dir, "tls.key"),
+		CAFile:   filepath.Join(dir, "ca.crt"),
+	}
+	writeFiles := func(c *testCert) {
+		_ = os.WriteFile(args.CertFile, c.certPEM, 0o600)
+		_ = os.WriteFile(args.KeyFile, c.keyPEM, 0o600)
+		_ = os.WriteFile(args.CAFile, ca.certPEM, 0o600)
+	}
+	writeFiles(server)
+
+	st, err := newServerTLS(args, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := st.config()
+	serial, err := handshake(t, cfg, ca, client)
+	if err != nil {
+		t.Fatalf("mTLS handshake failed: %v", err)
+	}
+	if serial.Cmp(server.cert.SerialNumber) != 0 {
+		t.Fatalf("unexpected server cert %v", serial)
+	}
+	if _, err = handshake(t, cfg, ca, nil); err == nil {
+		t.Fatal("expect the client without cert to be rejected")
+	}
+
+	// the reloaded cert is used by the new connections, and the invalid one is ignored.
+	rotated := newTestCert(t, "mcp-server", ca)
+	writeFiles(rotated)
+	m, _ := st.readFiles()
+	if err = st.update(m); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.update(tlsMaterial{cert: []byte("invalid"), key: m.key}); err == nil {
+		t.Fatal("expect invalid cert to be rejected")
+	}
+	serial, err = handshake(t, cfg, ca, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if serial.Cmp(rotated.cert.SerialNumber) != 0 {
+		t.Fatalf("expect the rotated server cert, got %v", serial)
+	}
+}
+
+func TestServerTLSFromSecret(t *testing.T) {
+	ca := newTestCert(t, "ca", nil)
+	server := newTestCert(t, "mcp-server", ca)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "mesh", Name: "mcp-certs"},
+		Data: map[string][]byte{
+			secretCertKey: server.certPEM,
+			secretKeyKey:  server.keyPEM,
+		},
+	}
+	client := fake.NewSimpleClientset(secret)
+	args := &bootstrap.McpTLSArgs{SecretName: "mcp-certs", SecretNamespace: "mesh"}
+
+	if _, err := newServerTLS(args, nil); err == nil {
+		t.Fatal("expect error without kube client")
+	}
+	st, err := newServerTLS(args, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go st.run(ctx)
+
+	rotated := newTestCert(t, "mcp-server", ca)
+	secret = secret.DeepCopy()
+	secret.Data[secretCertKey], secret.Data[secretKeyKey] = rotated.certPEM, rotated.keyPEM
+	// the watch may be established after the update, retry until it's observed.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, _ = client.CoreV1().Secrets("mesh").Update(ctx, secret, metav1.UpdateOptions{})
+		serial, err := handshake(t, st.config(), ca, nil)
+		if err == nil && serial.Cmp(rotated.cert.SerialNumber) == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the rotated cert in secret is not loaded, err %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, _ := jwk.New(&key.PublicKey)
+	_ = pub.Set(jwk.KeyIDKey, "k1")
+	_ = pub.Set(jwk.AlgorithmKey, jwa.ES256)
+	set := jwk.NewSet()
+	set.Add(pub)
+	b, _ := json.Marshal(set)
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	_ = os.WriteFile(jwksFile, b, 0o600)
+
+	signer, _ := jwk.New(key)
+	_ = signer.Set(jwk.KeyIDKey, "k1")
+	sign := func(iss, aud string, exp time.Time) string {
+		tok := jwt.New()
+		_ = tok.Set(jwt.IssuerKey, iss)
+		_ = tok.Set(jwt.AudienceKey, aud)
+		_ = tok.Set(jwt.ExpirationKey, exp)
+		signed, err := jwt.Sign(tok, jwa.ES256, signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(signed)
+	}
+
+	if _, err = newJWTAuthenticator(&bootstrap.McpJWTArgs{}); err == nil {
+		t.Fatal("expect error without jwks")
+	}
+	a, err := newJWTAuthenticator(&bootstrap.McpJWTArgs{
+		JwksFile:  jwksFile,
+		Issuer:    "slime",
+		Audiences: []string{"meshregistry", "mcp"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid := time.Now().Add(time.Hour)
+	tests := []struct {
+		name  string
+		auth  string
+		valid bool
+	}{
+		{name: "valid", auth: "Bearer " + sign("slime", "mcp", valid), valid: true},
+		{name: "lowercase scheme", auth: "bearer " + sign("slime", "meshregistry", valid), valid: true},
+		{name: "missing"},
+		{name: "not bearer", auth: "Basic dXNlcjpwYXNz"},
+		{name: "wrong issuer", auth: "Bearer " + sign("other", "mcp", valid)},
+		{name: "wrong audience", auth: "Bearer " + sign("slime", "other", valid)},
+		{name: "expired", auth: "Bearer " + sign("slime", "mcp", time.Now().Add(-time.Hour))},
+		{name: "malformed", auth: "Bearer abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.auth != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(authorizationHeader, tt.auth))
+			}
+			err := a.authenticate(ctx)
+			if tt.valid {
+				if err != nil {
+					t.Fatalf("expect valid, got %v", err)
+				}
+				return
+			}
+			if status.Code(err) != codes.Unauthenticated {
+				t.Fatalf("expect unauthenticated, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,428 @@
+package mcpoverxds
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+	"istio.io/istio-mcp/pkg/config/schema/resource"
+	mcp "istio.io/istio-mcp/pkg/mcp"
+	mcpxds "istio.io/istio-mcp/pkg/mcp/xds"
+	mcpmodel "istio.io/istio-mcp/pkg/model"
+
+	"slime.io/slime/modules/meshregistry/pkg/monitoring"
+)
+
+const (
+	// wildcardResourceName is the resource name used by delta clients to (un)subscribe all resources of a type.
+	wildcardResourceName = "*"
+	deltaConnIDInfix     = "-delta-"
+)
+
+var deltaConnectionNumber int64
+
+// serveDelta serves an incremental ADS stream.
+func (s *XdsServer) serveDelta(stream discovery.AggregatedDiscoveryService_DeltaAggregatedResourcesServer) error {
+	if s.store == nil {
+		return errors.New("config store not set")
+	}
+
+	peerAddr := "0.0.0.0"
+	if peerInfo, ok := peer.FromContext(stream.Context()); ok {
+		peerAddr = peerInfo.Addr.String()
+	}
+	con := &deltaConnection{
+		peerAddr: peerAddr,
+		connect:  time.Now(),
+		stream:   stream,
+		pushCh:   make(chan struct{}, 1),
+		watches:  map[string]*deltaWatch{},
+	}
+
+	var receiveErr error
+	reqCh := make(chan *discovery.DeltaDiscoveryRequest, 1)
+	go s.receiveDelta(con, reqCh, &receiveErr)
+
+	for {
+		select {
+		case req, ok := <-reqCh:
+			if !ok {
+				return receiveErr
+			}
+			if err := s.processDeltaRequest(con, req); err != nil {
+				log.Errorf("process delta req %s for %s met err %v", req.TypeUrl, con.id, err)
+				return err
+			}
+		case <-con.pushCh:
+			if err := s.pushDelta(con); err != nil {
+				log.Errorf("push delta for %s met err %v", con.id, err)
+				return err
+			}
+		}
+	}
+}
+
+func (s *XdsServer) receiveDelta(con *deltaConnection, reqCh chan *discovery.DeltaDiscoveryRequest, errP *error) {
+	defer close(reqCh)
+	firstReq := true
+	for {
+		req, err := con.stream.Recv()
+		if err != nil {
+			if !isExpectedGRPCError(err) {
+				*errP = err
+				log.Errorf("delta ADS: %q %s terminated with error: %v", con.peerAddr, con.id, err)
+			} else {
+				log.Infof("delta ADS: %q %s terminated %v", con.peerAddr, con.id, err)
+			}
+			return
+		}
+		if firstReq {
+			firstReq = false
+			if req.Node == nil || req.Node.Id == "" {
+				*errP = errors.New("missing node ID")
+				return
+			}
+			meta, err := mcpxds.ParseMetadata(req.Node.Metadata)
+			if err != nil {
+				*errP = err
+				return
+			}
+			filter, err := resolveClientFilter(con.stream.Context(), req.Node.Metadata)
+			if err != nil {
+				*errP = status.Errorf(codes.InvalidArgument, "invalid client filter: %v", err)
+				return
+			}
+			con.id = req.Node.Id + deltaConnIDInfix +
+				strconv.FormatInt(atomic.AddInt64(&deltaConnectionNumber, 1), 10)
+			con.revision = meta.IstioRevision
+			if filter != nil && filter.Revision != "" {
+				con.revision = filter.Revision
+			}
+			con.filter = filter
+			con.store = s.storeView(filter)
+			s.addDeltaCon(con)
+			defer s.removeDeltaCon(con.id)
+		}
+
+		select {
+		case reqCh <- req:
+		case <-con.stream.Context().Done():
+			log.Infof("delta ADS: %q %s terminated with stream closed", con.peerAddr, con.id)
+			return
+		}
+	}
+}
+
+func (s *XdsServer) processDeltaRequest(con *deltaConnection, req *discovery.DeltaDiscoveryRequest) error {
+	gvk := resource.TypeUrlToGvk(req.TypeUrl)
+	if gvk == resource.AllGvk {
+		log.Warnf("delta ADS: %s requests unknown type %s", con.id, req.TypeUrl)
+		return nil
+	}
+
+	con.mu.Lock()
+	w := con.watches[req.TypeUrl]
+	initial := w == nil
+	if initial {
+		w = newDeltaWatch(gvk, req)
+		con.watches[req.TypeUrl] = w
+	}
+
+	if req.ResponseNonce != "" {
+		if req.ErrorDetail != nil {
+			log.Warnf("delta ADS: %s nacked %s nonce %s: %s",
+				con.id, req.TypeUrl, req.ResponseNonce, req.ErrorDetail.GetMessage())
+		} else {
+			w.recordAck(req.ResponseNonce)
+		}
+	}
+
+	subscriptionChanged := w.updateSubscription(req.ResourceNamesSubscribe, req.ResourceNamesUnsubscribe)
+	con.mu.Unlock()
+
+	if !initial && !subscriptionChanged {
+		// pure ack/nack
+		return nil
+	}
+	return s.pushDeltaWatch(con, w, initial)
+}
+
+func (s *XdsServer) pushDelta(con *deltaConnection) error {
+	con.mu.Lock()
+	watches := make([]*deltaWatch, 0, len(con.watches))
+	for _, w := range con.watches {
+		watches = append(watches, w)
+	}
+	con.mu.Unlock()
+
+	for _, w := range watches {
+		if err := s.pushDeltaWatch(con, w, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pushDeltaWatch sends the changed and removed resources of a watch. An initial request is always responded,
+// even if there's nothing to send.
+func (s *XdsServer) pushDeltaWatch(con *deltaConnection, w *deltaWatch, force bool) error {
+	configs, _, err := con.store.List(w.gvk, resource.AllNamespace, "")
+	if err != nil {
+		return fmt.Errorf("list %v met err %v", w.gvk, err)
+	}
+
+	con.mu.Lock()
+	changed, removed := w.diff(configs, con.revision)
+	con.mu.Unlock()
+	if !force && len(changed) == 0 && len(removed) == 0 {
+		return nil
+	}
+
+	resp := &discovery.DeltaDiscoveryResponse{
+		SystemVersionInfo: s.store.Version(resource.AllNamespace),
+		TypeUrl:           w.gvk.String(),
+		RemovedResources:  removed,
+		Nonce:             uuid.New().String(),
+	}
+	sent := make(map[string]string, len(changed))
+	for _, cfg := range changed {
+		cfg := cfg
+		r, err := mcp.ConfigToResource(&cfg)
+		if err != nil {
+			log.Warnf("delta ADS: convert config %s/%s to mcp resource error: %v", cfg.Namespace, cfg.Name, err)
+			continue
+		}
+		body, err := anypb.New(r)
+		if err != nil {
+			log.Warnf("delta ADS: create any of %s/%s error: %v", cfg.Namespace, cfg.Name, err)
+			continue
+		}
+		name, ver := deltaResourceName(cfg), mcpmodel.CurrentResourceVersion(&cfg)
+		resp.Resources = append(resp.Resources, &discovery.Resource{
+			Name:     name,
+			Version:  ver,
+			Resource: body,
+		})
+		sent[name] = ver
+	}
+
+	if err := sendWithTimeout(con.stream, resp); err != nil {
+		return err
+	}
+
+	con.mu.Lock()
+	w.recordSent(resp.Nonce, resp.SystemVersionInfo, sent, removed)
+	con.mu.Unlock()
+	monitoring.RecordMcpDeltaPush(len(resp.Resources), len(removed))
+	return nil
+}
+
+func sendWithTimeout(stream discovery.AggregatedDiscoveryService_DeltaAggregatedResourcesServer,
+	resp *discovery.DeltaDiscoveryResponse,
+) error {
+	done := make(chan error, 1)
+	t := time.NewTimer(mcpxds.SendTimeout)
+	defer t.Stop()
+	go func() {
+		done <- stream.Send(resp)
+	}()
+	select {
+	case <-t.C:
+		return fmt.Errorf("delta send of %s timeout", resp.TypeUrl)
+	case err := <-done:
+		return err
+	}
+}
+
+func deltaResourceName(cfg mcpmodel.Config) string {
+	return cfg.Namespace + "/" + cfg.Name
+}
+
+type deltaConnection struct {
+	id, peerAddr string
+	connect      time.Time
+	// revision is the istio revision of the client, empty means it accepts configs of all revisions.
+	revision string
+	filter   *ClientFilter
+	// store is the view of the config store seen by the client
+	store  mcpmodel.ConfigStore
+	stream discovery.AggregatedDiscoveryService_DeltaAggregatedResourcesServer
+	pushCh chan struct{}
+
+	mu      sync.Mutex
+	watches map[string]*deltaWatch // typeUrl -> watch
+}
+
+func (con *deltaConnection) notify() bool {
+	select {
+	case con.pushCh <- struct{}{}:
+		return true
+	default:
+		// a push is already pending and will see the latest state.
+		return false
+	}
+}
+
+func (con *deltaConnection) clientInfo() mcp.ClientInfo {
+	return mcp.ClientInfo{
+		ClientId: con.id,
+		ConnId:   con.id,
+		Addr:     con.peerAddr,
+	}
+}
+
+func (con *deltaConnection) pushStatus() map[resource.GroupVersionKind]mcp.ConfigPushStatus {
+	con.mu.Lock()
+	defer con.mu.Unlock()
+	ret := make(map[resource.GroupVersionKind]mcp.ConfigPushStatus, len(con.watches))
+	for _, w := range con.watches {
+		ret[w.gvk] = mcp.ConfigPushStatus{PushVer: w.pushVer, AckVer: w.ackVer}
+	}
+	return ret
+}
+
+// deltaWatch tracks the subscription of a delta client to a type and the resources known by the client.
+type deltaWatch struct {
+	gvk        resource.GroupVersionKind
+	wildcard   bool
+	subscribed map[string]struct{}
+	// sent records the version of each resource the client is known to have
+	sent map[string]string
+
+	pushVer, ackVer string
+	// pending are the responses not acked yet, in the order of sending
+	pending []pendingResponse
+}
+
+// maxPendingResponses caps the responses waiting for acks of a watch, the oldest ones are dropped beyond it as a
+// client acks the latest responses only if it keeps up, or never acks at all.
+const maxPendingResponses = 16
+
+type pendingResponse struct {
+	nonce, ver string
+}
+
+func newDeltaWatch(gvk resource.GroupVersionKind, req *discovery.DeltaDiscoveryRequest) *deltaWatch {
+	w := &deltaWatch{
+		gvk:        gvk,
+		subscribed: map[string]struct{}{},
+		sent:       make(map[string]string, len(req.InitialResourceVersions)),
+		// legacy wildcard: the first request of a type subscribes nothing explicitly
+		wildcard: len(req.ResourceNamesSubscribe) == 0,
+	}
+	// resources the client already has (e.g. reconnecting), no need to resend them if unchanged.
+	for name, ver := range req.InitialResourceVersions {
+		w.sent[name] = ver
+	}
+	return w
+}
+
+func (w *deltaWatch) updateSubscription(subscribe, unsubscribe []string) bool {
+	var changed bool
+	for _, name := range subscribe {
+		if name == wildcardResourceName {
+			changed = changed || !w.wildcard
+			w.wildcard = true
+			continue
+		}
+		if _, ok := w.subscribed[name]; !ok {
+			w.subscribed[name] = struct{}{}
+			changed = true
+		}
+	}
+	for _, name := range unsubscribe {
+		if name == wildcardResourceName {
+			changed = changed || w.wildcard
+			w.wildcard = false
+			continue
+		}
+		if _, ok := w.subscribed[name]; ok {
+			delete(w.subscribed, name)
+			changed = true
+		}
+		// the client forgets unsubscribed resources, a re-subscription should send them again
+		delete(w.sent, name)
+	}
+	return changed
+}
+
+func (w *deltaWatch) interested(name string) bool {
+	if w.wildcard {
+		return true
+	}
+	_, ok := w.subscribed[name]
+	return ok
+}
+
+// diff returns the configs which are new or changed compared with the versions known by the client, and the
+// names of the resources which the client has but are deleted or no longer visible to it.
+func (w *deltaWatch) diff(configs []mcpmodel.Config, revision string) ([]mcpmodel.Config, []string) {
+	var (
+		changed []mcpmodel.Config
+		removed []string
+		current = make(map[string]struct{}, len(configs))
+	)
+	for _, cfg := range configs {
+		if cfg.Spec == nil { // zombie left for inc-push deletion
+			continue
+		}
+		name := deltaResourceName(cfg)
+		if !w.interested(name) || !configVisibleToRevision(&cfg, revision) {
+			continue
+		}
+		current[name] = struct{}{}
+		if ver, ok := w.sent[name]; ok && ver == mcpmodel.CurrentResourceVersion(&cfg) {
+			continue
+		}
+		changed = append(changed, cfg)
+	}
+	for name := range w.sent {
+		if _, ok := current[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	return changed, removed
+}
+
+func (w *deltaWatch) recordSent(nonce, ver string, sent map[string]string, removed []string) {
+	for name, v := range sent {
+		w.sent[name] = v
+	}
+	for _, name := range removed {
+		delete(w.sent, name)
+	}
+	w.pushVer = ver
+	w.pending = append(w.pending, pendingResponse{nonce: nonce, ver: ver})
+	if n := len(w.pending) - maxPendingResponses; n > 0 {
+		w.pending = append(w.pending[:0], w.pending[n:]...)
+	}
+}
+
+// recordAck matches the acked response by nonce, acks of unknown or stale nonces are ignored.
+func (w *deltaWatch) recordAck(nonce string) {
+	for i, resp := range w.pending {
+		if resp.nonce == nonce {
+			w.ackVer = resp.ver
+			// responses older than the acked one will never be interesting
+			w.pending = w.pending[i+1:]
+			return
+		}
+	}
+}
+
+func configVisibleToRevision(cfg *mcpmodel.Config, revision string) bool {
+	if revision == "" {
+		// consider it's a legacy client and leave the filter-by-rev thing to itself
+		return true
+	}
+	return mcpmodel.ObjectInRevision(cfg, revision)
+}
@@ -0,0 +1,153 @@
+package mcpoverxds
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	networkingapi "istio.io/api/networking/v1alpha3"
+	"istio.io/istio-mcp/pkg/config/schema/resource"
+	mcpmodel "istio.io/istio-mcp/pkg/model"
+)
+
+var seGvk = resource.GroupVersionKind{Group: "networking.istio.io", Version: "v1alpha3", Kind: "ServiceEntry"}
+
+func newTestConfig(ns, name, ver, rev string) mcpmodel.Config {
+	cfg := mcpmodel.Config{
+		Meta: mcpmodel.Meta{
+			GroupVersionKind: seGvk,
+			Namespace:        ns,
+			Name:             name,
+			ResourceVersion:  ver,
+		},
+		Spec: &networkingapi.ServiceEntry{Hosts: []string{name}},
+	}
+	if rev != "" {
+		cfg.Labels = map[string]string{mcpmodel.IstioRevLabel: rev}
+	}
+	mcpmodel.UpdateAnnotationResourceVersion(&cfg)
+	return cfg
+}
+
+func configNames(configs []mcpmodel.Config) []string {
+	ret := make([]string, 0, len(configs))
+	for _, cfg := range configs {
+		ret = append(ret, deltaResourceName(cfg))
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func TestDeltaWatchDiff(t *testing.T) {
+	w := newDeltaWatch(seGvk, &discovery.DeltaDiscoveryRequest{
+		InitialResourceVersions: map[string]string{"dubbo/a": "1", "dubbo/gone": "1"},
+	})
+	if !w.wildcard {
+		t.Fatalf("first request without names should be wildcard")
+	}
+
+	configs := []mcpmodel.Config{
+		newTestConfig("dubbo", "a", "1", ""),
+		newTestConfig("dubbo", "b", "2", ""),
+		newTestConfig("dubbo", "other-rev", "2", "canary"),
+	}
+	changed, removed := w.diff(configs, "default")
+	if got, want := configNames(changed), []string{"dubbo/b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("changed = %v, want %v", got, want)
+	}
+	if want := []string{"dubbo/gone"}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("removed = %v, want %v", removed, want)
+	}
+	w.recordSent("n1", "2", map[string]string{"dubbo/b": "2"}, removed)
+
+	// nothing changed
+	changed, removed = w.diff(configs, "default")
+	if len(changed) != 0 || len(removed) != 0 {
+		t.Errorf("expect no diff, got changed %v removed %v", configNames(changed), removed)
+	}
+
+	// update b, delete a (zombie)
+	zombie := newTestConfig("dubbo", "a", "3", "")
+	zombie.Spec = nil
+	configs = []mcpmodel.Config{zombie, newTestConfig("dubbo", "b", "3", "")}
+	changed, removed = w.diff(configs, "default")
+	if got, want := configNames(changed), []string{"dubbo/b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("changed = %v, want %v", got, want)
+	}
+	if want := []string{"dubbo/a"}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("removed = %v, want %v", removed, want)
+	}
+}
+
+func TestDeltaWatchSubscription(t *testing.T) {
+	w := newDeltaWatch(seGvk, &discovery.DeltaDiscoveryRequest{ResourceNamesSubscribe: []string{"dubbo/a"}})
+	if w.wildcard {
+		t.Fatalf("explicit subscription should not be wildcard")
+	}
+	if !w.updateSubscription([]string{"dubbo/a", "dubbo/b"}, nil) {
+		t.Errorf("subscribing new name should change subscription")
+	}
+	if w.updateSubscription([]string{"dubbo/b"}, nil) {
+		t.Errorf("subscribing known name should not change subscription")
+	}
+
+	configs := []mcpmodel.Config{
+		newTestConfig("dubbo", "a", "1", ""),
+		newTestConfig("dubbo", "b", "1", ""),
+		newTestConfig("dubbo", "c", "1", ""),
+	}
+	changed, _ := w.diff(configs, "")
+	if got, want := configNames(changed), []string{"dubbo/a", "dubbo/b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("changed = %v, want %v", got, want)
+	}
+	w.recordSent("n1", "1", map[string]string{"dubbo/a": "1", "dubbo/b": "1"}, nil)
+
+	if !w.updateSubscription(nil, []string{"dubbo/b"}) {
+		t.Errorf("unsubscribing known name should change subscription")
+	}
+	if _, ok := w.sent["dubbo/b"]; ok {
+		t.Errorf("unsubscribed resource should be forgotten")
+	}
+
+	if !w.updateSubscription([]string{wildcardResourceName}, nil) {
+		t.Errorf("subscribing wildcard should change subscription")
+	}
+	changed, _ = w.diff(configs, "")
+	if got, want := configNames(changed), []string{"dubbo/b", "dubbo/c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("changed = %v, want %v", got, want)
+	}
+}
+
+func TestDeltaWatchAck(t *testing.T) {
+	w := newDeltaWatch(seGvk, &discovery.DeltaDiscoveryRequest{})
+	w.recordSent("n9", "9", nil, nil)
+	w.recordSent("n10", "10", nil, nil)
+	w.recordAck("n10")
+	if w.ackVer != "10" || w.pushVer != "10" {
+		t.Errorf("ackVer %s pushVer %s, want 10", w.ackVer, w.pushVer)
+	}
+	if len(w.pending) != 0 {
+		t.Errorf("pending responses should be cleared, got %v", w.pending)
+	}
+
+	// a late ack of an older response is stale
+	w.recordAck("n9")
+	if w.ackVer != "10" {
+		t.Errorf("ackVer %s, want 10", w.ackVer)
+	}
+	// a client never acking does not make the pending responses grow without limit
+	for i := 0; i < 2*maxPendingResponses; i++ {
+		w.recordSent(fmt.Sprintf("m%d", i), strconv.Itoa(11+i), nil, nil)
+	}
+	if len(w.pending) != maxPendingResponses {
+		t.Errorf("pending responses %d, want %d", len(w.pending), maxPendingResponses)
+	}
+	last := fmt.Sprintf("m%d", 2*maxPendingResponses-1)
+	w.recordAck(last)
+	if w.ackVer != strconv.Itoa(10+2*maxPendingResponses) || len(w.pending) != 0 {
+		t.Errorf("ackVer %s pending %v after acking %s", w.ackVer, w.pending, last)
+	}
+}
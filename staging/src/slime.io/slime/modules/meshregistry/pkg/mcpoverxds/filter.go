@@ -0,0 +1,228 @@
+package mcpoverxds
+
+import (
+	"context"
+	"encoding/json"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/structpb"
+	"istio.io/istio-mcp/pkg/config/schema/resource"
+	mcpxds "istio.io/istio-mcp/pkg/mcp/xds"
+	mcpmodel "istio.io/istio-mcp/pkg/model"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// ClientFilterNodeMetaKey is the node metadata key used by mcp clients to declare their filter.
+	// The value can be either a json object or a json string of the ClientFilter.
+	ClientFilterNodeMetaKey = "SLIME_MCP_FILTER"
+	// ClientFilterHeader is the grpc request metadata used by mcp clients to declare their filter in json.
+	// It has higher priority than the node metadata.
+	ClientFilterHeader = "x-slime-mcp-filter"
+
+	// clientFilterLabel is the reserved node label used to hand over the resolved filter to the generator.
+	clientFilterLabel = "mcp.slime.io/client-filter"
+
+	nodeMetaLabels        = "LABELS"
+	nodeMetaIstioRevision = "ISTIO_REVISION"
+
+	apiGeneratorName = "api"
+)
+
+// ClientFilter is declared by a mcp client to restrict the configs pushed to it.
+// All the non-empty conditions are ANDed.
+type ClientFilter struct {
+	// Namespaces of the configs the client is interested in, empty means all.
+	Namespaces []string `json:"Namespaces,omitempty"`
+	// LabelSelector selects configs by their labels.
+	LabelSelector *metav1.LabelSelector `json:"LabelSelector,omitempty"`
+	// Sources are the registry sources, like `zookeeper`, `nacos` and `eureka`, of the configs the client is
+	// interested in. Configs not from any registry source, are only selected by an empty Sources.
+	Sources []string `json:"Sources,omitempty"`
+	// Revision overrides the istio revision declared by the client with node metadata `ISTIO_REVISION`.
+	Revision string `json:"Revision,omitempty"`
+
+	namespaces map[string]struct{}
+	sources    map[string]struct{}
+	selector   labels.Selector
+}
+
+func (f *ClientFilter) String() string {
+	b, _ := json.Marshal(f)
+	return string(b)
+}
+
+func (f *ClientFilter) init() error {
+	if len(f.Namespaces) > 0 {
+		f.namespaces = make(map[string]struct{}, len(f.Namespaces))
+		for _, ns := range f.Namespaces {
+			f.namespaces[ns] = struct{}{}
+		}
+	}
+	if len(f.Sources) > 0 {
+		f.sources = make(map[string]struct{}, len(f.Sources))
+		for _, src := range f.Sources {
+			f.sources[src] = struct{}{}
+		}
+	}
+	if f.LabelSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(f.LabelSelector)
+		if err != nil {
+			return err
+		}
+		f.selector = selector
+	}
+	return nil
+}
+
+func (f *ClientFilter) matchNamespace(ns string) bool {
+	if f == nil || f.namespaces == nil {
+		return true
+	}
+	_, ok := f.namespaces[ns]
+	return ok
+}
+
+// Matches returns whether the config, which comes from the registry source, is selected by the filter.
+func (f *ClientFilter) Matches(cfg *mcpmodel.Config, source string) bool {
+	if f == nil {
+		return true
+	}
+	if !f.matchNamespace(cfg.Namespace) {
+		return false
+	}
+	if f.sources != nil {
+		if _, ok := f.sources[source]; !ok {
+			return false
+		}
+	}
+	if f.selector != nil && !f.selector.Matches(labels.Set(cfg.Labels)) {
+		return false
+	}
+	return true
+}
+
+// ParseClientFilter parses the filter in json, nil will be returned if the filter is empty.
+func ParseClientFilter(data []byte) (*ClientFilter, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	f := &ClientFilter{}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, err
+	}
+	if len(f.Namespaces) == 0 && len(f.Sources) == 0 && f.LabelSelector == nil && f.Revision == "" {
+		return nil, nil
+	}
+	if err := f.init(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// resolveClientFilter resolves the filter declared by the client through the grpc request metadata or the
+// node metadata.
+func resolveClientFilter(ctx context.Context, nodeMeta *structpb.Struct) (*ClientFilter, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(ClientFilterHeader); len(values) > 0 && values[0] != "" {
+			return ParseClientFilter([]byte(values[0]))
+		}
+	}
+
+	v := nodeMeta.GetFields()[ClientFilterNodeMetaKey]
+	if v == nil {
+		return nil, nil
+	}
+	if str, ok := v.GetKind().(*structpb.Value_StringValue); ok {
+		return ParseClientFilter([]byte(str.StringValue))
+	}
+	data, err := v.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return ParseClientFilter(data)
+}
+
+// injectClientFilter hands over the filter to the generator through the node labels, which is the only
+// free-form part of the node metadata kept by the mcp xds server. The filter revision, if specified,
+// overrides the node revision so that the mcp xds server does the revision matching for us.
+func injectClientFilter(nodeMeta *structpb.Struct, filter *ClientFilter) *structpb.Struct {
+	if nodeMeta == nil {
+		nodeMeta = &structpb.Struct{}
+	}
+	if nodeMeta.Fields == nil {
+		nodeMeta.Fields = map[string]*structpb.Value{}
+	}
+
+	nodeLabels := nodeMeta.Fields[nodeMetaLabels].GetStructValue()
+	if nodeLabels == nil {
+		nodeLabels = &structpb.Struct{}
+		nodeMeta.Fields[nodeMetaLabels] = structpb.NewStructValue(nodeLabels)
+	}
+	if nodeLabels.Fields == nil {
+		nodeLabels.Fields = map[string]*structpb.Value{}
+	}
+	nodeLabels.Fields[clientFilterLabel] = structpb.NewStringValue(filter.String())
+
+	if filter.Revision != "" {
+		nodeMeta.Fields[nodeMetaIstioRevision] = structpb.NewStringValue(filter.Revision)
+	}
+	return nodeMeta
+}
+
+// clientFilterOfProxy returns the filter handed over through the node labels.
+func (s *XdsServer) clientFilterOfProxy(proxy *mcpxds.Proxy) *ClientFilter {
+	if proxy == nil || proxy.Metadata == nil {
+		return nil
+	}
+	raw := proxy.Metadata.Labels[clientFilterLabel]
+	if raw == "" {
+		return nil
+	}
+	if f := s.labelFilter(raw); f != nil {
+		return f
+	}
+	// not recorded by a connected client, parse it every time rather than caching it without bound
+	f, err := ParseClientFilter([]byte(raw))
+	if err != nil {
+		log.Errorf("parse client filter %s of %s met err %v", raw, proxy.ID, err)
+		return nil
+	}
+	return f
+}
+
+// filteringGenerator applies the client filter by generating with a filtered view of the config store.
+type filteringGenerator struct {
+	inner mcpxds.XdsResourceGenerator
+	s     *XdsServer
+}
+
+func (g *filteringGenerator) Generate(
+	proxy *mcpxds.Proxy,
+	push *mcpxds.PushContext,
+	w *mcpxds.WatchedResource,
+	updates mcpxds.XdsUpdates,
+) mcpxds.Resources {
+	if filter := g.s.clientFilterOfProxy(proxy); filter != nil {
+		push = &mcpxds.PushContext{
+			Version:     push.Version,
+			ConfigStore: g.s.storeView(filter),
+		}
+	}
+	return g.inner.Generate(proxy, push, w, updates)
+}
+
+// filteredConfigStore is the view of the McpConfigStore seen by a client with the filter.
+type filteredConfigStore struct {
+	*McpConfigStore
+	filter *ClientFilter
+}
+
+func (s *filteredConfigStore) Get(gvk resource.GroupVersionKind, ns, name string) (*mcpmodel.Config, error) {
+	return s.McpConfigStore.get(gvk, ns, name, s.filter)
+}
+
+func (s *filteredConfigStore) List(gvk resource.GroupVersionKind, ns, ver string) ([]mcpmodel.Config, string, error) {
+	return s.McpConfigStore.list(gvk, ns, ver, s.filter)
+}
@@ -0,0 +1,149 @@
+package mcpoverxds
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/structpb"
+	"istio.io/istio-mcp/pkg/config/schema/resource"
+	mcpxds "istio.io/istio-mcp/pkg/mcp/xds"
+)
+
+func TestClientFilterList(t *testing.T) {
+	store := NewConfigStore()
+	for _, item := range []struct {
+		source, ns, name string
+		labels           map[string]string
+	}{
+		{"zookeeper", "dubbo", "a", map[string]string{"app": "a"}},
+		{"zookeeper", "dubbo", "b", map[string]string{"app": "b"}},
+		{"nacos", "nacos", "c", map[string]string{"app": "a"}},
+		{"", "default", "d", nil},
+	} {
+		cfg := newTestConfig(item.ns, item.name, "", "")
+		cfg.Labels = item.labels
+		store.UpdateFromSource(item.source, item.ns, seGvk, item.name, &cfg)
+	}
+
+	tests := []struct {
+		name   string
+		filter string
+		want   []string
+	}{
+		{name: "no filter", filter: "", want: []string{"default/d", "dubbo/a", "dubbo/b", "nacos/c"}},
+		{name: "namespaces", filter: `{"Namespaces":["dubbo","default"]}`, want: []string{"default/d", "dubbo/a", "dubbo/b"}},
+		{name: "sources", filter: `{"Sources":["nacos"]}`, want: []string{"nacos/c"}},
+		{
+			name:   "label selector",
+			filter: `{"LabelSelector":{"matchLabels":{"app":"a"}}}`,
+			want:   []string{"dubbo/a", "nacos/c"},
+		},
+		{
+			name:   "anded",
+			filter: `{"Sources":["zookeeper"],"LabelSelector":{"matchLabels":{"app":"a"}}}`,
+			want:   []string{"dubbo/a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := ParseClientFilter([]byte(tt.filter))
+			if err != nil {
+				t.Fatalf("parse filter: %v", err)
+			}
+			configs, _, err := store.list(seGvk, resource.AllNamespace, "", filter)
+			if err != nil {
+				t.Fatalf("list: %v", err)
+			}
+			if got := configNames(configs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientFilterIncList(t *testing.T) {
+	store := NewConfigStore()
+	filter, err := ParseClientFilter([]byte(`{"LabelSelector":{"matchLabels":{"app":"a"}}}`))
+	if err != nil {
+		t.Fatalf("parse filter: %v", err)
+	}
+
+	a := newTestConfig("dubbo", "a", "1", "")
+	a.Labels = map[string]string{"app": "a"}
+	store.Update("dubbo", seGvk, "a", &a)
+	configs, ver, _ := store.list(seGvk, resource.AllNamespace, "", filter)
+	if len(configs) != 1 || ver != "1" {
+		t.Fatalf("got %v ver %s, want dubbo/a ver 1", configNames(configs), ver)
+	}
+
+	// no longer matches the filter
+	a = newTestConfig("dubbo", "a", "2", "")
+	a.Labels = map[string]string{"app": "b"}
+	store.Update("dubbo", seGvk, "a", &a)
+	configs, ver, _ = store.list(seGvk, resource.AllNamespace, "1", filter)
+	if len(configs) != 1 || configs[0].Name != "a" || configs[0].Spec != nil || ver != "2" {
+		t.Errorf("got %v ver %s, want dubbo/a deleted at ver 2", configs, ver)
+	}
+	if configs, _, _ = store.list(seGvk, resource.AllNamespace, "", filter); len(configs) != 0 {
+		t.Errorf("full list got %v, want none", configNames(configs))
+	}
+}
+
+func TestResolveAndInjectClientFilter(t *testing.T) {
+	nodeMeta, err := structpb.NewStruct(map[string]interface{}{
+		ClientFilterNodeMetaKey: map[string]interface{}{
+			"Namespaces": []interface{}{"dubbo"},
+			"Revision":   "canary",
+		},
+		"LABELS": map[string]interface{}{"app": "istiod"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filter, err := resolveClientFilter(context.Background(), nodeMeta)
+	if err != nil || filter == nil {
+		t.Fatalf("resolve filter from node meta, filter %v err %v", filter, err)
+	}
+	if !reflect.DeepEqual(filter.Namespaces, []string{"dubbo"}) || filter.Revision != "canary" {
+		t.Errorf("unexpected filter %s", filter)
+	}
+
+	meta, err := mcpxds.ParseMetadata(injectClientFilter(nodeMeta, filter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.IstioRevision != "canary" || meta.Labels["app"] != "istiod" {
+		t.Errorf("unexpected node meta %+v", meta)
+	}
+	s := &XdsServer{filters: map[string]*clientFilterRef{}, labelFilters: map[string]*clientFilterRef{}}
+	got := s.clientFilterOfProxy(&mcpxds.Proxy{Metadata: meta})
+	if got == nil || !reflect.DeepEqual(got.Namespaces, filter.Namespaces) {
+		t.Errorf("filter handed over %v, want %v", got, filter)
+	}
+
+	// the parsed filter is kept only while the client is connected
+	client := sotwClient{nodeID: "istiod-1", filterLabel: filter.String()}
+	s.recordClientFilter(client, filter)
+	if got := s.clientFilterOfProxy(&mcpxds.Proxy{Metadata: meta}); got != filter {
+		t.Errorf("filter handed over %v, want the recorded one", got)
+	}
+	s.releaseClientFilter(client)
+	if len(s.filters) != 0 || len(s.labelFilters) != 0 {
+		t.Errorf("filters should be released, got %v %v", s.filters, s.labelFilters)
+	}
+
+	// request metadata has higher priority
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(ClientFilterHeader, `{"Sources":["nacos"]}`))
+	filter, err = resolveClientFilter(ctx, nodeMeta)
+	if err != nil || filter == nil || !reflect.DeepEqual(filter.Sources, []string{"nacos"}) {
+		t.Errorf("resolve filter from request metadata, filter %v err %v", filter, err)
+	}
+
+	if _, err = ParseClientFilter([]byte(`{"LabelSelector":{"matchExpressions":[{"key":"a","operator":"bad"}]}}`)); err == nil {
+		t.Errorf("expect invalid selector error")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -19,6 +20,7 @@ import (
 	"istio.io/libistio/pkg/config/event"
 	resource2 "istio.io/libistio/pkg/config/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/yaml"
 
 	frameworkmodel "slime.io/slime/framework/model"
@@ -46,10 +48,14 @@ type McpController struct {
 	Handler     event.Handler
 }
 
-func NewController(args *bootstrap.RegistryArgs) (*McpController, error) {
+// NewController creates the mcp controller. The kubeClient is only required to load the server certs from Secret.
+func NewController(args *bootstrap.RegistryArgs, kubeClient kubernetes.Interface) (*McpController, error) {
 	if args.Mcp.EnableIncPush && !args.Mcp.EnableAnnoResVer {
 		return nil, errors.New("incPush enabled but anno res ver not enabled")
 	}
+	if args.Mcp.EnableDeltaXds && !args.Mcp.EnableAnnoResVer {
+		return nil, errors.New("delta xds enabled but anno res ver not enabled")
+	}
 	impl := &McpController{
 		mcpArgs: args.Mcp,
 
@@ -63,12 +69,7 @@ func NewController(args *bootstrap.RegistryArgs) (*McpController, error) {
 	// another side effect is that the init-full-push will contain some necessary nil-spec items,
 	// we'll have this in the push logic.
 	impl.Handler = &XdsEventHandler{c: impl, leaveZomb: args.Mcp.EnableIncPush}
-	svr, err := mcpsvr.NewServer(&mcpsvr.Options{
-		XdsServerOptions: &mcpxds.ServerOptions{
-			IncPush: args.Mcp.EnableIncPush,
-		},
-		ServerUrl: args.Mcp.ServerUrl,
-	})
+	svr, err := newMcpServer(args.Mcp, kubeClient)
 	if err != nil {
 		return nil, fmt.Errorf("new xds server with url %s met err %v", args.Mcp.ServerUrl, err)
 	}
@@ -77,8 +78,69 @@ func NewController(args *bootstrap.RegistryArgs) (*McpController, error) {
 	return impl, nil
 }
 
+func newMcpServer(args *bootstrap.McpArgs, kubeClient kubernetes.Interface) (mcp.Server, error) {
+	if !strings.HasPrefix(args.ServerUrl, "xds://") {
+		if args.EnableDeltaXds {
+			return nil, fmt.Errorf("delta xds requires xds server url, got %s", args.ServerUrl)
+		}
+		if args.TLS != nil || args.JWT != nil {
+			return nil, fmt.Errorf("tls and jwt require xds server url, got %s", args.ServerUrl)
+		}
+		log.Warnf("client filter is not supported by non-xds mcp server %s", args.ServerUrl)
+		return mcpsvr.NewServer(&mcpsvr.Options{
+			XdsServerOptions: &mcpxds.ServerOptions{
+				IncPush: args.EnableIncPush,
+			},
+			ServerUrl: args.ServerUrl,
+		})
+	}
+
+	o := mcpxds.DefaultServerOptions()
+	o.IncPush = args.EnableIncPush
+	if err := mcpxds.ParseIntoServerOptions(args.ServerUrl, o); err != nil {
+		return nil, err
+	}
+	svr := NewXdsServer(o, args.EnableDeltaXds)
+	if args.TLS != nil {
+		st, err := newServerTLS(args.TLS, kubeClient)
+		if err != nil {
+			return nil, fmt.Errorf("init tls failed: %v", err)
+		}
+		svr.tls = st
+	}
+	if args.JWT != nil {
+		a, err := newJWTAuthenticator(args.JWT)
+		if err != nil {
+			return nil, fmt.Errorf("init jwt authentication failed: %v", err)
+		}
+		svr.jwt = a
+	}
+	return svr, nil
+}
+
+// HandlerFor returns the event handler for the events from the registry source.
+func (c *McpController) HandlerFor(source string) event.Handler {
+	return &XdsEventHandler{c: c, leaveZomb: c.mcpArgs.EnableIncPush, source: source}
+}
+
+type clientInfo struct {
+	mcp.ClientDetailedInfo
+	// Filter is the effective filter declared by the client
+	Filter *ClientFilter `json:"Filter,omitempty"`
+}
+
 func (c *McpController) HandleClientsInfo(w http.ResponseWriter, _ *http.Request) {
-	b, err := json.MarshalIndent(c.mcpServer.ClientsInfo(), "", "  ")
+	clientsInfo := c.mcpServer.ClientsInfo()
+	infos := make(map[string]clientInfo, len(clientsInfo))
+	filterGetter, _ := c.mcpServer.(interface{ ClientFilter(string) *ClientFilter })
+	for id, ci := range clientsInfo {
+		info := clientInfo{ClientDetailedInfo: ci}
+		if filterGetter != nil {
+			info.Filter = filterGetter.ClientFilter(id)
+		}
+		infos[id] = info
+	}
+	b, err := json.MarshalIndent(infos, "", "  ")
 	if err != nil {
 		_, _ = fmt.Fprintf(w, "unable to marshal node cahce se cache: %v", err)
 		return
@@ -96,6 +158,7 @@ func (c *McpController) HandleClientsInfo(w http.ResponseWriter, _ *http.Request
 //	format: see below constants
 //	layout: see below constants
 //	k8sRsc: whether to convert config to k8s resource format obj
+//	filter: client filter in json, will return configs seen by a client with the filter
 func (c *McpController) HandleXdsCache(w http.ResponseWriter, r *http.Request) {
 	const (
 		layoutGroupByGvk = "gvk"   // map[typeUrl][]config
@@ -168,6 +231,12 @@ func (c *McpController) HandleXdsCache(w http.ResponseWriter, r *http.Request) {
 		k8sRsc = true
 	}
 
+	filter, err := ParseClientFilter([]byte(values.Get("filter")))
+	if err != nil {
+		http.Error(w, "invalid filter: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	converter := func(config mcpmodel.Config) interface{} {
 		type k8sResource struct {
 			metav1.TypeMeta `json:",inline"`
@@ -216,12 +285,11 @@ func (c *McpController) HandleXdsCache(w http.ResponseWriter, r *http.Request) {
 	var (
 		configs []mcpmodel.Config
 		config  *mcpmodel.Config
-		err     error
 	)
 	if name == "" {
-		configs, _, err = c.configStore.List(gvk, ns, ver)
+		configs, _, err = c.configStore.list(gvk, ns, ver, filter)
 	} else {
-		config, err = c.configStore.Get(gvk, ns, name)
+		config, err = c.configStore.get(gvk, ns, name, filter)
 	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -310,7 +378,13 @@ func (c *McpController) push() {
 	}
 
 	log.Infof("new push for version: %s", version)
+	var revChanged int
+	if req != nil {
+		revChanged = len(req.RevChangeConfigs)
+	}
+	endSpan := monitoring.TraceMcpPush(version, revChanged)
 	c.mcpServer.NotifyPush(req)
+	endSpan()
 	c.lastPushVer = version
 	monitoring.RecordMcpPush()
 }
@@ -436,6 +510,8 @@ func (c *McpController) RecordConfigRevChange(gvk resource.GroupVersionKind, nn
 type XdsEventHandler struct {
 	leaveZomb bool
 	c         *McpController
+	// source is the registry source of the events, can be empty
+	source string
 }
 
 func (h *XdsEventHandler) Handle(e event.Event) {
@@ -451,7 +527,7 @@ func (h *XdsEventHandler) Handle(e event.Event) {
 	var revChanged bool
 	switch e.Kind {
 	case event.Added, event.Updated:
-		revChanged = h.c.configStore.Update(ns, config.GroupVersionKind, name, config)
+		revChanged = h.c.configStore.UpdateFromSource(h.source, ns, config.GroupVersionKind, name, config)
 	case event.Deleted:
 		gvk := config.GroupVersionKind
 		if h.leaveZomb {
@@ -459,7 +535,7 @@ func (h *XdsEventHandler) Handle(e event.Event) {
 		} else {
 			config = nil
 		}
-		revChanged = h.c.configStore.Update(ns, gvk, name, config)
+		revChanged = h.c.configStore.UpdateFromSource(h.source, ns, gvk, name, config)
 	default:
 		// nothing
 	}
@@ -13,6 +13,8 @@ type McpConfigStore struct {
 	snaps               map[string]map[resource.GroupVersionKind]map[string]*mcpmodel.Config
 	nsVersions          map[string]string
 	gvkResourceVersions map[resource.GroupVersionKind]string
+	// sources records the registry source of configs
+	sources map[mcpmodel.ConfigKey]string
 }
 
 func NewConfigStore() *McpConfigStore {
@@ -20,6 +22,7 @@ func NewConfigStore() *McpConfigStore {
 		snaps:               make(map[string]map[resource.GroupVersionKind]map[string]*mcpmodel.Config),
 		nsVersions:          make(map[string]string),
 		gvkResourceVersions: map[resource.GroupVersionKind]string{},
+		sources:             map[mcpmodel.ConfigKey]string{},
 	}
 }
 
@@ -32,6 +35,17 @@ func (s *McpConfigStore) Update(
 	gvk resource.GroupVersionKind,
 	name string,
 	config *mcpmodel.Config,
+) (revChanged bool) {
+	return s.UpdateFromSource("", ns, gvk, name, config)
+}
+
+// UpdateFromSource is like Update, and records the registry source of the config.
+func (s *McpConfigStore) UpdateFromSource(
+	source string,
+	ns string,
+	gvk resource.GroupVersionKind,
+	name string,
+	config *mcpmodel.Config,
 ) (revChanged bool) {
 	var prev *mcpmodel.Config
 
@@ -77,10 +91,15 @@ func (s *McpConfigStore) Update(
 	}
 
 	prev = gvkConfigs[name]
+	key := mcpmodel.ConfigKey{Kind: gvk, Name: name, Namespace: ns}
 	if config == nil {
 		delete(gvkConfigs, name)
+		delete(s.sources, key)
 	} else {
 		gvkConfigs[name] = config
+		if source != "" {
+			s.sources[key] = source
+		}
 	}
 
 	s.nsVersions[ns] = tnow
@@ -106,11 +125,19 @@ func (s *McpConfigStore) Version(ns string) string {
 }
 
 func (s *McpConfigStore) Get(gvk resource.GroupVersionKind, ns, name string) (*mcpmodel.Config, error) {
+	return s.get(gvk, ns, name, nil)
+}
+
+func (s *McpConfigStore) get(
+	gvk resource.GroupVersionKind,
+	ns, name string,
+	filter *ClientFilter,
+) (*mcpmodel.Config, error) {
 	s.RLock()
 	defer s.RUnlock()
 
 	cfg := s.snaps[ns][gvk][name]
-	if cfg == nil {
+	if cfg == nil || !filter.Matches(cfg, s.sources[mcpmodel.Key(cfg)]) {
 		return nil, nil
 	}
 
@@ -119,6 +146,15 @@ func (s *McpConfigStore) Get(gvk resource.GroupVersionKind, ns, name string) (*m
 }
 
 func (s *McpConfigStore) List(gvk resource.GroupVersionKind, ns, ver string) ([]mcpmodel.Config, string, error) {
+	return s.list(gvk, ns, ver, nil)
+}
+
+// list lists the configs newer than ver and selected by the filter, nil filter selects all.
+func (s *McpConfigStore) list(
+	gvk resource.GroupVersionKind,
+	ns, ver string,
+	filter *ClientFilter,
+) ([]mcpmodel.Config, string, error) {
 	var (
 		ret    []mcpmodel.Config
 		retVer string
@@ -134,7 +170,10 @@ func (s *McpConfigStore) List(gvk resource.GroupVersionKind, ns, ver string) ([]
 		}
 	}
 
-	for _, nsConfigs := range snaps {
+	for nsName, nsConfigs := range snaps {
+		if !filter.matchNamespace(nsName) {
+			continue
+		}
 		if gvk != resource.AllGvk {
 			nsConfigs = map[resource.GroupVersionKind]map[string]*mcpmodel.Config{
 				gvk: nsConfigs[gvk],
@@ -143,12 +182,23 @@ func (s *McpConfigStore) List(gvk resource.GroupVersionKind, ns, ver string) ([]
 
 		for _, gvkConfigs := range nsConfigs {
 			for _, conf := range gvkConfigs {
-				if ver == "" || conf.ResourceVersion > ver {
-					if conf.ResourceVersion > retVer {
-						retVer = conf.ResourceVersion
+				if ver != "" && conf.ResourceVersion <= ver {
+					continue
+				}
+				if filter != nil && !filter.Matches(conf, s.sources[mcpmodel.Key(conf)]) {
+					if ver == "" {
+						continue
 					}
-					ret = append(ret, *conf)
+					// the config may have matched the filter before this change, send it as deleted in the
+					// incremental push so that the client does not keep a stale one.
+					tombstone := *conf
+					tombstone.Spec = nil
+					conf = &tombstone
+				}
+				if conf.ResourceVersion > retVer {
+					retVer = conf.ResourceVersion
 				}
+				ret = append(ret, *conf)
 			}
 		}
 	}
@@ -232,6 +282,7 @@ func (s *McpConfigStore) ClearZombie(
 			for name, cfg := range gvkConfigs {
 				if cfg.Spec == nil && cfg.ResourceVersion <= minVer {
 					delete(gvkConfigs, name)
+					delete(s.sources, mcpmodel.Key(cfg))
 					cnt[gvk]++
 				}
 			}
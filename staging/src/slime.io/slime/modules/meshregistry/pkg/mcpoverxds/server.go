@@ -0,0 +1,321 @@
+package mcpoverxds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	mcp "istio.io/istio-mcp/pkg/mcp"
+	mcpxds "istio.io/istio-mcp/pkg/mcp/xds"
+	mcpmodel "istio.io/istio-mcp/pkg/model"
+)
+
+// XdsServer serves MCP-over-xDS on top of the mcp xds server.
+//   - SotW streams are delegated to the wrapped mcp xds server, with the client filter declared by the client
+//     applied to the configs generated for it.
+//   - if delta is enabled, delta streams are served directly from the config store: the server tracks the
+//     resource names each client subscribed and the version sent for each resource, and only sends changed
+//     or removed resources on push.
+type XdsServer struct {
+	*mcpxds.Server
+
+	opts  *mcpxds.ServerOptions
+	delta bool
+	store mcpmodel.ConfigStore
+
+	connsMut sync.RWMutex
+	conns    map[string]*deltaConnection
+
+	filtersMut sync.RWMutex
+	// filters of the SotW clients, keyed by node id
+	filters map[string]*clientFilterRef
+	// labelFilters are the filters of the SotW clients keyed by the node label handing them over to the
+	// generator, so that they are parsed once per connection rather than per push
+	labelFilters map[string]*clientFilterRef
+
+	// tls and jwt are optional, the server is plain text and unauthenticated without them.
+	tls *serverTLS
+	jwt *jwtAuthenticator
+}
+
+type clientFilterRef struct {
+	filter *ClientFilter
+	refs   int
+}
+
+var _ mcp.Server = &XdsServer{}
+
+func NewXdsServer(o *mcpxds.ServerOptions, delta bool) *XdsServer {
+	s := &XdsServer{
+		Server:       mcpxds.NewServer(o),
+		opts:         o,
+		delta:        delta,
+		conns:        map[string]*deltaConnection{},
+		filters:      map[string]*clientFilterRef{},
+		labelFilters: map[string]*clientFilterRef{},
+	}
+	// must be done before any connection as the generator is bound to the proxy at connecting.
+	s.Server.Generators[apiGeneratorName] = &filteringGenerator{
+		inner: s.Server.Generators[apiGeneratorName],
+		s:     s,
+	}
+	return s
+}
+
+func (s *XdsServer) SetConfigStore(store mcpmodel.ConfigStore) {
+	s.store = store
+	s.Server.SetConfigStore(store)
+}
+
+// ClientsInfo returns both the SotW and the delta clients. Delta clients are marked with `-delta-` in their id.
+func (s *XdsServer) ClientsInfo() map[string]mcp.ClientDetailedInfo {
+	ret := s.Server.ClientsInfo()
+	if ret == nil {
+		ret = map[string]mcp.ClientDetailedInfo{}
+	}
+	for _, con := range s.deltaConnections() {
+		ret[con.id] = mcp.ClientDetailedInfo{
+			ClientInfo: con.clientInfo(),
+			PushStatus: con.pushStatus(),
+		}
+	}
+	return ret
+}
+
+// ClientFilter returns the effective filter of the client with the connection id, nil means no filter.
+func (s *XdsServer) ClientFilter(connID string) *ClientFilter {
+	if strings.Contains(connID, deltaConnIDInfix) {
+		s.connsMut.RLock()
+		defer s.connsMut.RUnlock()
+		if con := s.conns[connID]; con != nil {
+			return con.filter
+		}
+		return nil
+	}
+
+	// SotW connection id is in format of `<node-id>-<seq>`
+	idx := strings.LastIndex(connID, "-")
+	if idx < 0 {
+		return nil
+	}
+	s.filtersMut.RLock()
+	defer s.filtersMut.RUnlock()
+	if ref := s.filters[connID[:idx]]; ref != nil {
+		return ref.filter
+	}
+	return nil
+}
+
+func (s *XdsServer) NotifyPush(req *mcp.PushRequest) {
+	s.Server.NotifyPush(req)
+
+	conns := s.deltaConnections()
+	var newNotified int
+	for _, con := range conns {
+		if con.notify() {
+			newNotified++
+		}
+	}
+	if len(conns) > 0 {
+		log.Infof("XdsServer.NotifyPush, total delta clients %d, new notified %d", len(conns), newNotified)
+	}
+}
+
+func (s *XdsServer) Start(ctx context.Context) {
+	go func() {
+		addr := fmt.Sprintf("%s:%d", s.opts.Addr, s.opts.Port)
+		lis, err := net.Listen("tcp", addr)
+		if err != nil {
+			log.Errorf("can not listen to xds addr %s, err %v", addr, err)
+			return
+		}
+		var opts []grpc.ServerOption
+		if s.tls != nil {
+			go s.tls.run(ctx)
+			opts = append(opts, grpc.Creds(credentials.NewTLS(s.tls.config())))
+		}
+		if s.jwt != nil {
+			go s.jwt.run(ctx)
+			opts = append(opts, grpc.StreamInterceptor(s.jwt.streamInterceptor))
+		}
+		gs := grpc.NewServer(opts...)
+		discovery.RegisterAggregatedDiscoveryServiceServer(gs, s)
+		reflection.Register(gs)
+		go func() {
+			if err := gs.Serve(lis); err != nil {
+				log.Infof("xds server return err: %v", err)
+			}
+		}()
+		go func() {
+			<-ctx.Done()
+			gs.GracefulStop()
+		}()
+	}()
+}
+
+// StreamAggregatedResources implements the SotW ADS interface. The client filter is resolved from the first
+// request and handed over to the mcp xds server through the node metadata.
+func (s *XdsServer) StreamAggregatedResources(
+	stream discovery.AggregatedDiscoveryService_StreamAggregatedResourcesServer,
+) error {
+	fs := &filterResolvingStream{
+		AggregatedDiscoveryService_StreamAggregatedResourcesServer: stream,
+		s: s,
+	}
+	defer func() {
+		// Recv runs on the receive goroutine of the mcp xds server, which may still be running here
+		if client, _ := fs.client.Load().(sotwClient); client.nodeID != "" {
+			s.releaseClientFilter(client)
+		}
+	}()
+	return s.Server.StreamAggregatedResources(fs)
+}
+
+func (s *XdsServer) DeltaAggregatedResources(
+	stream discovery.AggregatedDiscoveryService_DeltaAggregatedResourcesServer,
+) error {
+	if !s.delta {
+		return status.Errorf(codes.Unimplemented, "delta xds not enabled")
+	}
+	return s.serveDelta(stream)
+}
+
+// sotwClient identifies the client filter recorded for a SotW connection.
+type sotwClient struct {
+	nodeID, filterLabel string
+}
+
+func (s *XdsServer) recordClientFilter(client sotwClient, filter *ClientFilter) {
+	s.filtersMut.Lock()
+	defer s.filtersMut.Unlock()
+	ref := s.filters[client.nodeID]
+	if ref == nil {
+		ref = &clientFilterRef{}
+		s.filters[client.nodeID] = ref
+	}
+	// the latest connection wins
+	ref.filter = filter
+	ref.refs++
+
+	ref = s.labelFilters[client.filterLabel]
+	if ref == nil {
+		ref = &clientFilterRef{filter: filter}
+		s.labelFilters[client.filterLabel] = ref
+	}
+	ref.refs++
+}
+
+func (s *XdsServer) releaseClientFilter(client sotwClient) {
+	s.filtersMut.Lock()
+	defer s.filtersMut.Unlock()
+	release := func(refs map[string]*clientFilterRef, key string) {
+		ref := refs[key]
+		if ref == nil {
+			return
+		}
+		if ref.refs--; ref.refs <= 0 {
+			delete(refs, key)
+		}
+	}
+	release(s.filters, client.nodeID)
+	release(s.labelFilters, client.filterLabel)
+}
+
+// labelFilter returns the filter of a connected SotW client by the node label handing it over.
+func (s *XdsServer) labelFilter(label string) *ClientFilter {
+	s.filtersMut.RLock()
+	defer s.filtersMut.RUnlock()
+	if ref := s.labelFilters[label]; ref != nil {
+		return ref.filter
+	}
+	return nil
+}
+
+// storeView returns the view of the config store seen by a client with the filter.
+func (s *XdsServer) storeView(filter *ClientFilter) mcpmodel.ConfigStore {
+	if filter == nil {
+		return s.store
+	}
+	if store, ok := s.store.(*McpConfigStore); ok {
+		return &filteredConfigStore{McpConfigStore: store, filter: filter}
+	}
+	return s.store
+}
+
+func (s *XdsServer) deltaConnections() []*deltaConnection {
+	s.connsMut.RLock()
+	defer s.connsMut.RUnlock()
+	ret := make([]*deltaConnection, 0, len(s.conns))
+	for _, con := range s.conns {
+		ret = append(ret, con)
+	}
+	return ret
+}
+
+func (s *XdsServer) addDeltaCon(con *deltaConnection) {
+	s.connsMut.Lock()
+	defer s.connsMut.Unlock()
+	s.conns[con.id] = con
+}
+
+func (s *XdsServer) removeDeltaCon(id string) {
+	s.connsMut.Lock()
+	defer s.connsMut.Unlock()
+	delete(s.conns, id)
+}
+
+// filterResolvingStream resolves the client filter from the first request of a SotW stream.
+type filterResolvingStream struct {
+	discovery.AggregatedDiscoveryService_StreamAggregatedResourcesServer
+	s      *XdsServer
+	inited bool
+	client atomic.Value // sotwClient
+}
+
+func (fs *filterResolvingStream) Recv() (*discovery.DiscoveryRequest, error) {
+	req, err := fs.AggregatedDiscoveryService_StreamAggregatedResourcesServer.Recv()
+	if err != nil || fs.inited {
+		return req, err
+	}
+	fs.inited = true
+	if req.Node == nil || req.Node.Id == "" {
+		// leave it to the mcp xds server
+		return req, nil
+	}
+
+	filter, err := resolveClientFilter(fs.Context(), req.Node.Metadata)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid client filter: %v", err)
+	}
+	if filter == nil {
+		return req, nil
+	}
+	req.Node.Metadata = injectClientFilter(req.Node.Metadata, filter)
+	client := sotwClient{nodeID: req.Node.Id, filterLabel: filter.String()}
+	fs.s.recordClientFilter(client, filter)
+	fs.client.Store(client)
+	log.Infof("mcp client %s declared filter %s", req.Node.Id, filter)
+	return req, nil
+}
+
+func isExpectedGRPCError(err error) bool {
+	if errors.Is(err, io.EOF) {
+		return true
+	}
+	s := status.Convert(err)
+	if s.Code() == codes.Canceled || s.Code() == codes.DeadlineExceeded {
+		return true
+	}
+	return s.Code() == codes.Unavailable && s.Message() == "client disconnected"
+}
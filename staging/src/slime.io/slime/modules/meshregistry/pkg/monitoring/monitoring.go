@@ -1,6 +1,9 @@
 package monitoring
 
 import (
+	"context"
+	"fmt"
+	"strconv"
 	"time"
 
 	"slime.io/slime/framework/monitoring"
@@ -12,6 +15,12 @@ var (
 	souceLabel = monitoring.MustCreateLabel("source")
 	// statusLabel is the label for the status of the event.
 	statusLabel = monitoring.MustCreateLabel("status")
+	// opLabel is the label for the operation of the kube sink write.
+	opLabel = monitoring.MustCreateLabel("op")
+	// versionLabel is the span attribute for the version of the mcp push.
+	versionLabel = monitoring.MustCreateLabel("version")
+	// revChangedLabel is the span attribute for the number of configs with rev changed of the mcp push.
+	revChangedLabel = monitoring.MustCreateLabel("rev_changed")
 )
 
 var (
@@ -36,6 +45,26 @@ var (
 		"mcp_push_count",
 		"Number of mcp push.",
 	)
+
+	// mcpDeltaPushResources is the number of resources sent by delta mcp push.
+	mcpDeltaPushResources = monitoring.NewSum(
+		model.ModuleName,
+		"mcp_delta_push_resources",
+		"Number of resources sent by delta mcp push.",
+	)
+	// mcpDeltaPushRemovedResources is the number of removed resources sent by delta mcp push.
+	mcpDeltaPushRemovedResources = monitoring.NewSum(
+		model.ModuleName,
+		"mcp_delta_push_removed_resources",
+		"Number of removed resources sent by delta mcp push.",
+	)
+
+	// kubeSinkWrites is the number of writes to kubernetes by the kube sink.
+	kubeSinkWrites = monitoring.NewSum(
+		model.ModuleName,
+		"kube_sink_writes",
+		"Number of writes to kubernetes by the kube sink.",
+	)
 )
 
 // RecordEnabledSource records the number of enabled sources.
@@ -52,3 +81,24 @@ func RecordReady(source string, t0, t1 time.Time) {
 func RecordMcpPush() {
 	mcpPushCount.Increment()
 }
+
+// TraceMcpPush starts a span of the mcp push, the returned func ends it.
+func TraceMcpPush(version string, revChanged int) func() {
+	_, span := monitoring.StartSpan(context.Background(), model.ModuleName, "McpPush",
+		versionLabel.Value(version), revChangedLabel.Value(strconv.Itoa(revChanged)))
+	return func() { monitoring.EndSpan(span, nil) }
+}
+
+// RecordMcpDeltaPush records the number of changed and removed resources of a delta mcp push.
+func RecordMcpDeltaPush(changed, removed int) {
+	mcpDeltaPushResources.Add(float64(changed))
+	mcpDeltaPushRemovedResources.Add(float64(removed))
+}
+
+// RecordKubeSinkWrite records a write to kubernetes by the kube sink.
+func RecordKubeSinkWrite(op string, success bool) {
+	kubeSinkWrites.With(
+		opLabel.Value(op),
+		statusLabel.Value(fmt.Sprintf("%t", success)),
+	).Increment()
+}
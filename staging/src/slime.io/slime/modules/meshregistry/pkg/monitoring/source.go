@@ -1,6 +1,8 @@
 package monitoring
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -111,8 +113,17 @@ var (
 	)
 )
 
+var errPollingFailed = errors.New("polling failed")
+
 // RecordPolling records the time spent on each polling and increment the number of pollings by source.
+// The polling is also traced as a span.
 func RecordPolling(source string, t0, t1 time.Time, success bool) {
+	var err error
+	if !success {
+		err = errPollingFailed
+	}
+	monitoring.RecordSpan(context.Background(), model.ModuleName, "RegistryPoll", t0, t1, err, souceLabel.Value(source))
+
 	pollingTime.With(
 		souceLabel.Value(source),
 		statusLabel.Value(fmt.Sprintf("%t", success)),
@@ -29,7 +29,10 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 
+	bootconfig "slime.io/slime/framework/apis/config/v1alpha1"
+	slimebootstrap "slime.io/slime/framework/bootstrap"
 	"slime.io/slime/modules/meshregistry/pkg/bootstrap"
+	"slime.io/slime/modules/meshregistry/pkg/kubesink"
 	"slime.io/slime/modules/meshregistry/pkg/mcpoverxds"
 	"slime.io/slime/modules/meshregistry/pkg/monitoring"
 	"slime.io/slime/modules/meshregistry/pkg/multicluster"
@@ -42,6 +45,7 @@ import (
 type Processing struct {
 	regArgs      *bootstrap.RegistryArgs
 	addOnRegArgs func(onRegArgs func(args *bootstrap.RegistryArgs))
+	slimeEnv     slimebootstrap.Environment
 
 	localCLusterID string
 
@@ -50,6 +54,7 @@ type Processing struct {
 	listener      net.Listener
 	stopCh        chan struct{}
 	httpServer    *HttpServer
+	kubeSink      *kubesink.Sink
 }
 
 // NewProcessing returns a new processing component.
@@ -57,6 +62,7 @@ func NewProcessing(args *Args) *Processing {
 	p := &Processing{
 		regArgs:        args.RegistryArgs,
 		addOnRegArgs:   args.AddOnRegArgs,
+		slimeEnv:       args.SlimeEnv,
 		stopCh:         make(chan struct{}),
 		localCLusterID: args.RegistryArgs.K8S.ClusterID,
 	}
@@ -84,8 +90,20 @@ func (p *Processing) Start() (err error) {
 	var srcPreStartHooks []func()
 	clusterCache := false
 	csrc := make([]event.Source, 0, len(source.RegistrySources()))
+	csrcIDs := make([]string, 0, len(source.RegistrySources()))
+
+	readyCallback := p.httpServer.SourceReadyCallBack
+	var dubboSidecarGen *source.DubboSidecarGenerator
+	if p.regArgs.DubboSidecar != nil {
+		dubboSidecarGen = source.NewDubboSidecarGenerator(p.regArgs.DubboSidecar, p.httpServer.SourceReadyCallBack)
+		readyCallback = func(registryID string) {
+			p.httpServer.SourceReadyCallBack(registryID)
+			dubboSidecarGen.SourceReady(registryID)
+		}
+	}
+
 	for registryID, initlizer := range source.RegistrySources() {
-		src, handlers, cacheCluster, skip, err := initlizer(p.regArgs, p.httpServer.SourceReadyCallBack, p.addOnRegArgs)
+		src, handlers, cacheCluster, skip, err := initlizer(p.regArgs, readyCallback, p.addOnRegArgs)
 		if err != nil {
 			log.Errorf("init registry source %s failed: %v", registryID, err)
 			return err
@@ -99,6 +117,30 @@ func (p *Processing) Start() (err error) {
 		}
 		clusterCache = clusterCache || cacheCluster
 		csrc = append(csrc, src)
+		csrcIDs = append(csrcIDs, registryID)
+	}
+
+	// the sources contributing dubbo call edges feed the shared dubbo `Sidecar` generation
+	contributorHandlers := map[int]event.Handler{}
+	if dubboSidecarGen != nil {
+		for idx, src := range csrc {
+			if c, ok := src.(source.DubboCallModelContributor); ok && c.ContributeDubboCallModel() {
+				contributorHandlers[idx] = dubboSidecarGen.HandlerFor(csrcIDs[idx])
+			}
+		}
+	}
+	if len(contributorHandlers) > 0 {
+		p.httpServer.SourceRegistry(source.DubboSidecarSourceName)
+		for path, handler := range dubboSidecarGen.DebugHandlers() {
+			p.httpServer.HandleFunc(path, handler)
+		}
+		csrc = append(csrc, dubboSidecarGen)
+		csrcIDs = append(csrcIDs, source.DubboSidecarSourceName)
+		if p.addOnRegArgs != nil {
+			p.addOnRegArgs(func(args *bootstrap.RegistryArgs) {
+				dubboSidecarGen.OnConfig(args.DubboSidecar)
+			})
+		}
 	}
 
 	if clusterCache {
@@ -111,14 +153,41 @@ func (p *Processing) Start() (err error) {
 		})
 	}
 
+	if p.regArgs.KubeSink != nil && p.regArgs.KubeSink.Enable {
+		p.kubeSink, err = kubesink.New(p.regArgs, p.slimeEnv.DynamicClient, p.clientGoTokenBucket())
+		if err != nil {
+			log.Errorf("init kube sink error: %v", err)
+			return err
+		}
+		go p.kubeSink.Run(p.stopCh)
+	}
+
 	p.httpServer.start()
 	// TODO start sources
-	mcpController, err := mcpoverxds.NewController(p.regArgs)
+	var mcpKubeClient kubernetes.Interface
+	if mcpTLS := p.regArgs.Mcp.TLS; mcpTLS != nil && mcpTLS.SecretName != "" {
+		if mcpKubeClient, err = p.getDeployKubeClient(); err != nil {
+			log.Errorf("get kube client for mcp server certs error: %v", err)
+			mcpKubeClient = nil
+		}
+	}
+	mcpController, err := mcpoverxds.NewController(p.regArgs, mcpKubeClient)
 	if err != nil {
 		log.Errorf("init mcpoverxds controller error: %v", err)
-	} else {
-		for _, src := range csrc {
-			src.Dispatch(mcpController.Handler)
+	}
+	for idx, src := range csrc {
+		var handler event.Handler
+		if mcpController != nil {
+			handler = mcpController.HandlerFor(csrcIDs[idx])
+		}
+		if p.kubeSink != nil {
+			handler = event.CombineHandlers(handler, p.kubeSink.HandlerFor(csrcIDs[idx]))
+		}
+		if contributorHandler, ok := contributorHandlers[idx]; ok {
+			handler = event.CombineHandlers(handler, contributorHandler)
+		}
+		if handler != nil {
+			src.Dispatch(handler)
 		}
 	}
 
@@ -152,10 +221,20 @@ func (p *Processing) startXdsOverMcp(mcpController *mcpoverxds.McpController, _
 	if prevReady {
 		return
 	}
+	if p.kubeSink != nil {
+		p.kubeSink.MarkSourcesReady()
+	}
 	mcpController.Run()
 	p.httpServer.HandleFunc("/xdsCache", mcpController.HandleXdsCache)
 }
 
+func (p *Processing) clientGoTokenBucket() *bootconfig.ClientGoTokenBucket {
+	if p.slimeEnv.Config == nil {
+		return nil
+	}
+	return p.slimeEnv.Config.GetGlobal().GetClientGoTokenBucket()
+}
+
 func CombineSources(c []event.Source) event.Source {
 	if len(c) == 0 {
 		return nil
@@ -254,8 +333,8 @@ func (p *Processing) initMulticluster() func() {
 }
 
 func (p *Processing) cacheRegArgs(w http.ResponseWriter, _ *http.Request) {
-	regArgs := p.regArgs
-	b, err := json.MarshalIndent(regArgs, "", "  ")
+	// the args are served without authentication, never expose the credentials
+	b, err := json.MarshalIndent(p.regArgs.Redacted(), "", "  ")
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		_, _ = fmt.Fprintf(w, "unable to marshal config: %v", err)
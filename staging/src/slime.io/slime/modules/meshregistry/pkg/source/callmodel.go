@@ -0,0 +1,258 @@
+package source
+
+import (
+	"strings"
+
+	networkingapi "istio.io/api/networking/v1alpha3"
+	"istio.io/libistio/pkg/config/event"
+)
+
+// AttachmentDubboCallModel is the key of the ServiceEntry event attachment whose value is the dubbo call models
+// (map[string]DubboCallModel, keyed by app) derived from the ServiceEntry. It should only be attached when the call
+// models changed, and an empty map means the ServiceEntry contributes nothing any more.
+const AttachmentDubboCallModel = "ATTACHMENT_DUBBO_CALL_MODEL"
+
+// DubboCallModelContributor is implemented by the sources which can contribute dubbo call edges to the
+// shared `DubboSidecarGenerator`. The call edges are carried by the ServiceEntry events of the source with the
+// attachment `AttachmentDubboCallModel`.
+type DubboCallModelContributor interface {
+	event.Source
+	// ContributeDubboCallModel returns whether the source is configured to contribute the call edges.
+	ContributeDubboCallModel() bool
+}
+
+type DubboCallModel struct {
+	Application     string              // dubbo service app
+	ProvideServices map[string]struct{} // services that app provides
+	ConsumeServices map[string]struct{} // services that app depends on
+}
+
+// Equals does not distinguish between nil and empty (map)
+func (m DubboCallModel) Equals(o DubboCallModel) bool {
+	if m.Application != o.Application {
+		return false
+	}
+
+	if len(m.ProvideServices) != len(o.ProvideServices) {
+		return false
+	}
+	for k := range m.ProvideServices {
+		if _, ok := o.ProvideServices[k]; !ok {
+			return false
+		}
+	}
+
+	if len(m.ConsumeServices) != len(o.ConsumeServices) {
+		return false
+	}
+	for k := range m.ConsumeServices {
+		if _, ok := o.ConsumeServices[k]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (m DubboCallModel) Reset() {
+}
+
+func (m DubboCallModel) String() string {
+	return m.Application
+}
+
+func (m DubboCallModel) ProtoMessage() {
+}
+
+func (m DubboCallModel) Provide(interfaceName string) bool {
+	_, ok := m.ProvideServices[interfaceName]
+	return ok
+}
+
+func (m DubboCallModel) Consume(interfaceName string) bool {
+	_, ok := m.ConsumeServices[interfaceName]
+	return ok
+}
+
+// AttachDubboCallModels attaches the call models to the ServiceEntry event.
+func AttachDubboCallModels(ev event.Event, callModels map[string]DubboCallModel) event.Event {
+	if ev.Resource == nil {
+		return ev
+	}
+	if ev.Resource.Attachments == nil {
+		ev.Resource.Attachments = map[string]interface{}{}
+	}
+	if callModels == nil {
+		callModels = map[string]DubboCallModel{}
+	}
+	ev.Resource.Attachments[AttachmentDubboCallModel] = callModels
+	return ev
+}
+
+// ConvertDubboCallModel derives the call models of a service: the apps of the endpoints provide it and the apps of
+// the inbound endpoints (consumers) consume it. The app of an endpoint is specified by the label `appLabel`.
+func ConvertDubboCallModel(
+	se *networkingapi.ServiceEntry,
+	interfaceName string,
+	inboundEndpoints []*networkingapi.WorkloadEntry,
+	appLabel string,
+) map[string]DubboCallModel {
+	dubboModels := make(map[string]DubboCallModel)
+
+	type item struct {
+		eps     []*networkingapi.WorkloadEntry
+		inbound bool
+	}
+
+	for _, it := range []item{
+		{eps: se.Endpoints, inbound: false},
+		{eps: inboundEndpoints, inbound: true},
+	} {
+		for _, e := range it.eps {
+			app, ok := e.Labels[appLabel]
+			if !ok {
+				continue
+			}
+
+			appModel, ok := dubboModels[app]
+			if !ok {
+				appModel = DubboCallModel{
+					Application:     app,
+					ConsumeServices: map[string]struct{}{},
+					ProvideServices: map[string]struct{}{},
+				}
+				dubboModels[app] = appModel
+			}
+
+			var m map[string]struct{}
+			if it.inbound {
+				m = appModel.ConsumeServices
+			} else {
+				m = appModel.ProvideServices
+			}
+
+			m[interfaceName] = struct{}{}
+		}
+	}
+
+	return dubboModels
+}
+
+// CalcChangedApps returns the apps whose call model changed.
+func CalcChangedApps(pre, cur map[string]DubboCallModel) []string {
+	var ret []string
+
+	for app, mo := range pre {
+		curMo, ok := cur[app]
+		if !ok || !mo.Equals(curMo) {
+			ret = append(ret, app)
+		}
+	}
+
+	for app := range cur {
+		if _, ok := pre[app]; !ok {
+			ret = append(ret, app)
+		}
+	}
+
+	return ret
+}
+
+func mergeDubboCallModels[K comparable](
+	seCallModels map[K]map[string]DubboCallModel,
+	includeProvider bool,
+	selfConsume bool,
+) map[string]DubboCallModel {
+	ret := make(map[string]DubboCallModel, len(seCallModels))
+
+	for _, curCallModels := range seCallModels {
+		for app, callModel := range curCallModels {
+			ret[app] = mergeToDubboCallModel(callModel, ret[app], includeProvider, selfConsume)
+		}
+	}
+
+	return ret
+}
+
+func mergeToDubboCallModel(from, to DubboCallModel, includeProvider, selfConsume bool) DubboCallModel {
+	if to.Application == "" {
+		to.Application = from.Application
+	}
+	if to.ConsumeServices == nil {
+		to.ConsumeServices = map[string]struct{}{}
+	}
+
+	for svc := range from.ConsumeServices {
+		to.ConsumeServices[svc] = struct{}{}
+	}
+	if selfConsume {
+		for svc := range from.ProvideServices {
+			to.ConsumeServices[svc] = struct{}{}
+		}
+	}
+
+	if includeProvider {
+		if to.ProvideServices == nil {
+			to.ProvideServices = map[string]struct{}{}
+		}
+		for svc := range from.ProvideServices {
+			to.ProvideServices[svc] = struct{}{}
+		}
+	}
+	return to
+}
+
+const (
+	suffixAdd = "\000+"
+	suffixDel = "\000-"
+)
+
+func valueFromDiff(v string) string {
+	if strings.HasSuffix(v, suffixAdd) {
+		return v[:len(v)-len(suffixAdd)]
+	} else if strings.HasSuffix(v, suffixDel) {
+		return v[:len(v)-len(suffixDel)]
+	}
+	return v
+}
+
+func diffDubboCallModels(prev, cur map[string]DubboCallModel) map[string]DubboCallModel {
+	ret := map[string]DubboCallModel{}
+
+	for app, mod := range prev {
+		if _, ok := cur[app]; !ok {
+			ret[app+suffixDel] = mod
+		}
+	}
+
+	for app, mod := range cur {
+		prevMod, ok := prev[app]
+		if !ok {
+			ret[app+suffixAdd] = mod
+			continue
+		}
+
+		diff := DubboCallModel{
+			Application:     app,
+			ConsumeServices: map[string]struct{}{},
+		}
+
+		for svc := range prevMod.ConsumeServices {
+			if _, ok := mod.ConsumeServices[svc]; !ok {
+				diff.ConsumeServices[svc+suffixDel] = struct{}{}
+			}
+		}
+
+		for svc := range mod.ConsumeServices {
+			if _, ok := prevMod.ConsumeServices[svc]; !ok {
+				diff.ConsumeServices[svc] = struct{}{}
+			}
+		}
+
+		if len(diff.ConsumeServices) > 0 {
+			ret[app] = diff
+		}
+	}
+
+	return ret
+}
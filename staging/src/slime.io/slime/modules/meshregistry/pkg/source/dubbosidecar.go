@@ -0,0 +1,564 @@
+package source
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v2"
+	networkingapi "istio.io/api/networking/v1alpha3"
+	"istio.io/libistio/pkg/config/event"
+	"istio.io/libistio/pkg/config/resource"
+	"istio.io/libistio/pkg/config/schema/collections"
+
+	"slime.io/slime/modules/meshregistry/pkg/bootstrap"
+	"slime.io/slime/modules/meshregistry/pkg/monitoring"
+)
+
+const (
+	// DubboSidecarSourceName is the source name of the generated dubbo `Sidecar`s.
+	DubboSidecarSourceName = "dubbo-sidecar"
+
+	DubboCallModelPath        = "/dubboCallModel"
+	SidecarDubboCallModelPath = "/sidecarDubboCallModel"
+
+	nonNsSpecSidecarAnno = "sidecar.config.istio.io/nonNsSpec"
+	wildcardNamespace    = "*"
+)
+
+type SidecarWithMeta struct {
+	Sidecar *networkingapi.Sidecar
+	Meta    resource.Metadata
+}
+
+func (scm SidecarWithMeta) Equals(o SidecarWithMeta) bool {
+	if !stringMapEquals(scm.Meta.Labels, o.Meta.Labels) ||
+		!stringMapEquals(scm.Meta.Annotations, o.Meta.Annotations) {
+		return false
+	}
+
+	return proto.Equal(scm.Sidecar, o.Sidecar)
+}
+
+func stringMapEquals(m1, m2 resource.StringMap) bool {
+	if len(m1) != len(m2) {
+		return false
+	}
+	for k, v1 := range m1 {
+		if v2, ok := m2[k]; !ok || v2 != v1 {
+			return false
+		}
+	}
+	return true
+}
+
+// callModelKey identifies the call models contributed by a ServiceEntry of a source.
+type callModelKey struct {
+	source string
+	name   resource.FullName
+}
+
+// DubboSidecarGenerator generates the dubbo `Sidecar`s from the call models contributed by all the sources, so that
+// the egress of an app is scoped to the services it consumes, no matter which registry they come from.
+// It is an event.Source itself, and the sources contribute to it through the handlers got by `HandlerFor`.
+type DubboSidecarGenerator struct {
+	args *bootstrap.DubboSidecarArgs
+
+	seCallModels         map[callModelKey]map[string]DubboCallModel
+	dubboCallModels      map[string]DubboCallModel // can only be replaced rather than being modified
+	changedApps          map[string]struct{}
+	appSidecarUpdateTime map[string]time.Time
+	sidecarCache         map[resource.FullName]SidecarWithMeta
+
+	// contributors records whether each contributing source is ready
+	contributors  map[string]bool
+	initCh        chan struct{}
+	readyCallback func(string)
+
+	handlers  []event.Handler
+	notifyCh  chan struct{}
+	stop      chan struct{}
+	mut       sync.RWMutex
+	startOnce sync.Once
+}
+
+func NewDubboSidecarGenerator(args *bootstrap.DubboSidecarArgs, readyCallback func(string)) *DubboSidecarGenerator {
+	return &DubboSidecarGenerator{
+		args:                 args,
+		seCallModels:         map[callModelKey]map[string]DubboCallModel{},
+		appSidecarUpdateTime: map[string]time.Time{},
+		contributors:         map[string]bool{},
+		initCh:               make(chan struct{}),
+		readyCallback:        readyCallback,
+		notifyCh:             make(chan struct{}, 1),
+		stop:                 make(chan struct{}),
+	}
+}
+
+// OnConfig updates the args, the changes take effect at the next refresh.
+func (g *DubboSidecarGenerator) OnConfig(args *bootstrap.DubboSidecarArgs) {
+	if args == nil {
+		return
+	}
+	g.mut.Lock()
+	g.args = args
+	g.mut.Unlock()
+}
+
+func (g *DubboSidecarGenerator) getArgs() *bootstrap.DubboSidecarArgs {
+	g.mut.RLock()
+	defer g.mut.RUnlock()
+	return g.args
+}
+
+// HandlerFor returns the handler recording the call models carried by the ServiceEntry events of the source.
+// The source is considered a contributor, and the init refresh waits until all the contributors are ready.
+func (g *DubboSidecarGenerator) HandlerFor(sourceID string) event.Handler {
+	g.mut.Lock()
+	if _, ok := g.contributors[sourceID]; !ok {
+		g.contributors[sourceID] = false
+	}
+	g.mut.Unlock()
+
+	return event.HandlerFromFn(func(e event.Event) {
+		g.handle(sourceID, e)
+	})
+}
+
+// SourceReady marks the contributor ready. It's ok to be called with a non-contributor source.
+func (g *DubboSidecarGenerator) SourceReady(sourceID string) {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+	ready, ok := g.contributors[sourceID]
+	if !ok || ready {
+		return
+	}
+	g.contributors[sourceID] = true
+	for _, ready := range g.contributors {
+		if !ready {
+			return
+		}
+	}
+	if g.initCh != nil {
+		close(g.initCh)
+		g.initCh = nil
+	}
+}
+
+func (g *DubboSidecarGenerator) handle(sourceID string, e event.Event) {
+	if !e.Source.Equal(collections.ServiceEntry) || e.Resource == nil {
+		return
+	}
+	key := callModelKey{source: sourceID, name: e.Resource.Metadata.FullName}
+
+	var callModels map[string]DubboCallModel
+	switch e.Kind {
+	case event.Deleted:
+	case event.Added, event.Updated:
+		att, ok := e.Resource.Attachments[AttachmentDubboCallModel]
+		if !ok {
+			return
+		}
+		if callModels, ok = att.(map[string]DubboCallModel); !ok {
+			return
+		}
+	default:
+		return
+	}
+
+	g.mut.Lock()
+	prev := g.seCallModels[key]
+	if len(callModels) == 0 {
+		delete(g.seCallModels, key)
+	} else {
+		g.seCallModels[key] = callModels
+	}
+	changedApps := CalcChangedApps(prev, callModels)
+	if len(changedApps) > 0 {
+		if g.changedApps == nil {
+			g.changedApps = map[string]struct{}{}
+		}
+		for _, app := range changedApps {
+			g.changedApps[app] = struct{}{}
+		}
+	}
+	g.mut.Unlock()
+
+	if len(changedApps) > 0 {
+		select {
+		case g.notifyCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (g *DubboSidecarGenerator) Dispatch(handler event.Handler) {
+	g.handlers = append(g.handlers, handler)
+}
+
+// Start does the init refresh after all the contributors are ready and then refreshes on call model changes.
+func (g *DubboSidecarGenerator) Start() {
+	g.startOnce.Do(func() {
+		g.mut.RLock()
+		initCh := g.initCh
+		g.mut.RUnlock()
+
+		go func() {
+			if initCh != nil {
+				select {
+				case <-g.stop:
+					return
+				case <-initCh:
+				}
+			}
+			log.Infof("%s contributors init done, begin to do init sidecar refresh", DubboSidecarSourceName)
+			g.Refresh(true)
+			if g.readyCallback != nil {
+				g.readyCallback(DubboSidecarSourceName)
+			}
+			g.refreshTask()
+		}()
+	})
+}
+
+func (g *DubboSidecarGenerator) Stop() {
+	close(g.stop)
+}
+
+func (g *DubboSidecarGenerator) refreshTask() {
+	var (
+		waitCh      <-chan time.Time
+		waitRefresh int
+	)
+
+	for {
+		select {
+		case <-g.stop:
+			return
+		case <-waitCh:
+			waitCh = nil
+			if waitRefresh == 0 {
+				continue
+			}
+		case <-g.notifyCh:
+			waitRefresh++
+			if waitCh != nil {
+				continue
+			}
+		}
+
+		log.Infof("waitRefresh %d, refresh sidecar", waitRefresh)
+		waitRefresh = 0
+		g.Refresh(false)
+		waitCh = time.After(time.Second)
+	}
+}
+
+// Refresh re-merges the call models and dispatches the changed `Sidecar`s. In init case, all the `Sidecar`s are
+// dispatched.
+func (g *DubboSidecarGenerator) Refresh(init bool) {
+	var changedApps map[string]struct{}
+	g.mut.Lock()
+	changedApps, g.changedApps = g.changedApps, nil
+	args := g.args
+	g.mut.Unlock()
+
+	if !init && len(changedApps) == 0 {
+		log.Debugf("refreshSidecar no changed apps")
+		return
+	}
+
+	log.Infof("refreshSidecar for init %v and changed apps %v", init, changedApps)
+
+	g.mut.RLock()
+	seCallModelsCopy := make(map[callModelKey]map[string]DubboCallModel, len(g.seCallModels))
+	for k, v := range g.seCallModels {
+		seCallModelsCopy[k] = v
+	}
+	prevCallModels := g.dubboCallModels
+	g.mut.RUnlock()
+	mergedCallModels := mergeDubboCallModels(seCallModelsCopy, false, args.SelfConsume)
+
+	diff := diffDubboCallModels(prevCallModels, mergedCallModels)
+	if len(diff) == 0 {
+		log.Debugf("%d app changed, but merged call models no change(size %d)",
+			len(changedApps), len(mergedCallModels))
+		return
+	}
+	v, err := json.MarshalIndent(diff, "", "  ")
+	log.Infof("dubbo call model diff: %s, json marshal err %v", string(v), err)
+
+	filtered := g.filterDubboCallModelDiff(diff, time.Duration(args.TrimDubboRemoveDepInterval))
+	if len(diff) == 0 {
+		log.Infof("%d apps changed, but filtered merged call models no change(size %d)",
+			len(changedApps), len(mergedCallModels))
+		return
+	} else if filtered {
+		v, err := json.MarshalIndent(diff, "", "  ")
+		log.Infof("filtered dubbo call model diff: %s, json marshal err %v", string(v), err)
+	}
+
+	protocol, _ := ProtocolName(args.SvcProtocol, args.GenericProtocol)
+	diffSidecars, deletedSidecars := convertDubboCallModelConfigToSidecar(args.ResourceNs, mergedCallModels, diff, args.DubboWorkloadAppLabel, protocol) //nolint: lll
+
+	sidecarMap := make(map[resource.FullName]SidecarWithMeta, len(diffSidecars))
+	for _, sc := range diffSidecars {
+		sidecarMap[sc.Meta.FullName] = sc
+	}
+
+	var prevSidecarCache map[resource.FullName]SidecarWithMeta
+
+	g.mut.Lock()
+	g.recordAppSidecarUpdateTime(diff)
+	for k, v := range g.sidecarCache {
+		if !deletedSidecars[k] {
+			_, ok := sidecarMap[k]
+			if !ok {
+				sidecarMap[k] = v
+			}
+		}
+	}
+	prevSidecarCache, g.sidecarCache = g.sidecarCache, sidecarMap
+	g.dubboCallModels = mergedCallModels
+	g.mut.Unlock()
+
+	var (
+		events                  []event.Event
+		added, updated, deleted int
+	)
+	for fn, cur := range sidecarMap {
+		prev, ok := prevSidecarCache[fn]
+		if !ok {
+			events = append(events, buildSidecarEvent(event.Added, cur.Sidecar, cur.Meta))
+			monitoring.RecordSidecarCreation(DubboSidecarSourceName)
+			added++
+		} else if !prev.Equals(cur) {
+			events = append(events, buildSidecarEvent(event.Updated, cur.Sidecar, cur.Meta))
+			monitoring.RecordSidecarUpdate(DubboSidecarSourceName)
+			updated++
+		}
+	}
+
+	if !(added == 0 && len(prevSidecarCache) == len(sidecarMap)) {
+		for fn, prev := range prevSidecarCache {
+			if _, ok := sidecarMap[fn]; !ok {
+				events = append(events, buildSidecarEvent(event.Deleted, prev.Sidecar, prev.Meta))
+				monitoring.RecordSidecarDeletion(DubboSidecarSourceName)
+				deleted++
+			}
+		}
+	}
+
+	if len(events) == 0 {
+		log.Warnf("%d apps changed, merged call models changed(size %d -> %d), "+
+			"but no sidecars changed",
+			len(changedApps), len(prevCallModels), len(mergedCallModels))
+		return
+	}
+	log.Infof("%d apps changed, merged call models changed(size %d -> %d), "+
+		"sidecars changed %d, add %d update %d delete %d",
+		len(changedApps), len(prevCallModels), len(mergedCallModels), len(events),
+		added, updated, deleted)
+
+	for _, ev := range events {
+		for _, h := range g.handlers {
+			h.Handle(ev)
+		}
+	}
+}
+
+func (g *DubboSidecarGenerator) filterDubboCallModelDiff(diff map[string]DubboCallModel, trimInterval time.Duration) bool {
+	g.mut.RLock()
+	defer g.mut.RUnlock()
+
+	var filtered bool
+
+	for app, m := range diff {
+		updateTime := g.appSidecarUpdateTime[valueFromDiff(app)]
+		// trim-del means will not flush deletions
+		trimDel := time.Since(updateTime) < trimInterval
+
+		if strings.HasSuffix(app, suffixDel) && trimDel {
+			delete(diff, app)
+			filtered = true
+		} else if !strings.HasSuffix(app, suffixAdd) {
+			for svc := range m.ConsumeServices {
+				if strings.HasSuffix(svc, suffixDel) && trimDel {
+					delete(m.ConsumeServices, svc)
+					filtered = true
+				}
+			}
+
+			if len(m.ConsumeServices) == 0 {
+				delete(diff, app)
+				filtered = true
+			}
+		}
+	}
+
+	return filtered
+}
+
+func (g *DubboSidecarGenerator) recordAppSidecarUpdateTime(diff map[string]DubboCallModel) {
+	// caller should hold the lock
+	now := time.Now()
+	for app := range diff {
+		g.appSidecarUpdateTime[valueFromDiff(app)] = now
+	}
+}
+
+func convertDubboCallModelConfigToSidecar(
+	resourceNs string,
+	callModel map[string]DubboCallModel,
+	diff map[string]DubboCallModel,
+	dubboWorkloadAppLabel string,
+	protocol string,
+) ([]SidecarWithMeta, map[resource.FullName]bool) {
+	var (
+		ret             []SidecarWithMeta
+		deletedSidecars = map[resource.FullName]bool{}
+	)
+
+	// the deleted apps are no longer in the call model
+	for app := range diff {
+		if strings.HasSuffix(app, suffixDel) {
+			name := fmt.Sprintf("%s.dubbo.generated", valueFromDiff(app))
+			deletedSidecars[resource.FullName{
+				Namespace: resource.Namespace(resourceNs),
+				Name:      resource.LocalName(name),
+			}] = true
+		}
+	}
+
+	now := time.Now()
+	for app, m := range callModel {
+		name := fmt.Sprintf("%s.dubbo.generated", m.Application)
+		fullName := resource.FullName{Namespace: resource.Namespace(resourceNs), Name: resource.LocalName(name)}
+		if diff != nil {
+			if _, ok := diff[app]; !ok {
+				if _, ok = diff[app+suffixAdd]; !ok {
+					// not changed call model/sidecar
+					continue
+				}
+			}
+		}
+
+		hosts := make([]string, 0, len(m.ConsumeServices))
+		for svc := range m.ConsumeServices {
+			hosts = append(hosts, wildcardNamespace+"/"+svc)
+		}
+
+		sort.Strings(hosts)
+
+		scm := SidecarWithMeta{
+			Meta: resource.Metadata{
+				FullName:   fullName,
+				CreateTime: now,
+				Version:    resource.Version(now.String()),
+				Annotations: map[string]string{
+					nonNsSpecSidecarAnno: "true",
+				},
+				Labels: map[string]string{},
+			},
+			Sidecar: &networkingapi.Sidecar{
+				WorkloadSelector: &networkingapi.WorkloadSelector{
+					Labels: map[string]string{dubboWorkloadAppLabel: m.Application},
+				},
+				Ingress: nil,
+				Egress: []*networkingapi.IstioEgressListener{
+					{
+						Hosts: hosts,
+						Port: &networkingapi.Port{
+							Protocol: protocol,
+						},
+					},
+				},
+				OutboundTrafficPolicy: nil,
+			},
+		}
+
+		FillRevision(&scm.Meta)
+
+		ret = append(ret, scm)
+	}
+
+	return ret, deletedSidecars
+}
+
+// buildSidecarEvent assembled the incoming data into an event. Event handle should not modify the data.
+func buildSidecarEvent(kind event.Kind, item *networkingapi.Sidecar, meta resource.Metadata) event.Event {
+	meta = meta.Clone()
+	FillRevision(&meta)
+	return event.Event{
+		Kind:   kind,
+		Source: collections.Sidecar,
+		Resource: &resource.Instance{
+			Metadata: meta,
+			Message:  item,
+		},
+	}
+}
+
+// DebugHandlers returns the debug handlers of the call models.
+func (g *DubboSidecarGenerator) DebugHandlers() map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		DubboCallModelPath:        g.HandleDubboCallModel,
+		SidecarDubboCallModelPath: g.HandleSidecarDubboCallModel,
+	}
+}
+
+// HandleDubboCallModel dumps the call models merged from all the contributors, with the provided services.
+func (g *DubboSidecarGenerator) HandleDubboCallModel(w http.ResponseWriter, request *http.Request) {
+	app := request.URL.Query().Get("app")
+
+	g.mut.RLock()
+	seCallModelsCopy := make(map[callModelKey]map[string]DubboCallModel, len(g.seCallModels))
+	for k, v := range g.seCallModels {
+		seCallModelsCopy[k] = v
+	}
+	selfConsume := g.args.SelfConsume
+	g.mut.RUnlock()
+	mergedCallModels := mergeDubboCallModels(seCallModelsCopy, true, selfConsume)
+
+	if app != "" {
+		mergedCallModels = map[string]DubboCallModel{
+			app: mergedCallModels[app],
+		}
+	}
+
+	writeDubboCallModels(w, mergedCallModels)
+}
+
+// HandleSidecarDubboCallModel dumps the call models which the current `Sidecar`s are generated from.
+func (g *DubboSidecarGenerator) HandleSidecarDubboCallModel(w http.ResponseWriter, request *http.Request) {
+	app := request.URL.Query().Get("app")
+
+	g.mut.RLock()
+	callModels := g.dubboCallModels
+	g.mut.RUnlock()
+
+	if callModels != nil && app != "" {
+		callModels = map[string]DubboCallModel{
+			app: callModels[app],
+		}
+	}
+
+	writeDubboCallModels(w, callModels)
+}
+
+func writeDubboCallModels(w http.ResponseWriter, callModels map[string]DubboCallModel) {
+	bs, err := yaml.Marshal(callModels)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "unable to marshal push dubbo call mode config: %v, %v", err, callModels)
+		return
+	}
+	w.Header().Add("Content-Type", "text/yaml")
+
+	_, _ = w.Write(bs)
+}
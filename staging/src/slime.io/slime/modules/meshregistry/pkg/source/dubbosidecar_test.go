@@ -0,0 +1,113 @@
+package source
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	networkingapi "istio.io/api/networking/v1alpha3"
+	"istio.io/libistio/pkg/config/event"
+	"istio.io/libistio/pkg/config/resource"
+
+	"slime.io/slime/modules/meshregistry/pkg/bootstrap"
+	"slime.io/slime/modules/meshregistry/pkg/util"
+)
+
+func TestDubboSidecarGenerator(t *testing.T) {
+	gen := NewDubboSidecarGenerator(&bootstrap.DubboSidecarArgs{
+		ResourceNs:                 "dubbo",
+		DubboWorkloadAppLabel:      "app",
+		SvcProtocol:                "DUBBO",
+		TrimDubboRemoveDepInterval: util.Duration(time.Hour),
+	}, nil)
+
+	sidecars := map[resource.FullName]*networkingapi.Sidecar{}
+	gen.Dispatch(event.HandlerFromFn(func(e event.Event) {
+		switch e.Kind {
+		case event.Added, event.Updated:
+			sidecars[e.Resource.Metadata.FullName] = e.Resource.Message.(*networkingapi.Sidecar)
+		case event.Deleted:
+			delete(sidecars, e.Resource.Metadata.FullName)
+		}
+	}))
+
+	zkHandler := gen.HandlerFor("zookeeper")
+	nacosHandler := gen.HandlerFor("nacos")
+
+	seEvent := func(kind event.Kind, ns, name string, callModels map[string]DubboCallModel) event.Event {
+		ev := BuildServiceEntryEvent(kind, &networkingapi.ServiceEntry{Hosts: []string{name}}, resource.Metadata{
+			FullName: resource.FullName{Namespace: resource.Namespace(ns), Name: resource.LocalName(name)},
+		})
+		if callModels != nil {
+			ev = AttachDubboCallModels(ev, callModels)
+		}
+		return ev
+	}
+	consume := func(app string, svcs ...string) DubboCallModel {
+		m := DubboCallModel{Application: app, ConsumeServices: map[string]struct{}{}}
+		for _, svc := range svcs {
+			m.ConsumeServices[svc] = struct{}{}
+		}
+		return m
+	}
+	egressHosts := func(app string) []string {
+		sc := sidecars[resource.FullName{Namespace: "dubbo", Name: resource.LocalName(app + ".dubbo.generated")}]
+		if sc == nil {
+			return nil
+		}
+		return sc.Egress[0].Hosts
+	}
+
+	// the call edges of the same app contributed by different sources are merged
+	zkHandler.Handle(seEvent(event.Added, "dubbo", "svc-a", map[string]DubboCallModel{
+		"app-x": consume("app-x", "svc-a"),
+	}))
+	nacosHandler.Handle(seEvent(event.Added, "nacos", "svc-b", map[string]DubboCallModel{
+		"app-x": consume("app-x", "svc-b"),
+	}))
+	// the events without call models are ignored
+	nacosHandler.Handle(seEvent(event.Updated, "nacos", "svc-b", nil))
+
+	initCh := gen.initCh
+	select {
+	case <-initCh:
+		t.Fatal("init should not be done before all the contributors are ready")
+	default:
+	}
+	gen.SourceReady("zookeeper")
+	gen.SourceReady("k8s") // not a contributor
+	gen.SourceReady("nacos")
+	select {
+	case <-initCh:
+	default:
+		t.Fatal("init should be done after all the contributors are ready")
+	}
+
+	gen.Refresh(true)
+	assert.Equal(t, []string{"*/svc-a", "*/svc-b"}, egressHosts("app-x"))
+
+	// the ServiceEntries of a source with the same name as another source's do not override each other
+	nacosHandler.Handle(seEvent(event.Updated, "dubbo", "svc-a", map[string]DubboCallModel{
+		"app-y": consume("app-y", "svc-a"),
+	}))
+	gen.Refresh(false)
+	assert.Equal(t, []string{"*/svc-a", "*/svc-b"}, egressHosts("app-x"))
+	assert.Equal(t, []string{"*/svc-a"}, egressHosts("app-y"))
+
+	// the removed deps are trimmed within the interval
+	nacosHandler.Handle(seEvent(event.Deleted, "nacos", "svc-b", nil))
+	gen.Refresh(false)
+	assert.Equal(t, []string{"*/svc-a", "*/svc-b"}, egressHosts("app-x"))
+
+	// the sidecar is regenerated from the merged call model on the next change of the app
+	gen.OnConfig(&bootstrap.DubboSidecarArgs{
+		ResourceNs:            "dubbo",
+		DubboWorkloadAppLabel: "app",
+		SvcProtocol:           "DUBBO",
+	})
+	nacosHandler.Handle(seEvent(event.Updated, "nacos", "svc-c", map[string]DubboCallModel{
+		"app-x": consume("app-x", "svc-c"),
+	}))
+	gen.Refresh(false)
+	assert.Equal(t, []string{"*/svc-a", "*/svc-c"}, egressHosts("app-x"))
+}
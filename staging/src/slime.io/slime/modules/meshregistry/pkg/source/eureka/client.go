@@ -31,7 +31,14 @@ type instance struct {
 	SecurePort port   `json:"securePort"`
 	App        string `json:"app"`
 	// TODO: read dataCenterInfo for AZ support
-	Metadata eurekaMetadata `json:"metadata,omitempty"`
+	Metadata  eurekaMetadata `json:"metadata,omitempty"`
+	LeaseInfo *leaseInfo     `json:"leaseInfo,omitempty"`
+}
+
+type leaseInfo struct {
+	// timestamps in milliseconds
+	RegistrationTimestamp int64 `json:"registrationTimestamp,omitempty"`
+	ServiceUpTimestamp    int64 `json:"serviceUpTimestamp,omitempty"`
 }
 
 type port struct {
@@ -101,6 +108,10 @@ type client struct {
 	registryID string
 	urls       []string
 	index      int
+
+	// basic auth
+	username string
+	password string
 }
 
 func (c *client) RegistryInfo() string {
@@ -119,6 +130,8 @@ func NewClient(server bootstrap.EurekaServer) *client {
 		registryID: server.RegistryID,
 		urls:       server.Address,
 		index:      0,
+		username:   server.Username,
+		password:   server.Password,
 	}
 }
 
@@ -148,26 +161,21 @@ func (c *client) Applications() ([]*application, error) {
 		return nil, err
 	}
 	req.Header.Set("Accept", "application/json")
-
-	resp, err := c.client.Do(req)
-	monitoring.RecordSourceClientRequest(SourceName, err == nil)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code from EurekaSource server: %v", resp.Status)
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
 	}
 
-	data, err := io.ReadAll(resp.Body)
+	apps, err := c.doApplications(req)
 	if err != nil {
+		source.RecordRegistrySync(SourceName, c.registryID, 0, 0, err)
 		return nil, err
 	}
 
-	var apps getApplications
-	if err = json.Unmarshal(data, &apps); err != nil {
-		return nil, err
+	instances := 0
+	for _, app := range apps.Applications.Applications {
+		instances += len(app.Instances)
 	}
+	source.RecordRegistrySync(SourceName, c.registryID, len(apps.Applications.Applications), instances, nil)
 
 	if c.registryID != "" {
 		for _, app := range apps.Applications.Applications {
@@ -182,3 +190,26 @@ func (c *client) Applications() ([]*application, error) {
 
 	return apps.Applications.Applications, nil
 }
+
+func (c *client) doApplications(req *http.Request) (*getApplications, error) {
+	resp, err := c.client.Do(req)
+	monitoring.RecordSourceClientRequest(SourceName, err == nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code from EurekaSource server: %v", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var apps getApplications
+	if err = json.Unmarshal(data, &apps); err != nil {
+		return nil, err
+	}
+	return &apps, nil
+}
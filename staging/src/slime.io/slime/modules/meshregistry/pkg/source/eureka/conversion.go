@@ -4,7 +4,9 @@ import (
 	"math"
 	"net"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	networkingapi "istio.io/api/networking/v1alpha3"
 
@@ -26,8 +28,18 @@ type convertOptions struct {
 	protocol string
 	// the protocol name used for Port.Name
 	protocolName string
+
+	healthMapper *source.EndpointHealthMapper
 }
 
+const (
+	statusUp           = "UP"
+	statusOutOfService = "OUT_OF_SERVICE"
+
+	// metaKeyWeight is the metadata key of the instance weight, which is not a part of the eureka spec.
+	metaKeyWeight = "weight"
+)
+
 func ConvertServiceEntryMap(apps []*application, opts *convertOptions) (map[string]*networkingapi.ServiceEntry, error) {
 	seMap := make(map[string]*networkingapi.ServiceEntry, 0)
 	if len(apps) == 0 {
@@ -153,7 +165,12 @@ func convertEndpointsWithNs(instances []*instance, projectCode string, opts *con
 	})
 
 	for _, ins := range instances {
-		if !strings.EqualFold(ins.Status, "UP") {
+		health := instanceHealth(ins)
+		if opts.healthMapper == nil {
+			if !strings.EqualFold(ins.Status, statusUp) {
+				continue
+			}
+		} else if !opts.healthMapper.Include(health) {
 			continue
 		}
 		if ins.Port.Port > math.MaxUint16 {
@@ -211,6 +228,9 @@ func convertEndpointsWithNs(instances []*instance, projectCode string, opts *con
 			Ports:   map[string]uint32{svcPortName: uint32(ins.Port.Port)},
 			Labels:  ins.Metadata,
 		}
+		if opts.healthMapper != nil {
+			opts.healthMapper.Apply(ep, health)
+		}
 
 		util.FillWorkloadEntryLocality(ep)
 
@@ -219,3 +239,29 @@ func convertEndpointsWithNs(instances []*instance, projectCode string, opts *con
 
 	return endpointsMap, svcPortsMap, useDNSMap
 }
+
+func instanceHealth(ins *instance) source.InstanceHealth {
+	h := source.NewInstanceHealth()
+	switch strings.ToUpper(ins.Status) {
+	case statusUp:
+	case statusOutOfService:
+		h.Status = source.InstanceDisabled
+	default: // DOWN, STARTING, UNKNOWN
+		h.Status = source.InstanceUnhealthy
+	}
+	if v, err := strconv.ParseInt(ins.Metadata[metaKeyWeight], 10, 64); err == nil && v >= 0 {
+		h.Weight = v
+	} else {
+		h.Weight = source.DefaultInstanceWeight
+	}
+	if ins.LeaseInfo != nil {
+		ts := ins.LeaseInfo.ServiceUpTimestamp
+		if ts <= 0 {
+			ts = ins.LeaseInfo.RegistrationTimestamp
+		}
+		if ts > 0 {
+			h.RegisterTime = time.UnixMilli(ts)
+		}
+	}
+	return h
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"reflect"
 	"strings"
 	"sync"
 	"time"
@@ -56,7 +57,7 @@ type Source struct {
 func New(
 	moduleArgs *bootstrap.RegistryArgs,
 	readyCallback func(string),
-	_ func(func(*bootstrap.RegistryArgs)),
+	addOnReArgs func(func(*bootstrap.RegistryArgs)),
 ) (event.Source, map[string]http.HandlerFunc, bool, bool, error) {
 	args := moduleArgs.EurekaSource
 	if !args.Enabled {
@@ -84,11 +85,7 @@ func New(
 		seMergePortMocker: svcMocker,
 	}
 
-	serviers := args.Servers
-	if len(serviers) == 0 {
-		serviers = []bootstrap.EurekaServer{args.EurekaServer}
-	}
-	src.client = NewClients(serviers)
+	src.client = newSourceClient(args)
 
 	src.initWg.Add(1) // service entry init-sync
 	if src.seMergePortMocker != nil {
@@ -102,6 +99,12 @@ func New(
 		src.initWg.Add(1)
 	}
 
+	if addOnReArgs != nil {
+		addOnReArgs(func(reArgs *bootstrap.RegistryArgs) {
+			src.onConfig(reArgs.EurekaSource)
+		})
+	}
+
 	debugHandler := map[string]http.HandlerFunc{
 		HttpPath: src.handleHttp,
 	}
@@ -109,6 +112,34 @@ func New(
 	return src, debugHandler, args.LabelPatch, false, nil
 }
 
+func newSourceClient(args *bootstrap.EurekaSourceArgs) Client {
+	servers := args.Servers
+	if len(servers) == 0 {
+		servers = []bootstrap.EurekaServer{args.EurekaServer}
+	}
+	return NewClients(servers)
+}
+
+// onConfig only supports reloading the servers, which may be changed by the RegistrySource connections.
+func (s *Source) onConfig(args *bootstrap.EurekaSourceArgs) {
+	if args == nil {
+		return
+	}
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if reflect.DeepEqual(s.args.EurekaServer, args.EurekaServer) && reflect.DeepEqual(s.args.Servers, args.Servers) {
+		return
+	}
+	s.args.EurekaServer, s.args.Servers = args.EurekaServer, args.Servers
+	s.client = newSourceClient(args)
+}
+
+func (s *Source) getClient() Client {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	return s.client
+}
+
 func (s *Source) cacheShallowCopy() map[string]*networkingapi.ServiceEntry {
 	s.mut.RLock()
 	defer s.mut.RUnlock()
@@ -130,7 +161,7 @@ func (s *Source) handleHttp(w http.ResponseWriter, req *http.Request) {
 }
 
 func (s *Source) dumpClients(w http.ResponseWriter, _ *http.Request) {
-	_, _ = w.Write([]byte(s.client.RegistryInfo()))
+	_, _ = w.Write([]byte(s.getClient().RegistryInfo()))
 }
 
 func (s *Source) cacheJson(w http.ResponseWriter, _ *http.Request) {
@@ -165,7 +196,7 @@ func (s *Source) refresh() {
 }
 
 func (s *Source) updateServiceInfo() error {
-	apps, err := s.client.Applications()
+	apps, err := s.getClient().Applications()
 	if err != nil {
 		return fmt.Errorf("get eureka app failed: %v", err)
 	}
@@ -179,6 +210,7 @@ func (s *Source) updateServiceInfo() error {
 		svcPort:               s.args.SvcPort,
 		defaultSvcNs:          s.args.DefaultServiceNs,
 		appSuffix:             s.args.AppSuffix,
+		healthMapper:          source.NewEndpointHealthMapper(s.args.EndpointHealth),
 	}
 	opts.protocol, opts.protocolName = source.ProtocolName(s.args.SvcProtocol, s.args.GenericProtocol)
 	newServiceEntryMap, err := ConvertServiceEntryMap(apps, opts)
@@ -0,0 +1,151 @@
+package source
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	networkingapi "istio.io/api/networking/v1alpha3"
+
+	"slime.io/slime/modules/meshregistry/pkg/bootstrap"
+)
+
+// InstanceStatus is the registry-agnostic status of an instance.
+type InstanceStatus string
+
+const (
+	InstanceHealthy   InstanceStatus = "healthy"
+	InstanceUnhealthy InstanceStatus = "unhealthy"
+	InstanceDisabled  InstanceStatus = "disabled"
+)
+
+const (
+	// DefaultInstanceWeight is the normalized default weight of the instances of all the registries.
+	DefaultInstanceWeight = 100
+
+	// the dubbo url params(also metadata in nacos) about the warm-up, both in milliseconds
+	DubboParamTimestamp = "timestamp"
+	DubboParamWarmup    = "warmup"
+
+	// the warming up weights are re-evaluated in about 10 steps, and at most once per 5s
+	warmupRefreshSteps       = 10
+	minWarmupRefreshInterval = 5 * time.Second
+)
+
+// InstanceHealth is the health info of an instance extracted from the registry.
+type InstanceHealth struct {
+	Status InstanceStatus
+	// Weight is the normalized weight, negative means not reported by the registry.
+	Weight int64
+	// RegisterTime is the time the instance is registered or becomes up, zero means unknown.
+	RegisterTime time.Time
+	// Warmup overrides the warm-up duration configured in args if positive.
+	Warmup time.Duration
+}
+
+// NewInstanceHealth returns the health of a healthy instance with an unknown weight.
+func NewInstanceHealth() InstanceHealth {
+	return InstanceHealth{Status: InstanceHealthy, Weight: -1}
+}
+
+// FillWarmupFromMeta fills the register time and the warm-up duration from the dubbo params in the metadata.
+func (h *InstanceHealth) FillWarmupFromMeta(meta map[string]string) {
+	if v, err := strconv.ParseInt(meta[DubboParamTimestamp], 10, 64); err == nil && v > 0 {
+		h.RegisterTime = time.UnixMilli(v)
+	}
+	if v, err := strconv.ParseInt(meta[DubboParamWarmup], 10, 64); err == nil && v > 0 {
+		h.Warmup = time.Duration(v) * time.Millisecond
+	}
+}
+
+// EndpointHealthMapper maps the instance health to the endpoint according to `bootstrap.EndpointHealthArgs`.
+// A nil mapper means the args is not configured and the sources should keep their legacy behavior.
+type EndpointHealthMapper struct {
+	args *bootstrap.EndpointHealthArgs
+	now  func() time.Time
+	// nextRefresh is the earliest time the applied weights should be re-evaluated
+	nextRefresh time.Time
+}
+
+func NewEndpointHealthMapper(args *bootstrap.EndpointHealthArgs) *EndpointHealthMapper {
+	if args == nil {
+		return nil
+	}
+	return &EndpointHealthMapper{args: args, now: time.Now}
+}
+
+func (m *EndpointHealthMapper) status(h InstanceHealth) InstanceStatus {
+	if h.Status == InstanceHealthy && h.Weight == 0 {
+		return InstanceDisabled
+	}
+	return h.Status
+}
+
+// Include returns whether the instance should be converted to an endpoint.
+func (m *EndpointHealthMapper) Include(h InstanceHealth) bool {
+	switch m.status(h) {
+	case InstanceUnhealthy:
+		return m.args.KeepUnhealthy
+	case InstanceDisabled:
+		return m.args.KeepDisabled
+	default:
+		return true
+	}
+}
+
+// Apply sets the status label and the weight of the endpoint.
+func (m *EndpointHealthMapper) Apply(ep *networkingapi.WorkloadEntry, h InstanceHealth) {
+	if m.args.StatusLabel != "" {
+		if ep.Labels == nil {
+			ep.Labels = map[string]string{}
+		}
+		ep.Labels[m.args.StatusLabel] = string(m.status(h))
+	}
+
+	if !m.args.EnableWeight || h.Weight < 0 {
+		return
+	}
+	weight := h.Weight
+	if weight > math.MaxUint32 {
+		weight = math.MaxUint32
+	}
+
+	warmup := time.Duration(m.args.Warmup)
+	if h.Warmup > 0 {
+		warmup = h.Warmup
+	}
+	if warmup > 0 && !h.RegisterTime.IsZero() {
+		// same as dubbo: weight * uptime / warmup, at least 1
+		if uptime := m.now().Sub(h.RegisterTime); uptime > 0 && uptime < warmup {
+			weight = int64(float64(weight) * float64(uptime) / float64(warmup))
+			if weight < 1 {
+				weight = 1
+			}
+			m.recordRefresh(h.RegisterTime.Add(warmup), warmup)
+		}
+	}
+	ep.Weight = uint32(weight)
+}
+
+func (m *EndpointHealthMapper) recordRefresh(warmupDone time.Time, warmup time.Duration) {
+	step := warmup / warmupRefreshSteps
+	if step < minWarmupRefreshInterval {
+		step = minWarmupRefreshInterval
+	}
+	next := m.now().Add(step)
+	if warmupDone.Before(next) {
+		next = warmupDone
+	}
+	if m.nextRefresh.IsZero() || next.Before(m.nextRefresh) {
+		m.nextRefresh = next
+	}
+}
+
+// NextRefresh returns the earliest time the weights applied should be re-evaluated as some endpoints are still
+// warming up, zero if none. The sources not re-converting the instances periodically should refresh by then.
+func (m *EndpointHealthMapper) NextRefresh() time.Time {
+	if m == nil {
+		return time.Time{}
+	}
+	return m.nextRefresh
+}
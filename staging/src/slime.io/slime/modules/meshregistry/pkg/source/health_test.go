@@ -0,0 +1,178 @@
+package source
+
+import (
+	"testing"
+	"time"
+
+	networkingapi "istio.io/api/networking/v1alpha3"
+
+	"slime.io/slime/modules/meshregistry/pkg/bootstrap"
+	"slime.io/slime/modules/meshregistry/pkg/util"
+)
+
+func TestEndpointHealthMapper(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name        string
+		args        *bootstrap.EndpointHealthArgs
+		health      InstanceHealth
+		wantInclude bool
+		wantWeight  uint32
+		wantLabel   string
+	}{
+		{
+			name:        "unhealthy excluded",
+			args:        &bootstrap.EndpointHealthArgs{},
+			health:      InstanceHealth{Status: InstanceUnhealthy, Weight: -1},
+			wantInclude: false,
+		},
+		{
+			name:        "unhealthy kept",
+			args:        &bootstrap.EndpointHealthArgs{KeepUnhealthy: true, StatusLabel: "status"},
+			health:      InstanceHealth{Status: InstanceUnhealthy, Weight: -1},
+			wantInclude: true,
+			wantLabel:   "unhealthy",
+		},
+		{
+			name:        "zero weight is disabled",
+			args:        &bootstrap.EndpointHealthArgs{KeepUnhealthy: true, StatusLabel: "status"},
+			health:      InstanceHealth{Status: InstanceHealthy, Weight: 0},
+			wantInclude: false,
+		},
+		{
+			name:        "disabled kept",
+			args:        &bootstrap.EndpointHealthArgs{KeepDisabled: true, StatusLabel: "status"},
+			health:      InstanceHealth{Status: InstanceDisabled, Weight: 100},
+			wantInclude: true,
+			wantLabel:   "disabled",
+		},
+		{
+			name:        "weight not enabled",
+			args:        &bootstrap.EndpointHealthArgs{},
+			health:      InstanceHealth{Status: InstanceHealthy, Weight: 200},
+			wantInclude: true,
+		},
+		{
+			name:        "weight",
+			args:        &bootstrap.EndpointHealthArgs{EnableWeight: true, StatusLabel: "status"},
+			health:      InstanceHealth{Status: InstanceHealthy, Weight: 200},
+			wantInclude: true,
+			wantWeight:  200,
+			wantLabel:   "healthy",
+		},
+		{
+			name: "warming up",
+			args: &bootstrap.EndpointHealthArgs{EnableWeight: true, Warmup: util.Duration(10 * time.Minute)},
+			health: InstanceHealth{
+				Status: InstanceHealthy, Weight: 100, RegisterTime: now.Add(-time.Minute),
+			},
+			wantInclude: true,
+			wantWeight:  10,
+		},
+		{
+			name: "warmup overridden by instance",
+			args: &bootstrap.EndpointHealthArgs{EnableWeight: true, Warmup: util.Duration(10 * time.Minute)},
+			health: InstanceHealth{
+				Status: InstanceHealthy, Weight: 100, RegisterTime: now.Add(-time.Minute), Warmup: 2 * time.Minute,
+			},
+			wantInclude: true,
+			wantWeight:  50,
+		},
+		{
+			name: "warmup at least 1",
+			args: &bootstrap.EndpointHealthArgs{EnableWeight: true, Warmup: util.Duration(10 * time.Minute)},
+			health: InstanceHealth{
+				Status: InstanceHealthy, Weight: 100, RegisterTime: now.Add(-time.Second),
+			},
+			wantInclude: true,
+			wantWeight:  1,
+		},
+		{
+			name: "register time in the future",
+			args: &bootstrap.EndpointHealthArgs{EnableWeight: true, Warmup: util.Duration(10 * time.Minute)},
+			health: InstanceHealth{
+				Status: InstanceHealthy, Weight: 100, RegisterTime: now.Add(time.Second),
+			},
+			wantInclude: true,
+			wantWeight:  100,
+		},
+		{
+			name: "warmup done",
+			args: &bootstrap.EndpointHealthArgs{EnableWeight: true, Warmup: util.Duration(10 * time.Minute)},
+			health: InstanceHealth{
+				Status: InstanceHealthy, Weight: 100, RegisterTime: now.Add(-time.Hour),
+			},
+			wantInclude: true,
+			wantWeight:  100,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewEndpointHealthMapper(tt.args)
+			m.now = func() time.Time { return now }
+			if got := m.Include(tt.health); got != tt.wantInclude {
+				t.Fatalf("Include() = %v, want %v", got, tt.wantInclude)
+			}
+			if !tt.wantInclude {
+				return
+			}
+			ep := &networkingapi.WorkloadEntry{}
+			m.Apply(ep, tt.health)
+			if ep.Weight != tt.wantWeight {
+				t.Errorf("Apply() weight = %v, want %v", ep.Weight, tt.wantWeight)
+			}
+			if got := ep.Labels[tt.args.StatusLabel]; tt.args.StatusLabel != "" && got != tt.wantLabel {
+				t.Errorf("Apply() status label = %v, want %v", got, tt.wantLabel)
+			}
+		})
+	}
+}
+
+func TestEndpointHealthMapperNextRefresh(t *testing.T) {
+	now := time.Now()
+	m := NewEndpointHealthMapper(&bootstrap.EndpointHealthArgs{
+		EnableWeight: true, Warmup: util.Duration(10 * time.Minute),
+	})
+	m.now = func() time.Time { return now }
+
+	m.Apply(&networkingapi.WorkloadEntry{}, InstanceHealth{
+		Status: InstanceHealthy, Weight: 100, RegisterTime: now.Add(-time.Hour),
+	})
+	if got := m.NextRefresh(); !got.IsZero() {
+		t.Errorf("NextRefresh() = %v, want zero without warming up endpoints", got)
+	}
+
+	m.Apply(&networkingapi.WorkloadEntry{}, InstanceHealth{
+		Status: InstanceHealthy, Weight: 100, RegisterTime: now.Add(-time.Minute),
+	})
+	if got, want := m.NextRefresh(), now.Add(time.Minute); !got.Equal(want) {
+		t.Errorf("NextRefresh() = %v, want %v", got, want)
+	}
+
+	// almost done, refresh when the warm-up is done
+	m.Apply(&networkingapi.WorkloadEntry{}, InstanceHealth{
+		Status: InstanceHealthy, Weight: 100, RegisterTime: now.Add(-time.Minute), Warmup: time.Minute + time.Second,
+	})
+	if got, want := m.NextRefresh(), now.Add(time.Second); !got.Equal(want) {
+		t.Errorf("NextRefresh() = %v, want %v", got, want)
+	}
+
+	var nilMapper *EndpointHealthMapper
+	if got := nilMapper.NextRefresh(); !got.IsZero() {
+		t.Errorf("NextRefresh() of nil mapper = %v, want zero", got)
+	}
+}
+
+func TestInstanceHealthFillWarmupFromMeta(t *testing.T) {
+	h := NewInstanceHealth()
+	h.FillWarmupFromMeta(map[string]string{
+		DubboParamTimestamp: "1700000000000",
+		DubboParamWarmup:    "60000",
+	})
+	if !h.RegisterTime.Equal(time.UnixMilli(1700000000000)) {
+		t.Errorf("RegisterTime = %v", h.RegisterTime)
+	}
+	if h.Warmup != time.Minute {
+		t.Errorf("Warmup = %v", h.Warmup)
+	}
+}
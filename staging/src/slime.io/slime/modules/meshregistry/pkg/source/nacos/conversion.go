@@ -1,6 +1,7 @@
 package nacos
 
 import (
+	"math"
 	"net"
 	"sort"
 	"strings"
@@ -26,10 +27,18 @@ type convertOptions struct {
 	// the protocol name used for Port.Name
 	protocolName string
 
-	filter      func(*instance) bool
-	hostAliases map[string][]string
+	filter       func(*instance) bool
+	hostAliases  map[string][]string
+	healthMapper *source.EndpointHealthMapper
+
+	// callModels, if not nil, collects the dubbo call models derived from each converted ServiceEntry.
+	callModels map[string]map[string]source.DubboCallModel
 }
 
+// dubboAppMetaKey is the instance metadata key of the dubbo application, which is also used as the app label of
+// the subscribers.
+const dubboAppMetaKey = "application"
+
 func ConvertServiceEntryMap(
 	instances []*instanceResp,
 	opts *convertOptions,
@@ -76,6 +85,21 @@ func ConvertServiceEntryMap(
 	return seMap, nil
 }
 
+// convertSubscriberEndpoints converts the subscribers with known app to the inbound endpoints of the service.
+func convertSubscriberEndpoints(subscribers []*subscriber) []*networkingapi.WorkloadEntry {
+	var ret []*networkingapi.WorkloadEntry
+	for _, sub := range subscribers {
+		if sub.App == "" || sub.App == "unknown" { // nacos-spec, `unknown` means the app is not reported
+			continue
+		}
+		ret = append(ret, &networkingapi.WorkloadEntry{
+			Address: sub.Ip,
+			Labels:  map[string]string{dubboAppMetaKey: sub.App},
+		})
+	}
+	return ret
+}
+
 func convertServiceEntry(
 	instanceResp *instanceResp,
 	projectCode string,
@@ -146,6 +170,10 @@ func convertServiceEntry(
 				Endpoints:  endpoints,
 				Ports:      ports,
 			}
+			if opts.callModels != nil {
+				opts.callModels[seName] = source.ConvertDubboCallModel(ses[seName], host,
+					convertSubscriberEndpoints(instanceResp.Subscribers), dubboAppMetaKey)
+			}
 		}
 	}
 
@@ -165,7 +193,12 @@ func convertEndpointsWithNs(instances []*instance, projectCode string, opts *con
 		if opts.filter != nil && !opts.filter(ins) {
 			continue
 		}
-		if !ins.Healthy { // nacos-spec
+		health := instanceHealth(ins)
+		if opts.healthMapper == nil {
+			if !ins.Healthy { // nacos-spec
+				continue
+			}
+		} else if !opts.healthMapper.Include(health) {
 			continue
 		}
 		// if the project code is not empty, and the project code of the instance is not equal to the project code, skip it
@@ -227,6 +260,9 @@ func convertEndpointsWithNs(instances []*instance, projectCode string, opts *con
 			Ports:   map[string]uint32{svcPortName: uint32(ins.Port)},
 			Labels:  ins.Metadata,
 		}
+		if opts.healthMapper != nil {
+			opts.healthMapper.Apply(ep, health)
+		}
 
 		util.FillWorkloadEntryLocality(ep)
 
@@ -236,6 +272,21 @@ func convertEndpointsWithNs(instances []*instance, projectCode string, opts *con
 	return endpointsMap, svcPortsMap, useDNSMap
 }
 
+func instanceHealth(ins *instance) source.InstanceHealth {
+	h := source.NewInstanceHealth()
+	if !ins.Healthy {
+		h.Status = source.InstanceUnhealthy
+	}
+	if ins.Enabled != nil && !*ins.Enabled {
+		h.Status = source.InstanceDisabled
+	}
+	if ins.Weight != nil {
+		h.Weight = int64(math.Round(*ins.Weight * source.DefaultInstanceWeight))
+	}
+	h.FillWarmupFromMeta(ins.Metadata)
+	return h
+}
+
 func convertInstanceId(labels map[string]string) {
 	v, ok := labels["instanceId"]
 	if ok {
@@ -23,6 +23,7 @@ const (
 	serviceListAPI        = "/nacos/v1/ns/service/list"
 	catalogServiceListAPI = "/nacos/v1/ns/catalog/services"
 	intancesListAPI       = "/nacos/v1/ns/instance/list"
+	subscribersListAPI    = "/nacos/v1/ns/service/subscribers"
 	loginAPI              = "/nacos/v1/auth/login"
 
 	defaultNacosTokenTTL = 5
@@ -61,6 +62,8 @@ type instance struct {
 	Port        int           `json:"port"`
 	Healthy     bool          `json:"healthy"`
 	Valid       bool          `json:"valid"`
+	Enabled     *bool         `json:"enabled,omitempty"`
+	Weight      *float64      `json:"weight,omitempty"`
 	Ephemeral   bool          `json:"ephemeral"`
 	InstanceId  string        `json:"instanceId"`
 	ClusterName string        `json:"clusterName"`
@@ -71,6 +74,30 @@ type instance struct {
 type instanceResp struct {
 	Hosts []*instance `json:"hosts"`
 	Dom   string      `json:"dom"`
+	// Subscribers of the service, only fetched when the dubbo sidecar is enabled.
+	Subscribers []*subscriber `json:"subscribers,omitempty"`
+}
+
+type subscriber struct {
+	AddrStr     string `json:"addrStr"`
+	Agent       string `json:"agent"`
+	App         string `json:"app"`
+	Ip          string `json:"ip"`
+	Port        int    `json:"port"`
+	NamespaceId string `json:"namespaceId"`
+	ServiceName string `json:"serviceName"`
+	Cluster     string `json:"cluster"`
+}
+
+type subscriberResp struct {
+	Subscribers []*subscriber `json:"subscribers"`
+	Count       int           `json:"count"`
+}
+
+// merge merges the instances and subscribers of the same service from another namespace, group or server.
+func (ir *instanceResp) merge(o *instanceResp) {
+	ir.Hosts = append(ir.Hosts, o.Hosts...)
+	ir.Subscribers = append(ir.Subscribers, o.Subscribers...)
 }
 
 // Client for Nacos
@@ -87,10 +114,11 @@ func NewClients(
 	servers []bootstrap.NacosServer,
 	metaKeyNamespace, metaKeyGroup string,
 	headers map[string]string,
+	fetchSubscribers bool,
 ) Client {
 	clis := make(clients, 0, len(servers))
 	for _, server := range servers {
-		clis = append(clis, newClient(server, metaKeyNamespace, metaKeyGroup, headers))
+		clis = append(clis, newClient(server, metaKeyNamespace, metaKeyGroup, headers, fetchSubscribers))
 	}
 	return clis
 }
@@ -99,7 +127,7 @@ func (clis clients) Instances() ([]*instanceResp, error) {
 	if len(clis) == 1 {
 		return clis[0].Instances()
 	}
-	cache := make(map[string][]*instance)
+	cache := make(map[string]*instanceResp)
 	for _, cli := range clis {
 		insts, err := cli.Instances()
 		if err != nil {
@@ -107,19 +135,27 @@ func (clis clients) Instances() ([]*instanceResp, error) {
 			continue
 		}
 		for _, instResp := range insts {
-			cache[instResp.Dom] = append([]*instance(cache[instResp.Dom]), instResp.Hosts...)
+			if prev, ok := cache[instResp.Dom]; ok {
+				prev.merge(instResp)
+			} else {
+				cache[instResp.Dom] = instResp
+			}
 		}
 	}
 	ret := make([]*instanceResp, 0, len(cache))
-	for dom, hosts := range cache {
-		ret = append(ret, &instanceResp{
-			Dom:   dom,
-			Hosts: hosts,
-		})
+	for _, instResp := range cache {
+		ret = append(ret, instResp)
 	}
 	return ret, nil
 }
 
+// close stops the background token refreshing of the clients.
+func (clis clients) close() {
+	for _, cli := range clis {
+		close(cli.stop)
+	}
+}
+
 func (clis clients) RegistryInfo() string {
 	info := make([]json.RawMessage, 0, len(clis))
 	for _, cli := range clis {
@@ -140,12 +176,14 @@ type client struct {
 	metaKeyNamespace, metaKeyGroup string
 	fetchAllNamespaces             bool
 	injectNsGroupIntoMeta          bool
+	fetchSubscribers               bool
 
 	// security login
 	username string
 	password string
 	token    *atomic.Value
 	tokenTTL int64
+	stop     chan struct{}
 
 	namespaceNameToID map[string]string
 }
@@ -154,6 +192,7 @@ func newClient(
 	server bootstrap.NacosServer,
 	metaKeyNamespace, metaKeyGroup string,
 	headers map[string]string,
+	fetchSubscribers bool,
 ) *client {
 	c := &client{
 		client:             http.Client{Timeout: 30 * time.Second},
@@ -164,10 +203,12 @@ func newClient(
 		metaKeyNamespace:   metaKeyNamespace,
 		metaKeyGroup:       metaKeyGroup,
 		fetchAllNamespaces: server.AllNamespaces,
+		fetchSubscribers:   fetchSubscribers,
 		username:           server.Username,
 		password:           server.Password,
 		tokenTTL:           defaultNacosTokenTTL, // default TokenTTL as 5 second, if first login failed
 		token:              &atomic.Value{},
+		stop:               make(chan struct{}),
 	}
 	c.injectNsGroupIntoMeta = c.metaKeyNamespace != "" || c.metaKeyGroup != ""
 	if c.headers == nil {
@@ -259,7 +300,7 @@ func (c *client) doCall(url string, method string, header map[string]string, bod
 }
 
 func (c *client) Instances() ([]*instanceResp, error) {
-	var fetcher func() (map[string][]*instance, error)
+	var fetcher func() (map[string]*instanceResp, error)
 	if c.fetchAllNamespaces {
 		fetcher = c.allNamespacesInstances
 	} else {
@@ -268,15 +309,17 @@ func (c *client) Instances() ([]*instanceResp, error) {
 	m, err := fetcher()
 	if err != nil {
 		log.Errorf("do get instances failed: %s", err)
+		source.RecordRegistrySync(SourceName, c.registryID, 0, 0, err)
 		return nil, err
 	}
 	resp := make([]*instanceResp, 0, len(m))
-	for svc, instances := range m {
-		resp = append(resp, &instanceResp{
-			Dom:   svc,
-			Hosts: instances,
-		})
+	instances := 0
+	for svc, instResp := range m {
+		instResp.Dom = svc
+		resp = append(resp, instResp)
+		instances += len(instResp.Hosts)
 	}
+	source.RecordRegistrySync(SourceName, c.registryID, len(resp), instances, nil)
 	return resp, nil
 }
 
@@ -397,6 +440,68 @@ func (c *client) listInstances(namespaceId, groupName, serviceName string) ([]*i
 	return ir.Hosts, nil
 }
 
+func (c *client) pagingListSubscribers(
+	namespaceId, groupName, serviceName string,
+	pageNo int,
+) (*subscriberResp, error) {
+	var sr subscriberResp
+	param := map[string]string{
+		"namespaceId": namespaceId,
+		"groupName":   groupName,
+		"serviceName": serviceName,
+		"pageSize":    fmt.Sprintf("%d", serviceListPageSize),
+		"pageNo":      fmt.Sprintf("%d", pageNo),
+	}
+	c.injectAuthParam(param)
+	resp, err := c.call(subscribersListAPI, http.MethodGet, c.headers, param, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(resp, &sr); err != nil {
+		return nil, err
+	}
+	return &sr, nil
+}
+
+func (c *client) listSubscribers(namespaceId, groupName, serviceName string) ([]*subscriber, error) {
+	probeResp, err := c.pagingListSubscribers(namespaceId, groupName, serviceName, 1)
+	if err != nil {
+		return nil, err
+	}
+	subscribers := probeResp.Subscribers
+	if probeResp.Count > serviceListPageSize {
+		pageCount := probeResp.Count/serviceListPageSize + 1
+		for page := 2; page <= pageCount; page++ {
+			sr, err := c.pagingListSubscribers(namespaceId, groupName, serviceName, page)
+			if err != nil {
+				return nil, err
+			}
+			subscribers = append(subscribers, sr.Subscribers...)
+		}
+	}
+	return subscribers, nil
+}
+
+// listService lists the instances of the service, and also the subscribers if `fetchSubscribers` is true.
+func (c *client) listService(namespaceId, groupName, serviceName string) (*instanceResp, error) {
+	instances, err := c.listInstances(namespaceId, groupName, serviceName)
+	if err != nil {
+		return nil, err
+	}
+	ret := &instanceResp{Hosts: instances}
+	if c.fetchSubscribers {
+		subscribers, err := c.listSubscribers(namespaceId, groupName, serviceName)
+		if err != nil {
+			// not fatal, the call edges of the service may be stale
+			log.Warnf("list subscribers of service %q in namespace %q group %q failed: %s",
+				serviceName, namespaceId, groupName, err)
+		} else {
+			ret.Subscribers = subscribers
+		}
+	}
+	return ret, nil
+}
+
 func (c *client) listNamespaces() ([]*nacosNamespace, error) {
 	var nr namespaceResp
 	param := map[string]string{}
@@ -432,8 +537,8 @@ func (c *client) getNamespaceID(namespace string) string {
 	return id
 }
 
-func (c *client) namespacedGroupsInstances() (map[string][]*instance, error) {
-	svcInstances := map[string][]*instance{}
+func (c *client) namespacedGroupsInstances() (map[string]*instanceResp, error) {
+	svcInstances := map[string]*instanceResp{}
 	for ns, gs := range c.namespaceGoups {
 		for _, g := range gs {
 			instances, err := c.namespacedGroupedInstances(c.getNamespaceID(ns), g)
@@ -442,23 +547,31 @@ func (c *client) namespacedGroupsInstances() (map[string][]*instance, error) {
 				// try best
 				continue
 			}
-			for k, v := range instances {
-				svcInstances[k] = append(svcInstances[k], v...)
-			}
+			mergeSvcInstances(svcInstances, instances)
 		}
 	}
 	return svcInstances, nil
 }
 
-func (c *client) namespacedGroupedInstances(namespaceId, groupName string) (map[string][]*instance, error) {
+func mergeSvcInstances(to, from map[string]*instanceResp) {
+	for k, v := range from {
+		if prev, ok := to[k]; ok {
+			prev.merge(v)
+		} else {
+			to[k] = v
+		}
+	}
+}
+
+func (c *client) namespacedGroupedInstances(namespaceId, groupName string) (map[string]*instanceResp, error) {
 	svcs, err := c.listServices(namespaceId, groupName)
 	if err != nil {
 		log.Errorf("list services in namespace %q group %q failed: %s", namespaceId, groupName, err)
 		return nil, err
 	}
-	svcInstances := make(map[string][]*instance, len(svcs))
+	svcInstances := make(map[string]*instanceResp, len(svcs))
 	for _, svc := range svcs {
-		instances, err := c.listInstances(namespaceId, groupName, svc)
+		instances, err := c.listService(namespaceId, groupName, svc)
 		if err != nil {
 			log.Warnf("list instances of service %q in namespace %q group %q failed: %s", namespaceId, groupName, svc, err)
 			// try best
@@ -469,15 +582,15 @@ func (c *client) namespacedGroupedInstances(namespaceId, groupName string) (map[
 	return svcInstances, nil
 }
 
-func (c *client) namespacedInstances(namespaceId string) (map[string][]*instance, error) {
+func (c *client) namespacedInstances(namespaceId string) (map[string]*instanceResp, error) {
 	svcs, err := c.listCatalogServices(namespaceId)
 	if err != nil {
 		log.Errorf("list services using catalog api in namespace %q failed: %s", namespaceId, err)
 		return nil, err
 	}
-	svcInstances := make(map[string][]*instance, len(svcs))
+	svcInstances := make(map[string]*instanceResp, len(svcs))
 	for _, svc := range svcs {
-		instances, err := c.listInstances(namespaceId, svc.GroupName, svc.Name)
+		instances, err := c.listService(namespaceId, svc.GroupName, svc.Name)
 		if err != nil {
 			log.Warnf("list instances of service %q in namespace %q group %q failed: %s",
 				namespaceId, svc.GroupName, svc.Name, err)
@@ -485,18 +598,18 @@ func (c *client) namespacedInstances(namespaceId string) (map[string][]*instance
 			continue
 		}
 
-		svcInstances[svc.Name] = append(svcInstances[svc.Name], instances...)
+		mergeSvcInstances(svcInstances, map[string]*instanceResp{svc.Name: instances})
 	}
 	return svcInstances, nil
 }
 
-func (c *client) allNamespacesInstances() (map[string][]*instance, error) {
+func (c *client) allNamespacesInstances() (map[string]*instanceResp, error) {
 	nsList, err := c.listNamespaces()
 	if err != nil {
 		log.Errorf("list namespaces failed: %s", err)
 		return nil, err
 	}
-	svcInstances := make(map[string][]*instance)
+	svcInstances := make(map[string]*instanceResp)
 	for _, ns := range nsList {
 		instances, err := c.namespacedInstances(ns.Namespace)
 		if err != nil {
@@ -504,9 +617,7 @@ func (c *client) allNamespacesInstances() (map[string][]*instance, error) {
 			// try best
 			continue
 		}
-		for k, v := range instances {
-			svcInstances[k] = append(svcInstances[k], v...)
-		}
+		mergeSvcInstances(svcInstances, instances)
 	}
 	return svcInstances, nil
 }
@@ -530,6 +641,7 @@ func (c *client) login() {
 	resp, err := c.call(loginAPI, http.MethodPost, c.headers, nil, body)
 	if err != nil {
 		log.Warnf("login with user %s failed: %s", c.username, err)
+		source.RecordRegistryConnection(SourceName, c.registryID, false, fmt.Errorf("login failed: %w", err))
 		needResetTTL = true
 		return
 	}
@@ -560,8 +672,13 @@ func (c *client) autoRefresh() {
 	}
 	go func() {
 		timer := time.NewTimer(time.Duration((c.tokenTTL - c.tokenTTL/10)) * time.Second)
+		defer timer.Stop()
 		for {
-			<-timer.C
+			select {
+			case <-c.stop:
+				return
+			case <-timer.C:
+			}
 			c.login()
 			timer.Reset(time.Duration((c.tokenTTL - c.tokenTTL/10)) * time.Second)
 		}
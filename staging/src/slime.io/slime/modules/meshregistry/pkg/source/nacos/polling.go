@@ -50,7 +50,7 @@ func (s *Source) refresh() {
 }
 
 func (s *Source) updateServiceInfo() error {
-	instances, err := s.client.Instances()
+	instances, err := s.getClient().Instances()
 	if err != nil {
 		return fmt.Errorf("get nacos instances failed: %v", err)
 	}
@@ -70,12 +70,24 @@ func (s *Source) updateServiceInfo() error {
 		domSuffix:             s.args.DomSuffix,
 		filter:                s.getInstanceFilters(),
 		hostAliases:           s.getServiceHostAlias(),
+		healthMapper:          source.NewEndpointHealthMapper(s.args.EndpointHealth),
 	}
 	opts.protocol, opts.protocolName = source.ProtocolName(s.args.SvcProtocol, s.args.GenericProtocol)
+	if s.args.EnableDubboSidecar {
+		opts.callModels = map[string]map[string]source.DubboCallModel{}
+	}
 	newServiceEntryMap, err := ConvertServiceEntryMap(instances, opts)
 	if err != nil {
 		return fmt.Errorf("convert nacos servceentry map failed: %v", err)
 	}
+	// changedCallModels records the call models of the ServiceEntries whose call models changed
+	changedCallModels := s.updateDubboCallModels(opts.callModels)
+	attachCallModels := func(ev event.Event, fullName string) event.Event {
+		if callModels, ok := changedCallModels[fullName]; ok {
+			ev = source.AttachDubboCallModels(ev, callModels)
+		}
+		return ev
+	}
 
 	cache := s.cacheShallowCopy()
 	seMetaModifierFactory := s.getSeMetaModifierFactory()
@@ -92,6 +104,7 @@ func (s *Source) updateServiceInfo() error {
 			} else {
 				log.Infof("delete(update) nacos se, hosts: %s ,ep: %s ,size : %d ",
 					se.Hosts[0], printEps(se.Endpoints), len(se.Endpoints))
+				event = attachCallModels(event, fullName)
 				for _, h := range s.handlers {
 					h.Handle(event)
 				}
@@ -109,12 +122,13 @@ func (s *Source) updateServiceInfo() error {
 			} else {
 				log.Infof("add nacos se, hosts: %s ,ep: %s, size: %d ",
 					newEntry.Hosts[0], printEps(newEntry.Endpoints), len(newEntry.Endpoints))
+				event = attachCallModels(event, fullName)
 				for _, h := range s.handlers {
 					h.Handle(event)
 				}
 			}
 			monitoring.RecordServiceEntryCreation(SourceName, err == nil)
-		} else if !proto.Equal(oldEntry, newEntry) {
+		} else if _, callModelChanged := changedCallModels[fullName]; callModelChanged || !proto.Equal(oldEntry, newEntry) {
 			// UPDATE
 			event, err := buildEvent(event.Updated, newEntry, fullName, s.args.ResourceNs, seMetaModifierFactory(fullName), s.args.NsHost) //nolint: lll
 			if err != nil {
@@ -122,6 +136,7 @@ func (s *Source) updateServiceInfo() error {
 			} else {
 				log.Infof("update nacos se, hosts: %s, ep: %s, size: %d ", newEntry.Hosts[0],
 					printEps(newEntry.Endpoints), len(newEntry.Endpoints))
+				event = attachCallModels(event, fullName)
 				for _, h := range s.handlers {
 					h.Handle(event)
 				}
@@ -136,3 +151,29 @@ func (s *Source) updateServiceInfo() error {
 
 	return nil
 }
+
+// updateDubboCallModels records the call models of the ServiceEntries and returns the changed ones. The ServiceEntries
+// no longer exist are considered contributing nothing.
+func (s *Source) updateDubboCallModels(
+	callModels map[string]map[string]source.DubboCallModel,
+) map[string]map[string]source.DubboCallModel {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	changed := map[string]map[string]source.DubboCallModel{}
+	for fullName, pre := range s.seDubboCallModels {
+		if _, ok := callModels[fullName]; !ok {
+			delete(s.seDubboCallModels, fullName)
+			if len(pre) > 0 {
+				changed[fullName] = map[string]source.DubboCallModel{}
+			}
+		}
+	}
+	for fullName, cur := range callModels {
+		if len(source.CalcChangedApps(s.seDubboCallModels[fullName], cur)) > 0 {
+			changed[fullName] = cur
+		}
+		s.seDubboCallModels[fullName] = cur
+	}
+	return changed
+}
@@ -49,6 +49,9 @@ type Source struct {
 	// source cache
 	cache    map[string]*networkingapi.ServiceEntry
 	handlers []event.Handler
+	// seDubboCallModels is the call models derived from each ServiceEntry, which are contributed to the shared
+	// dubbo `Sidecar` generation on change.
+	seDubboCallModels map[string]map[string]source.DubboCallModel
 
 	mut sync.RWMutex
 
@@ -104,25 +107,13 @@ func New(
 		started:           false,
 		initedCallback:    readyCallback,
 		cache:             make(map[string]*networkingapi.ServiceEntry),
+		seDubboCallModels: make(map[string]map[string]source.DubboCallModel),
 		stop:              make(chan struct{}),
 		seInitCh:          make(chan struct{}),
 		seMergePortMocker: svcMocker,
 	}
 
-	servers := args.Servers
-	if len(servers) == 0 {
-		servers = []bootstrap.NacosServer{args.NacosServer}
-	}
-	headers := make(map[string]string)
-	if nacosHeaders := features.NacosClientHeaders; nacosHeaders != "" {
-		for _, header := range strings.Split(nacosHeaders, ",") {
-			items := strings.SplitN(header, "=", 2)
-			if len(items) == 2 {
-				headers[items[0]] = items[1]
-			}
-		}
-	}
-	src.client = NewClients(servers, args.MetaKeyNamespace, args.MetaKeyGroup, headers)
+	src.client = newSourceClient(args)
 
 	src.initWg.Add(1) // // service entry init-sync
 	if src.seMergePortMocker != nil {
@@ -154,6 +145,23 @@ func New(
 	return src, debugHandler, args.LabelPatch, false, nil
 }
 
+func newSourceClient(args *bootstrap.NacosSourceArgs) Client {
+	servers := args.Servers
+	if len(servers) == 0 {
+		servers = []bootstrap.NacosServer{args.NacosServer}
+	}
+	headers := make(map[string]string)
+	if nacosHeaders := features.NacosClientHeaders; nacosHeaders != "" {
+		for _, header := range strings.Split(nacosHeaders, ",") {
+			items := strings.SplitN(header, "=", 2)
+			if len(items) == 2 {
+				headers[items[0]] = items[1]
+			}
+		}
+	}
+	return NewClients(servers, args.MetaKeyNamespace, args.MetaKeyGroup, headers, args.EnableDubboSidecar)
+}
+
 func (s *Source) cacheShallowCopy() map[string]*networkingapi.ServiceEntry {
 	s.mut.RLock()
 	defer s.mut.RUnlock()
@@ -283,9 +291,24 @@ func (s *Source) onConfig(args *bootstrap.NacosSourceArgs) {
 		newReGroupInstances := reGroupInstances(args.InstanceMetaRelabel, args.ServiceNaming)
 		s.reGroupInstances = newReGroupInstances
 	}
+
+	// the servers may be changed by the RegistrySource connections
+	if !reflect.DeepEqual(prevArgs.NacosServer, args.NacosServer) ||
+		!reflect.DeepEqual(prevArgs.Servers, args.Servers) {
+		if prevClients, ok := s.client.(clients); ok {
+			prevClients.close()
+		}
+		s.client = newSourceClient(args)
+	}
 	s.mut.Unlock()
 }
 
+func (s *Source) getClient() Client {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	return s.client
+}
+
 func (s *Source) getInstanceFilters() func(*instance) bool {
 	s.mut.RLock()
 	defer s.mut.RUnlock()
@@ -316,7 +339,7 @@ func (s *Source) handleHttp(w http.ResponseWriter, req *http.Request) {
 }
 
 func (s *Source) dumpClients(w http.ResponseWriter, _ *http.Request) {
-	_, _ = w.Write([]byte(s.client.RegistryInfo()))
+	_, _ = w.Write([]byte(s.getClient().RegistryInfo()))
 }
 
 func (s *Source) cacheJson(w http.ResponseWriter, _ *http.Request) {
@@ -409,6 +432,11 @@ func (s *Source) Start() {
 	}
 }
 
+// ContributeDubboCallModel implements source.DubboCallModelContributor.
+func (s *Source) ContributeDubboCallModel() bool {
+	return s.args.EnableDubboSidecar
+}
+
 func (s *Source) Stop() {
 	s.stop <- struct{}{}
 }
@@ -484,20 +512,28 @@ func reGroupInstances(
 	}
 
 	return func(in []*instanceResp) []*instanceResp {
-		m := map[string][]*instance{}
+		m := map[string]*instanceResp{}
 		for _, ir := range in {
+			// the subscribers of the original service are considered subscribing all the regrouped services
+			subscribed := map[string]struct{}{}
 			for _, host := range ir.Hosts {
 				instanceRelabel(host)
 				dom := instanceDom(host)
-				m[dom] = append([]*instance(m[dom]), host)
+				out, ok := m[dom]
+				if !ok {
+					out = &instanceResp{Dom: dom}
+					m[dom] = out
+				}
+				out.Hosts = append(out.Hosts, host)
+				if _, ok := subscribed[dom]; !ok {
+					subscribed[dom] = struct{}{}
+					out.Subscribers = append(out.Subscribers, ir.Subscribers...)
+				}
 			}
 		}
 		out := make([]*instanceResp, 0, len(m))
-		for dom, hosts := range m {
-			out = append(out, &instanceResp{
-				Dom:   dom,
-				Hosts: hosts,
-			})
+		for _, ir := range m {
+			out = append(out, ir)
 		}
 		return out
 	}
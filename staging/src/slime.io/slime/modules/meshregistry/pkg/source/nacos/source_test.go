@@ -6,6 +6,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"slime.io/slime/modules/meshregistry/pkg/bootstrap"
+	"slime.io/slime/modules/meshregistry/pkg/source"
 	"slime.io/slime/modules/meshregistry/pkg/source/sourcetest"
 )
 
@@ -118,6 +119,53 @@ func TestUpdateServiceInfo(t *testing.T) {
 				seMetaModifierFactory: emptySeMetaModifierFactory,
 			},
 		},
+		{
+			name: "dubbo-sidecar",
+			data: []testData{
+				{
+					in:     "./testdata/dubbo_sidecar.json",
+					expect: "./testdata/dubbo_sidecar.expected.yaml",
+				},
+			},
+			s: &Source{
+				args: &bootstrap.NacosSourceArgs{
+					SourceArgs: bootstrap.SourceArgs{
+						SvcProtocol:           "dubbo",
+						InstancePortAsSvcPort: true,
+						ResourceNs:            "nacos",
+						DefaultServiceNs:      "nacos",
+					},
+					EnableDubboSidecar: true,
+				},
+				seMetaModifierFactory: emptySeMetaModifierFactory,
+				seDubboCallModels:     map[string]map[string]source.DubboCallModel{},
+			},
+		},
+		{
+			name: "endpoint-health",
+			data: []testData{
+				{
+					in:     "./testdata/endpoint_health.json",
+					expect: "./testdata/endpoint_health.expected.yaml",
+				},
+			},
+			s: &Source{
+				args: &bootstrap.NacosSourceArgs{
+					SourceArgs: bootstrap.SourceArgs{
+						SvcProtocol:           "http",
+						InstancePortAsSvcPort: true,
+						ResourceNs:            "nacos",
+						DefaultServiceNs:      "nacos",
+						EndpointHealth: &bootstrap.EndpointHealthArgs{
+							KeepUnhealthy: true,
+							StatusLabel:   "status",
+							EnableWeight:  true,
+						},
+					},
+				},
+				seMetaModifierFactory: emptySeMetaModifierFactory,
+			},
+		},
 		// TODO: add test cases for:
 		// - instance filter
 		// - hostalias
@@ -127,11 +175,22 @@ func TestUpdateServiceInfo(t *testing.T) {
 		// - ...
 	}
 
+	var sidecarGenerator *source.DubboSidecarGenerator
 	initTest := func(s *Source) {
 		mockClient.Reset()
 		s.client = mockClient
 		assertHandler.Reset()
 		s.Dispatch(assertHandler)
+		sidecarGenerator = nil
+		if s.ContributeDubboCallModel() {
+			sidecarGenerator = source.NewDubboSidecarGenerator(&bootstrap.DubboSidecarArgs{
+				ResourceNs:            "dubbo",
+				DubboWorkloadAppLabel: "app",
+				SvcProtocol:           "dubbo",
+			}, nil)
+			s.Dispatch(sidecarGenerator.HandlerFor(SourceName))
+			sidecarGenerator.Dispatch(assertHandler)
+		}
 	}
 
 	for _, tt := range args {
@@ -151,6 +210,9 @@ func TestUpdateServiceInfo(t *testing.T) {
 					assert.NoError(t, assertHandler.LoadExpected(d.expect))
 				}
 				assert.NoError(t, tt.s.updateServiceInfo())
+				if sidecarGenerator != nil {
+					sidecarGenerator.Refresh(false)
+				}
 				assertHandler.Assert(t)
 			}
 		})
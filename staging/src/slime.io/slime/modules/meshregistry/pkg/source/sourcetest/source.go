@@ -258,6 +258,19 @@ func (n *ZkNode) GetChildren(path string) []string {
 	return nil
 }
 
+// GetNode returns the node of the path, or nil if not found.
+func (n *ZkNode) GetNode(path string) *ZkNode {
+	if n.FullPath == path {
+		return n
+	}
+	for _, child := range n.Children {
+		if child.FullPath == path || strings.HasPrefix(path, child.FullPath+"/") {
+			return child.GetNode(path)
+		}
+	}
+	return nil
+}
+
 type MockZookeeperClient struct {
 	err  error
 	root *ZkNode
@@ -289,6 +302,14 @@ func (m *MockZookeeperClient) Children(path string) ([]string, error) {
 	return children, nil
 }
 
+func (m *MockZookeeperClient) Get(path string) ([]byte, error) {
+	node := m.root.GetNode(path)
+	if node == nil {
+		return nil, fmt.Errorf("node of path %s not found", path)
+	}
+	return []byte(node.Data), nil
+}
+
 func (m *MockZookeeperClient) ChildrenW(_ string) ([]string, <-chan zk.Event, error) {
 	panic("implement me")
 }
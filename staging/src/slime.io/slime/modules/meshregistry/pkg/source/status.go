@@ -0,0 +1,84 @@
+package source
+
+import (
+	"sync"
+	"time"
+)
+
+// RegistryStatusKey identifies a registry connection of a source. The RegistryID is the `RegistryID` of the
+// configured server, and is empty for the source without multiple servers (like zookeeper).
+type RegistryStatusKey struct {
+	Source     string
+	RegistryID string
+}
+
+// RegistryStatus is the observed status of a registry connection.
+type RegistryStatus struct {
+	Connected bool
+	// LastSyncTime is the time of the last successful sync with the registry.
+	LastSyncTime time.Time
+	// Services and Instances are the counts fetched by the last successful sync.
+	Services  int
+	Instances int
+	// Error is the error of the last failed sync or connection, and is cleared by a successful sync.
+	Error     string
+	ErrorTime time.Time
+}
+
+var registryStatuses = struct {
+	sync.RWMutex
+	m map[RegistryStatusKey]RegistryStatus
+}{m: map[RegistryStatusKey]RegistryStatus{}}
+
+func updateRegistryStatus(key RegistryStatusKey, f func(st *RegistryStatus)) {
+	registryStatuses.Lock()
+	st := registryStatuses.m[key]
+	f(&st)
+	registryStatuses.m[key] = st
+	registryStatuses.Unlock()
+}
+
+// RecordRegistryConnection records the connectivity of a registry connection.
+func RecordRegistryConnection(source, registryID string, connected bool, err error) {
+	updateRegistryStatus(RegistryStatusKey{Source: source, RegistryID: registryID}, func(st *RegistryStatus) {
+		st.Connected = connected
+		if err != nil {
+			st.Error, st.ErrorTime = err.Error(), time.Now()
+		}
+	})
+}
+
+// RecordRegistrySync records the result of a sync with a registry. A failed sync marks the connection as
+// disconnected, a successful one marks it as connected and clears the previous error.
+func RecordRegistrySync(source, registryID string, services, instances int, err error) {
+	updateRegistryStatus(RegistryStatusKey{Source: source, RegistryID: registryID}, func(st *RegistryStatus) {
+		if err != nil {
+			st.Connected = false
+			st.Error, st.ErrorTime = err.Error(), time.Now()
+			return
+		}
+		st.Connected = true
+		st.LastSyncTime = time.Now()
+		st.Services, st.Instances = services, instances
+		st.Error, st.ErrorTime = "", time.Time{}
+	})
+}
+
+// GetRegistryStatus returns the observed status of a registry connection, false if nothing has been recorded.
+func GetRegistryStatus(source, registryID string) (RegistryStatus, bool) {
+	registryStatuses.RLock()
+	defer registryStatuses.RUnlock()
+	st, ok := registryStatuses.m[RegistryStatusKey{Source: source, RegistryID: registryID}]
+	return st, ok
+}
+
+// RegistryStatuses returns a copy of the observed status of all the registry connections.
+func RegistryStatuses() map[RegistryStatusKey]RegistryStatus {
+	registryStatuses.RLock()
+	defer registryStatuses.RUnlock()
+	ret := make(map[RegistryStatusKey]RegistryStatus, len(registryStatuses.m))
+	for k, v := range registryStatuses.m {
+		ret[k] = v
+	}
+	return ret
+}
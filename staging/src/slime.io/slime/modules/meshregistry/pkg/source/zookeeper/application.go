@@ -0,0 +1,490 @@
+package zookeeper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-zookeeper/zk"
+	networkingapi "istio.io/api/networking/v1alpha3"
+	"istio.io/libistio/pkg/config/event"
+	"istio.io/libistio/pkg/config/resource"
+
+	"slime.io/slime/modules/meshregistry/pkg/bootstrap"
+	"slime.io/slime/modules/meshregistry/pkg/monitoring"
+	"slime.io/slime/modules/meshregistry/pkg/source"
+	"slime.io/slime/modules/meshregistry/pkg/util"
+)
+
+// dubbo 3 application-level service discovery:
+//   - app instances are registered under `ApplicationRegisterRootNode/<app>/<ip:port>` in curator format.
+//   - the interfaces provided by an app revision are reported under `MetadataRootNode/<app>/<revision>`.
+//   - the providers apps of an interface are mapped under `MappingRootNode/<interface>`.
+//
+// Each app is converted to an app-level ServiceEntry with the app name as host, and the interfaces it provides
+// and the revisions providing them are carried as per-interface routing metadata.
+
+const (
+	ZkAppPath = "/zkApps"
+
+	dubboMetadataRevisionKey    = "dubbo.metadata.revision"
+	dubboMetadataStorageTypeKey = "dubbo.metadata.storage-type"
+	dubboEndpointsKey           = "dubbo.endpoints"
+
+	dubboMetadataStorageTypeLocal = "local"
+	dubboEndpointProtocol         = "dubbo"
+
+	// dubboAppServicesAnnotation is the annotation of the app-level ServiceEntry, whose value is the json of
+	// map[serviceKey][]revision. Empty revisions means all the endpoints provide the service.
+	dubboAppServicesAnnotation = "dubbo.apache.org/services"
+	dubboRegisterModeLabel     = "registerMode"
+)
+
+type dubboEndpoint struct {
+	Port     uint32 `json:"port"`
+	Protocol string `json:"protocol"`
+}
+
+// dubboMetadataInfo is the metadata of an app revision reported by dubbo 3.
+type dubboMetadataInfo struct {
+	App      string                       `json:"app"`
+	Revision string                       `json:"revision"`
+	Services map[string]*dubboServiceInfo `json:"services"`
+}
+
+type dubboServiceInfo struct {
+	Name     string            `json:"name"`
+	Group    string            `json:"group,omitempty"`
+	Version  string            `json:"version,omitempty"`
+	Protocol string            `json:"protocol,omitempty"`
+	Port     uint32            `json:"port,omitempty"`
+	Path     string            `json:"path,omitempty"`
+	Params   map[string]string `json:"params,omitempty"`
+}
+
+func (info *dubboServiceInfo) serviceKey() string {
+	return buildServiceKey(info.Name, map[string]string{
+		dubboParamGroupKey:   info.Group,
+		dubboParamVersionKey: info.Version,
+	})
+}
+
+// updateAppServiceInfo refreshes the app-level ServiceEntries.
+func (s *Source) updateAppServiceInfo() error {
+	apps, err := s.Con.Children(s.args.ApplicationRegisterRootNode)
+	if err != nil {
+		if !errors.Is(err, zk.ErrNoNode) {
+			return fmt.Errorf("zk path %s get child error: %s", s.args.ApplicationRegisterRootNode, err.Error())
+		}
+		apps = nil
+	}
+
+	mapping := s.readServiceMapping()
+	appMetadata := make(map[string]map[string]*dubboMetadataInfo, len(apps))
+	for _, app := range apps {
+		cse, metadata, err := s.app(app, mapping[app])
+		if err != nil {
+			log.Errorf("zk app %s refresh error: %v", app, err)
+			continue
+		}
+		appMetadata[app] = metadata
+		s.updateAppSeCache(app, cse)
+	}
+
+	s.mut.Lock()
+	s.appMetadata = appMetadata
+	s.mut.Unlock()
+
+	s.handleAppsDelete(apps)
+	return nil
+}
+
+// readServiceMapping reads the interface-app mapping and returns the interfaces of each app.
+func (s *Source) readServiceMapping() map[string][]string {
+	ifaces, err := s.Con.Children(s.args.MappingRootNode)
+	if err != nil {
+		log.Debugf("zk path %s get child error: %v", s.args.MappingRootNode, err)
+		return nil
+	}
+
+	ret := map[string][]string{}
+	for _, iface := range ifaces {
+		data, err := s.Con.Get(s.args.MappingRootNode + "/" + iface)
+		if err != nil {
+			log.Debugf("zk mapping of %s get error: %v", iface, err)
+			continue
+		}
+		for _, app := range strings.Split(string(data), ",") {
+			if app = strings.TrimSpace(app); app != "" {
+				ret[app] = append(ret[app], iface)
+			}
+		}
+	}
+	return ret
+}
+
+func (s *Source) app(app string, mappedIfaces []string) (
+	*convertedServiceEntry, map[string]*dubboMetadataInfo, error,
+) {
+	appPath := s.args.ApplicationRegisterRootNode + "/" + app
+	nodes, err := s.Con.Children(appPath)
+	if err != nil && !errors.Is(err, zk.ErrNoNode) {
+		return nil, nil, err
+	}
+
+	instances := make([]*DubboServiceInstance, 0, len(nodes))
+	for _, node := range nodes {
+		data, err := s.Con.Get(appPath + "/" + node)
+		if err != nil {
+			log.Debugf("zk app instance %s/%s get error: %v", appPath, node, err)
+			continue
+		}
+		inst := &DubboServiceInstance{}
+		if err := json.Unmarshal(data, inst); err != nil {
+			log.Errorf("invalid app instance %s/%s: %v", appPath, node, err)
+			continue
+		}
+		instances = append(instances, inst)
+	}
+
+	opts := &convertOptions{
+		svcPort:               s.args.SvcPort,
+		instancePortAsSvcPort: s.args.InstancePortAsSvcPort,
+		patchLabel:            s.args.LabelPatch,
+		ignoreLabels:          s.ignoreLabelsMap,
+		hostSuffix:            s.args.HostSuffix,
+		filter:                s.getInstanceFilter(),
+		healthMapper:          source.NewEndpointHealthMapper(s.args.EndpointHealth),
+	}
+	opts.protocol, opts.protocolName = source.ProtocolName(s.args.SvcProtocol, s.args.GenericProtocol)
+
+	metadata := s.appRevisionsMetadata(app, instances)
+	return s.convertAppServiceEntry(app, instances, metadata, mappedIfaces, opts), metadata, nil
+}
+
+// appRevisionsMetadata returns the metadata of the revisions of the instances. As a revision is the digest of
+// its metadata, the fetched metadata is reused until the revision is gone.
+func (s *Source) appRevisionsMetadata(app string, instances []*DubboServiceInstance) map[string]*dubboMetadataInfo {
+	s.mut.RLock()
+	prev := s.appMetadata[app]
+	s.mut.RUnlock()
+
+	ret := map[string]*dubboMetadataInfo{}
+	for _, inst := range instances {
+		meta := inst.Payload.Metadata
+		rev := meta[dubboMetadataRevisionKey]
+		if rev == "" {
+			continue
+		}
+		if _, ok := ret[rev]; ok {
+			continue
+		}
+		if info, ok := prev[rev]; ok && info != nil {
+			ret[rev] = info
+			continue
+		}
+		if meta[dubboMetadataStorageTypeKey] == dubboMetadataStorageTypeLocal {
+			// only served by the metadata service of the instance, rely on the mapping instead
+			ret[rev] = nil
+			continue
+		}
+
+		data, err := s.Con.Get(s.args.MetadataRootNode + "/" + app + "/" + rev)
+		if err != nil {
+			log.Debugf("zk metadata of app %s revision %s get error: %v", app, rev, err)
+			ret[rev] = nil
+			continue
+		}
+		info := &dubboMetadataInfo{}
+		if err := json.Unmarshal(data, info); err != nil {
+			log.Errorf("invalid metadata of app %s revision %s: %v", app, rev, err)
+			ret[rev] = nil
+			continue
+		}
+		ret[rev] = info
+	}
+	return ret
+}
+
+func (s *Source) convertAppServiceEntry(
+	app string,
+	instances []*DubboServiceInstance,
+	metadata map[string]*dubboMetadataInfo,
+	mappedIfaces []string,
+	opts *convertOptions,
+) *convertedServiceEntry {
+	host := app
+	if opts.hostSuffix != "" {
+		host += opts.hostSuffix
+	}
+	se := &networkingapi.ServiceEntry{
+		Hosts:      []string{host},
+		Ports:      make([]*networkingapi.ServicePort, 0),
+		Resolution: networkingapi.ServiceEntry_STATIC,
+	}
+
+	uniquePort := map[uint32]struct{}{}
+	for _, inst := range instances {
+		if inst.Address == "" {
+			continue
+		}
+		portNum := appInstancePort(inst)
+		if portNum == 0 {
+			continue
+		}
+
+		meta := appInstanceMeta(app, inst, opts.ignoreLabels)
+		if s.instanceMetaModifier != nil {
+			s.instanceMetaModifier(&meta)
+		}
+		util.FilterEndpointLabels(meta, opts.patchLabel, inst.Address, "zookeeper:"+inst.Address)
+
+		instance := dubboInstance{
+			Addr:     inst.Address,
+			Port:     portNum,
+			Service:  app,
+			Metadata: meta,
+		}
+		if opts.filter != nil && !opts.filter(&instance) {
+			continue
+		}
+		health := dubboInstanceHealth(inst.Payload.Metadata, meta)
+		if opts.healthMapper != nil && !opts.healthMapper.Include(health) {
+			continue
+		}
+
+		svcPortInUse := opts.svcPort
+		if opts.instancePortAsSvcPort {
+			svcPortInUse = portNum
+		}
+		ep := convertEndpoint(inst.Address, meta, &networkingapi.ServicePort{
+			Number:   portNum,
+			Protocol: opts.protocol,
+			Name:     source.PortName(opts.protocolName, svcPortInUse),
+		})
+		if opts.healthMapper != nil {
+			opts.healthMapper.Apply(ep, health)
+		}
+		se.Endpoints = append(se.Endpoints, ep)
+
+		svcPortsToAdd := []uint32{svcPortInUse}
+		if opts.svcPort != 0 && opts.svcPort != svcPortInUse {
+			svcPortsToAdd = append(svcPortsToAdd, opts.svcPort)
+		}
+		for _, p := range svcPortsToAdd {
+			if _, ok := uniquePort[p]; !ok {
+				se.Ports = append(se.Ports, &networkingapi.ServicePort{
+					Number:   p,
+					Protocol: opts.protocol,
+					Name:     source.PortName(opts.protocolName, p),
+				})
+				uniquePort[p] = struct{}{}
+			}
+		}
+	}
+
+	source.ApplyServicePortToEndpoints(se)
+	source.RectifyServiceEntry(se)
+
+	return &convertedServiceEntry{
+		se:     se,
+		labels: map[string]string{dubboSvcAppLabel: app},
+		annotations: map[string]string{
+			dubboAppServicesAnnotation: appServicesAnnotation(metadata, mappedIfaces),
+		},
+	}
+}
+
+// appInstancePort returns the dubbo protocol port of the instance.
+func appInstancePort(inst *DubboServiceInstance) uint32 {
+	if v := inst.Payload.Metadata[dubboEndpointsKey]; v != "" {
+		var endpoints []dubboEndpoint
+		if err := json.Unmarshal([]byte(v), &endpoints); err == nil {
+			for _, ep := range endpoints {
+				if ep.Protocol == dubboEndpointProtocol && ep.Port > 0 {
+					return ep.Port
+				}
+			}
+		}
+	}
+	return inst.Port
+}
+
+func appInstanceMeta(app string, inst *DubboServiceInstance, ignoreLabels map[string]string) map[string]string {
+	meta := make(map[string]string, len(inst.Payload.Metadata)+1)
+	for k, v := range inst.Payload.Metadata {
+		if _, ignored := ignoreLabels[k]; ignored {
+			continue
+		}
+		switch k {
+		case dubboEndpointsKey, metaDataServiceKey:
+			// json values, not label values
+			continue
+		}
+		v = strings.ReplaceAll(v, ",", "_")
+		v = strings.ReplaceAll(v, ":", "_")
+		meta[k] = v
+	}
+	meta[dubboSvcAppLabel] = app
+	return meta
+}
+
+// appServicesAnnotation builds the per-interface routing metadata: the services provided by the app and the
+// revisions providing them. The mapped interfaces missing in the metadata, mostly because of the local storage
+// type, are considered provided by all revisions.
+func appServicesAnnotation(metadata map[string]*dubboMetadataInfo, mappedIfaces []string) string {
+	services := map[string][]string{}
+	ifaces := map[string]struct{}{}
+	for rev, info := range metadata {
+		if info == nil {
+			continue
+		}
+		for _, svc := range info.Services {
+			if svc == nil || svc.Name == "" {
+				continue
+			}
+			key := svc.serviceKey()
+			services[key] = append(services[key], rev)
+			ifaces[svc.Name] = struct{}{}
+		}
+	}
+	for _, iface := range mappedIfaces {
+		if _, ok := ifaces[iface]; !ok {
+			services[iface] = []string{}
+		}
+	}
+	for _, revs := range services {
+		sort.Strings(revs)
+	}
+
+	b, _ := json.Marshal(services)
+	return string(b)
+}
+
+func (s *Source) updateAppSeCache(app string, cse *convertedServiceEntry) {
+	now := time.Now()
+	meta := resource.Metadata{
+		FullName: resource.FullName{
+			Namespace: resource.Namespace(s.args.ResourceNs),
+			Name:      resource.LocalName(app),
+		},
+		CreateTime: now,
+		Version:    resource.Version(now.String()),
+		Labels: map[string]string{
+			"path":                 app,
+			"registry":             SourceName,
+			dubboRegisterModeLabel: bootstrap.DubboRegisterModeInstance,
+		},
+		Annotations: map[string]string{},
+	}
+	for k, v := range cse.labels {
+		meta.Labels[k] = v
+	}
+	for k, v := range cse.annotations {
+		meta.Annotations[k] = v
+	}
+
+	newMetaSe, _ := prepareServiceEntryWithMeta(cse.se, meta)
+	kind := event.Added
+	if oldSem, exist := s.appCache.Get(app); exist {
+		if oldSem.Equals(*newMetaSe) {
+			return
+		}
+		kind = event.Updated
+	}
+	s.appCache.Set(app, newMetaSe)
+
+	ev, err := buildServiceEntryEvent(kind, newMetaSe.ServiceEntry, newMetaSe.Meta, nil)
+	if err == nil {
+		log.Infof("%s zk app se, hosts: %s, ep size: %d ",
+			kind, newMetaSe.ServiceEntry.Hosts[0], len(newMetaSe.ServiceEntry.Endpoints))
+		for _, h := range s.handlers {
+			h.Handle(ev)
+		}
+	}
+	if kind == event.Added {
+		monitoring.RecordServiceEntryCreation(SourceName, err == nil)
+	} else {
+		monitoring.RecordServiceEntryUpdate(SourceName, err == nil)
+	}
+}
+
+// handleAppsDelete clears the endpoints of the apps gone, the same as the interface-level ones.
+func (s *Source) handleAppsDelete(apps []string) {
+	if s.args.EnableEmptyProtection {
+		return
+	}
+	exist := make(map[string]struct{}, len(apps))
+	for _, app := range apps {
+		exist[app] = struct{}{}
+	}
+
+	for app, sem := range s.appCache.Items() {
+		if _, ok := exist[app]; ok || len(sem.ServiceEntry.Endpoints) == 0 {
+			continue
+		}
+		seValueCopy := *sem
+		seValueCopy.ServiceEntry = &networkingapi.ServiceEntry{
+			Hosts:      sem.ServiceEntry.Hosts,
+			Ports:      sem.ServiceEntry.Ports,
+			Resolution: sem.ServiceEntry.Resolution,
+			Endpoints:  make([]*networkingapi.WorkloadEntry, 0),
+		}
+		s.appCache.Set(app, &seValueCopy)
+
+		ev, err := buildServiceEntryEvent(event.Updated, seValueCopy.ServiceEntry, seValueCopy.Meta, nil)
+		if err == nil {
+			log.Infof("delete(update) zk app se, hosts: %s", seValueCopy.ServiceEntry.Hosts[0])
+			for _, h := range s.handlers {
+				h.Handle(ev)
+			}
+		}
+		monitoring.RecordServiceEntryDeletion(SourceName, false, err == nil)
+	}
+}
+
+// pollingApps refreshes the app-level ServiceEntries periodically, used in watching mode.
+func (s *Source) pollingApps() {
+	ticker := time.NewTicker(time.Duration(s.args.RefreshPeriod))
+	defer ticker.Stop()
+	for {
+		forceUpdateTrigger := s.forceUpdateTrigger.Load().(chan struct{})
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		case <-forceUpdateTrigger:
+		}
+		if err := s.updateAppServiceInfo(); err != nil {
+			log.Errorf("zk update app service info failed: %v", err)
+		}
+	}
+}
+
+func (s *Source) appCacheJson(w http.ResponseWriter, req *http.Request) {
+	app := req.URL.Query().Get("app")
+	result := map[string]interface{}{}
+	s.appCache.IterCb(func(key string, sem *ServiceEntryWithMeta) {
+		if app == "" || app == key {
+			result[key] = sem
+		}
+	})
+
+	s.mut.RLock()
+	metadata := s.appMetadata
+	s.mut.RUnlock()
+	if app != "" {
+		metadata = map[string]map[string]*dubboMetadataInfo{app: metadata[app]}
+	}
+
+	b, err := json.MarshalIndent(map[string]interface{}{"cache": result, "metadata": metadata}, "", "  ")
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "unable to marshal zk app cache: %v", err)
+		return
+	}
+	_, _ = w.Write(b)
+}
@@ -28,6 +28,9 @@ const (
 	dubboParamVersionKey        = "version"
 	dubboParamDefaultVersionKey = "default.version"
 	dubboParamMethods           = "methods"
+	dubboParamWeight            = "weight"
+	dubboParamDisabled          = "disabled"
+	dubboParamEnabled           = "enabled"
 	dubboTag                    = "dubbo.tag"
 
 	metaDataServiceKey            = "dubbo.metadata-service.url-params"
@@ -89,6 +92,7 @@ type convertedServiceEntry struct {
 	se               *networkingapi.ServiceEntry
 	methodsLabel     string
 	labels           map[string]string
+	annotations      map[string]string
 	InboundEndPoints []*networkingapi.WorkloadEntry
 }
 
@@ -99,6 +103,7 @@ type convertOptions struct {
 	hostSuffix            string
 	ignoreLabels          map[string]string
 	filter                func(*dubboInstance) bool
+	healthMapper          *source.EndpointHealthMapper
 
 	// the protocol used for Port.Protocol
 	protocol string
@@ -197,6 +202,10 @@ func (s *Source) convertServiceEntry(
 		if opts.filter != nil && !opts.filter(&instance) {
 			continue
 		}
+		health := dubboInstanceHealth(urlParams(providerParts[len(providerParts)-1]), meta)
+		if opts.healthMapper != nil && !opts.healthMapper.Include(health) {
+			continue
+		}
 
 		// update methods of the service
 		if len(methods) > 0 {
@@ -263,13 +272,15 @@ func (s *Source) convertServiceEntry(
 		}
 
 		// add endpoint
-		se.Endpoints = append(se.Endpoints,
-			convertEndpoint(addr, meta, &networkingapi.ServicePort{
-				Number:   portNum,
-				Protocol: opts.protocol,
-				Name:     source.PortName(opts.protocolName, svcPortInUse),
-			}),
-		)
+		ep := convertEndpoint(addr, meta, &networkingapi.ServicePort{
+			Number:   portNum,
+			Protocol: opts.protocol,
+			Name:     source.PortName(opts.protocolName, svcPortInUse),
+		})
+		if opts.healthMapper != nil {
+			opts.healthMapper.Apply(ep, health)
+		}
+		se.Endpoints = append(se.Endpoints, ep)
 
 		// update ports of the serviceEntry
 		if _, ok := uniquePort[serviceKey]; !ok {
@@ -440,6 +451,39 @@ func verifyMeta(url string, ip string, port uint32, service string, patchLabel b
 	return meta, true
 }
 
+// urlParams returns the raw params of the dubbo url, which may contain the ignored labels.
+func urlParams(url string) map[string]string {
+	idx := strings.Index(url, "?")
+	if idx < 0 {
+		return nil
+	}
+	entries := strings.Split(url[idx+1:], "&")
+	params := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if kv := strings.SplitN(entry, "=", 2); len(kv) == 2 {
+			params[kv[0]] = kv[1]
+		}
+	}
+	return params
+}
+
+// dubboInstanceHealth extracts the health from the raw params of the instance, the meta which may be patched by
+// the configurators takes precedence.
+func dubboInstanceHealth(params, meta map[string]string) source.InstanceHealth {
+	h := source.NewInstanceHealth()
+	h.Weight = source.DefaultInstanceWeight
+	h.FillWarmupFromMeta(params)
+	for _, m := range []map[string]string{params, meta} {
+		if m[dubboParamDisabled] == "true" || m[dubboParamEnabled] == "false" {
+			h.Status = source.InstanceDisabled
+		}
+		if v, err := strconv.ParseInt(m[dubboParamWeight], 10, 64); err == nil && v >= 0 {
+			h.Weight = v
+		}
+	}
+	return h
+}
+
 func parseDubboTag(str string, meta map[string]string) {
 	pairs := strings.Split(str, ",")
 	if len(pairs) == 1 {
@@ -26,6 +26,10 @@ func (m *MockZkConn) ChildrenW(path string) ([]string, <-chan zk.Event, error) {
 	return m.MockZookeeperClient.ChildrenW(path)
 }
 
+func (m *MockZkConn) Get(path string) ([]byte, error) {
+	return m.MockZookeeperClient.Get(path)
+}
+
 func (m *MockZkConn) LoadData(path string) error {
 	return m.MockZookeeperClient.Load(path)
 }
@@ -6,20 +6,11 @@ import (
 	"istio.io/libistio/pkg/config/resource"
 )
 
-const (
-	wildcardNamespace = "*"
-)
-
 type ServiceEntryWithMeta struct {
 	ServiceEntry *networkingapi.ServiceEntry
 	Meta         resource.Metadata
 }
 
-type SidecarWithMeta struct {
-	Sidecar *networkingapi.Sidecar
-	Meta    resource.Metadata
-}
-
 func strMapEquals(m1, m2 resource.StringMap) bool {
 	if len(m1) != len(m2) {
 		return false
@@ -53,14 +44,6 @@ func (sem ServiceEntryWithMeta) Equals(o ServiceEntryWithMeta) bool {
 	return proto.Equal(sem.ServiceEntry, o.ServiceEntry)
 }
 
-func (scm SidecarWithMeta) Equals(o SidecarWithMeta) bool {
-	if !metadataEquals(scm.Meta, o.Meta) {
-		return false
-	}
-
-	return proto.Equal(scm.Sidecar, o.Sidecar)
-}
-
 type DubboServiceInstance struct {
 	Name                string                 `json:"name"`
 	Id                  string                 `json:"id"`
@@ -6,6 +6,7 @@ import (
 
 	"slime.io/slime/framework/util"
 	"slime.io/slime/modules/meshregistry/pkg/monitoring"
+	"slime.io/slime/modules/meshregistry/pkg/source"
 )
 
 func (s *Source) Polling() {
@@ -31,16 +32,30 @@ func (s *Source) refresh() {
 	log.Infof("zk refresh start : %d", t0.UnixNano())
 	if err := s.updateServiceInfo(); err != nil {
 		monitoring.RecordPolling(SourceName, t0, time.Now(), false)
+		source.RecordRegistrySync(SourceName, "", 0, 0, err)
 		log.Errorf("nacos update service info failed: %v", err)
 		return
 	}
 	t1 := time.Now()
 	log.Infof("zk refresh finish : %d", t1.UnixNano())
 	monitoring.RecordPolling(SourceName, t0, t1, true)
+	s.recordRegistrySync()
 	s.markServiceEntryInitDone()
 }
 
 func (s *Source) updateServiceInfo() error {
+	if s.args.DiscoverInterfaces() {
+		if err := s.updateInterfaceServiceInfo(); err != nil {
+			return err
+		}
+	}
+	if s.args.DiscoverApplications() {
+		return s.updateAppServiceInfo()
+	}
+	return nil
+}
+
+func (s *Source) updateInterfaceServiceInfo() error {
 	interfaces, err := s.Con.Children(s.args.RegistryRootNode)
 	monitoring.RecordSourceClientRequest(SourceName, err == nil)
 	if err != nil {
@@ -38,18 +38,14 @@ import (
 const (
 	SourceName = "zookeeper"
 
-	ZkPath                    = "/zk"
-	ZkSimplePath              = "/zks"
-	DubboCallModelPath        = "/dubboCallModel"
-	SidecarDubboCallModelPath = "/sidecarDubboCallModel"
+	ZkPath       = "/zk"
+	ZkSimplePath = "/zks"
 
 	ProviderNode       = "providers"
 	ConfiguratorNode   = "configurators"
 	providerPathSuffix = "/" + ProviderNode
 	configuratorSuffix = "/" + ConfiguratorNode
 
-	AttachmentDubboCallModel = "ATTACHMENT_DUBBO_CALL_MODEL"
-
 	defaultServiceFilter = ""
 )
 
@@ -60,6 +56,7 @@ type ZkConn interface {
 	Load() any
 	Children(string) ([]string, error)
 	ChildrenW(string) ([]string, <-chan zk.Event, error)
+	Get(string) ([]byte, error)
 }
 
 type zkConn struct {
@@ -86,6 +83,12 @@ func (z *zkConn) ChildrenW(path string) ([]string, <-chan zk.Event, error) {
 	return children, c, err
 }
 
+func (z *zkConn) Get(path string) ([]byte, error) {
+	data, _, err := z.conn.Load().(*zk.Conn).Get(path)
+	monitoring.RecordSourceClientRequest(SourceName, err == nil)
+	return data, err
+}
+
 func init() {
 	source.RegisterSourceInitlizer(SourceName, source.RegistrySourceInitlizer(New))
 }
@@ -101,22 +104,23 @@ type Source struct {
 
 	registryServiceCache cmap.ConcurrentMap[string, cmap.ConcurrentMap[string, []dubboInstance]]
 	cache                cmap.ConcurrentMap[string, cmap.ConcurrentMap[string, *ServiceEntryWithMeta]]
+	// appCache is the cache of the app-level ServiceEntries keyed by app name.
+	appCache cmap.ConcurrentMap[string, *ServiceEntryWithMeta]
+	// appMetadata is the metadata of the app revisions in use, keyed by app and revision. Guarded by mut.
+	appMetadata map[string]map[string]*dubboMetadataInfo
 
-	sidecarCache         map[resource.FullName]SidecarWithMeta
-	dubboCallModels      map[string]DubboCallModel // can only be replaced rather than being modified
-	seDubboCallModels    map[resource.FullName]map[string]DubboCallModel
-	changedApps          map[string]struct{}
-	appSidecarUpdateTime map[string]time.Time
-	dubboPortsCache      map[uint32]*networkingapi.Port
+	// seDubboCallModels is the call models derived from each ServiceEntry, which are contributed to the shared
+	// dubbo `Sidecar` generation on change. Guarded by mut.
+	seDubboCallModels map[resource.FullName]map[string]source.DubboCallModel
+	dubboPortsCache   map[uint32]*networkingapi.Port
 
 	handlers       []event.Handler
 	initedCallback func(string)
 	mut            sync.RWMutex
 
-	seInitCh               chan struct{}
-	initWg                 sync.WaitGroup
-	refreshSidecarNotifyCh chan struct{}
-	stop                   chan struct{}
+	seInitCh chan struct{}
+	initWg   sync.WaitGroup
+	stop     chan struct{}
 
 	Con               ZkConn
 	seMergePortMocker *source.ServiceEntryMergePortMocker
@@ -132,6 +136,14 @@ type Source struct {
 	methodLBChecker func(*convertedServiceEntry) bool
 
 	forceUpdateTrigger *atomic.Value // store chan struct{}
+
+	// warmupRefresh re-evaluates the weights of the warming up endpoints in watching mode, guarded by mut.
+	warmupRefresh   *time.Timer
+	warmupRefreshAt time.Time
+	// warmingServices is the next refresh of each interface having warming up endpoints, guarded by mut.
+	warmingServices map[string]time.Time
+	// refreshService has the watcher of the interface re-handle its latest data, guarded by mut.
+	refreshService func(iface string)
 }
 
 func New(
@@ -161,33 +173,24 @@ func New(
 	}
 
 	src := &Source{
-		args:                   args,
-		ignoreLabelsMap:        ignoreLabels,
-		initedCallback:         readyCallback,
-		serviceMethods:         map[string]string{},
-		registryServiceCache:   cmap.New[cmap.ConcurrentMap[string, []dubboInstance]](),
-		cache:                  cmap.New[cmap.ConcurrentMap[string, *ServiceEntryWithMeta]](),
-		seDubboCallModels:      map[resource.FullName]map[string]DubboCallModel{},
-		appSidecarUpdateTime:   map[string]time.Time{},
-		dubboPortsCache:        map[uint32]*networkingapi.Port{},
-		seInitCh:               make(chan struct{}),
-		stop:                   make(chan struct{}),
-		refreshSidecarNotifyCh: make(chan struct{}, 1),
-		Con:                    &zkConn{},
-		seMergePortMocker:      svcMocker,
-		forceUpdateTrigger:     &atomic.Value{},
+		args:                 args,
+		ignoreLabelsMap:      ignoreLabels,
+		initedCallback:       readyCallback,
+		serviceMethods:       map[string]string{},
+		registryServiceCache: cmap.New[cmap.ConcurrentMap[string, []dubboInstance]](),
+		cache:                cmap.New[cmap.ConcurrentMap[string, *ServiceEntryWithMeta]](),
+		appCache:             cmap.New[*ServiceEntryWithMeta](),
+		seDubboCallModels:    map[resource.FullName]map[string]source.DubboCallModel{},
+		dubboPortsCache:      map[uint32]*networkingapi.Port{},
+		seInitCh:             make(chan struct{}),
+		stop:                 make(chan struct{}),
+		Con:                  &zkConn{},
+		seMergePortMocker:    svcMocker,
+		forceUpdateTrigger:   &atomic.Value{},
 	}
 	src.forceUpdateTrigger.Store(make(chan struct{}))
 
-	src.handlers = append(
-		src.handlers,
-		event.HandlerFromFn(src.serviceEntryHandlerRefreshSidecar),
-	)
-
 	src.initWg.Add(1) // ServiceEntry init-sync ready
-	if args.EnableDubboSidecar {
-		src.initWg.Add(1) // Sidecar init-sync ready
-	}
 	if src.seMergePortMocker != nil {
 		src.handlers = append(src.handlers, src.seMergePortMocker)
 		src.seMergePortMocker.SetDispatcher(src.dispatchMergePortsServiceEntry)
@@ -205,10 +208,11 @@ func New(
 	}
 
 	debugHandler := map[string]http.HandlerFunc{
-		ZkPath:                    src.cacheJson,
-		ZkSimplePath:              src.simpleCacheJson,
-		DubboCallModelPath:        src.HandleDubboCallModel,
-		SidecarDubboCallModelPath: src.HandleSidecarDubboCallModel,
+		ZkPath:       src.cacheJson,
+		ZkSimplePath: src.simpleCacheJson,
+	}
+	if args.DiscoverApplications() {
+		debugHandler[ZkAppPath] = src.appCacheJson
 	}
 
 	return src, debugHandler, args.LabelPatch, false, nil
@@ -216,7 +220,7 @@ func New(
 
 func (s *Source) dispatchMergePortsServiceEntry(meta resource.Metadata, se *networkingapi.ServiceEntry) {
 	prepared, _ := prepareServiceEntryWithMeta(se, meta)
-	ev, err := buildServiceEntryEvent(event.Updated, prepared.ServiceEntry, prepared.Meta, false)
+	ev, err := buildServiceEntryEvent(event.Updated, prepared.ServiceEntry, prepared.Meta, nil)
 	if err != nil {
 		log.Errorf("buildSeEvent met err %v", err)
 		return
@@ -237,6 +241,7 @@ func (l zkLogger) Printf(format string, args ...interface{}) {
 
 func (s *Source) reConFunc(reconCh chan<- struct{}) {
 	monitoring.RecordSourceConnectionStatus(SourceName, false)
+	source.RecordRegistryConnection(SourceName, "", false, nil)
 
 	// TODO: use the zk.Conn.hostProvider.Len() replace the len(s.args.Address)?
 	connectTimeout := time.Duration(len(s.args.Address)+1) * time.Second
@@ -248,6 +253,7 @@ func (s *Source) reConFunc(reconCh chan<- struct{}) {
 		if curConn.State() == zk.StateHasSession {
 			log.Infof("the state of zk conn %p is ok with sessionID: %d", curConn, curConn.SessionID())
 			monitoring.RecordSourceConnectionStatus(SourceName, true)
+			source.RecordRegistryConnection(SourceName, "", true, nil)
 			return
 		}
 		curConn.Close()
@@ -288,6 +294,7 @@ func (s *Source) reConFunc(reconCh chan<- struct{}) {
 			}))
 		if err != nil {
 			log.Infof("connect zk error %v", err)
+			source.RecordRegistryConnection(SourceName, "", false, err)
 			time.Sleep(time.Second)
 		} else {
 			// TODO: this should be done in go-zk
@@ -308,13 +315,24 @@ func (s *Source) reConFunc(reconCh chan<- struct{}) {
 				default:
 				}
 			}
+			if connected && s.args.Username != "" {
+				if err := con.AddAuth("digest", []byte(s.args.Username+":"+s.args.Password)); err != nil {
+					log.Errorf("zk conn %s add digest auth failed: %v", logCon(), err)
+					source.RecordRegistryConnection(SourceName, "", false, fmt.Errorf("add digest auth failed: %w", err))
+					con.Close()
+					time.Sleep(time.Second)
+					continue
+				}
+			}
 			if connected {
 				// replace the connection
 				s.Con.Store(con)
 				log.Infof("zk conn %s connect to zk successfully with sessionID: %d", logCon(), con.SessionID())
 				monitoring.RecordSourceConnectionStatus(SourceName, true)
+				source.RecordRegistryConnection(SourceName, "", true, nil)
 				break
 			}
+			source.RecordRegistryConnection(SourceName, "", false, fmt.Errorf("connect timeout"))
 		}
 	}
 }
@@ -469,17 +487,8 @@ func (s *Source) Start() {
 				s.seMergePortMocker.Refresh()
 				s.initWg.Done()
 			}
-
-			if s.args.EnableDubboSidecar {
-				log.Infof("%s service entry init done, begin to do init sidecar refresh", SourceName)
-				s.refreshSidecar(true)
-				s.markSidecarInitDone()
-			}
 		}
 
-		if s.args.EnableDubboSidecar {
-			go s.refreshSidecarTask(s.stop)
-		}
 		if s.seMergePortMocker != nil {
 			go s.seMergePortMocker.Start(s.stop)
 		}
@@ -508,6 +517,20 @@ func (s *Source) cacheInfo(iface string) interface{} {
 	return info
 }
 
+// recordRegistrySync records the counts of the cached services and instances as a successful sync.
+func (s *Source) recordRegistrySync() {
+	services, instances := 0, 0
+	count := func(_ string, sem *ServiceEntryWithMeta) {
+		services++
+		instances += len(sem.ServiceEntry.Endpoints)
+	}
+	s.cache.IterCb(func(_ string, ses cmap.ConcurrentMap[string, *ServiceEntryWithMeta]) {
+		ses.IterCb(count)
+	})
+	s.appCache.IterCb(count)
+	source.RecordRegistrySync(SourceName, "", services, instances, nil)
+}
+
 func (s *Source) cacheSummary() map[string]interface{} {
 	info := make(map[string]interface{}, 0)
 	count := 0
@@ -536,6 +559,9 @@ func (s *Source) ServiceEntries() []*networkingapi.ServiceEntry {
 			ret = append(ret, sem.ServiceEntry)
 		}
 	}
+	for _, sem := range s.appCache.Items() {
+		ret = append(ret, sem.ServiceEntry)
+	}
 
 	return ret
 }
@@ -547,6 +573,10 @@ func (s *Source) ServiceEntry(fullName resource.FullName) *networkingapi.Service
 
 	ses, ok := s.cache.Get(service)
 	if !ok {
+		// maybe an app-level one
+		if sem, ok := s.appCache.Get(serviceKey); ok {
+			return sem.ServiceEntry
+		}
 		return nil
 	}
 
@@ -678,7 +708,7 @@ func (s *Source) handleServiceDelete(iface string, ignoredSes frameworkutil.Set[
 			seValueCopy.ServiceEntry = &seCopy
 			ses.Set(se, &seValueCopy)
 			sem = &seValueCopy
-			event, err := buildServiceEntryEvent(event.Updated, sem.ServiceEntry, sem.Meta, false)
+			event, err := buildServiceEntryEvent(event.Updated, sem.ServiceEntry, sem.Meta, nil)
 			if err != nil {
 				log.Errorf("delete(update) svc %s failed, case: %v", se, err.Error())
 			} else {
@@ -705,11 +735,80 @@ func (s *Source) handleServiceData(providers, consumers, configutators []string,
 		ignoreLabels:          s.ignoreLabelsMap,
 		hostSuffix:            s.args.HostSuffix,
 		filter:                s.getInstanceFilter(),
+		healthMapper:          source.NewEndpointHealthMapper(s.args.EndpointHealth),
 	}
 	opts.protocol, opts.protocolName = source.ProtocolName(s.args.SvcProtocol, s.args.GenericProtocol)
 	freshSvcMap, freshSeMap := s.convertServiceEntry(providers, consumers, configutators, dubboInterface, opts)
 	s.updateRegistryServiceCache(dubboInterface, freshSvcMap)
 	s.updateSeCache(freshSeMap, dubboInterface)
+	if !s.isPollingMode() {
+		s.scheduleWarmupRefresh(dubboInterface, opts.healthMapper.NextRefresh())
+	}
+}
+
+// scheduleWarmupRefresh records the next refresh of the interface to re-evaluate the weights of its warming up
+// endpoints, as nothing else re-converts the unchanged services in watching mode. Zero `at` means the interface
+// is not warming up any more.
+func (s *Source) scheduleWarmupRefresh(iface string, at time.Time) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if at.IsZero() {
+		delete(s.warmingServices, iface)
+		return
+	}
+	if s.warmingServices == nil {
+		s.warmingServices = map[string]time.Time{}
+	}
+	s.warmingServices[iface] = at
+	s.armWarmupRefreshLocked(at)
+}
+
+// armWarmupRefreshLocked keeps the timer firing at the earliest refresh, must be called with mut held.
+func (s *Source) armWarmupRefreshLocked(at time.Time) {
+	if s.warmupRefresh != nil && !s.warmupRefreshAt.After(at) {
+		return
+	}
+	if s.warmupRefresh != nil {
+		s.warmupRefresh.Stop()
+	}
+	s.warmupRefreshAt = at
+	s.warmupRefresh = time.AfterFunc(time.Until(at), s.refreshWarmingServices)
+}
+
+// refreshWarmingServices has the interfaces due to refresh re-handled by their watchers with the latest data,
+// instead of re-listing all the interfaces from zk.
+func (s *Source) refreshWarmingServices() {
+	var (
+		now  = time.Now()
+		due  []string
+		next time.Time
+	)
+	s.mut.Lock()
+	s.warmupRefresh = nil
+	for iface, at := range s.warmingServices {
+		if at.After(now) {
+			if next.IsZero() || at.Before(next) {
+				next = at
+			}
+			continue
+		}
+		// recorded again by the re-handling if still warming up
+		delete(s.warmingServices, iface)
+		due = append(due, iface)
+	}
+	if !next.IsZero() {
+		s.armWarmupRefreshLocked(next)
+	}
+	refresh := s.refreshService
+	s.mut.Unlock()
+
+	if refresh == nil || len(due) == 0 {
+		return
+	}
+	log.Infof("zk source refresh the weights of the warming up endpoints of %v", due)
+	for _, iface := range due {
+		refresh(iface)
+	}
 }
 
 func (s *Source) updateRegistryServiceCache(dubboInterface string, freshSvcMap map[string][]dubboInstance) {
@@ -782,29 +881,26 @@ func (s *Source) updateSeCache(freshSeMap map[string]*convertedServiceEntry, dub
 			continue
 		}
 
-		var preCallModel map[string]DubboCallModel
-		interfaceName := se.Hosts[0]
-		if s.args.HostSuffix != "" {
-			interfaceName = strings.TrimSuffix(interfaceName, s.args.HostSuffix)
-		}
-		callModel := convertDubboCallModel(se, interfaceName, convertedSe.InboundEndPoints)
-		s.mut.Lock()
-		preCallModel, s.seDubboCallModels[meta.FullName] = s.seDubboCallModels[meta.FullName], callModel
-		changedApps := calcChangedApps(preCallModel, callModel)
-		attachCallModel := len(changedApps) > 0
-		if attachCallModel {
-			if s.changedApps == nil {
-				s.changedApps = map[string]struct{}{}
+		var callModel map[string]source.DubboCallModel
+		if s.args.EnableDubboSidecar {
+			var preCallModel map[string]source.DubboCallModel
+			interfaceName := se.Hosts[0]
+			if s.args.HostSuffix != "" {
+				interfaceName = strings.TrimSuffix(interfaceName, s.args.HostSuffix)
 			}
-			for _, app := range changedApps {
-				s.changedApps[app] = struct{}{}
+			callModel = source.ConvertDubboCallModel(se, interfaceName, convertedSe.InboundEndPoints, dubboSvcAppLabel)
+			s.mut.Lock()
+			preCallModel, s.seDubboCallModels[meta.FullName] = s.seDubboCallModels[meta.FullName], callModel
+			s.mut.Unlock()
+			if len(source.CalcChangedApps(preCallModel, callModel)) == 0 {
+				callModel = nil
 			}
 		}
-		s.mut.Unlock()
+		attachCallModel := callModel != nil
 
 		if oldSem, exist := interfaceSeCache.Get(serviceKey); !exist {
 			interfaceSeCache.Set(serviceKey, newMetaSe)
-			ev, err := buildServiceEntryEvent(event.Added, newMetaSe.ServiceEntry, newMetaSe.Meta, attachCallModel)
+			ev, err := buildServiceEntryEvent(event.Added, newMetaSe.ServiceEntry, newMetaSe.Meta, callModel)
 			if err == nil {
 				log.Infof("add zk se, hosts: %s, ep size: %d ",
 					newMetaSe.ServiceEntry.Hosts[0], len(newMetaSe.ServiceEntry.Endpoints))
@@ -818,7 +914,7 @@ func (s *Source) updateSeCache(freshSeMap map[string]*convertedServiceEntry, dub
 				continue
 			}
 			interfaceSeCache.Set(serviceKey, newMetaSe)
-			ev, err := buildServiceEntryEvent(event.Updated, newMetaSe.ServiceEntry, newMetaSe.Meta, attachCallModel)
+			ev, err := buildServiceEntryEvent(event.Updated, newMetaSe.ServiceEntry, newMetaSe.Meta, callModel)
 			if err == nil {
 				log.Infof("update zk se, hosts: %s, ep size: %d ",
 					newMetaSe.ServiceEntry.Hosts[0], len(newMetaSe.ServiceEntry.Endpoints))
@@ -905,33 +1001,28 @@ func prepareServiceEntryWithMeta(se *networkingapi.ServiceEntry, meta resource.M
 }
 
 // buildServiceEntryEvent assembled the incoming data into an event. Event handle should not modify the data.
+// The call models are attached only if not nil, which means they changed.
 func buildServiceEntryEvent(
 	kind event.Kind,
 	se *networkingapi.ServiceEntry,
 	meta resource.Metadata,
-	attachCallModel bool,
+	callModels map[string]source.DubboCallModel,
 ) (event.Event, error) {
-	return event.Event{
+	ev := event.Event{
 		Kind:   kind,
 		Source: collections.ServiceEntry,
-		Resource: &resource.Instance{
-			Metadata:    meta,
-			Message:     se,
-			Attachments: map[string]interface{}{AttachmentDubboCallModel: attachCallModel},
-		},
-	}, nil
-}
-
-// buildSidecarEvent assembled the incoming data into an event. Event handle should not modify the data.
-func buildSidecarEvent(kind event.Kind, item *networkingapi.Sidecar, meta resource.Metadata) event.Event {
-	meta = meta.Clone()
-	source.FillRevision(&meta)
-	return event.Event{
-		Kind:   kind,
-		Source: collections.Sidecar,
 		Resource: &resource.Instance{
 			Metadata: meta,
-			Message:  item,
+			Message:  se,
 		},
 	}
+	if callModels != nil {
+		ev = source.AttachDubboCallModels(ev, callModels)
+	}
+	return ev, nil
+}
+
+// ContributeDubboCallModel implements source.DubboCallModelContributor.
+func (s *Source) ContributeDubboCallModel() bool {
+	return s.args.EnableDubboSidecar
 }
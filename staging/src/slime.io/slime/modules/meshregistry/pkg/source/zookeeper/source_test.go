@@ -105,10 +105,10 @@ func TestUpdateServiceInfo(t *testing.T) {
 		s := &Source{
 			args:                 args,
 			serviceMethods:       map[string]string{},
-			seDubboCallModels:    map[resource.FullName]map[string]DubboCallModel{},
-			appSidecarUpdateTime: map[string]time.Time{},
+			seDubboCallModels:    map[resource.FullName]map[string]source.DubboCallModel{},
 			registryServiceCache: cmap.New[cmap.ConcurrentMap[string, []dubboInstance]](),
 			cache:                cmap.New[cmap.ConcurrentMap[string, *ServiceEntryWithMeta]](),
+			appCache:             cmap.New[*ServiceEntryWithMeta](),
 		}
 		if args.MockServiceEntryName != "" && args.MockServiceName != "" {
 			s.seMergePortMocker = source.NewServiceEntryMergePortMocker(
@@ -281,6 +281,28 @@ func TestUpdateServiceInfo(t *testing.T) {
 				RegistryRootNode: "/dubbo",
 			}),
 		},
+		{
+			name: "application_level_migrating",
+			data: []testData{
+				{
+					in:     "./testdata/application.yaml",
+					expect: "./testdata/application.expected.yaml",
+				},
+			},
+			s: newSource(&bootstrap.ZookeeperSourceArgs{
+				SourceArgs: bootstrap.SourceArgs{
+					SvcProtocol:           "DUBBO",
+					InstancePortAsSvcPort: true,
+					ResourceNs:            "dubbo",
+					DefaultServiceNs:      "dubbo",
+				},
+				RegistryRootNode:            "/dubbo",
+				ApplicationRegisterRootNode: "/services",
+				MappingRootNode:             "/dubbo/mapping",
+				MetadataRootNode:            "/dubbo/metadata",
+				RegisterMode:                bootstrap.DubboRegisterModeAll,
+			}),
+		},
 		// TODO: add test cases for:
 		// - instance filter
 		// - instance meta modifier
@@ -288,11 +310,18 @@ func TestUpdateServiceInfo(t *testing.T) {
 		// - ...
 	}
 
+	var sidecarGenerator *source.DubboSidecarGenerator
 	initTest := func(s *Source) {
 		mockClient.Reset()
 		s.Con = mockClient
 		assertHandler.Reset()
 		s.Dispatch(assertHandler)
+		sidecarGenerator = nil
+		if s.ContributeDubboCallModel() {
+			sidecarGenerator = source.NewDubboSidecarGenerator(s.args.DubboSidecarArgs(), nil)
+			s.Dispatch(sidecarGenerator.HandlerFor(SourceName))
+			sidecarGenerator.Dispatch(assertHandler)
+		}
 	}
 
 	for _, tt := range args {
@@ -311,8 +340,8 @@ func TestUpdateServiceInfo(t *testing.T) {
 					assert.NoError(t, assertHandler.LoadExpected(d.expect))
 				}
 				assert.NoError(t, tt.s.updateServiceInfo())
-				if tt.s.args.EnableDubboSidecar {
-					tt.s.refreshSidecar(false)
+				if sidecarGenerator != nil {
+					sidecarGenerator.Refresh(false)
 				}
 				if tt.s.seMergePortMocker != nil {
 					tt.s.seMergePortMocker.Refresh()
@@ -323,3 +352,39 @@ func TestUpdateServiceInfo(t *testing.T) {
 		})
 	}
 }
+
+func TestScheduleWarmupRefresh(t *testing.T) {
+	refreshed := make(chan string, 3)
+	s := &Source{refreshService: func(iface string) { refreshed <- iface }}
+
+	s.scheduleWarmupRefresh("a", time.Now().Add(time.Hour))
+	s.scheduleWarmupRefresh("b", time.Now().Add(10*time.Millisecond))
+	s.scheduleWarmupRefresh("c", time.Now().Add(20*time.Millisecond))
+	// c is not warming up any more
+	s.scheduleWarmupRefresh("c", time.Time{})
+
+	select {
+	case iface := <-refreshed:
+		if iface != "b" {
+			t.Fatalf("refreshed %s, want b", iface)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("warming up service not refreshed")
+	}
+	select {
+	case iface := <-refreshed:
+		t.Fatalf("unexpected refresh of %s", iface)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	// only the services due are refreshed, the timer is re-armed for the rest
+	if _, ok := s.warmingServices["a"]; !ok || len(s.warmingServices) != 1 {
+		t.Errorf("warming services %v, want only a", s.warmingServices)
+	}
+	if s.warmupRefresh == nil || !s.warmupRefreshAt.Equal(s.warmingServices["a"]) {
+		t.Errorf("warmup refresh should be re-armed at the refresh of a")
+	}
+	s.warmupRefresh.Stop()
+}
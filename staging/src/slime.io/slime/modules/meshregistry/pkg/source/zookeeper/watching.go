@@ -27,6 +27,8 @@ var (
 		"Jitter time window for doing forced updates, default to 1 minute",
 	).Get()
 
+	watchingStatusReportPeriod = 30 * time.Second
+
 	cronOption = cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor
 )
 
@@ -42,6 +44,8 @@ func jitter(period time.Duration) time.Duration {
 func (s *Source) ServiceNodeDelete(path string) {
 	ss := strings.Split(path, "/")
 	service := ss[len(ss)-2]
+	// keyed the same as EndpointUpdate
+	s.scheduleWarmupRefresh(strings.Split(path, "/")[2], time.Time{})
 	s.handleServiceDelete(service, util.NewSet[string]())
 }
 
@@ -94,8 +98,38 @@ func (s *Source) Watching() {
 		c.Stop()
 	}()
 
-	sw.Start(ctx)
+	if s.args.DiscoverApplications() {
+		// there is no need to watch the app instances as the app-level data is far less than the interface-level.
+		if err := s.updateAppServiceInfo(); err != nil {
+			log.Errorf("zk update app service info failed: %v", err)
+		}
+		go s.pollingApps()
+	}
+	if s.args.DiscoverInterfaces() {
+		s.mut.Lock()
+		s.refreshService = sw.RefreshService
+		s.mut.Unlock()
+		sw.Start(ctx)
+	}
 	s.markServiceEntryInitDone()
+	go s.reportWatchingStatus(ctx)
+}
+
+// reportWatchingStatus periodically records the registry status in watching mode, where the cache is kept in sync
+// with zk as long as the session is alive.
+func (s *Source) reportWatchingStatus(ctx context.Context) {
+	ticker := time.NewTicker(watchingStatusReportPeriod)
+	defer ticker.Stop()
+	for {
+		if con, ok := s.Con.Load().(*zk.Conn); ok && con.State() == zk.StateHasSession {
+			s.recordRegistrySync()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
 
 type EventType int
@@ -103,6 +137,8 @@ type EventType int
 const (
 	EventTypeCreate EventType = iota
 	EventTypeDelete
+	// EventTypeRefresh has the endpoint watcher re-handle its latest data
+	EventTypeRefresh
 )
 
 type ServiceEvent struct {
@@ -135,6 +171,7 @@ func NewEndpointWatcher(servicePath string, opts EndpointWatcherOpts) *EndpointW
 		},
 		signalExit:         make(chan struct{}),
 		exit:               make(chan struct{}),
+		refresh:            make(chan struct{}, 1),
 		initCallback:       opts.initCallbackFactory(),
 		watchConfigurators: opts.watchConfigurators,
 		debounceConfig:     opts.debounceConfig,
@@ -160,6 +197,8 @@ type EndpointWatcher struct {
 	watchConfigurators         bool
 
 	signalExit, exit chan struct{}
+	// refresh signals to re-handle the latest data
+	refresh chan struct{}
 
 	initCallback func()
 
@@ -173,6 +212,14 @@ func (ew *EndpointWatcher) Start(ctx context.Context) {
 	go ew.watchService(ctx, ew.providerPath, ew.consumerPath)
 }
 
+// Refresh has the watcher re-handle the latest data without watching again, a pending one covers the later ones.
+func (ew *EndpointWatcher) Refresh() {
+	select {
+	case ew.refresh <- struct{}{}:
+	default:
+	}
+}
+
 func (ew *EndpointWatcher) Exit() {
 	close(ew.signalExit)
 	// wait for exit
@@ -325,6 +372,7 @@ func (ew *EndpointWatcher) watchService(ctx context.Context, providerPath, consu
 			if item.err == nil {
 				configuratorCache = item.data
 			}
+		case <-ew.refresh:
 		case <-ew.signalExit:
 			ew.serviceDeleteHandler()
 			log.Infof("endpointWatcher %q exit due to service deleted", ew.servicePath)
@@ -420,6 +468,10 @@ func (w *worker) processItem(ctx context.Context) bool {
 		w.cache.Remove(e.path)
 		return true
 	}
+	if e.etype == EventTypeRefresh && ok {
+		ew.Refresh()
+		return true
+	}
 	if e.etype == EventTypeCreate && !ok {
 		ew := NewEndpointWatcher(e.path, w.epWatcherOpts)
 		w.cache.Set(e.path, ew)
@@ -449,6 +501,9 @@ type ServiceWatcher struct {
 
 	workers []*worker
 
+	// workersMut guards workers, which are created on demand
+	workersMut sync.Mutex
+
 	initLock               sync.Mutex
 	initWait               sync.WaitGroup
 	initCnt, initThreshold int
@@ -550,6 +605,8 @@ func (sw *ServiceWatcher) handleSvcs(svcs []string) {
 }
 
 func (sw *ServiceWatcher) dispatch(e ServiceEvent) {
+	sw.workersMut.Lock()
+	defer sw.workersMut.Unlock()
 	workerIdx := fnv32(e.path) % uint32(len(sw.workers))
 	if sw.workers[workerIdx] == nil {
 		sw.workers[workerIdx] = NewServiceWorker(EndpointWatcherOpts{
@@ -568,6 +625,17 @@ func (sw *ServiceWatcher) dispatch(e ServiceEvent) {
 	sw.workers[workerIdx].HandleEvent(e)
 }
 
+// RefreshService has the endpoint watcher of the service re-handle its latest data, which does not list anything
+// from zk. Nothing happens if the service is not watched.
+func (sw *ServiceWatcher) RefreshService(service string) {
+	path := filepath.Join(sw.rootPath, service)
+	sw.workersMut.Lock()
+	defer sw.workersMut.Unlock()
+	if w := sw.workers[fnv32(path)%uint32(len(sw.workers))]; w != nil {
+		w.HandleEvent(ServiceEvent{path: path, etype: EventTypeRefresh})
+	}
+}
+
 func (sw *ServiceWatcher) initCallbackFactory() func() {
 	sw.initLock.Lock()
 	defer sw.initLock.Unlock()
@@ -20,6 +20,7 @@ import (
 	"context"
 
 	networkingv1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -30,8 +31,11 @@ import (
 
 	"slime.io/slime/framework/bootstrap"
 	"slime.io/slime/framework/model"
+	"slime.io/slime/framework/model/event"
+	"slime.io/slime/framework/monitoring"
 	"slime.io/slime/modules/plugin/api/config"
 	pluginv1alpha1 "slime.io/slime/modules/plugin/api/v1alpha1"
+	modmodel "slime.io/slime/modules/plugin/model"
 )
 
 // EnvoyPluginReconciler reconciles a EnvoyPlugin object
@@ -40,17 +44,25 @@ type EnvoyPluginReconciler struct {
 	Scheme *runtime.Scheme
 	Env    *bootstrap.Environment
 	Cfg    *config.PluginModule
+	// EventRecorder records the events on the envoyplugins, nil to skip
+	EventRecorder event.Recorder
+}
+
+func recordEvent(recorder event.Recorder, obj runtime.Object, eventType, reason, messageFmt string, args ...interface{}) {
+	if recorder != nil {
+		recorder.Eventf(obj, eventType, reason, messageFmt, args...)
+	}
 }
 
 //nolint: lll
 // +kubebuilder:rbac:groups=microservice.slime.io.my.domain,resources=envoyplugins,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=microservice.slime.io.my.domain,resources=envoyplugins/status,verbs=get;update;patch
 
-func (r *EnvoyPluginReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *EnvoyPluginReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
 	EnvoypluginReconciles.Increment()
 	// Fetch the EnvoyPlugin instance
 	instance := &pluginv1alpha1.EnvoyPlugin{}
-	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	err = r.Client.Get(ctx, req.NamespacedName, instance)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			return reconcile.Result{}, nil
@@ -82,6 +94,10 @@ func (r *EnvoyPluginReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	model.PatchObjectMeta(&ef.ObjectMeta, &instance.ObjectMeta)
 	model.PatchIstioRevLabel(&ef.Labels, istioRev)
 
+	ctx, span := monitoring.StartSpan(ctx, modmodel.ModuleName, "ApplyEnvoyFilter",
+		monitoring.ResourceAttributes(ef.Namespace, ef.Name)...)
+	defer func() { monitoring.EndSpan(span, err) }()
+
 	found := &networkingv1alpha3.EnvoyFilter{}
 	err = r.Client.Get(ctx, types.NamespacedName{Name: ef.Name, Namespace: ef.Namespace}, found)
 	if err != nil {
@@ -99,9 +115,13 @@ func (r *EnvoyPluginReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		if err != nil {
 			log.Errorf("create new EnvoyFilter %s/%s met err %v", ef.Namespace, ef.Name, err)
 			EnvoypluginReconcilesFailed.Increment()
+			recordEvent(r.EventRecorder, instance, corev1.EventTypeWarning, "EnvoyFilterCreateFailed",
+				"create envoyfilter %s/%s failed: %v", ef.Namespace, ef.Name, err)
 			return reconcile.Result{}, err
 		}
 		EnvoyfilterCreations.With(resourceName.Value("envoyplugin")).Increment()
+		recordEvent(r.EventRecorder, instance, corev1.EventTypeNormal, "PluginTranslated",
+			"translated into new envoyfilter %s/%s", ef.Namespace, ef.Name)
 		log.Infof("create a new EnvoyFilter %s/%s", ef.Namespace, ef.Name)
 	} else if foundRev := model.IstioRevFromLabel(found.Labels); !r.Env.RevInScope(foundRev) {
 		log.Debugf("existed envoyfilter %v istioRev %s but our rev %s, skip updating to %+v",
@@ -112,9 +132,13 @@ func (r *EnvoyPluginReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		err := r.Client.Update(ctx, ef)
 		if err != nil {
 			EnvoypluginReconcilesFailed.Increment()
+			recordEvent(r.EventRecorder, instance, corev1.EventTypeWarning, "EnvoyFilterUpdateFailed",
+				"update envoyfilter %s/%s failed: %v", ef.Namespace, ef.Name, err)
 			return reconcile.Result{}, err
 		}
 		EnvoyfilterRefreshes.With(resourceName.Value("envoyplugin")).Increment()
+		recordEvent(r.EventRecorder, instance, corev1.EventTypeNormal, "PluginTranslated",
+			"translated into envoyfilter %s/%s", ef.Namespace, ef.Name)
 		log.Infof("update a EnvoyFilter %s/%s", ef.Namespace, ef.Name)
 	}
 
@@ -127,6 +151,7 @@ func (r *EnvoyPluginReconciler) newEnvoyFilterForEnvoyPlugin(cr *pluginv1alpha1.
 	envoyFilterWrapper, err := translateOutputToEnvoyFilterWrapper(out)
 	if err != nil {
 		log.Errorf("translateOutputToEnvoyFilterWrapper for envoyfilter %s/%s met err %v", cr.Namespace, cr.Name, err)
+		recordEvent(r.EventRecorder, cr, corev1.EventTypeWarning, "PluginTranslateFailed", "%v", err)
 	}
 	envoyFilterWrapper.Name, envoyFilterWrapper.Namespace = cr.Name, cr.Namespace
 
@@ -22,6 +22,7 @@ import (
 
 	"google.golang.org/protobuf/types/known/structpb"
 	networkingv1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -33,8 +34,11 @@ import (
 
 	"slime.io/slime/framework/bootstrap"
 	"slime.io/slime/framework/model"
+	"slime.io/slime/framework/model/event"
+	"slime.io/slime/framework/monitoring"
 	"slime.io/slime/modules/plugin/api/config"
 	pluginv1alpha1 "slime.io/slime/modules/plugin/api/v1alpha1"
+	modmodel "slime.io/slime/modules/plugin/model"
 )
 
 // PluginManagerReconciler reconciles a PluginManager object
@@ -46,6 +50,7 @@ type PluginManagerReconciler struct {
 	credController *CredentialsController
 	env            bootstrap.Environment
 	cfg            *config.PluginModule
+	eventRecorder  event.Recorder
 
 	mut                  sync.RWMutex
 	secretWatchers       map[types.NamespacedName]map[types.NamespacedName]struct{}
@@ -59,8 +64,10 @@ func NewPluginManagerReconciler(
 	client client.Client,
 	scheme *runtime.Scheme,
 	cfg *config.PluginModule,
+	eventRecorder event.Recorder,
 ) *PluginManagerReconciler {
 	return &PluginManagerReconciler{
+		eventRecorder:        eventRecorder,
 		client:               client,
 		scheme:               scheme,
 		env:                  env,
@@ -81,10 +88,10 @@ func (r *PluginManagerReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	return r.reconcile(ctx, req.NamespacedName)
 }
 
-func (r *PluginManagerReconciler) reconcile(ctx context.Context, nn types.NamespacedName) (ctrl.Result, error) {
+func (r *PluginManagerReconciler) reconcile(ctx context.Context, nn types.NamespacedName) (_ ctrl.Result, err error) {
 	// Fetch the PluginManager instance
 	instance := &pluginv1alpha1.PluginManager{}
-	err := r.client.Get(ctx, nn, instance)
+	err = r.client.Get(ctx, nn, instance)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// TODO del relevant resource
@@ -121,6 +128,10 @@ func (r *PluginManagerReconciler) reconcile(ctx context.Context, nn types.Namesp
 	model.PatchObjectMeta(&ef.ObjectMeta, &instance.ObjectMeta)
 	model.PatchIstioRevLabel(&ef.Labels, istioRev)
 
+	ctx, span := monitoring.StartSpan(ctx, modmodel.ModuleName, "ApplyEnvoyFilter",
+		monitoring.ResourceAttributes(ef.Namespace, ef.Name)...)
+	defer func() { monitoring.EndSpan(span, err) }()
+
 	found := &networkingv1alpha3.EnvoyFilter{}
 	nsName := types.NamespacedName{Name: ef.Name, Namespace: ef.Namespace}
 	err = r.client.Get(ctx, nsName, found)
@@ -135,9 +146,13 @@ func (r *PluginManagerReconciler) reconcile(ctx context.Context, nn types.Namesp
 		err := r.client.Create(ctx, ef)
 		if err != nil {
 			PluginManagerReconcilesFailed.Increment()
+			recordEvent(r.eventRecorder, instance, corev1.EventTypeWarning, "EnvoyFilterCreateFailed",
+				"create envoyfilter %s failed: %v", nsName, err)
 			return reconcile.Result{}, err
 		}
 		EnvoyfilterCreations.With(resourceName.Value("pluginmanager")).Increment()
+		recordEvent(r.eventRecorder, instance, corev1.EventTypeNormal, "PluginTranslated",
+			"translated into new envoyfilter %s", nsName)
 		log.Infof("create a new EnvoyFilter %s/%s", ef.Namespace, ef.Name)
 	} else if foundRev := model.IstioRevFromLabel(found.Labels); !r.env.RevInScope(foundRev) {
 		log.Debugf("existing envoyfilter %v istioRev %s but our %s, skip ...",
@@ -149,9 +164,13 @@ func (r *PluginManagerReconciler) reconcile(ctx context.Context, nn types.Namesp
 		err := r.client.Update(ctx, ef)
 		if err != nil {
 			PluginManagerReconcilesFailed.Increment()
+			recordEvent(r.eventRecorder, instance, corev1.EventTypeWarning, "EnvoyFilterUpdateFailed",
+				"update envoyfilter %s failed: %v", nsName, err)
 			return reconcile.Result{}, err
 		}
 		EnvoyfilterRefreshes.With(resourceName.Value("pluginmanager")).Increment()
+		recordEvent(r.eventRecorder, instance, corev1.EventTypeNormal, "PluginTranslated",
+			"translated into envoyfilter %s", nsName)
 		log.Infof("update EnvoyFilter %s/%s", ef.Namespace, ef.Name)
 	}
 
@@ -166,6 +185,7 @@ func (r *PluginManagerReconciler) translatePluginManagerToEnvoyFilter(
 	envoyFilterWrapper, err := translateOutputToEnvoyFilterWrapper(out)
 	if err != nil {
 		log.Errorf("translateOutputToEnvoyFilterWrapper for envoyfilter %s/%s met err %v", cr.Namespace, cr.Name, err)
+		recordEvent(r.eventRecorder, cr, corev1.EventTypeWarning, "PluginTranslateFailed", "%v", err)
 		return nil
 	}
 	envoyFilterWrapper.Name, envoyFilterWrapper.Namespace = cr.Name, cr.Namespace
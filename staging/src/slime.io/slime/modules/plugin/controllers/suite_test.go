@@ -128,7 +128,7 @@ var _ = BeforeSuite(func() {
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
-	plm := NewPluginManagerReconciler(slimeEnv, k8sManager.GetClient(), k8sManager.GetScheme(), pluginCfg)
+	plm := NewPluginManagerReconciler(slimeEnv, k8sManager.GetClient(), k8sManager.GetScheme(), pluginCfg, nil)
 	err = plm.SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
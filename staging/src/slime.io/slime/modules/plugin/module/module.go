@@ -51,7 +51,7 @@ func (m *Module) Setup(opts module.ModuleOptions) error {
 	mgr := opts.Manager
 
 	var err error
-	pmr := controllers.NewPluginManagerReconciler(env, mgr.GetClient(), mgr.GetScheme(), cfg)
+	pmr := controllers.NewPluginManagerReconciler(env, mgr.GetClient(), mgr.GetScheme(), cfg, opts.EventRecorder)
 	if opts.LeaderElectionCbs != nil {
 		opts.LeaderElectionCbs.AddOnStartedLeading(pmr.OnStartLeading)
 	}
@@ -63,6 +63,8 @@ func (m *Module) Setup(opts module.ModuleOptions) error {
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
 		Cfg:    cfg,
+
+		EventRecorder: opts.EventRecorder,
 	}).SetupWithManager(mgr); err != nil {
 		return fmt.Errorf("unable to create EnvoyPlugin controller, %+v", err)
 	}
@@ -0,0 +1,25 @@
+package module_test
+
+import (
+	"testing"
+
+	networkingv1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+
+	"slime.io/slime/framework/model/module"
+	"slime.io/slime/framework/test/conformance"
+	pluginmodule "slime.io/slime/modules/plugin/module"
+)
+
+func TestConformance(t *testing.T) {
+	h := conformance.New(t, conformance.Options{
+		Modules: []module.Module{&pluginmodule.Module{}},
+		Config:  `{"name": "plugin", "kind": "plugin", "enable": true}`,
+	})
+	efGVK := networkingv1alpha3.SchemeGroupVersion.WithKind("EnvoyFilter")
+
+	h.ApplyFile("testdata/envoyplugin.yaml")
+	h.ExpectGolden(efGVK, "default", "testdata/envoyfilter.golden.yaml")
+
+	h.DeleteFile("testdata/envoyplugin.yaml")
+	h.ExpectGolden(efGVK, "default", "testdata/empty.golden.yaml")
+}